@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// staticPrefix is where fingerprinted assets are served, e.g.
+// /static/app.3fa9c2ab.js for web/app.js
+const staticPrefix = "/static/"
+
+// assetManifestPath serves the logical name → fingerprinted URL map
+const assetManifestPath = "/asset-manifest.json"
+
+// assetHashLen is how many hex characters of a file's SHA-256 go into its
+// fingerprinted name
+const assetHashLen = 8
+
+// Cache-Control for fingerprinted URLs, whose content never changes, and
+// for everything else, which browsers must revalidate
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+// assets fingerprints a frontend directory at startup. Files changed on
+// disk afterwards keep serving under their new content, but their
+// fingerprints and ETags only move on restart.
+type assets struct {
+	hashes   map[string]string // logical name ("components/about.css") → content hash
+	manifest []byte            // JSON of logical name → fingerprinted URL
+	index    []byte            // index.html referencing fingerprinted scripts and stylesheets; nil without one
+}
+
+// loadAssets hashes every file under root that has an extension, skipping
+// dotfiles and precompressed siblings, which are served with their original
+func loadAssets(root string) (*assets, error) {
+	a := &assets{hashes: make(map[string]string)}
+	urls := make(map[string]string)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || path.Ext(d.Name()) == "" || isPrecompressed(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		hash, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		logical := filepath.ToSlash(rel)
+		a.hashes[logical] = hash
+		urls[logical] = fingerprintURL(logical, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fingerprint %s: %w", root, err)
+	}
+
+	if a.manifest, err = json.Marshal(urls); err != nil {
+		return nil, fmt.Errorf("failed to encode asset manifest: %w", err)
+	}
+	index, err := os.ReadFile(filepath.Join(root, "index.html"))
+	if err == nil {
+		a.index = a.rewriteReferences(index)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read index.html: %w", err)
+	}
+	return a, nil
+}
+
+// hashFile returns the truncated hex SHA-256 of a file's content
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:assetHashLen], nil
+}
+
+func isPrecompressed(name string) bool {
+	for _, p := range precompressed {
+		if strings.HasSuffix(name, p.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprintURL puts hash before the extension: "ui/about.js" becomes
+// "/static/ui/about.3fa9c2ab.js"
+func fingerprintURL(logical, hash string) string {
+	ext := path.Ext(logical)
+	return staticPrefix + strings.TrimSuffix(logical, ext) + "." + hash + ext
+}
+
+// rewriteReferences points index.html's absolute script and stylesheet
+// references (src="/app.js", href="/style.css") at their fingerprinted URLs
+func (a *assets) rewriteReferences(index []byte) []byte {
+	var pairs []string
+	for logical, hash := range a.hashes {
+		if ext := path.Ext(logical); ext != ".js" && ext != ".css" {
+			continue
+		}
+		for _, attr := range []string{"src", "href"} {
+			pairs = append(pairs, attr+`="/`+logical+`"`, attr+`="`+fingerprintURL(logical, hash)+`"`)
+		}
+	}
+	return []byte(strings.NewReplacer(pairs...).Replace(string(index)))
+}
+
+// lookup maps a path under staticPrefix to its logical name. A
+// fingerprinted path must carry the file's current hash; an outdated one is
+// not found. A plain path (e.g. a module imported relative to a
+// fingerprinted script) is found unversioned.
+func (a *assets) lookup(rel string) (logical string, fingerprinted, ok bool) {
+	if _, known := a.hashes[rel]; known {
+		return rel, false, true
+	}
+	ext := path.Ext(rel)
+	stem := strings.TrimSuffix(rel, ext)
+	hashExt := path.Ext(stem)
+	if len(hashExt) != assetHashLen+1 || !isHex(hashExt[1:]) {
+		return rel, false, true
+	}
+	logical = strings.TrimSuffix(stem, hashExt) + ext
+	if a.hashes[logical] != hashExt[1:] {
+		return "", false, false
+	}
+	return logical, true, true
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// setCacheHeaders marks a fingerprinted response immutable, and makes any
+// other response revalidate, against the content hash when the file has one
+func (a *assets) setCacheHeaders(w http.ResponseWriter, logical string, fingerprinted bool) {
+	if fingerprinted {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+		return
+	}
+	w.Header().Set("Cache-Control", revalidateCacheControl)
+	if hash, ok := a.hashes[logical]; ok {
+		w.Header().Set("ETag", `"`+hash+`"`)
+	}
+}
+
+// serveBytes serves generated content (the manifest, the rewritten index)
+// under an ETag of its hash, so clients revalidate with If-None-Match
+func serveBytes(w http.ResponseWriter, r *http.Request, name string, content []byte) {
+	sum := sha256.Sum256(content)
+	w.Header().Set("Cache-Control", revalidateCacheControl)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])[:assetHashLen]+`"`)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
@@ -5,27 +5,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
-	"path"
-	"path/filepath"
 	"runtime"
-	"strings"
+	"slices"
 	"syscall"
+	"time"
 
 	"github.com/1mb-dev/driftfm/internal/api"
 	"github.com/1mb-dev/driftfm/internal/audio"
 	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/compress"
 	"github.com/1mb-dev/driftfm/internal/config"
+	"github.com/1mb-dev/driftfm/internal/drain"
+	"github.com/1mb-dev/driftfm/internal/health"
 	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/logfile"
 	"github.com/1mb-dev/driftfm/internal/metrics"
 	"github.com/1mb-dev/driftfm/internal/radio"
+	"github.com/1mb-dev/driftfm/internal/rooms"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+	"github.com/1mb-dev/driftfm/internal/worker"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// version is set at build time via -ldflags "-X main.version=..."
-var version = "dev"
+// version and buildTime are set at build time via
+// -ldflags "-X main.version=... -X main.buildTime=..."
+var (
+	version   = "dev"
+	buildTime = ""
+)
 
 func main() {
 	if err := run(); err != nil {
@@ -50,68 +61,222 @@ func run() error {
 			log.Printf("Error closing repository: %v", err)
 		}
 	}()
+	if err := repo.EnsureSchema(cfg.Database.SchemaPath, cfg.Database.AutoMigrate); err != nil {
+		return fmt.Errorf("failed to initialize database %s: %w", cfg.Database.Path, err)
+	}
+	if err := checkIntegrity(repo, cfg.Database); err != nil {
+		return err
+	}
+	if !cfg.Database.SkipSchemaCheck {
+		if err := repo.VerifySchema(); err != nil {
+			return fmt.Errorf("database %s: %w (or set database.skip_schema_check)", cfg.Database.Path, err)
+		}
+	}
 
 	// Initialize audio resolver
-	audioResolver := audio.NewResolver(cfg.Audio.LocalPath)
+	audioResolver, err := newAudioResolver(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize audio resolver: %w", err)
+	}
 
-	// Initialize cache
-	appCache, err := cache.New()
+	// Initialize cache, namespaced so keys from different builds never mix
+	baseCache, err := newCache(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize cache: %w", err)
 	}
 	defer func() {
-		if err := appCache.Close(); err != nil {
+		if err := baseCache.Close(); err != nil {
 			log.Printf("Error closing cache: %v", err)
 		}
 	}()
+	cacheNamespace := cfg.Cache.Namespace
+	if cacheNamespace == "" {
+		cacheNamespace = version
+	}
+	appCache := baseCache.WithNamespace(cacheNamespace)
+	loadTimeout, err := cfg.GetCacheLoadTimeout()
+	if err != nil {
+		return fmt.Errorf("invalid cache load timeout: %w", err)
+	}
+	appCache.SetLoadTimeout(loadTimeout)
 
-	// Create radio manager and API handler
-	radioMgr := radio.NewManager(repo)
-	handler := api.NewHandler(repo, radioMgr, audioResolver, appCache)
-
-	// Create mux
-	mux := http.NewServeMux()
-
-	// Health check (liveness probe)
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet && r.Method != http.MethodHead {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
+	// Create a radio manager per station and the API handler. A configured
+	// station sees only its audio path and moods of the library and caches
+	// under its own namespace; the default station keeps the unscoped
+	// namespace, as it serves the unscoped routes. Without stations there
+	// is one station over the whole library.
+	repo.SetMinDuration(cfg.Radio.MinDurationSeconds)
+	stationCaches := make(map[string]*cache.Cache)
+	var stations []api.Station
+	for _, id := range stationIDs(cfg) {
+		sc := cfg.Stations[id]
+		stationRepo := repo
+		if id != "" {
+			stationRepo = repo.WithScope(sc.PathPrefix(), sc.Moods)
 		}
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte("ok " + version)); err != nil {
-			log.Printf("Error writing health response: %v", err)
+		stationCache := appCache
+		if id != cfg.DefaultStation {
+			stationCache = appCache.WithNamespace(cacheNamespace + ":station:" + id)
+			stationCaches[id] = stationCache
 		}
-	})
+		radioMgr, err := newRadioManager(cfg, stationRepo, stationCache)
+		if err != nil {
+			return err
+		}
+		stations = append(stations, api.Station{ID: id, Moods: sc.Moods, Repo: stationRepo, Radio: radioMgr, Cache: stationCache})
+	}
+	defaultStation := stations[0]
+	handler := api.NewHandler(defaultStation.Repo, defaultStation.Radio, audioResolver, defaultStation.Cache)
+	handler.SetStation(defaultStation.ID, defaultStation.Moods)
+	handler.SetCacheShuffle(cfg.Radio.ShuffleCached())
+	moodStatsTTL, err := cfg.GetMoodStatsTTL()
+	if err != nil {
+		return fmt.Errorf("invalid mood stats TTL: %w", err)
+	}
+	handler.SetMoodStatsTTL(moodStatsTTL)
+	handler.SetMaxPlaylistSize(cfg.Radio.MaxPlaylistSize)
+	handler.SetPlaylistLyrics(cfg.Radio.PlaylistLyrics, cfg.Radio.LyricsPreviewLength)
+	handler.SetFallbackMoods(cfg.Radio.FallbackMoods)
+	handler.SetBodyLimits(api.BodyLimits(cfg.Server.MaxBody))
+	if err := handler.SetAdminTokens(cfg.Admin.Tokens); err != nil {
+		return fmt.Errorf("invalid admin tokens: %w", err)
+	}
+	if len(cfg.Admin.Tokens) == 0 {
+		log.Printf("Admin API disabled: no admin.tokens configured")
+	}
+	handler.SetBuildInfo(version, buildTime)
+	handler.SetDisplayNames(cfg.Moods.DisplayNames)
+	moodDisplay := make(map[string]api.MoodDisplay, len(cfg.Moods.Display))
+	for mood, d := range cfg.Moods.Display {
+		moodDisplay[mood] = api.MoodDisplay(d)
+	}
+	handler.SetMoodDisplay(moodDisplay)
 
-	// Readiness check (verifies database connectivity)
-	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet && r.Method != http.MethodHead {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
+	// Optionally sign local audio URLs so tracks can't be hotlinked
+	var audioSigner *audio.Signer
+	if cfg.Audio.SigningKey != "" {
+		signedTTL, err := cfg.GetSignedURLTTL()
+		if err != nil {
+			return fmt.Errorf("invalid signed URL TTL: %w", err)
 		}
-		if err := repo.Ping(); err != nil {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			if _, err := w.Write([]byte("not ready")); err != nil {
-				log.Printf("Error writing ready response: %v", err)
+		audioSigner = audio.NewSigner([]byte(cfg.Audio.SigningKey), "/audio")
+		handler.SetURLSigner(audioSigner, signedTTL)
+	}
+
+	sessionGap, err := cfg.GetSessionGap()
+	if err != nil {
+		return fmt.Errorf("invalid analytics session gap: %w", err)
+	}
+	handler.SetSessionGap(sessionGap)
+	handler.SetMaxPosition(cfg.Analytics.MaxPosition)
+	maxTrackDuration, err := cfg.GetMaxTrackDuration()
+	if err != nil {
+		return fmt.Errorf("invalid analytics max track duration: %w", err)
+	}
+	handler.SetMaxTrackDuration(maxTrackDuration)
+	handler.SetPublicStats(cfg.GetPublicStats())
+	handler.SetAudioRoot(cfg.Audio.LocalPath)
+	handler.SetPrefetchHint(cfg.Audio.PrefetchHint)
+
+	dedupWindow, err := cfg.GetListenDedupWindow()
+	if err != nil {
+		return fmt.Errorf("invalid listen dedup window: %w", err)
+	}
+	handler.SetPlayDedup(dedupWindow, cfg.Listen.DedupMaxEntries)
+
+	writeDeadline, err := cfg.GetWriteDeadline()
+	if err != nil {
+		return fmt.Errorf("invalid database write deadline: %w", err)
+	}
+	if err := repo.SetWriteDeadline(writeDeadline); err != nil {
+		return err
+	}
+	handler.SetWriteDeadline(writeDeadline)
+
+	// Optionally move listen event writes off the request path
+	var eventQueue *inventory.EventQueue
+	if cfg.Listen.Async {
+		flushInterval, err := cfg.GetListenFlushInterval()
+		if err != nil {
+			return fmt.Errorf("invalid listen flush interval: %w", err)
+		}
+		eventQueue = inventory.NewEventQueue(repo, inventory.QueueOptions{
+			Size:          cfg.Listen.QueueSize,
+			BatchSize:     cfg.Listen.BatchSize,
+			FlushInterval: flushInterval,
+			Block:         cfg.Listen.OnFull == "block",
+			WriteDeadline: writeDeadline,
+		})
+		defer func() {
+			if err := eventQueue.Close(); err != nil {
+				log.Printf("Error closing listen queue: %v", err)
 			}
-			return
+		}()
+		handler.SetEventQueue(eventQueue)
+	}
+
+	// Post-play side effects run on a worker pool, drained after the server
+	// stops taking requests
+	workers := worker.New(worker.Options{
+		Workers:   cfg.Workers.Size,
+		QueueSize: cfg.Workers.QueueSize,
+	})
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := workers.Close(ctx); err != nil {
+			log.Printf("Warning: worker pool did not drain: %v", err)
 		}
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte("ready")); err != nil {
-			log.Printf("Error writing ready response: %v", err)
+	}()
+	handler.SetTasks(workers)
+
+	// Optionally host listen-together rooms
+	var roomHub *rooms.Hub
+	if cfg.Rooms.Enabled {
+		emptyTTL, err := cfg.GetRoomsEmptyTTL()
+		if err != nil {
+			return fmt.Errorf("invalid rooms empty TTL: %w", err)
 		}
-	})
+		roomHub = rooms.NewHub(rooms.Options{
+			MaxRooms:   cfg.Rooms.MaxRooms,
+			MaxMembers: cfg.Rooms.MaxMembers,
+			EmptyTTL:   emptyTTL,
+			SendBuffer: 16,
+		})
+		defer roomHub.Close()
+		handler.SetRooms(roomHub)
+	}
+
+	// Create mux
+	mux := http.NewServeMux()
 
-	// Metrics endpoint (runtime + application stats) — localhost only
+	// Turns away new API/audio requests during shutdown while audio transfers finish
+	drainer := drain.New()
+
+	// Component checks: the database gates readiness; the rest only show up
+	// in the verbose health report
+	checks := health.NewRegistry(version)
+	registerHealthChecks(checks, repo, appCache, cfg.Audio.LocalPath, eventQueue)
+
+	// Health check (liveness probe; ?verbose=1 for per-component JSON)
+	mux.HandleFunc("/health", checks.LivenessHandler())
+
+	// Readiness check (verifies database connectivity; fails while draining so
+	// the load balancer pulls the instance)
+	mux.HandleFunc("/ready", checks.ReadinessHandler(drainer.Draining))
+
+	// Metrics endpoint (runtime + application stats) — restricted to metrics.allowed_cidrs
+	metricsCIDRs, err := cfg.GetMetricsAllowedCIDRs()
+	if err != nil {
+		return fmt.Errorf("invalid metrics allowed CIDRs: %w", err)
+	}
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodHead {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		host, _, _ := net.SplitHostPort(r.RemoteAddr)
-		if host != "127.0.0.1" && host != "::1" {
+		if !metrics.AllowedClient(r, metricsCIDRs) {
 			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
 		}
@@ -131,6 +296,20 @@ func run() error {
 			"app":   metrics.Get().Snapshot(),
 			"cache": appCache.Stats(),
 		}
+		if len(stationCaches) > 0 {
+			stats := make(map[string]any, len(stationCaches))
+			for id, c := range stationCaches {
+				stats[id] = c.Stats()
+			}
+			output["station_caches"] = stats
+		}
+		if eventQueue != nil {
+			output["listen_queue"] = eventQueue.Stats()
+		}
+		output["workers"] = workers.Stats()
+		if roomHub != nil {
+			output["rooms"] = roomHub.Stats()
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(output); err != nil {
@@ -138,32 +317,26 @@ func run() error {
 		}
 	})
 
-	// Register API routes
+	// Register API routes; every station also serves its listener routes
+	// under /api/stations/{id}/
+	if len(cfg.Stations) > 0 {
+		stationRouter := api.NewStationRouter()
+		for _, st := range stations {
+			stationRouter.Add(st.ID, handler.ForStation(st))
+		}
+		mux.Handle("/api/stations/", stationRouter)
+	}
 	handler.RegisterRoutes(mux)
 
 	// Serve static files from web/
-	webFS := http.FileServer(http.Dir("web"))
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// Root path and paths with file extensions: serve normally via FileServer
-		if r.URL.Path == "/" || path.Ext(r.URL.Path) != "" {
-			webFS.ServeHTTP(w, r)
-			return
-		}
-		// Extensionless paths: check if file exists on disk, else 404
-		cleanPath := filepath.Clean(filepath.Join("web", filepath.FromSlash(r.URL.Path)))
-		// Prevent path traversal: ensure resolved path stays under web/
-		if strings.HasPrefix(cleanPath, "web"+string(filepath.Separator)) {
-			if _, err := os.Stat(cleanPath); err == nil {
-				webFS.ServeHTTP(w, r)
-				return
-			}
-		}
-		http.NotFound(w, r)
-	})
+	mux.Handle("/", staticHandler("web", cfg.Server.SPAFallback))
 
 	// Serve audio files from local directory
-	audioFS := http.FileServer(http.Dir(cfg.Audio.LocalPath))
-	mux.Handle("/audio/", http.StripPrefix("/audio/", audioFS))
+	var audioFS http.Handler = audio.Throttle(cfg.Audio.ThrottleBPS, http.FileServer(http.Dir(cfg.Audio.LocalPath)))
+	if audioSigner != nil {
+		audioFS = audioSigner.RequireToken(audioFS)
+	}
+	mux.Handle("/audio/", http.StripPrefix("/audio/", audio.RejectUnsafePaths(audioFS)))
 
 	// Get parsed timeouts (validated during config.Load, errors should not occur)
 	readTimeout, err := cfg.GetReadTimeout()
@@ -178,11 +351,62 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("invalid shutdown timeout: %w", err)
 	}
+	drainTimeout, err := cfg.GetDrainTimeout()
+	if err != nil {
+		return fmt.Errorf("invalid drain timeout: %w", err)
+	}
+
+	logFilter := metrics.NewLogFilter(cfg.Logging.SkipPaths, cfg.Logging.SkipExtensions, cfg.Logging.SampleRate)
+	if cfg.Logging.AccessLogFile != "" {
+		accessLog, err := logfile.Open(cfg.Logging.AccessLogFile, logfile.Options{
+			MaxSize:    int64(cfg.Logging.AccessLogMaxSizeMB) << 20,
+			MaxBackups: cfg.Logging.AccessLogMaxBackups,
+			Compress:   cfg.Logging.AccessLogCompress,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to open access log: %w", err)
+		}
+		defer func() {
+			if err := accessLog.Close(); err != nil {
+				log.Printf("Error closing access log: %v", err)
+			}
+		}()
+		logFilter.SetOutput(accessLog)
+		reopenOnHangup(accessLog)
+		log.Printf("Access log: %s", cfg.Logging.AccessLogFile)
+	}
+
+	compressor, err := compress.New(cfg.GetCompressionEncodings(), cfg.Server.Compression.MinSize)
+	if err != nil {
+		return fmt.Errorf("invalid compression config: %w", err)
+	}
+
+	// Optional tracing; when disabled no tracing middleware is installed
+	var tracerProvider trace.TracerProvider
+	if cfg.OTel.Enabled {
+		tp, err := tracing.New(context.Background(), tracing.Options{
+			Endpoint:       cfg.OTel.Endpoint,
+			SampleRate:     cfg.OTel.SampleRate,
+			ServiceVersion: version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize tracing: %w", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tp.Shutdown(ctx); err != nil {
+				log.Printf("Error flushing traces: %v", err)
+			}
+		}()
+		tracerProvider = tp
+		log.Printf("Tracing enabled: exporting to %s (sample rate %g)", cfg.OTel.Endpoint, cfg.OTel.SampleRate)
+	}
 
 	// Create server with production timeouts
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:           securityHeaders(metrics.Middleware(mux)),
+		Handler:           securityHeaders(tracing.Middleware(tracerProvider, logFilter.Middleware(drainer.Middleware(compressor.Middleware(mux))))),
 		ReadTimeout:       readTimeout,
 		ReadHeaderTimeout: readTimeout / 3,
 		WriteTimeout:      writeTimeout * 4, // Long for potential audio streaming
@@ -190,6 +414,11 @@ func run() error {
 		MaxHeaderBytes:    1 << 20, // 1 MB
 	}
 
+	// Hijacked WebSocket connections aren't tracked by Shutdown; close them explicitly
+	if roomHub != nil {
+		server.RegisterOnShutdown(roomHub.Close)
+	}
+
 	// Start server in goroutine
 	serverErr := make(chan error, 1)
 	go func() {
@@ -217,18 +446,170 @@ func run() error {
 
 	log.Println("Shutting down server...")
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	// Stop new API/audio requests, then let in-flight audio transfers finish
+	// within drain_timeout before the server itself shuts down
+	deadline := time.Now().Add(drainTimeout)
+	drainer.Start()
+	if drainTimeout > 0 {
+		if n := drainer.Active(); n > 0 {
+			log.Printf("Draining %d audio transfer(s) for up to %s...", n, drainTimeout)
+		}
+		drainCtx, cancelDrain := context.WithDeadline(context.Background(), deadline)
+		if err := drainer.Wait(drainCtx); err != nil {
+			log.Printf("Warning: drain timed out with %d audio transfer(s) in flight", drainer.Active())
+		}
+		cancelDrain()
+	}
+
+	// Graceful shutdown gets what remains of the drain budget, but never less
+	// than shutdown_timeout
+	if remaining := time.Until(deadline); remaining < shutdownTimeout {
+		deadline = time.Now().Add(shutdownTimeout)
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Background admin jobs stop before the database closes
+	if err := handler.StopJobs(ctx); err != nil {
+		log.Printf("Warning: background jobs did not stop: %v", err)
+	}
+
 	log.Println("Server stopped")
 	return nil
 }
 
+// reopenOnHangup reopens the access log on each SIGHUP, so it follows the
+// file after logrotate moves it
+func reopenOnHangup(f *logfile.RotatingFile) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := f.Reopen(); err != nil {
+				log.Printf("Error reopening access log: %v", err)
+			}
+		}
+	}()
+}
+
+// checkIntegrity runs the configured startup integrity check, failing
+// startup if the database is corrupt
+func checkIntegrity(repo *inventory.Repository, cfg config.DatabaseConfig) error {
+	if cfg.IntegrityCheck == inventory.IntegrityOff {
+		return nil
+	}
+	report, err := repo.CheckIntegrity(context.Background(), cfg.IntegrityCheck)
+	if err != nil {
+		return err
+	}
+	if !report.OK {
+		return fmt.Errorf("%w: %s failed the %s integrity check with %d problem(s); restore it from a backup or set database.integrity_check: off to start anyway",
+			inventory.ErrCorrupt, cfg.Path, report.Mode, len(report.Problems))
+	}
+	log.Printf("Database integrity check (%s) passed in %dms", report.Mode, report.DurationMs)
+	return nil
+}
+
+// newAudioResolver builds the audio resolver from config. Without providers,
+// files resolve under local_path with no existence check. Files on local
+// disk get a content version in their URL, so re-mastering one under the
+// same path reaches listeners.
+func newAudioResolver(cfg *config.Config) (audio.Resolver, error) {
+	ttl, err := cfg.GetExistsCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Audio.Providers) == 0 {
+		return audio.NewVersionedResolver(audio.NewResolver(cfg.Audio.LocalPath), ttl, audio.LocalVersion(cfg.Audio.LocalPath)), nil
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	providers := make([]audio.Provider, 0, len(cfg.Audio.Providers))
+	for _, p := range cfg.Audio.Providers {
+		switch p.Type {
+		case "local":
+			providers = append(providers, audio.Provider{
+				Name:     "local",
+				Resolver: audio.NewResolver(cfg.Audio.LocalPath),
+				Exists:   audio.LocalExists(cfg.Audio.LocalPath),
+			})
+		case "remote":
+			provider := audio.Provider{Name: p.URL, Resolver: audio.NewRemoteResolver(p.URL)}
+			if p.Check == "head" {
+				provider.Exists = audio.HTTPExists(client, p.URL)
+			}
+			providers = append(providers, provider)
+		}
+	}
+	chain := audio.NewChainResolver(ttl, providers...)
+	return audio.NewVersionedResolver(chain, ttl, audio.LocalVersion(cfg.Audio.LocalPath)), nil
+}
+
+// newCache builds the cache on the configured backend. An unreachable Redis
+// is logged, not fatal: the store misses until it comes back.
+func newCache(cfg *config.Config) (*cache.Cache, error) {
+	if cfg.Cache.Backend != "redis" {
+		store := cache.NewMemoryStore()
+		store.SetEvictBatch(cfg.Cache.CleanupBatchSize)
+		return cache.NewWithStore(store), nil
+	}
+
+	timeout, err := cfg.GetCacheRedisTimeout()
+	if err != nil {
+		return nil, err
+	}
+	store := cache.NewRedisStore(cache.RedisOptions{
+		Addr:     cfg.Cache.Redis.Addr,
+		Password: cfg.Cache.Redis.Password,
+		DB:       cfg.Cache.Redis.DB,
+		Timeout:  timeout,
+	})
+	if err := store.Ping(); err == nil {
+		log.Printf("Cache: redis at %s", cfg.Cache.Redis.Addr)
+	}
+	return cache.NewWithStore(store), nil
+}
+
+// registerHealthChecks registers the server's components with the health
+// registry. Only the database is required for readiness.
+func registerHealthChecks(reg *health.Registry, repo *inventory.Repository, appCache *cache.Cache, audioPath string, eventQueue *inventory.EventQueue) {
+	reg.Register("database", true, func(context.Context) (map[string]any, error) {
+		return nil, repo.Ping()
+	})
+	reg.Register("cache", false, func(context.Context) (map[string]any, error) {
+		n, err := appCache.KeyCount()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"key_count": n}, nil
+	})
+	reg.Register("audio", false, func(context.Context) (map[string]any, error) {
+		details := map[string]any{"path": audioPath}
+		info, err := os.Stat(audioPath)
+		if err != nil {
+			return details, err
+		}
+		if !info.IsDir() {
+			return details, fmt.Errorf("%s is not a directory", audioPath)
+		}
+		return details, nil
+	})
+	// Last run of each background job; nil until it first runs
+	if eventQueue != nil {
+		reg.Register("jobs", false, func(context.Context) (map[string]any, error) {
+			var flushed *time.Time
+			if t := eventQueue.LastFlush(); !t.IsZero() {
+				flushed = &t
+			}
+			return map[string]any{"listen_flush": flushed}, nil
+		})
+	}
+}
+
 // securityHeaders adds standard security headers to all responses.
 func securityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -238,3 +619,56 @@ func securityHeaders(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// stationIDs lists the configured stations, the default first and the rest
+// sorted, or the one unscoped station "" without stations
+func stationIDs(cfg *config.Config) []string {
+	if len(cfg.Stations) == 0 {
+		return []string{""}
+	}
+	ids := []string{cfg.DefaultStation}
+	for _, id := range slices.Sorted(maps.Keys(cfg.Stations)) {
+		if id != cfg.DefaultStation {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// newRadioManager creates a radio manager over repo with the configured
+// playlist settings, keeping track sets in c unless every request shuffles
+func newRadioManager(cfg *config.Config, repo *inventory.Repository, c *cache.Cache) (*radio.Manager, error) {
+	radioMgr := radio.NewManager(repo)
+	radioMgr.SetBorrowing(cfg.Radio.MinPlaylistLength, cfg.Radio.FallbackMoods)
+	radioMgr.SetPlaylistSize(cfg.Radio.DefaultPlaylistSize, cfg.Radio.MaxPlaylistSize)
+	radioMgr.SetRules(radioRules(cfg.Radio.Rules))
+	radioMgr.SetMaxCached(cfg.Radio.MaxCached)
+	radioMgr.SetHeadMemory(cfg.Radio.HeadMemory)
+	recentWindow, err := cfg.GetRecentWindow()
+	if err != nil {
+		return nil, fmt.Errorf("invalid radio recent window: %w", err)
+	}
+	radioMgr.SetRecentWindow(recentWindow)
+	radioMgr.SetRecencyWeight(cfg.Radio.RecencyWeighting())
+	if !cfg.Radio.ShuffleCached() {
+		radioMgr.SetTrackCache(api.NewTrackCache(c))
+	}
+	return radioMgr, nil
+}
+
+// radioRules converts the configured per-mood rules for the radio manager
+func radioRules(cfg map[string]config.RadioRulesConfig) map[string]*radio.Rules {
+	rules := make(map[string]*radio.Rules, len(cfg))
+	for mood, rc := range cfg {
+		caps := make(map[string]radio.RatioCap, len(rc.RatioCaps))
+		for energy, c := range rc.RatioCaps {
+			caps[energy] = radio.RatioCap{Max: c.Max, Window: c.Window}
+		}
+		rules[mood] = &radio.Rules{
+			AllowedEnergies: rc.AllowedEnergies,
+			MaxConsecutive:  rc.MaxConsecutive,
+			RatioCaps:       caps,
+		}
+	}
+	return rules
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/compress"
+)
+
+// precompressed lists the sibling suffix for each encoding a file may be
+// shipped in, in server preference order
+var precompressed = []struct{ encoding, suffix string }{
+	{compress.Brotli, ".br"},
+	{compress.Gzip, ".gz"},
+}
+
+// staticHandler serves the frontend from dir. The root and paths with a file
+// extension go straight to the file server, unless the file has precompressed
+// siblings (see servePrecompressed). Extensionless paths are served only if
+// they exist under dir; otherwise they get 404, or dir/index.html when
+// spaFallback is set so client-side routes load the app.
+//
+// Files are fingerprinted at startup (see assets): /static/ serves them
+// under hashed names with a year-long immutable Cache-Control, the index
+// page references its scripts and stylesheets that way, and
+// /asset-manifest.json lists every hashed URL. Other paths revalidate.
+func staticHandler(dir string, spaFallback bool) http.Handler {
+	fs := http.FileServer(http.Dir(dir))
+	root := filepath.Clean(dir)
+	index := filepath.Join(root, "index.html")
+
+	fingerprints, err := loadAssets(root)
+	if err != nil {
+		log.Printf("Warning: serving static files without fingerprints: %v", err)
+	}
+	serveIndex := func(w http.ResponseWriter, r *http.Request) {
+		if fingerprints != nil && fingerprints.index != nil {
+			serveBytes(w, r, "index.html", fingerprints.index)
+			return
+		}
+		http.ServeFile(w, r, index)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fingerprints != nil {
+			switch {
+			case r.URL.Path == assetManifestPath:
+				serveBytes(w, r, "asset-manifest.json", fingerprints.manifest)
+				return
+			case strings.HasPrefix(r.URL.Path, staticPrefix):
+				logical, hashed, ok := fingerprints.lookup(strings.TrimPrefix(r.URL.Path, staticPrefix))
+				if !ok || path.Ext(logical) == "" {
+					http.NotFound(w, r)
+					return
+				}
+				fingerprints.setCacheHeaders(w, logical, hashed)
+				r = r.Clone(r.Context())
+				r.URL.Path, r.URL.RawPath = "/"+logical, ""
+			case path.Ext(r.URL.Path) != "":
+				fingerprints.setCacheHeaders(w, strings.TrimPrefix(r.URL.Path, "/"), false)
+			}
+		}
+
+		if r.URL.Path == "/" {
+			if fingerprints != nil && fingerprints.index != nil {
+				serveIndex(w, r)
+				return
+			}
+			fs.ServeHTTP(w, r)
+			return
+		}
+
+		// Prevent path traversal: the resolved path must stay under dir
+		cleanPath := filepath.Clean(filepath.Join(root, filepath.FromSlash(r.URL.Path)))
+		if !strings.HasPrefix(cleanPath, root+string(filepath.Separator)) {
+			http.NotFound(w, r)
+			return
+		}
+
+		if path.Ext(r.URL.Path) != "" {
+			if !servePrecompressed(w, r, cleanPath) {
+				fs.ServeHTTP(w, r)
+			}
+			return
+		}
+
+		if _, err := os.Stat(cleanPath); err == nil {
+			fs.ServeHTTP(w, r)
+			return
+		}
+		if spaFallback {
+			serveIndex(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// servePrecompressed serves file, or the .br/.gz sibling the client prefers,
+// when the file has any precompressed siblings. The response keeps the
+// original file's Content-Type, varies by Accept-Encoding, and carries an
+// ETag per encoding so caches never mix variants. It reports false, having
+// written nothing, when file or all of its siblings are missing.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, file string) bool {
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	var offered []string
+	siblings := make(map[string]os.FileInfo)
+	for _, p := range precompressed {
+		if si, err := os.Stat(file + p.suffix); err == nil && si.Mode().IsRegular() {
+			offered = append(offered, p.encoding)
+			siblings[p.encoding] = si
+		}
+	}
+	if len(offered) == 0 {
+		return false
+	}
+
+	name := file
+	encoding := compress.Negotiate(r.Header.Get("Accept-Encoding"), offered)
+	if si, ok := siblings[encoding]; ok {
+		name, info = filepath.Join(filepath.Dir(file), si.Name()), si
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+
+	h := w.Header()
+	compress.AddVary(h)
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	etag := fmt.Sprintf(`"%x-%x`, info.ModTime().UnixNano(), info.Size())
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+		etag += "-" + encoding
+	}
+	h.Set("ETag", etag+`"`)
+
+	http.ServeContent(w, r, file, info.ModTime(), f)
+	return true
+}
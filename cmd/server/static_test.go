@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupWebDir creates a minimal frontend tree for the static handler
+func setupWebDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":       "<html>app</html>",
+		"app.js":           "console.log('app')",
+		"about/index.html": "<html>about</html>",
+	}
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestStaticHandler(t *testing.T) {
+	dir := setupWebDir(t)
+
+	tests := []struct {
+		name       string
+		spa        bool
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"root", false, "/", http.StatusOK, "app"},
+		{"existing file", false, "/app.js", http.StatusOK, "console.log"},
+		{"existing directory", false, "/about/", http.StatusOK, "about"},
+		{"missing route, spa off", false, "/moods/focus", http.StatusNotFound, ""},
+		{"missing route, spa on", true, "/moods/focus", http.StatusOK, "app"},
+		{"existing file, spa on", true, "/app.js", http.StatusOK, "console.log"},
+		{"missing asset, spa on", true, "/missing.js", http.StatusNotFound, ""},
+		{"traversal, spa on", true, "/../../etc/passwd", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path // bypass client-side cleaning
+			w := httptest.NewRecorder()
+			staticHandler(dir, tt.spa).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestStaticHandler_Precompressed(t *testing.T) {
+	dir := setupWebDir(t)
+	files := map[string]string{
+		"bundle.js":     "plain bundle",
+		"bundle.js.br":  "brotli bundle",
+		"bundle.js.gz":  "gzip bundle",
+		"style.css":     "plain style",
+		"style.css.gz":  "gzip style",
+		"orphan.js.br":  "brotli without a plain file",
+		"plain-only.js": "no siblings",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	jsType, cssType := mime.TypeByExtension(".js"), mime.TypeByExtension(".css")
+	tests := []struct {
+		name         string
+		path         string
+		accept       string
+		wantStatus   int
+		wantBody     string
+		wantEncoding string
+		wantType     string
+		wantVary     bool
+	}{
+		{"brotli preferred", "/bundle.js", "gzip, deflate, br", http.StatusOK, "brotli bundle", "br", jsType, true},
+		{"gzip weighted higher", "/bundle.js", "br;q=0.5, gzip", http.StatusOK, "gzip bundle", "gzip", jsType, true},
+		{"no accepted sibling", "/bundle.js", "deflate", http.StatusOK, "plain bundle", "", jsType, true},
+		{"no accept-encoding", "/bundle.js", "", http.StatusOK, "plain bundle", "", jsType, true},
+		{"only gzip sibling", "/style.css", "br, gzip", http.StatusOK, "gzip style", "gzip", cssType, true},
+		{"no siblings", "/plain-only.js", "br, gzip", http.StatusOK, "no siblings", "", jsType, false},
+		{"sibling without plain file", "/orphan.js", "br", http.StatusNotFound, "", "", "", false},
+	}
+
+	handler := staticHandler(dir, false)
+	etags := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := w.Header().Values("Vary"); tt.wantVary != (len(got) == 1 && got[0] == "Accept-Encoding") {
+				t.Errorf("Vary = %q, want Accept-Encoding: %v", got, tt.wantVary)
+			}
+
+			// Each variant of a file gets its own ETag
+			if tt.wantVary {
+				etag := w.Header().Get("ETag")
+				if etag == "" {
+					t.Fatal("missing ETag")
+				}
+				key := tt.path + " " + tt.wantEncoding
+				for other, otherTag := range etags {
+					if otherTag == etag && other != key {
+						t.Errorf("ETag %s shared by %q and %q", etag, key, other)
+					}
+				}
+				etags[key] = etag
+			}
+		})
+	}
+}
+
+func TestStaticHandler_PrecompressedConditional(t *testing.T) {
+	dir := setupWebDir(t)
+	for name, body := range map[string]string{"app.js.br": "brotli app", "app.js.gz": "gzip app"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	handler := staticHandler(dir, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+
+	// The brotli ETag revalidates the brotli variant...
+	req = httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("matching If-None-Match status = %d, want 304", w.Code)
+	}
+
+	// ...but not the gzip one
+	req = httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "gzip app" {
+		t.Errorf("gzip with brotli ETag: status %d body %q, want 200 gzip app", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticHandler_Fingerprints(t *testing.T) {
+	dir := setupWebDir(t)
+	files := map[string]string{
+		"index.html":        `<script type="module" src="/app.js"></script><link rel="stylesheet" href="/ui/theme.css"><link rel="icon" href="/icon.png">`,
+		"ui/theme.css":      "body{}",
+		"icon.png":          "png",
+		"app.js.gz":         "gzipped",
+		".hidden/secret.js": "secret",
+	}
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := staticHandler(dir, false)
+	get := func(target string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/asset-manifest.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("manifest status = %d, want 200", w.Code)
+	}
+	var manifest map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	appHash, _ := hashFile(filepath.Join(dir, "app.js"))
+	want := map[string]string{
+		"app.js":           "/static/app." + appHash + ".js",
+		"index.html":       "",
+		"about/index.html": "",
+		"ui/theme.css":     "",
+		"icon.png":         "",
+	}
+	for name, url := range want {
+		if manifest[name] == "" || (url != "" && manifest[name] != url) {
+			t.Errorf("manifest[%q] = %q, want %q", name, manifest[name], url)
+		}
+	}
+	for _, name := range []string{"app.js.gz", ".hidden/secret.js"} {
+		if _, ok := manifest[name]; ok {
+			t.Errorf("manifest lists %s", name)
+		}
+	}
+
+	t.Run("fingerprinted", func(t *testing.T) {
+		w := get(manifest["app.js"])
+		if w.Code != http.StatusOK || w.Body.String() != "console.log('app')" {
+			t.Fatalf("GET %s = %d %q", manifest["app.js"], w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Cache-Control"); got != immutableCacheControl {
+			t.Errorf("Cache-Control = %q, want %q", got, immutableCacheControl)
+		}
+		// Precompressed siblings still apply under the hashed name
+		w = get(manifest["app.js"], "Accept-Encoding", "gzip")
+		if w.Body.String() != "gzipped" || w.Header().Get("Content-Encoding") != "gzip" {
+			t.Errorf("gzip request = %q with Content-Encoding %q", w.Body.String(), w.Header().Get("Content-Encoding"))
+		}
+	})
+
+	t.Run("stale hash", func(t *testing.T) {
+		for _, target := range []string{"/static/app.00000000.js", "/static/ui/theme.0badc0de.css", "/static/missing.0badc0de.js"} {
+			if w := get(target); w.Code != http.StatusNotFound {
+				t.Errorf("GET %s = %d, want 404", target, w.Code)
+			}
+		}
+	})
+
+	t.Run("unhashed revalidates", func(t *testing.T) {
+		for _, target := range []string{"/app.js", "/static/ui/theme.css"} {
+			w := get(target)
+			if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-cache" {
+				t.Fatalf("GET %s = %d, Cache-Control %q", target, w.Code, w.Header().Get("Cache-Control"))
+			}
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				t.Fatalf("GET %s has no ETag", target)
+			}
+			if w := get(target, "If-None-Match", etag); w.Code != http.StatusNotModified {
+				t.Errorf("conditional GET %s = %d, want 304", target, w.Code)
+			}
+		}
+	})
+
+	t.Run("index references", func(t *testing.T) {
+		w := get("/")
+		body := w.Body.String()
+		for _, ref := range []string{`src="` + manifest["app.js"] + `"`, `href="` + manifest["ui/theme.css"] + `"`, `href="/icon.png"`} {
+			if !strings.Contains(body, ref) {
+				t.Errorf("index = %q, want it to contain %s", body, ref)
+			}
+		}
+		if w := get("/", "If-None-Match", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+			t.Errorf("conditional index = %d, want 304", w.Code)
+		}
+	})
+}
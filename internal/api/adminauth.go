@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+// adminTokenHashPrefix marks a configured admin token given as the hex
+// SHA-256 digest of the token rather than the token itself
+const adminTokenHashPrefix = "sha256:"
+
+// adminChallenge is the WWW-Authenticate value for a missing admin token
+const adminChallenge = `Bearer realm="driftfm admin"`
+
+// adminToken is an accepted admin token's digest and the name recorded as
+// the actor of changes made with it
+type adminToken struct {
+	name   string
+	digest [sha256.Size]byte
+}
+
+// adminNameKey is the request context key of the authenticated admin's name
+type adminNameKey struct{}
+
+// SetAdminTokens enables the /api/admin/ routes for requests carrying one of
+// tokens, keyed by name, as a bearer token. A value "sha256:<hex>" is the
+// digest of a token, so the config need not hold the secret. With no tokens
+// every admin route answers 404.
+func (h *Handler) SetAdminTokens(tokens map[string]string) error {
+	accepted := make([]adminToken, 0, len(tokens))
+	for _, name := range slices.Sorted(maps.Keys(tokens)) {
+		token := tokens[name]
+		if name == "" {
+			return fmt.Errorf("admin token without a name")
+		}
+		hexDigest, hashed := strings.CutPrefix(token, adminTokenHashPrefix)
+		if !hashed {
+			accepted = append(accepted, adminToken{name: name, digest: sha256.Sum256([]byte(token))})
+			continue
+		}
+		raw, err := hex.DecodeString(hexDigest)
+		if err != nil || len(raw) != sha256.Size {
+			return fmt.Errorf("admin token %q: invalid sha256 digest", name)
+		}
+		accepted = append(accepted, adminToken{name: name, digest: [sha256.Size]byte(raw)})
+	}
+	h.adminTokens = accepted
+	if h.adminSessionKey == nil {
+		h.adminSessionKey = newAdminSessionKey()
+	}
+	return nil
+}
+
+// admin wraps an /api/admin/ handler with token auth and the admin body limit
+func (h *Handler) admin(next http.HandlerFunc) http.HandlerFunc {
+	return h.requireAdmin(h.limitBody(bodyAdmin, next))
+}
+
+// requireAdmin rejects requests without a valid admin bearer token or
+// session cookie with 401, or with 404 when no tokens are configured so the
+// admin API isn't exposed. Session requests that can change state must also
+// pass checkCSRF (403 otherwise); bearer requests can't be forged by another
+// site, so they are exempt. The name of the token, or of the token that
+// started the session, goes in the request context for adminActor.
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(h.adminTokens) == 0 {
+			writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			if s, ok := h.adminSessionFrom(r); ok {
+				if !h.checkCSRF(r, s) {
+					metrics.Get().RecordAdminAuthFailure()
+					writeError(w, r, http.StatusForbidden, CodeCSRF, "Missing or invalid CSRF token")
+					return
+				}
+				next(w, r.WithContext(context.WithValue(r.Context(), adminNameKey{}, s.name)))
+				return
+			}
+
+			metrics.Get().RecordAdminAuthFailure()
+			w.Header().Set("WWW-Authenticate", adminChallenge)
+			writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Bearer token required")
+			return
+		}
+		name, ok := h.validAdminToken(token)
+		if !ok {
+			metrics.Get().RecordAdminAuthFailure()
+			w.Header().Set("WWW-Authenticate", adminChallenge+`, error="invalid_token"`)
+			writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid bearer token")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), adminNameKey{}, name)))
+	}
+}
+
+// notFound answers admin paths with no handler, once the caller is authorized
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+}
+
+// validAdminToken returns the name of the configured token matching token.
+// It compares against every digest in constant time, so neither the match
+// nor its position leaks.
+func (h *Handler) validAdminToken(token string) (string, bool) {
+	digest := sha256.Sum256([]byte(token))
+	match := -1
+	for i, want := range h.adminTokens {
+		match = subtle.ConstantTimeSelect(subtle.ConstantTimeCompare(digest[:], want.digest[:]), i, match)
+	}
+	if match < 0 {
+		return "", false
+	}
+	return h.adminTokens[match].name, true
+}
+
+// adminName returns the authenticated admin's name set by requireAdmin
+func adminName(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(adminNameKey{}).(string)
+	return name, ok && name != ""
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
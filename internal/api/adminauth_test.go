@@ -0,0 +1,126 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+func TestAdminAuth(t *testing.T) {
+	hashed := "second-admin-token-abcdef"
+	digest := sha256.Sum256([]byte(hashed))
+
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	if err := h.SetAdminTokens(map[string]string{testAdminName: testAdminToken, "ci": "sha256:" + hex.EncodeToString(digest[:])}); err != nil {
+		t.Fatalf("SetAdminTokens: %v", err)
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+		wantCode      string
+		wantChallenge string
+	}{
+		{"valid token", "/api/admin/analytics/sessions", "Bearer " + testAdminToken, http.StatusOK, "", ""},
+		{"valid hashed token", "/api/admin/analytics/sessions", "Bearer " + hashed, http.StatusOK, "", ""},
+		{"scheme is case-insensitive", "/api/admin/analytics/sessions", "bearer " + testAdminToken, http.StatusOK, "", ""},
+		{"invalid token", "/api/admin/analytics/sessions", "Bearer wrong-token", http.StatusUnauthorized, CodeUnauthorized, `error="invalid_token"`},
+		{"digest is not a token", "/api/admin/analytics/sessions", "Bearer " + hex.EncodeToString(digest[:]), http.StatusUnauthorized, CodeUnauthorized, `error="invalid_token"`},
+		{"missing token", "/api/admin/analytics/sessions", "", http.StatusUnauthorized, CodeUnauthorized, `realm="driftfm admin"`},
+		{"basic auth", "/api/admin/analytics/sessions", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, CodeUnauthorized, `realm="driftfm admin"`},
+		{"unknown admin path needs a token", "/api/admin/backups", "", http.StatusUnauthorized, CodeUnauthorized, `realm="driftfm admin"`},
+		{"unknown admin path", "/api/admin/backups", "Bearer " + testAdminToken, http.StatusNotFound, CodeNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := metrics.Get().Snapshot()["admin_auth_failures"].(uint64)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+
+			challenge := w.Header().Get("WWW-Authenticate")
+			after := metrics.Get().Snapshot()["admin_auth_failures"].(uint64)
+			if tt.wantStatus != http.StatusUnauthorized {
+				if challenge != "" {
+					t.Errorf("WWW-Authenticate = %q, want none", challenge)
+				}
+				if after != before {
+					t.Error("admin_auth_failures moved for an authorized request")
+				}
+				return
+			}
+			if !strings.HasPrefix(challenge, "Bearer ") || !strings.Contains(challenge, tt.wantChallenge) {
+				t.Errorf("WWW-Authenticate = %q, want Bearer challenge with %s", challenge, tt.wantChallenge)
+			}
+			if after != before+1 {
+				t.Errorf("admin_auth_failures = %d, want %d", after, before+1)
+			}
+		})
+	}
+}
+
+func TestAdminAuth_DisabledWithoutTokens(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for _, path := range []string{
+		"/api/admin/analytics/sessions",
+		"/api/admin/tracks/1",
+		"/api/admin/radio/focus",
+		"/api/admin/backups",
+	} {
+		req := asAdmin(httptest.NewRequest(http.MethodGet, path, nil))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+		if code := errorCode(t, w); code != CodeNotFound {
+			t.Errorf("%s: code = %q, want %q", path, code, CodeNotFound)
+		}
+	}
+
+	// Public routes are unaffected
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/api/version status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestSetAdminTokens_InvalidDigest(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	for _, token := range []string{"sha256:zz", "sha256:abcd"} {
+		if err := h.SetAdminTokens(map[string]string{"ci": token}); err == nil {
+			t.Errorf("SetAdminTokens(%q) succeeded, want error", token)
+		}
+	}
+	if err := h.SetAdminTokens(map[string]string{"": testAdminToken}); err == nil {
+		t.Error("SetAdminTokens with an unnamed token succeeded, want error")
+	}
+}
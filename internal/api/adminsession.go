@@ -0,0 +1,190 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminSessionCookie carries a browser's admin session, so an admin page
+// doesn't keep the bearer token in script-readable storage
+const adminSessionCookie = "driftfm_admin"
+
+// adminSessionTTL is how long an admin session lasts
+const adminSessionTTL = 12 * time.Hour
+
+// csrfHeader must carry the session's CSRF token on every admin request
+// authenticated by the session cookie that can change state
+const csrfHeader = "X-CSRF-Token"
+
+// adminSessionNonceBytes is the random part of a session
+const adminSessionNonceBytes = 16
+
+// CSRFInfo is the response of the admin session and CSRF endpoints
+type CSRFInfo struct {
+	Token     string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// newAdminSessionKey returns a random key for signing admin sessions.
+// Sessions don't outlive the process that issued them.
+func newAdminSessionKey() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return key
+}
+
+// adminSession is a verified session cookie. name is the admin token that
+// started it, so changes made in the session are attributed to that name.
+type adminSession struct {
+	nonce     string
+	name      string
+	expiresAt time.Time
+}
+
+// sessionMAC signs a session's fields with the handler's session key
+func (h *Handler) sessionMAC(parts ...string) string {
+	mac := hmac.New(sha256.New, h.adminSessionKey)
+	mac.Write([]byte(strings.Join(parts, ".")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// csrfToken derives a session's CSRF token, so it needs no storage
+func (h *Handler) csrfToken(s adminSession) string {
+	return h.sessionMAC("csrf", s.nonce)
+}
+
+// adminSessionFrom returns the request's admin session when its cookie is
+// validly signed and unexpired
+func (h *Handler) adminSessionFrom(r *http.Request) (adminSession, bool) {
+	c, err := r.Cookie(adminSessionCookie)
+	if err != nil {
+		return adminSession{}, false
+	}
+	parts := strings.Split(c.Value, ".")
+	if len(parts) != 4 {
+		return adminSession{}, false
+	}
+	exp, nonce, hexName, sig := parts[0], parts[1], parts[2], parts[3]
+	if !hmac.Equal([]byte(sig), []byte(h.sessionMAC("session", exp, nonce, hexName))) {
+		return adminSession{}, false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !h.now().Before(time.Unix(unix, 0)) {
+		return adminSession{}, false
+	}
+	name, err := hex.DecodeString(hexName)
+	if err != nil {
+		return adminSession{}, false
+	}
+	return adminSession{nonce: nonce, name: string(name), expiresAt: time.Unix(unix, 0).UTC()}, true
+}
+
+// checkCSRF guards a session-authenticated request that can change state:
+// its Origin (or, without one, Referer) must be this host, and it must
+// carry the session's CSRF token. Safe methods pass.
+func (h *Handler) checkCSRF(r *http.Request, s adminSession) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	if !sameOrigin(r) {
+		return false
+	}
+	token := r.Header.Get(csrfHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.csrfToken(s))) == 1
+}
+
+// sameOrigin reports whether the request's Origin, or Referer when it has
+// no Origin, names the host it was sent to. A request with neither fails.
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" || source == "null" {
+		source = r.Header.Get("Referer")
+	}
+	u, err := url.Parse(source)
+	if source == "" || err != nil {
+		return false
+	}
+	return u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// handleAdminSession serves /api/admin/session. POST, made with a bearer
+// token, starts a browser session under that token's name: an HttpOnly,
+// SameSite=Strict cookie good for adminSessionTTL, and its CSRF token.
+// DELETE ends it.
+func (h *Handler) handleAdminSession(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		name, named := adminName(r.Context())
+		if _, ok := bearerToken(r); !ok || !named {
+			writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Bearer token required to start a session")
+			return
+		}
+		nonce := make([]byte, adminSessionNonceBytes)
+		if _, err := rand.Read(nonce); err != nil {
+			log.Printf("Error generating admin session: %v", err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+			return
+		}
+		s := adminSession{nonce: hex.EncodeToString(nonce), name: name, expiresAt: h.now().Add(adminSessionTTL).UTC().Truncate(time.Second)}
+		exp := strconv.FormatInt(s.expiresAt.Unix(), 10)
+		hexName := hex.EncodeToString([]byte(s.name))
+		http.SetCookie(w, &http.Cookie{
+			Name:     adminSessionCookie,
+			Value:    exp + "." + s.nonce + "." + hexName + "." + h.sessionMAC("session", exp, s.nonce, hexName),
+			Path:     "/api/admin/",
+			Expires:  s.expiresAt,
+			HttpOnly: true,
+			Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteStrictMode,
+		})
+		h.writeCSRF(w, s)
+	case http.MethodDelete:
+		http.SetCookie(w, &http.Cookie{
+			Name:     adminSessionCookie,
+			Path:     "/api/admin/",
+			MaxAge:   -1,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// getCSRF serves GET /api/admin/csrf: the CSRF token of the caller's
+// session, for a page that lost it. Bearer clients have no session and
+// need no token.
+func (h *Handler) getCSRF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	s, ok := h.adminSessionFrom(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, CodeNoSession, "No admin session; bearer clients need no CSRF token")
+		return
+	}
+	h.writeCSRF(w, s)
+}
+
+func (h *Handler) writeCSRF(w http.ResponseWriter, s adminSession) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(CSRFInfo{Token: h.csrfToken(s), ExpiresAt: s.expiresAt}); err != nil {
+		log.Printf("Error encoding CSRF token: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdminSession_CSRF(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// A bearer client starts a browser session
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/session", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("session status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var info CSRFInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("failed to decode session: %v", err)
+	}
+	var session *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == adminSessionCookie {
+			session = c
+		}
+	}
+	if session == nil || !session.HttpOnly || session.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("session cookie = %+v, want HttpOnly and SameSite=Strict", session)
+	}
+	if info.Token == "" || !info.ExpiresAt.After(time.Now()) {
+		t.Fatalf("session = %+v, want a token and a future expiry", info)
+	}
+
+	send := func(method, path, origin, token string, bearer bool) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(session)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if token != "" {
+			req.Header.Set(csrfHeader, token)
+		}
+		if bearer {
+			req = asAdmin(req)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	const self = "http://example.com" // httptest requests go to example.com
+
+	// The session reads without a token and can fetch its token again
+	if w := send(http.MethodGet, "/api/admin/csrf", "", "", false); w.Code != http.StatusOK {
+		t.Fatalf("csrf status = %d, want 200 (%s)", w.Code, w.Body.String())
+	} else {
+		var again CSRFInfo
+		if err := json.NewDecoder(w.Body).Decode(&again); err != nil || again.Token != info.Token {
+			t.Errorf("csrf token = %q, want the session's %q", again.Token, info.Token)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		origin     string
+		token      string
+		bearer     bool
+		wantStatus int
+	}{
+		{"missing token", self, "", false, http.StatusForbidden},
+		{"wrong token", self, info.Token[1:] + "0", false, http.StatusForbidden},
+		{"cross-site origin", "https://evil.example", info.Token, false, http.StatusForbidden},
+		{"no origin", "", info.Token, false, http.StatusForbidden},
+		{"bearer is exempt", "https://evil.example", "", true, http.StatusNoContent},
+		{"happy path", self, info.Token, false, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(http.MethodDelete, "/api/admin/session", tt.origin, tt.token, tt.bearer)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				if code := errorCode(t, w); code != CodeCSRF {
+					t.Errorf("code = %q, want %q", code, CodeCSRF)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminSession_Invalid(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// Bearer clients have no session to fetch a token for
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/csrf", nil)))
+	if w.Code != http.StatusBadRequest || errorCode(t, w) != CodeNoSession {
+		t.Errorf("bearer csrf status = %d, want 400 no_session", w.Code)
+	}
+
+	// A forged or expired cookie is no session at all
+	expired := h.now().Add(-time.Minute).Unix()
+	for _, value := range []string{
+		"9999999999.abcd.616c696365.deadbeef",
+		strconv.FormatInt(expired, 10) + ".abcd.616c696365." + h.sessionMAC("session", strconv.FormatInt(expired, 10), "abcd", "616c696365"),
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/analytics/sessions", nil)
+		req.AddCookie(&http.Cookie{Name: adminSessionCookie, Value: value})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("cookie %q: status = %d, want 401", value, w.Code)
+		}
+	}
+}
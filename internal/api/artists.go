@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Artist catalog paging
+const (
+	DefaultArtistPageSize = 50
+	MaxArtistPageSize     = 200
+)
+
+// totalCountHeader carries the size of a paginated listing
+const totalCountHeader = "X-Total-Count"
+
+// handleArtists serves GET /api/artists/{artist}/tracks?limit=&offset=: an
+// artist's approved tracks by title, slim like playlist entries, with the
+// artist's total in X-Total-Count. The name matches case-insensitively.
+func (h *Handler) handleArtists(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Split the escaped path so an encoded "/" (AC%2FDC) stays in the name
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/artists/")
+	escaped, rest, _ := strings.Cut(path, "/")
+	if rest != "tracks" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+	artist, err := url.PathUnescape(escaped)
+	if err != nil || strings.TrimSpace(artist) == "" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+
+	q := r.URL.Query()
+	limit := DefaultArtistPageSize
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, MaxArtistPageSize)
+	}
+	offset := 0
+	if raw := q.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidOffset, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+
+	tracks, total, err := h.repo.GetByArtist(artist, limit, offset)
+	if err != nil {
+		log.Printf("Error fetching tracks for artist %q: %v", artist, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	for _, track := range tracks {
+		h.resolveAudioURL(track)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Set(totalCountHeader, strconv.Itoa(total))
+	if err := json.NewEncoder(w).Encode(h.toPlaylistTracks("", tracks)); err != nil {
+		log.Printf("Error encoding artist tracks: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestArtistTracks(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	repo := setupTestDBAt(t, dbPath)
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO tracks (file_path, title, artist, mood, duration_seconds, status) VALUES
+		('focus/thunder.mp3', 'Thunder', 'AC/DC', 'focus', 200, 'approved')`); err != nil {
+		t.Fatalf("failed to seed artist: %v", err)
+	}
+	_ = db.Close()
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantTitles []string
+		wantTotal  string
+	}{
+		{"default artist", "/api/artists/Drift%20FM/tracks", http.StatusOK, "", []string{"Calm Track 1", "Focus Track 1", "Focus Track 2"}, "3"},
+		{"case-insensitive page", "/api/artists/drift%20fm/tracks?limit=1&offset=1", http.StatusOK, "", []string{"Focus Track 1"}, "3"},
+		{"encoded slash", "/api/artists/AC%2FDC/tracks", http.StatusOK, "", []string{"Thunder"}, "1"},
+		{"unknown artist", "/api/artists/Nobody/tracks", http.StatusOK, "", []string{}, "0"},
+		{"missing tracks segment", "/api/artists/Drift%20FM", http.StatusNotFound, CodeNotFound, nil, ""},
+		{"bad limit", "/api/artists/Drift%20FM/tracks?limit=0", http.StatusBadRequest, CodeInvalidLimit, nil, ""},
+		{"bad offset", "/api/artists/Drift%20FM/tracks?offset=-1", http.StatusBadRequest, CodeInvalidOffset, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			if got := w.Header().Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("X-Total-Count = %q, want %q", got, tt.wantTotal)
+			}
+
+			var tracks []PlaylistTrack
+			if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(tracks) != len(tt.wantTitles) {
+				t.Fatalf("got %d tracks, want %d", len(tracks), len(tt.wantTitles))
+			}
+			for i, tr := range tracks {
+				if tr.Title == nil || *tr.Title != tt.wantTitles[i] {
+					t.Errorf("track %d title = %v, want %q", i, tr.Title, tt.wantTitles[i])
+				}
+				if tr.BorrowedFrom != "" {
+					t.Errorf("track %d borrowed_from = %q, want empty", i, tr.BorrowedFrom)
+				}
+			}
+		})
+	}
+}
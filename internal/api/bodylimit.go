@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+// Default request body limits per route class, in bytes
+const (
+	DefaultEventBodyLimit  = 4 << 10   // listener events
+	DefaultAdminBodyLimit  = 64 << 10  // admin JSON
+	DefaultUploadBodyLimit = 256 << 20 // audio uploads
+)
+
+// BodyLimits caps request bodies per route class. Zero fields keep the default.
+type BodyLimits struct {
+	Events int64 // public routes, e.g. POST /api/tracks/{id}/play
+	Admin  int64 // /api/admin/ JSON routes
+	Upload int64 // audio upload routes, once they exist
+}
+
+// bodyClass selects which of BodyLimits applies to a route
+type bodyClass int
+
+const (
+	bodyEvents bodyClass = iota
+	bodyAdmin
+	bodyUpload
+)
+
+// limit returns the byte limit for a route class
+func (l BodyLimits) limit(c bodyClass) int64 {
+	switch c {
+	case bodyAdmin:
+		return l.Admin
+	case bodyUpload:
+		return l.Upload
+	default:
+		return l.Events
+	}
+}
+
+// SetBodyLimits replaces the request body limits; zero fields keep the default
+func (h *Handler) SetBodyLimits(l BodyLimits) {
+	if l.Events > 0 {
+		h.bodyLimits.Events = l.Events
+	}
+	if l.Admin > 0 {
+		h.bodyLimits.Admin = l.Admin
+	}
+	if l.Upload > 0 {
+		h.bodyLimits.Upload = l.Upload
+	}
+}
+
+// limitBody caps the request body at the route class's limit. A declared
+// Content-Length over the limit is rejected up front; otherwise reads past
+// it fail with *http.MaxBytesError, which handlers report via bodyTooLarge.
+func (h *Handler) limitBody(c bodyClass, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := h.bodyLimits.limit(c)
+		if r.ContentLength > limit {
+			writeBodyTooLarge(w, r, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next(w, r)
+	}
+}
+
+// bodyTooLarge writes a 413 and returns true if err came from reading past
+// the body limit. Callers treat any other error as a malformed body.
+func bodyTooLarge(w http.ResponseWriter, r *http.Request, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	writeBodyTooLarge(w, r, tooLarge.Limit)
+	return true
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	metrics.Get().RecordBodyTooLarge()
+	writeError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+func TestBodyLimits(t *testing.T) {
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	h.SetBodyLimits(BodyLimits{Events: 256, Admin: 512, Upload: 1024})
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	// No upload route exists yet; exercise the class through a reading handler
+	mux.HandleFunc("/test/upload", h.limitBody(bodyUpload, func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); bodyTooLarge(w, r, err) {
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// jsonBody pads a valid JSON object to n bytes
+	jsonBody := func(n int) string {
+		prefix := `{"event":"play","session_id":"`
+		return prefix + strings.Repeat("x", n-len(prefix)-2) + `"}`
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		chunked    bool // hide Content-Length so the limit trips while reading
+		wantStatus int
+	}{
+		{"event under limit", http.MethodPost, "/api/tracks/1/play", `{"event":"play"}`, false, http.StatusOK},
+		{"event over limit", http.MethodPost, "/api/tracks/1/play", jsonBody(300), false, http.StatusRequestEntityTooLarge},
+		{"event over limit chunked", http.MethodPost, "/api/tracks/1/play", jsonBody(300), true, http.StatusRequestEntityTooLarge},
+		{"admin under limit", http.MethodPatch, "/api/admin/tracks/1", `{"title":"` + strings.Repeat("a", 300) + `"}`, false, http.StatusOK},
+		{"admin over limit", http.MethodPatch, "/api/admin/tracks/1", `{"title":"` + strings.Repeat("a", 600) + `"}`, false, http.StatusRequestEntityTooLarge},
+		{"admin over limit chunked", http.MethodPatch, "/api/admin/tracks/1", `{"title":"` + strings.Repeat("a", 600) + `"}`, true, http.StatusRequestEntityTooLarge},
+		{"upload under limit", http.MethodPost, "/test/upload", strings.Repeat("b", 1000), false, http.StatusCreated},
+		{"upload over limit", http.MethodPost, "/test/upload", strings.Repeat("b", 1100), true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := metrics.Get().Snapshot()["requests_body_too_large"].(uint64)
+
+			req := asAdmin(httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			after := metrics.Get().Snapshot()["requests_body_too_large"].(uint64)
+			if tt.wantStatus != http.StatusRequestEntityTooLarge {
+				if after != before {
+					t.Errorf("body_too_large counter moved for an accepted request")
+				}
+				return
+			}
+			if code := errorCode(t, w); code != CodeBodyTooLarge {
+				t.Errorf("code = %q, want %q", code, CodeBodyTooLarge)
+			}
+			if after != before+1 {
+				t.Errorf("body_too_large counter = %d, want %d", after, before+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// maxAppVersionLength bounds the client-supplied app version
+const maxAppVersionLength = 32
+
+// knownPlatforms are the platform values stored as-is; anything else is
+// recorded as inventory.PlatformOther
+var knownPlatforms = map[string]bool{
+	inventory.PlatformIOS:     true,
+	inventory.PlatformAndroid: true,
+	inventory.PlatformDesktop: true,
+	inventory.PlatformOther:   true,
+}
+
+// normalizeClient validates the client fields of a listen event, mapping the
+// platform onto the known set and deriving it from userAgent when absent.
+func normalizeClient(c inventory.ClientInfo, userAgent string) (inventory.ClientInfo, error) {
+	c.AppVersion = strings.TrimSpace(c.AppVersion)
+	if len(c.AppVersion) > maxAppVersionLength {
+		return c, errors.New("app_version too long")
+	}
+
+	p := strings.ToLower(strings.TrimSpace(c.Platform))
+	switch {
+	case p == "":
+		c.Platform = platformFromUserAgent(userAgent)
+	case knownPlatforms[p]:
+		c.Platform = p
+	default:
+		c.Platform = inventory.PlatformOther
+	}
+	return c, nil
+}
+
+// platformFromUserAgent classifies a User-Agent header. It only needs to be
+// good enough for analytics: iPadOS in desktop mode reports as desktop.
+func platformFromUserAgent(ua string) string {
+	switch {
+	case ua == "":
+		return inventory.PlatformOther
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		return inventory.PlatformIOS
+	case strings.Contains(ua, "Android"):
+		return inventory.PlatformAndroid
+	case strings.Contains(ua, "Windows"), strings.Contains(ua, "Macintosh"),
+		strings.Contains(ua, "CrOS"), strings.Contains(ua, "X11"), strings.Contains(ua, "Linux"):
+		return inventory.PlatformDesktop
+	default:
+		return inventory.PlatformOther
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+func TestNormalizeClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      inventory.ClientInfo
+		ua      string
+		want    inventory.ClientInfo
+		wantErr bool
+	}{
+		{"explicit", inventory.ClientInfo{Platform: "ios", AppVersion: "2.1.0"}, "", inventory.ClientInfo{Platform: "ios", AppVersion: "2.1.0"}, false},
+		{"case and space", inventory.ClientInfo{Platform: " Android ", AppVersion: " 1.0 "}, "", inventory.ClientInfo{Platform: "android", AppVersion: "1.0"}, false},
+		{"unknown platform", inventory.ClientInfo{Platform: "smart-fridge"}, "", inventory.ClientInfo{Platform: "other"}, false},
+		{"explicit wins over UA", inventory.ClientInfo{Platform: "desktop"}, "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0)", inventory.ClientInfo{Platform: "desktop"}, false},
+		{"UA fallback", inventory.ClientInfo{}, "Mozilla/5.0 (Linux; Android 14; Pixel 8)", inventory.ClientInfo{Platform: "android"}, false},
+		{"version too long", inventory.ClientInfo{AppVersion: strings.Repeat("9", 33)}, "", inventory.ClientInfo{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeClient(tt.in, tt.ua)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeClient() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlatformFromUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{"", "other"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "ios"},
+		{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X)", "ios"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8)", "android"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0)", "desktop"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "desktop"},
+		{"Mozilla/5.0 (X11; Ubuntu; Linux x86_64)", "desktop"},
+		{"curl/8.4.0", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := platformFromUserAgent(tt.ua); got != tt.want {
+			t.Errorf("platformFromUserAgent(%q) = %q, want %q", tt.ua, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/probe"
+)
+
+// Fade estimation job states
+const (
+	FadeJobIdle    = "idle"
+	FadeJobRunning = "running"
+	FadeJobDone    = "done"
+	FadeJobFailed  = "failed"  // the job crashed part way
+	FadeJobStopped = "stopped" // the server shut down part way
+)
+
+// fadeEstimateActor is recorded for fades written by the estimation job
+const fadeEstimateActor = "crossfade-estimator"
+
+// FadeEstimateProgress reports the crossfade estimation job, running or last run
+type FadeEstimateProgress struct {
+	State     string `json:"state"`
+	Mood      string `json:"mood,omitempty"` // empty = every mood
+	Overwrite bool   `json:"overwrite"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Updated   int    `json:"updated"`
+	// Skipped tracks already had both fades, or their file was silent
+	// throughout or in a format the probe can't measure
+	Skipped    int        `json:"skipped"`
+	Failed     int        `json:"failed"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// fadeEstimator guards the progress of the one estimation job allowed at a
+// time, and stops it on shutdown
+type fadeEstimator struct {
+	mu       sync.Mutex
+	progress FadeEstimateProgress
+
+	ctx    context.Context // canceled by StopJobs
+	cancel context.CancelFunc
+	jobs   sync.WaitGroup
+}
+
+func newFadeEstimator() *fadeEstimator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fadeEstimator{ctx: ctx, cancel: cancel}
+}
+
+func (e *fadeEstimator) snapshot() FadeEstimateProgress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p := e.progress
+	if p.State == "" {
+		p.State = FadeJobIdle
+	}
+	return p
+}
+
+// start claims the job, reporting false when one is already running
+func (e *fadeEstimator) start(p FadeEstimateProgress) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.progress.State == FadeJobRunning {
+		return false
+	}
+	e.progress = p
+	return true
+}
+
+func (e *fadeEstimator) update(fn func(*FadeEstimateProgress)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(&e.progress)
+}
+
+// StopJobs stops the running crossfade estimation job, if any, after the
+// track it is on, and waits for it to exit or ctx to be done. Call it once
+// the server stops taking requests; jobs started later stop at once.
+func (h *Handler) StopJobs(ctx context.Context) error {
+	h.fades.cancel()
+	done := make(chan struct{})
+	go func() {
+		h.fades.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetAudioRoot enables crossfade estimation over the local audio files
+// under root, where track file paths are resolved
+func (h *Handler) SetAudioRoot(root string) {
+	h.audioRoot = root
+}
+
+// estimateFades serves /api/admin/crossfade/estimate. POST ?mood=&overwrite=
+// starts estimating fade_in_ms and fade_out_ms from the silent padding of
+// each approved or pending track's audio file (every mood when mood is
+// empty) and returns 202 with the job's progress; GET reports that
+// progress. Fades already set are kept unless overwrite=true. One job runs
+// at a time; starting another while it runs is a 409.
+func (h *Handler) estimateFades(w http.ResponseWriter, r *http.Request) {
+	if h.audioRoot == "" {
+		notFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeFadeProgress(w, http.StatusOK, h.fades.snapshot())
+		return
+	case http.MethodPost:
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	mood := q.Get("mood")
+	if mood != "" && !validMoods[mood] {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood: "+mood, unknownMoodDetails(mood))
+		return
+	}
+	overwrite, err := parseBool(q, "overwrite")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBoolean, err.Error())
+		return
+	}
+
+	tracks, err := h.fadeCandidates(mood)
+	if err != nil {
+		log.Printf("Error listing tracks for fade estimation: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	started := h.now().UTC()
+	progress := FadeEstimateProgress{
+		State:     FadeJobRunning,
+		Mood:      mood,
+		Overwrite: overwrite,
+		Total:     len(tracks),
+		StartedAt: &started,
+	}
+	if !h.fades.start(progress) {
+		writeError(w, r, http.StatusConflict, CodeJobRunning, "A fade estimation job is already running")
+		return
+	}
+	h.fades.jobs.Add(1)
+	go h.runFadeEstimate(h.fades.ctx, tracks, overwrite)
+
+	writeFadeProgress(w, http.StatusAccepted, progress)
+}
+
+func writeFadeProgress(w http.ResponseWriter, status int, p FadeEstimateProgress) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("Error encoding fade estimation progress: %v", err)
+	}
+}
+
+// fadeCandidates lists the approved and pending tracks of mood (every mood
+// when empty) up front, so the job's total is known when it starts
+func (h *Handler) fadeCandidates(mood string) ([]*inventory.Track, error) {
+	var tracks []*inventory.Track
+	for page := 1; ; page++ {
+		batch, total, err := h.repo.ListTracks(inventory.ListOptions{
+			Mood:    mood,
+			Page:    page,
+			PerPage: inventory.MaxListPerPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, batch...)
+		if len(batch) == 0 || len(tracks) >= total {
+			return tracks, nil
+		}
+	}
+}
+
+// runFadeEstimate probes each track's file and stores its fades, reporting
+// progress after every track. A panic ends the job as failed rather than
+// taking the server down, and ctx's cancellation ends it as stopped.
+func (h *Handler) runFadeEstimate(ctx context.Context, tracks []*inventory.Track, overwrite bool) {
+	defer h.fades.jobs.Done()
+
+	moods := map[string]bool{}
+	state := FadeJobFailed
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("Error in fade estimation: panic: %v\n%s", v, debug.Stack())
+		}
+
+		// Playlists embed the fades
+		for mood := range moods {
+			h.invalidatePlaylists(mood)
+		}
+
+		finished := h.now().UTC()
+		h.fades.update(func(p *FadeEstimateProgress) {
+			p.State = state
+			p.FinishedAt = &finished
+			log.Printf("Fade estimation %s: %d updated, %d skipped, %d failed of %d tracks",
+				state, p.Updated, p.Skipped, p.Failed, p.Total)
+		})
+	}()
+
+	for _, track := range tracks {
+		if ctx.Err() != nil {
+			state = FadeJobStopped
+			return
+		}
+		updated, skipped, err := h.estimateTrackFades(track, overwrite)
+		if err != nil {
+			log.Printf("Warning: fade estimation failed for track %d: %v", track.ID, err)
+		}
+		if updated {
+			moods[track.Mood] = true
+		}
+		h.fades.update(func(p *FadeEstimateProgress) {
+			p.Processed++
+			switch {
+			case err != nil:
+				p.Failed++
+			case updated:
+				p.Updated++
+			case skipped:
+				p.Skipped++
+			}
+		})
+	}
+	state = FadeJobDone
+}
+
+// estimateTrackFades sets one track's missing fades (all of them with
+// overwrite) from its file's leading and trailing silence
+func (h *Handler) estimateTrackFades(track *inventory.Track, overwrite bool) (updated, skipped bool, err error) {
+	setIn := overwrite || track.FadeInMs == nil
+	setOut := overwrite || track.FadeOutMs == nil
+	if !setIn && !setOut {
+		return false, true, nil
+	}
+
+	local, err := h.localAudioPath(track.FilePath)
+	if err != nil {
+		return false, false, err
+	}
+	silence, err := probe.DetectSilence(local)
+	if errors.Is(err, probe.ErrUnsupported) {
+		return false, true, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	if silence.Silent() {
+		return false, true, nil
+	}
+
+	fields := map[string]any{}
+	if setIn {
+		fields["fade_in_ms"] = min(int(silence.Lead.Milliseconds()), inventory.MaxFadeMs)
+	}
+	if setOut {
+		fields["fade_out_ms"] = min(int(silence.Trail.Milliseconds()), inventory.MaxFadeMs)
+	}
+	if err := h.repo.UpdateTrack(track.ID, fields, fadeEstimateActor, ""); err != nil {
+		return false, false, err
+	}
+	return true, false, nil
+}
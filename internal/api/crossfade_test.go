@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+// writeTestMP3 writes an MPEG-1 Layer III stream of 417-byte frames
+// (128 kbit/s, 44.1 kHz, so 1152/44100 s each): lead silent frames, sound
+// frames carrying coded spectrum, then trail silent frames
+func writeTestMP3(t *testing.T, path string, lead, sound, trail int) {
+	t.Helper()
+	frame := func(silent bool) []byte {
+		f := make([]byte, 417)
+		copy(f, []byte{0xFF, 0xFB, 0x90, 0x00})
+		if !silent {
+			f[7] = 0xFF // inside the first granule's part2_3_length
+		}
+		return f
+	}
+	var b bytes.Buffer
+	for i := range lead + sound + trail {
+		b.Write(frame(i < lead || i >= lead+sound))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create audio dir: %v", err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write audio file: %v", err)
+	}
+}
+
+// waitFadeJob polls the estimation job until it's no longer running
+func waitFadeJob(t *testing.T, mux *http.ServeMux) FadeEstimateProgress {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/crossfade/estimate", nil)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("progress status = %d, want 200", w.Code)
+		}
+		var p FadeEstimateProgress
+		if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+			t.Fatalf("failed to decode progress: %v", err)
+		}
+		if p.State != FadeJobRunning {
+			return p
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("estimation job still %s after 5s: %+v", p.State, p)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEstimateFades(t *testing.T) {
+	repo := setupTestDB(t)
+	root := t.TempDir()
+	// Track 1 has 10 frames (261ms) of lead-in and 5 (130ms) of lead-out;
+	// track 2's file is missing; track 3 is in another mood
+	writeTestMP3(t, filepath.Join(root, "focus", "track1.mp3"), 10, 40, 5)
+	writeTestMP3(t, filepath.Join(root, "calm", "track1.mp3"), 3, 40, 3)
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	h.SetAudioRoot(root)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/crossfade/estimate", nil)))
+	var idle FadeEstimateProgress
+	if err := json.NewDecoder(w.Body).Decode(&idle); err != nil || idle.State != FadeJobIdle {
+		t.Fatalf("progress before any job = %+v (%v), want idle", idle, err)
+	}
+
+	// Warm the playlist cache so the job has to invalidate it
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/crossfade/estimate?mood=focus", nil)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202 (%s)", w.Code, w.Body.String())
+	}
+	var started FadeEstimateProgress
+	if err := json.NewDecoder(w.Body).Decode(&started); err != nil {
+		t.Fatalf("failed to decode progress: %v", err)
+	}
+	if started.State != FadeJobRunning || started.Mood != "focus" || started.Total != 2 || started.StartedAt == nil {
+		t.Errorf("started = %+v, want running over 2 focus tracks", started)
+	}
+
+	done := waitFadeJob(t, mux)
+	if done.Processed != 2 || done.Updated != 1 || done.Failed != 1 || done.Skipped != 0 || done.FinishedAt == nil {
+		t.Errorf("done = %+v, want 1 updated and 1 failed of 2", done)
+	}
+
+	track, _ := repo.GetByID(1)
+	if track.FadeInMs == nil || *track.FadeInMs != 261 || track.FadeOutMs == nil || *track.FadeOutMs != 130 {
+		t.Errorf("track 1 fades = %v, %v; want 261, 130", track.FadeInMs, track.FadeOutMs)
+	}
+	if track, _ := repo.GetByID(3); track.FadeInMs != nil {
+		t.Errorf("calm track estimated in a focus run: fade in %d", *track.FadeInMs)
+	}
+
+	// Playlists carry the new fades
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+	var playlist []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&playlist); err != nil {
+		t.Fatalf("failed to decode playlist: %v", err)
+	}
+	found := false
+	for _, pt := range playlist {
+		if pt.ID == 1 {
+			found = pt.FadeInMs != nil && *pt.FadeInMs == 261
+		}
+	}
+	if !found {
+		t.Error("playlist track 1 lacks fade_in_ms 261")
+	}
+
+	// Manual fades survive a rerun unless overwritten
+	if err := repo.UpdateTrack(1, map[string]any{"fade_in_ms": 2000}, "tester", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/crossfade/estimate?mood=focus", nil)))
+	if done := waitFadeJob(t, mux); done.Skipped != 1 || done.Updated != 0 {
+		t.Errorf("rerun = %+v, want track 1 skipped", done)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/crossfade/estimate?mood=focus&overwrite=true", nil)))
+	waitFadeJob(t, mux)
+	if track, _ := repo.GetByID(1); track.FadeInMs == nil || *track.FadeInMs != 261 {
+		t.Errorf("fade in after overwrite = %v, want 261", track.FadeInMs)
+	}
+}
+
+func TestEstimateFades_FailedAndStopped(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	h.SetAudioRoot(t.TempDir())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// A crash ends the job as failed rather than leaving it running
+	h.fades.start(FadeEstimateProgress{State: FadeJobRunning, Total: 1})
+	h.fades.jobs.Add(1)
+	go h.runFadeEstimate(h.fades.ctx, []*inventory.Track{nil}, false)
+	if p := waitFadeJob(t, mux); p.State != FadeJobFailed || p.FinishedAt == nil {
+		t.Errorf("after a panic = %+v, want failed and finished", p)
+	}
+
+	// Once jobs are stopped for shutdown, a new job stops before any track
+	if err := h.StopJobs(context.Background()); err != nil {
+		t.Fatalf("StopJobs failed: %v", err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/crossfade/estimate?mood=focus", nil)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202 (%s)", w.Code, w.Body.String())
+	}
+	if p := waitFadeJob(t, mux); p.State != FadeJobStopped || p.Total != 2 || p.Processed != 0 {
+		t.Errorf("after shutdown = %+v, want stopped with 0 of 2 processed", p)
+	}
+	if err := h.StopJobs(context.Background()); err != nil {
+		t.Errorf("StopJobs with the job stopped failed: %v", err)
+	}
+}
+
+func TestEstimateFades_Errors(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/crossfade/estimate", nil)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status without an audio root = %d, want 404", w.Code)
+	}
+
+	h.SetAudioRoot(t.TempDir())
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown mood", http.MethodPost, "?mood=focs", http.StatusNotFound, CodeMoodNotFound},
+		{"wrong method", http.MethodDelete, "", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(tt.method, "/api/admin/crossfade/estimate"+tt.query, nil)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("already running", func(t *testing.T) {
+		h.fades.start(FadeEstimateProgress{State: FadeJobRunning})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/crossfade/estimate", nil)))
+		if w.Code != http.StatusConflict {
+			t.Fatalf("status = %d, want 409", w.Code)
+		}
+		if code := errorCode(t, w); code != CodeJobRunning {
+			t.Errorf("code = %q, want %q", code, CodeJobRunning)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"container/list"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// DefaultDedupMaxEntries bounds the play dedup store
+const DefaultDedupMaxEntries = 10000
+
+// playKey identifies a play from one client for one track
+type playKey struct {
+	trackID int64
+	client  netip.Addr
+}
+
+// playDeduper remembers recent plays so a repeat of the same (track, client)
+// within window can be ignored
+type playDeduper = windowSet[playKey]
+
+func newPlayDeduper(window time.Duration, maxEntries int) *playDeduper {
+	return newWindowSet[playKey](window, maxEntries)
+}
+
+// windowSet remembers keys claimed within a window. Memory is bounded by
+// maxEntries. Every entry lives for the same window, so claim order is
+// expiry order: when full, expired entries go first from the front of the
+// list, then the oldest, without scanning the map.
+type windowSet[K comparable] struct {
+	window     time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	seen  map[K]*list.Element
+	order *list.List // of windowEntry[K], oldest claim first
+}
+
+// windowEntry is one claim in a windowSet's order list
+type windowEntry[K comparable] struct {
+	key K
+	at  time.Time
+}
+
+func newWindowSet[K comparable](window time.Duration, maxEntries int) *windowSet[K] {
+	return &windowSet[K]{
+		window:     window,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		seen:       make(map[K]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// claim records key and reports whether it is new. It returns false if the
+// same key was claimed within the window.
+func (s *windowSet[K]) claim(key K) bool {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.seen[key]; ok {
+		if now.Sub(e.Value.(windowEntry[K]).at) < s.window {
+			return false
+		}
+		s.removeLocked(e)
+	}
+	s.evictLocked(now)
+	s.seen[key] = s.order.PushBack(windowEntry[K]{key: key, at: now})
+	return true
+}
+
+// release forgets a claim, so a retry after a failed write is not ignored
+func (s *windowSet[K]) release(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.seen[key]; ok {
+		s.removeLocked(e)
+	}
+}
+
+// evictLocked drops expired entries, then the oldest while the set is full.
+// Caller must hold s.mu.
+func (s *windowSet[K]) evictLocked(now time.Time) {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		if now.Sub(e.Value.(windowEntry[K]).at) < s.window && len(s.seen) < s.maxEntries {
+			return
+		}
+		s.removeLocked(e)
+	}
+}
+
+// removeLocked drops one entry. Caller must hold s.mu.
+func (s *windowSet[K]) removeLocked(e *list.Element) {
+	delete(s.seen, e.Value.(windowEntry[K]).key)
+	s.order.Remove(e)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPlayDeduper_Window(t *testing.T) {
+	d := newPlayDeduper(2*time.Second, 100)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	alice := playKey{trackID: 1, client: netip.MustParseAddr("10.0.0.1")}
+	bob := playKey{trackID: 1, client: netip.MustParseAddr("10.0.0.2")}
+	aliceOther := playKey{trackID: 2, client: alice.client}
+
+	if !d.claim(alice) {
+		t.Fatal("first play should be claimed")
+	}
+	if d.claim(alice) {
+		t.Error("repeat within window should be rejected")
+	}
+	if !d.claim(bob) || !d.claim(aliceOther) {
+		t.Error("different client or track should be claimed")
+	}
+
+	now = now.Add(2 * time.Second)
+	if !d.claim(alice) {
+		t.Error("play after window should be claimed")
+	}
+
+	d.release(alice)
+	if !d.claim(alice) {
+		t.Error("released key should be claimable again")
+	}
+}
+
+func TestPlayDeduper_Bounded(t *testing.T) {
+	d := newPlayDeduper(time.Minute, 3)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	client := netip.MustParseAddr("10.0.0.1")
+	for id := int64(1); id <= 10; id++ {
+		now = now.Add(time.Second)
+		d.claim(playKey{trackID: id, client: client})
+		if len(d.seen) > 3 {
+			t.Fatalf("store grew to %d entries, want at most 3", len(d.seen))
+		}
+	}
+
+	// Oldest entries were evicted; the newest is still deduplicated
+	if d.claim(playKey{trackID: 10, client: client}) {
+		t.Error("most recent key should still be remembered")
+	}
+	if !d.claim(playKey{trackID: 1, client: client}) {
+		t.Error("evicted key should be claimable")
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+// Bounds on GET /api/discover?limit=
+const (
+	DefaultDiscoverTracks = 20
+	MaxDiscoverTracks     = 100
+)
+
+// getDiscover serves GET /api/discover?moods=focus,calm&weights=focus:3:
+// up to limit tracks (1-100, default 20) blended from several moods, each
+// contributing in proportion to its weight. Moods may be listed in moods,
+// weights, or both; a mood without a weight counts 1. Every call draws
+// anew, so nothing is cached.
+func (h *Handler) getDiscover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	weights, err := parseMoodWeights(q)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidWeights, err.Error())
+		return
+	}
+	for _, mw := range weights {
+		if !h.servesMood(mw.Mood) {
+			writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood: "+mw.Mood, h.servedMoodDetails(mw.Mood))
+			return
+		}
+	}
+	if len(weights) == 0 {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidMoods, "moods or weights must list at least one mood")
+		return
+	}
+	size := DefaultDiscoverTracks
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > MaxDiscoverTracks {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, "limit must be an integer 1-"+strconv.Itoa(MaxDiscoverTracks))
+			return
+		}
+		size = n
+	}
+
+	tracks, err := h.radio.GetDiscoverPlaylist(r.Context(), weights, size)
+	if r.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching discover playlist: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if tracks == nil {
+		tracks = []*inventory.Track{}
+	}
+	for _, track := range tracks {
+		h.resolveAudioURL(track)
+	}
+
+	hidden, err := h.hiddenTracks(r)
+	if err != nil {
+		log.Printf("Error fetching hidden tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	// Tracks keep their own mood; none count as borrowed
+	playlist := withoutHidden(h.toPlaylistTracks("", tracks), hidden)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	setPlaylistID(w, playlist)
+	h.setPrefetchLink(w, playlist)
+	if err := json.NewEncoder(w).Encode(playlist); err != nil {
+		log.Printf("Error encoding discover playlist: %v", err)
+	}
+}
+
+// parseMoodWeights reads ?moods=a,b and ?weights=a:3,c:1 into one weight per
+// mood, in order of first mention. Listed moods without a weight get 1;
+// weights must be positive numbers, and a mood may be weighted only once.
+// Mood names are checked by the caller.
+func parseMoodWeights(q url.Values) ([]radio.MoodWeight, error) {
+	var weights []radio.MoodWeight
+	index := func(mood string) int {
+		return slices.IndexFunc(weights, func(mw radio.MoodWeight) bool { return mw.Mood == mood })
+	}
+	for mood := range strings.SplitSeq(q.Get("moods"), ",") {
+		if mood = strings.TrimSpace(mood); mood != "" && index(mood) < 0 {
+			weights = append(weights, radio.MoodWeight{Mood: mood, Weight: 1})
+		}
+	}
+
+	weighted := map[string]bool{}
+	for pair := range strings.SplitSeq(q.Get("weights"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		mood, raw, ok := strings.Cut(pair, ":")
+		mood = strings.TrimSpace(mood)
+		if !ok || mood == "" {
+			return nil, fmt.Errorf("weights must be mood:weight pairs, got %q", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || !(weight > 0) || math.IsInf(weight, 1) {
+			return nil, fmt.Errorf("weight of %s must be a positive number, got %q", mood, raw)
+		}
+		if weighted[mood] {
+			return nil, fmt.Errorf("%s is weighted more than once", mood)
+		}
+		weighted[mood] = true
+		if i := index(mood); i >= 0 {
+			weights[i].Weight = weight
+		} else {
+			weights = append(weights, radio.MoodWeight{Mood: mood, Weight: weight})
+		}
+	}
+	return weights, nil
+}
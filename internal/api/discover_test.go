@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestGetDiscover_Weighted(t *testing.T) {
+	repo := setupTestDB(t)
+	seed := func(mood string, n int) {
+		t.Helper()
+		for i := range n {
+			title := fmt.Sprintf("%s extra %d", mood, i)
+			track := &inventory.Track{FilePath: fmt.Sprintf("%s/extra%d.mp3", mood, i), Title: &title, Mood: mood, DurationSeconds: 180, Status: inventory.StatusApproved}
+			if _, err := repo.InsertTrack(track); err != nil {
+				t.Fatalf("InsertTrack failed: %v", err)
+			}
+		}
+	}
+	seed("focus", 28)
+	seed("calm", 29)
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func(query string) []PlaylistTrack {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/discover?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200 (%s)", query, w.Code, w.Body.String())
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("Cache-Control = %q, want no-store", cc)
+		}
+		var playlist []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&playlist); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		return playlist
+	}
+	count := func(playlist []PlaylistTrack) map[string]int {
+		moods := map[string]int{}
+		for _, track := range playlist {
+			mood, _, _ := strings.Cut(track.FilePath, "/")
+			moods[mood]++
+		}
+		return moods
+	}
+
+	weighted := get("weights=focus:3,calm:1&limit=20")
+	if len(weighted) != 20 {
+		t.Fatalf("got %d tracks, want 20", len(weighted))
+	}
+	if got := count(weighted); got["focus"] != 15 || got["calm"] != 5 {
+		t.Errorf("moods = %v, want focus 15 and calm 5 at 3:1", got)
+	}
+
+	// Without weights the moods share equally
+	if got := count(get("moods=focus,calm&limit=20")); got["focus"] != 10 || got["calm"] != 10 {
+		t.Errorf("moods = %v, want 10 each", got)
+	}
+}
+
+func TestGetDiscover_Errors(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		query    string
+		wantCode int
+		wantErr  string
+	}{
+		{"", http.StatusBadRequest, CodeInvalidMoods},
+		{"moods=focus,disco", http.StatusNotFound, CodeMoodNotFound},
+		{"weights=disco:2", http.StatusNotFound, CodeMoodNotFound},
+		{"weights=focus", http.StatusBadRequest, CodeInvalidWeights},
+		{"weights=focus:0", http.StatusBadRequest, CodeInvalidWeights},
+		{"weights=focus:-1", http.StatusBadRequest, CodeInvalidWeights},
+		{"weights=focus:lots", http.StatusBadRequest, CodeInvalidWeights},
+		{"weights=focus:NaN", http.StatusBadRequest, CodeInvalidWeights},
+		{"weights=focus:1,focus:2", http.StatusBadRequest, CodeInvalidWeights},
+		{"moods=focus&limit=0", http.StatusBadRequest, CodeInvalidLimit},
+		{"moods=focus&limit=101", http.StatusBadRequest, CodeInvalidLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/discover?"+tt.query, nil))
+			if w.Code != tt.wantCode || errorCode(t, w) != tt.wantErr {
+				t.Errorf("status = %d, want %d %s (%s)", w.Code, tt.wantCode, tt.wantErr, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestParseMoodWeights(t *testing.T) {
+	q := url.Values{"moods": {"focus, calm"}, "weights": {"calm:2.5,energize:4"}}
+	got, err := parseMoodWeights(q)
+	if err != nil {
+		t.Fatalf("parseMoodWeights failed: %v", err)
+	}
+	want := []radio.MoodWeight{{Mood: "focus", Weight: 1}, {Mood: "calm", Weight: 2.5}, {Mood: "energize", Weight: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("weights = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+// audioContentTypes covers audio extensions Go's built-in MIME table lacks,
+// so the Content-Type doesn't depend on the host's mime.types
+var audioContentTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+}
+
+// downloadTrack serves GET /api/tracks/{id}/download: the track's original
+// audio file as an attachment named "Artist - Title.ext", with Range
+// support. The caller needs an admin bearer token, or the exp and t query
+// values of a signed audio URL for the track's file. A file missing from
+// disk is a 404 and flags the track in GET /api/admin/tracks/invalid.
+func (h *Handler) downloadTrack(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.audioRoot == "" || (len(h.adminTokens) == 0 && h.signer == nil) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+
+	token, hasBearer := bearerToken(r)
+	if _, valid := h.validAdminToken(token); hasBearer && !valid {
+		metrics.Get().RecordAdminAuthFailure()
+		w.Header().Set("WWW-Authenticate", adminChallenge+`, error="invalid_token"`)
+		writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid bearer token")
+		return
+	}
+	if !hasBearer && h.signer == nil {
+		w.Header().Set("WWW-Authenticate", adminChallenge)
+		writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Bearer token required")
+		return
+	}
+
+	track, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error loading track %d for download: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if !hasBearer {
+		// An unknown ID fails like a bad token, so IDs can't be probed
+		if track == nil || h.signer.Verify(track.FilePath, r.URL.Query()) != nil {
+			writeError(w, r, http.StatusForbidden, CodeForbidden, "Valid download token required")
+			return
+		}
+	}
+	if track == nil {
+		writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		return
+	}
+
+	f, info, err := h.openAudioFile(track.FilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Warning: audio file for track %d missing: %s", track.ID, track.FilePath)
+		if err := h.repo.SetFileMissing(track.ID, h.now()); err != nil {
+			log.Printf("Error flagging missing file for track %d: %v", track.ID, err)
+		}
+		writeError(w, r, http.StatusNotFound, CodeAudioFileMissing, "Audio file not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening audio file for track %d: %v", track.ID, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	if track.FileMissingAt != nil {
+		if err := h.repo.SetFileMissing(track.ID, time.Time{}); err != nil {
+			log.Printf("Error clearing missing file flag for track %d: %v", track.ID, err)
+		}
+	}
+
+	name := downloadFilename(track)
+	contentType := audioContentTypes[path.Ext(name)]
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	// Resumed and segmented transfers send Range; count only whole downloads
+	if r.Method == http.MethodGet && r.Header.Get("Range") == "" {
+		metrics.Get().RecordDownload()
+	}
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// openAudioFile opens a track's file under the audio root. An unsafe path
+// or a directory is reported as fs.ErrNotExist, like a missing file.
+func (h *Handler) openAudioFile(filePath string) (*os.File, fs.FileInfo, error) {
+	local, err := h.localAudioPath(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("%s is a directory: %w", filePath, fs.ErrNotExist)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// localAudioPath maps a track's file path to the file under the audio root.
+// File paths come from the database, but still must stay under the root.
+func (h *Handler) localAudioPath(filePath string) (string, error) {
+	rel, err := filepath.Localize(strings.TrimPrefix(filePath, "/"))
+	if err != nil {
+		return "", fmt.Errorf("unsafe file path %q: %w", filePath, err)
+	}
+	return filepath.Join(h.audioRoot, rel), nil
+}
+
+// downloadFilename names a track's download "Artist - Title.ext", falling
+// back to the file's own name without a title
+func downloadFilename(track *inventory.Track) string {
+	ext := strings.ToLower(path.Ext(track.FilePath))
+	name := strings.TrimSuffix(path.Base(track.FilePath), path.Ext(track.FilePath))
+	if track.Title != nil && strings.TrimSpace(*track.Title) != "" {
+		name = strings.TrimSpace(*track.Title)
+	}
+	if track.Artist != nil && strings.TrimSpace(*track.Artist) != "" {
+		name = strings.TrimSpace(*track.Artist) + " - " + name
+	}
+	return name + ext
+}
+
+// contentDisposition builds an attachment header for filename. Control
+// characters are dropped and path separators become dashes. Non-ASCII names
+// get an ASCII filename fallback plus the exact name in an RFC 5987
+// filename* parameter.
+func contentDisposition(filename string) string {
+	var clean, fallback strings.Builder
+	for _, r := range filename {
+		switch {
+		case r < 0x20 || r == 0x7F:
+			continue
+		case r == '/' || r == '\\':
+			r = '-'
+		}
+		clean.WriteRune(r)
+		if r > 0x7E || r == '"' {
+			fallback.WriteByte('_')
+		} else {
+			fallback.WriteRune(r)
+		}
+	}
+
+	header := `attachment; filename="` + fallback.String() + `"`
+	if fallback.String() != clean.String() {
+		header += "; filename*=UTF-8''" + encodeRFC5987(clean.String())
+	}
+	return header
+}
+
+// encodeRFC5987 percent-encodes s as an RFC 5987 ext-value, keeping only
+// attr-chars literal
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0F])
+	}
+	return b.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/audio"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/metrics"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name, filename, want string
+	}{
+		{"ascii", "Nova - Alpha Waves.mp3", `attachment; filename="Nova - Alpha Waves.mp3"`},
+		{"unicode", "Björk - Jóga.mp3", `attachment; filename="Bj_rk - J_ga.mp3"; filename*=UTF-8''Bj%C3%B6rk%20-%20J%C3%B3ga.mp3`},
+		{"cjk", "夜.mp3", `attachment; filename="_.mp3"; filename*=UTF-8''%E5%A4%9C.mp3`},
+		{"quotes", `Say "Hi".mp3`, `attachment; filename="Say _Hi_.mp3"; filename*=UTF-8''Say%20%22Hi%22.mp3`},
+		{"separators and controls", "AC/DC\\Live\r\n.mp3", `attachment; filename="AC-DC-Live.mp3"`},
+		{"header injection", "a.mp3\r\nSet-Cookie: x=1", `attachment; filename="a.mp3Set-Cookie: x=1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentDisposition(tt.filename); got != tt.want {
+				t.Errorf("contentDisposition(%q) =\n  %s\nwant\n  %s", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFilename(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		track inventory.Track
+		want  string
+	}{
+		{"artist and title", inventory.Track{FilePath: "focus/a1b2.MP3", Artist: str("Nova"), Title: str("Alpha")}, "Nova - Alpha.mp3"},
+		{"no artist", inventory.Track{FilePath: "focus/a1b2.mp3", Title: str("Alpha")}, "Alpha.mp3"},
+		{"blank title", inventory.Track{FilePath: "focus/a1b2.wav", Artist: str("Nova"), Title: str(" ")}, "Nova - a1b2.wav"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := downloadFilename(&tt.track); got != tt.want {
+				t.Errorf("downloadFilename = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// setupDownloadHandler serves the seeded tracks with track 1's file (1000
+// bytes) on disk and track 2's missing
+func setupDownloadHandler(t *testing.T) (*Handler, *http.ServeMux, *inventory.Repository, string, []byte) {
+	t.Helper()
+	repo := setupTestDB(t)
+	root := t.TempDir()
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	if err := os.MkdirAll(filepath.Join(root, "focus"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "focus", "track1.mp3"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetAudioRoot(root)
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return h, mux, repo, root, content
+}
+
+func downloads() uint64 {
+	return metrics.Get().Snapshot()["downloads_total"].(uint64)
+}
+
+func TestDownloadTrack(t *testing.T) {
+	_, mux, _, _, content := setupDownloadHandler(t)
+
+	before := downloads()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/tracks/1/download", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if !bytes.Equal(w.Body.Bytes(), content) {
+		t.Errorf("body is %d bytes, want the 1000-byte file", w.Body.Len())
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":        "audio/mpeg",
+		"Content-Length":      "1000",
+		"Content-Disposition": `attachment; filename="Drift FM - Focus Track 1.mp3"`,
+		"Accept-Ranges":       "bytes",
+	}
+	for name, want := range wantHeaders {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if got := downloads() - before; got != 1 {
+		t.Errorf("downloads_total grew by %d, want 1", got)
+	}
+
+	t.Run("range", func(t *testing.T) {
+		before := downloads()
+		req := asAdmin(httptest.NewRequest(http.MethodGet, "/api/tracks/1/download", nil))
+		req.Header.Set("Range", "bytes=100-199")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("status = %d, want 206", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), content[100:200]) {
+			t.Errorf("range body = %q, want bytes 100-199", w.Body.String())
+		}
+		if got := w.Header().Get("Content-Range"); got != "bytes 100-199/1000" {
+			t.Errorf("Content-Range = %q", got)
+		}
+		if w.Header().Get("Content-Disposition") == "" {
+			t.Error("range response lacks Content-Disposition")
+		}
+		if downloads() != before {
+			t.Error("a range request counted as a download")
+		}
+	})
+
+	t.Run("unsatisfiable range", func(t *testing.T) {
+		req := asAdmin(httptest.NewRequest(http.MethodGet, "/api/tracks/1/download", nil))
+		req.Header.Set("Range", "bytes=5000-")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("status = %d, want 416", w.Code)
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		before := downloads()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodHead, "/api/tracks/1/download", nil)))
+		if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "1000" {
+			t.Errorf("HEAD = %d with %d body bytes, Content-Length %q", w.Code, w.Body.Len(), w.Header().Get("Content-Length"))
+		}
+		if downloads() != before {
+			t.Error("HEAD counted as a download")
+		}
+	})
+}
+
+func TestDownloadTrack_MissingFile(t *testing.T) {
+	_, mux, repo, root, _ := setupDownloadHandler(t)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/tracks/2/download", nil)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+	if code := errorCode(t, w); code != CodeAudioFileMissing {
+		t.Errorf("code = %q, want %q", code, CodeAudioFileMissing)
+	}
+
+	// The track is flagged in the integrity report
+	if track, _ := repo.GetByID(2); track.FileMissingAt == nil {
+		t.Error("track 2 not flagged as missing its file")
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/tracks/invalid", nil)))
+	var invalid []inventory.Track
+	if err := json.NewDecoder(w.Body).Decode(&invalid); err != nil {
+		t.Fatalf("failed to decode invalid tracks: %v", err)
+	}
+	if len(invalid) != 1 || invalid[0].ID != 2 || invalid[0].FileMissingAt == nil {
+		t.Errorf("invalid tracks = %+v, want track 2 with file_missing_at", invalid)
+	}
+
+	// Restoring the file clears the flag on the next download
+	if err := os.WriteFile(filepath.Join(root, "focus", "track2.mp3"), []byte("restored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/tracks/2/download", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status after restoring = %d, want 200", w.Code)
+	}
+	if track, _ := repo.GetByID(2); track.FileMissingAt != nil {
+		t.Error("missing file flag not cleared after a download")
+	}
+}
+
+func TestDownloadTrack_Auth(t *testing.T) {
+	h, mux, _, _, _ := setupDownloadHandler(t)
+
+	get := func(target, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("/api/tracks/1/download", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no credentials = %d, want 401", w.Code)
+	}
+	if w := get("/api/tracks/1/download", "Bearer wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong bearer = %d, want 401", w.Code)
+	}
+	if w := get("/api/tracks/999/download", "Bearer "+testAdminToken); w.Code != http.StatusNotFound || errorCode(t, w) != CodeTrackNotFound {
+		t.Errorf("unknown track as admin = %d, want 404 track_not_found", w.Code)
+	}
+
+	// A signed audio URL's token also unlocks the download of that file
+	signer := audio.NewSigner([]byte("0123456789abcdef0123456789abcdef"), "/audio")
+	h.SetURLSigner(signer, time.Hour)
+	token := func(filePath string) string {
+		signed, err := signer.SignedURL(filePath, time.Hour)
+		if err != nil {
+			t.Fatalf("SignedURL failed: %v", err)
+		}
+		u, _ := url.Parse(signed)
+		return u.RawQuery
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{"token for the file", "/api/tracks/1/download?" + token("focus/track1.mp3"), http.StatusOK},
+		{"token for another file", "/api/tracks/1/download?" + token("focus/track2.mp3"), http.StatusForbidden},
+		{"no token", "/api/tracks/1/download", http.StatusForbidden},
+		{"unknown track", "/api/tracks/999/download?" + token("focus/track1.mp3"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := get(tt.target, ""); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/tracks/1/download", nil)))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", w.Code)
+	}
+
+	h.SetAudioRoot("")
+	if w := get("/api/tracks/1/download", "Bearer "+testAdminToken); w.Code != http.StatusNotFound {
+		t.Errorf("without an audio root = %d, want 404", w.Code)
+	}
+}
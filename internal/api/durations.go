@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/probe"
+)
+
+// DurationOutlier is a flagged track with the admin route that re-probes
+// its file, when local audio is configured
+type DurationOutlier struct {
+	inventory.DurationOutlier
+	ReprobeURL string `json:"reprobe_url,omitempty"`
+}
+
+// DurationReport is the response of GET /api/admin/reports/durations
+type DurationReport struct {
+	MaxSeconds int                       `json:"max_seconds"`
+	Moods      []inventory.MoodDurations `json:"moods"`
+	Outliers   []DurationOutlier         `json:"outliers"`
+}
+
+// SetMaxTrackDuration sets the duration past which the duration report
+// flags a track (default inventory.DefaultMaxTrackSeconds)
+func (h *Handler) SetMaxTrackDuration(d time.Duration) {
+	h.maxTrackSeconds = int(d / time.Second)
+}
+
+// durationReport serves GET /api/admin/reports/durations: a histogram of
+// track durations per mood, and the tracks whose duration is zero,
+// negative, or past the configured maximum, each with the route that fixes
+// it by re-probing.
+func (h *Handler) durationReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	report, err := h.repo.DurationReport(h.maxTrackSeconds)
+	if err != nil {
+		log.Printf("Error building duration report: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	resp := DurationReport{
+		MaxSeconds: report.MaxSeconds,
+		Moods:      report.Moods,
+		Outliers:   make([]DurationOutlier, len(report.Outliers)),
+	}
+	for i, o := range report.Outliers {
+		resp.Outliers[i].DurationOutlier = o
+		if h.audioRoot != "" {
+			resp.Outliers[i].ReprobeURL = "/api/admin/tracks/" + strconv.FormatInt(o.Track.ID, 10) + "/reprobe"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding duration report: %v", err)
+	}
+}
+
+// reprobeTrack serves POST /api/admin/tracks/{id}/reprobe: it measures the
+// track's local audio file again and stores the duration, returning the
+// updated track. A missing file is a 404 and flags the track like a failed
+// download; a file the probe can't measure is a 422.
+func (h *Handler) reprobeTrack(w http.ResponseWriter, r *http.Request, id int64) {
+	if h.audioRoot == "" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+
+	track, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error loading track %d for re-probe: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track == nil {
+		writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		return
+	}
+
+	seconds, err := h.probeDuration(track.FilePath)
+	switch {
+	case err == nil:
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("Warning: audio file for track %d missing: %s", track.ID, track.FilePath)
+		if err := h.repo.SetFileMissing(track.ID, h.now()); err != nil {
+			log.Printf("Error flagging missing file for track %d: %v", track.ID, err)
+		}
+		writeError(w, r, http.StatusNotFound, CodeAudioFileMissing, "Audio file not found")
+		return
+	case errors.Is(err, probe.ErrUnsupported):
+		writeError(w, r, http.StatusUnprocessableEntity, CodeUnmeasurableAudio, err.Error())
+		return
+	default:
+		log.Printf("Error re-probing track %d: %v", track.ID, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	if err := h.repo.SetDuration(track.ID, seconds); err != nil {
+		log.Printf("Error setting duration of track %d: %v", track.ID, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track.FileMissingAt != nil {
+		if err := h.repo.SetFileMissing(track.ID, time.Time{}); err != nil {
+			log.Printf("Error clearing missing file flag for track %d: %v", track.ID, err)
+		}
+	}
+	log.Printf("Re-probed track %d: duration %ds -> %ds", track.ID, track.DurationSeconds, seconds)
+	// Playlists carry durations and the moods list totals them
+	h.invalidatePlaylists(track.Mood)
+
+	track, err = h.repo.GetByID(id)
+	if err != nil || track == nil {
+		log.Printf("Error reloading track %d after re-probe: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(track); err != nil {
+		log.Printf("Error encoding track %d: %v", id, err)
+	}
+}
+
+// probeDuration measures a file under the audio root in whole seconds. An
+// unsafe path is reported as fs.ErrNotExist, and a file without measurable
+// audio as probe.ErrUnsupported.
+func (h *Handler) probeDuration(filePath string) (int, error) {
+	local, err := h.localAudioPath(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	}
+	silence, err := probe.DetectSilence(local)
+	if err != nil {
+		return 0, err
+	}
+	seconds := int(math.Round(silence.Duration.Seconds()))
+	if seconds < 1 {
+		return 0, fmt.Errorf("%w: under a second of audio", probe.ErrUnsupported)
+	}
+	return seconds, nil
+}
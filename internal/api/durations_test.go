@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestDurationReport(t *testing.T) {
+	repo := setupTestDB(t)
+	insert := func(filePath string, seconds int) int64 {
+		t.Helper()
+		title := filepath.Base(filePath)
+		id, err := repo.InsertTrack(&inventory.Track{FilePath: filePath, Title: &title, Mood: "focus", DurationSeconds: seconds, Status: inventory.StatusApproved})
+		if err != nil {
+			t.Fatalf("InsertTrack failed: %v", err)
+		}
+		return id
+	}
+	zero := insert("focus/zero.mp3", 0)
+	negative := insert("focus/negative.mp3", -300)
+	long := insert("focus/long.mp3", 3600)
+
+	root := t.TempDir()
+	// 115 frames of 1152/44100 s each: 3.004s
+	writeTestMP3(t, filepath.Join(root, "focus", "zero.mp3"), 0, 115, 0)
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetAudioRoot(root)
+	h.SetMaxTrackDuration(time.Hour - time.Second)
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	report := func() DurationReport {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/reports/durations", nil)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+		var resp DurationReport
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode report: %v", err)
+		}
+		return resp
+	}
+
+	resp := report()
+	if resp.MaxSeconds != 3599 {
+		t.Errorf("max_seconds = %d, want 3599", resp.MaxSeconds)
+	}
+	want := map[int64]string{zero: inventory.OutlierZero, negative: inventory.OutlierNegative, long: inventory.OutlierTooLong}
+	if len(resp.Outliers) != len(want) {
+		t.Fatalf("got %d outliers, want %d: %+v", len(resp.Outliers), len(want), resp.Outliers)
+	}
+	for _, o := range resp.Outliers {
+		if o.Reason != want[o.Track.ID] {
+			t.Errorf("track %d flagged %q, want %q", o.Track.ID, o.Reason, want[o.Track.ID])
+		}
+	}
+	if got := resp.Outliers[0].ReprobeURL; got != "/api/admin/tracks/4/reprobe" {
+		t.Errorf("reprobe_url = %q, want /api/admin/tracks/4/reprobe", got)
+	}
+
+	// Bad durations don't drag the moods list total down
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods", nil))
+	var moods []MoodInfo
+	if err := json.NewDecoder(w.Body).Decode(&moods); err != nil {
+		t.Fatalf("failed to decode moods: %v", err)
+	}
+	i := slices.IndexFunc(moods, func(m MoodInfo) bool { return m.Name == "focus" })
+	if i < 0 || moods[i].TotalMins != 67 { // 180 + 240 + 3600 seconds
+		t.Errorf("moods = %+v, want focus totalling 67 minutes", moods)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/tracks/4/reprobe", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reprobe status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var track inventory.Track
+	if err := json.NewDecoder(w.Body).Decode(&track); err != nil {
+		t.Fatalf("failed to decode track: %v", err)
+	}
+	if track.DurationSeconds != 3 {
+		t.Errorf("re-probed duration = %d, want 3", track.DurationSeconds)
+	}
+	if resp := report(); len(resp.Outliers) != 2 {
+		t.Errorf("got %d outliers after re-probe, want 2", len(resp.Outliers))
+	}
+
+	// The negative track's file doesn't exist
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/tracks/5/reprobe", nil)))
+	if w.Code != http.StatusNotFound || errorCode(t, w) != CodeAudioFileMissing {
+		t.Errorf("missing file: status = %d, want 404 %s", w.Code, CodeAudioFileMissing)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/tracks/4/reprobe", nil)))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET reprobe status = %d, want 405", w.Code)
+	}
+}
+
+func TestReprobeWithoutAudioRoot(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/tracks/1/reprobe", nil)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// Error codes are stable identifiers clients can switch on; messages may change.
+const (
+	CodeNotFound          = "not_found"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeForbidden         = "forbidden"
+	CodeUnauthorized      = "unauthorized"
+	CodeCSRF              = "csrf_failed"
+	CodeNoSession         = "no_session"
+	CodeInternal          = "internal_error"
+	CodeMoodNotFound      = "mood_not_found"
+	CodeStationNotFound   = "station_not_found"
+	CodeTrackNotFound     = "track_not_found"
+	CodeTrackNotPending   = "track_not_pending"
+	CodeInvalidTrackID    = "invalid_track_id"
+	CodeInvalidTags       = "invalid_tags"
+	CodeInvalidEventType  = "invalid_event_type"
+	CodeInvalidReason     = "invalid_reason"
+	CodeInvalidSessionID  = "invalid_session_id"
+	CodeInvalidPlaylistID = "invalid_playlist_id"
+	CodeInvalidClient     = "invalid_client"
+	CodeInvalidPosition   = "invalid_position"
+	CodeInvalidJSON       = "invalid_json"
+	CodeBodyTooLarge      = "body_too_large"
+	CodeInvalidField      = "invalid_field"
+	CodeInvalidDays       = "invalid_days"
+	CodeInvalidTimeRange  = "invalid_time_range"
+	CodeInvalidLimit      = "invalid_limit"
+	CodeInvalidOffset     = "invalid_offset"
+	CodeInvalidPage       = "invalid_page"
+	CodeInvalidStatus     = "invalid_status"
+	CodeInvalidMoods      = "invalid_moods"
+	CodeInvalidSort       = "invalid_sort"
+	CodeInvalidIntensity  = "invalid_intensity"
+	CodeInvalidBPM        = "invalid_bpm"
+	CodeInvalidStrategy   = "invalid_strategy"
+	CodeInvalidMode       = "invalid_mode"
+	CodeInvalidSteps      = "invalid_steps"
+	CodeInvalidBoolean    = "invalid_boolean"
+	CodeInvalidWeights    = "invalid_weights"
+	CodePlayNotRecorded   = "play_not_recorded"
+	CodeQueueFull         = "listen_queue_full"
+	CodeDatabaseBusy      = "database_busy"
+	CodeInvalidRoom       = "invalid_room"
+	CodeRoomFull          = "room_full"
+	CodeTooManyRooms      = "too_many_rooms"
+	CodeJobRunning        = "job_running"
+	CodeAudioFileMissing  = "audio_file_missing"
+	CodeUnmeasurableAudio = "unmeasurable_audio"
+)
+
+// requestIDHeader carries a caller-supplied or generated request ID
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds a caller-supplied request ID echoed back
+const maxRequestIDLength = 64
+
+// ErrorBody is the payload inside an error response
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+	Details   any    `json:"details,omitempty"` // code-specific hints, e.g. UnknownMoodDetails
+}
+
+// ErrorResponse is the JSON envelope for all API errors
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// writeError writes a JSON error response with a stable code. The request ID
+// is taken from X-Request-ID when the caller sent a usable one, otherwise
+// generated, and echoed in the response header for log correlation.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeErrorDetails(w, r, status, code, message, nil)
+}
+
+// writeErrorDetails is writeError with a code-specific details object
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details any) {
+	id := requestID(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set(requestIDHeader, id)
+	w.WriteHeader(status)
+
+	resp := ErrorResponse{Error: ErrorBody{Code: code, Message: message, RequestID: id, Details: details}}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding error response: %v", err)
+	}
+}
+
+// requestID returns the caller's X-Request-ID if it is short and printable,
+// or a fresh random ID.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(requestIDHeader); id != "" && len(id) <= maxRequestIDLength && isPrintableASCII(id) {
+		return id
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
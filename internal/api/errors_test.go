@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// errorCode decodes a JSON error response and returns its code
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error body is not JSON: %v (%s)", err, w.Body.String())
+	}
+	return resp.Error.Code
+}
+
+func TestWriteError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/moods/x/playlist", nil)
+	w := httptest.NewRecorder()
+
+	writeError(w, req, http.StatusNotFound, CodeMoodNotFound, "Unknown mood")
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Error.Code != CodeMoodNotFound || resp.Error.Message != "Unknown mood" {
+		t.Errorf("error = %+v", resp.Error)
+	}
+	if resp.Error.RequestID == "" || resp.Error.RequestID != w.Header().Get("X-Request-ID") {
+		t.Errorf("request_id = %q, header = %q; want matching non-empty IDs",
+			resp.Error.RequestID, w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantEcho bool
+	}{
+		{"no header", "", false},
+		{"caller supplied", "abc-123", true},
+		{"too long", strings.Repeat("a", 65), false},
+		{"control chars", "abc\n123", false},
+		{"spaces", "abc 123", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			got := requestID(req)
+			if got == "" {
+				t.Fatal("requestID returned empty string")
+			}
+			if (got == tt.header) != tt.wantEcho {
+				t.Errorf("requestID = %q, echo caller ID = %v, want %v", got, got == tt.header, tt.wantEcho)
+			}
+		})
+	}
+}
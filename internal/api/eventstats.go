@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultEventStatsWindow is the range event counts cover when since is omitted
+const DefaultEventStatsWindow = 24 * time.Hour
+
+// EventStatsResponse counts listen events by type over [since, until)
+type EventStatsResponse struct {
+	Since  time.Time      `json:"since"`
+	Until  time.Time      `json:"until"`
+	Counts map[string]int `json:"counts"`
+}
+
+// eventStats serves GET /api/admin/stats/events?since=...&until=... with
+// RFC3339 bounds. until defaults to now and since to a day before until.
+func (h *Handler) eventStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	until, err := parseTimeParam(r, "until", h.now())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidTimeRange, err.Error())
+		return
+	}
+	since, err := parseTimeParam(r, "since", until.Add(-DefaultEventStatsWindow))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidTimeRange, err.Error())
+		return
+	}
+	if !since.Before(until) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidTimeRange, "since must be before until")
+		return
+	}
+
+	counts, err := h.repo.CountEventsByType(since, until)
+	if err != nil {
+		log.Printf("Error counting listen events: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	resp := EventStatsResponse{Since: since.UTC(), Until: until.UTC(), Counts: counts}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding event stats: %v", err)
+	}
+}
+
+// parseTimeParam reads an RFC3339 query parameter, or returns def when absent
+func parseTimeParam(r *http.Request, name string, def time.Time) (time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestEventStats(t *testing.T) {
+	repo := setupTestDB(t)
+	tx, err := repo.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	for _, evt := range []string{inventory.EventPlay, inventory.EventPlay, inventory.EventSkip} {
+		if err := repo.RecordListenEventTx(tx, inventory.ListenEvent{TrackID: 1, Mood: "focus", EventType: evt}); err != nil {
+			t.Fatalf("RecordListenEventTx failed: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func(query string) EventStatsResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/stats/events"+query, nil)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+		var resp EventStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// The default window is the last day, which holds the events just recorded
+	resp := get("")
+	if want := map[string]int{"play": 2, "skip": 1, "complete": 0}; !maps.Equal(resp.Counts, want) {
+		t.Errorf("counts = %v, want %v", resp.Counts, want)
+	}
+	if got := resp.Until.Sub(resp.Since); got != DefaultEventStatsWindow {
+		t.Errorf("default window = %v, want %v", got, DefaultEventStatsWindow)
+	}
+
+	resp = get("?since=2020-01-01T00:00:00Z&until=2020-01-02T00:00:00Z")
+	if want := map[string]int{"play": 0, "skip": 0, "complete": 0}; !maps.Equal(resp.Counts, want) {
+		t.Errorf("counts in 2020 = %v, want %v", resp.Counts, want)
+	}
+	if !resp.Since.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("since = %v, want 2020-01-01", resp.Since)
+	}
+}
+
+func TestEventStats_Errors(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		admin      bool
+		wantStatus int
+		wantCode   string
+	}{
+		{"not RFC3339", http.MethodGet, "?since=yesterday", true, http.StatusBadRequest, CodeInvalidTimeRange},
+		{"date only", http.MethodGet, "?until=2026-03-01", true, http.StatusBadRequest, CodeInvalidTimeRange},
+		{"since after until", http.MethodGet, "?since=2026-03-02T00:00:00Z&until=2026-03-01T00:00:00Z", true, http.StatusBadRequest, CodeInvalidTimeRange},
+		{"empty range", http.MethodGet, "?since=2026-03-01T00:00:00Z&until=2026-03-01T00:00:00Z", true, http.StatusBadRequest, CodeInvalidTimeRange},
+		{"wrong method", http.MethodPost, "", true, http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"no token", http.MethodGet, "", false, http.StatusUnauthorized, CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/admin/stats/events"+tt.query, nil)
+			if tt.admin {
+				req = asAdmin(req)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
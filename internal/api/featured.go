@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// FeaturedResponse is the track of the day for a UTC date
+type FeaturedResponse struct {
+	Date  string        `json:"date"` // YYYY-MM-DD, UTC
+	Track PlaylistTrack `json:"track"`
+}
+
+// getFeatured serves GET /api/featured: the day's featured track, the same
+// for every listener until midnight UTC. The pick is cached server-side
+// until then; the response is built per request, so a signed audio URL is
+// always fresh, and clients may cache it until midnight or until the URL
+// expires, whichever is sooner.
+func (h *Handler) getFeatured(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	now := h.now().UTC()
+	track, hit, err := h.featured(r.Context(), now)
+	if r.Context().Err() != nil {
+		return // client went away; the shared load still fills the cache
+	}
+	if err != nil {
+		log.Printf("Error fetching featured track: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track == nil {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "No featured track")
+		return
+	}
+	h.resolveAudioURL(track)
+
+	maxAge := untilMidnight(now)
+	if h.signer != nil {
+		maxAge = min(maxAge, h.signedURLTTL)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+	w.Header().Set("X-Cache", cacheState(hit))
+	featured := FeaturedResponse{
+		Date:  now.Format(inventory.FeaturedDate),
+		Track: h.toPlaylistTracks("", []*inventory.Track{track})[0],
+	}
+	if err := json.NewEncoder(w).Encode(featured); err != nil {
+		log.Printf("Error encoding featured track: %v", err)
+	}
+}
+
+// featured returns the day's featured track, reading the pick from the
+// cache or making and caching it; nil when there is no approved track to
+// feature. A picked track removed or unapproved since is replaced for the
+// rest of the day.
+func (h *Handler) featured(ctx context.Context, now time.Time) (*inventory.Track, bool, error) {
+	key := cache.FeaturedKey(now.Format(inventory.FeaturedDate))
+	id, hit, err := h.featuredPicks.GetOrLoad(ctx, key, func(context.Context) (int64, bool, error) {
+		track, err := h.pickFeatured(key, now)
+		if err != nil || track == nil {
+			return 0, false, err
+		}
+		return track.ID, false, nil // pickFeatured cached it until midnight
+	})
+	if err != nil || id == 0 {
+		return nil, hit, err
+	}
+
+	track, err := h.repo.GetByID(id)
+	if err != nil {
+		return nil, hit, err
+	}
+	if track == nil || track.Status != inventory.StatusApproved {
+		track, err = h.pickFeatured(key, now)
+		return track, false, err
+	}
+	return track, hit, nil
+}
+
+// pickFeatured chooses the day's track and caches its ID until midnight
+// UTC, so tracks approved or removed later in the day don't change it
+func (h *Handler) pickFeatured(key string, now time.Time) (*inventory.Track, error) {
+	track, err := h.repo.GetFeaturedTrack(now)
+	if err != nil || track == nil {
+		return nil, err
+	}
+	if err := h.featuredPicks.SetWithTTL(key, track.ID, untilMidnight(now)); err != nil {
+		log.Printf("Warning: failed to cache featured pick %s: %v", key, err)
+	}
+	return track, nil
+}
+
+// untilMidnight returns the time left in now's UTC day
+func untilMidnight(now time.Time) time.Duration {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
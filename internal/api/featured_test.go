@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/audio"
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestGetFeatured(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	now := time.Date(2026, 3, 14, 23, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	get := func() (*httptest.ResponseRecorder, FeaturedResponse) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/featured", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+		var resp FeaturedResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return w, resp
+	}
+
+	w, first := get()
+	if first.Date != "2026-03-14" {
+		t.Errorf("date = %q, want 2026-03-14", first.Date)
+	}
+	if first.Track.ID == 0 || first.Track.AudioURL == "" {
+		t.Errorf("featured track = %+v, want an approved track with an audio URL", first.Track)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("Cache-Control = %q, want max-age until midnight UTC", got)
+	}
+	if got := w.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("X-Cache = %q, want MISS", got)
+	}
+
+	// Later the same day: the cached pick, with less time left on it
+	now = now.Add(30 * time.Minute)
+	w, again := get()
+	if again.Track.ID != first.Track.ID {
+		t.Errorf("same day featured track %d, want %d", again.Track.ID, first.Track.ID)
+	}
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("X-Cache = %q, want HIT", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=1800" {
+		t.Errorf("Cache-Control = %q, want max-age=1800", got)
+	}
+
+	// The pick holds all day, past the default cache TTL and whatever the
+	// library does meanwhile
+	for i := range 5 {
+		track := &inventory.Track{FilePath: fmt.Sprintf("calm/new%d.mp3", i), Mood: "calm", DurationSeconds: 200, Status: inventory.StatusApproved}
+		if _, err := repo.InsertTrack(track); err != nil {
+			t.Fatalf("InsertTrack failed: %v", err)
+		}
+	}
+	now = now.Add(cache.DefaultTTL)
+	w, later := get()
+	if later.Track.ID != first.Track.ID || w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("featured track after library changes = %d (%s), want cached %d", later.Track.ID, w.Header().Get("X-Cache"), first.Track.ID)
+	}
+
+	// A pick rejected since is replaced for the rest of the day
+	if err := repo.UpdateTrack(first.Track.ID, map[string]any{"status": inventory.StatusRejected}, "tester", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	_, replaced := get()
+	if replaced.Track.ID == first.Track.ID {
+		t.Errorf("featured track %d still served after rejection", replaced.Track.ID)
+	}
+	if _, again := get(); again.Track.ID != replaced.Track.ID {
+		t.Errorf("replacement pick = %d, then %d; want it kept", replaced.Track.ID, again.Track.ID)
+	}
+
+	// After midnight UTC the cached pick no longer applies
+	now = now.Add(time.Hour - cache.DefaultTTL)
+	w, next := get()
+	if next.Date != "2026-03-15" {
+		t.Errorf("date = %q, want 2026-03-15", next.Date)
+	}
+	if got := w.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("X-Cache after midnight = %q, want MISS", got)
+	}
+}
+
+func TestGetFeatured_SignedMaxAge(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetURLSigner(audio.NewSigner([]byte("0123456789abcdef0123456789abcdef"), "/audio"), 30*time.Minute)
+	h.now = func() time.Time { return time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC) }
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// Clients mustn't keep the response past its signed audio URL
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/featured", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=1800" {
+		t.Errorf("Cache-Control = %q, want max-age capped at the signed URL TTL", got)
+	}
+}
+
+func TestGetFeatured_NoTracks(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/featured", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+	if code := errorCode(t, w); code != CodeNotFound {
+		t.Errorf("code = %q, want %q", code, CodeNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/featured", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
@@ -1,59 +1,313 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/1mb-dev/driftfm/internal/audio"
 	"github.com/1mb-dev/driftfm/internal/cache"
 	"github.com/1mb-dev/driftfm/internal/inventory"
 	"github.com/1mb-dev/driftfm/internal/metrics"
+	"github.com/1mb-dev/driftfm/internal/mood"
+	"github.com/1mb-dev/driftfm/internal/radio"
+	"github.com/1mb-dev/driftfm/internal/rooms"
+	"github.com/1mb-dev/driftfm/internal/tracing"
 )
 
 // Repository defines the data operations the handler needs
 type Repository interface {
 	GetMoodStats() ([]inventory.MoodStats, error)
+	GetTagCounts() ([]inventory.TagCount, error)
 	GetByID(id int64) (*inventory.Track, error)
-	BeginTx(ctx context.Context) (*sql.Tx, error)
+	UpdateTrack(id int64, fields map[string]any, actor, reason string) error
+	GetStatusHistory(trackID int64) ([]inventory.StatusChange, error)
+	StreamTracks(fn func(*inventory.Track) error) error
+	InvalidTracks() ([]*inventory.Track, error)
+	SetFileMissing(id int64, since time.Time) error
+	GetByArtist(artist string, limit, offset int) ([]*inventory.Track, int, error)
+	GetPending(limit, offset int) ([]*inventory.Track, error)
+	ListTracks(opts inventory.ListOptions) ([]*inventory.Track, int, error)
+	GetMoodMeta() (map[string]inventory.MoodMeta, error)
+	SetMoodMeta(m inventory.MoodMeta) error
+	Approve(id int64, actor string) error
+	GetFeaturedTrack(date time.Time) (*inventory.Track, error)
+	GetRandom(n int) ([]*inventory.Track, error)
+	Duplicates() ([]inventory.DuplicateGroup, error)
+	RecalculatePlayStats(ctx context.Context, strategy string) (*inventory.RecalcResult, error)
+	CheckIntegrity(ctx context.Context, mode string) (*inventory.IntegrityReport, error)
+	GetSessionStats(since time.Time, gap time.Duration) (*inventory.SessionReport, error)
+	CountEventsByType(since, until time.Time) (map[string]int, error)
+	GetPositionFunnel(mood string, since time.Time, maxPosition int) (*inventory.PositionFunnel, error)
+	WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error
 	UpdatePlayStatsTx(tx *sql.Tx, id int64) error
 	RecordListenEventTx(tx *sql.Tx, evt inventory.ListenEvent) error
+	SaveListenerState(s inventory.ListenerState) error
+	GetListenerState(listenerID, mood string, maxAge time.Duration) (*inventory.ListenerState, error)
+	GetLastPosition(clientID string) (*inventory.ResumePoint, error)
+	HideTrack(listenerID string, trackID int64) error
+	UnhideTrack(listenerID string, trackID int64) error
+	HiddenTrackIDs(listenerID string) ([]int64, error)
+	DurationReport(maxSeconds int) (*inventory.DurationReport, error)
+	SetDuration(id int64, seconds int) error
+	LibraryTotals(since time.Time) (*inventory.LibraryTotals, error)
 }
 
 // Radio provides playlist retrieval and play tracking
 type Radio interface {
-	GetPlaylist(mood string, instrumentalOnly bool) ([]*inventory.Track, error)
+	// GetPlaylist returns up to limit tracks, plus any beta tracks among
+	// them; limit 0 selects the default size
+	GetPlaylist(ctx context.Context, mood string, filter inventory.TrackFilter, limit int) ([]*inventory.Track, error)
+	// PlaylistSize returns the length GetPlaylist cuts to for limit (0 = no cut)
+	PlaylistSize(limit int) int
+	// GetTransitionPlaylist returns up to steps tracks drifting from one mood into another
+	GetTransitionPlaylist(ctx context.Context, from, to string, steps int) ([]*inventory.Track, error)
+	// GetDiscoverPlaylist returns up to size tracks blended from moods in proportion to their weights
+	GetDiscoverPlaylist(ctx context.Context, weights []radio.MoodWeight, size int) ([]*inventory.Track, error)
 	RecordPlay(mood string, trackID int64)
+	StateSnapshot(mood string) radio.StateSnapshot
+	ShuffleStats(mood string) radio.ShuffleStats
+}
+
+// EventQueue accepts listen events for asynchronous, batched writing
+type EventQueue interface {
+	Enqueue(ctx context.Context, evt inventory.ListenEvent) bool
+}
+
+// Tasks runs side effects off the request path. Submit may run the task
+// before returning when the pool is saturated.
+type Tasks interface {
+	Submit(task func()) bool
+}
+
+// Cache stores rendered responses. Cached values may come back as
+// json.RawMessage from a shared backend, so they are only re-encoded.
+type Cache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any) error
+	SetWithTTL(key string, value any, ttl time.Duration) error
+	// GetOrLoad coalesces concurrent misses for key into one loader call
+	GetOrLoad(ctx context.Context, key string, loader cache.Loader) (any, bool, error)
+	// EvictMismatch drops an entry a typed view found holding the wrong type
+	EvictMismatch(key string, got any)
+	InvalidateMoods()
+	InvalidateMood(mood string)
+	InvalidateMoodsList()
+}
+
+// Rooms runs listen-together sessions over WebSocket
+type Rooms interface {
+	Serve(w http.ResponseWriter, r *http.Request, room string) error
 }
 
 // Handler holds dependencies for API handlers
 type Handler struct {
-	repo          Repository
-	radio         Radio
-	audioResolver audio.Resolver
-	cache         *cache.Cache
+	repo              Repository
+	radio             Radio
+	audioResolver     audio.Resolver
+	cache             Cache
+	playlists         *cache.Typed[[]PlaylistTrack]         // playlist and transition responses
+	moodLists         *cache.Typed[[]MoodInfo]              // GET /api/moods responses
+	statsCache        *cache.Typed[[]inventory.MoodStats]   // aggregation behind every moods list
+	totalsCache       *cache.Typed[inventory.LibraryTotals] // aggregation behind GET /api/stats/public
+	featuredPicks     *cache.Typed[int64]                   // the day's featured track ID, until midnight UTC
+	moodStatsTTL      time.Duration                         // 0 = aggregate on every moods list miss
+	events            EventQueue                            // nil = write listen events synchronously
+	tasks             Tasks                                 // nil = run side effects inside the request
+	sessionGap        time.Duration
+	maxPosition       int          // first pooled position in the listen-through funnel
+	maxPlaylist       int          // caps ?limit= (0 = unlimited)
+	dedup             *playDeduper // nil = every play is counted
+	rooms             Rooms        // nil = listening rooms disabled
+	build             BuildInfo
+	displayNames      map[string]map[string]string // locale → mood → name
+	moodDisplay       map[string]MoodDisplay       // mood → presentation metadata
+	fallbackMoods     map[string]string            // sparse mood → mood it borrows from
+	signer            *audio.Signer                // nil = unsigned audio URLs
+	signedURLTTL      time.Duration
+	audioRoot         string // local audio files for fade estimation; "" = disabled
+	fades             *fadeEstimator
+	prefetchHint      bool   // Link rel=prefetch for the second track of a playlist
+	shufflePerRequest bool   // skip the playlist cache so every request is shuffled anew
+	lyricsMode        string // how playlists carry lyrics ("" = LyricsOmit)
+	lyricsPreview     int    // characters kept by LyricsPreview
+	progress          *progressThrottle
+	resumeMaxAge      time.Duration
+	bodyLimits        BodyLimits
+	adminTokens       []adminToken    // accepted admin tokens; none = admin API off
+	adminSessionKey   []byte          // signs admin session cookies and CSRF tokens
+	writeDeadline     time.Duration   // bound on synchronous play transactions
+	maxTrackSeconds   int             // duration report flags longer tracks (0 = inventory default)
+	station           string          // recorded on play events; "" = no stations
+	stationMoods      map[string]bool // moods served to listeners; nil = every mood
+	publicStats       []string        // fields of GET /api/stats/public; none = 404
+	now               func() time.Time
 }
 
 // NewHandler creates a new API handler
-func NewHandler(repo Repository, radio Radio, audioResolver audio.Resolver, c *cache.Cache) *Handler {
+func NewHandler(repo Repository, radio Radio, audioResolver audio.Resolver, c Cache) *Handler {
 	return &Handler{
 		repo:          repo,
 		radio:         radio,
 		audioResolver: audioResolver,
 		cache:         c,
+		playlists:     cache.NewTyped[[]PlaylistTrack](c),
+		moodLists:     cache.NewTyped[[]MoodInfo](c),
+		statsCache:    cache.NewTyped[[]inventory.MoodStats](c),
+		totalsCache:   cache.NewTyped[inventory.LibraryTotals](c),
+		featuredPicks: cache.NewTyped[int64](c),
+		moodStatsTTL:  DefaultMoodStatsTTL,
+		sessionGap:    inventory.DefaultSessionGap,
+		maxPosition:   inventory.DefaultMaxPosition,
+		build:         BuildInfo{Version: "dev", GoVersion: runtime.Version()},
+		displayNames:  defaultDisplayNames,
+		fades:         newFadeEstimator(),
+		publicStats:   PublicStatFields,
+		progress:      newProgressThrottle(DefaultProgressInterval, DefaultDedupMaxEntries),
+		resumeMaxAge:  DefaultResumeMaxAge,
+		writeDeadline: inventory.DefaultWriteDeadline,
+		now:           time.Now,
+		bodyLimits: BodyLimits{
+			Events: DefaultEventBodyLimit,
+			Admin:  DefaultAdminBodyLimit,
+			Upload: DefaultUploadBodyLimit,
+		},
+	}
+}
+
+// SetEventQueue switches play recording to async mode: events are queued
+// and the request returns 202 Accepted without waiting for the DB write.
+func (h *Handler) SetEventQueue(q EventQueue) {
+	h.events = q
+}
+
+// SetTasks moves post-commit play side effects (metrics, radio recency)
+// onto a worker pool
+func (h *Handler) SetTasks(t Tasks) {
+	h.tasks = t
+}
+
+// SetWriteDeadline bounds how long a synchronous play write may wait on the
+// database before failing with 503 (0 = no bound beyond the request)
+func (h *Handler) SetWriteDeadline(d time.Duration) {
+	h.writeDeadline = d
+}
+
+// SetSessionGap sets the idle time that ends a listening session in analytics
+func (h *Handler) SetSessionGap(gap time.Duration) {
+	h.sessionGap = gap
+}
+
+// SetMaxPosition sets the playlist position from which the listen-through
+// funnel pools events into one overflow bucket
+func (h *Handler) SetMaxPosition(n int) {
+	h.maxPosition = n
+}
+
+// SetMaxPlaylistSize caps the ?limit= a client may request for a playlist
+func (h *Handler) SetMaxPlaylistSize(n int) {
+	h.maxPlaylist = n
+}
+
+// SetPlayDedup ignores a repeat play of the same track from the same client
+// within window. The store holds at most maxEntries keys (default
+// DefaultDedupMaxEntries); window <= 0 disables.
+func (h *Handler) SetPlayDedup(window time.Duration, maxEntries int) {
+	if window <= 0 {
+		h.dedup = nil
+		return
+	}
+	if maxEntries < 1 {
+		maxEntries = DefaultDedupMaxEntries
 	}
+	h.dedup = newPlayDeduper(window, maxEntries)
+}
+
+// SetFallbackMoods tells cache invalidation which moods pad their playlists
+// from another (see radio.Manager.SetBorrowing), so a change to a fallback
+// mood's tracks also refreshes the moods borrowing from it.
+func (h *Handler) SetFallbackMoods(fallbacks map[string]string) {
+	h.fallbackMoods = fallbacks
+}
+
+// SetRooms enables GET /api/rooms/{room}/ws
+func (h *Handler) SetRooms(r Rooms) {
+	h.rooms = r
+}
+
+// SetDisplayNames replaces the mood display names, keyed by locale then mood.
+// Moods missing from a locale fall back to DefaultLocale, then the identifier.
+func (h *Handler) SetDisplayNames(names map[string]map[string]string) {
+	h.displayNames = names
+}
+
+// SetMoodDisplay sets the configured per-mood color, icon, and description
+// included in GET /api/moods. Metadata set through the admin API overrides
+// it, and moods left without a color get a generated one.
+func (h *Handler) SetMoodDisplay(display map[string]MoodDisplay) {
+	h.moodDisplay = display
+}
+
+// SetURLSigner makes playlists return local audio URLs carrying a token
+// valid for ttl. ttl should outlive the playlist cache entry and playback.
+func (h *Handler) SetURLSigner(s *audio.Signer, ttl time.Duration) {
+	h.signer = s
+	h.signedURLTTL = ttl
+}
+
+// SetBuildInfo sets the version and build time reported by GET /api/version.
+// An empty buildTime is omitted from the response.
+func (h *Handler) SetBuildInfo(version, buildTime string) {
+	h.build.Version = version
+	h.build.BuildTime = buildTime
 }
 
 // RegisterRoutes registers API routes on the given mux
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/moods", h.listMoods)
-	mux.HandleFunc("/api/moods/", h.handleMoods)
-	mux.HandleFunc("/api/tracks/", h.handleTracks)
+	mux.HandleFunc("/api/moods", h.limitBody(bodyEvents, h.listMoods))
+	mux.HandleFunc("/api/moods/", h.limitBody(bodyEvents, h.handleMoods))
+	mux.HandleFunc("/api/tracks/", h.limitBody(bodyEvents, h.handleTracks))
+	mux.HandleFunc("/api/playlists", h.limitBody(bodyEvents, h.getPlaylists))
+	mux.HandleFunc("/api/tags", h.limitBody(bodyEvents, h.listTags))
+	mux.HandleFunc("/api/artists/", h.limitBody(bodyEvents, h.handleArtists))
+	mux.HandleFunc("/api/featured", h.limitBody(bodyEvents, h.getFeatured))
+	mux.HandleFunc("/api/random", h.limitBody(bodyEvents, h.getRandom))
+	mux.HandleFunc("/api/transition", h.limitBody(bodyEvents, h.getTransition))
+	mux.HandleFunc("/api/discover", h.limitBody(bodyEvents, h.getDiscover))
+	mux.HandleFunc("/api/rooms/", h.limitBody(bodyEvents, h.handleRooms))
+	mux.HandleFunc("/api/me/resume", h.limitBody(bodyEvents, h.getResume))
+	mux.HandleFunc("/api/version", h.limitBody(bodyEvents, h.getVersion))
+	mux.HandleFunc("/api/stats/public", h.limitBody(bodyEvents, h.getPublicStats))
+	mux.HandleFunc("/api/admin/", h.admin(notFound))
+	mux.HandleFunc("/api/admin/session", h.admin(h.handleAdminSession))
+	mux.HandleFunc("/api/admin/csrf", h.admin(h.getCSRF))
+	mux.HandleFunc("/api/admin/tracks", h.admin(h.listTracks))
+	mux.HandleFunc("/api/admin/tracks/", h.admin(h.handleAdminTracks))
+	mux.HandleFunc("/api/admin/tracks/export", h.admin(h.exportTracks))
+	mux.HandleFunc("/api/admin/tracks/invalid", h.admin(h.invalidTracks))
+	mux.HandleFunc("/api/admin/duplicates", h.admin(h.duplicateTracks))
+	mux.HandleFunc("/api/admin/pending", h.admin(h.listPending))
+	mux.HandleFunc("/api/admin/moods/", h.admin(h.handleAdminMoods))
+	mux.HandleFunc("/api/admin/radio/", h.admin(h.radioState))
+	mux.HandleFunc("/api/admin/analytics/sessions", h.admin(h.sessionAnalytics))
+	mux.HandleFunc("/api/admin/analytics/positions", h.admin(h.positionAnalytics))
+	mux.HandleFunc("/api/admin/stats/events", h.admin(h.eventStats))
+	mux.HandleFunc("/api/admin/playstats/recalculate", h.admin(h.recalculatePlayStats))
+	mux.HandleFunc("/api/admin/crossfade/estimate", h.admin(h.estimateFades))
+	mux.HandleFunc("/api/admin/db/integrity", h.admin(h.checkIntegrity))
+	mux.HandleFunc("/api/admin/reports/durations", h.admin(h.durationReport))
 }
 
 // MoodInfo contains metadata about a mood
@@ -62,19 +316,55 @@ type MoodInfo struct {
 	DisplayName string  `json:"display_name"`
 	TrackCount  int     `json:"track_count"`
 	TotalMins   float64 `json:"total_minutes"`
+	MoodDisplay
+}
+
+// MoodDisplay is configurable presentation metadata for a mood. Color is
+// always set in GET /api/moods; an empty icon or description is omitted.
+type MoodDisplay struct {
+	Color       string `json:"color,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 func (h *Handler) listMoods(w http.ResponseWriter, r *http.Request) {
 	// Only handle exact /api/moods path
 	if r.URL.Path != "/api/moods" {
-		http.Error(w, "Not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
 		return
 	}
 
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = moodSortName
+	}
+	if !slices.Contains(moodSorts, sortBy) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidSort, "sort must be one of "+strings.Join(moodSorts, ", "))
+		return
+	}
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	// No list is longer than the served moods, so a limit that reaches
+	// their count is no limit and shares the unlimited cache entry
+	if limit >= h.servedMoodCount() {
+		limit = 0
+	}
+
+	locale := requestLocale(r, h.displayNames)
+	cacheKey := moodsCacheKey(locale, sortBy, limit)
+
 	// Check cache first
-	if cached, found := h.cache.Get(cache.KeyMoodsList); found {
+	if cached, found := h.moodLists.Get(cacheKey); found {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Header().Set("Cache-Control", publicMaxAge(cache.MoodsListTTL))
+		w.Header().Set("Vary", "Accept-Language")
 		w.Header().Set("X-Cache", "HIT")
 		if err := json.NewEncoder(w).Encode(cached); err != nil {
 			log.Printf("Error encoding cached moods: %v", err)
@@ -82,50 +372,93 @@ func (h *Handler) listMoods(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	moods, err := h.repo.GetMoodStats()
+	moods, err := h.moodStats()
 	if err != nil {
 		log.Printf("Error fetching moods: %v", err)
-		http.Error(w, "Internal error", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
 		return
 	}
 
-	// Convert to MoodInfo with display names
-	displayNames := map[string]string{
-		"focus":      "Focus",
-		"calm":       "Calm",
-		"late_night": "Late Night",
-		"energize":   "Energize",
+	// Stored metadata is optional; without it moods fall back to config
+	stored, err := h.repo.GetMoodMeta()
+	if err != nil {
+		log.Printf("Warning: failed to load mood metadata: %v", err)
 	}
 
-	var result []MoodInfo
+	// Convert to MoodInfo with display names in the request's locale; an
+	// empty inventory is [] rather than null, and cached like any other
+	result := make([]MoodInfo, 0, len(moods))
 	for _, m := range moods {
-		displayName := displayNames[m.Mood]
-		if displayName == "" {
-			displayName = m.Mood
+		if !h.servesMood(m.Mood) {
+			continue
 		}
 		result = append(result, MoodInfo{
 			Name:        m.Mood,
-			DisplayName: displayName,
+			DisplayName: displayName(h.displayNames, locale, m.Mood),
 			TrackCount:  m.TrackCount,
 			TotalMins:   float64(m.TotalSeconds) / 60.0,
+			MoodDisplay: h.resolveMoodDisplay(m.Mood, stored),
 		})
 	}
 
+	sortMoods(result, sortBy)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
 	// Cache the result
-	if err := h.cache.Set(cache.KeyMoodsList, result); err != nil {
+	if err := h.moodLists.Set(cacheKey, result); err != nil {
 		log.Printf("Warning: failed to cache moods list: %v", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Cache-Control", publicMaxAge(cache.MoodsListTTL))
+	w.Header().Set("Vary", "Accept-Language")
 	w.Header().Set("X-Cache", "MISS")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		log.Printf("Error encoding moods: %v", err)
 	}
 }
 
+// Mood list orderings for GET /api/moods?sort=
+const (
+	moodSortName     = "name"     // alphabetical, the default
+	moodSortTracks   = "tracks"   // most tracks first
+	moodSortDuration = "duration" // most total minutes first
+)
+
+var moodSorts = []string{moodSortName, moodSortTracks, moodSortDuration}
+
+// sortMoods orders moods by sortBy. Ties, and the name order itself, fall
+// back to the mood identifier so the order is stable across requests.
+func sortMoods(moods []MoodInfo, sortBy string) {
+	slices.SortStableFunc(moods, func(a, b MoodInfo) int {
+		var c int
+		switch sortBy {
+		case moodSortTracks:
+			c = cmp.Compare(b.TrackCount, a.TrackCount)
+		case moodSortDuration:
+			c = cmp.Compare(b.TotalMins, a.TotalMins)
+		}
+		return cmp.Or(c, strings.Compare(a.Name, b.Name))
+	})
+}
+
+// moodsCacheKey returns the cache key for a moods list; non-default sort
+// and limit get their own entries under the locale's key.
+func moodsCacheKey(locale, sortBy string, limit int) string {
+	key := cache.MoodsListKey(locale)
+	if sortBy != moodSortName {
+		key += ":sort=" + sortBy
+	}
+	if limit > 0 {
+		key += ":limit=" + strconv.Itoa(limit)
+	}
+	return key
+}
+
 // PlaylistTrack is a slim view of a track for playlist responses.
-// The frontend uses only these 8 fields; dropping the other 12 reduces
+// The frontend uses only these fields; dropping the rest of Track reduces
 // payload size by ~60%.
 type PlaylistTrack struct {
 	ID        int64   `json:"id"`
@@ -136,9 +469,31 @@ type PlaylistTrack struct {
 	Energy    string  `json:"energy"`
 	Intensity *int    `json:"intensity,omitempty"`
 	Lyrics    *string `json:"lyrics,omitempty"`
+	FadeInMs  *int    `json:"fade_in_ms,omitempty"`
+	FadeOutMs *int    `json:"fade_out_ms,omitempty"`
+
+	// HasLyrics is set whether or not Lyrics are sent (radio.playlist_lyrics);
+	// GET /api/tracks/{id} has the full text, as LyricsTruncated previews need
+	HasLyrics       bool `json:"has_lyrics,omitempty"`
+	LyricsTruncated bool `json:"lyrics_truncated,omitempty"`
+
+	// PlaylistID is shared by every track of one generated playlist;
+	// listen events send it back
+	PlaylistID string `json:"playlist_id,omitempty"`
+
+	// BorrowedFrom is set when a sparse mood was padded from a fallback mood
+	BorrowedFrom string `json:"borrowed_from,omitempty"`
+
+	// RolloutPercent is set on the beta tracks of a shared playlist, so the
+	// cached entry carries its own rollouts; withRollout clears it before
+	// the playlist is served
+	RolloutPercent int `json:"rollout_percent,omitempty"`
 }
 
-func toPlaylistTracks(tracks []*inventory.Track) []PlaylistTrack {
+// toPlaylistTracks converts tracks for a playlist of mood, marking tracks
+// borrowed from other moods, with lyrics as radio.playlist_lyrics says. An
+// empty mood marks none.
+func (h *Handler) toPlaylistTracks(mood string, tracks []*inventory.Track) []PlaylistTrack {
 	out := make([]PlaylistTrack, len(tracks))
 	for i, t := range tracks {
 		out[i] = PlaylistTrack{
@@ -150,17 +505,34 @@ func toPlaylistTracks(tracks []*inventory.Track) []PlaylistTrack {
 			Energy:    t.Energy,
 			Intensity: t.Intensity,
 			Lyrics:    t.Lyrics,
+			FadeInMs:  t.FadeInMs,
+			FadeOutMs: t.FadeOutMs,
+
+			PlaylistID: t.PlaylistID,
+		}
+		h.slimLyrics(&out[i])
+		if mood != "" && t.Mood != "" && t.Mood != mood {
+			out[i].BorrowedFrom = t.Mood
 		}
 	}
 	return out
 }
 
 // validMoods contains the known mood identifiers
-var validMoods = map[string]bool{
-	"focus":      true,
-	"calm":       true,
-	"late_night": true,
-	"energize":   true,
+var validMoods = mood.Set()
+
+// moodNames lists the known moods in sorted order
+var moodNames = mood.Names()
+
+// UnknownMoodDetails lets clients self-correct an unrecognized mood
+type UnknownMoodDetails struct {
+	ValidMoods []string `json:"valid_moods"`
+	Suggestion string   `json:"suggestion,omitempty"` // closest valid mood, if near enough
+}
+
+func unknownMoodDetails(mood string) UnknownMoodDetails {
+	suggestion, _ := closestMatch(strings.ToLower(mood), moodNames, maxSuggestDistance)
+	return UnknownMoodDetails{ValidMoods: moodNames, Suggestion: suggestion}
 }
 
 func (h *Handler) handleMoods(w http.ResponseWriter, r *http.Request) {
@@ -169,47 +541,224 @@ func (h *Handler) handleMoods(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(path, "/")
 
 	if len(parts) < 2 || parts[1] != "playlist" {
-		http.Error(w, "Not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
 		return
 	}
 
 	mood := parts[0]
 
-	// Validate mood is a known value
-	if !validMoods[mood] {
-		http.Error(w, "Unknown mood", http.StatusNotFound)
+	// Validate mood is a known value played here
+	if !h.servesMood(mood) {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood", h.servedMoodDetails(mood))
 		return
 	}
 
-	instrumentalOnly := r.URL.Query().Get("instrumental") == "true"
-	h.getPlaylist(w, mood, instrumentalOnly)
-}
+	instrumental, err := parseBool(r.URL.Query(), "instrumental")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBoolean, err.Error())
+		return
+	}
+	filter := inventory.TrackFilter{InstrumentalOnly: instrumental}
 
-func (h *Handler) getPlaylist(w http.ResponseWriter, mood string, instrumentalOnly bool) {
-	// Cache key for mood's playlist (instrumental gets separate cache entry)
-	cacheKey := cache.PlaylistKey(mood)
-	if instrumentalOnly {
-		cacheKey += ":instrumental"
+	// ?tags=piano,rain — a track must carry every listed tag
+	if raw := r.URL.Query().Get("tags"); raw != "" {
+		tags, err := inventory.NormalizeTags(strings.Split(raw, ","))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidTags, err.Error())
+			return
+		}
+		filter.Tags = tags
 	}
 
-	if cached, found := h.cache.Get(cacheKey); found {
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Cache-Control", "public, max-age=60")
-		w.Header().Set("X-Cache", "HIT")
-		if err := json.NewEncoder(w).Encode(cached); err != nil {
-			log.Printf("Error encoding cached playlist: %v", err)
+	// ?intensity_min=3&intensity_max=6 — inclusive, either side optional
+	minIntensity, maxIntensity, err := parseBounds(r.URL.Query(), "intensity", inventory.MinIntensity, inventory.MaxIntensity)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidIntensity, err.Error())
+		return
+	}
+	filter.IntensityMin = minIntensity
+	filter.IntensityMax = maxIntensity
+
+	// ?bpm_min=100&bpm_max=130 — same rules, in beats per minute
+	minBPM, maxBPM, err := parseBounds(r.URL.Query(), "bpm", inventory.MinBPM, inventory.MaxBPM)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBPM, err.Error())
+		return
+	}
+	filter.BPMMin = minBPM
+	filter.BPMMax = maxBPM
+
+	// ?sort=bpm_asc — a fixed order instead of a shuffle
+	if sortBy := r.URL.Query().Get("sort"); sortBy != "" {
+		if !slices.Contains(playlistSorts, sortBy) {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidSort, "sort must be one of "+strings.Join(playlistSorts, ", "))
+			return
 		}
+		filter.Sort = sortBy
+	}
+
+	limit, err := h.parseLimit(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, err.Error())
 		return
 	}
 
-	// Get shuffled playlist
-	tracks, err := h.radio.GetPlaylist(mood, instrumentalOnly)
+	// Beta tracks rolled out to anyone join the shared playlist; each
+	// listener's rollout bucket is applied after the cache
+	filter.IncludeBeta = true
+
+	h.getPlaylist(w, r, mood, filter, limit)
+}
+
+// parseLimit reads ?limit=N, clamped to the configured maximum. Absent is 0,
+// the radio's default size.
+func (h *Handler) parseLimit(q url.Values) (int, error) {
+	raw := q.Get("limit")
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	if h.maxPlaylist > 0 && n > h.maxPlaylist {
+		n = h.maxPlaylist
+	}
+	return n, nil
+}
+
+// parseBool reads a boolean query parameter as strconv.ParseBool does (1, t,
+// true, TRUE, 0, f, false...); absent is false
+func parseBool(q url.Values, name string) (bool, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean (true, false, 1 or 0)", name)
+	}
+	return b, nil
+}
+
+// parseBounds reads the inclusive range <name>_min and <name>_max. Absent
+// bounds are 0; present ones must be integers from lo to hi, min <= max.
+func parseBounds(q url.Values, name string, lo, hi int) (int, int, error) {
+	bounds := [2]int{}
+	for i, param := range []string{name + "_min", name + "_max"} {
+		raw := q.Get(param)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < lo || n > hi {
+			return 0, 0, fmt.Errorf("%s must be an integer %d-%d", param, lo, hi)
+		}
+		bounds[i] = n
+	}
+	if bounds[0] > 0 && bounds[1] > 0 && bounds[0] > bounds[1] {
+		return 0, 0, fmt.Errorf("%s_min must not exceed %s_max", name, name)
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// playlistSorts are the accepted ?sort= values for playlists
+var playlistSorts = []string{inventory.SortBPMAsc, inventory.SortBPMDesc}
+
+// playlistCacheKey returns the cache key for a mood's playlist under a filter
+// and explicit limit. Each combination gets its own entry; tags are already sorted.
+func playlistCacheKey(mood string, filter inventory.TrackFilter, limit int) string {
+	key := playlistKey(mood, filter)
+	if limit > 0 {
+		key = key.With("limit", strconv.Itoa(limit))
+	}
+	return key.String()
+}
+
+// playlistKey starts the cache key of a mood's entries under a filter
+func playlistKey(mood string, filter inventory.TrackFilter) cache.PlaylistKeyBuilder {
+	key := cache.NewPlaylistKey(mood)
+	if filter.InstrumentalOnly {
+		key = key.With("instrumental", "")
+	}
+	if len(filter.Tags) > 0 {
+		key = key.With("tags", strings.Join(filter.Tags, ","))
+	}
+	if filter.IntensityMin > 0 || filter.IntensityMax > 0 {
+		key = key.With("intensity", fmt.Sprintf("%d-%d", filter.IntensityMin, filter.IntensityMax))
+	}
+	if filter.BPMMin > 0 || filter.BPMMax > 0 {
+		key = key.With("bpm", fmt.Sprintf("%d-%d", filter.BPMMin, filter.BPMMax))
+	}
+	if filter.Sort != "" {
+		key = key.With("sort", filter.Sort)
+	}
+	if filter.IncludeBeta {
+		key = key.With("beta", strconv.Itoa(filter.RolloutBucket))
+	}
+	return key
+}
+
+func (h *Handler) getPlaylist(w http.ResponseWriter, r *http.Request, mood string, filter inventory.TrackFilter, limit int) {
+	playlist, hit, err := h.playlist(r.Context(), mood, filter, limit)
+	if r.Context().Err() != nil {
+		return // client went away; the shared load still fills the cache
+	}
 	if err != nil {
 		log.Printf("Error fetching playlist: %v", err)
-		http.Error(w, "Internal error", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
 		return
 	}
 
+	// The shared playlist is personalized after the cache, so listeners
+	// who hid tracks or sit in different rollout buckets still share the
+	// per-mood entry
+	hidden, err := h.hiddenTracks(r)
+	if err != nil {
+		log.Printf("Error fetching hidden tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	playlist, beta := withRollout(withoutHidden(playlist, hidden), listenerID(r), h.radio.PlaylistSize(limit))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", playlistCacheControl(len(hidden) > 0 || beta || h.shufflePerRequest))
+	w.Header().Set("X-Cache", cacheState(hit))
+	setPlaylistID(w, playlist)
+	h.setPrefetchLink(w, playlist)
+	if filter.Sort != "" {
+		// Sorted playlists keep recently played tracks in place
+		w.Header().Set("X-Recency-Demotion", "skipped")
+	}
+	_, span := tracing.Start(r.Context(), "json.encode")
+	defer span.End()
+	if err := json.NewEncoder(w).Encode(playlist); err != nil {
+		log.Printf("Error encoding playlist: %v", err)
+	}
+}
+
+// playlist returns a mood's slim playlist from the cache, or builds and
+// caches it; concurrent misses share one build. hit reports whether it came
+// from the cache.
+func (h *Handler) playlist(ctx context.Context, mood string, filter inventory.TrackFilter, limit int) (playlist []PlaylistTrack, hit bool, err error) {
+	if h.shufflePerRequest {
+		playlist, _, err = h.buildPlaylist(ctx, mood, filter, limit)
+		return playlist, false, err
+	}
+	return h.playlists.GetOrLoad(ctx, playlistCacheKey(mood, filter, limit), func(ctx context.Context) ([]PlaylistTrack, bool, error) {
+		return h.buildPlaylist(ctx, mood, filter, limit)
+	})
+}
+
+// buildPlaylist shuffles a mood's slim playlist, reporting whether it is
+// worth caching
+func (h *Handler) buildPlaylist(ctx context.Context, mood string, filter inventory.TrackFilter, limit int) ([]PlaylistTrack, bool, error) {
+	// Get shuffled playlist
+	tracks, err := h.radio.GetPlaylist(ctx, mood, filter, limit)
+	if err != nil {
+		return nil, false, err
+	}
+
 	// Return empty array instead of null if no tracks
 	if tracks == nil {
 		tracks = []*inventory.Track{}
@@ -217,56 +766,230 @@ func (h *Handler) getPlaylist(w http.ResponseWriter, mood string, instrumentalOn
 
 	// Resolve audio URLs for each track
 	for _, track := range tracks {
-		url, err := h.audioResolver.ResolveURL(track.FilePath)
+		h.resolveAudioURL(track)
+	}
+
+	// Convert to slim playlist payload; only cache non-empty results
+	slim := h.toPlaylistTracks(mood, tracks)
+	for i, track := range tracks {
+		if track.Status == inventory.StatusBeta {
+			slim[i].RolloutPercent = track.RolloutPercent
+		}
+	}
+	return slim, len(slim) > 0, nil
+}
+
+// resolveAudioURL sets track.AudioURL from the audio providers, signed when
+// URL signing is enabled. A failure is logged and leaves the URL empty.
+// Remote URLs the resolver didn't version take theirs from the track's
+// content hash.
+func (h *Handler) resolveAudioURL(track *inventory.Track) {
+	url, err := h.audioResolver.ResolveURL(track.FilePath)
+	if errors.Is(err, audio.ErrNotFound) {
+		log.Printf("Warning: audio for track %d not found in any provider: %s", track.ID, track.FilePath)
+	} else if err != nil {
+		log.Printf("Warning: failed to resolve audio URL for track %d: %v", track.ID, err)
+	}
+	local := strings.HasPrefix(url, "/")
+	// Only paths served by this server's /audio/ route can be checked
+	if h.signer != nil && local {
+		version := audio.URLVersion(url)
+		url, err = h.signer.SignedURL(track.FilePath, h.signedURLTTL)
 		if err != nil {
-			log.Printf("Warning: failed to resolve audio URL for track %d: %v", track.ID, err)
+			log.Printf("Warning: failed to sign audio URL for track %d: %v", track.ID, err)
 		}
-		track.AudioURL = url
+		url = audio.WithVersion(url, version)
 	}
+	if !local && track.ContentHash != nil && audio.URLVersion(url) == "" {
+		url = audio.WithVersion(url, audio.HashVersion(*track.ContentHash))
+	}
+	track.AudioURL = url
+}
 
-	// Convert to slim playlist payload
-	slim := toPlaylistTracks(tracks)
+// playlistCacheControl lets shared caches keep a playlist response unless it
+// was personalized for the listener (hidden tracks, beta rollout) or
+// shuffled for the request alone
+func playlistCacheControl(personalized bool) string {
+	if personalized {
+		return "private, no-store"
+	}
+	return publicMaxAge(cache.PlaylistTTL)
+}
 
-	// Cache the result
-	if len(slim) > 0 {
-		if err := h.cache.Set(cacheKey, slim); err != nil {
-			log.Printf("Warning: failed to cache playlist: %v", err)
+// publicMaxAge is a Cache-Control value letting any cache reuse a response
+// for d, kept in step with the server-side TTL of the entry behind it
+func publicMaxAge(d time.Duration) string {
+	return "public, max-age=" + strconv.Itoa(int(d.Seconds()))
+}
+
+// setPlaylistID names the generated playlist in X-Playlist-ID, for clients
+// that read it once rather than per track
+func setPlaylistID(w http.ResponseWriter, playlist []PlaylistTrack) {
+	if len(playlist) > 0 && playlist[0].PlaylistID != "" {
+		w.Header().Set("X-Playlist-ID", playlist[0].PlaylistID)
+	}
+}
+
+// cacheState is the X-Cache header value for a cache lookup
+func cacheState(hit bool) string {
+	if hit {
+		return "HIT"
+	}
+	return "MISS"
+}
+
+// getPlaylists serves GET /api/playlists?moods=focus,calm&limit=5: each
+// mood's playlist keyed by mood, composed from the per-mood cache entries
+// GET /api/moods/{mood}/playlist?limit= uses.
+func (h *Handler) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var moods []string
+	for mood := range strings.SplitSeq(r.URL.Query().Get("moods"), ",") {
+		mood = strings.TrimSpace(mood)
+		if mood == "" || slices.Contains(moods, mood) {
+			continue
+		}
+		if !h.servesMood(mood) {
+			writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood: "+mood, h.servedMoodDetails(mood))
+			return
 		}
+		moods = append(moods, mood)
+	}
+	if len(moods) == 0 {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidMoods, "moods must list at least one mood")
+		return
+	}
+
+	limit, err := h.parseLimit(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, err.Error())
+		return
+	}
+
+	hidden, err := h.hiddenTracks(r)
+	if err != nil {
+		log.Printf("Error fetching hidden tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	listener := listenerID(r)
+
+	result := make(map[string][]PlaylistTrack, len(moods))
+	allHit := true
+	anyBeta := false
+	for _, mood := range moods {
+		playlist, hit, err := h.playlist(r.Context(), mood, inventory.TrackFilter{IncludeBeta: true}, limit)
+		if r.Context().Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching playlist for %s: %v", mood, err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+			return
+		}
+		playlist, beta := withRollout(withoutHidden(playlist, hidden), listener, h.radio.PlaylistSize(limit))
+		result[mood] = playlist
+		allHit = allHit && hit
+		anyBeta = anyBeta || beta
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "public, max-age=60")
-	w.Header().Set("X-Cache", "MISS")
-	if err := json.NewEncoder(w).Encode(slim); err != nil {
-		log.Printf("Error encoding playlist: %v", err)
+	w.Header().Set("Cache-Control", playlistCacheControl(len(hidden) > 0 || anyBeta || h.shufflePerRequest))
+	w.Header().Set("X-Cache", cacheState(allHit))
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("Error encoding playlists: %v", err)
+	}
+}
+
+// TagInfo contains a tag and how many approved tracks carry it
+type TagInfo struct {
+	Tag        string `json:"tag"`
+	TrackCount int    `json:"track_count"`
+}
+
+func (h *Handler) listTags(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/tags" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+
+	counts, err := h.repo.GetTagCounts()
+	if err != nil {
+		log.Printf("Error fetching tags: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	result := make([]TagInfo, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, TagInfo{Tag: c.Tag, TrackCount: c.TrackCount})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("Error encoding tags: %v", err)
+	}
+}
+
+// BuildInfo identifies the running server build
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	BuildTime string `json:"build_time,omitempty"`
+}
+
+func (h *Handler) getVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(h.build); err != nil {
+		log.Printf("Error encoding version: %v", err)
 	}
 }
 
 func (h *Handler) handleTracks(w http.ResponseWriter, r *http.Request) {
-	// Parse path: /api/tracks/{id}/play
+	// Parse path: /api/tracks/{id}, /api/tracks/{id}/play,
+	// /api/tracks/{id}/download, or /api/tracks/{id}/hide
 	path := strings.TrimPrefix(r.URL.Path, "/api/tracks/")
 	parts := strings.Split(path, "/")
 
-	if len(parts) < 2 {
-		http.Error(w, "Not found", http.StatusNotFound)
+	if parts[0] == "" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
 		return
 	}
 
 	id, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidTrackID, "Invalid track ID")
 		return
 	}
 
+	if len(parts) == 1 {
+		h.getTrack(w, r, id)
+		return
+	}
 	switch parts[1] {
 	case "play":
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
 			return
 		}
 		h.recordPlay(w, r, id)
+	case "download":
+		h.downloadTrack(w, r, id)
+	case "hide":
+		h.hideTrack(w, r, id)
 	default:
-		http.Error(w, "Not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
 	}
 }
 
@@ -275,13 +998,32 @@ var validEventTypes = map[string]bool{
 	inventory.EventPlay:     true,
 	inventory.EventSkip:     true,
 	inventory.EventComplete: true,
+	inventory.EventProgress: true,
+}
+
+// validSkipReasons are the allowed reasons of a skip event
+var validSkipReasons = map[string]bool{
+	inventory.SkipReasonDislike:   true,
+	inventory.SkipReasonWrongMood: true,
+	inventory.SkipReasonTooLong:   true,
+	inventory.SkipReasonRepeat:    true,
+	inventory.SkipReasonOther:     true,
 }
 
+// maxSessionIDLength bounds the client-supplied session identifier
+const maxSessionIDLength = 64
+
+// maxPlaylistIDLength bounds the client-echoed playlist identifier
+const maxPlaylistIDLength = 64
+
 func (h *Handler) recordPlay(w http.ResponseWriter, r *http.Request, trackID int64) {
 	// Decode optional JSON body; empty body defaults to a play event
 	var evt inventory.ListenEvent
 	if r.Body != nil {
-		body, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+		body, err := io.ReadAll(r.Body)
+		if bodyTooLarge(w, r, err) {
+			return
+		}
 		if err == nil && len(body) > 0 {
 			// Ignore decode errors — treat as body-less play
 			_ = json.Unmarshal(body, &evt)
@@ -296,7 +1038,45 @@ func (h *Handler) recordPlay(w http.ResponseWriter, r *http.Request, trackID int
 
 	// Validate event type
 	if !validEventTypes[evt.EventType] {
-		http.Error(w, "invalid event type", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidEventType, "invalid event type")
+		return
+	}
+	if evt.Reason != "" && (evt.EventType != inventory.EventSkip || !validSkipReasons[evt.Reason]) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidReason, "reason must be dislike, wrong_mood, too_long, repeat, or other, on a skip event")
+		return
+	}
+	if len(evt.SessionID) > maxSessionIDLength {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidSessionID, "invalid session id")
+		return
+	}
+	if len(evt.PlaylistID) > maxPlaylistIDLength {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidPlaylistID, "invalid playlist id")
+		return
+	}
+	client, err := normalizeClient(evt.Client, r.UserAgent())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidClient, err.Error())
+		return
+	}
+	evt.Client = client
+	if p := evt.PositionSeconds; p != nil && (*p < 0 || *p > maxPositionSeconds) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidPosition, "invalid position_seconds")
+		return
+	}
+
+	// Progress pings only move the listener's resume point
+	if evt.EventType == inventory.EventProgress {
+		h.recordProgress(w, r, evt)
+		return
+	}
+	evt.ClientID = ensureListenerID(w, r)
+	evt.Station = h.station
+
+	// A repeat play from the same client inside the dedup window (double
+	// click, re-trigger) is acknowledged but not counted again
+	claim, duplicate := h.claimPlay(r, evt)
+	if duplicate {
+		writePlayAck(w, http.StatusOK, "ok", trackID)
 		return
 	}
 
@@ -310,49 +1090,516 @@ func (h *Handler) recordPlay(w http.ResponseWriter, r *http.Request, trackID int
 		}
 	}
 
+	var recorded bool
+	if h.events != nil {
+		recorded = h.enqueuePlay(w, r, evt, track)
+	} else {
+		recorded = h.writePlay(w, r, evt, track)
+	}
+	if claim != nil && !recorded {
+		h.dedup.release(*claim)
+	}
+}
+
+// claimPlay registers a play event with the deduper, returning the claimed
+// key or reporting a repeat within the window. Other event types and clients
+// without a usable address pass through unclaimed. The key's address comes
+// from metrics.ClientAddr, which ignores X-Forwarded-For entries the client
+// wrote, so a client can neither rotate them to replay nor name another
+// listener to have their plays dropped.
+func (h *Handler) claimPlay(r *http.Request, evt inventory.ListenEvent) (claim *playKey, duplicate bool) {
+	if h.dedup == nil || evt.EventType != inventory.EventPlay {
+		return nil, false
+	}
+	addr, ok := metrics.ClientAddr(r)
+	if !ok {
+		return nil, false
+	}
+	key := playKey{trackID: evt.TrackID, client: addr}
+	if !h.dedup.claim(key) {
+		return nil, true
+	}
+	return &key, false
+}
+
+// writePlay records the event synchronously in one transaction.
+// Returns false if nothing was written.
+func (h *Handler) writePlay(w http.ResponseWriter, r *http.Request, evt inventory.ListenEvent, track *inventory.Track) bool {
+	trackID := evt.TrackID
+
 	// Wrap DB writes in a transaction to prevent partial state
-	tx, err := h.repo.BeginTx(r.Context())
+	ctx, cancel := inventory.WriteContext(r.Context(), h.writeDeadline)
+	defer cancel()
+
+	err := h.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		// Only update play_stats for non-skip events
+		if evt.EventType != inventory.EventSkip {
+			if err := h.repo.UpdatePlayStatsTx(tx, trackID); err != nil {
+				return err
+			}
+		}
+		// Record listen event if we have a mood
+		if evt.Mood != "" {
+			return h.repo.RecordListenEventTx(tx, evt)
+		}
+		return nil
+	})
+	if err != nil {
+		playWriteFailed(ctx, w, r, "recording play", trackID, err)
+		return false
+	}
+
+	// Update in-memory state after successful commit
+	h.afterPlay(evt, track)
+
+	writePlayAck(w, http.StatusOK, "ok", trackID)
+	return true
+}
+
+// playWriteFailed reports a failed play write. Running out of write deadline
+// or lock wait means the database is busy, not broken, so the client gets a
+// retryable 503.
+func playWriteFailed(ctx context.Context, w http.ResponseWriter, r *http.Request, step string, trackID int64, err error) {
+	if inventory.IsBusy(err) || errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("Warning: write deadline exceeded %s for track %d: %v", step, trackID, err)
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, http.StatusServiceUnavailable, CodeDatabaseBusy, "database busy, retry shortly")
+		return
+	}
+	log.Printf("Error %s for track %d: %v", step, trackID, err)
+	writeError(w, r, http.StatusInternalServerError, CodePlayNotRecorded, "failed to record play")
+}
+
+// enqueuePlay hands the event to the background writer and acknowledges
+// immediately. In-memory radio state is updated optimistically.
+// Returns false if the queue rejected the event.
+func (h *Handler) enqueuePlay(w http.ResponseWriter, r *http.Request, evt inventory.ListenEvent, track *inventory.Track) bool {
+	if !h.events.Enqueue(r.Context(), evt) {
+		log.Printf("Warning: listen queue full, dropped %s event for track %d", evt.EventType, evt.TrackID)
+		writeError(w, r, http.StatusServiceUnavailable, CodeQueueFull, "listen queue full")
+		return false
+	}
+
+	h.afterPlay(evt, track)
+
+	writePlayAck(w, http.StatusAccepted, "accepted", evt.TrackID)
+	return true
+}
+
+// afterPlay applies a recorded play's side effects: the play metric and the
+// radio's recently played list. They don't affect the response, so they run
+// on the task pool when there is one, in no particular order relative to
+// other plays.
+func (h *Handler) afterPlay(evt inventory.ListenEvent, track *inventory.Track) {
+	if evt.EventType == inventory.EventSkip {
+		return
+	}
+	task := func() {
+		metrics.Get().RecordPlay()
+		if track != nil {
+			h.radio.RecordPlay(track.Mood, evt.TrackID)
+		}
+	}
+	if h.tasks == nil {
+		task()
+		return
+	}
+	h.tasks.Submit(task)
+}
+
+// writePlayAck writes the plain-text success response for a play request
+func writePlayAck(w http.ResponseWriter, status int, body string, trackID int64) {
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(body)); err != nil {
+		log.Printf("Error writing response for track %d play: %v", trackID, err)
+	}
+}
+
+func (h *Handler) handleRooms(w http.ResponseWriter, r *http.Request) {
+	// Parse path: /api/rooms/{room}/ws
+	path := strings.TrimPrefix(r.URL.Path, "/api/rooms/")
+	parts := strings.Split(path, "/")
+
+	if h.rooms == nil || len(parts) != 2 || parts[1] != "ws" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	err := h.rooms.Serve(w, r, parts[0])
+	switch {
+	case err == nil:
+	case errors.Is(err, rooms.ErrInvalidRoom):
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRoom, "room must be 1-32 characters of a-z, 0-9, - or _")
+	case errors.Is(err, rooms.ErrRoomFull):
+		writeError(w, r, http.StatusServiceUnavailable, CodeRoomFull, "room is full")
+	case errors.Is(err, rooms.ErrTooManyRooms):
+		writeError(w, r, http.StatusServiceUnavailable, CodeTooManyRooms, "no rooms available")
+	default:
+		// The upgrader has already answered the request
+		log.Printf("Warning: room %s upgrade failed: %v", parts[0], err)
+	}
+}
+
+func (h *Handler) handleAdminTracks(w http.ResponseWriter, r *http.Request) {
+	// Parse path: /api/admin/tracks/{id}, /api/admin/tracks/{id}/history,
+	// /api/admin/tracks/{id}/approve, or /api/admin/tracks/{id}/reprobe
+	rest := strings.TrimPrefix(r.URL.Path, "/api/admin/tracks/")
+	idStr, sub, nested := strings.Cut(rest, "/")
+	if idStr == "" || (nested && sub != "history" && sub != "approve" && sub != "reprobe") {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		log.Printf("Error starting transaction for track %d: %v", trackID, err)
-		http.Error(w, "failed to record play", http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidTrackID, "Invalid track ID")
 		return
 	}
-	defer func() { _ = tx.Rollback() }()
 
-	// Only update play_stats for non-skip events
-	if evt.EventType != inventory.EventSkip {
-		if err := h.repo.UpdatePlayStatsTx(tx, trackID); err != nil {
-			log.Printf("Error recording play for track %d: %v", trackID, err)
-			http.Error(w, "failed to record play", http.StatusInternalServerError)
+	if sub == "approve" || sub == "reprobe" {
+		if r.Method != http.MethodPost {
+			writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
 			return
 		}
+		if sub == "approve" {
+			h.approveTrack(w, r, id)
+		} else {
+			h.reprobeTrack(w, r, id)
+		}
+		return
+	}
+	if nested {
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.statusHistory(w, r, id)
+		return
 	}
 
-	// Record listen event if we have a mood
-	if evt.Mood != "" {
-		if err := h.repo.RecordListenEventTx(tx, evt); err != nil {
-			log.Printf("Error recording listen event for track %d: %v", trackID, err)
-			http.Error(w, "failed to record play", http.StatusInternalServerError)
+	if r.Method != http.MethodPatch {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	h.updateTrack(w, r, id)
+}
+
+// invalidatePlaylists drops the cached playlists of moods and of any mood
+// that borrows tracks from them, leaving other moods cached.
+func (h *Handler) invalidatePlaylists(moods ...string) {
+	for _, mood := range moods {
+		h.cache.InvalidateMood(mood)
+		for sparse, fallback := range h.fallbackMoods {
+			if fallback == mood && !slices.Contains(moods, sparse) {
+				h.cache.InvalidateMood(sparse)
+			}
+		}
+	}
+}
+
+// defaultAdminActor is recorded for admin changes when the request carries
+// no authenticated admin name
+const defaultAdminActor = "admin"
+
+// adminActor identifies who made an admin change for the audit log: the
+// name of the admin token requireAdmin accepted, directly or through the
+// session it started
+func adminActor(r *http.Request) string {
+	if name, ok := adminName(r.Context()); ok {
+		return name
+	}
+	return defaultAdminActor
+}
+
+// updateTrack applies a partial JSON update to a track's metadata. An
+// optional "reason" string in the body is kept with any status change.
+func (h *Handler) updateTrack(w http.ResponseWriter, r *http.Request, id int64) {
+	var fields map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		if !bodyTooLarge(w, r, err) {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON body")
+		}
+		return
+	}
+
+	var reason string
+	if v, ok := fields["reason"]; ok {
+		s, isString := v.(string)
+		if !isString {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidField, "reason must be a string")
 			return
 		}
+		reason = strings.TrimSpace(s)
+		delete(fields, "reason")
 	}
 
-	if err := tx.Commit(); err != nil {
-		log.Printf("Error committing transaction for track %d: %v", trackID, err)
-		http.Error(w, "failed to record play", http.StatusInternalServerError)
+	// The track leaves its old mood's playlists if the mood changes
+	before, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error loading track %d before update: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
 		return
 	}
 
-	// Update in-memory state after successful commit
-	if evt.EventType != inventory.EventSkip {
-		metrics.Get().RecordPlay()
-		if track != nil {
-			h.radio.RecordPlay(track.Mood, trackID)
+	if err := h.repo.UpdateTrack(id, fields, adminActor(r), reason); err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrInvalidField):
+			writeError(w, r, http.StatusBadRequest, CodeInvalidField, err.Error())
+		case errors.Is(err, inventory.ErrTrackNotFound):
+			writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		default:
+			log.Printf("Error updating track %d: %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
 		}
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write([]byte("ok")); err != nil {
-		log.Printf("Error writing response for track %d play: %v", trackID, err)
+	track, err := h.repo.GetByID(id)
+	if err != nil || track == nil {
+		// Without the new mood, fall back to clearing every playlist
+		h.cache.InvalidateMoods()
+		log.Printf("Error reloading track %d after update: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	// Mood, status, and vocals all change which playlists a track lands in
+	moods := []string{track.Mood}
+	if before != nil && before.Mood != track.Mood {
+		moods = append(moods, before.Mood)
+	}
+	h.invalidatePlaylists(moods...)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(track); err != nil {
+		log.Printf("Error encoding track %d: %v", id, err)
+	}
+}
+
+// statusHistory lists a track's status changes, oldest first
+func (h *Handler) statusHistory(w http.ResponseWriter, r *http.Request, id int64) {
+	track, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error loading track %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track == nil {
+		writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		return
+	}
+
+	history, err := h.repo.GetStatusHistory(id)
+	if err != nil {
+		log.Printf("Error loading status history for track %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("Error encoding status history for track %d: %v", id, err)
+	}
+}
+
+// exportFlushEvery is the number of NDJSON rows written between flushes
+const exportFlushEvery = 100
+
+// exportTracks streams every approved track as NDJSON, one object per line.
+// The repository reads a page at a time and releases the connection before
+// each page is written, so a slow client doesn't block other queries.
+func (h *Handler) exportTracks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-store")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	written := 0
+	err := h.repo.StreamTracks(func(track *inventory.Track) error {
+		if err := enc.Encode(track); err != nil {
+			return err
+		}
+		written++
+		if flusher != nil && written%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// Once rows are out the status is committed; the client sees a short body
+		log.Printf("Error exporting tracks after %d rows: %v", written, err)
+		if written == 0 {
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		}
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// invalidTracks lists tracks with a missing or non-positive duration, which
+// break M3U output and completion analytics until re-probed, and tracks
+// whose audio file was found missing.
+func (h *Handler) invalidTracks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	tracks, err := h.repo.InvalidTracks()
+	if err != nil {
+		log.Printf("Error listing invalid tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(tracks); err != nil {
+		log.Printf("Error encoding invalid tracks: %v", err)
+	}
+}
+
+// radioState reports a mood's radio internals (recently played IDs, recency
+// window, sampling threshold, borrowing) for debugging shuffle behaviour.
+// /api/admin/radio/{mood}/stats goes to shuffleStats.
+func (h *Handler) radioState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	mood := strings.TrimPrefix(r.URL.Path, "/api/admin/radio/")
+	mood, stats := strings.CutSuffix(mood, "/stats")
+	if !validMoods[mood] {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood", unknownMoodDetails(mood))
+		return
+	}
+	if stats {
+		h.shuffleStats(w, mood)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(h.radio.StateSnapshot(mood)); err != nil {
+		log.Printf("Error encoding radio state: %v", err)
+	}
+}
+
+// duplicateTracks lists groups of tracks whose audio files share a content
+// hash, i.e. the same recording imported more than once
+func (h *Handler) duplicateTracks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	groups, err := h.repo.Duplicates()
+	if err != nil {
+		log.Printf("Error listing duplicate tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(groups); err != nil {
+		log.Printf("Error encoding duplicate tracks: %v", err)
+	}
+}
+
+// recalculatePlayStats rebuilds play counts from listen events and reports
+// how many rows changed. Query: ?strategy=max (default) or events.
+func (h *Handler) recalculatePlayStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	strategy := r.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = inventory.RecalcKeepMax
+	}
+
+	result, err := h.repo.RecalculatePlayStats(r.Context(), strategy)
+	if errors.Is(err, inventory.ErrInvalidStrategy) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidStrategy, "strategy must be max or events")
+		return
+	}
+	if err != nil {
+		log.Printf("Error recalculating play stats: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	log.Printf("Recalculated play stats (%s): %d of %d tracks changed", result.Strategy, result.Changed, result.Tracks)
+
+	// Play counts order playlists least-played first
+	if result.Changed > 0 {
+		h.cache.InvalidateMoods()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("Error encoding recalculation result: %v", err)
+	}
+}
+
+// Analytics window bounds for ?days=
+const (
+	defaultAnalyticsDays = 7
+	maxAnalyticsDays     = 365
+)
+
+// analyticsSince returns the start of the ?days= window, writing a 400 and
+// returning false when days is out of range
+func (h *Handler) analyticsSince(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	days := defaultAnalyticsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAnalyticsDays {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidDays, "days must be 1-365")
+			return time.Time{}, false
+		}
+		days = n
+	}
+	return h.now().AddDate(0, 0, -days), true
+}
+
+// sessionAnalytics reports session length and engagement per mood.
+// Query: ?days=N (default 7, max 365).
+func (h *Handler) sessionAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	since, ok := h.analyticsSince(w, r)
+	if !ok {
+		return
+	}
+	report, err := h.repo.GetSessionStats(since, h.sessionGap)
+	if err != nil {
+		log.Printf("Error computing session stats: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding session stats: %v", err)
 	}
 }
@@ -9,12 +9,20 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/1mb-dev/driftfm/internal/audio"
 	"github.com/1mb-dev/driftfm/internal/cache"
 	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/metrics"
 	"github.com/1mb-dev/driftfm/internal/radio"
+	"github.com/1mb-dev/driftfm/internal/rooms"
 	"github.com/1mb-dev/driftfm/internal/testutil"
 	_ "modernc.org/sqlite"
 )
@@ -41,10 +49,35 @@ func setupTestCache(t *testing.T) *cache.Cache {
 }
 
 // setupTestDB creates a temp SQLite database with schema and test data
+// testAdminToken is the bearer token enableAdmin accepts, named
+// testAdminName
+const (
+	testAdminToken = "test-admin-token-0123456789"
+	testAdminName  = "alice"
+)
+
+// enableAdmin turns on h's admin API for testAdminToken
+func enableAdmin(t *testing.T, h *Handler) {
+	t.Helper()
+	if err := h.SetAdminTokens(map[string]string{testAdminName: testAdminToken}); err != nil {
+		t.Fatalf("SetAdminTokens: %v", err)
+	}
+}
+
+// asAdmin authorizes req with testAdminToken
+func asAdmin(req *http.Request) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
 func setupTestDB(t *testing.T) *inventory.Repository {
 	t.Helper()
+	return setupTestDBAt(t, t.TempDir()+"/test.db")
+}
 
-	tmpDB := t.TempDir() + "/test.db"
+// setupTestDBAt is setupTestDB for callers that need the database file
+func setupTestDBAt(t *testing.T, tmpDB string) *inventory.Repository {
+	t.Helper()
 
 	// Create schema and seed data first
 	db, err := sql.Open("sqlite", tmpDB)
@@ -82,10 +115,11 @@ func TestListMoods(t *testing.T) {
 		name       string
 		path       string
 		wantStatus int
-		wantMoods  int // expected number of moods in response
+		wantMoods  int    // expected number of moods in response
+		wantCode   string // expected error code for non-200 responses
 	}{
-		{"valid path", "/api/moods", http.StatusOK, 2}, // focus and calm
-		{"wrong path", "/api/moods/", http.StatusNotFound, 0},
+		{"valid path", "/api/moods", http.StatusOK, 2, ""}, // focus and calm
+		{"wrong path", "/api/moods/", http.StatusNotFound, 0, CodeNotFound},
 	}
 
 	for _, tt := range tests {
@@ -98,6 +132,11 @@ func TestListMoods(t *testing.T) {
 			if w.Code != tt.wantStatus {
 				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
 			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
 
 			if tt.wantStatus == http.StatusOK {
 				var moods []MoodInfo
@@ -112,6 +151,124 @@ func TestListMoods(t *testing.T) {
 	}
 }
 
+func TestListMoods_EmptyInventory(t *testing.T) {
+	tmpDB := t.TempDir() + "/empty.db"
+	db, err := sql.Open("sqlite", tmpDB)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if _, err := db.Exec(testutil.SchemaDDL); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	_ = db.Close()
+	repo, err := inventory.NewRepository(tmpDB)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for _, wantCache := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != "[]" {
+			t.Errorf("body = %s, want []", body)
+		}
+		if got := w.Header().Get("X-Cache"); got != wantCache {
+			t.Errorf("X-Cache = %q, want %q (empty lists are cached too)", got, wantCache)
+		}
+	}
+}
+
+func TestListMoods_DisplayMetadata(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetMoodDisplay(map[string]MoodDisplay{
+		"focus": {Color: "#3b82f6", Icon: "target", Description: "Deep work"},
+	})
+
+	w := httptest.NewRecorder()
+	h.listMoods(w, httptest.NewRequest(http.MethodGet, "/api/moods", nil))
+
+	var moods []map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&moods); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	byName := make(map[string]map[string]any)
+	for _, m := range moods {
+		byName[m["name"].(string)] = m
+	}
+
+	focus := byName["focus"]
+	if focus["color"] != "#3b82f6" || focus["icon"] != "target" || focus["description"] != "Deep work" {
+		t.Errorf("focus = %v, want configured color, icon, description", focus)
+	}
+	// Unconfigured moods get a generated color and omit the other fields
+	if byName["calm"]["color"] != generatedColor("calm") {
+		t.Errorf("calm color = %v, want generated %s", byName["calm"]["color"], generatedColor("calm"))
+	}
+	for _, key := range []string{"icon", "description"} {
+		if _, ok := byName["calm"][key]; ok {
+			t.Errorf("calm has %q, want it omitted", key)
+		}
+	}
+}
+
+func TestListMoods_PerLocale(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetDisplayNames(map[string]map[string]string{
+		"en": {"focus": "Focus", "calm": "Calm"},
+		"es": {"focus": "Concentración"},
+	})
+
+	get := func(acceptLanguage string) (map[string]string, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/moods", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		w := httptest.NewRecorder()
+		h.listMoods(w, req)
+
+		if got := w.Header().Get("Vary"); got != "Accept-Language" {
+			t.Errorf("Vary = %q, want Accept-Language", got)
+		}
+		var moods []MoodInfo
+		if err := json.NewDecoder(w.Body).Decode(&moods); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		names := make(map[string]string)
+		for _, m := range moods {
+			names[m.Name] = m.DisplayName
+		}
+		return names, w.Header().Get("X-Cache")
+	}
+
+	en, cacheState := get("en-US")
+	if en["focus"] != "Focus" || cacheState != "MISS" {
+		t.Errorf("en: focus = %q (%s), want Focus (MISS)", en["focus"], cacheState)
+	}
+
+	// A cached English list must not be served to a Spanish client
+	es, cacheState := get("es-ES,en;q=0.5")
+	if es["focus"] != "Concentración" || cacheState != "MISS" {
+		t.Errorf("es: focus = %q (%s), want Concentración (MISS)", es["focus"], cacheState)
+	}
+	if es["calm"] != "Calm" {
+		t.Errorf("es: calm = %q, want English fallback", es["calm"])
+	}
+
+	if _, cacheState := get("es"); cacheState != "HIT" {
+		t.Errorf("repeat es request X-Cache = %s, want HIT", cacheState)
+	}
+}
+
 func TestGetPlaylist(t *testing.T) {
 	repo := setupTestDB(t)
 	c := setupTestCache(t)
@@ -124,11 +281,12 @@ func TestGetPlaylist(t *testing.T) {
 		name       string
 		path       string
 		wantStatus int
-		wantTracks int // expected minimum tracks (-1 to skip check)
+		wantTracks int    // expected minimum tracks (-1 to skip check)
+		wantCode   string // expected error code for non-200 responses
 	}{
-		{"valid mood", "/api/moods/focus/playlist", http.StatusOK, 2},
-		{"unknown mood", "/api/moods/unknown/playlist", http.StatusNotFound, -1},
-		{"missing playlist", "/api/moods/focus", http.StatusNotFound, -1},
+		{"valid mood", "/api/moods/focus/playlist", http.StatusOK, 2, ""},
+		{"unknown mood", "/api/moods/unknown/playlist", http.StatusNotFound, -1, CodeMoodNotFound},
+		{"missing playlist", "/api/moods/focus", http.StatusNotFound, -1, CodeNotFound},
 	}
 
 	for _, tt := range tests {
@@ -141,6 +299,11 @@ func TestGetPlaylist(t *testing.T) {
 			if w.Code != tt.wantStatus {
 				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
 			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
 
 			// Validate response body for successful requests
 			if tt.wantStatus == http.StatusOK && tt.wantTracks >= 0 {
@@ -184,11 +347,14 @@ func TestRecordPlay(t *testing.T) {
 		method     string
 		path       string
 		wantStatus int
+		wantCode   string
 	}{
-		{"valid POST", http.MethodPost, "/api/tracks/1/play", http.StatusOK},
-		{"invalid method", http.MethodGet, "/api/tracks/1/play", http.StatusMethodNotAllowed},
-		{"invalid ID", http.MethodPost, "/api/tracks/abc/play", http.StatusBadRequest},
-		{"unknown action", http.MethodPost, "/api/tracks/1/unknown", http.StatusNotFound},
+		{"valid POST", http.MethodPost, "/api/tracks/1/play", http.StatusOK, ""},
+		{"invalid method", http.MethodGet, "/api/tracks/1/play", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"invalid ID", http.MethodPost, "/api/tracks/abc/play", http.StatusBadRequest, CodeInvalidTrackID},
+		{"POST to a track", http.MethodPost, "/api/tracks/1", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"missing ID", http.MethodPost, "/api/tracks/", http.StatusNotFound, CodeNotFound},
+		{"unknown action", http.MethodPost, "/api/tracks/1/unknown", http.StatusNotFound, CodeNotFound},
 	}
 
 	for _, tt := range tests {
@@ -201,6 +367,11 @@ func TestRecordPlay(t *testing.T) {
 			if w.Code != tt.wantStatus {
 				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
 			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
 		})
 	}
 }
@@ -211,12 +382,18 @@ func TestRecordPlay(t *testing.T) {
 type mockRepo struct {
 	getMoodStatsErr        error
 	getMoodStatsResult     []inventory.MoodStats
+	getMoodStatsCalls      int
 	getByIDErr             error
 	getByIDResult          *inventory.Track
 	updatePlayStatsErr     error
+	updatePlayStatsCalls   int
 	recordListenEventErr   error
 	recordListenEventCalls []inventory.ListenEvent
 	beginTxErr             error
+	streamTracksErr        error
+	libraryTotals          inventory.LibraryTotals
+	libraryTotalsCalls     int
+	libraryTotalsSince     time.Time
 
 	// in-memory DB for transaction support in tests
 	txDB *sql.DB
@@ -228,21 +405,111 @@ func newMockRepo() *mockRepo {
 }
 
 func (m *mockRepo) GetMoodStats() ([]inventory.MoodStats, error) {
+	m.getMoodStatsCalls++
 	return m.getMoodStatsResult, m.getMoodStatsErr
 }
 
+func (m *mockRepo) UpdateTrack(_ int64, _ map[string]any, _, _ string) error {
+	return nil
+}
+
+func (m *mockRepo) GetStatusHistory(_ int64) ([]inventory.StatusChange, error) {
+	return []inventory.StatusChange{}, nil
+}
+
+func (m *mockRepo) StreamTracks(_ func(*inventory.Track) error) error {
+	return m.streamTracksErr
+}
+
+func (m *mockRepo) InvalidTracks() ([]*inventory.Track, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetFileMissing(_ int64, _ time.Time) error {
+	return nil
+}
+
+func (m *mockRepo) GetByArtist(_ string, _, _ int) ([]*inventory.Track, int, error) {
+	return []*inventory.Track{}, 0, nil
+}
+
+func (m *mockRepo) GetPending(_, _ int) ([]*inventory.Track, error) {
+	return []*inventory.Track{}, nil
+}
+
+func (m *mockRepo) ListTracks(_ inventory.ListOptions) ([]*inventory.Track, int, error) {
+	return []*inventory.Track{}, 0, nil
+}
+
+func (m *mockRepo) Approve(_ int64, _ string) error {
+	return nil
+}
+
+func (m *mockRepo) GetMoodMeta() (map[string]inventory.MoodMeta, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetMoodMeta(_ inventory.MoodMeta) error {
+	return nil
+}
+
+func (m *mockRepo) GetFeaturedTrack(_ time.Time) (*inventory.Track, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) GetRandom(_ int) ([]*inventory.Track, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Duplicates() ([]inventory.DuplicateGroup, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) RecalculatePlayStats(_ context.Context, strategy string) (*inventory.RecalcResult, error) {
+	return &inventory.RecalcResult{Strategy: strategy}, nil
+}
+
+func (m *mockRepo) CheckIntegrity(_ context.Context, mode string) (*inventory.IntegrityReport, error) {
+	return &inventory.IntegrityReport{Mode: mode, OK: true}, nil
+}
+
+func (m *mockRepo) GetSessionStats(_ time.Time, _ time.Duration) (*inventory.SessionReport, error) {
+	return &inventory.SessionReport{}, nil
+}
+
+func (m *mockRepo) CountEventsByType(_, _ time.Time) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func (m *mockRepo) GetPositionFunnel(mood string, _ time.Time, _ int) (*inventory.PositionFunnel, error) {
+	return &inventory.PositionFunnel{Mood: mood, Positions: []inventory.PositionStats{}}, nil
+}
+
+func (m *mockRepo) GetTagCounts() ([]inventory.TagCount, error) {
+	return nil, nil
+}
+
 func (m *mockRepo) GetByID(id int64) (*inventory.Track, error) {
 	return m.getByIDResult, m.getByIDErr
 }
 
-func (m *mockRepo) BeginTx(_ context.Context) (*sql.Tx, error) {
+func (m *mockRepo) WithTx(_ context.Context, fn func(tx *sql.Tx) error) error {
 	if m.beginTxErr != nil {
-		return nil, m.beginTxErr
+		return m.beginTxErr
+	}
+	tx, err := m.txDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := fn(tx); err != nil {
+		return err
 	}
-	return m.txDB.Begin()
+	return tx.Commit()
 }
 
 func (m *mockRepo) UpdatePlayStatsTx(_ *sql.Tx, _ int64) error {
+	m.updatePlayStatsCalls++
 	return m.updatePlayStatsErr
 }
 
@@ -251,16 +518,71 @@ func (m *mockRepo) RecordListenEventTx(_ *sql.Tx, evt inventory.ListenEvent) err
 	return m.recordListenEventErr
 }
 
+func (m *mockRepo) SaveListenerState(_ inventory.ListenerState) error {
+	return nil
+}
+
+func (m *mockRepo) GetListenerState(_, _ string, _ time.Duration) (*inventory.ListenerState, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) GetLastPosition(_ string) (*inventory.ResumePoint, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) HideTrack(_ string, _ int64) error {
+	return nil
+}
+
+func (m *mockRepo) UnhideTrack(_ string, _ int64) error {
+	return nil
+}
+
+func (m *mockRepo) HiddenTrackIDs(_ string) ([]int64, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) DurationReport(maxSeconds int) (*inventory.DurationReport, error) {
+	return &inventory.DurationReport{MaxSeconds: maxSeconds}, nil
+}
+
+func (m *mockRepo) SetDuration(_ int64, _ int) error {
+	return nil
+}
+
+func (m *mockRepo) LibraryTotals(since time.Time) (*inventory.LibraryTotals, error) {
+	m.libraryTotalsCalls++
+	m.libraryTotalsSince = since
+	totals := m.libraryTotals
+	return &totals, nil
+}
+
 var _ Repository = (*mockRepo)(nil)
 
 // mockRadio implements Radio with configurable errors
 type mockRadio struct {
 	getPlaylistErr    error
 	getPlaylistResult []*inventory.Track
+	lastLimit         int
 	recordPlayCalled  bool
 }
 
-func (m *mockRadio) GetPlaylist(_ string, _ bool) ([]*inventory.Track, error) {
+func (m *mockRadio) GetPlaylist(_ context.Context, _ string, _ inventory.TrackFilter, limit int) ([]*inventory.Track, error) {
+	m.lastLimit = limit
+	return m.getPlaylistResult, m.getPlaylistErr
+}
+
+func (m *mockRadio) PlaylistSize(limit int) int {
+	return limit
+}
+
+func (m *mockRadio) GetTransitionPlaylist(_ context.Context, _, _ string, steps int) ([]*inventory.Track, error) {
+	m.lastLimit = steps
+	return m.getPlaylistResult, m.getPlaylistErr
+}
+
+func (m *mockRadio) GetDiscoverPlaylist(_ context.Context, _ []radio.MoodWeight, size int) ([]*inventory.Track, error) {
+	m.lastLimit = size
 	return m.getPlaylistResult, m.getPlaylistErr
 }
 
@@ -268,6 +590,14 @@ func (m *mockRadio) RecordPlay(_ string, _ int64) {
 	m.recordPlayCalled = true
 }
 
+func (m *mockRadio) StateSnapshot(mood string) radio.StateSnapshot {
+	return radio.StateSnapshot{Mood: mood}
+}
+
+func (m *mockRadio) ShuffleStats(mood string) radio.ShuffleStats {
+	return radio.ShuffleStats{Mood: mood}
+}
+
 var _ Radio = (*mockRadio)(nil)
 
 // --- Error path tests ---
@@ -289,11 +619,149 @@ func TestRecordPlay_DBFailure(t *testing.T) {
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
 	}
+	if code := errorCode(t, w); code != CodePlayNotRecorded {
+		t.Errorf("code = %q, want %q", code, CodePlayNotRecorded)
+	}
 	if r.recordPlayCalled {
 		t.Error("RecordPlay should not be called when DB write fails")
 	}
 }
 
+func TestRecordPlay_WriteDeadline(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	repo := setupTestDBAt(t, dbPath)
+	if err := repo.SetWriteDeadline(100 * time.Millisecond); err != nil {
+		t.Fatalf("SetWriteDeadline: %v", err)
+	}
+	r := &mockRadio{}
+	h := NewHandler(repo, r, &mockResolver{}, setupTestCache(t))
+	h.SetWriteDeadline(100 * time.Millisecond)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// A long write on another connection holds SQLite's write lock
+	other, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open second connection: %v", err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	holder, err := other.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	if _, err := holder.ExecContext(context.Background(), "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("failed to take write lock: %v", err)
+	}
+
+	start := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d (%s)", w.Code, http.StatusServiceUnavailable, w.Body.String())
+	}
+	if code := errorCode(t, w); code != CodeDatabaseBusy {
+		t.Errorf("code = %q, want %q", code, CodeDatabaseBusy)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("503 should carry Retry-After")
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("blocked write took %v, want it to fail near the 100ms deadline", elapsed)
+	}
+	if r.recordPlayCalled {
+		t.Error("RecordPlay should not be called when the write times out")
+	}
+
+	// Once the lock is released the same request succeeds
+	if _, err := holder.ExecContext(context.Background(), "ROLLBACK"); err != nil {
+		t.Fatalf("failed to release write lock: %v", err)
+	}
+	_ = holder.Close()
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status after release = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestListMoods_SortAndLimit(t *testing.T) {
+	repo := newMockRepo()
+	repo.getMoodStatsResult = []inventory.MoodStats{
+		{Mood: "calm", TrackCount: 3, TotalSeconds: 900},
+		{Mood: "energize", TrackCount: 7, TotalSeconds: 600},
+		{Mood: "focus", TrackCount: 12, TotalSeconds: 300},
+		{Mood: "late_night", TrackCount: 7, TotalSeconds: 1200},
+	}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{"", http.StatusOK, []string{"calm", "energize", "focus", "late_night"}},
+		{"?sort=tracks", http.StatusOK, []string{"focus", "energize", "late_night", "calm"}}, // tie by name
+		{"?sort=duration", http.StatusOK, []string{"late_night", "calm", "energize", "focus"}},
+		{"?sort=tracks&limit=2", http.StatusOK, []string{"focus", "energize"}},
+		{"?limit=10", http.StatusOK, []string{"calm", "energize", "focus", "late_night"}},
+		{"?sort=plays", http.StatusBadRequest, nil},
+		{"?limit=0", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.listMoods(w, httptest.NewRequest(http.MethodGet, "/api/moods"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var moods []MoodInfo
+			if err := json.NewDecoder(w.Body).Decode(&moods); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var got []string
+			for _, m := range moods {
+				got = append(got, m.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("moods = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Each ordering is cached separately; a repeat is served from cache
+	w := httptest.NewRecorder()
+	h.listMoods(w, httptest.NewRequest(http.MethodGet, "/api/moods?sort=tracks&limit=2", nil))
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("X-Cache = %q, want HIT", got)
+	}
+	var cached []MoodInfo
+	_ = json.NewDecoder(w.Body).Decode(&cached)
+	if len(cached) != 2 || cached[0].Name != "focus" {
+		t.Errorf("cached moods = %+v, want focus first of 2", cached)
+	}
+
+	// Limits at or past the mood count all share the unlimited entry
+	for _, limit := range []int{len(validMoods), 999999999} {
+		w := httptest.NewRecorder()
+		h.listMoods(w, httptest.NewRequest(http.MethodGet, "/api/moods?limit="+strconv.Itoa(limit), nil))
+		if got := w.Header().Get("X-Cache"); got != "HIT" {
+			t.Errorf("limit=%d: X-Cache = %q, want HIT from the unlimited entry", limit, got)
+		}
+	}
+	if _, found := h.moodLists.Get(moodsCacheKey(DefaultLocale, moodSortName, 999999999)); found {
+		t.Error("a limit past the mood count got its own cache entry")
+	}
+}
+
 func TestListMoods_DBFailure(t *testing.T) {
 	c := setupTestCache(t)
 	repo := newMockRepo()
@@ -307,6 +775,9 @@ func TestListMoods_DBFailure(t *testing.T) {
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
 	}
+	if code := errorCode(t, w); code != CodeInternal {
+		t.Errorf("code = %q, want %q", code, CodeInternal)
+	}
 }
 
 func TestGetPlaylist_RadioFailure(t *testing.T) {
@@ -325,6 +796,9 @@ func TestGetPlaylist_RadioFailure(t *testing.T) {
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
 	}
+	if code := errorCode(t, w); code != CodeInternal {
+		t.Errorf("code = %q, want %q", code, CodeInternal)
+	}
 }
 
 func TestRecordPlay_GetByIDFailure(t *testing.T) {
@@ -431,6 +905,53 @@ func TestRecordPlay_SkipEvent_NoPlayStatsUpdate(t *testing.T) {
 	}
 }
 
+// queuedTasks is a Tasks that holds submitted tasks until run
+type queuedTasks struct {
+	tasks []func()
+}
+
+func (q *queuedTasks) Submit(task func()) bool {
+	q.tasks = append(q.tasks, task)
+	return true
+}
+
+func TestRecordPlay_SideEffectsOnTasks(t *testing.T) {
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	r := &mockRadio{}
+	h := NewHandler(repo, r, &mockResolver{}, setupTestCache(t))
+	tasks := &queuedTasks{}
+	h.SetTasks(tasks)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for _, body := range []string{`{"event":"play"}`, `{"event":"skip"}`} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+	}
+
+	// The response went out before the radio heard about the play
+	if r.recordPlayCalled {
+		t.Error("RecordPlay called inside the request, want it on the task pool")
+	}
+	if len(tasks.tasks) != 1 {
+		t.Fatalf("submitted %d tasks, want 1 (skips have no side effects)", len(tasks.tasks))
+	}
+
+	before := metrics.Get().Snapshot()["plays_total"].(uint64)
+	tasks.tasks[0]()
+	if !r.recordPlayCalled {
+		t.Error("task did not call RecordPlay")
+	}
+	if got := metrics.Get().Snapshot()["plays_total"].(uint64); got != before+1 {
+		t.Errorf("plays_total = %d after the task, want %d", got, before+1)
+	}
+}
+
 func TestRecordPlay_InvalidEventType_Returns400(t *testing.T) {
 	c := setupTestCache(t)
 	repo := newMockRepo()
@@ -449,6 +970,9 @@ func TestRecordPlay_InvalidEventType_Returns400(t *testing.T) {
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
+	if code := errorCode(t, w); code != CodeInvalidEventType {
+		t.Errorf("code = %q, want %q", code, CodeInvalidEventType)
+	}
 	if r.recordPlayCalled {
 		t.Error("RecordPlay should not be called for invalid event types")
 	}
@@ -478,3 +1002,1559 @@ func TestRecordPlay_MalformedBody_StillSucceeds(t *testing.T) {
 		t.Error("RecordPlay should be called even with malformed body (defaults to play)")
 	}
 }
+
+// mockQueue implements EventQueue
+type mockQueue struct {
+	full   bool
+	events []inventory.ListenEvent
+}
+
+func (m *mockQueue) Enqueue(_ context.Context, evt inventory.ListenEvent) bool {
+	if m.full {
+		return false
+	}
+	m.events = append(m.events, evt)
+	return true
+}
+
+var _ EventQueue = (*mockQueue)(nil)
+
+func TestRecordPlay_AsyncQueue(t *testing.T) {
+	c := setupTestCache(t)
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	repo.updatePlayStatsErr = errors.New("sync path must not run")
+	r := &mockRadio{}
+	q := &mockQueue{}
+	h := NewHandler(repo, r, &mockResolver{}, c)
+	h.SetEventQueue(q)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := `{"event":"complete","listen_seconds":180}`
+	req := httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if len(q.events) != 1 || q.events[0].Mood != "focus" || q.events[0].EventType != "complete" {
+		t.Errorf("queued events = %+v, want one complete event for focus", q.events)
+	}
+	if len(repo.recordListenEventCalls) != 0 {
+		t.Error("listen event should not be written synchronously in async mode")
+	}
+	if !r.recordPlayCalled {
+		t.Error("RecordPlay should be called after a successful enqueue")
+	}
+}
+
+func TestRecordPlay_AsyncQueueFull(t *testing.T) {
+	c := setupTestCache(t)
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	r := &mockRadio{}
+	h := NewHandler(repo, r, &mockResolver{}, c)
+	h.SetEventQueue(&mockQueue{full: true})
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if code := errorCode(t, w); code != CodeQueueFull {
+		t.Errorf("code = %q, want %q", code, CodeQueueFull)
+	}
+	if r.recordPlayCalled {
+		t.Error("RecordPlay should not be called when the event is dropped")
+	}
+}
+
+func TestGetPlaylist_TagFilter(t *testing.T) {
+	repo := setupTestDB(t)
+	_ = repo.SetTags(1, []string{"piano", "rain"})
+	_ = repo.SetTags(2, []string{"piano"})
+	c := setupTestCache(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, c)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTracks int
+	}{
+		{"no filter", "", http.StatusOK, 2},
+		{"one tag", "?tags=piano", http.StatusOK, 2},
+		{"two tags AND", "?tags=piano,rain", http.StatusOK, 1},
+		{"normalized duplicate", "?tags=RAIN,%20rain%20", http.StatusOK, 1},
+		{"no match", "?tags=vinyl", http.StatusOK, 0},
+		{"invalid tag", "?tags=piano,%21%21", http.StatusBadRequest, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist"+tt.query, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantTracks < 0 {
+				if code := errorCode(t, w); code != CodeInvalidTags {
+					t.Errorf("code = %q, want %q", code, CodeInvalidTags)
+				}
+				return
+			}
+			var tracks []PlaylistTrack
+			if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(tracks) != tt.wantTracks {
+				t.Errorf("got %d tracks, want %d", len(tracks), tt.wantTracks)
+			}
+		})
+	}
+}
+
+func TestGetPlaylist_IntensityValidation(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"valid range", "intensity_min=3&intensity_max=6", http.StatusOK},
+		{"single bound", "intensity_max=10", http.StatusOK},
+		{"min above max", "intensity_min=7&intensity_max=3", http.StatusBadRequest},
+		{"below scale", "intensity_min=0", http.StatusBadRequest},
+		{"above scale", "intensity_max=11", http.StatusBadRequest},
+		{"not a number", "intensity_min=high", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist?"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				if code := errorCode(t, w); code != CodeInvalidIntensity {
+					t.Errorf("code = %q, want %q", code, CodeInvalidIntensity)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPlaylist_BPMAndSortValidation(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"valid range", "bpm_min=100&bpm_max=130", http.StatusOK, ""},
+		{"single bound", "bpm_min=120", http.StatusOK, ""},
+		{"sort with range", "bpm_min=100&sort=bpm_desc", http.StatusOK, ""},
+		{"min above max", "bpm_min=130&bpm_max=100", http.StatusBadRequest, CodeInvalidBPM},
+		{"zero", "bpm_min=0", http.StatusBadRequest, CodeInvalidBPM},
+		{"negative", "bpm_max=-5", http.StatusBadRequest, CodeInvalidBPM},
+		{"above ceiling", "bpm_max=401", http.StatusBadRequest, CodeInvalidBPM},
+		{"not a number", "bpm_min=fast", http.StatusBadRequest, CodeInvalidBPM},
+		{"unknown sort", "sort=bpm", http.StatusBadRequest, CodeInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist?"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPlaylist_SortedByBPM(t *testing.T) {
+	repo := setupTestDB(t)
+	// Track 3 is calm; focus has 1 (vocals, 90 BPM) and 2 (instrumental, 128)
+	if err := repo.UpdateTrack(1, map[string]any{"tempo_bpm": 90, "has_vocals": true}, "test", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	if err := repo.UpdateTrack(2, map[string]any{"tempo_bpm": 128}, "test", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func(query string) ([]int64, *httptest.ResponseRecorder) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200 (%s)", query, w.Code, w.Body.String())
+		}
+		var tracks []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		ids := make([]int64, len(tracks))
+		for i, tr := range tracks {
+			ids[i] = tr.ID
+		}
+		return ids, w
+	}
+
+	ids, w := get("sort=bpm_desc")
+	if !slices.Equal(ids, []int64{2, 1}) {
+		t.Errorf("bpm_desc = %v, want [2 1]", ids)
+	}
+	if got := w.Header().Get("X-Recency-Demotion"); got != "skipped" {
+		t.Errorf("X-Recency-Demotion = %q, want skipped", got)
+	}
+	if ids, _ := get("sort=bpm_asc"); !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("bpm_asc = %v, want [1 2]", ids)
+	}
+
+	// The instrumental filter narrows a sorted playlist without reordering it
+	if ids, _ := get("sort=bpm_asc&instrumental=true"); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("instrumental bpm_asc = %v, want [2]", ids)
+	}
+	if ids, _ := get("bpm_min=100&bpm_max=130"); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("bpm 100-130 = %v, want [2]", ids)
+	}
+
+	// Shuffled playlists don't carry the header
+	if _, w := get(""); w.Header().Get("X-Recency-Demotion") != "" {
+		t.Error("unsorted playlist has X-Recency-Demotion")
+	}
+}
+
+func TestGetPlaylist_InstrumentalParam(t *testing.T) {
+	repo := setupTestDB(t)
+	if err := repo.UpdateTrack(1, map[string]any{"has_vocals": true}, "test", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		value    string
+		wantCode int
+		wantIDs  []int64
+	}{
+		{"1", http.StatusOK, []int64{2}},
+		{"true", http.StatusOK, []int64{2}},
+		{"TRUE", http.StatusOK, []int64{2}},
+		{"0", http.StatusOK, []int64{1, 2}},
+		{"yes", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist?instrumental="+tt.value, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				if code := errorCode(t, w); code != CodeInvalidBoolean {
+					t.Errorf("code = %q, want %q", code, CodeInvalidBoolean)
+				}
+				return
+			}
+			var tracks []PlaylistTrack
+			if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+				t.Fatalf("failed to decode playlist: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("playlist = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestGetPlaylist_SignedURLs(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	signer := audio.NewSigner([]byte("0123456789abcdef0123456789abcdef"), "/audio")
+	h.SetURLSigner(signer, time.Hour)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var tracks []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tracks) == 0 {
+		t.Fatal("expected tracks in playlist")
+	}
+	for _, tr := range tracks {
+		u, err := url.Parse(tr.AudioURL)
+		if err != nil {
+			t.Fatalf("audio_url %q does not parse: %v", tr.AudioURL, err)
+		}
+		if u.Path != "/audio/"+tr.FilePath {
+			t.Errorf("audio_url path = %q, want /audio/%s", u.Path, tr.FilePath)
+		}
+		if err := signer.Verify(tr.FilePath, u.Query()); err != nil {
+			t.Errorf("audio_url %q does not verify: %v", tr.AudioURL, err)
+		}
+	}
+}
+
+// versionedResolver resolves every file locally at a fixed content version
+type versionedResolver struct{}
+
+func (versionedResolver) ResolveURL(filePath string) (string, error) {
+	return audio.WithVersion("/audio/"+filePath, "0a1b2c3d4e"), nil
+}
+
+func TestResolveAudioURL_Versions(t *testing.T) {
+	repo := setupTestDB(t)
+	hash := "ffeeddccbbaa99887766"
+
+	t.Run("remote from content hash", func(t *testing.T) {
+		h := NewHandler(repo, radio.NewManager(repo), audio.NewRemoteResolver("https://cdn.example.com"), setupTestCache(t))
+		track := &inventory.Track{ID: 1, FilePath: "focus/a.mp3", ContentHash: &hash}
+		h.resolveAudioURL(track)
+		if want := "https://cdn.example.com/focus/a.mp3?v=ffeeddccbb"; track.AudioURL != want {
+			t.Errorf("audio_url = %q, want %q", track.AudioURL, want)
+		}
+
+		track = &inventory.Track{ID: 2, FilePath: "focus/b.mp3"}
+		h.resolveAudioURL(track)
+		if track.AudioURL != "https://cdn.example.com/focus/b.mp3" {
+			t.Errorf("audio_url without a hash = %q, want it unversioned", track.AudioURL)
+		}
+	})
+
+	t.Run("signed keeps the version", func(t *testing.T) {
+		h := NewHandler(repo, radio.NewManager(repo), versionedResolver{}, setupTestCache(t))
+		signer := audio.NewSigner([]byte("0123456789abcdef0123456789abcdef"), "/audio")
+		h.SetURLSigner(signer, time.Hour)
+
+		track := &inventory.Track{ID: 1, FilePath: "focus/a.mp3", ContentHash: &hash}
+		h.resolveAudioURL(track)
+		u, err := url.Parse(track.AudioURL)
+		if err != nil {
+			t.Fatalf("audio_url %q does not parse: %v", track.AudioURL, err)
+		}
+		if got := u.Query().Get(audio.VersionParam); got != "0a1b2c3d4e" {
+			t.Errorf("version = %q, want the resolver's 0a1b2c3d4e", got)
+		}
+		if err := signer.Verify(track.FilePath, u.Query()); err != nil {
+			t.Errorf("audio_url %q does not verify: %v", track.AudioURL, err)
+		}
+	})
+}
+
+func TestPlaylistCacheKey(t *testing.T) {
+	plain := playlistCacheKey("focus", inventory.TrackFilter{}, 0)
+	tagged := playlistCacheKey("focus", inventory.TrackFilter{Tags: []string{"piano", "rain"}}, 0)
+	both := playlistCacheKey("focus", inventory.TrackFilter{InstrumentalOnly: true, Tags: []string{"piano"}}, 0)
+	limited := playlistCacheKey("focus", inventory.TrackFilter{}, 10)
+	ranged := playlistCacheKey("focus", inventory.TrackFilter{IntensityMin: 3, IntensityMax: 6}, 0)
+	minOnly := playlistCacheKey("focus", inventory.TrackFilter{IntensityMin: 3}, 0)
+	tempo := playlistCacheKey("focus", inventory.TrackFilter{BPMMin: 3, BPMMax: 6}, 0)
+	sorted := playlistCacheKey("focus", inventory.TrackFilter{Sort: inventory.SortBPMAsc}, 0)
+	sortedDesc := playlistCacheKey("focus", inventory.TrackFilter{Sort: inventory.SortBPMDesc}, 0)
+
+	keys := map[string]bool{plain: true, tagged: true, both: true, limited: true, ranged: true, minOnly: true, tempo: true, sorted: true, sortedDesc: true}
+	if len(keys) != 9 {
+		t.Errorf("filters must produce distinct keys: %q %q %q %q %q %q %q %q %q", plain, tagged, both, limited, ranged, minOnly, tempo, sorted, sortedDesc)
+	}
+	if plain != cache.PlaylistKey("focus") {
+		t.Errorf("unfiltered key = %q, want %q", plain, cache.PlaylistKey("focus"))
+	}
+}
+
+func TestListTags(t *testing.T) {
+	repo := setupTestDB(t)
+	_ = repo.SetTags(1, []string{"piano"})
+	_ = repo.SetTags(3, []string{"piano", "rain"})
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tags", nil)
+	w := httptest.NewRecorder()
+	h.listTags(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var tags []TagInfo
+	if err := json.NewDecoder(w.Body).Decode(&tags); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Tag != "piano" || tags[0].TrackCount != 2 || tags[1].TrackCount != 1 {
+		t.Errorf("tags = %+v, want piano=2 rain=1", tags)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		buildTime string
+	}{
+		{"with build time", "v1.4.0", "2026-10-16T09:00:00Z"},
+		{"without build time", "dev", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+			h.SetBuildInfo(tt.version, tt.buildTime)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/version", nil)
+			w := httptest.NewRecorder()
+			h.getVersion(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			var fields map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&fields); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if fields["version"] != tt.version {
+				t.Errorf("version = %q, want %q", fields["version"], tt.version)
+			}
+			if fields["go_version"] != runtime.Version() {
+				t.Errorf("go_version = %q, want %q", fields["go_version"], runtime.Version())
+			}
+			if got, ok := fields["build_time"]; ok != (tt.buildTime != "") || got != tt.buildTime {
+				t.Errorf("build_time = %q (present %v), want %q", got, ok, tt.buildTime)
+			}
+		})
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+		w := httptest.NewRecorder()
+		h.getVersion(w, httptest.NewRequest(http.MethodPost, "/api/version", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestUpdateTrack(t *testing.T) {
+	repo := setupTestDB(t)
+	c := setupTestCache(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, c)
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"single field", http.MethodPatch, "/api/admin/tracks/1", `{"title":"Fixed Title"}`, http.StatusOK, ""},
+		{"non-whitelisted field", http.MethodPatch, "/api/admin/tracks/1", `{"file_path":"evil.mp3"}`, http.StatusBadRequest, CodeInvalidField},
+		{"invalid value", http.MethodPatch, "/api/admin/tracks/1", `{"intensity":11}`, http.StatusBadRequest, CodeInvalidField},
+		{"unknown mood", http.MethodPatch, "/api/admin/tracks/1", `{"mood":"foucs"}`, http.StatusBadRequest, CodeInvalidField},
+		{"empty object", http.MethodPatch, "/api/admin/tracks/1", `{}`, http.StatusBadRequest, CodeInvalidField},
+		{"malformed json", http.MethodPatch, "/api/admin/tracks/1", `{title`, http.StatusBadRequest, CodeInvalidJSON},
+		{"unknown track", http.MethodPatch, "/api/admin/tracks/999", `{"title":"x"}`, http.StatusNotFound, CodeTrackNotFound},
+		{"invalid id", http.MethodPatch, "/api/admin/tracks/abc", `{"title":"x"}`, http.StatusBadRequest, CodeInvalidTrackID},
+		{"nested path", http.MethodPatch, "/api/admin/tracks/1/tags", `{"title":"x"}`, http.StatusNotFound, CodeNotFound},
+		{"wrong method", http.MethodPost, "/api/admin/tracks/1", `{"title":"x"}`, http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+
+	// Without credentials nothing is written
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/1", bytes.NewBufferString(`{"title":"Anonymous"}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	track, _ := repo.GetByID(1)
+	if track.Title == nil || *track.Title != "Fixed Title" {
+		t.Errorf("title = %v, want %q", track.Title, "Fixed Title")
+	}
+}
+
+func TestUpdateTrack_InvalidatesPlaylistCache(t *testing.T) {
+	repo := setupTestDB(t)
+	c := setupTestCache(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, c)
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// Warm the focus playlist cache
+	mux.ServeHTTP(httptest.NewRecorder(), asAdmin(httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil)))
+
+	// Reclassify track 1 out of focus
+	req := asAdmin(httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/1", bytes.NewBufferString(`{"mood":"calm"}`)))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil)))
+	if got := w.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("X-Cache = %q, want MISS after update", got)
+	}
+	var tracks []PlaylistTrack
+	_ = json.NewDecoder(w.Body).Decode(&tracks)
+	if len(tracks) != 1 {
+		t.Errorf("got %d focus tracks after reclassification, want 1", len(tracks))
+	}
+}
+
+func TestUpdateTrack_InvalidatesOnlyAffectedMoods(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	playlists := []string{
+		"/api/moods/focus/playlist",
+		"/api/moods/focus/playlist?limit=1",
+		"/api/moods/calm/playlist",
+		"/api/moods/calm/playlist?limit=1",
+	}
+	// cacheStates fetches every playlist, returning its X-Cache by path
+	cacheStates := func() map[string]string {
+		states := make(map[string]string)
+		for _, p := range playlists {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, p, nil)))
+			states[p] = w.Header().Get("X-Cache")
+		}
+		return states
+	}
+	patch := func(id, body string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/"+id, bytes.NewBufferString(body))))
+		if w.Code != http.StatusOK {
+			t.Fatalf("PATCH status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+		}
+	}
+	expect := func(step string, want map[string]string) {
+		t.Helper()
+		got := cacheStates()
+		for p, state := range want {
+			if got[p] != state {
+				t.Errorf("%s: %s X-Cache = %q, want %q", step, p, got[p], state)
+			}
+		}
+	}
+
+	cacheStates() // warm
+
+	// Editing a calm track leaves every focus variant cached
+	patch("3", `{"title":"Calmer"}`)
+	expect("calm edit", map[string]string{
+		"/api/moods/focus/playlist": "HIT", "/api/moods/focus/playlist?limit=1": "HIT",
+		"/api/moods/calm/playlist": "MISS", "/api/moods/calm/playlist?limit=1": "MISS",
+	})
+
+	// Moving a track between moods refreshes both
+	patch("1", `{"mood":"calm"}`)
+	expect("mood change", map[string]string{
+		"/api/moods/focus/playlist": "MISS", "/api/moods/calm/playlist?limit=1": "MISS",
+	})
+
+	// A mood that borrows from focus is refreshed with it
+	h.SetFallbackMoods(map[string]string{"calm": "focus"})
+	patch("2", `{"title":"Deeper"}`)
+	expect("fallback", map[string]string{
+		"/api/moods/focus/playlist": "MISS", "/api/moods/calm/playlist": "MISS",
+	})
+}
+
+func TestRadioState(t *testing.T) {
+	repo := setupTestDB(t)
+	mgr := radio.NewManager(repo)
+	h := NewHandler(repo, mgr, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	mgr.RecordPlay("focus", 1)
+	mgr.RecordPlay("focus", 2)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		remoteAddr string
+		wantStatus int
+		wantCode   string
+	}{
+		{"localhost", http.MethodGet, "/api/admin/radio/focus", "127.0.0.1:40000", http.StatusOK, ""},
+		{"remote client with token", http.MethodGet, "/api/admin/radio/focus", "203.0.113.9:40000", http.StatusOK, ""},
+		{"unknown mood", http.MethodGet, "/api/admin/radio/focuss", "127.0.0.1:40000", http.StatusNotFound, CodeMoodNotFound},
+		{"wrong method", http.MethodPost, "/api/admin/radio/focus", "127.0.0.1:40000", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, tt.path, nil))
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			var snap radio.StateSnapshot
+			if err := json.NewDecoder(w.Body).Decode(&snap); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !slices.Equal(snap.RecentlyPlayed, []int64{1, 2}) || snap.MaxRecent != radio.DefaultMaxRecent {
+				t.Errorf("snapshot = %+v, want recent [1 2] with default max", snap)
+			}
+		})
+	}
+}
+
+func TestStatusHistory(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	// Reject track 1 with alice's token and a reason
+	req := asAdmin(httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/1",
+		bytes.NewBufferString(`{"status":"rejected","reason":"clipping at 1:20"}`)))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// Approve it again in a session started with bob's token; a header
+	// can't claim another identity
+	bobToken := "bob-admin-token-0123456789"
+	if err := h.SetAdminTokens(map[string]string{testAdminName: testAdminToken, "bob": bobToken}); err != nil {
+		t.Fatalf("SetAdminTokens: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/session", nil)
+	req.Header.Set("Authorization", "Bearer "+bobToken)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var csrf CSRFInfo
+	if err := json.NewDecoder(w.Body).Decode(&csrf); err != nil {
+		t.Fatalf("failed to decode session: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/1", bytes.NewBufferString(`{"status":"approved"}`))
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set(csrfHeader, csrf.Token)
+	req.Header.Set("X-Forwarded-User", "mallory")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("session PATCH status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		remoteAddr string
+		wantStatus int
+		wantCode   string
+	}{
+		{"localhost", http.MethodGet, "/api/admin/tracks/1/history", "127.0.0.1:40000", http.StatusOK, ""},
+		{"remote client with token", http.MethodGet, "/api/admin/tracks/1/history", "203.0.113.9:40000", http.StatusOK, ""},
+		{"unknown track", http.MethodGet, "/api/admin/tracks/999/history", "127.0.0.1:40000", http.StatusNotFound, CodeTrackNotFound},
+		{"invalid id", http.MethodGet, "/api/admin/tracks/abc/history", "127.0.0.1:40000", http.StatusBadRequest, CodeInvalidTrackID},
+		{"wrong method", http.MethodPost, "/api/admin/tracks/1/history", "127.0.0.1:40000", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, tt.path, nil))
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+
+			var history []inventory.StatusChange
+			if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("got %d history rows, want 2: %+v", len(history), history)
+			}
+			first, second := history[0], history[1]
+			if first.OldStatus != inventory.StatusApproved || first.NewStatus != inventory.StatusRejected ||
+				first.Actor != testAdminName || first.Reason != "clipping at 1:20" {
+				t.Errorf("history[0] = %+v, want approved->rejected by %s with reason", first, testAdminName)
+			}
+			if second.Actor != "bob" {
+				t.Errorf("history[1].Actor = %q, want bob, whose token started the session", second.Actor)
+			}
+		})
+	}
+
+	t.Run("non-string reason", func(t *testing.T) {
+		req := asAdmin(httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/1", bytes.NewBufferString(`{"status":"approved","reason":5}`)))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if code := errorCode(t, w); w.Code != http.StatusBadRequest || code != CodeInvalidField {
+			t.Errorf("status = %d code = %q, want %d %q", w.Code, code, http.StatusBadRequest, CodeInvalidField)
+		}
+	})
+}
+
+func TestSessionAnalytics(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"default window", http.MethodGet, "", http.StatusOK, ""},
+		{"explicit days", http.MethodGet, "?days=30", http.StatusOK, ""},
+		{"zero days", http.MethodGet, "?days=0", http.StatusBadRequest, CodeInvalidDays},
+		{"too many days", http.MethodGet, "?days=1000", http.StatusBadRequest, CodeInvalidDays},
+		{"non-numeric", http.MethodGet, "?days=week", http.StatusBadRequest, CodeInvalidDays},
+		{"wrong method", http.MethodPost, "", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, "/api/admin/analytics/sessions"+tt.query, nil))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordPlay_SessionID(t *testing.T) {
+	c := setupTestCache(t)
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, c)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := `{"event":"play","session_id":"tab-123"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := repo.recordListenEventCalls[0].SessionID; got != "tab-123" {
+		t.Errorf("session_id = %q, want %q", got, "tab-123")
+	}
+
+	long := fmt.Sprintf(`{"event":"play","session_id":%q}`, strings.Repeat("x", 65))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(long)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d for oversized session id", w.Code, http.StatusBadRequest)
+	}
+	if code := errorCode(t, w); code != CodeInvalidSessionID {
+		t.Errorf("code = %q, want %q", code, CodeInvalidSessionID)
+	}
+}
+
+func TestRecordPlay_Client(t *testing.T) {
+	c := setupTestCache(t)
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, c)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := `{"event":"play","client":{"platform":"iOS","app_version":"2.4.1"}}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	want := inventory.ClientInfo{Platform: inventory.PlatformIOS, AppVersion: "2.4.1"}
+	if got := repo.recordListenEventCalls[0].Client; got != want {
+		t.Errorf("client = %+v, want %+v", got, want)
+	}
+
+	// Without a client object the platform comes from the User-Agent
+	req := httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(`{"event":"play"}`))
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Linux; Android 14; Pixel 8)")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := repo.recordListenEventCalls[1].Client.Platform; got != inventory.PlatformAndroid {
+		t.Errorf("platform = %q, want %q", got, inventory.PlatformAndroid)
+	}
+
+	long := fmt.Sprintf(`{"event":"play","client":{"app_version":%q}}`, strings.Repeat("1", 33))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(long)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d for oversized app version", w.Code, http.StatusBadRequest)
+	}
+	if code := errorCode(t, w); code != CodeInvalidClient {
+		t.Errorf("code = %q, want %q", code, CodeInvalidClient)
+	}
+}
+
+func TestRecordPlay_PlaylistID(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	repo := setupTestDBAt(t, dbPath)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+	var playlist []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&playlist); err != nil {
+		t.Fatalf("failed to decode playlist: %v", err)
+	}
+	id := w.Header().Get("X-Playlist-ID")
+	if id == "" {
+		t.Fatal("playlist response has no X-Playlist-ID")
+	}
+	for _, track := range playlist {
+		if track.PlaylistID != id {
+			t.Errorf("track %d playlist_id = %q, want %q", track.ID, track.PlaylistID, id)
+		}
+	}
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"event":"skip","position":1,"playlist_id":%q}`, id),
+		`{"event":"play","position":0}`,
+	} {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	got := map[string]sql.NullString{}
+	rows, err := db.Query(`SELECT event_type, playlist_id FROM listen_events`)
+	if err != nil {
+		t.Fatalf("failed to query listen events: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var event string
+		var playlistID sql.NullString
+		if err := rows.Scan(&event, &playlistID); err != nil {
+			t.Fatalf("failed to scan listen event: %v", err)
+		}
+		got[event] = playlistID
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d listen events, want 2", len(got))
+	}
+	if got["skip"].String != id {
+		t.Errorf("skip playlist_id = %q, want %q", got["skip"].String, id)
+	}
+	if got["play"].Valid {
+		t.Errorf("play playlist_id = %q, want NULL", got["play"].String)
+	}
+
+	long := fmt.Sprintf(`{"event":"play","playlist_id":%q}`, strings.Repeat("x", 65))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(long)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d for oversized playlist id", w.Code, http.StatusBadRequest)
+	}
+	if code := errorCode(t, w); code != CodeInvalidPlaylistID {
+		t.Errorf("code = %q, want %q", code, CodeInvalidPlaylistID)
+	}
+}
+
+func TestRecordPlay_SkipReason(t *testing.T) {
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	q := &mockQueue{}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	h.SetEventQueue(q)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body)))
+		return w
+	}
+
+	for _, reason := range []string{"dislike", "wrong_mood", "too_long", "repeat", "other"} {
+		if w := post(`{"event":"skip","reason":"` + reason + `"}`); w.Code != http.StatusAccepted {
+			t.Errorf("skip with reason %s: status = %d, want %d: %s", reason, w.Code, http.StatusAccepted, w.Body.String())
+		}
+	}
+	if w := post(`{"event":"skip"}`); w.Code != http.StatusAccepted {
+		t.Errorf("skip without reason: status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if len(q.events) != 6 {
+		t.Fatalf("queued %d events, want 6", len(q.events))
+	}
+	if q.events[1].Reason != inventory.SkipReasonWrongMood || q.events[5].Reason != "" {
+		t.Errorf("reasons = %q, %q; want wrong_mood and none", q.events[1].Reason, q.events[5].Reason)
+	}
+
+	for _, body := range []string{
+		`{"event":"skip","reason":"boring"}`,
+		`{"event":"skip","reason":"Dislike"}`,
+		`{"event":"play","reason":"dislike"}`,
+		`{"event":"complete","reason":"other"}`,
+		`{"reason":"dislike"}`, // defaults to a play
+		`{"event":"progress","position_seconds":30,"reason":"other"}`,
+	} {
+		w := post(body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", body, w.Code, http.StatusBadRequest)
+			continue
+		}
+		if code := errorCode(t, w); code != CodeInvalidReason {
+			t.Errorf("%s: code = %q, want %q", body, code, CodeInvalidReason)
+		}
+	}
+	if len(q.events) != 6 {
+		t.Errorf("queued %d events, want the 6 valid ones", len(q.events))
+	}
+}
+
+func TestGetPlaylist_BorrowedTracksTagged(t *testing.T) {
+	repo := setupTestDB(t)
+	mgr := radio.NewManager(repo)
+	mgr.SetBorrowing(3, map[string]string{"calm": "focus"})
+	h := NewHandler(repo, mgr, &mockResolver{}, setupTestCache(t))
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/calm/playlist", nil))
+
+	var tracks []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3", len(tracks))
+	}
+	if tracks[0].BorrowedFrom != "" {
+		t.Errorf("own track tagged as borrowed from %q", tracks[0].BorrowedFrom)
+	}
+	for _, tr := range tracks[1:] {
+		if tr.BorrowedFrom != "focus" {
+			t.Errorf("track %d borrowed_from = %q, want focus", tr.ID, tr.BorrowedFrom)
+		}
+	}
+}
+
+func TestExportTracks(t *testing.T) {
+	repo := setupTestDB(t)
+	_ = repo.SetTags(1, []string{"piano"})
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	req := asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/tracks/export", nil))
+	req.RemoteAddr = "127.0.0.1:40000"
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	for i, line := range lines {
+		var track inventory.Track
+		if err := json.Unmarshal([]byte(line), &track); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v (%s)", i+1, err, line)
+		}
+		if track.ID != int64(i+1) {
+			t.Errorf("line %d id = %d, want %d", i+1, track.ID, i+1)
+		}
+	}
+}
+
+func TestExportTracks_Errors(t *testing.T) {
+	repo := newMockRepo()
+	repo.streamTracksErr = errors.New("db error")
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		remoteAddr string
+		wantStatus int
+		wantCode   string
+	}{
+		{"wrong method", http.MethodPost, "127.0.0.1:40000", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"stream failure", http.MethodGet, "[::1]:40000", http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, "/api/admin/tracks/export", nil))
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestInvalidTracks(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		remoteAddr string
+		wantStatus int
+	}{
+		{"localhost", http.MethodGet, "127.0.0.1:40000", http.StatusOK},
+		{"remote client with token", http.MethodGet, "203.0.113.9:40000", http.StatusOK},
+		{"wrong method", http.MethodPost, "127.0.0.1:40000", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, "/api/admin/tracks/invalid", nil))
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var tracks []inventory.Track
+			if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(tracks) != 0 {
+				t.Errorf("got %d invalid tracks, want 0 for seeded durations", len(tracks))
+			}
+		})
+	}
+}
+
+func TestDuplicateTracks(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		remoteAddr string
+		wantStatus int
+	}{
+		{"localhost", http.MethodGet, "127.0.0.1:40000", http.StatusOK},
+		{"remote client with token", http.MethodGet, "203.0.113.9:40000", http.StatusOK},
+		{"wrong method", http.MethodPost, "127.0.0.1:40000", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, "/api/admin/duplicates", nil))
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var groups []inventory.DuplicateGroup
+			if err := json.NewDecoder(w.Body).Decode(&groups); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if groups == nil || len(groups) != 0 {
+				t.Errorf("groups = %v, want empty list for unhashed seed data", groups)
+			}
+		})
+	}
+}
+
+func TestRecalculatePlayStats(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		remoteAddr string
+		wantStatus int
+		wantCode   string
+	}{
+		{"default strategy", http.MethodPost, "", "127.0.0.1:40000", http.StatusOK, ""},
+		{"events strategy", http.MethodPost, "?strategy=events", "127.0.0.1:40000", http.StatusOK, ""},
+		{"unknown strategy", http.MethodPost, "?strategy=sum", "127.0.0.1:40000", http.StatusBadRequest, CodeInvalidStrategy},
+		{"remote client with token", http.MethodPost, "", "203.0.113.9:40000", http.StatusOK, ""},
+		{"wrong method", http.MethodGet, "", "127.0.0.1:40000", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, "/api/admin/playstats/recalculate"+tt.query, nil))
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			var result inventory.RecalcResult
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if result.Strategy == "" {
+				t.Error("strategy missing from result")
+			}
+		})
+	}
+}
+
+func TestGetPlaylist_UnknownMoodSuggestions(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name           string
+		mood           string
+		wantSuggestion string
+	}{
+		{"near miss", "focuss", "focus"},
+		{"case and typo", "Clam", "calm"},
+		{"no close match", "polka", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/"+tt.mood+"/playlist", nil))
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			var resp struct {
+				Error struct {
+					Code    string             `json:"code"`
+					Details UnknownMoodDetails `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Error.Code != CodeMoodNotFound {
+				t.Errorf("code = %q, want %q", resp.Error.Code, CodeMoodNotFound)
+			}
+			want := []string{"calm", "energize", "focus", "late_night"}
+			if !slices.Equal(resp.Error.Details.ValidMoods, want) {
+				t.Errorf("valid_moods = %v, want %v", resp.Error.Details.ValidMoods, want)
+			}
+			if resp.Error.Details.Suggestion != tt.wantSuggestion {
+				t.Errorf("suggestion = %q, want %q", resp.Error.Details.Suggestion, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestGetPlaylists(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/playlists"+query, nil))
+		return w
+	}
+
+	w := get("?moods=focus,calm,focus&limit=1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var playlists map[string][]PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&playlists); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(playlists) != 2 {
+		t.Errorf("got %d playlists, want 2 (focus and calm, deduplicated)", len(playlists))
+	}
+	for _, mood := range []string{"focus", "calm"} {
+		tracks, ok := playlists[mood]
+		if !ok {
+			t.Errorf("missing %s playlist", mood)
+			continue
+		}
+		if len(tracks) != 1 {
+			t.Errorf("%s has %d tracks, want 1 (limit)", mood, len(tracks))
+		}
+		for _, tr := range tracks {
+			if !strings.HasPrefix(tr.FilePath, mood+"/") {
+				t.Errorf("%s playlist has track %s", mood, tr.FilePath)
+			}
+		}
+	}
+
+	// The per-mood entries are shared with the single-mood endpoint
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/calm/playlist?limit=1", nil))
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("single-mood X-Cache = %q, want HIT", got)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown mood", "?moods=focus,focuss", http.StatusNotFound, CodeMoodNotFound},
+		{"no moods", "?moods=,", http.StatusBadRequest, CodeInvalidMoods},
+		{"bad limit", "?moods=focus&limit=0", http.StatusBadRequest, CodeInvalidLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.query)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGetPlaylist_Limit(t *testing.T) {
+	r := &mockRadio{}
+	h := NewHandler(newMockRepo(), r, &mockResolver{}, setupTestCache(t))
+	h.SetMaxPlaylistSize(60)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantLimit  int
+	}{
+		{"no limit uses default", "", http.StatusOK, 0},
+		{"within max", "?limit=20", http.StatusOK, 20},
+		{"absurd limit clamped", "?limit=1000000", http.StatusOK, 60},
+		{"zero", "?limit=0", http.StatusBadRequest, -1},
+		{"negative", "?limit=-5", http.StatusBadRequest, -1},
+		{"non-numeric", "?limit=all", http.StatusBadRequest, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.lastLimit = -1
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if code := errorCode(t, w); code != CodeInvalidLimit {
+					t.Errorf("code = %q, want %q", code, CodeInvalidLimit)
+				}
+				return
+			}
+			if r.lastLimit != tt.wantLimit {
+				t.Errorf("radio limit = %d, want %d", r.lastLimit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestRecordPlay_DedupRapidRepeat(t *testing.T) {
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	h.SetPlayDedup(2*time.Second, 100)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	play := func(remoteAddr, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", bytes.NewBufferString(body))
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Double click: both answered 200, one increment
+	for i := range 2 {
+		if code := play("10.0.0.1:5000", ""); code != http.StatusOK {
+			t.Fatalf("play %d: status = %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+	if repo.updatePlayStatsCalls != 1 || len(repo.recordListenEventCalls) != 1 {
+		t.Errorf("play_stats updates = %d, listen events = %d; want 1 each",
+			repo.updatePlayStatsCalls, len(repo.recordListenEventCalls))
+	}
+
+	// Another client and non-play events are not deduplicated
+	play("10.0.0.2:5000", "")
+	play("10.0.0.1:5000", `{"event":"complete"}`)
+	if repo.updatePlayStatsCalls != 3 {
+		t.Errorf("play_stats updates = %d, want 3", repo.updatePlayStatsCalls)
+	}
+
+	// Behind the proxy, the client's own X-Forwarded-For entries neither
+	// dodge the window nor spend another listener's
+	proxied := func(xff string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", nil)
+		req.RemoteAddr = "127.0.0.1:5000"
+		req.Header.Set("X-Forwarded-For", xff)
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	proxied("198.51.100.1, 203.0.113.9")
+	proxied("198.51.100.2, 203.0.113.9")
+	if repo.updatePlayStatsCalls != 4 {
+		t.Errorf("play_stats updates = %d, want 4: rotating forged hops dodged the window", repo.updatePlayStatsCalls)
+	}
+	proxied("203.0.113.11, 203.0.113.10")
+	proxied("203.0.113.10")
+	if repo.updatePlayStatsCalls != 5 {
+		t.Errorf("play_stats updates = %d, want 5", repo.updatePlayStatsCalls)
+	}
+	proxied("203.0.113.11")
+	if repo.updatePlayStatsCalls != 6 {
+		t.Errorf("play_stats updates = %d, want 6: a forged hop spent another listener's play", repo.updatePlayStatsCalls)
+	}
+}
+
+func TestRecordPlay_DedupReleasedOnFailure(t *testing.T) {
+	repo := newMockRepo()
+	repo.getByIDResult = &inventory.Track{ID: 1, Mood: "focus"}
+	repo.updatePlayStatsErr = errors.New("db busy")
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	h.SetPlayDedup(2*time.Second, 100)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	// The client's retry must be written, not swallowed as a duplicate
+	repo.updatePlayStatsErr = nil
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tracks/1/play", nil))
+	if w.Code != http.StatusOK || repo.updatePlayStatsCalls != 2 {
+		t.Errorf("retry: status = %d, updates = %d; want 200 and 2", w.Code, repo.updatePlayStatsCalls)
+	}
+}
+
+// mockRooms implements Rooms, returning a fixed error
+type mockRooms struct {
+	err  error
+	room string
+}
+
+func (m *mockRooms) Serve(_ http.ResponseWriter, _ *http.Request, room string) error {
+	m.room = room
+	return m.err
+}
+
+var _ Rooms = (*mockRooms)(nil)
+
+func TestHandleRooms(t *testing.T) {
+	tests := []struct {
+		name       string
+		rooms      *mockRooms // nil = rooms disabled
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"disabled", nil, http.MethodGet, "/api/rooms/friday/ws", http.StatusNotFound, CodeNotFound},
+		{"missing ws suffix", &mockRooms{}, http.MethodGet, "/api/rooms/friday", http.StatusNotFound, CodeNotFound},
+		{"wrong method", &mockRooms{}, http.MethodPost, "/api/rooms/friday/ws", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"invalid room", &mockRooms{err: rooms.ErrInvalidRoom}, http.MethodGet, "/api/rooms/BAD/ws", http.StatusBadRequest, CodeInvalidRoom},
+		{"room full", &mockRooms{err: rooms.ErrRoomFull}, http.MethodGet, "/api/rooms/friday/ws", http.StatusServiceUnavailable, CodeRoomFull},
+		{"too many rooms", &mockRooms{err: rooms.ErrTooManyRooms}, http.MethodGet, "/api/rooms/friday/ws", http.StatusServiceUnavailable, CodeTooManyRooms},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+			if tt.rooms != nil {
+				h.SetRooms(tt.rooms)
+			}
+			mux := http.NewServeMux()
+			h.RegisterRoutes(mux)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"log"
+	"net/http"
+)
+
+// hideTrack serves POST and DELETE /api/tracks/{id}/hide: adds the track to
+// or removes it from the listener's hidden set, which playlists served to
+// them leave out. POST issues the listener cookie if needed; DELETE without
+// one has nothing to undo. Both answer 204.
+func (h *Handler) hideTrack(w http.ResponseWriter, r *http.Request, id int64) {
+	switch r.Method {
+	case http.MethodPost:
+		track, err := h.repo.GetByID(id)
+		if err != nil {
+			log.Printf("Error fetching track %d to hide: %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+			return
+		}
+		if track == nil {
+			writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+			return
+		}
+		if err := h.repo.HideTrack(ensureListenerID(w, r), id); err != nil {
+			log.Printf("Error hiding track %d: %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+			return
+		}
+	case http.MethodDelete:
+		if listener := listenerID(r); listener != "" {
+			if err := h.repo.UnhideTrack(listener, id); err != nil {
+				log.Printf("Error unhiding track %d: %v", id, err)
+				writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+				return
+			}
+		}
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// hiddenTracks returns the set of tracks the request's listener hid, or nil
+// for a request without a listener cookie
+func (h *Handler) hiddenTracks(r *http.Request) (map[int64]bool, error) {
+	listener := listenerID(r)
+	if listener == "" {
+		return nil, nil
+	}
+	ids, err := h.repo.HiddenTrackIDs(listener)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		hidden[id] = true
+	}
+	return hidden, nil
+}
+
+// withoutHidden returns a cached playlist minus the hidden tracks. The
+// shared cache entry is left untouched: a copy is filtered.
+func withoutHidden(tracks []PlaylistTrack, hidden map[int64]bool) []PlaylistTrack {
+	if len(hidden) == 0 {
+		return tracks
+	}
+	kept := make([]PlaylistTrack, 0, len(tracks))
+	for _, t := range tracks {
+		if !hidden[t.ID] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
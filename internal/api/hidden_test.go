@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestHideTrack(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	var cookie *http.Cookie
+	send := func(method, target string, withCookie bool) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, nil)
+		if withCookie && cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		for _, c := range w.Result().Cookies() {
+			if c.Name == listenerCookie {
+				cookie = c
+			}
+		}
+		return w
+	}
+	playlistIDs := func(w *httptest.ResponseRecorder) []int64 {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("playlist status = %d, want 200", w.Code)
+		}
+		var tracks []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		ids := make([]int64, len(tracks))
+		for i, tr := range tracks {
+			ids[i] = tr.ID
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	const focus = "/api/moods/focus/playlist"
+
+	// Anonymous requests fill and then share the per-mood cache entry
+	if w := send(http.MethodGet, focus, false); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("first request X-Cache = %q, want MISS", w.Header().Get("X-Cache"))
+	}
+
+	if w := send(http.MethodPost, "/api/tracks/1/hide", false); w.Code != http.StatusNoContent {
+		t.Fatalf("hide status = %d, want 204 (%s)", w.Code, w.Body.String())
+	}
+	if cookie == nil {
+		t.Fatal("hide did not issue a listener cookie")
+	}
+
+	// The listener's playlist comes from the shared entry, minus track 1,
+	// and isn't cacheable by shared caches
+	w := send(http.MethodGet, focus, true)
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("personalized X-Cache = %q, want HIT", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+		t.Errorf("personalized Cache-Control = %q, want private, no-store", got)
+	}
+	if ids := playlistIDs(w); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("playlist after hide = %v, want [2]", ids)
+	}
+
+	// Anonymous requests still get the full shared playlist
+	w = send(http.MethodGet, focus, false)
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("anonymous X-Cache = %q, want HIT", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != publicMaxAge(cache.PlaylistTTL) {
+		t.Errorf("anonymous Cache-Control = %q, want public", got)
+	}
+	if ids := playlistIDs(w); !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("anonymous playlist = %v, want [1 2]", ids)
+	}
+
+	// The multi-mood endpoint honors the hidden set too
+	w = send(http.MethodGet, "/api/playlists?moods=focus,calm", true)
+	var multi map[string][]PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&multi); err != nil {
+		t.Fatalf("failed to decode playlists: %v", err)
+	}
+	if len(multi["focus"]) != 1 || multi["focus"][0].ID != 2 || len(multi["calm"]) != 1 {
+		t.Errorf("playlists = %+v, want focus without track 1 and calm unchanged", multi)
+	}
+
+	if w := send(http.MethodDelete, "/api/tracks/1/hide", true); w.Code != http.StatusNoContent {
+		t.Fatalf("unhide status = %d, want 204", w.Code)
+	}
+	w = send(http.MethodGet, focus, true)
+	if ids := playlistIDs(w); !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("playlist after unhide = %v, want [1 2]", ids)
+	}
+	if got := w.Header().Get("Cache-Control"); got != publicMaxAge(cache.PlaylistTTL) {
+		t.Errorf("Cache-Control with nothing hidden = %q, want public", got)
+	}
+}
+
+func TestHideTrack_Validation(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown track", http.MethodPost, "/api/tracks/999/hide", http.StatusNotFound, CodeTrackNotFound},
+		{"bad id", http.MethodPost, "/api/tracks/abc/hide", http.StatusBadRequest, CodeInvalidTrackID},
+		{"wrong method", http.MethodPut, "/api/tracks/1/hide", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+
+	// Unhiding without a cookie has nothing to undo
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/tracks/1/hide", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("anonymous unhide = %d, want 204", w.Code)
+	}
+}
+
+func TestWithoutHidden(t *testing.T) {
+	cached := []PlaylistTrack{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	tracks := withoutHidden(cached, map[int64]bool{2: true})
+	if len(tracks) != 2 || tracks[0].ID != 1 || tracks[1].ID != 3 {
+		t.Errorf("tracks = %+v, want 1 and 3", tracks)
+	}
+	if cached[1].ID != 2 || len(cached) != 3 {
+		t.Error("the cached playlist was modified")
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// checkIntegrity serves GET /api/admin/db/integrity?mode=quick|full: runs
+// SQLite's integrity check and reports what it found. A corrupt database is
+// a 200 with "ok": false. The check holds the repository's only connection,
+// so other queries wait until it finishes; a full check on a large database
+// can take minutes.
+func (h *Handler) checkIntegrity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = inventory.IntegrityQuick
+	}
+
+	report, err := h.repo.CheckIntegrity(r.Context(), mode)
+	if errors.Is(err, inventory.ErrInvalidIntegrityMode) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidMode, "mode must be quick or full")
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking database integrity: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if report.OK {
+		log.Printf("Database integrity check (%s) passed in %dms", report.Mode, report.DurationMs)
+	} else {
+		log.Printf("Error: database integrity check (%s) found %d problem(s)", report.Mode, len(report.Problems))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding integrity report: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		wantStatus int
+		wantMode   string
+		wantCode   string
+	}{
+		{"default quick", http.MethodGet, "", http.StatusOK, inventory.IntegrityQuick, ""},
+		{"full", http.MethodGet, "?mode=full", http.StatusOK, inventory.IntegrityFull, ""},
+		{"unknown mode", http.MethodGet, "?mode=off", http.StatusBadRequest, "", CodeInvalidMode},
+		{"wrong method", http.MethodPost, "", http.StatusMethodNotAllowed, "", CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(tt.method, "/api/admin/db/integrity"+tt.query, nil)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			var report inventory.IntegrityReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !report.OK || report.Mode != tt.wantMode || len(report.Problems) != 0 {
+				t.Errorf("report = %+v, want an ok %s check", report, tt.wantMode)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/db/integrity", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without a token = %d, want 401", w.Code)
+	}
+}
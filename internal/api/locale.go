@@ -0,0 +1,102 @@
+package api
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/mood"
+)
+
+// DefaultLocale is served when no requested locale is supported
+const DefaultLocale = "en"
+
+// defaultDisplayNames holds the built-in English mood names, used until
+// SetDisplayNames is called
+var defaultDisplayNames = map[string]map[string]string{
+	DefaultLocale: mood.DisplayNames(),
+}
+
+// requestLocale picks the response locale: ?lang= if supported, else the
+// highest-weighted supported Accept-Language entry, else DefaultLocale.
+// Tags match on their primary subtag, so es-MX selects es.
+func requestLocale(r *http.Request, supported map[string]map[string]string) string {
+	if lang := primarySubtag(r.URL.Query().Get("lang")); lang != "" {
+		if _, ok := supported[lang]; ok {
+			return lang
+		}
+	}
+	for _, lang := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if _, ok := supported[lang]; ok {
+			return lang
+		}
+	}
+	return DefaultLocale
+}
+
+// parseAcceptLanguage returns the primary subtags of an Accept-Language
+// header ordered by q-value, highest first; ties keep header order.
+// Wildcards and entries with q=0 or a malformed q are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var entries []weighted
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang := primarySubtag(tag)
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				continue
+			}
+			q = parsed
+		}
+		if q == 0 {
+			continue
+		}
+		entries = append(entries, weighted{lang, q})
+	}
+
+	slices.SortStableFunc(entries, func(a, b weighted) int { return cmp.Compare(b.q, a.q) })
+	langs := make([]string, len(entries))
+	for i, e := range entries {
+		langs[i] = e.lang
+	}
+	return langs
+}
+
+// primarySubtag lowercases a language tag's primary subtag ("es-MX" → "es").
+// Returns "" for the wildcard or anything that isn't 2-8 ASCII letters.
+func primarySubtag(tag string) string {
+	lang, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	if len(lang) < 2 || len(lang) > 8 {
+		return ""
+	}
+	for i := 0; i < len(lang); i++ {
+		c := lang[i] | 0x20 // ASCII lowercase
+		if c < 'a' || c > 'z' {
+			return ""
+		}
+	}
+	return strings.ToLower(lang)
+}
+
+// displayName returns a mood's name in locale, falling back to the default
+// locale and then to the raw identifier.
+func displayName(names map[string]map[string]string, locale, mood string) string {
+	if name := names[locale][mood]; name != "" {
+		return name
+	}
+	if name := names[DefaultLocale][mood]; name != "" {
+		return name
+	}
+	return mood
+}
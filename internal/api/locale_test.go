@@ -0,0 +1,104 @@
+package api
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/mood"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"es", []string{"es"}},
+		{"es-MX,es;q=0.9,en;q=0.8", []string{"es", "es", "en"}},
+		{"en;q=0.5, fr-CA;q=0.9, de", []string{"de", "fr", "en"}},
+		{"fr;q=0.7,es;q=0.7", []string{"fr", "es"}}, // ties keep header order
+		{"es;q=0, en", []string{"en"}},              // q=0 means not acceptable
+		{"*, es;q=0.5", []string{"es"}},
+		{"es;q=abc, en;q=2, de", []string{"de"}}, // malformed q dropped
+		{"x1-Y, ZH-Hant", []string{"zh"}},
+	}
+
+	for _, tt := range tests {
+		got := parseAcceptLanguage(tt.header)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	supported := map[string]map[string]string{
+		"en": {"late_night": "Late Night"},
+		"es": {"late_night": "Noche"},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		acceptLanguage string
+		want           string
+	}{
+		{"no preference", "", "", "en"},
+		{"header region tag", "", "es-MX,en;q=0.5", "es"},
+		{"header q-value order", "", "en;q=0.3,es;q=0.8", "es"},
+		{"unsupported falls through", "", "fr-FR,es;q=0.4", "es"},
+		{"nothing supported", "", "fr,de;q=0.9", "en"},
+		{"query wins over header", "?lang=es", "en", "es"},
+		{"unsupported query uses header", "?lang=fr", "es", "es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/moods"+tt.query, nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if got := requestLocale(r, supported); got != tt.want {
+				t.Errorf("requestLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	names := map[string]map[string]string{
+		"en": {"calm": "Calm", "late_night": "Late Night"},
+		"es": {"late_night": "Noche"},
+	}
+
+	tests := []struct {
+		locale, mood, want string
+	}{
+		{"es", "late_night", "Noche"},
+		{"es", "calm", "Calm"},   // missing translation → English
+		{"es", "rainy", "rainy"}, // unknown mood → identifier
+		{"de", "calm", "Calm"},   // unknown locale → English
+	}
+
+	for _, tt := range tests {
+		if got := displayName(names, tt.locale, tt.mood); got != tt.want {
+			t.Errorf("displayName(%s, %s) = %q, want %q", tt.locale, tt.mood, got, tt.want)
+		}
+	}
+}
+
+func TestMoodRegistryInSync(t *testing.T) {
+	want := mood.Names()
+	if got := slices.Sorted(maps.Keys(validMoods)); !slices.Equal(got, want) {
+		t.Errorf("validMoods = %v, want the registry %v", got, want)
+	}
+	if !slices.Equal(moodNames, want) {
+		t.Errorf("moodNames = %v, want the registry %v", moodNames, want)
+	}
+	if got := slices.Sorted(maps.Keys(defaultDisplayNames[DefaultLocale])); !slices.Equal(got, want) {
+		t.Errorf("default display names cover %v, want the registry %v", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// How playlist responses carry lyrics (radio.playlist_lyrics)
+const (
+	LyricsOmit    = "omit"    // has_lyrics only; GET /api/tracks/{id} has the text
+	LyricsPreview = "preview" // the first lyrics_preview_length characters
+	LyricsFull    = "full"    // the whole text
+)
+
+// DefaultLyricsPreviewLength is how many characters of lyrics a preview keeps
+const DefaultLyricsPreviewLength = 280
+
+// SetPlaylistLyrics sets how playlist responses carry lyrics: LyricsOmit
+// (the default, also for an unknown mode), LyricsPreview cut to
+// previewLength characters, or LyricsFull
+func (h *Handler) SetPlaylistLyrics(mode string, previewLength int) {
+	if previewLength < 1 {
+		previewLength = DefaultLyricsPreviewLength
+	}
+	h.lyricsMode = mode
+	h.lyricsPreview = previewLength
+}
+
+// slimLyrics applies the playlist lyrics mode to a converted track, whose
+// Lyrics still hold the full text, and flags whether it has any
+func (h *Handler) slimLyrics(t *PlaylistTrack) {
+	t.HasLyrics = t.Lyrics != nil && *t.Lyrics != ""
+	switch {
+	case !t.HasLyrics:
+		t.Lyrics = nil
+	case h.lyricsMode == LyricsFull:
+	case h.lyricsMode == LyricsPreview:
+		preview, cut := truncateRunes(*t.Lyrics, h.lyricsPreview)
+		t.Lyrics, t.LyricsTruncated = &preview, cut
+	default:
+		t.Lyrics = nil
+	}
+}
+
+// truncateRunes cuts s to at most n characters, back to the last word
+// break when there is one, ending the cut text with an ellipsis. It
+// reports whether it cut.
+func truncateRunes(s string, n int) (string, bool) {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s, false
+	}
+	cut := string(runes[:n])
+	if !unicode.IsSpace(runes[n]) {
+		if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRightFunc(cut, unicode.IsSpace) + "…", true
+}
+
+// getTrack serves GET /api/tracks/{id}: one servable track in the playlist
+// shape with its full lyrics, which playlists omit or cut short. Tracks out
+// of rotation, and beta tracks not rolled out to the caller, are a 404.
+func (h *Handler) getTrack(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	track, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching track %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track == nil || !track.RolledOutTo(inventory.RolloutBucket(listenerID(r))) {
+		writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		return
+	}
+	h.resolveAudioURL(track)
+
+	resp := h.toPlaylistTracks("", []*inventory.Track{track})[0]
+	if resp.HasLyrics {
+		resp.Lyrics, resp.LyricsTruncated = track.Lyrics, false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding track %d: %v", id, err)
+	}
+}
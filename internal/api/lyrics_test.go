@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+// lyricsTestHandler serves the test database plus a focus track with
+// lyrics, whose ID it returns
+func lyricsTestHandler(t *testing.T, lyrics string) (*Handler, *http.ServeMux, int64) {
+	t.Helper()
+	repo := setupTestDB(t)
+	title := "Sung"
+	id, err := repo.InsertTrack(&inventory.Track{FilePath: "focus/sung.mp3", Title: &title, Mood: "focus", DurationSeconds: 180, Lyrics: &lyrics, Status: inventory.StatusApproved})
+	if err != nil {
+		t.Fatalf("InsertTrack failed: %v", err)
+	}
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return h, mux, id
+}
+
+// playlistTrack fetches the focus playlist and returns its track id
+func playlistTrack(t *testing.T, mux *http.ServeMux, id int64) PlaylistTrack {
+	t.Helper()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var playlist []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&playlist); err != nil {
+		t.Fatalf("failed to decode playlist: %v", err)
+	}
+	for _, track := range playlist {
+		if track.ID == id {
+			return track
+		}
+	}
+	t.Fatalf("track %d not in playlist", id)
+	return PlaylistTrack{}
+}
+
+func TestPlaylistLyrics_OmittedByDefault(t *testing.T) {
+	lyrics := strings.Repeat("la la la\n", 200)
+	_, mux, id := lyricsTestHandler(t, lyrics)
+
+	track := playlistTrack(t, mux, id)
+	if track.Lyrics != nil {
+		t.Errorf("playlist carries %d bytes of lyrics, want none", len(*track.Lyrics))
+	}
+	if !track.HasLyrics {
+		t.Error("has_lyrics = false, want true")
+	}
+	if other := playlistTrack(t, mux, 1); other.HasLyrics {
+		t.Error("track without lyrics has has_lyrics")
+	}
+
+	// The track endpoint has the full text
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/tracks/"+strconv.FormatInt(id, 10), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var detail PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode track: %v", err)
+	}
+	if detail.Lyrics == nil || *detail.Lyrics != lyrics || !detail.HasLyrics || detail.LyricsTruncated {
+		t.Errorf("track = %+v, want its full lyrics", detail)
+	}
+}
+
+func TestPlaylistLyrics_Preview(t *testing.T) {
+	h, mux, id := lyricsTestHandler(t, "Über den Wolken muss die Freiheit wohl grenzenlos sein")
+	h.SetPlaylistLyrics(LyricsPreview, 10)
+
+	track := playlistTrack(t, mux, id)
+	if track.Lyrics == nil || *track.Lyrics != "Über den…" || !track.LyricsTruncated {
+		t.Errorf("track = %+v, want a 10-character preview", track)
+	}
+
+	// A cut inside the first word keeps the characters
+	h.SetPlaylistLyrics(LyricsPreview, 3)
+	h.cache.InvalidateMoods()
+	if track := playlistTrack(t, mux, id); track.Lyrics == nil || *track.Lyrics != "Übe…" {
+		t.Errorf("track = %+v, want a 3-character preview", track)
+	}
+
+	// Short lyrics are sent whole
+	h.SetPlaylistLyrics(LyricsPreview, 500)
+	h.cache.InvalidateMoods()
+	if track := playlistTrack(t, mux, id); track.LyricsTruncated || track.Lyrics == nil || !strings.HasSuffix(*track.Lyrics, "sein") {
+		t.Errorf("track = %+v, want untruncated lyrics", track)
+	}
+}
+
+func TestPlaylistLyrics_Full(t *testing.T) {
+	lyrics := strings.Repeat("verse ", 100)
+	h, mux, id := lyricsTestHandler(t, lyrics)
+	h.SetPlaylistLyrics(LyricsFull, 0)
+
+	if track := playlistTrack(t, mux, id); track.Lyrics == nil || *track.Lyrics != lyrics || track.LyricsTruncated {
+		t.Errorf("track = %+v, want its full lyrics", track)
+	}
+}
+
+func TestGetTrack_NotServable(t *testing.T) {
+	repo := setupTestDB(t)
+	title := "Pending"
+	id, err := repo.InsertTrack(&inventory.Track{FilePath: "focus/pending.mp3", Title: &title, Mood: "focus", DurationSeconds: 180, Status: inventory.StatusPending})
+	if err != nil {
+		t.Fatalf("InsertTrack failed: %v", err)
+	}
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for path, want := range map[string]int{
+		"/api/tracks/999":                          http.StatusNotFound,
+		"/api/tracks/" + strconv.FormatInt(id, 10): http.StatusNotFound,
+		"/api/tracks/x":                            http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
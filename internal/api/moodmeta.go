@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// moodPalette holds the theme colors handed out to moods without one. They
+// are mid-tone hues that read on both light and dark backgrounds.
+var moodPalette = []string{
+	"#3b82f6", // blue
+	"#8b5cf6", // violet
+	"#ec4899", // pink
+	"#f97316", // orange
+	"#eab308", // amber
+	"#22c55e", // green
+	"#14b8a6", // teal
+	"#06b6d4", // cyan
+	"#6366f1", // indigo
+	"#a855f7", // purple
+	"#f43f5e", // rose
+	"#84cc16", // lime
+}
+
+// generatedColor picks a palette color from a hash of the mood name, so a
+// mood keeps its color across restarts and instances
+func generatedColor(mood string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(mood))
+	return moodPalette[h.Sum32()%uint32(len(moodPalette))]
+}
+
+// resolveMoodDisplay layers a mood's presentation metadata: fields stored
+// through the admin API win over configured ones, and a mood with no color
+// either way gets a generated one
+func (h *Handler) resolveMoodDisplay(mood string, stored map[string]inventory.MoodMeta) MoodDisplay {
+	d := h.moodDisplay[mood]
+	if m, ok := stored[mood]; ok {
+		if m.Color != "" {
+			d.Color = m.Color
+		}
+		if m.Icon != "" {
+			d.Icon = m.Icon
+		}
+		if m.Description != "" {
+			d.Description = m.Description
+		}
+	}
+	if d.Color == "" {
+		d.Color = generatedColor(mood)
+	}
+	return d
+}
+
+// handleAdminMoods serves PUT /api/admin/moods/{mood}/meta, replacing the
+// mood's stored color, icon, and description. Fields left out or empty are
+// cleared and fall back to config or a generated color. Responds with the
+// mood's metadata as GET /api/moods now shows it.
+func (h *Handler) handleAdminMoods(w http.ResponseWriter, r *http.Request) {
+	mood, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/admin/moods/"), "/")
+	if sub != "meta" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodPut {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !validMoods[mood] {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood", unknownMoodDetails(mood))
+		return
+	}
+
+	var body MoodDisplay
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		if !bodyTooLarge(w, r, err) {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON body")
+		}
+		return
+	}
+
+	meta := inventory.MoodMeta{
+		Mood:        mood,
+		Color:       strings.TrimSpace(body.Color),
+		Icon:        strings.TrimSpace(body.Icon),
+		Description: strings.TrimSpace(body.Description),
+	}
+	if err := h.repo.SetMoodMeta(meta); err != nil {
+		if errors.Is(err, inventory.ErrInvalidField) {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidField, err.Error())
+			return
+		}
+		log.Printf("Error saving metadata for mood %s: %v", mood, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	h.cache.InvalidateMoodsList()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.resolveMoodDisplay(mood, map[string]inventory.MoodMeta{mood: meta})); err != nil {
+		log.Printf("Error encoding metadata for mood %s: %v", mood, err)
+	}
+}
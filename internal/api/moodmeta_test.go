@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestGeneratedColor(t *testing.T) {
+	// Pinned so a palette or hash change, which would recolor every
+	// unconfigured mood for existing listeners, is deliberate
+	want := map[string]string{
+		"focus":      "#06b6d4",
+		"calm":       "#ec4899",
+		"energize":   "#eab308",
+		"late_night": "#6366f1",
+	}
+	for mood, color := range want {
+		if got := generatedColor(mood); got != color {
+			t.Errorf("generatedColor(%q) = %s, want %s", mood, got, color)
+		}
+	}
+
+	for _, mood := range []string{"", "rainy_day", "ünïcode"} {
+		got := generatedColor(mood)
+		if !slices.Contains(moodPalette, got) {
+			t.Errorf("generatedColor(%q) = %s, not in the palette", mood, got)
+		}
+		if again := generatedColor(mood); again != got {
+			t.Errorf("generatedColor(%q) changed between calls: %s then %s", mood, got, again)
+		}
+	}
+}
+
+func TestPutMoodMeta(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetMoodDisplay(map[string]MoodDisplay{
+		"calm": {Color: "#aabbcc", Icon: "leaf"},
+	})
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	listMoods := func() (map[string]MoodInfo, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods", nil))
+		var moods []MoodInfo
+		if err := json.NewDecoder(w.Body).Decode(&moods); err != nil {
+			t.Fatalf("failed to decode moods: %v", err)
+		}
+		byName := make(map[string]MoodInfo)
+		for _, m := range moods {
+			byName[m.Name] = m
+		}
+		return byName, w.Header().Get("X-Cache")
+	}
+	put := func(mood, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/moods/"+mood+"/meta", bytes.NewBufferString(body))
+		mux.ServeHTTP(w, asAdmin(req))
+		return w
+	}
+
+	// Warm the cache with the configured and generated metadata
+	moods, _ := listMoods()
+	if moods["focus"].Color != generatedColor("focus") {
+		t.Errorf("focus color = %q before update, want generated", moods["focus"].Color)
+	}
+
+	w := put("focus", `{"color":"#112233","icon":"target","description":"Deep work"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var resp MoodDisplay
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp != (MoodDisplay{Color: "#112233", Icon: "target", Description: "Deep work"}) {
+		t.Errorf("PUT response = %+v", resp)
+	}
+
+	moods, cacheState := listMoods()
+	if cacheState != "MISS" {
+		t.Errorf("X-Cache = %q after update, want MISS", cacheState)
+	}
+	if got := moods["focus"].MoodDisplay; got != resp {
+		t.Errorf("focus = %+v after update, want %+v", got, resp)
+	}
+
+	// Stored fields override config; unset ones keep falling back to it
+	if w := put("calm", `{"description":"Wind down"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	moods, _ = listMoods()
+	if got, want := moods["calm"].MoodDisplay, (MoodDisplay{Color: "#aabbcc", Icon: "leaf", Description: "Wind down"}); got != want {
+		t.Errorf("calm = %+v, want %+v", got, want)
+	}
+
+	// Clearing the stored metadata restores the generated color
+	if w := put("focus", `{}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	moods, _ = listMoods()
+	if got, want := moods["focus"].MoodDisplay, (MoodDisplay{Color: generatedColor("focus")}); got != want {
+		t.Errorf("focus = %+v after clearing, want %+v", got, want)
+	}
+}
+
+func TestPutMoodMeta_Errors(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown mood", http.MethodPut, "/api/admin/moods/focuss/meta", `{}`, http.StatusNotFound, CodeMoodNotFound},
+		{"bad color", http.MethodPut, "/api/admin/moods/focus/meta", `{"color":"blue"}`, http.StatusBadRequest, CodeInvalidField},
+		{"unknown field", http.MethodPut, "/api/admin/moods/focus/meta", `{"colour":"#fff"}`, http.StatusBadRequest, CodeInvalidJSON},
+		{"malformed json", http.MethodPut, "/api/admin/moods/focus/meta", `{color`, http.StatusBadRequest, CodeInvalidJSON},
+		{"wrong method", http.MethodPost, "/api/admin/moods/focus/meta", `{}`, http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"missing meta segment", http.MethodPut, "/api/admin/moods/focus", `{}`, http.StatusNotFound, CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// DefaultMoodStatsTTL is how long the per-mood track counts and durations
+// are shared between moods list variants
+const DefaultMoodStatsTTL = 5 * time.Minute
+
+// SetMoodStatsTTL sets how long the per-mood stats aggregation is cached
+// (0 = run it on every moods list miss). Status changes drop the entry
+// sooner.
+func (h *Handler) SetMoodStatsTTL(d time.Duration) {
+	h.moodStatsTTL = d
+}
+
+// moodStats returns the approved track count and total duration of every
+// mood. Each locale, sort, and limit of the moods list is cached on its
+// own, so the aggregation behind them is cached once for all of them.
+func (h *Handler) moodStats() ([]inventory.MoodStats, error) {
+	if h.moodStatsTTL <= 0 {
+		return h.repo.GetMoodStats()
+	}
+	if stats, found := h.statsCache.Get(cache.KeyMoodStats); found {
+		return stats, nil
+	}
+
+	stats, err := h.repo.GetMoodStats()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.statsCache.SetWithTTL(cache.KeyMoodStats, stats, h.moodStatsTTL); err != nil {
+		log.Printf("Warning: failed to cache mood stats: %v", err)
+	}
+	return stats, nil
+}
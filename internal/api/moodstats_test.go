@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+func TestMoodStatsCache(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.NewMemoryStore()
+	store.SetClock(func() time.Time { return now })
+	c := cache.NewWithStore(store)
+	t.Cleanup(func() { _ = c.Close() })
+
+	repo := newMockRepo()
+	repo.getMoodStatsResult = []inventory.MoodStats{{Mood: "focus", TrackCount: 2, TotalSeconds: 420}}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, c)
+	h.SetMoodStatsTTL(time.Minute)
+
+	list := func(query string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.listMoods(w, httptest.NewRequest(http.MethodGet, "/api/moods"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// Each sort is its own moods list entry, but they share the stats
+	list("")
+	list("?sort=tracks")
+	list("?sort=duration")
+	if repo.getMoodStatsCalls != 1 {
+		t.Errorf("GetMoodStats ran %d times within the TTL, want 1", repo.getMoodStatsCalls)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	list("?limit=1")
+	if repo.getMoodStatsCalls != 2 {
+		t.Errorf("GetMoodStats ran %d times after the TTL, want 2", repo.getMoodStatsCalls)
+	}
+
+	// A status change invalidates the mood, and with it the stats
+	c.InvalidateMood("focus")
+	list("")
+	if repo.getMoodStatsCalls != 3 {
+		t.Errorf("GetMoodStats ran %d times after invalidation, want 3", repo.getMoodStatsCalls)
+	}
+}
+
+func TestMoodStatsCache_Disabled(t *testing.T) {
+	repo := newMockRepo()
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	h.SetMoodStatsTTL(0)
+
+	for _, query := range []string{"", "?sort=tracks"} {
+		w := httptest.NewRecorder()
+		h.listMoods(w, httptest.NewRequest(http.MethodGet, "/api/moods"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+	}
+	if repo.getMoodStatsCalls != 2 {
+		t.Errorf("GetMoodStats ran %d times, want 2 with the stats cache off", repo.getMoodStatsCalls)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// Pending review queue paging
+const (
+	DefaultPendingPageSize = 50
+	MaxPendingPageSize     = 200
+)
+
+// listPending serves GET /api/admin/pending?limit=&offset=: tracks awaiting
+// review, oldest first, with audio URLs so they can be previewed
+func (h *Handler) listPending(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	limit := DefaultPendingPageSize
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, MaxPendingPageSize)
+	}
+	offset := 0
+	if raw := q.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidOffset, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+
+	tracks, err := h.repo.GetPending(limit, offset)
+	if err != nil {
+		log.Printf("Error listing pending tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	for _, track := range tracks {
+		h.resolveAudioURL(track)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(tracks); err != nil {
+		log.Printf("Error encoding pending tracks: %v", err)
+	}
+}
+
+// approveTrack approves a pending track and drops its mood's cached
+// playlists so it shows up on the next request
+func (h *Handler) approveTrack(w http.ResponseWriter, r *http.Request, id int64) {
+	err := h.repo.Approve(id, adminActor(r))
+	switch {
+	case err == nil:
+	case errors.Is(err, inventory.ErrTrackNotFound):
+		writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		return
+	case errors.Is(err, inventory.ErrNotPending):
+		writeError(w, r, http.StatusConflict, CodeTrackNotPending, "Track is not pending")
+		return
+	default:
+		log.Printf("Error approving track %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	track, err := h.repo.GetByID(id)
+	if err != nil || track == nil {
+		h.cache.InvalidateMoods()
+		log.Printf("Error reloading track %d after approval: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	h.invalidatePlaylists(track.Mood)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(track); err != nil {
+		log.Printf("Error encoding track %d: %v", id, err)
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+// setupPendingHandler serves the standard test library plus a pending focus
+// track (ID 4) with the admin API enabled
+func setupPendingHandler(t *testing.T) *http.ServeMux {
+	t.Helper()
+	dbPath := t.TempDir() + "/test.db"
+	repo := setupTestDBAt(t, dbPath)
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+		(4, 'focus/pending.mp3', 'Pending Track', 'focus', 210, 'pending')`); err != nil {
+		t.Fatalf("failed to seed pending track: %v", err)
+	}
+	_ = db.Close()
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return mux
+}
+
+func TestListPending(t *testing.T) {
+	mux := setupPendingHandler(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantIDs    []int64
+	}{
+		{"pending only", http.MethodGet, "/api/admin/pending", http.StatusOK, "", []int64{4}},
+		{"past the end", http.MethodGet, "/api/admin/pending?offset=1", http.StatusOK, "", []int64{}},
+		{"bad limit", http.MethodGet, "/api/admin/pending?limit=0", http.StatusBadRequest, CodeInvalidLimit, nil},
+		{"bad offset", http.MethodGet, "/api/admin/pending?offset=x", http.StatusBadRequest, CodeInvalidOffset, nil},
+		{"wrong method", http.MethodPost, "/api/admin/pending", http.StatusMethodNotAllowed, CodeMethodNotAllowed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(tt.method, tt.path, nil)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+
+			var tracks []inventory.Track
+			if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(tracks) != len(tt.wantIDs) {
+				t.Fatalf("got %d tracks, want %d", len(tracks), len(tt.wantIDs))
+			}
+			for i, track := range tracks {
+				if track.ID != tt.wantIDs[i] {
+					t.Errorf("tracks[%d].ID = %d, want %d", i, track.ID, tt.wantIDs[i])
+				}
+				if track.AudioURL != "/audio/"+track.FilePath {
+					t.Errorf("audio_url = %q, want a resolved URL for preview", track.AudioURL)
+				}
+			}
+		})
+	}
+
+	// Unauthenticated requests never reach the queue
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/pending", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want 401", w.Code)
+	}
+}
+
+func TestApproveTrack(t *testing.T) {
+	mux := setupPendingHandler(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown track", http.MethodPost, "/api/admin/tracks/999/approve", http.StatusNotFound, CodeTrackNotFound},
+		{"already approved", http.MethodPost, "/api/admin/tracks/1/approve", http.StatusConflict, CodeTrackNotPending},
+		{"invalid id", http.MethodPost, "/api/admin/tracks/abc/approve", http.StatusBadRequest, CodeInvalidTrackID},
+		{"wrong method", http.MethodGet, "/api/admin/tracks/4/approve", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"approve", http.MethodPost, "/api/admin/tracks/4/approve", http.StatusOK, ""},
+		{"approve twice", http.MethodPost, "/api/admin/tracks/4/approve", http.StatusConflict, CodeTrackNotPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(tt.method, tt.path, nil)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+
+			var track inventory.Track
+			if err := json.NewDecoder(w.Body).Decode(&track); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if track.ID != 4 || track.Status != inventory.StatusApproved {
+				t.Errorf("track = %d %q, want 4 approved", track.ID, track.Status)
+			}
+		})
+	}
+}
+
+func TestApproveTrack_JoinsPlaylist(t *testing.T) {
+	mux := setupPendingHandler(t)
+
+	playlistIDs := func() []int64 {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+		var tracks []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		ids := make([]int64, len(tracks))
+		for i, track := range tracks {
+			ids[i] = track.ID
+		}
+		return ids
+	}
+
+	// Pending tracks stay out, and the playlist is now cached without it
+	if ids := playlistIDs(); slices.Contains(ids, 4) {
+		t.Fatalf("pending track in playlist before approval: %v", ids)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/tracks/4/approve", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("approve status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+
+	if ids := playlistIDs(); !slices.Contains(ids, 4) {
+		t.Errorf("approved track missing from playlist: %v", ids)
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// positionAnalytics serves the listen-through funnel by playlist position:
+// plays, skips and completes at each position and the share of listeners
+// lost before the next one. Query: ?mood= (default all moods), ?days=N
+// (default 7, max 365).
+func (h *Handler) positionAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	mood := r.URL.Query().Get("mood")
+	if mood != "" && !validMoods[mood] {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood: "+mood, unknownMoodDetails(mood))
+		return
+	}
+	since, ok := h.analyticsSince(w, r)
+	if !ok {
+		return
+	}
+
+	funnel, err := h.repo.GetPositionFunnel(mood, since, h.maxPosition)
+	if err != nil {
+		log.Printf("Error computing position funnel: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(funnel); err != nil {
+		log.Printf("Error encoding position funnel: %v", err)
+	}
+}
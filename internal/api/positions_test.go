@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestPositionAnalytics(t *testing.T) {
+	repo := setupTestDB(t)
+	tx, err := repo.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	record := func(mood string, position *int) {
+		t.Helper()
+		evt := inventory.ListenEvent{TrackID: 1, Mood: mood, EventType: inventory.EventPlay, PlaylistPosition: position}
+		if err := repo.RecordListenEventTx(tx, evt); err != nil {
+			t.Fatalf("RecordListenEventTx failed: %v", err)
+		}
+	}
+	at := func(p int) *int { return &p }
+	// Four focus listeners start, one reaches the second track, one gets to
+	// the fourth (past the cap of 2)
+	for range 4 {
+		record("focus", at(0))
+	}
+	record("focus", at(1))
+	record("focus", at(3))
+	record("focus", nil)
+	record("calm", at(0))
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.SetMaxPosition(2)
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/analytics/positions?mood=focus&days=30", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+
+	var funnel inventory.PositionFunnel
+	if err := json.NewDecoder(w.Body).Decode(&funnel); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if funnel.Mood != "focus" || funnel.ExcludedEvents != 1 {
+		t.Errorf("mood = %q, excluded = %d, want focus, 1", funnel.Mood, funnel.ExcludedEvents)
+	}
+	if len(funnel.Positions) != 3 {
+		t.Fatalf("got %d positions, want 3: %+v", len(funnel.Positions), funnel.Positions)
+	}
+	first, second, overflow := funnel.Positions[0], funnel.Positions[1], funnel.Positions[2]
+	if first.Plays != 4 || first.DropOff == nil || *first.DropOff != 0.75 {
+		t.Errorf("position 0 = %+v, want 4 plays with 0.75 drop-off", first)
+	}
+	if second.Plays != 1 || second.Retention != 0.25 || second.DropOff != nil {
+		t.Errorf("position 1 = %+v, want 1 play, 0.25 retention, no drop-off", second)
+	}
+	if !overflow.Overflow || overflow.Position != 2 || overflow.Plays != 1 {
+		t.Errorf("overflow bucket = %+v, want position 2 with 1 play", overflow)
+	}
+}
+
+func TestPositionAnalytics_Errors(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		admin      bool
+		wantStatus int
+		wantCode   string
+	}{
+		{"all moods", http.MethodGet, "", true, http.StatusOK, ""},
+		{"unknown mood", http.MethodGet, "?mood=focs", true, http.StatusNotFound, CodeMoodNotFound},
+		{"days out of range", http.MethodGet, "?mood=focus&days=366", true, http.StatusBadRequest, CodeInvalidDays},
+		{"days not a number", http.MethodGet, "?days=week", true, http.StatusBadRequest, CodeInvalidDays},
+		{"wrong method", http.MethodPost, "", true, http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"no token", http.MethodGet, "", false, http.StatusUnauthorized, CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/admin/analytics/positions"+tt.query, nil)
+			if tt.admin {
+				req = asAdmin(req)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
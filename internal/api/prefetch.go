@@ -0,0 +1,20 @@
+package api
+
+import "net/http"
+
+// SetPrefetchHint adds a Link rel=prefetch header for the second track's
+// audio to playlist responses, so the player can fetch it while the first
+// one plays
+func (h *Handler) SetPrefetchHint(enabled bool) {
+	h.prefetchHint = enabled
+}
+
+// setPrefetchLink points the player at the track after the first one. A
+// playlist of fewer than two tracks, or whose next track has no audio URL,
+// gets no header.
+func (h *Handler) setPrefetchLink(w http.ResponseWriter, tracks []PlaylistTrack) {
+	if !h.prefetchHint || len(tracks) < 2 || tracks[1].AudioURL == "" {
+		return
+	}
+	w.Header().Set("Link", "<"+tracks[1].AudioURL+">; rel=prefetch")
+}
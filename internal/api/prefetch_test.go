@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestGetPlaylist_PrefetchHint(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func(target string) (*httptest.ResponseRecorder, []PlaylistTrack) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", target, w.Code)
+		}
+		var tracks []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		return w, tracks
+	}
+
+	if w, _ := get("/api/moods/focus/playlist"); w.Header().Get("Link") != "" {
+		t.Errorf("Link = %q without prefetch_hint, want none", w.Header().Get("Link"))
+	}
+
+	h.SetPrefetchHint(true)
+	w, tracks := get("/api/moods/focus/playlist")
+	if len(tracks) < 2 {
+		t.Fatalf("focus playlist has %d tracks, want at least 2", len(tracks))
+	}
+	if want := "<" + tracks[1].AudioURL + ">; rel=prefetch"; w.Header().Get("Link") != want {
+		t.Errorf("Link = %q, want %q", w.Header().Get("Link"), want)
+	}
+
+	w, tracks = get("/api/moods/focus/playlist?limit=1")
+	if len(tracks) != 1 {
+		t.Fatalf("limit=1 playlist has %d tracks", len(tracks))
+	}
+	if got := w.Header().Get("Link"); got != "" {
+		t.Errorf("Link = %q for a single-track playlist, want none", got)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// Fields of GET /api/stats/public
+const (
+	PublicStatTracks         = "tracks"            // approved tracks
+	PublicStatCatalogMinutes = "catalog_minutes"   // total length of the approved tracks
+	PublicStatPlays          = "plays"             // all-time play count
+	PublicStatRecentPlays    = "plays_last_7_days" // play events in the last 7 days
+	PublicStatHoursListened  = "hours_listened"    // listen time of every event
+)
+
+// PublicStatFields lists every public stats field, the default allowlist
+var PublicStatFields = []string{
+	PublicStatTracks, PublicStatCatalogMinutes, PublicStatPlays, PublicStatRecentPlays, PublicStatHoursListened,
+}
+
+// publicStatsWindow is the span of PublicStatRecentPlays
+const publicStatsWindow = 7 * 24 * time.Hour
+
+// SetPublicStats sets the fields GET /api/stats/public exposes; none
+// disables the endpoint. Unknown fields are ignored.
+func (h *Handler) SetPublicStats(fields []string) {
+	h.publicStats = fields
+}
+
+// getPublicStats serves GET /api/stats/public: library-wide totals for
+// marketing pages, limited to the configured fields. Only aggregates over
+// the whole library are computed, so nothing identifies a track or a
+// listener. Totals are cached for an hour, by the server and by clients.
+func (h *Handler) getPublicStats(w http.ResponseWriter, r *http.Request) {
+	if len(h.publicStats) == 0 {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	totals, hit, err := h.totalsCache.GetOrLoad(r.Context(), cache.KeyPublicStats,
+		func(context.Context) (inventory.LibraryTotals, bool, error) {
+			totals, err := h.repo.LibraryTotals(h.now().Add(-publicStatsWindow))
+			if err != nil {
+				return inventory.LibraryTotals{}, false, err
+			}
+			return *totals, true, nil
+		})
+	if r.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching library totals: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	all := map[string]int{
+		PublicStatTracks:         totals.ApprovedTracks,
+		PublicStatCatalogMinutes: int(math.Round(float64(totals.CatalogSeconds) / 60)),
+		PublicStatPlays:          totals.AllTimePlays,
+		PublicStatRecentPlays:    totals.RecentPlays,
+		PublicStatHoursListened:  int(math.Round(float64(totals.ListenedSeconds) / 3600)),
+	}
+	stats := make(map[string]int, len(h.publicStats))
+	for _, field := range h.publicStats {
+		if v, ok := all[field]; ok {
+			stats[field] = v
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", publicMaxAge(cache.PublicStatsTTL))
+	w.Header().Set("X-Cache", cacheState(hit))
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Printf("Error encoding public stats: %v", err)
+	}
+}
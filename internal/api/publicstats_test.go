@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+func TestPublicStats(t *testing.T) {
+	repo := newMockRepo()
+	repo.libraryTotals = inventory.LibraryTotals{
+		ApprovedTracks:  42,
+		CatalogSeconds:  7290, // 121.5 minutes
+		AllTimePlays:    1000,
+		RecentPlays:     70,
+		ListenedSeconds: 9000, // 2.5 hours
+	}
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	h.SetPublicStats([]string{PublicStatTracks, PublicStatCatalogMinutes, PublicStatHoursListened})
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for _, want := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats/public", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+			t.Errorf("Cache-Control = %q, want public, max-age=3600", got)
+		}
+		if got := w.Header().Get("X-Cache"); got != want {
+			t.Errorf("X-Cache = %q, want %q", got, want)
+		}
+
+		var stats map[string]int
+		if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+			t.Fatalf("failed to decode stats: %v", err)
+		}
+		wantStats := map[string]int{PublicStatTracks: 42, PublicStatCatalogMinutes: 122, PublicStatHoursListened: 3}
+		if len(stats) != len(wantStats) {
+			t.Errorf("stats = %v, want only %v", stats, wantStats)
+		}
+		for k, v := range wantStats {
+			if stats[k] != v {
+				t.Errorf("%s = %d, want %d", k, stats[k], v)
+			}
+		}
+	}
+
+	if repo.libraryTotalsCalls != 1 {
+		t.Errorf("LibraryTotals called %d times, want 1 (second request cached)", repo.libraryTotalsCalls)
+	}
+	if want := now.Add(-7 * 24 * time.Hour); !repo.libraryTotalsSince.Equal(want) {
+		t.Errorf("since = %v, want %v", repo.libraryTotalsSince, want)
+	}
+}
+
+func TestPublicStatsDefaultFields(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats/public", nil))
+	var stats map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	for _, field := range PublicStatFields {
+		if _, ok := stats[field]; !ok {
+			t.Errorf("default stats lack %s: %v", field, stats)
+		}
+	}
+}
+
+func TestPublicStatsDisabled(t *testing.T) {
+	repo := newMockRepo()
+	h := NewHandler(repo, &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	h.SetPublicStats(nil)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats/public", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	if repo.libraryTotalsCalls != 0 {
+		t.Errorf("LibraryTotals called %d times, want 0", repo.libraryTotalsCalls)
+	}
+}
+
+func TestPublicStatsMethodNotAllowed(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/stats/public", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// Bounds on GET /api/random?n=
+const (
+	DefaultRandomTracks = 10
+	MaxRandomTracks     = 50
+)
+
+// getRandom serves GET /api/random?n=: up to n approved tracks (1-50,
+// default 10) picked at random across all moods. Every call draws anew, so
+// nothing is cached.
+func (h *Handler) getRandom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	n := DefaultRandomTracks
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > MaxRandomTracks {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, "n must be an integer 1-"+strconv.Itoa(MaxRandomTracks))
+			return
+		}
+		n = v
+	}
+
+	tracks, err := h.repo.GetRandom(n)
+	if err != nil {
+		log.Printf("Error fetching random tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if tracks == nil {
+		tracks = []*inventory.Track{}
+	}
+	for _, track := range tracks {
+		h.resolveAudioURL(track)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(h.toPlaylistTracks("", tracks)); err != nil {
+		log.Printf("Error encoding random tracks: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestGetRandom(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/random?n=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	var tracks []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(tracks))
+	}
+	for _, tr := range tracks {
+		if track, _ := repo.GetByID(tr.ID); track == nil || track.Status != inventory.StatusApproved {
+			t.Errorf("track %d is not an approved track", tr.ID)
+		}
+		if tr.AudioURL == "" {
+			t.Errorf("track %d has no audio_url", tr.ID)
+		}
+	}
+
+	for _, n := range []string{"0", "51", "-1", "abc"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/random?n="+n, nil))
+		if w.Code != http.StatusBadRequest || errorCode(t, w) != CodeInvalidLimit {
+			t.Errorf("n=%s: status = %d, want 400 %s", n, w.Code, CodeInvalidLimit)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+const (
+	// DefaultProgressInterval is the minimum time between resume-state writes
+	// for one listener
+	DefaultProgressInterval = 15 * time.Second
+	// DefaultResumeMaxAge is how long a listener's position stays resumable
+	DefaultResumeMaxAge = 24 * time.Hour
+)
+
+// maxPositionSeconds bounds a reported playback offset within a track
+const maxPositionSeconds = 6 * 60 * 60
+
+// listenerCookie holds a random anonymous ID used only to key resume state.
+// It is issued by the first listen event and identifies nothing else.
+const (
+	listenerCookie       = "driftfm_listener"
+	listenerCookieMaxAge = 365 * 24 * 60 * 60
+	listenerIDBytes      = 16
+)
+
+// listenerID returns the request's listener ID, or "" if it has no valid cookie
+func listenerID(r *http.Request) string {
+	c, err := r.Cookie(listenerCookie)
+	if err != nil || len(c.Value) != 2*listenerIDBytes {
+		return ""
+	}
+	if _, err := hex.DecodeString(c.Value); err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// ensureListenerID returns the request's listener ID, issuing a new cookie
+// when it has none
+func ensureListenerID(w http.ResponseWriter, r *http.Request) string {
+	if id := listenerID(r); id != "" {
+		return id
+	}
+	b := make([]byte, listenerIDBytes)
+	_, _ = rand.Read(b) // crypto/rand.Read never fails
+	id := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     listenerCookie,
+		Value:    id,
+		Path:     "/api/",
+		MaxAge:   listenerCookieMaxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// progressThrottle limits resume-state writes to one per listener per
+// interval, whatever track or mood the reports are for, so progress pings
+// don't each hit SQLite
+type progressThrottle = windowSet[string]
+
+func newProgressThrottle(interval time.Duration, maxEntries int) *progressThrottle {
+	return newWindowSet[string](interval, maxEntries)
+}
+
+// recordProgress saves a listener's position for GET /api/me/resume.
+// Throttled reports are acknowledged without a write.
+func (h *Handler) recordProgress(w http.ResponseWriter, r *http.Request, evt inventory.ListenEvent) {
+	if evt.PositionSeconds == nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidPosition, "progress events require position_seconds")
+		return
+	}
+	listener := ensureListenerID(w, r)
+	if !h.progress.claim(listener) {
+		writePlayAck(w, http.StatusOK, "ok", evt.TrackID)
+		return
+	}
+
+	track, err := h.repo.GetByID(evt.TrackID)
+	if err != nil {
+		h.progress.release(listener)
+		log.Printf("Error fetching track %d for progress: %v", evt.TrackID, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track == nil {
+		h.progress.release(listener)
+		writeError(w, r, http.StatusNotFound, CodeTrackNotFound, "Track not found")
+		return
+	}
+
+	// A borrowed track resumes in the mood it was played in
+	mood := evt.Mood
+	if !validMoods[mood] {
+		mood = track.Mood
+	}
+	state := inventory.ListenerState{
+		ListenerID:      listener,
+		Mood:            mood,
+		TrackID:         track.ID,
+		PositionSeconds: *evt.PositionSeconds,
+	}
+	if err := h.repo.SaveListenerState(state); err != nil {
+		h.progress.release(listener)
+		log.Printf("Error saving progress for track %d: %v", track.ID, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	writePlayAck(w, http.StatusOK, "ok", track.ID)
+}
+
+// ResumeInfo is where a listener left off
+type ResumeInfo struct {
+	Track           PlaylistTrack `json:"track"`
+	Mood            string        `json:"mood"`
+	PositionSeconds int           `json:"position_seconds"`
+	// PlaylistPosition is the track's index in the playlist the listener
+	// was playing, when resuming from their latest listen event
+	PlaylistPosition *int      `json:"playlist_position,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// getResume serves GET /api/me/resume?mood=focus: the listener's last track
+// and offset in the mood, or 204 if there is nothing recent to resume.
+// Without a mood it resumes from the listener's latest listen event in any
+// mood, with the offset if a progress report for that track is recent.
+func (h *Handler) getResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mood := r.URL.Query().Get("mood")
+	if mood != "" && !h.servesMood(mood) {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood", h.servedMoodDetails(mood))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	listener := listenerID(r)
+	if listener == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var resp ResumeInfo
+	var trackID int64
+	if mood == "" {
+		point, err := h.repo.GetLastPosition(listener)
+		if err != nil {
+			log.Printf("Error fetching last position: %v", err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+			return
+		}
+		if point == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mood, trackID = point.Mood, point.TrackID
+		resp.PlaylistPosition = point.PlaylistPosition
+		resp.UpdatedAt = point.At
+	}
+
+	state, err := h.repo.GetListenerState(listener, mood, h.resumeMaxAge)
+	if err != nil {
+		log.Printf("Error fetching resume state: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if trackID == 0 {
+		if state == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		trackID = state.TrackID
+	}
+	if state != nil && state.TrackID == trackID {
+		resp.PositionSeconds = state.PositionSeconds
+		resp.UpdatedAt = state.UpdatedAt
+	}
+
+	// The track may have been pulled from rotation since
+	track, err := h.repo.GetByID(trackID)
+	if err != nil {
+		log.Printf("Error fetching resume track %d: %v", trackID, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	if track == nil || !track.RolledOutTo(inventory.RolloutBucket(listener)) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.resolveAudioURL(track)
+
+	resp.Track = h.toPlaylistTracks(mood, []*inventory.Track{track})[0]
+	resp.Mood = mood
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding resume state: %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestProgressThrottle(t *testing.T) {
+	th := newProgressThrottle(15*time.Second, 2)
+	now := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	th.now = func() time.Time { return now }
+
+	if !th.claim("a") {
+		t.Fatal("first report should be written")
+	}
+	now = now.Add(10 * time.Second)
+	if th.claim("a") {
+		t.Error("report inside the interval should be throttled")
+	}
+	if !th.claim("b") {
+		t.Error("listeners are throttled independently")
+	}
+	now = now.Add(5 * time.Second)
+	if !th.claim("a") {
+		t.Error("report after the interval should be written")
+	}
+
+	// Full: an unseen listener evicts rather than growing past maxEntries
+	th.claim("c")
+	if n := len(th.seen); n > 2 {
+		t.Errorf("throttle holds %d entries, want at most 2", n)
+	}
+}
+
+func TestResume(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	repo.SetClock(func() time.Time { return now })
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	h.progress.now = func() time.Time { return now }
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	var cookie *http.Cookie
+	progress := func(trackID, position int) *httptest.ResponseRecorder {
+		t.Helper()
+		body := fmt.Sprintf(`{"event":"progress","mood":"focus","position_seconds":%d}`, position)
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/tracks/%d/play", trackID), bytes.NewBufferString(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("progress status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+		}
+		return w
+	}
+	resume := func(mood string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/me/resume?mood="+mood, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	resumeInfo := func() ResumeInfo {
+		t.Helper()
+		w := resume("focus")
+		if w.Code != http.StatusOK {
+			t.Fatalf("resume status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var info ResumeInfo
+		if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return info
+	}
+
+	if w := resume("focus"); w.Code != http.StatusNoContent {
+		t.Errorf("status without cookie = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	// The first progress report issues the listener cookie
+	w := progress(1, 30)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == listenerCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly {
+		t.Fatalf("listener cookie = %+v, want an HttpOnly cookie", cookie)
+	}
+
+	info := resumeInfo()
+	if info.Track.ID != 1 || info.PositionSeconds != 30 || info.Track.AudioURL == "" {
+		t.Errorf("resume = %+v, want track 1 at 30s with an audio URL", info)
+	}
+
+	// Pings inside the interval are acknowledged but not written
+	now = now.Add(5 * time.Second)
+	progress(1, 35)
+	if info := resumeInfo(); info.PositionSeconds != 30 {
+		t.Errorf("position = %d after throttled ping, want 30", info.PositionSeconds)
+	}
+	now = now.Add(15 * time.Second)
+	progress(1, 50)
+	if info := resumeInfo(); info.PositionSeconds != 50 {
+		t.Errorf("position = %d after interval, want 50", info.PositionSeconds)
+	}
+
+	// Switching tracks doesn't reset the listener's interval
+	now = now.Add(5 * time.Second)
+	progress(2, 10)
+	if info := resumeInfo(); info.Track.ID != 1 || info.PositionSeconds != 50 {
+		t.Errorf("resume = track %d at %ds after a throttled switch, want track 1 at 50s", info.Track.ID, info.PositionSeconds)
+	}
+
+	// Moods are independent, and state expires after a day
+	if w := resume("calm"); w.Code != http.StatusNoContent {
+		t.Errorf("calm status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	now = now.Add(DefaultResumeMaxAge + time.Second)
+	if w := resume("focus"); w.Code != http.StatusNoContent {
+		t.Errorf("status after expiry = %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestResume_LatestEvent(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	var cookie *http.Cookie
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		for _, c := range w.Result().Cookies() {
+			if c.Name == listenerCookie {
+				cookie = c
+			}
+		}
+		return w
+	}
+
+	if w := send(http.MethodGet, "/api/me/resume", ""); w.Code != http.StatusNoContent {
+		t.Errorf("status without cookie = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	// A play issues the cookie and tags the event with the listener
+	send(http.MethodPost, "/api/tracks/1/play", `{"mood":"focus","position":2}`)
+	if cookie == nil {
+		t.Fatal("play did not issue a listener cookie")
+	}
+	send(http.MethodPost, "/api/tracks/3/play", `{"mood":"calm","position":5}`)
+	send(http.MethodPost, "/api/tracks/3/play", `{"event":"progress","mood":"calm","position_seconds":42}`)
+
+	w := send(http.MethodGet, "/api/me/resume", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	var info ResumeInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if info.Track.ID != 3 || info.Mood != "calm" || info.PlaylistPosition == nil || *info.PlaylistPosition != 5 {
+		t.Errorf("resume = %+v, want track 3 in calm at playlist position 5", info)
+	}
+	if info.PositionSeconds != 42 {
+		t.Errorf("position_seconds = %d, want 42 from the progress report", info.PositionSeconds)
+	}
+
+	// Another listener has no history
+	cookie = &http.Cookie{Name: listenerCookie, Value: "00000000000000000000000000000000"}
+	if w := send(http.MethodGet, "/api/me/resume", ""); w.Code != http.StatusNoContent {
+		t.Errorf("status for a new listener = %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestResume_Validation(t *testing.T) {
+	h := NewHandler(newMockRepo(), &mockRadio{}, &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown mood", http.MethodGet, "/api/me/resume?mood=focuss", "", http.StatusNotFound, CodeMoodNotFound},
+		{"wrong method", http.MethodPost, "/api/me/resume?mood=focus", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"progress without position", http.MethodPost, "/api/tracks/1/play", `{"event":"progress"}`, http.StatusBadRequest, CodeInvalidPosition},
+		{"negative position", http.MethodPost, "/api/tracks/1/play", `{"event":"progress","position_seconds":-1}`, http.StatusBadRequest, CodeInvalidPosition},
+		{"unknown track", http.MethodPost, "/api/tracks/1/play", `{"event":"progress","position_seconds":5}`, http.StatusNotFound, CodeTrackNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
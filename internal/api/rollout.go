@@ -0,0 +1,36 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// withRollout drops the beta tracks of a shared playlist (those carrying a
+// RolloutPercent) that the listener's rollout bucket doesn't reach;
+// anonymous requests (listener "") get no beta tracks. The radio doesn't
+// count beta tracks toward a playlist's length, so what is left is cut to
+// size (0 = no cut), and listeners the beta tracks are withheld from still
+// get a full playlist. beta reports whether the playlist held any, in which
+// case the response depends on the listener.
+func withRollout(playlist []PlaylistTrack, listener string, size int) (_ []PlaylistTrack, beta bool) {
+	if !slices.ContainsFunc(playlist, func(t PlaylistTrack) bool { return t.RolloutPercent > 0 }) {
+		return playlist, false
+	}
+
+	bucket := inventory.RolloutBucket(listener)
+	kept := make([]PlaylistTrack, 0, len(playlist))
+	for _, t := range playlist {
+		if t.RolloutPercent > 0 {
+			if listener == "" || bucket >= t.RolloutPercent {
+				continue
+			}
+			t.RolloutPercent = 0
+		}
+		kept = append(kept, t)
+	}
+	if size > 0 && len(kept) > size {
+		kept = kept[:size]
+	}
+	return kept, true
+}
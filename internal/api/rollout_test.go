@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestPlaylist_BetaRollout(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	listener := fmt.Sprintf("%032x", 1)
+	bucket := inventory.RolloutBucket(listener)
+	// Updates go through the admin API, which invalidates the cached
+	// playlists the rollouts are read from
+	patch := func(body string) {
+		t.Helper()
+		req := asAdmin(httptest.NewRequest(http.MethodPatch, "/api/admin/tracks/2", bytes.NewBufferString(body)))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("PATCH status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+	}
+	setRollout := func(percent int) {
+		t.Helper()
+		patch(fmt.Sprintf(`{"status":%q,"rollout_percent":%d}`, inventory.StatusBeta, percent))
+	}
+	get := func(withCookie bool) ([]int64, *httptest.ResponseRecorder) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil)
+		if withCookie {
+			req.AddCookie(&http.Cookie{Name: listenerCookie, Value: listener})
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+		var tracks []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		ids := make([]int64, len(tracks))
+		for i, tr := range tracks {
+			ids[i] = tr.ID
+			if tr.RolloutPercent != 0 {
+				t.Errorf("track %d served with rollout_percent %d", tr.ID, tr.RolloutPercent)
+			}
+		}
+		slices.Sort(ids)
+		return ids, w
+	}
+
+	// Fully rolled out: the listener gets the beta track, anonymous never does
+	setRollout(100)
+	if ids, w := get(true); !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("listener playlist = %v, want [1 2]", ids)
+	} else if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+		t.Errorf("beta Cache-Control = %q, want private, no-store", got)
+	}
+	if ids, _ := get(false); !slices.Equal(ids, []int64{1}) {
+		t.Errorf("anonymous playlist = %v, want [1]", ids)
+	}
+
+	// The listener's bucket decides, the same way on every request
+	setRollout(bucket)
+	for range 3 {
+		if ids, _ := get(true); !slices.Equal(ids, []int64{1}) {
+			t.Errorf("rollout at bucket %d: playlist = %v, want [1]", bucket, ids)
+		}
+	}
+	setRollout(bucket + 1)
+	for range 3 {
+		if ids, _ := get(true); !slices.Equal(ids, []int64{1, 2}) {
+			t.Errorf("rollout above bucket %d: playlist = %v, want [1 2]", bucket, ids)
+		}
+	}
+
+	// Back to approved, the track is public again
+	patch(fmt.Sprintf(`{"status":%q}`, inventory.StatusApproved))
+	if ids, w := get(false); !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("anonymous playlist after approval = %v, want [1 2]", ids)
+	} else if got := w.Header().Get("Cache-Control"); got != publicMaxAge(cache.PlaylistTTL) {
+		t.Errorf("Cache-Control after approval = %q, want public", got)
+	}
+}
+
+func TestPlaylist_BetaRolloutKeepsLimit(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	fields := map[string]any{"status": inventory.StatusBeta, "rollout_percent": 100}
+	if err := repo.UpdateTrack(2, fields, "alice", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+
+	// Filtering the beta track out must not leave anyone short of the limit
+	for _, withCookie := range []bool{true, false} {
+		req := httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist?limit=1", nil)
+		if withCookie {
+			req.AddCookie(&http.Cookie{Name: listenerCookie, Value: fmt.Sprintf("%032x", 1)})
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+		var tracks []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		if len(tracks) != 1 {
+			t.Errorf("listener=%v: got %d tracks, want 1", withCookie, len(tracks))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+// SetCacheShuffle chooses how playlists are cached. On (the default), a
+// shuffled playlist is cached and shared by every listener until it
+// expires. Off, every request is shuffled anew: pair it with a radio track
+// cache (see NewTrackCache) so the unshuffled tracks still come from the
+// cache rather than the database.
+func (h *Handler) SetCacheShuffle(on bool) {
+	h.shufflePerRequest = !on
+}
+
+// trackSetCache keeps moods' unshuffled track sets in the playlist cache.
+// Entries live under the mood's playlist prefix, so the invalidation that
+// drops its playlists drops them too.
+type trackSetCache struct {
+	tracks *cache.Typed[[]cachedTrack]
+}
+
+// cachedTrack is a track as the track set cache stores it. Track leaves its
+// content hash out of JSON, which a Redis backend round-trips entries
+// through, so the hash that versions remote audio URLs is kept beside it.
+type cachedTrack struct {
+	*inventory.Track
+	ContentHash *string `json:"content_hash,omitempty"`
+}
+
+// NewTrackCache returns a radio.TrackCache storing track sets in c
+func NewTrackCache(c Cache) radio.TrackCache {
+	return &trackSetCache{tracks: cache.NewTyped[[]cachedTrack](c)}
+}
+
+// Tracks returns copies of the cached tracks, as callers shuffle the slice
+// and set audio URLs on its tracks. Empty sets aren't cached.
+func (c *trackSetCache) Tracks(ctx context.Context, mood string, filter inventory.TrackFilter, load func(ctx context.Context) ([]*inventory.Track, error)) ([]*inventory.Track, error) {
+	key := playlistKey(mood, filter).With("tracks", "").String()
+	tracks, _, err := c.tracks.GetOrLoad(ctx, key, func(ctx context.Context) ([]cachedTrack, bool, error) {
+		tracks, err := load(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		cached := make([]cachedTrack, len(tracks))
+		for i, t := range tracks {
+			cached[i] = cachedTrack{Track: t, ContentHash: t.ContentHash}
+		}
+		return cached, len(cached) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	copies := make([]*inventory.Track, len(tracks))
+	for i, t := range tracks {
+		track := *t.Track
+		track.ContentHash = t.ContentHash
+		copies[i] = &track
+	}
+	return copies, nil
+}
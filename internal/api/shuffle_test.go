@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestCacheShuffleOff(t *testing.T) {
+	repo := setupTestDB(t)
+	for i := 3; i <= 12; i++ {
+		title := fmt.Sprintf("Focus Track %d", i)
+		if _, err := repo.InsertTrack(&inventory.Track{FilePath: fmt.Sprintf("focus/track%d.mp3", i), Title: &title, Mood: "focus", DurationSeconds: 180, Status: inventory.StatusApproved}); err != nil {
+			t.Fatalf("InsertTrack failed: %v", err)
+		}
+	}
+	c := setupTestCache(t)
+	mgr := radio.NewManager(repo)
+	mgr.SetTrackCache(NewTrackCache(c))
+	h := NewHandler(repo, mgr, &mockResolver{}, c)
+	h.SetCacheShuffle(false)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func() []int64 {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("X-Cache"); got != "MISS" {
+			t.Errorf("X-Cache = %q, want MISS", got)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+			t.Errorf("Cache-Control = %q, want private, no-store", got)
+		}
+		var playlist []PlaylistTrack
+		if err := json.NewDecoder(w.Body).Decode(&playlist); err != nil {
+			t.Fatalf("failed to decode playlist: %v", err)
+		}
+		ids := make([]int64, len(playlist))
+		for i, track := range playlist {
+			ids[i] = track.ID
+		}
+		return ids
+	}
+
+	first := get()
+	if len(first) != 12 {
+		t.Fatalf("got %d tracks, want 12", len(first))
+	}
+
+	// A track added without invalidation stays out: the set is cached
+	title := "Focus Track 13"
+	if _, err := repo.InsertTrack(&inventory.Track{FilePath: "focus/track13.mp3", Title: &title, Mood: "focus", DurationSeconds: 180, Status: inventory.StatusApproved}); err != nil {
+		t.Fatalf("InsertTrack failed: %v", err)
+	}
+
+	differs := false
+	for range 10 {
+		ids := get()
+		if len(ids) != 12 {
+			t.Fatalf("got %d tracks, want the cached 12", len(ids))
+		}
+		differs = differs || !slices.Equal(ids, first)
+	}
+	if !differs {
+		t.Error("every request got the same order, want a shuffle per request")
+	}
+}
+
+func TestCacheShuffleOn(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	var bodies []string
+	for _, want := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+		if got := w.Header().Get("X-Cache"); got != want {
+			t.Errorf("X-Cache = %q, want %q", got, want)
+		}
+		bodies = append(bodies, w.Body.String())
+	}
+	if bodies[0] != bodies[1] {
+		t.Error("cached playlist should be served unchanged")
+	}
+}
+
+func TestTrackCache_ContentHashSurvivesRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := cache.NewWithStore(cache.NewRedisStore(cache.RedisOptions{Addr: mr.Addr()}))
+	t.Cleanup(func() { _ = c.Close() })
+	tc := NewTrackCache(c)
+
+	hash := strings.Repeat("ab", 32)
+	loads := 0
+	load := func(context.Context) ([]*inventory.Track, error) {
+		loads++
+		return []*inventory.Track{{ID: 1, FilePath: "focus/a.mp3", Mood: "focus", ContentHash: &hash}}, nil
+	}
+
+	// The second read decodes the set from Redis rather than loading it
+	for range 2 {
+		tracks, err := tc.Tracks(context.Background(), "focus", inventory.TrackFilter{}, load)
+		if err != nil {
+			t.Fatalf("Tracks failed: %v", err)
+		}
+		if len(tracks) != 1 || tracks[0].ContentHash == nil || *tracks[0].ContentHash != hash {
+			t.Fatalf("tracks = %+v, want track 1 with its content hash", tracks)
+		}
+		body, _ := json.Marshal(tracks[0])
+		if strings.Contains(string(body), "content_hash") {
+			t.Errorf("track JSON = %s, want no content_hash", body)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times, want 1", loads)
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// shuffleStats serves GET /api/admin/radio/{mood}/stats: how often each
+// track opened the mood's shuffled playlists today (UTC), the recency
+// demotions the shuffles made, the recently played list, and the shuffle's
+// random source. It is for checking complaints that the shuffle repeats
+// itself; counts live in memory and restart with the server.
+func (h *Handler) shuffleStats(w http.ResponseWriter, mood string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(h.radio.ShuffleStats(mood)); err != nil {
+		log.Printf("Error encoding shuffle stats: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestShuffleStatsRoute(t *testing.T) {
+	repo := setupTestDB(t)
+	mgr := radio.NewManager(repo)
+	h := NewHandler(repo, mgr, &mockResolver{}, setupTestCache(t))
+	enableAdmin(t, h)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	mgr.RecordPlay("focus", 1)
+	for range 3 {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist?limit=2", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("playlist status = %d, want 200", w.Code)
+		}
+		h.invalidatePlaylists("focus") // make every request shuffle
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/radio/focus/stats", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	var stats radio.ShuffleStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	if stats.Mood != "focus" || stats.Playlists != 3 || stats.RNG == "" {
+		t.Errorf("stats = %+v, want 3 focus playlists", stats)
+	}
+	var appearances int
+	for _, ta := range stats.Tracks {
+		appearances += ta.Count
+	}
+	if appearances != 6 {
+		t.Errorf("appearances = %d, want 6 (2 tracks in each of 3 playlists)", appearances)
+	}
+	if stats.RecencyDemotions != 3 || len(stats.RecentlyPlayed) != 1 {
+		t.Errorf("recency = %d demotions, recent %v; want 3 and [1]", stats.RecencyDemotions, stats.RecentlyPlayed)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/admin/radio/focuss/stats", http.StatusNotFound},
+		{http.MethodPost, "/api/admin/radio/focus/stats", http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, asAdmin(httptest.NewRequest(tc.method, tc.path, nil)))
+		if w.Code != tc.want {
+			t.Errorf("%s %s: status = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+}
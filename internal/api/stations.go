@@ -0,0 +1,162 @@
+package api
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// Station is one radio served under /api/stations/{id}/, with its own view
+// of the library, its own radio, and its own cache
+type Station struct {
+	ID    string
+	Moods []string // moods the station plays; empty = every mood
+	Repo  Repository
+	Radio Radio
+	Cache Cache
+}
+
+// SetStation records plays through h under id and limits the moods h
+// serves to listeners; empty moods = every mood. The admin API is unaffected.
+func (h *Handler) SetStation(id string, moods []string) {
+	h.station = id
+	h.stationMoods = nil
+	if len(moods) > 0 {
+		h.stationMoods = make(map[string]bool, len(moods))
+		for _, mood := range moods {
+			h.stationMoods[mood] = true
+		}
+	}
+}
+
+// ForStation returns a copy of h serving st in place of h's library, radio,
+// and cache, keeping every other setting, so configure h first. h goes on
+// invalidating st's cache along with its own, so admin changes made through
+// h reach every station.
+func (h *Handler) ForStation(st Station) *Handler {
+	fan, ok := h.cache.(*stationCaches)
+	if !ok {
+		fan = &stationCaches{Cache: h.cache}
+		h.cache = fan
+	}
+	if st.Cache != fan.Cache && !slices.Contains(fan.others, st.Cache) {
+		fan.others = append(fan.others, st.Cache)
+	}
+
+	sh := *h
+	sh.repo = st.Repo
+	sh.radio = st.Radio
+	sh.cache = st.Cache
+	sh.playlists = cache.NewTyped[[]PlaylistTrack](st.Cache)
+	sh.moodLists = cache.NewTyped[[]MoodInfo](st.Cache)
+	sh.statsCache = cache.NewTyped[[]inventory.MoodStats](st.Cache)
+	sh.totalsCache = cache.NewTyped[inventory.LibraryTotals](st.Cache)
+	sh.featuredPicks = cache.NewTyped[int64](st.Cache)
+	sh.SetStation(st.ID, st.Moods)
+	return &sh
+}
+
+// servesMood reports whether mood is known and played by h's station
+func (h *Handler) servesMood(mood string) bool {
+	return validMoods[mood] && (h.stationMoods == nil || h.stationMoods[mood])
+}
+
+// servedMoodCount is how many moods h's station serves, the most a mood
+// list can hold
+func (h *Handler) servedMoodCount() int {
+	n := 0
+	for mood := range validMoods {
+		if h.servesMood(mood) {
+			n++
+		}
+	}
+	return n
+}
+
+// servedMoodDetails is unknownMoodDetails limited to the station's moods
+func (h *Handler) servedMoodDetails(mood string) UnknownMoodDetails {
+	if h.stationMoods == nil {
+		return unknownMoodDetails(mood)
+	}
+	names := slices.Sorted(maps.Keys(h.stationMoods))
+	suggestion, _ := closestMatch(strings.ToLower(mood), names, maxSuggestDistance)
+	return UnknownMoodDetails{ValidMoods: names, Suggestion: suggestion}
+}
+
+// stationCaches passes invalidations on to other stations' caches
+type stationCaches struct {
+	Cache
+	others []Cache
+}
+
+func (c *stationCaches) InvalidateMoods() {
+	c.Cache.InvalidateMoods()
+	for _, o := range c.others {
+		o.InvalidateMoods()
+	}
+}
+
+func (c *stationCaches) InvalidateMood(mood string) {
+	c.Cache.InvalidateMood(mood)
+	for _, o := range c.others {
+		o.InvalidateMood(mood)
+	}
+}
+
+func (c *stationCaches) InvalidateMoodsList() {
+	c.Cache.InvalidateMoodsList()
+	for _, o := range c.others {
+		o.InvalidateMoodsList()
+	}
+}
+
+// UnknownStationDetails lists the stations a client may use
+type UnknownStationDetails struct {
+	ValidStations []string `json:"valid_stations"`
+}
+
+// StationRouter serves /api/stations/{id}/...: a request goes to its
+// station's listener routes with the path rewritten to the unscoped
+// /api/... form. The admin API is not served per station.
+type StationRouter struct {
+	stations map[string]http.Handler
+}
+
+// NewStationRouter creates a router with no stations
+func NewStationRouter() *StationRouter {
+	return &StationRouter{stations: make(map[string]http.Handler)}
+}
+
+// Add serves h, usually made with ForStation, under /api/stations/{id}/
+func (sr *StationRouter) Add(id string, h *Handler) {
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	sr.stations[id] = mux
+}
+
+func (sr *StationRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, route, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/stations/"), "/")
+	station, ok := sr.stations[id]
+	if !ok {
+		details := UnknownStationDetails{ValidStations: slices.Sorted(maps.Keys(sr.stations))}
+		writeErrorDetails(w, r, http.StatusNotFound, CodeStationNotFound, "Unknown station", details)
+		return
+	}
+	if route == "" || route == "admin" || strings.HasPrefix(route, "admin/") {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+
+	scoped := new(http.Request)
+	*scoped = *r
+	scoped.URL = new(url.URL)
+	*scoped.URL = *r.URL
+	scoped.URL.Path = "/api/" + route
+	scoped.URL.RawPath = ""
+	station.ServeHTTP(w, scoped)
+}
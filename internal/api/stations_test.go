@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+// setupStations serves two stations over the test library plus tracks 4
+// (a/, focus), 5 (b/, focus) and 6 (b/, calm): station a plays focus from
+// a/ and is the default, station b plays focus and calm from b/. It returns
+// the mux, the default station's handler, station b's cache, and the
+// database path.
+func setupStations(t *testing.T) (*http.ServeMux, *Handler, *cache.Cache, string) {
+	t.Helper()
+	dbPath := t.TempDir() + "/test.db"
+	repo := setupTestDBAt(t, dbPath)
+	for _, tr := range []struct{ path, mood string }{
+		{"a/focus1.mp3", "focus"},
+		{"b/focus1.mp3", "focus"},
+		{"b/calm1.mp3", "calm"},
+	} {
+		title := tr.path
+		if _, err := repo.InsertTrack(&inventory.Track{FilePath: tr.path, Title: &title, Mood: tr.mood, DurationSeconds: 180, Status: inventory.StatusApproved}); err != nil {
+			t.Fatalf("InsertTrack failed: %v", err)
+		}
+	}
+
+	base := setupTestCache(t)
+	newStation := func(id, prefix string, moods []string, c *cache.Cache) Station {
+		scoped := repo.WithScope(prefix, moods)
+		return Station{ID: id, Moods: moods, Repo: scoped, Radio: radio.NewManager(scoped), Cache: c}
+	}
+	bCache := base.WithNamespace("station:b")
+	a := newStation("a", "a/", []string{"focus"}, base)
+	b := newStation("b", "b/", []string{"focus", "calm"}, bCache)
+
+	h := NewHandler(a.Repo, a.Radio, &mockResolver{}, a.Cache)
+	h.SetStation(a.ID, a.Moods)
+	router := NewStationRouter()
+	router.Add(a.ID, h.ForStation(a))
+	router.Add(b.ID, h.ForStation(b))
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/stations/", router)
+	h.RegisterRoutes(mux)
+	return mux, h, bCache, dbPath
+}
+
+func playlistIDs(t *testing.T, mux *http.ServeMux, path string) []int64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200: %s", path, w.Code, w.Body.String())
+	}
+	var playlist []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&playlist); err != nil {
+		t.Fatalf("failed to decode playlist: %v", err)
+	}
+	ids := make([]int64, len(playlist))
+	for i, track := range playlist {
+		ids[i] = track.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestStationIsolation(t *testing.T) {
+	mux, _, _, _ := setupStations(t)
+
+	// Station a's focus track never reaches station b, nor the reverse,
+	// whichever station builds and caches its playlist first
+	for range 5 {
+		if got := playlistIDs(t, mux, "/api/stations/b/moods/focus/playlist"); !slices.Equal(got, []int64{5}) {
+			t.Fatalf("station b focus playlist = %v, want [5]", got)
+		}
+		if got := playlistIDs(t, mux, "/api/stations/a/moods/focus/playlist"); !slices.Equal(got, []int64{4}) {
+			t.Fatalf("station a focus playlist = %v, want [4]", got)
+		}
+	}
+	if got := playlistIDs(t, mux, "/api/stations/b/discover?moods=focus,calm"); !slices.Equal(got, []int64{5, 6}) {
+		t.Errorf("station b discover = %v, want [5 6]", got)
+	}
+
+	// The unscoped routes serve the default station
+	if got := playlistIDs(t, mux, "/api/moods/focus/playlist"); !slices.Equal(got, []int64{4}) {
+		t.Errorf("unscoped focus playlist = %v, want the default station's [4]", got)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stations/b/moods", nil))
+	var moods []MoodInfo
+	if err := json.NewDecoder(w.Body).Decode(&moods); err != nil {
+		t.Fatalf("failed to decode moods: %v", err)
+	}
+	counts := map[string]int{}
+	for _, m := range moods {
+		counts[m.Name] = m.TrackCount
+	}
+	if want := map[string]int{"calm": 1, "focus": 1}; len(counts) != len(want) || counts["calm"] != 1 || counts["focus"] != 1 {
+		t.Errorf("station b moods = %v, want %v", counts, want)
+	}
+}
+
+func TestStationRouting(t *testing.T) {
+	mux, _, _, _ := setupStations(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantErr  string
+	}{
+		{"unknown station", "/api/stations/c/moods/focus/playlist", http.StatusNotFound, CodeStationNotFound},
+		{"missing route", "/api/stations/a/", http.StatusNotFound, CodeNotFound},
+		{"admin API", "/api/stations/a/admin/tracks", http.StatusNotFound, CodeNotFound},
+		{"mood the station doesn't play", "/api/stations/a/moods/calm/playlist", http.StatusNotFound, CodeMoodNotFound},
+		{"unscoped mood the default station doesn't play", "/api/moods/calm/playlist", http.StatusNotFound, CodeMoodNotFound},
+		{"mood the station plays", "/api/stations/b/moods/calm/playlist", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantErr != "" {
+				if got := errorCode(t, w); got != tt.wantErr {
+					t.Errorf("error code = %q, want %q", got, tt.wantErr)
+				}
+			}
+		})
+	}
+
+	// An unknown mood lists only the station's moods
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stations/a/moods/calm/playlist", nil))
+	var resp struct {
+		Error struct {
+			Details UnknownMoodDetails `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if got := resp.Error.Details.ValidMoods; !slices.Equal(got, []string{"focus"}) {
+		t.Errorf("valid_moods = %v, want [focus]", got)
+	}
+}
+
+func TestStationPlayEvents(t *testing.T) {
+	mux, _, _, dbPath := setupStations(t)
+
+	for _, path := range []string{"/api/stations/b/tracks/5/play", "/api/tracks/4/play"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{"event":"play"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST %s status = %d, want 200: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	got := map[int64]string{}
+	rows, err := db.Query(`SELECT track_id, station FROM listen_events`)
+	if err != nil {
+		t.Fatalf("failed to query listen events: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id int64
+		var station sql.NullString
+		if err := rows.Scan(&id, &station); err != nil {
+			t.Fatalf("failed to scan listen event: %v", err)
+		}
+		got[id] = station.String
+	}
+	if got[5] != "b" || got[4] != "a" {
+		t.Errorf("listen event stations = %v, want track 5 on b and track 4 on the default a", got)
+	}
+}
+
+func TestStationInvalidation(t *testing.T) {
+	mux, h, bCache, _ := setupStations(t)
+
+	playlistIDs(t, mux, "/api/stations/b/moods/focus/playlist")
+	if n, err := bCache.KeyCount(); err != nil || n == 0 {
+		t.Fatalf("station b's cache holds %d keys (%v), want its focus playlist", n, err)
+	}
+
+	// Admin changes go through the default station's handler
+	h.invalidatePlaylists("focus")
+	if n, err := bCache.KeyCount(); err != nil || n != 0 {
+		t.Errorf("station b's cache holds %d keys (%v) after invalidating through the default station, want 0", n, err)
+	}
+}
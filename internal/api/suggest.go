@@ -0,0 +1,39 @@
+package api
+
+// maxSuggestDistance is the largest edit distance still offered as a suggestion
+const maxSuggestDistance = 2
+
+// closestMatch returns the candidate nearest to input by edit distance, if it
+// is within maxDist. Ties go to the earlier candidate.
+func closestMatch(input string, candidates []string, maxDist int) (string, bool) {
+	best, bestDist := "", maxDist+1
+	for _, c := range candidates {
+		if d := levenshtein(input, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, best != ""
+}
+
+// levenshtein returns the edit distance between a and b, counting insertions,
+// deletions, and substitutions of single bytes.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
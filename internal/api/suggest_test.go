@@ -0,0 +1,56 @@
+package api
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"focus", "focus", 0},
+		{"focus", "", 5},
+		{"", "calm", 4},
+		{"focuss", "focus", 1},
+		{"clam", "calm", 2},
+		{"latenight", "late_night", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := levenshtein(tt.b, tt.a); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d (symmetry)", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	moods := []string{"calm", "energize", "focus", "late_night"}
+
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"focuss", "focus", true},
+		{"clam", "calm", true},
+		{"late-night", "late_night", true},
+		{"energise", "energize", true},
+		{"jazz", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := closestMatch(tt.input, moods, maxSuggestDistance)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("closestMatch(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+)
+
+func TestTracing_PlaylistSpanHierarchy(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	handler := tracing.Middleware(tp, mux)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+
+	byName := make(map[string]tracetest.SpanStub)
+	for _, s := range exp.GetSpans() {
+		byName[s.Name] = s
+	}
+
+	root, ok := byName["GET /api/moods/"]
+	if !ok {
+		t.Fatalf("no server span, got %v", spanNames(exp.GetSpans()))
+	}
+	if root.SpanKind != trace.SpanKindServer || root.Parent.IsValid() {
+		t.Errorf("root span: kind %v, parent valid %v; want a server span without parent", root.SpanKind, root.Parent.IsValid())
+	}
+
+	// child -> parent
+	hierarchy := [][2]string{
+		{"cache.GetOrLoad", "GET /api/moods/"},
+		{"cache.load", "cache.GetOrLoad"},
+		{"radio.GetPlaylist", "cache.load"},
+		{"inventory.GetByMood", "radio.GetPlaylist"},
+		{"radio.shuffle", "radio.GetPlaylist"},
+		{"json.encode", "GET /api/moods/"},
+	}
+	for _, link := range hierarchy {
+		child, ok := byName[link[0]]
+		if !ok {
+			t.Errorf("missing span %s, got %v", link[0], spanNames(exp.GetSpans()))
+			continue
+		}
+		if child.Parent.SpanID() != byName[link[1]].SpanContext.SpanID() {
+			t.Errorf("%s is not a child of %s", link[0], link[1])
+		}
+		if child.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("%s is in another trace", link[0])
+		}
+	}
+
+	// A cached second request skips the load entirely
+	exp.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/moods/focus/playlist", nil))
+	for _, s := range exp.GetSpans() {
+		if s.Name == "cache.load" || s.Name == "radio.GetPlaylist" {
+			t.Errorf("cache hit still produced span %s", s.Name)
+		}
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	return names
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// listStatuses are the accepted ?status= values for the admin track listing
+var listStatuses = map[string]bool{
+	inventory.StatusApproved: true,
+	inventory.StatusPending:  true,
+	inventory.StatusRejected: true,
+	inventory.StatusBeta:     true,
+	inventory.ListStatusAll:  true,
+}
+
+// listSorts are the accepted ?sort= values for the admin track listing
+var listSorts = map[string]bool{
+	inventory.ListSortCreated: true,
+	inventory.ListSortTitle:   true,
+	inventory.ListSortPlays:   true,
+}
+
+// listTracks serves GET /api/admin/tracks?status=&mood=&q=&sort=&page=&per_page=:
+// full tracks of any status for curation, with the match count in
+// X-Total-Count. Rejected tracks are listed only with status=rejected or
+// status=all; q matches title or artist; sort is created_at (newest first,
+// the default), title or plays; per_page is capped at 200.
+func (h *Handler) listTracks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	opts := inventory.ListOptions{
+		Status: q.Get("status"),
+		Mood:   q.Get("mood"),
+		Query:  q.Get("q"),
+		Sort:   q.Get("sort"),
+	}
+	if opts.Status != "" && !listStatuses[opts.Status] {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidStatus, "status must be approved, pending, rejected, beta or all")
+		return
+	}
+	if opts.Mood != "" && !validMoods[opts.Mood] {
+		writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood: "+opts.Mood, unknownMoodDetails(opts.Mood))
+		return
+	}
+	if opts.Sort != "" && !listSorts[opts.Sort] {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidSort, "sort must be created_at, title or plays")
+		return
+	}
+	if raw := q.Get("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidPage, "page must be a positive integer")
+			return
+		}
+		opts.Page = n
+	}
+	if raw := q.Get("per_page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidLimit, "per_page must be a positive integer")
+			return
+		}
+		opts.PerPage = n
+	}
+
+	tracks, total, err := h.repo.ListTracks(opts)
+	if err != nil {
+		log.Printf("Error listing tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	for _, track := range tracks {
+		h.resolveAudioURL(track)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(totalCountHeader, strconv.Itoa(total))
+	if err := json.NewEncoder(w).Encode(tracks); err != nil {
+		log.Printf("Error encoding track list: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+func TestListTracks(t *testing.T) {
+	// Tracks 1-3 approved (1, 2 focus; 3 calm), 4 pending focus
+	mux := setupPendingHandler(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCode   string
+		wantIDs    []int64
+		wantTotal  string
+	}{
+		{"defaults", "", http.StatusOK, "", []int64{4, 3, 2, 1}, "4"},
+		{"status", "?status=pending", http.StatusOK, "", []int64{4}, "1"},
+		{"no rejected tracks", "?status=rejected", http.StatusOK, "", []int64{}, "0"},
+		{"mood, query and sort", "?mood=focus&q=focus&sort=title", http.StatusOK, "", []int64{1, 2}, "2"},
+		{"page", "?sort=title&page=2&per_page=2", http.StatusOK, "", []int64{2, 4}, "4"},
+		{"query is literal", "?q=%25%27%20OR%201%3D1%20--", http.StatusOK, "", []int64{}, "0"},
+		{"unknown status", "?status=deleted", http.StatusBadRequest, CodeInvalidStatus, nil, ""},
+		{"injected status", "?status=approved%27%20OR%20%271%27%3D%271", http.StatusBadRequest, CodeInvalidStatus, nil, ""},
+		{"unknown sort", "?sort=id%3BDROP%20TABLE%20tracks", http.StatusBadRequest, CodeInvalidSort, nil, ""},
+		{"unknown mood", "?mood=focs", http.StatusNotFound, CodeMoodNotFound, nil, ""},
+		{"bad page", "?page=0", http.StatusBadRequest, CodeInvalidPage, nil, ""},
+		{"bad per_page", "?per_page=x", http.StatusBadRequest, CodeInvalidLimit, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodGet, "/api/admin/tracks"+tt.query, nil)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+
+			if got := w.Header().Get(totalCountHeader); got != tt.wantTotal {
+				t.Errorf("%s = %q, want %q", totalCountHeader, got, tt.wantTotal)
+			}
+			var tracks []inventory.Track
+			if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, track := range tracks {
+				ids[i] = track.ID
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/tracks", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want 401", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, asAdmin(httptest.NewRequest(http.MethodPost, "/api/admin/tracks", nil)))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
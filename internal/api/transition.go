@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/1mb-dev/driftfm/internal/cache"
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+)
+
+// Bounds on GET /api/transition?steps=
+const (
+	DefaultTransitionSteps = 20
+	MaxTransitionSteps     = 100
+)
+
+// getTransition serves GET /api/transition?from=energize&to=calm&steps=20:
+// a playlist of up to steps tracks (2-100, default 20) that starts in one
+// mood and drifts into the other, e.g. workout to cooldown. Each (from, to,
+// steps) is cached like a playlist of the from mood.
+func (h *Handler) getTransition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	for _, m := range []string{from, to} {
+		if !h.servesMood(m) {
+			writeErrorDetails(w, r, http.StatusNotFound, CodeMoodNotFound, "Unknown mood", h.servedMoodDetails(m))
+			return
+		}
+	}
+	if from == to {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidMoods, "from and to must be different moods")
+		return
+	}
+	steps := DefaultTransitionSteps
+	if raw := q.Get("steps"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 2 || n > MaxTransitionSteps {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidSteps, "steps must be an integer 2-"+strconv.Itoa(MaxTransitionSteps))
+			return
+		}
+		steps = n
+	}
+
+	playlist, hit, err := h.transition(r.Context(), from, to, steps)
+	if r.Context().Err() != nil {
+		return // client went away; the shared load still fills the cache
+	}
+	if err != nil {
+		log.Printf("Error fetching %s to %s transition: %v", from, to, err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+
+	hidden, err := h.hiddenTracks(r)
+	if err != nil {
+		log.Printf("Error fetching hidden tracks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+		return
+	}
+	playlist = withoutHidden(playlist, hidden)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", playlistCacheControl(len(hidden) > 0))
+	w.Header().Set("X-Cache", cacheState(hit))
+	setPlaylistID(w, playlist)
+	h.setPrefetchLink(w, playlist)
+	_, span := tracing.Start(r.Context(), "json.encode")
+	defer span.End()
+	if err := json.NewEncoder(w).Encode(playlist); err != nil {
+		log.Printf("Error encoding transition: %v", err)
+	}
+}
+
+// transitionCacheKey files a transition under its from mood's playlist
+// prefix, so invalidating that mood drops it. A change to the to mood's
+// tracks shows up once the entry expires.
+func transitionCacheKey(from, to string, steps int) string {
+	return cache.NewPlaylistKey(from).With("transition", to).With("steps", strconv.Itoa(steps)).String()
+}
+
+// transition returns a transition playlist from the cache, or builds and
+// caches it
+func (h *Handler) transition(ctx context.Context, from, to string, steps int) (playlist []PlaylistTrack, hit bool, err error) {
+	return h.playlists.GetOrLoad(ctx, transitionCacheKey(from, to, steps), func(ctx context.Context) ([]PlaylistTrack, bool, error) {
+		tracks, err := h.radio.GetTransitionPlaylist(ctx, from, to, steps)
+		if err != nil {
+			return nil, false, err
+		}
+		if tracks == nil {
+			tracks = []*inventory.Track{}
+		}
+		for _, track := range tracks {
+			h.resolveAudioURL(track)
+		}
+		// Tracks keep their own mood; none count as borrowed
+		slim := h.toPlaylistTracks("", tracks)
+		return slim, len(slim) > 0, nil
+	})
+}
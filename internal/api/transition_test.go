@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/radio"
+)
+
+func TestGetTransition(t *testing.T) {
+	repo := setupTestDB(t)
+	h := NewHandler(repo, radio.NewManager(repo), &mockResolver{}, setupTestCache(t))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/api/transition?from=focus&to=calm&steps=3")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var tracks []PlaylistTrack
+	if err := json.NewDecoder(w.Body).Decode(&tracks); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tracks) != 3 {
+		t.Fatalf("got %d tracks, want focus's 2 and calm's 1", len(tracks))
+	}
+	// calm's only track comes in once focus's share starts falling
+	if !strings.HasPrefix(tracks[0].FilePath, "focus/") || tracks[1].FilePath != "calm/track1.mp3" {
+		t.Errorf("transition = %s, %s, %s; want focus first, then calm", tracks[0].FilePath, tracks[1].FilePath, tracks[2].FilePath)
+	}
+	for _, tr := range tracks {
+		if tr.AudioURL == "" || tr.BorrowedFrom != "" {
+			t.Errorf("track %d: audio_url %q, borrowed_from %q", tr.ID, tr.AudioURL, tr.BorrowedFrom)
+		}
+	}
+	if w := get("/api/transition?from=focus&to=calm&steps=3"); w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("repeat X-Cache = %q, want HIT", w.Header().Get("X-Cache"))
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown from", "from=disco&to=calm", http.StatusNotFound, CodeMoodNotFound},
+		{"missing to", "from=focus", http.StatusNotFound, CodeMoodNotFound},
+		{"same mood", "from=calm&to=calm", http.StatusBadRequest, CodeInvalidMoods},
+		{"one step", "from=focus&to=calm&steps=1", http.StatusBadRequest, CodeInvalidSteps},
+		{"too many steps", "from=focus&to=calm&steps=101", http.StatusBadRequest, CodeInvalidSteps},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get("/api/transition?" + tt.query)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
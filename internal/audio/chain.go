@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no provider in a chain has the file
+var ErrNotFound = errors.New("audio file not found in any provider")
+
+// ExistsFunc reports whether a storage backend has the file
+type ExistsFunc func(filePath string) (bool, error)
+
+// Provider pairs a resolver with the existence check for its backend
+type Provider struct {
+	Name     string
+	Resolver Resolver
+	Exists   ExistsFunc // nil = file assumed present
+}
+
+type existsEntry struct {
+	exists    bool
+	expiresAt time.Time
+}
+
+// ChainResolver tries providers in order and returns the URL from the first
+// whose backend has the file. Existence checks are cached for ttl so
+// playlist generation doesn't stat or HEAD every track on every request.
+type ChainResolver struct {
+	providers []Provider
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]existsEntry
+}
+
+// NewChainResolver creates a resolver over providers, checked in order
+func NewChainResolver(ttl time.Duration, providers ...Provider) *ChainResolver {
+	return &ChainResolver{
+		providers: providers,
+		ttl:       ttl,
+		now:       time.Now,
+		cache:     make(map[string]existsEntry),
+	}
+}
+
+// ResolveURL returns the first provider URL whose backend has the file,
+// ErrNotFound if none do, or ErrUnsafePath without asking any.
+func (c *ChainResolver) ResolveURL(filePath string) (string, error) {
+	safe, err := cleanPath(filePath)
+	if err != nil {
+		return "", err
+	}
+	for i, p := range c.providers {
+		if !c.exists(i, p, safe) {
+			continue
+		}
+		return p.Resolver.ResolveURL(safe)
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, safe)
+}
+
+// exists consults the cache before running the provider's check.
+// Check errors count as a miss and are not cached, so they retry next time.
+func (c *ChainResolver) exists(idx int, p Provider, filePath string) bool {
+	if p.Exists == nil {
+		return true
+	}
+
+	key := fmt.Sprintf("%d:%s", idx, filePath)
+	now := c.now()
+
+	c.mu.Lock()
+	e, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && now.Before(e.expiresAt) {
+		return e.exists
+	}
+
+	found, err := p.Exists(filePath)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	c.cache[key] = existsEntry{exists: found, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return found
+}
+
+// LocalExists returns an ExistsFunc that stats files under dir
+func LocalExists(dir string) ExistsFunc {
+	return func(filePath string) (bool, error) {
+		safe, err := cleanPath(filePath)
+		if err != nil {
+			return false, err
+		}
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(safe)))
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return !info.IsDir(), nil
+	}
+}
+
+// HTTPExists returns an ExistsFunc that sends a HEAD request for the file
+// under baseURL. Any 2xx counts as present; 404/410 as absent.
+func HTTPExists(client *http.Client, baseURL string) ExistsFunc {
+	base := strings.TrimRight(baseURL, "/")
+	return func(filePath string) (bool, error) {
+		safe, err := cleanPath(filePath)
+		if err != nil {
+			return false, err
+		}
+		req, err := http.NewRequest(http.MethodHead, base+"/"+safe, nil)
+		if err != nil {
+			return false, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, err
+		}
+		_ = resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return true, nil
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			return false, nil
+		default:
+			return false, fmt.Errorf("HEAD %s: unexpected status %d", filePath, resp.StatusCode)
+		}
+	}
+}
+
+// RemoteResolver returns URLs under an absolute base URL (e.g. a CDN or bucket)
+type RemoteResolver struct {
+	BaseURL string // e.g., "https://cdn.example.com/audio"
+}
+
+// NewRemoteResolver creates a resolver for files served from baseURL
+func NewRemoteResolver(baseURL string) Resolver {
+	return &RemoteResolver{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// ResolveURL returns the remote URL for a track, or ErrUnsafePath
+func (r *RemoteResolver) ResolveURL(filePath string) (string, error) {
+	safe, err := cleanPath(filePath)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", r.BaseURL, safe), nil
+}
@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeBackend is an ExistsFunc over a fixed file set that counts calls
+type fakeBackend struct {
+	files map[string]bool
+	calls int
+	err   error
+}
+
+func (f *fakeBackend) exists(filePath string) (bool, error) {
+	f.calls++
+	return f.files[filePath], f.err
+}
+
+func TestChainResolver_Ordering(t *testing.T) {
+	local := &fakeBackend{files: map[string]bool{"a.mp3": true}}
+	remote := &fakeBackend{files: map[string]bool{"a.mp3": true, "b.mp3": true}}
+
+	chain := NewChainResolver(time.Minute,
+		Provider{Name: "local", Resolver: NewResolver("audio"), Exists: local.exists},
+		Provider{Name: "remote", Resolver: NewRemoteResolver("https://cdn.example.com/audio/"), Exists: remote.exists},
+	)
+
+	tests := []struct {
+		filePath string
+		want     string
+	}{
+		{"a.mp3", "/audio/a.mp3"},                        // local wins when both have it
+		{"b.mp3", "https://cdn.example.com/audio/b.mp3"}, // falls through to remote
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			got, err := chain.ResolveURL(tt.filePath)
+			if err != nil {
+				t.Fatalf("ResolveURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveURL(%q) = %q, want %q", tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainResolver_NilExistsAssumesPresent(t *testing.T) {
+	chain := NewChainResolver(time.Minute,
+		Provider{Name: "local", Resolver: NewResolver("audio"), Exists: (&fakeBackend{}).exists},
+		Provider{Name: "remote", Resolver: NewRemoteResolver("https://cdn.example.com")},
+	)
+
+	got, err := chain.ResolveURL("x.mp3")
+	if err != nil || got != "https://cdn.example.com/x.mp3" {
+		t.Errorf("ResolveURL() = %q, %v; want remote URL", got, err)
+	}
+}
+
+func TestChainResolver_AllMiss(t *testing.T) {
+	chain := NewChainResolver(time.Minute,
+		Provider{Name: "local", Resolver: NewResolver("audio"), Exists: (&fakeBackend{}).exists},
+		Provider{Name: "remote", Resolver: NewRemoteResolver("https://cdn"), Exists: (&fakeBackend{err: errors.New("timeout")}).exists},
+	)
+
+	got, err := chain.ResolveURL("missing.mp3")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+	if got != "" {
+		t.Errorf("url = %q, want empty", got)
+	}
+}
+
+func TestChainResolver_CachesExistence(t *testing.T) {
+	local := &fakeBackend{files: map[string]bool{"a.mp3": true}}
+	chain := NewChainResolver(time.Minute, Provider{Name: "local", Resolver: NewResolver("audio"), Exists: local.exists})
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	chain.now = func() time.Time { return now }
+
+	for range 3 {
+		_, _ = chain.ResolveURL("a.mp3")
+		_, _ = chain.ResolveURL("gone.mp3")
+	}
+	if local.calls != 2 {
+		t.Errorf("exists called %d times, want 2 (hits and misses both cached)", local.calls)
+	}
+
+	// After TTL the check runs again and sees the new state
+	local.files["gone.mp3"] = true
+	now = now.Add(2 * time.Minute)
+	if _, err := chain.ResolveURL("gone.mp3"); err != nil {
+		t.Errorf("expected file to resolve after TTL expiry, got %v", err)
+	}
+	if local.calls != 3 {
+		t.Errorf("exists called %d times, want 3", local.calls)
+	}
+}
+
+func TestChainResolver_ErrorsNotCached(t *testing.T) {
+	backend := &fakeBackend{files: map[string]bool{"a.mp3": true}, err: errors.New("flaky")}
+	chain := NewChainResolver(time.Minute, Provider{Name: "remote", Resolver: NewRemoteResolver("https://cdn"), Exists: backend.exists})
+
+	if _, err := chain.ResolveURL("a.mp3"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want ErrNotFound while backend errors", err)
+	}
+	backend.err = nil
+	if _, err := chain.ResolveURL("a.mp3"); err != nil {
+		t.Errorf("expected recovery once backend stops erroring, got %v", err)
+	}
+}
+
+func TestLocalExists(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "focus"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "focus", "a.mp3"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exists := LocalExists(dir)
+	for path, want := range map[string]bool{"focus/a.mp3": true, "focus/b.mp3": false, "focus": false} {
+		got, err := exists(path)
+		if err != nil || got != want {
+			t.Errorf("LocalExists(%q) = %v, %v; want %v", path, got, err, want)
+		}
+	}
+}
+
+func TestHTTPExists(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio/a.mp3":
+			w.WriteHeader(http.StatusOK)
+		case "/audio/broken.mp3":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	exists := HTTPExists(srv.Client(), srv.URL+"/audio/")
+
+	if ok, err := exists("a.mp3"); !ok || err != nil {
+		t.Errorf("a.mp3: got %v, %v; want true", ok, err)
+	}
+	if ok, err := exists("b.mp3"); ok || err != nil {
+		t.Errorf("b.mp3: got %v, %v; want false, nil", ok, err)
+	}
+	if _, err := exists("broken.mp3"); err == nil {
+		t.Error("broken.mp3: expected error for 500")
+	}
+}
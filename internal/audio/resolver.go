@@ -2,7 +2,6 @@ package audio
 
 import (
 	"fmt"
-	"path"
 	"strings"
 )
 
@@ -17,25 +16,16 @@ func NewResolver(basePath string) Resolver {
 	return &LocalResolver{BasePath: "/" + strings.Trim(basePath, "/")}
 }
 
-// sanitizePath cleans a file path to prevent traversal attacks
-func sanitizePath(filePath string) string {
-	// Clean the path and remove any traversal attempts
-	cleaned := path.Clean(filePath)
-	// Remove leading slashes and parent references
-	cleaned = strings.TrimPrefix(cleaned, "/")
-	for strings.HasPrefix(cleaned, "../") {
-		cleaned = strings.TrimPrefix(cleaned, "../")
-	}
-	return cleaned
-}
-
 // LocalResolver returns local file server paths
 type LocalResolver struct {
 	BasePath string // e.g., "/audio"
 }
 
-// ResolveURL returns the local path for a track
+// ResolveURL returns the local path for a track, or ErrUnsafePath
 func (r *LocalResolver) ResolveURL(filePath string) (string, error) {
-	safe := sanitizePath(filePath)
+	safe, err := cleanPath(filePath)
+	if err != nil {
+		return "", err
+	}
 	return fmt.Sprintf("%s/%s", r.BasePath, safe), nil
 }
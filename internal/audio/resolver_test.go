@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -15,9 +16,9 @@ func TestLocalResolver(t *testing.T) {
 		{"simple path", "track.mp3", "/audio/track.mp3"},
 		{"nested path", "focus/track1.mp3", "/audio/focus/track1.mp3"},
 		{"deep path", "focus/ambient/track.mp3", "/audio/focus/ambient/track.mp3"},
-		{"traversal attempt", "../../../etc/passwd", "/audio/etc/passwd"},
 		{"leading slash", "/focus/track.mp3", "/audio/focus/track.mp3"},
-		{"double dots in middle", "focus/../calm/track.mp3", "/audio/calm/track.mp3"},
+		{"redundant separators", "focus//./track.mp3", "/audio/focus/track.mp3"},
+		{"dots in a name", "focus/..track.mp3", "/audio/focus/..track.mp3"},
 	}
 
 	for _, tt := range tests {
@@ -32,3 +33,34 @@ func TestLocalResolver(t *testing.T) {
 		})
 	}
 }
+
+func TestResolvers_RejectTraversal(t *testing.T) {
+	resolvers := map[string]Resolver{
+		"local":  NewResolver("audio"),
+		"remote": NewRemoteResolver("https://cdn.example.com/audio"),
+	}
+	inputs := []string{
+		"../../../etc/passwd",
+		"..",
+		"focus/../calm/track.mp3",
+		"focus/../../secret.mp3",
+		"/../etc/passwd",
+		`focus\..\..\etc\passwd`,
+		"focus/track.mp3\x00.txt",
+		"",
+		"/",
+		".",
+	}
+
+	for name, resolver := range resolvers {
+		for _, in := range inputs {
+			got, err := resolver.ResolveURL(in)
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("%s ResolveURL(%q) = %q, %v; want ErrUnsafePath", name, in, got, err)
+			}
+			if got != "" {
+				t.Errorf("%s ResolveURL(%q) returned rewritten path %q", name, in, got)
+			}
+		}
+	}
+}
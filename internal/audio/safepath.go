@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrUnsafePath is returned for a file path that could reach outside the
+// audio root: one with a ".." segment, a backslash or a NUL byte, or one
+// naming the root itself
+var ErrUnsafePath = errors.New("unsafe audio path")
+
+// cleanPath returns filePath relative to the audio root, or ErrUnsafePath.
+// Traversal is rejected, never rewritten: "../../etc/passwd" is an error,
+// not "etc/passwd". A leading slash is allowed and dropped.
+func cleanPath(filePath string) (string, error) {
+	if strings.ContainsAny(filePath, "\\\x00") {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, filePath)
+	}
+	for seg := range strings.SplitSeq(filePath, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("%w: %q", ErrUnsafePath, filePath)
+		}
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, filePath)
+	}
+	return cleaned, nil
+}
+
+// RejectUnsafePaths answers 400 for requests whose path (relative to the
+// audio mount, as left by http.StripPrefix) fails cleanPath, so a traversal
+// attempt never reaches the file server
+func RejectUnsafePaths(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := cleanPath(r.URL.Path); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRejectUnsafePaths(t *testing.T) {
+	root := t.TempDir()
+	audioDir := filepath.Join(root, "audio")
+	if err := os.MkdirAll(filepath.Join(audioDir, "focus"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(audioDir, "focus", "a.mp3"), []byte("audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/audio/", http.StripPrefix("/audio/", RejectUnsafePaths(http.FileServer(http.Dir(audioDir)))))
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{"file", "/audio/focus/a.mp3", http.StatusOK},
+		{"encoded traversal", "/audio/%2e%2e/secret.txt", http.StatusBadRequest},
+		{"encoded slash traversal", "/audio/focus%2f..%2f..%2fsecret.txt", http.StatusBadRequest},
+		{"backslash traversal", "/audio/..%5csecret.txt", http.StatusBadRequest},
+		{"NUL byte", "/audio/focus/a.mp3%00.txt", http.StatusBadRequest},
+		{"audio root", "/audio/", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Body.String() == "secret" {
+				t.Error("file outside the audio root was served")
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSigner returns a signer with a clock the test can move
+func newTestSigner(now *time.Time) *Signer {
+	s := NewSigner([]byte("0123456789abcdef0123456789abcdef"), "/audio/")
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+// splitSigned separates a signed URL into its path and query
+func splitSigned(t *testing.T, signed string) (string, url.Values) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("signed URL %q does not parse: %v", signed, err)
+	}
+	return u.Path, u.Query()
+}
+
+// mustSign signs filePath for a minute
+func mustSign(t *testing.T, s *Signer, filePath string) string {
+	t.Helper()
+	signed, err := s.SignedURL(filePath, time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL(%q) error = %v", filePath, err)
+	}
+	return signed
+}
+
+func TestSigner_SignedURLRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSigner(&now)
+
+	signed, err := s.SignedURL("focus/a.mp3", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	path, q := splitSigned(t, signed)
+	if path != "/audio/focus/a.mp3" {
+		t.Errorf("path = %q, want /audio/focus/a.mp3", path)
+	}
+	if q.Get("exp") != "1767272400" {
+		t.Errorf("exp = %q, want one hour after now", q.Get("exp"))
+	}
+	if err := s.Verify("focus/a.mp3", q); err != nil {
+		t.Errorf("Verify() = %v, want nil", err)
+	}
+	// Paths are compared after cleaning, so a leading slash is equivalent
+	if err := s.Verify("/focus/a.mp3", q); err != nil {
+		t.Errorf("Verify() with leading slash = %v, want nil", err)
+	}
+	// A traversal that cleans to the signed path is rejected, not matched
+	if err := s.Verify("calm/../focus/a.mp3", q); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Verify() of traversal = %v, want %v", err, ErrTokenInvalid)
+	}
+	if got, err := s.SignedURL("../etc/passwd", time.Hour); !errors.Is(err, ErrUnsafePath) || got != "" {
+		t.Errorf("SignedURL() of traversal = %q, %v; want ErrUnsafePath", got, err)
+	}
+}
+
+func TestSigner_Verify(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSigner(&now)
+	_, q := splitSigned(t, mustSign(t, s, "focus/a.mp3"))
+
+	with := func(key, value string) url.Values {
+		out := url.Values{"exp": {q.Get("exp")}, "t": {q.Get("t")}}
+		out.Set(key, value)
+		return out
+	}
+	otherKey := NewSigner([]byte("fedcba9876543210fedcba9876543210"), "/audio")
+	otherKey.now = s.now
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		path   string
+		query  url.Values
+		want   error
+	}{
+		{"missing token", s, "focus/a.mp3", url.Values{"exp": {q.Get("exp")}}, ErrTokenMissing},
+		{"missing expiry", s, "focus/a.mp3", url.Values{"t": {q.Get("t")}}, ErrTokenMissing},
+		{"other file", s, "focus/b.mp3", q, ErrTokenInvalid},
+		{"extended expiry", s, "focus/a.mp3", with("exp", "9999999999"), ErrTokenInvalid},
+		{"non-numeric expiry", s, "focus/a.mp3", with("exp", "soon"), ErrTokenInvalid},
+		{"tampered token", s, "focus/a.mp3", with("t", strings.Repeat("A", 43)), ErrTokenInvalid},
+		{"different key", otherKey, "focus/a.mp3", q, ErrTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signer.Verify(tt.path, tt.query); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigner_Expired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSigner(&now)
+	_, q := splitSigned(t, mustSign(t, s, "focus/a.mp3"))
+
+	now = now.Add(59 * time.Second)
+	if err := s.Verify("focus/a.mp3", q); err != nil {
+		t.Fatalf("Verify() before expiry = %v, want nil", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := s.Verify("focus/a.mp3", q); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Verify() at expiry = %v, want %v", err, ErrTokenExpired)
+	}
+}
+
+func TestSigner_RequireToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSigner(&now)
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/audio/", http.StripPrefix("/audio/", s.RequireToken(inner)))
+
+	signed := mustSign(t, s, "focus/a.mp3")
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{"valid token", signed, http.StatusOK},
+		{"with a content version", signed + "&v=0a1b2c3d4e", http.StatusOK},
+		{"no token", "/audio/focus/a.mp3", http.StatusForbidden},
+		{"token for another file", strings.Replace(signed, "a.mp3", "b.mp3", 1), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	now = now.Add(time.Hour)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, signed, nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expired token status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,129 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Bounds on a throttled write
+const (
+	// maxThrottleChunk caps the bytes sent between pauses
+	maxThrottleChunk = 32 << 10
+	// throttleWriteTimeout is the write deadline of each chunk; a throttled
+	// file outlasts the server's write timeout, so the deadline moves with
+	// the stream rather than covering the whole response
+	throttleWriteTimeout = 30 * time.Second
+)
+
+// Throttle paces the response bodies of next to about bytesPerSec, so a
+// player streams audio roughly as fast as it plays instead of downloading
+// whole files it may skip. The pacing sits under the io.Copy that
+// http.ServeContent makes after resolving a Range, so partial responses are
+// paced the same way and stay byte-exact. bytesPerSec below 1 returns next
+// unchanged.
+//
+// A throttled transfer lasts about as long as the track plays, and graceful
+// shutdown waits for audio transfers in flight, so with throttling on a
+// drain can take up to server.drain_timeout.
+func Throttle(bytesPerSec int, next http.Handler) http.Handler {
+	if bytesPerSec < 1 {
+		return next
+	}
+	chunk := min(max(bytesPerSec/4, 1), maxThrottleChunk)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&throttledWriter{
+			ResponseWriter: w,
+			rc:             http.NewResponseController(w),
+			ctx:            r.Context(),
+			rate:           float64(bytesPerSec),
+			chunk:          chunk,
+		}, r)
+	})
+}
+
+// throttledWriter writes in chunks, each no earlier than the rate allows
+// for the bytes sent before it. It deliberately has no ReadFrom, so io.Copy
+// goes through Write rather than sendfile.
+type throttledWriter struct {
+	http.ResponseWriter
+	rc    *http.ResponseController
+	ctx   context.Context
+	rate  float64 // bytes per second
+	chunk int
+	start time.Time
+	sent  int64
+}
+
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	if tw.start.IsZero() {
+		tw.start = time.Now()
+	}
+	var n int
+	for len(p) > 0 {
+		size := min(len(p), tw.chunk)
+		if err := tw.wait(); err != nil {
+			return n, err
+		}
+		if err := tw.setDeadline(); err != nil {
+			return n, err
+		}
+		m, err := tw.ResponseWriter.Write(p[:size])
+		n += m
+		tw.sent += int64(m)
+		if err != nil {
+			return n, err
+		}
+		p = p[size:]
+	}
+	return n, nil
+}
+
+// noDeadlineWarning logs once that a writer in the chain hides the
+// connection's deadlines
+var noDeadlineWarning sync.Once
+
+// setDeadline moves the write deadline past the next chunk. A writer that
+// can't reach the connection leaves the server's write timeout in force,
+// which cuts throttled files off; that is logged rather than failing the
+// request.
+func (tw *throttledWriter) setDeadline() error {
+	err := tw.rc.SetWriteDeadline(time.Now().Add(throttleWriteTimeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		noDeadlineWarning.Do(func() {
+			log.Printf("Warning: audio throttle can't set write deadlines through %T; throttled files may be cut off by server.write_timeout", tw.ResponseWriter)
+		})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+	return nil
+}
+
+// wait sleeps until the bytes already sent are due at the rate, or the
+// request is canceled
+func (tw *throttledWriter) wait() error {
+	due := tw.start.Add(time.Duration(float64(tw.sent) / tw.rate * float64(time.Second)))
+	d := time.Until(due)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-tw.ctx.Done():
+		return tw.ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (tw *throttledWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/compress"
+	"github.com/1mb-dev/driftfm/internal/drain"
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+// serveBytes serves body through http.ServeContent, which handles Range
+func serveBytes(body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "track.mp3", time.Time{}, bytes.NewReader(body))
+	})
+}
+
+func TestThrottle(t *testing.T) {
+	body := bytes.Repeat([]byte("drift"), 32<<10/5) // ~32KB
+	get := func(h http.Handler, rangeHeader string) (*httptest.ResponseRecorder, time.Duration) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/focus/track.mp3", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		start := time.Now()
+		h.ServeHTTP(w, req)
+		return w, time.Since(start)
+	}
+
+	// 64KB/s in 16KB chunks: the second chunk is due at 250ms
+	w, unthrottled := get(serveBytes(body), "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), body) {
+		t.Fatalf("unthrottled: status %d, %d bytes", w.Code, w.Body.Len())
+	}
+	w, throttled := get(Throttle(64<<10, serveBytes(body)), "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), body) {
+		t.Fatalf("throttled: status %d, %d bytes, want the whole body", w.Code, w.Body.Len())
+	}
+	if throttled < 200*time.Millisecond || throttled < unthrottled+150*time.Millisecond {
+		t.Errorf("throttled stream took %v (unthrottled %v), want at least 200ms", throttled, unthrottled)
+	}
+
+	// A Range response is byte-exact and paced by its own length
+	w, ranged := get(Throttle(64<<10, serveBytes(body)), "bytes=1000-17383")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("range status = %d, want 206", w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), body[1000:17384]) {
+		t.Errorf("range body is %d bytes, want bytes 1000-17383", w.Body.Len())
+	}
+	if got := w.Header().Get("Content-Length"); got != "16384" {
+		t.Errorf("Content-Length = %s, want 16384", got)
+	}
+	if ranged > throttled {
+		t.Errorf("16KB range took %v, longer than the whole body's %v", ranged, throttled)
+	}
+}
+
+func TestThrottleOff(t *testing.T) {
+	var got http.ResponseWriter
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { got = w })
+	w := httptest.NewRecorder()
+	Throttle(0, next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/focus/track.mp3", nil))
+	if got != w {
+		t.Errorf("Throttle(0) wrapped the writer in %T, want it unthrottled", got)
+	}
+}
+
+func TestThrottleCanceled(t *testing.T) {
+	body := make([]byte, 64<<10)
+	req := httptest.NewRequest(http.MethodGet, "/focus/track.mp3", nil)
+	ctx, cancel := context.WithTimeout(req.Context(), 100*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	Throttle(1<<10, serveBytes(body)).ServeHTTP(w, req.WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("canceled stream took %v, want it to stop with the request", elapsed)
+	}
+	if w.Body.Len() >= len(body) {
+		t.Errorf("canceled stream sent all %d bytes", w.Body.Len())
+	}
+}
+
+func TestThrottleOutlastsWriteTimeout(t *testing.T) {
+	// ~32KB at 64KB/s takes about 500ms, well past the write timeout; the
+	// per-chunk deadlines have to reach the connection through the same
+	// middleware the server wraps /audio/ in
+	body := bytes.Repeat([]byte("drift"), 32<<10/5)
+	compressor, err := compress.New([]string{"gzip"}, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/audio/", Throttle(64<<10, serveBytes(body)))
+	srv := httptest.NewUnstartedServer(metrics.NewLogFilter(nil, nil, nil).Middleware(drain.New().Middleware(compressor.Middleware(mux))))
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/audio/focus/track.mp3")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Errorf("read %d of %d bytes (err %v), want the whole throttled body", len(got), len(body), err)
+	}
+}
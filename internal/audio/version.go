@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VersionParam is the query parameter carrying a file's content version.
+// The audio route ignores it; it only makes a re-mastered file a new URL
+// to browsers and CDNs.
+const VersionParam = "v"
+
+// versionLen is how many hex characters of a hash make a version
+const versionLen = 10
+
+// VersionFunc returns a short content version for a file, or "" when the
+// backend doesn't have it
+type VersionFunc func(filePath string) (string, error)
+
+type versionEntry struct {
+	version   string
+	expiresAt time.Time
+}
+
+// VersionedResolver decorates a resolver, adding the file's content version
+// to each URL so a file replaced under the same path isn't served from a
+// stale browser cache. Versions are cached for ttl so playlist generation
+// doesn't stat every track on every request; a replaced file gets its new
+// version once its entry expires.
+type VersionedResolver struct {
+	next    Resolver
+	version VersionFunc
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]versionEntry
+}
+
+// NewVersionedResolver wraps next, versioning its URLs with version
+func NewVersionedResolver(next Resolver, ttl time.Duration, version VersionFunc) *VersionedResolver {
+	return &VersionedResolver{
+		next:    next,
+		version: version,
+		ttl:     ttl,
+		now:     time.Now,
+		cache:   make(map[string]versionEntry),
+	}
+}
+
+// ResolveURL returns next's URL with the file's version added. A file
+// without a version, or whose version can't be read, resolves unversioned.
+func (v *VersionedResolver) ResolveURL(filePath string) (string, error) {
+	u, err := v.next.ResolveURL(filePath)
+	if err != nil {
+		return u, err
+	}
+	return WithVersion(u, v.lookup(filePath)), nil
+}
+
+// lookup consults the cache before running the version func. Errors count
+// as no version and are not cached, so they retry next time.
+func (v *VersionedResolver) lookup(filePath string) string {
+	now := v.now()
+
+	v.mu.Lock()
+	e, ok := v.cache[filePath]
+	v.mu.Unlock()
+	if ok && now.Before(e.expiresAt) {
+		return e.version
+	}
+
+	version, err := v.version(filePath)
+	if err != nil {
+		return ""
+	}
+
+	v.mu.Lock()
+	v.cache[filePath] = versionEntry{version: version, expiresAt: now.Add(v.ttl)}
+	v.mu.Unlock()
+	return version
+}
+
+// LocalVersion returns a VersionFunc hashing the size and modification time
+// of files under dir; a missing file has no version
+func LocalVersion(dir string) VersionFunc {
+	return statVersion(dir, os.Stat)
+}
+
+func statVersion(dir string, stat func(string) (fs.FileInfo, error)) VersionFunc {
+	return func(filePath string) (string, error) {
+		safe, err := cleanPath(filePath)
+		if err != nil {
+			return "", err
+		}
+		info, err := stat(filepath.Join(dir, filepath.FromSlash(safe)))
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256([]byte(strconv.FormatInt(info.Size(), 10) + ":" + strconv.FormatInt(info.ModTime().UnixNano(), 10)))
+		return hex.EncodeToString(sum[:])[:versionLen], nil
+	}
+}
+
+// HashVersion shortens a content hash (such as a track's content_hash) to a
+// version; "" stays unversioned
+func HashVersion(contentHash string) string {
+	if len(contentHash) > versionLen {
+		return contentHash[:versionLen]
+	}
+	return contentHash
+}
+
+// WithVersion returns rawURL with its version parameter set. An empty
+// version or URL is returned unchanged.
+func WithVersion(rawURL, version string) string {
+	if rawURL == "" || version == "" {
+		return rawURL
+	}
+	base, query, _ := strings.Cut(rawURL, "?")
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return rawURL
+	}
+	q.Set(VersionParam, version)
+	return base + "?" + q.Encode()
+}
+
+// URLVersion returns the version parameter of rawURL, or ""
+func URLVersion(rawURL string) string {
+	_, query, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return ""
+	}
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return ""
+	}
+	return q.Get(VersionParam)
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVersionedResolver_FileUpdate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "focus", "a.mp3")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("first master"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := NewVersionedResolver(NewResolver("audio"), time.Minute, LocalVersion(dir))
+	v.now = func() time.Time { return now }
+
+	before, err := v.ResolveURL("focus/a.mp3")
+	if err != nil {
+		t.Fatalf("ResolveURL() error = %v", err)
+	}
+	if URLVersion(before) == "" {
+		t.Fatalf("ResolveURL() = %q, want a version", before)
+	}
+
+	if err := os.WriteFile(file, []byte("second, longer master"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := v.ResolveURL("focus/a.mp3"); got != before {
+		t.Errorf("within the TTL = %q, want the cached %q", got, before)
+	}
+
+	now = now.Add(2 * time.Minute)
+	after, _ := v.ResolveURL("focus/a.mp3")
+	if URLVersion(after) == "" || after == before {
+		t.Errorf("after the update = %q, want a new version than %q", after, before)
+	}
+
+	if got, _ := v.ResolveURL("focus/missing.mp3"); got != "/audio/focus/missing.mp3" {
+		t.Errorf("missing file = %q, want it unversioned", got)
+	}
+}
+
+func TestVersionedResolver_CachesStat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.mp3"), []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stats := 0
+	stat := func(name string) (fs.FileInfo, error) {
+		stats++
+		return os.Stat(name)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := NewVersionedResolver(NewResolver("audio"), time.Minute, statVersion(dir, stat))
+	v.now = func() time.Time { return now }
+
+	for range 5 {
+		if _, err := v.ResolveURL("a.mp3"); err != nil {
+			t.Fatalf("ResolveURL() error = %v", err)
+		}
+	}
+	if stats != 1 {
+		t.Errorf("stat ran %d times for 5 resolves, want 1", stats)
+	}
+
+	now = now.Add(2 * time.Minute)
+	_, _ = v.ResolveURL("a.mp3")
+	if stats != 2 {
+		t.Errorf("stat ran %d times after the TTL, want 2", stats)
+	}
+}
+
+func TestWithVersion(t *testing.T) {
+	tests := []struct {
+		name, url, version, want string
+	}{
+		{"plain", "/audio/a.mp3", "abc", "/audio/a.mp3?v=abc"},
+		{"signed", "/audio/a.mp3?exp=1&t=x", "abc", "/audio/a.mp3?exp=1&t=x&v=abc"},
+		{"replaces", "https://cdn.example.com/a.mp3?v=old", "new", "https://cdn.example.com/a.mp3?v=new"},
+		{"no version", "/audio/a.mp3", "", "/audio/a.mp3"},
+		{"no url", "", "abc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WithVersion(tt.url, tt.version)
+			if got != tt.want {
+				t.Errorf("WithVersion(%q, %q) = %q, want %q", tt.url, tt.version, got, tt.want)
+			}
+			if tt.url != "" && URLVersion(got) != tt.version {
+				t.Errorf("URLVersion(%q) = %q, want %q", got, URLVersion(got), tt.version)
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package cache
 
 import (
-	"fmt"
+	"log"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -10,91 +11,186 @@ import (
 
 // Default cache configuration
 const (
-	DefaultTTL      = 5 * time.Minute // Playlist refresh interval
+	DefaultTTL      = 5 * time.Minute // Entries without a per-type TTL
 	CleanupInterval = 1 * time.Minute // Expired entry cleanup
+
+	// DefaultEvictBatch is how many expired entries the memory store's
+	// cleanup removes per write-lock hold
+	DefaultEvictBatch = 512
+
+	// DefaultShards is how many independently locked shards the memory
+	// store spreads keys over
+	DefaultShards = 32
+)
+
+// Per-type TTLs. Each matches the Cache-Control max-age of the response it
+// backs, so the server never serves an entry older than clients are told
+// it may be.
+const (
+	MoodsListTTL   = 5 * time.Minute
+	PlaylistTTL    = 60 * time.Second
+	PublicStatsTTL = time.Hour
 )
 
 // Cache keys
 const (
-	KeyMoodsList = "moods:list"
-	KeyPlaylist  = "playlist:%s" // playlist:{mood}
+	KeyMoodsList   = "moods:list"   // prefix of per-locale keys, see MoodsListKey
+	KeyMoodStats   = "moods:stats"  // per-mood track counts and durations behind every moods list
+	KeyPlaylist    = "playlist:"    // prefix of playlist:{mood}:{variant}, see NewPlaylistKey
+	KeyFeatured    = "featured:"    // prefix of featured:{YYYY-MM-DD}, see FeaturedKey
+	KeyPublicStats = "stats:public" // library totals behind GET /api/stats/public
 )
 
-type entry struct {
-	value     any
-	expiresAt time.Time
+// Store is a cache backend. Values must be JSON-serializable: a remote store
+// returns them as json.RawMessage rather than the original Go value, so
+// callers should only re-encode what they get back.
+type Store interface {
+	// Get returns the value for key; a backend failure is reported as a miss.
+	Get(key string) (any, bool)
+	// Set stores value under key with DefaultTTL.
+	Set(key string, value any) error
+	// SetWithTTL stores value under key, expiring after ttl.
+	SetWithTTL(key string, value any, ttl time.Duration) error
+	// Delete removes the given keys; missing keys are ignored.
+	Delete(keys ...string) error
+	// Keys lists the keys that start with prefix.
+	Keys(prefix string) ([]string, error)
+	// Stats returns backend statistics for the metrics endpoint.
+	Stats() map[string]any
+	Close() error
 }
 
-// Cache is a simple in-memory key-value store with TTL expiration.
+// Cache namespaces keys on a Store and tracks hit/miss statistics.
+// Views created with WithNamespace share one store without seeing each
+// other's entries.
 type Cache struct {
-	mu      sync.RWMutex
-	items   map[string]entry
-	hits    atomic.Int64
-	misses  atomic.Int64
-	stopCh  chan struct{}
-	stopped chan struct{}
+	store     Store
+	namespace string
+	hits      atomic.Int64
+	misses    atomic.Int64
+	// mismatches counts entries a Typed view evicted for holding the wrong type
+	mismatches atomic.Int64
+
+	loadTimeout time.Duration
+	loadMu      sync.Mutex
+	loads       map[string]*load // in-flight GetOrLoad calls by key
+	loadGen     uint64           // bumped by each invalidation; see GetOrLoad
 }
 
-// New creates a new cache that periodically evicts expired entries.
+// New creates a cache backed by an in-memory store.
 func New() (*Cache, error) {
-	c := &Cache{
-		items:   make(map[string]entry),
-		stopCh:  make(chan struct{}),
-		stopped: make(chan struct{}),
-	}
-	go c.cleanup()
-	return c, nil
-}
-
-func (c *Cache) cleanup() {
-	defer close(c.stopped)
-	ticker := time.NewTicker(CleanupInterval)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
-			c.evictExpired()
-		case <-c.stopCh:
-			return
-		}
-	}
+	return NewWithStore(NewMemoryStore()), nil
 }
 
-func (c *Cache) evictExpired() {
-	now := time.Now()
-	c.mu.Lock()
-	for k, e := range c.items {
-		if now.After(e.expiresAt) {
-			delete(c.items, k)
-		}
+// NewWithStore creates a cache on the given backend.
+func NewWithStore(s Store) *Cache {
+	return &Cache{store: s, loadTimeout: DefaultLoadTimeout}
+}
+
+// WithNamespace returns a view of the same backing store whose keys are
+// prefixed with namespace, so deployments of different versions sharing a
+// backend cannot read or invalidate each other's entries. Hit/miss stats
+// are tracked per view. An empty namespace leaves keys unprefixed.
+func (c *Cache) WithNamespace(namespace string) *Cache {
+	return &Cache{store: c.store, namespace: namespace, loadTimeout: c.loadTimeout}
+}
+
+// key maps a logical key into the cache's namespace.
+func (c *Cache) key(k string) string {
+	if c.namespace == "" {
+		return k
 	}
-	c.mu.Unlock()
+	return c.namespace + ":" + k
 }
 
-// Get retrieves a value from cache. Returns (nil, false) on miss or expiry.
+// Get retrieves a value from cache. Returns (nil, false) on miss, expiry,
+// or backend failure.
 func (c *Cache) Get(key string) (any, bool) {
-	c.mu.RLock()
-	e, ok := c.items[key]
-	c.mu.RUnlock()
-	if !ok || time.Now().After(e.expiresAt) {
+	v, ok := c.store.Get(c.key(key))
+	if !ok {
 		c.misses.Add(1)
 		return nil, false
 	}
 	c.hits.Add(1)
-	return e.value, true
+	return v, true
 }
 
-// Set stores a value with the default TTL.
+// Set stores a value with the TTL for its key type (see TTLFor).
 func (c *Cache) Set(key string, value any) error {
-	c.mu.Lock()
-	c.items[key] = entry{value: value, expiresAt: time.Now().Add(DefaultTTL)}
-	c.mu.Unlock()
-	return nil
+	return c.SetWithTTL(key, value, TTLFor(key))
+}
+
+// SetWithTTL stores a value that expires after ttl.
+func (c *Cache) SetWithTTL(key string, value any, ttl time.Duration) error {
+	return c.store.SetWithTTL(c.key(key), value, ttl)
+}
+
+// TTLFor returns how long an entry under key lives: the per-type TTL of a
+// moods list or playlist key, DefaultTTL for anything else.
+func TTLFor(key string) time.Duration {
+	switch {
+	case strings.HasPrefix(key, KeyMoodsList):
+		return MoodsListTTL
+	case strings.HasPrefix(key, KeyPlaylist):
+		return PlaylistTTL
+	case key == KeyPublicStats:
+		return PublicStatsTTL
+	default:
+		return DefaultTTL
+	}
+}
+
+// MoodsListKey returns the cache key for the moods list in a locale.
+func MoodsListKey(locale string) string {
+	return KeyMoodsList + ":" + locale
+}
+
+// FeaturedKey returns the cache key for a UTC day's featured track. The key
+// changes at midnight UTC, so a cached pick never outlives its day.
+func FeaturedKey(date string) string {
+	return KeyFeatured + date
 }
 
-// PlaylistKey returns the cache key for a mood's playlist.
+// PlaylistKey returns the cache key for a mood's unfiltered playlist.
 func PlaylistKey(mood string) string {
-	return fmt.Sprintf(KeyPlaylist, mood)
+	return NewPlaylistKey(mood).String()
+}
+
+// PlaylistKeyBuilder builds the cache key for one variant of a mood's
+// playlist. Every variant of a mood shares the prefix "playlist:{mood}:",
+// which is what InvalidateMood deletes.
+type PlaylistKeyBuilder struct {
+	mood  string
+	parts []string
+}
+
+// NewPlaylistKey starts a playlist key for mood.
+func NewPlaylistKey(mood string) PlaylistKeyBuilder {
+	return PlaylistKeyBuilder{mood: mood}
+}
+
+// With adds a variant part, "name=value" or just "name" when value is empty.
+// Callers must add parts in a fixed order so equal variants share a key.
+func (b PlaylistKeyBuilder) With(name, value string) PlaylistKeyBuilder {
+	part := name
+	if value != "" {
+		part += "=" + value
+	}
+	b.parts = append(slices.Clip(b.parts), part)
+	return b
+}
+
+// String returns the key; a playlist with no variant parts is "default".
+func (b PlaylistKeyBuilder) String() string {
+	if len(b.parts) == 0 {
+		return playlistPrefix(b.mood) + "default"
+	}
+	return playlistPrefix(b.mood) + strings.Join(b.parts, ":")
+}
+
+// playlistPrefix is the key prefix shared by every variant of a mood's playlist.
+func playlistPrefix(mood string) string {
+	return KeyPlaylist + mood + ":"
 }
 
 // Stats returns cache statistics for the metrics endpoint.
@@ -106,33 +202,67 @@ func (c *Cache) Stats() map[string]any {
 	if total > 0 {
 		hitRate = float64(hits) / float64(total)
 	}
-	c.mu.RLock()
-	keyCount := len(c.items)
-	c.mu.RUnlock()
-	return map[string]any{
-		"hits":      hits,
-		"misses":    misses,
-		"hit_rate":  hitRate,
-		"key_count": keyCount,
-		"total":     total,
+
+	stats := c.store.Stats()
+	stats["hits"] = hits
+	stats["misses"] = misses
+	stats["hit_rate"] = hitRate
+	stats["total"] = total
+	stats["type_mismatches"] = c.mismatches.Load()
+	if n, err := c.KeyCount(); err == nil {
+		stats["key_count"] = n
+	}
+	return stats
+}
+
+// KeyCount returns how many keys the cache holds in its namespace. It fails
+// when the store can't be reached.
+func (c *Cache) KeyCount() (int, error) {
+	keys, err := c.store.Keys(c.key(""))
+	if err != nil {
+		return 0, err
 	}
+	return len(keys), nil
 }
 
-// InvalidateMoods clears all mood-related cache entries.
+// InvalidateMoods clears all mood-related cache entries in the cache's namespace.
 func (c *Cache) InvalidateMoods() {
-	c.mu.Lock()
-	delete(c.items, KeyMoodsList)
-	for k := range c.items {
-		if strings.HasPrefix(k, "playlist:") {
-			delete(c.items, k)
+	c.deletePrefixed(KeyMoodsList, KeyMoodStats, KeyPlaylist)
+}
+
+// InvalidateMood clears every playlist variant of one mood, plus the moods
+// list and mood stats whose counts it feeds. Other moods' playlists stay
+// cached.
+func (c *Cache) InvalidateMood(mood string) {
+	c.deletePrefixed(KeyMoodsList, KeyMoodStats, playlistPrefix(mood))
+}
+
+// InvalidateMoodsList clears the cached moods list in every locale and sort,
+// leaving playlists and mood stats cached.
+func (c *Cache) InvalidateMoodsList() {
+	c.deletePrefixed(KeyMoodsList)
+}
+
+// deletePrefixed deletes every key in the cache's namespace that starts with
+// one of prefixes. Loads in flight are kept from caching what they read
+// before it. Failures are logged; a stale entry expires with its TTL.
+func (c *Cache) deletePrefixed(prefixes ...string) {
+	c.bumpLoadGen()
+	var keys []string
+	for _, prefix := range prefixes {
+		matched, err := c.store.Keys(c.key(prefix))
+		if err != nil {
+			log.Printf("Warning: failed to list %s cache keys: %v", prefix, err)
+			continue
 		}
+		keys = append(keys, matched...)
+	}
+	if err := c.store.Delete(keys...); err != nil {
+		log.Printf("Warning: failed to invalidate mood cache: %v", err)
 	}
-	c.mu.Unlock()
 }
 
-// Close stops the cleanup goroutine.
+// Close closes the backing store, which is shared with every namespace view.
 func (c *Cache) Close() error {
-	close(c.stopCh)
-	<-c.stopped
-	return nil
+	return c.store.Close()
 }
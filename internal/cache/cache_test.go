@@ -1,6 +1,12 @@
 package cache
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -65,8 +71,10 @@ func TestInvalidateMoods(t *testing.T) {
 
 	// Set some values
 	_ = c.Set(KeyMoodsList, []string{"focus", "calm"})
+	_ = c.Set(MoodsListKey("es"), []string{"focus", "calm"})
 	_ = c.Set(PlaylistKey("focus"), "focus-playlist")
 	_ = c.Set(PlaylistKey("calm"), "calm-playlist")
+	_ = c.Set(KeyMoodStats, "stats")
 	_ = c.Set("other-key", "other-value")
 
 	// Verify they exist
@@ -81,12 +89,18 @@ func TestInvalidateMoods(t *testing.T) {
 	if _, found := c.Get(KeyMoodsList); found {
 		t.Error("moods list should be invalidated")
 	}
+	if _, found := c.Get(MoodsListKey("es")); found {
+		t.Error("per-locale moods list should be invalidated")
+	}
 	if _, found := c.Get(PlaylistKey("focus")); found {
 		t.Error("focus playlist should be invalidated")
 	}
 	if _, found := c.Get(PlaylistKey("calm")); found {
 		t.Error("calm playlist should be invalidated")
 	}
+	if _, found := c.Get(KeyMoodStats); found {
+		t.Error("mood stats should be invalidated")
+	}
 
 	// Other keys should still exist
 	if _, found := c.Get("other-key"); !found {
@@ -94,6 +108,93 @@ func TestInvalidateMoods(t *testing.T) {
 	}
 }
 
+func TestInvalidateMood(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	focusKeys := []string{
+		PlaylistKey("focus"),
+		NewPlaylistKey("focus").With("instrumental", "").String(),
+		NewPlaylistKey("focus").With("tags", "piano,rain").With("limit", "5").String(),
+		NewPlaylistKey("focus").With("intensity", "3-6").String(),
+	}
+	survivors := []string{
+		PlaylistKey("calm"),
+		NewPlaylistKey("calm").With("instrumental", "").String(),
+		PlaylistKey("focus_deep"), // shares a name prefix, not a mood
+		"other-key",
+	}
+	for _, key := range append(focusKeys, survivors...) {
+		_ = c.Set(key, "v")
+	}
+	_ = c.Set(MoodsListKey("en"), "moods")
+	_ = c.Set(KeyMoodStats, "stats")
+
+	c.InvalidateMood("focus")
+
+	for _, key := range append(focusKeys, MoodsListKey("en"), KeyMoodStats) {
+		if _, found := c.Get(key); found {
+			t.Errorf("%s should be invalidated", key)
+		}
+	}
+	for _, key := range survivors {
+		if _, found := c.Get(key); !found {
+			t.Errorf("%s should NOT be invalidated", key)
+		}
+	}
+}
+
+func TestInvalidateMoodsList(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	_ = c.Set(MoodsListKey("en"), "moods")
+	_ = c.Set(MoodsListKey("es")+":sort=tracks", "moods")
+	_ = c.Set(PlaylistKey("focus"), "v")
+	_ = c.Set(KeyMoodStats, "stats")
+
+	c.InvalidateMoodsList()
+
+	for _, key := range []string{MoodsListKey("en"), MoodsListKey("es") + ":sort=tracks"} {
+		if _, found := c.Get(key); found {
+			t.Errorf("%s should be invalidated", key)
+		}
+	}
+	if _, found := c.Get(PlaylistKey("focus")); !found {
+		t.Error("playlists should NOT be invalidated")
+	}
+	if _, found := c.Get(KeyMoodStats); !found {
+		t.Error("mood stats should NOT be invalidated")
+	}
+}
+
+func TestPlaylistKeyBuilder(t *testing.T) {
+	base := NewPlaylistKey("calm")
+	tagged := base.With("tags", "piano")
+	both := tagged.With("limit", "5")
+	other := tagged.With("instrumental", "")
+
+	tests := []struct {
+		got, want string
+	}{
+		{base.String(), "playlist:calm:default"},
+		{tagged.String(), "playlist:calm:tags=piano"},
+		{both.String(), "playlist:calm:tags=piano:limit=5"},
+		{other.String(), "playlist:calm:tags=piano:instrumental"}, // builders don't share parts
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("key = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
 func TestCacheExpiry(t *testing.T) {
 	c, err := New()
 	if err != nil {
@@ -102,12 +203,347 @@ func TestCacheExpiry(t *testing.T) {
 	defer func() { _ = c.Close() }()
 
 	// Manually insert an already-expired entry
-	c.mu.Lock()
-	c.items["expired"] = entry{value: "gone", expiresAt: time.Now().Add(-time.Second)}
-	c.mu.Unlock()
+	sh := c.store.(*MemoryStore).shardFor("expired")
+	sh.mu.Lock()
+	sh.items["expired"] = entry{value: "gone", expiresAt: time.Now().Add(-time.Second)}
+	sh.mu.Unlock()
 
 	// Should not be found (expired on read)
 	if _, found := c.Get("expired"); found {
 		t.Error("expected expired value to not be returned")
 	}
 }
+
+func TestNamespacesAreIsolated(t *testing.T) {
+	base, err := New()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer func() { _ = base.Close() }()
+
+	v1 := base.WithNamespace("v1")
+	v2 := base.WithNamespace("v2")
+
+	_ = v1.Set(KeyMoodsList, "v1-moods")
+	_ = v1.Set(PlaylistKey("focus"), "v1-focus")
+	_ = v2.Set(PlaylistKey("focus"), "v2-focus")
+
+	if _, found := v2.Get(KeyMoodsList); found {
+		t.Error("v2 should not see v1's moods list")
+	}
+	if _, found := base.Get(KeyMoodsList); found {
+		t.Error("unnamespaced view should not see v1's moods list")
+	}
+	if val, _ := v2.Get(PlaylistKey("focus")); val != "v2-focus" {
+		t.Errorf("v2 focus playlist = %v, want v2-focus", val)
+	}
+	if n := v1.Stats()["key_count"]; n != 2 {
+		t.Errorf("v1 key_count = %v, want 2", n)
+	}
+
+	// Invalidation stays within the namespace
+	v1.InvalidateMoods()
+	if _, found := v1.Get(PlaylistKey("focus")); found {
+		t.Error("v1 focus playlist should be invalidated")
+	}
+	if _, found := v2.Get(PlaylistKey("focus")); !found {
+		t.Error("v2 focus playlist should survive v1's invalidation")
+	}
+}
+
+func TestCacheStats_ApproxBytes(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	approxBytes := func() int64 {
+		t.Helper()
+		n, ok := c.Stats()["approx_bytes"].(int64)
+		if !ok {
+			t.Fatalf("approx_bytes missing from stats: %v", c.Stats())
+		}
+		return n
+	}
+
+	if n := approxBytes(); n != 0 {
+		t.Errorf("empty cache approx_bytes = %d, want 0", n)
+	}
+
+	_ = c.Set("small", "x")
+	small := approxBytes()
+	if small <= 0 {
+		t.Fatalf("approx_bytes = %d after small value, want > 0", small)
+	}
+
+	_ = c.Set(PlaylistKey("focus"), strings.Repeat("track", 2000))
+	large := approxBytes()
+	if large < small+10000 {
+		t.Errorf("approx_bytes = %d after 10KB value, want at least %d", large, small+10000)
+	}
+
+	// Overwriting replaces the entry's size rather than adding to it
+	_ = c.Set(PlaylistKey("focus"), "short")
+	if n := approxBytes(); n >= large {
+		t.Errorf("approx_bytes = %d after shrinking entry, want < %d", n, large)
+	}
+
+	c.InvalidateMoods()
+	if n := approxBytes(); n != small {
+		t.Errorf("approx_bytes = %d after invalidation, want %d", n, small)
+	}
+}
+
+func TestMemoryStore_ExpiryWithInjectedClock(t *testing.T) {
+	store := NewMemoryStore()
+	defer func() { _ = store.Close() }()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+	c := NewWithStore(store)
+
+	_ = c.Set("key", "value")
+
+	now = now.Add(DefaultTTL - time.Second)
+	if _, found := c.Get("key"); !found {
+		t.Fatal("entry expired before its TTL")
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, found := c.Get("key"); found {
+		t.Error("entry still returned after its TTL")
+	}
+
+	// The sweep removes it and its size
+	store.evictExpired()
+	if keys, _ := store.Keys(""); len(keys) != 0 {
+		t.Errorf("keys after eviction = %v, want none", keys)
+	}
+	if n := store.Stats()["approx_bytes"]; n != int64(0) {
+		t.Errorf("approx_bytes after eviction = %v, want 0", n)
+	}
+}
+
+func TestMemoryStore_EvictInBatches(t *testing.T) {
+	store := NewMemoryStore()
+	defer func() { _ = store.Close() }()
+	store.SetEvictBatch(3)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	// 10 entries expire, spanning several batches including a partial one
+	for i := range 10 {
+		_ = store.SetWithTTL(fmt.Sprintf("old:%d", i), i, time.Second)
+	}
+	_ = store.SetWithTTL("fresh", "v", time.Hour)
+	now = now.Add(time.Minute)
+
+	store.evictExpired()
+	keys, _ := store.Keys("")
+	if !slices.Equal(keys, []string{"fresh"}) {
+		t.Errorf("keys after eviction = %v, want [fresh]", keys)
+	}
+	if n, want := store.Stats()["approx_bytes"], approxSize("fresh", "v"); n != want {
+		t.Errorf("approx_bytes = %v, want %d", n, want)
+	}
+}
+
+// BenchmarkEvictExpired_100k sweeps 100,000 expired entries and reports how
+// long the write lock was held at the 99th percentile, which is how long Get
+// and Set can stall. Removing everything under one lock holds it for the
+// whole sweep; batches bound each hold to one batch.
+func BenchmarkEvictExpired_100k(b *testing.B) {
+	const n = 100_000
+	for _, bc := range []struct {
+		name  string
+		batch int
+	}{
+		{"single-batch", n},
+		{"batched", DefaultEvictBatch},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			// One shard, so a single batch really is the whole sweep
+			store := newMemoryStore(1)
+			defer func() { _ = store.Close() }()
+
+			var holds []time.Duration
+			for b.Loop() {
+				b.StopTimer()
+				for i := range n {
+					_ = store.SetWithTTL("k:"+strconv.Itoa(i), i, -time.Second)
+				}
+				b.StartTimer()
+
+				// evictExpired, timing each write-lock hold
+				now := store.now()
+				for _, sh := range store.shards {
+					for batch := range slices.Chunk(sh.expiredKeys(now), bc.batch) {
+						start := time.Now()
+						sh.removeExpired(batch, now)
+						holds = append(holds, time.Since(start))
+					}
+				}
+			}
+			if keys, _ := store.Keys(""); len(keys) != 0 {
+				b.Fatalf("%d keys left after eviction", len(keys))
+			}
+			slices.Sort(holds)
+			p99 := holds[len(holds)*99/100]
+			b.ReportMetric(float64(p99.Microseconds()), "p99-lock-µs")
+		})
+	}
+}
+
+func TestMemoryStore_Shards(t *testing.T) {
+	for _, tt := range []struct{ n, want int }{{0, 1}, {1, 1}, {3, 4}, {32, 32}, {33, 64}} {
+		store := newMemoryStore(tt.n)
+		if got := len(store.shards); got != tt.want {
+			t.Errorf("newMemoryStore(%d) has %d shards, want %d", tt.n, got, tt.want)
+		}
+		_ = store.Close()
+	}
+
+	store := NewMemoryStore()
+	defer func() { _ = store.Close() }()
+
+	// Writers on disjoint keys across every shard, readers alongside
+	const writers, perWriter = 8, 200
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Go(func() {
+			for i := range perWriter {
+				key := fmt.Sprintf("w%d:%d", w, i)
+				_ = store.Set(key, i)
+				if v, ok := store.Get(key); !ok || v != i {
+					t.Errorf("Get(%s) = %v, %v right after Set", key, v, ok)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	keys, _ := store.Keys("w3:")
+	if len(keys) != perWriter {
+		t.Errorf("Keys(w3:) returned %d keys, want %d", len(keys), perWriter)
+	}
+	var want int64
+	for w := range writers {
+		for i := range perWriter {
+			want += approxSize(fmt.Sprintf("w%d:%d", w, i), i)
+		}
+	}
+	if n := store.Stats()["approx_bytes"]; n != want {
+		t.Errorf("approx_bytes = %v, want %d summed over shards", n, want)
+	}
+
+	all, _ := store.Keys("")
+	_ = store.Delete(all...)
+	if n := store.Stats()["approx_bytes"]; n != int64(0) {
+		t.Errorf("approx_bytes after deleting everything = %v, want 0", n)
+	}
+}
+
+// BenchmarkMemoryStore_Parallel reads hot playlist keys from every
+// goroutine, with a write every 16 operations, on one lock versus the
+// default shards. Run with -cpu to see how each scales.
+func BenchmarkMemoryStore_Parallel(b *testing.B) {
+	const nkeys = 1024
+	keys := make([]string, nkeys)
+	for i := range keys {
+		keys[i] = PlaylistKey("mood" + strconv.Itoa(i))
+	}
+
+	for _, bc := range []struct {
+		name   string
+		shards int
+	}{
+		{"single-lock", 1},
+		{"sharded", DefaultShards},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			store := newMemoryStore(bc.shards)
+			defer func() { _ = store.Close() }()
+			for _, k := range keys {
+				_ = store.Set(k, k)
+			}
+
+			var next atomic.Uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := next.Add(1) * 7919 // spread goroutines over the keys
+				for pb.Next() {
+					i++
+					k := keys[i%nkeys]
+					if i%16 == 0 {
+						_ = store.Set(k, k)
+					} else {
+						store.Get(k)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestTTLFor(t *testing.T) {
+	tests := []struct {
+		key  string
+		want time.Duration
+	}{
+		{MoodsListKey("en"), MoodsListTTL},
+		{MoodsListKey("es") + ":sort=tracks:limit=2", MoodsListTTL},
+		{PlaylistKey("focus"), PlaylistTTL},
+		{NewPlaylistKey("calm").With("tags", "piano").String(), PlaylistTTL},
+		{FeaturedKey("2026-03-14"), DefaultTTL},
+		{KeyPublicStats, PublicStatsTTL},
+		{"other-key", DefaultTTL},
+	}
+
+	for _, tt := range tests {
+		if got := TTLFor(tt.key); got != tt.want {
+			t.Errorf("TTLFor(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSet_PerTypeTTL(t *testing.T) {
+	store := NewMemoryStore()
+	defer func() { _ = store.Close() }()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+	c := NewWithStore(store).WithNamespace("v1")
+
+	playlist, moods := PlaylistKey("focus"), MoodsListKey("en")
+	_ = c.Set(playlist, "tracks")
+	_ = c.Set(moods, "moods")
+
+	// Just before the playlist's max-age both are fresh
+	now = now.Add(PlaylistTTL - time.Second)
+	if _, found := c.Get(playlist); !found {
+		t.Fatal("playlist expired before PlaylistTTL")
+	}
+
+	// Past it the playlist is gone while the moods list lives on
+	now = now.Add(2 * time.Second)
+	if _, found := c.Get(playlist); found {
+		t.Error("playlist still cached past PlaylistTTL")
+	}
+	if _, found := c.Get(moods); !found {
+		t.Error("moods list expired with the playlist, want it kept until MoodsListTTL")
+	}
+
+	now = now.Add(MoodsListTTL - PlaylistTTL)
+	if _, found := c.Get(moods); found {
+		t.Error("moods list still cached past MoodsListTTL")
+	}
+
+	// An explicit TTL overrides the key type's
+	_ = c.SetWithTTL(playlist, "tracks", time.Hour)
+	now = now.Add(30 * time.Minute)
+	if _, found := c.Get(playlist); !found {
+		t.Error("entry set with a 1h TTL expired after 30m")
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultLoadTimeout caps how long a shared loader may run
+const DefaultLoadTimeout = 10 * time.Second
+
+// Loader produces the value for a missing key. store=false returns the
+// value to callers without caching it (e.g. an empty playlist).
+type Loader func(ctx context.Context) (value any, store bool, err error)
+
+// load is one in-flight Loader call shared by every caller of its key
+type load struct {
+	done  chan struct{}
+	gen   uint64 // Cache.loadGen when the load started
+	value any
+	err   error
+}
+
+// SetLoadTimeout sets the ceiling on a shared loader's context
+func (c *Cache) SetLoadTimeout(d time.Duration) {
+	c.loadTimeout = d
+}
+
+// GetOrLoad returns the cached value for key, or runs loader once for all
+// concurrent callers of a missing key. A caller whose ctx is done stops
+// waiting and gets ctx.Err(), but the loader keeps running on a context
+// detached from any one request, bounded by the load timeout, and still
+// caches its result for later requests. A load overtaken by an
+// invalidation still answers its waiters but isn't cached, and callers
+// arriving after the invalidation start a fresh one. A panicking loader
+// fails its waiters with an error. hit reports a cache hit.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, loader Loader) (value any, hit bool, err error) {
+	ctx, span := tracing.Start(ctx, "cache.GetOrLoad", attribute.String("cache.key", key))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", hit))
+		span.End()
+	}()
+
+	if v, found := c.Get(key); found {
+		return v, true, nil
+	}
+
+	c.loadMu.Lock()
+	l, inFlight := c.loads[key]
+	if !inFlight || l.gen != c.loadGen {
+		if c.loads == nil {
+			c.loads = make(map[string]*load)
+		}
+		l = &load{done: make(chan struct{}), gen: c.loadGen}
+		c.loads[key] = l
+		go c.runLoad(context.WithoutCancel(ctx), key, l, loader)
+	}
+	c.loadMu.Unlock()
+
+	select {
+	case <-l.done:
+		return l.value, false, l.err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// runLoad calls loader, caches its value unless an invalidation overtook
+// it, and releases the waiters, even if loader panics
+func (c *Cache) runLoad(ctx context.Context, key string, l *load, loader Loader) {
+	defer close(l.done)
+	defer func() {
+		c.loadMu.Lock()
+		if c.loads[key] == l {
+			delete(c.loads, key)
+		}
+		c.loadMu.Unlock()
+	}()
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Error: cache load of %s panicked: %v", key, p)
+			l.value, l.err = nil, fmt.Errorf("cache load of %s panicked: %v", key, p)
+		}
+	}()
+
+	if c.loadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.loadTimeout)
+		defer cancel()
+	}
+
+	ctx, span := tracing.Start(ctx, "cache.load", attribute.String("cache.key", key))
+	defer span.End()
+	value, store, err := loader(ctx)
+	l.value, l.err = value, err
+	if err == nil && store && c.loadCurrent(l) {
+		if err := c.Set(key, value); err != nil {
+			log.Printf("Warning: failed to cache %s: %v", key, err)
+		} else if !c.loadCurrent(l) {
+			// Invalidated while storing; the invalidation may have listed
+			// keys before this one landed
+			if err := c.store.Delete(c.key(key)); err != nil {
+				log.Printf("Warning: failed to drop invalidated %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// loadCurrent reports whether no invalidation has happened since l started
+func (c *Cache) loadCurrent(l *load) bool {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	return l.gen == c.loadGen
+}
+
+// bumpLoadGen marks every in-flight load as overtaken by an invalidation
+func (c *Cache) bumpLoadGen() {
+	c.loadMu.Lock()
+	c.loadGen++
+	c.loadMu.Unlock()
+}
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	c, err := New()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestGetOrLoad_Coalesces(t *testing.T) {
+	c := newTestCache(t)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(context.Context) (any, bool, error) {
+		calls.Add(1)
+		<-release
+		return "value", true, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]any, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, err := c.GetOrLoad(context.Background(), "key", loader)
+			if err != nil {
+				t.Errorf("GetOrLoad: %v", err)
+			}
+			results[i] = v
+		}()
+	}
+	// Let every caller join the in-flight load before it finishes
+	waitFor(t, func() bool {
+		c.loadMu.Lock()
+		defer c.loadMu.Unlock()
+		return c.loads["key"] != nil
+	})
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times, want 1", n)
+	}
+	for i, v := range results {
+		if v != "value" {
+			t.Errorf("caller %d got %v, want value", i, v)
+		}
+	}
+	if v, hit, _ := c.GetOrLoad(context.Background(), "key", loader); !hit || v != "value" {
+		t.Errorf("after load got (%v, hit=%v), want cached value", v, hit)
+	}
+}
+
+func TestGetOrLoad_CallerCanceledMidLoad(t *testing.T) {
+	c := newTestCache(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loaded := make(chan error, 1)
+	loader := func(ctx context.Context) (any, bool, error) {
+		close(started)
+		<-release
+		loaded <- ctx.Err()
+		return "value", true, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := c.GetOrLoad(ctx, "key", loader)
+		errCh <- err
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("canceled caller kept waiting on the loader")
+	}
+
+	// The loader isn't canceled with the caller and still fills the cache
+	close(release)
+	if err := <-loaded; err != nil {
+		t.Errorf("loader context err = %v, want nil after caller cancellation", err)
+	}
+	waitFor(t, func() bool {
+		_, found := c.Get("key")
+		return found
+	})
+}
+
+func TestGetOrLoad_LoaderExceedsCeiling(t *testing.T) {
+	c := newTestCache(t)
+	c.SetLoadTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, _, err := c.GetOrLoad(context.Background(), "key", func(ctx context.Context) (any, bool, error) {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("load took %v, want about the 50ms ceiling", elapsed)
+	}
+	if _, found := c.Get("key"); found {
+		t.Error("a failed load should not be cached")
+	}
+
+	// A later caller starts a fresh load
+	v, _, err := c.GetOrLoad(context.Background(), "key", func(context.Context) (any, bool, error) {
+		return "value", true, nil
+	})
+	if err != nil || v != "value" {
+		t.Errorf("retry got (%v, %v), want value", v, err)
+	}
+}
+
+func TestGetOrLoad_StoreFalse(t *testing.T) {
+	c := newTestCache(t)
+
+	v, hit, err := c.GetOrLoad(context.Background(), "key", func(context.Context) (any, bool, error) {
+		return []string{}, false, nil
+	})
+	if err != nil || hit {
+		t.Fatalf("got (hit=%v, %v), want a miss without error", hit, err)
+	}
+	if got, ok := v.([]string); !ok || len(got) != 0 {
+		t.Errorf("value = %v, want the loader's empty slice", v)
+	}
+	if _, found := c.Get("key"); found {
+		t.Error("store=false value should not be cached")
+	}
+}
+
+func TestGetOrLoad_LoaderPanics(t *testing.T) {
+	c := newTestCache(t)
+
+	_, _, err := c.GetOrLoad(context.Background(), "key", func(context.Context) (any, bool, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("a panicking loader returned no error")
+	}
+
+	// The key isn't left stuck on the failed load
+	v, _, err := c.GetOrLoad(context.Background(), "key", func(context.Context) (any, bool, error) {
+		return "value", true, nil
+	})
+	if err != nil || v != "value" {
+		t.Errorf("after a panic got (%v, %v), want value", v, err)
+	}
+}
+
+func TestGetOrLoad_InvalidatedMidLoad(t *testing.T) {
+	c := newTestCache(t)
+	key := PlaylistKey("focus")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	type result struct {
+		v   any
+		err error
+	}
+	stale := make(chan result, 1)
+	go func() {
+		v, _, err := c.GetOrLoad(context.Background(), key, func(context.Context) (any, bool, error) {
+			close(started)
+			<-release
+			return "stale", true, nil
+		})
+		stale <- result{v, err}
+	}()
+	<-started
+	c.InvalidateMood("focus")
+
+	// A caller after the invalidation doesn't join the stale load
+	v, _, err := c.GetOrLoad(context.Background(), key, func(context.Context) (any, bool, error) {
+		return "fresh", true, nil
+	})
+	if err != nil || v != "fresh" {
+		t.Errorf("after invalidation got (%v, %v), want fresh", v, err)
+	}
+
+	// The stale load still answers its waiter but doesn't overwrite the cache
+	close(release)
+	if r := <-stale; r.err != nil || r.v != "stale" {
+		t.Errorf("stale waiter got (%v, %v), want its own value", r.v, r.err)
+	}
+	if v, found := c.Get(key); !found || v != "fresh" {
+		t.Errorf("cached %v (found=%v), want fresh", v, found)
+	}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
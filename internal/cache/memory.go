@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"encoding/json"
+	"hash/maphash"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value     any
+	expiresAt time.Time
+	size      int64 // approximate bytes: key plus JSON-encoded value
+}
+
+// shard holds the entries whose keys hash to it, under its own lock
+type shard struct {
+	mu    sync.RWMutex
+	items map[string]entry
+	bytes int64 // sum of entry sizes
+}
+
+// MemoryStore is an in-process Store with TTL expiration. Values are kept
+// as-is, not serialized. Keys are spread over shards with a lock each, so
+// concurrent access to different keys rarely contends.
+type MemoryStore struct {
+	shards    []*shard
+	seed      maphash.Seed
+	now       func() time.Time // clock for expiry; time.Now outside tests
+	batch     int              // expired entries removed per write-lock hold
+	stopCh    chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryStore creates a store that periodically evicts expired entries.
+func NewMemoryStore() *MemoryStore {
+	return newMemoryStore(DefaultShards)
+}
+
+// newMemoryStore creates a store with n shards, rounded up to a power of two
+func newMemoryStore(n int) *MemoryStore {
+	n = 1 << bitsFor(n)
+	s := &MemoryStore{
+		shards:  make([]*shard, n),
+		seed:    maphash.MakeSeed(),
+		now:     time.Now,
+		batch:   DefaultEvictBatch,
+		stopCh:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for i := range s.shards {
+		s.shards[i] = &shard{items: make(map[string]entry)}
+	}
+	go s.cleanup()
+	return s
+}
+
+// bitsFor returns the smallest b with 1<<b >= n
+func bitsFor(n int) int {
+	b := 0
+	for 1<<b < n {
+		b++
+	}
+	return b
+}
+
+// shardFor returns the shard that holds key
+func (s *MemoryStore) shardFor(key string) *shard {
+	return s.shards[maphash.String(s.seed, key)&uint64(len(s.shards)-1)]
+}
+
+// SetClock replaces the clock used for expiry, so tests can advance time
+// without sleeping. Call before the store is shared.
+func (s *MemoryStore) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetEvictBatch sets how many expired entries the cleanup sweep removes
+// each time it takes a shard's write lock; n below 1 selects
+// DefaultEvictBatch. Smaller batches shorten the stalls Get and Set see
+// during a sweep. Call before the store is shared.
+func (s *MemoryStore) SetEvictBatch(n int) {
+	if n < 1 {
+		n = DefaultEvictBatch
+	}
+	s.batch = n
+}
+
+func (s *MemoryStore) cleanup() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.evictExpired()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// evictExpired removes entries expired as of the start of the sweep, one
+// shard at a time. Within a shard, expired keys are found under the read
+// lock, so Gets carry on meanwhile, then removed in batches; blocked
+// callers get the lock between batches.
+func (s *MemoryStore) evictExpired() {
+	now := s.now()
+	for _, sh := range s.shards {
+		for batch := range slices.Chunk(sh.expiredKeys(now), s.batch) {
+			sh.removeExpired(batch, now)
+		}
+	}
+}
+
+// expiredKeys lists the keys of entries expired at now
+func (sh *shard) expiredKeys(now time.Time) []string {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	var expired []string
+	for k, e := range sh.items {
+		if now.After(e.expiresAt) {
+			expired = append(expired, k)
+		}
+	}
+	return expired
+}
+
+// removeExpired deletes those of keys still expired at now under one write
+// lock. An entry Set again since it was listed is kept.
+func (sh *shard) removeExpired(keys []string, now time.Time) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for _, k := range keys {
+		if e, ok := sh.items[k]; ok && now.After(e.expiresAt) {
+			sh.removeLocked(k)
+		}
+	}
+}
+
+// Get returns the value for key unless it is missing or expired.
+func (s *MemoryStore) Get(key string) (any, bool) {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	e, ok := sh.items[key]
+	sh.mu.RUnlock()
+	if !ok || s.now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores a value with the default TTL.
+func (s *MemoryStore) Set(key string, value any) error {
+	return s.SetWithTTL(key, value, DefaultTTL)
+}
+
+// SetWithTTL stores a value that expires after ttl. The entry's size is
+// estimated once here, from its JSON encoding, so Stats stays cheap.
+func (s *MemoryStore) SetWithTTL(key string, value any, ttl time.Duration) error {
+	e := entry{value: value, expiresAt: s.now().Add(ttl), size: approxSize(key, value)}
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	sh.removeLocked(key)
+	sh.items[key] = e
+	sh.bytes += e.size
+	sh.mu.Unlock()
+	return nil
+}
+
+// Delete removes the given keys.
+func (s *MemoryStore) Delete(keys ...string) error {
+	for _, k := range keys {
+		sh := s.shardFor(k)
+		sh.mu.Lock()
+		sh.removeLocked(k)
+		sh.mu.Unlock()
+	}
+	return nil
+}
+
+// removeLocked deletes key and its size from the shard total. Caller must
+// hold sh.mu.
+func (sh *shard) removeLocked(key string) {
+	if e, ok := sh.items[key]; ok {
+		sh.bytes -= e.size
+		delete(sh.items, key)
+	}
+}
+
+// approxSize estimates an entry's footprint as the key plus the value's JSON
+// encoding. Values that don't encode count the key only.
+func approxSize(key string, value any) int64 {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return int64(len(key))
+	}
+	return int64(len(key) + len(b))
+}
+
+// Keys lists stored keys that start with prefix, including expired entries
+// not yet evicted.
+func (s *MemoryStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for k := range sh.items {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return keys, nil
+}
+
+// Stats reports the backend type and the approximate bytes held across all
+// namespaces and shards.
+func (s *MemoryStore) Stats() map[string]any {
+	var bytes int64
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		bytes += sh.bytes
+		sh.mu.RUnlock()
+	}
+	return map[string]any{"backend": "memory", "approx_bytes": bytes}
+}
+
+// Close stops the cleanup goroutine. Safe to call more than once.
+func (s *MemoryStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+		<-s.stopped
+	})
+	return nil
+}
+
+var _ Store = (*MemoryStore)(nil)
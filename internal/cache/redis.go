@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisTimeout bounds each Redis call so a slow or unreachable server
+// costs a request at most this much before it falls through as a miss.
+const DefaultRedisTimeout = 200 * time.Millisecond
+
+// redisKeyIndex is a sorted set of every key the store has written, all
+// scored 0 so they sort lexically and a prefix is one range read. Keys
+// listing goes through it instead of SCANning a possibly shared keyspace.
+const redisKeyIndex = "driftfm:cache:keys"
+
+// RedisOptions configures a RedisStore
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	Timeout  time.Duration // per call; 0 = DefaultRedisTimeout
+}
+
+// RedisStore is a Store shared across instances. Values are JSON-encoded on
+// Set and returned as json.RawMessage. Written keys are indexed in
+// redisKeyIndex; entries whose key has expired are pruned as Keys finds
+// them. When Redis is unreachable the store
+// degrades to pass-through: every Get misses and writes fail without
+// affecting the request.
+type RedisStore struct {
+	client  *redis.Client
+	timeout time.Duration
+
+	errors      atomic.Int64
+	unavailable atomic.Bool // last call failed; logged on transition only
+}
+
+// NewRedisStore creates a store for the given server. It does not connect
+// until first use, so an unavailable server never blocks startup.
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRedisTimeout
+	}
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:         opts.Addr,
+			Password:     opts.Password,
+			DB:           opts.DB,
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		}),
+		timeout: timeout,
+	}
+}
+
+// Ping checks connectivity, for a startup warning.
+func (s *RedisStore) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return s.observe(s.client.Ping(ctx).Err())
+}
+
+// observe records the outcome of a Redis call and logs availability changes.
+func (s *RedisStore) observe(err error) error {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		s.errors.Add(1)
+		if !s.unavailable.Swap(true) {
+			log.Printf("Warning: redis cache unavailable, serving uncached: %v", err)
+		}
+		return err
+	}
+	if s.unavailable.Swap(false) {
+		log.Printf("Redis cache available again")
+	}
+	return err
+}
+
+// Get returns the raw JSON for key. Misses and failures both return false.
+func (s *RedisStore) Get(key string) (any, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	b, err := s.client.Get(ctx, key).Bytes()
+	if s.observe(err) != nil {
+		return nil, false
+	}
+	return json.RawMessage(b), true
+}
+
+// Set JSON-encodes value and stores it with the default TTL.
+func (s *RedisStore) Set(key string, value any) error {
+	return s.SetWithTTL(key, value, DefaultTTL)
+}
+
+// SetWithTTL JSON-encodes value and stores it, expiring after ttl, and
+// adds key to the key index.
+func (s *RedisStore) SetWithTTL(key string, value any, ttl time.Duration) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, key, b, ttl)
+		p.ZAdd(ctx, redisKeyIndex, redis.Z{Member: key})
+		return nil
+	})
+	if err := s.observe(err); err != nil {
+		return fmt.Errorf("failed to set cache key: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the given keys and their index entries.
+func (s *RedisStore) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	members := make([]any, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, keys...)
+		p.ZRem(ctx, redisKeyIndex, members...)
+		return nil
+	})
+	if err := s.observe(err); err != nil {
+		return fmt.Errorf("failed to delete cache keys: %w", err)
+	}
+	return nil
+}
+
+// Keys lists keys starting with prefix from the key index, so the cost
+// follows the matching keys rather than the server's whole keyspace.
+// Index entries whose key has expired are dropped on the way.
+func (s *RedisStore) Keys(prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	indexed, err := s.client.ZRangeByLex(ctx, redisKeyIndex, &redis.ZRangeBy{
+		Min: "[" + prefix,
+		Max: "[" + prefix + "\xff",
+	}).Result()
+	if err := s.observe(err); err != nil {
+		return nil, fmt.Errorf("failed to list cache keys: %w", err)
+	}
+	if len(indexed) == 0 {
+		return nil, nil
+	}
+
+	exists := make([]*redis.IntCmd, len(indexed))
+	_, err = s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range indexed {
+			exists[i] = p.Exists(ctx, key)
+		}
+		return nil
+	})
+	if err := s.observe(err); err != nil {
+		return nil, fmt.Errorf("failed to check cache keys: %w", err)
+	}
+
+	keys := make([]string, 0, len(indexed))
+	var expired []any
+	for i, key := range indexed {
+		if exists[i].Val() > 0 {
+			keys = append(keys, key)
+		} else {
+			expired = append(expired, key)
+		}
+	}
+	if len(expired) > 0 {
+		if err := s.observe(s.client.ZRem(ctx, redisKeyIndex, expired...).Err()); err != nil {
+			log.Printf("Warning: failed to prune expired cache keys from the index: %v", err)
+		}
+	}
+	return keys, nil
+}
+
+// Stats reports the backend type, availability, and failed calls.
+func (s *RedisStore) Stats() map[string]any {
+	return map[string]any{
+		"backend":   "redis",
+		"available": !s.unavailable.Load(),
+		"errors":    s.errors.Load(),
+	}
+}
+
+// Close closes the connection pool.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+var _ Store = (*RedisStore)(nil)
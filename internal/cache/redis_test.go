@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *RedisStore) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	s := NewRedisStore(RedisOptions{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = s.Close() })
+	return mr, s
+}
+
+func TestRedisStore_JSONRoundTrip(t *testing.T) {
+	mr, s := newTestRedis(t)
+	c := NewWithStore(s).WithNamespace("v1")
+
+	type track struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := c.Set(PlaylistKey("focus"), []track{{1, "Rain"}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	raw, err := mr.Get("v1:playlist:focus:default")
+	if err != nil {
+		t.Fatalf("key not stored under namespace: %v", err)
+	}
+	if raw != `[{"id":1,"title":"Rain"}]` {
+		t.Errorf("stored value = %s, want JSON", raw)
+	}
+	if ttl := mr.TTL("v1:playlist:focus:default"); ttl != PlaylistTTL {
+		t.Errorf("TTL = %s, want %s", ttl, PlaylistTTL)
+	}
+
+	val, found := c.Get(PlaylistKey("focus"))
+	if !found {
+		t.Fatal("expected to find cached value")
+	}
+	var got []track
+	if err := json.Unmarshal(val.(json.RawMessage), &got); err != nil {
+		t.Fatalf("cached value is not JSON: %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "Rain" {
+		t.Errorf("decoded = %+v, want the stored track", got)
+	}
+
+	if _, found := c.Get(PlaylistKey("calm")); found {
+		t.Error("expected miss for unset key")
+	}
+}
+
+func TestRedisStore_InvalidateMoodsWithinNamespace(t *testing.T) {
+	mr, s := newTestRedis(t)
+	base := NewWithStore(s)
+	v1 := base.WithNamespace("v1")
+	v2 := base.WithNamespace("v2")
+
+	_ = v1.Set(KeyMoodsList, []string{"focus"})
+	_ = v1.Set(PlaylistKey("focus"), "a")
+	_ = v1.Set(NewPlaylistKey("focus").With("instrumental", "").String(), "b")
+	_ = v1.Set("other-key", "c")
+	_ = v2.Set(PlaylistKey("focus"), "d")
+
+	v1.InvalidateMoods()
+
+	for _, key := range []string{"v1:moods:list", "v1:playlist:focus:default", "v1:playlist:focus:instrumental"} {
+		if mr.Exists(key) {
+			t.Errorf("%s should be invalidated", key)
+		}
+	}
+	for _, key := range []string{"v1:other-key", "v2:playlist:focus:default"} {
+		if !mr.Exists(key) {
+			t.Errorf("%s should NOT be invalidated", key)
+		}
+	}
+	if n := v1.Stats()["key_count"]; n != 1 {
+		t.Errorf("v1 key_count = %v, want 1", n)
+	}
+}
+
+func TestRedisStore_UnavailableDegradesToMiss(t *testing.T) {
+	mr, s := newTestRedis(t)
+	c := NewWithStore(s)
+
+	_ = c.Set(KeyMoodsList, []string{"focus"})
+	mr.Close()
+
+	if _, found := c.Get(KeyMoodsList); found {
+		t.Error("expected miss while redis is down")
+	}
+	if err := c.Set(KeyMoodsList, []string{"calm"}); err == nil {
+		t.Error("expected Set to fail while redis is down")
+	}
+	c.InvalidateMoods() // must not panic
+
+	stats := c.Stats()
+	if stats["available"] != false {
+		t.Errorf("available = %v, want false", stats["available"])
+	}
+	if n, _ := stats["errors"].(int64); n == 0 {
+		t.Error("expected failed calls to be counted")
+	}
+	if stats["misses"].(int64) != 1 {
+		t.Errorf("misses = %v, want 1", stats["misses"])
+	}
+
+	// Recovers once the server is back
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("restart miniredis: %v", err)
+	}
+	if err := c.Set(KeyMoodsList, []string{"calm"}); err != nil {
+		t.Fatalf("Set after restart: %v", err)
+	}
+	if _, found := c.Get(KeyMoodsList); !found {
+		t.Error("expected hit after redis came back")
+	}
+	if c.Stats()["available"] != true {
+		t.Error("available should be true after recovery")
+	}
+}
+
+func TestRedisStore_KeysFromIndex(t *testing.T) {
+	mr, s := newTestRedis(t)
+	c := NewWithStore(s).WithNamespace("v1")
+
+	// Keys the store didn't write aren't listed or invalidated
+	if err := mr.Set("v1:playlist:focus:foreign", "x"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(PlaylistKey("focus"), "a")
+	_ = c.SetWithTTL(PlaylistKey("calm"), "b", time.Second)
+
+	keys, err := s.Keys("v1:playlist:")
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if !slices.Equal(keys, []string{"v1:playlist:calm:default", "v1:playlist:focus:default"}) {
+		t.Errorf("Keys = %v, want the two indexed playlists", keys)
+	}
+
+	// Expired keys drop out of the index when listed
+	mr.FastForward(2 * time.Second)
+	if n := c.Stats()["key_count"]; n != 1 {
+		t.Errorf("key_count after expiry = %v, want 1", n)
+	}
+	if members, _ := mr.ZMembers(redisKeyIndex); len(members) != 1 {
+		t.Errorf("index holds %v, want only the live key", members)
+	}
+
+	c.InvalidateMoods()
+	if !mr.Exists("v1:playlist:focus:foreign") {
+		t.Error("a key outside the index was deleted")
+	}
+	if members, _ := mr.ZMembers(redisKeyIndex); len(members) != 0 {
+		t.Errorf("index holds %v after invalidation, want none", members)
+	}
+}
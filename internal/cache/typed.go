@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Backing is the untyped cache a Typed view stores into; *Cache is one
+type Backing interface {
+	Get(key string) (any, bool)
+	Set(key string, value any) error
+	SetWithTTL(key string, value any, ttl time.Duration) error
+	GetOrLoad(ctx context.Context, key string, loader Loader) (any, bool, error)
+	// EvictMismatch drops a key whose value isn't of the type its reader
+	// expected, counting the read as a miss
+	EvictMismatch(key string, got any)
+}
+
+// Typed is a view of a cache whose values are all of type T. Values a
+// remote store hands back as JSON are decoded into T. A value that is
+// neither a T nor decodes into one (say, left by a release that stored a
+// different shape) is evicted and read as a miss, so it is rebuilt rather
+// than served.
+type Typed[T any] struct {
+	c Backing
+}
+
+// NewTyped returns a typed view of c. Views of different types may share
+// a cache, but must not share keys.
+func NewTyped[T any](c Backing) *Typed[T] {
+	return &Typed[T]{c: c}
+}
+
+// Get returns the value for key
+func (t *Typed[T]) Get(key string) (T, bool) {
+	v, ok := t.c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return t.convert(key, v)
+}
+
+// Set stores value under key with the TTL for its key type (see TTLFor)
+func (t *Typed[T]) Set(key string, value T) error {
+	return t.c.Set(key, value)
+}
+
+// SetWithTTL stores value under key, expiring after ttl
+func (t *Typed[T]) SetWithTTL(key string, value T, ttl time.Duration) error {
+	return t.c.SetWithTTL(key, value, ttl)
+}
+
+// GetOrLoad is Cache.GetOrLoad for T values. A cached value of the wrong
+// type is evicted and loaded afresh.
+func (t *Typed[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, bool, error)) (T, bool, error) {
+	load := func(ctx context.Context) (any, bool, error) {
+		return loader(ctx)
+	}
+	for attempt := 0; ; attempt++ {
+		v, hit, err := t.c.GetOrLoad(ctx, key, load)
+		if err != nil {
+			var zero T
+			return zero, false, err
+		}
+		value, ok := t.convert(key, v)
+		// A loaded value is always a T, so only a cached one can miss
+		if ok || !hit {
+			return value, hit, nil
+		}
+		if attempt > 0 {
+			// The eviction didn't take; build the value without the cache
+			value, _, err := loader(ctx)
+			return value, false, err
+		}
+	}
+}
+
+// convert returns v as a T, evicting key when it isn't one
+func (t *Typed[T]) convert(key string, v any) (T, bool) {
+	switch v := v.(type) {
+	case T:
+		return v, true
+	case json.RawMessage:
+		var decoded T
+		if err := json.Unmarshal(v, &decoded); err == nil {
+			return decoded, true
+		}
+	}
+	t.c.EvictMismatch(key, v)
+	var zero T
+	return zero, false
+}
+
+// EvictMismatch deletes key, whose value a typed view couldn't use, and
+// moves the read from the hit count to the misses
+func (c *Cache) EvictMismatch(key string, got any) {
+	log.Printf("Warning: cache entry %s holds an unexpected %T; evicting", key, got)
+	c.hits.Add(-1)
+	c.misses.Add(1)
+	c.mismatches.Add(1)
+	if err := c.store.Delete(c.key(key)); err != nil {
+		log.Printf("Warning: failed to evict %s: %v", key, err)
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type testTrack struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestTyped_RoundTrip(t *testing.T) {
+	c, _ := New()
+	defer func() { _ = c.Close() }()
+	tracks := NewTyped[[]testTrack](c)
+
+	want := []testTrack{{ID: 1, Title: "Alpha"}, {ID: 2, Title: "Beta"}}
+	if err := tracks.Set("playlist:focus:default", want); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	got, ok := tracks.Get("playlist:focus:default")
+	if !ok || len(got) != 2 || got[1] != want[1] {
+		t.Errorf("Get = %v, %v; want %v", got, ok, want)
+	}
+	if _, ok := tracks.Get("playlist:calm:default"); ok {
+		t.Error("Get of a missing key reported a hit")
+	}
+
+	// A remote store's JSON decodes into the view's type
+	raw, _ := json.Marshal(want)
+	_ = c.Set("playlist:sleep:default", json.RawMessage(raw))
+	got, ok = tracks.Get("playlist:sleep:default")
+	if !ok || len(got) != 2 || got[0] != want[0] {
+		t.Errorf("Get of JSON = %v, %v; want %v", got, ok, want)
+	}
+	if n := c.Stats()["type_mismatches"]; n != int64(0) {
+		t.Errorf("type_mismatches = %v, want 0", n)
+	}
+}
+
+func TestTyped_MismatchEvicts(t *testing.T) {
+	c, _ := New()
+	defer func() { _ = c.Close() }()
+	tracks := NewTyped[[]testTrack](c)
+
+	entries := map[string]any{
+		"playlist:focus:default": map[string]string{"not": "a playlist"},
+		"playlist:calm:default":  json.RawMessage(`{"not":"a playlist"}`),
+	}
+	for key, v := range entries {
+		_ = c.Set(key, v)
+		if _, ok := tracks.Get(key); ok {
+			t.Errorf("Get(%s) of a %T reported a hit", key, v)
+		}
+		if _, ok := c.Get(key); ok {
+			t.Errorf("%s was not evicted", key)
+		}
+	}
+
+	stats := c.Stats()
+	if stats["type_mismatches"] != int64(2) {
+		t.Errorf("type_mismatches = %v, want 2", stats["type_mismatches"])
+	}
+	if stats["hits"] != int64(0) {
+		t.Errorf("hits = %v, want mismatches counted as misses", stats["hits"])
+	}
+}
+
+func TestTyped_GetOrLoadReloadsMismatch(t *testing.T) {
+	c, _ := New()
+	defer func() { _ = c.Close() }()
+	tracks := NewTyped[[]testTrack](c)
+	_ = c.Set("playlist:focus:default", "stale shape")
+
+	loads := 0
+	loader := func(context.Context) ([]testTrack, bool, error) {
+		loads++
+		return []testTrack{{ID: 7}}, true, nil
+	}
+	got, hit, err := tracks.GetOrLoad(context.Background(), "playlist:focus:default", loader)
+	if err != nil || hit || len(got) != 1 || got[0].ID != 7 {
+		t.Fatalf("GetOrLoad = %v, %v, %v; want a fresh load of track 7", got, hit, err)
+	}
+
+	got, hit, _ = tracks.GetOrLoad(context.Background(), "playlist:focus:default", loader)
+	if !hit || loads != 1 || got[0].ID != 7 {
+		t.Errorf("second GetOrLoad = %v, hit %v after %d loads; want the reloaded entry", got, hit, loads)
+	}
+}
@@ -0,0 +1,297 @@
+// Package compress negotiates a response Content-Encoding from the client's
+// Accept-Encoding and compresses text responses large enough to benefit.
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Supported encodings, as named in Accept-Encoding
+const (
+	Brotli  = "br"
+	Gzip    = "gzip"
+	Deflate = "deflate"
+)
+
+// DefaultEncodings are enabled by default, in server preference order; the
+// order breaks ties between encodings the client weights equally.
+var DefaultEncodings = []string{Brotli, Gzip, Deflate}
+
+// DefaultMinSize is the smallest body worth compressing; below it the
+// encoding overhead outweighs the savings.
+const DefaultMinSize = 1024
+
+// encoder is the common surface of the gzip, flate, and brotli writers
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// newEncoder constructs each supported encoder at a level that favors
+// latency: responses are compressed per request, not ahead of time.
+var newEncoder = map[string]func() encoder{
+	Brotli: func() encoder { return brotli.NewWriterLevel(io.Discard, 5) },
+	Gzip: func() encoder {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+	Deflate: func() encoder {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	},
+}
+
+// Compressor is response compression middleware for a fixed set of encodings
+type Compressor struct {
+	encodings []string
+	minSize   int
+	pools     map[string]*sync.Pool
+}
+
+// New creates a Compressor offering encodings in preference order. Bodies
+// shorter than minSize are sent as-is. No encodings disables compression.
+func New(encodings []string, minSize int) (*Compressor, error) {
+	c := &Compressor{minSize: minSize, pools: make(map[string]*sync.Pool)}
+	for _, enc := range encodings {
+		mk, ok := newEncoder[enc]
+		if !ok {
+			return nil, fmt.Errorf("unsupported encoding %q", enc)
+		}
+		if _, dup := c.pools[enc]; dup {
+			continue
+		}
+		c.encodings = append(c.encodings, enc)
+		c.pools[enc] = &sync.Pool{New: func() any { return mk() }}
+	}
+	return c, nil
+}
+
+// Negotiate picks the enabled encoding with the highest quality in an
+// Accept-Encoding header, or "" for identity.
+func (c *Compressor) Negotiate(acceptEncoding string) string {
+	return Negotiate(acceptEncoding, c.encodings)
+}
+
+// Negotiate picks the offered encoding with the highest quality in an
+// Accept-Encoding header, or "" for identity. Ties go to the earlier offer;
+// "*" weights encodings the header doesn't name; q=0 rules an encoding out.
+func Negotiate(acceptEncoding string, offered []string) string {
+	weights := parseAcceptEncoding(acceptEncoding)
+	wildcard, hasWildcard := weights["*"]
+
+	best, bestQ := "", 0.0
+	for _, enc := range offered {
+		q, ok := weights[enc]
+		if !ok {
+			if !hasWildcard {
+				continue
+			}
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// parseAcceptEncoding maps each coding in the header to its quality value.
+// A malformed q counts as 0 (not acceptable).
+func parseAcceptEncoding(header string) map[string]float64 {
+	weights := make(map[string]float64)
+	for part := range strings.SplitSeq(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+		weights[coding] = q
+	}
+	return weights
+}
+
+// Middleware compresses eligible responses with the negotiated encoding.
+// Audio, range and upgrade requests, HEAD, and non-text bodies pass through.
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	if len(c.encodings) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" || r.Header.Get("Upgrade") != "" ||
+			strings.HasPrefix(r.URL.Path, "/audio/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		AddVary(w.Header())
+		encoding := c.Negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{ResponseWriter: w, c: c, encoding: encoding, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// AddVary marks a response as varying by Accept-Encoding, once
+func AddVary(h http.Header) {
+	for _, v := range h.Values("Vary") {
+		for field := range strings.SplitSeq(v, ",") {
+			if f := strings.TrimSpace(field); f == "*" || strings.EqualFold(f, "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	h.Add("Vary", "Accept-Encoding")
+}
+
+// responseWriter buffers the start of a body until it knows whether the
+// response is worth compressing, then streams through the encoder or as-is.
+type responseWriter struct {
+	http.ResponseWriter
+	c        *Compressor
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder // nil = passing through uncompressed
+}
+
+func (cw *responseWriter) WriteHeader(code int) {
+	if cw.decided {
+		return
+	}
+	if code < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(code) // informational, the final header follows
+		return
+	}
+	cw.status = code
+	// Bodiless responses can't be compressed; send the header now
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		_ = cw.decide()
+	}
+}
+
+func (cw *responseWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.c.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush commits to a decision so streamed responses (NDJSON exports) reach
+// the client as they are written.
+func (cw *responseWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide()
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *responseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the header, compressing when the buffered body reached
+// minSize and the response is a compressible type, then writes the buffer.
+func (cw *responseWriter) decide() error {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// What net/http would sniff, decided before the bytes are encoded
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if len(cw.buf) >= cw.c.minSize && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag) // the encoded bytes differ from the original
+		}
+		cw.enc = cw.c.pools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// close finishes the response: a body that never reached minSize is sent
+// as-is, and an encoder is flushed and returned to its pool.
+func (cw *responseWriter) close() {
+	if !cw.decided {
+		_ = cw.decide()
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Close()
+		cw.enc.Reset(io.Discard)
+		cw.c.pools[cw.encoding].Put(cw.enc)
+		cw.enc = nil
+	}
+}
+
+// compressible reports whether a Content-Type is text worth compressing.
+// Audio, images, and other already-compressed media are left alone.
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+	}
+	return false
+}
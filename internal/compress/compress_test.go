@@ -0,0 +1,274 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiate(t *testing.T) {
+	c, err := New(DefaultEncodings, DefaultMinSize)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", Gzip},
+		{"gzip, deflate, br", Brotli},          // equal weights: server order
+		{"br;q=0.5, gzip;q=0.8", Gzip},         // client weights win
+		{"deflate, gzip;q=0.9", Deflate},       // unqualified means q=1
+		{"br;q=0, gzip;q=0", ""},               // q=0 rules an encoding out
+		{"*", Brotli},                          // wildcard covers everything
+		{"*;q=0.1, gzip;q=0.5", Gzip},          // named beats wildcard
+		{"*, br;q=0", Gzip},                    // wildcard, minus an exclusion
+		{"GZIP;Q=0.7, zstd", Gzip},             // case-insensitive, unknowns ignored
+		{"gzip;q=abc, deflate;q=0.2", Deflate}, // malformed q is not acceptable
+	}
+	for _, tt := range tests {
+		if got := c.Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate_DisabledEncoding(t *testing.T) {
+	c, err := New([]string{Gzip, Deflate}, DefaultMinSize)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := c.Negotiate("br, gzip;q=0.5"); got != Gzip {
+		t.Errorf("Negotiate with brotli disabled = %q, want %q", got, Gzip)
+	}
+	if got := c.Negotiate("br"); got != "" {
+		t.Errorf("Negotiate(br) with brotli disabled = %q, want identity", got)
+	}
+}
+
+func TestNew_UnknownEncoding(t *testing.T) {
+	if _, err := New([]string{Gzip, "zstd"}, DefaultMinSize); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+// decode reverses a Content-Encoding for assertions
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case Brotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = zr
+	case Deflate:
+		r = flate.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", encoding, err)
+	}
+	return string(out)
+}
+
+func TestMiddleware_CompressesByPreference(t *testing.T) {
+	payload := `{"tracks":[` + strings.Repeat(`{"title":"Ocean Waves","mood":"calm"},`, 100) + `{}]}`
+	c, err := New(DefaultEncodings, DefaultMinSize)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "999999")
+		w.Header().Set("ETag", `"abc"`)
+		_, _ = io.WriteString(w, payload)
+	}))
+
+	for header, want := range map[string]string{
+		"gzip, deflate, br":   Brotli,
+		"br;q=0.1, gzip":      Gzip,
+		"deflate":             Deflate,
+		"br;q=0, gzip;q=0":    "",
+		"":                    "",
+		"gzip;q=0.2, deflate": Deflate,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/moods/calm/playlist", nil)
+		req.Header.Set("Accept-Encoding", header)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", header, got, want)
+			continue
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Vary = %q, want Accept-Encoding", header, got)
+		}
+		if want == "" {
+			if got := w.Header().Get("ETag"); got != `"abc"` {
+				t.Errorf("Accept-Encoding %q: uncompressed ETag = %q, want unchanged", header, got)
+			}
+		} else {
+			if got := w.Header().Get("Content-Length"); got != "" {
+				t.Errorf("Accept-Encoding %q: Content-Length = %q on a compressed body", header, got)
+			}
+			if got := w.Header().Get("ETag"); got != `W/"abc"` {
+				t.Errorf("Accept-Encoding %q: ETag = %q, want weakened", header, got)
+			}
+			if w.Body.Len() >= len(payload) {
+				t.Errorf("Accept-Encoding %q: body %d bytes, not smaller than %d", header, w.Body.Len(), len(payload))
+			}
+		}
+		if got := decode(t, want, w.Body.Bytes()); got != payload {
+			t.Errorf("Accept-Encoding %q: decoded body does not match the original", header)
+		}
+	}
+}
+
+func TestMiddleware_PassesThrough(t *testing.T) {
+	large := strings.Repeat("a", 4096)
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		rangeHeader string
+	}{
+		{name: "below min size", method: http.MethodGet, path: "/api/tags", contentType: "application/json", body: `{"tags":[]}`},
+		{name: "audio content type", method: http.MethodGet, path: "/api/stream", contentType: "audio/mpeg", body: large},
+		{name: "audio path", method: http.MethodGet, path: "/audio/tracks/a/song.mp3", contentType: "text/plain", body: large},
+		{name: "range request", method: http.MethodGet, path: "/app.js", contentType: "text/javascript", body: large, rangeHeader: "bytes=0-99"},
+		{name: "head request", method: http.MethodHead, path: "/app.js", contentType: "text/javascript", body: large},
+	}
+
+	c, err := New(DefaultEncodings, DefaultMinSize)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept-Encoding", "br, gzip")
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("body altered: got %d bytes, want %d", w.Body.Len(), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestMiddleware_StatusAndStreaming(t *testing.T) {
+	c, err := New(DefaultEncodings, DefaultMinSize)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// Status codes survive buffering, and 204 carries no encoding
+	handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/me/resume?mood=focus", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("204 Content-Encoding = %q, want none", got)
+	}
+
+	// A flush commits to compression before min size so streams aren't held back
+	line := `{"id":1,"title":"Ocean Waves"}` + "\n"
+	handler = c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, line)
+		http.NewResponseController(w).Flush()
+		_, _ = io.WriteString(w, strings.Repeat(line, 100))
+	}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+	if !w.Flushed {
+		t.Error("flush did not reach the underlying writer")
+	}
+	if got := decode(t, w.Header().Get("Content-Encoding"), w.Body.Bytes()); got != strings.Repeat(line, 101) {
+		t.Errorf("streamed body does not round-trip")
+	}
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	c, err := New(nil, DefaultMinSize)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, strings.Repeat("a", 4096))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q with compression disabled", got)
+	}
+	if got := w.Header().Get("Vary"); got != "" {
+		t.Errorf("Vary = %q with compression disabled", got)
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	tests := []struct {
+		existing []string
+		want     []string
+	}{
+		{nil, []string{"Accept-Encoding"}},
+		{[]string{"Origin"}, []string{"Origin", "Accept-Encoding"}},
+		{[]string{"Origin, accept-encoding"}, []string{"Origin, accept-encoding"}},
+		{[]string{"*"}, []string{"*"}},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		for _, v := range tt.existing {
+			h.Add("Vary", v)
+		}
+		AddVary(h)
+		if got := h.Values("Vary"); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("AddVary(%q) = %q, want %q", tt.existing, got, tt.want)
+		}
+	}
+}
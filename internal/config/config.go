@@ -1,19 +1,49 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/netip"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/1mb-dev/driftfm/internal/mood"
 )
 
 // Config holds application configuration
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Audio    AudioConfig    `yaml:"audio"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Audio     AudioConfig     `yaml:"audio"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	Listen    ListenConfig    `yaml:"listen"`
+	Workers   WorkersConfig   `yaml:"workers"`
+	Analytics AnalyticsConfig `yaml:"analytics"`
+	Radio     RadioConfig     `yaml:"radio"`
+	Rooms     RoomsConfig     `yaml:"rooms"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Moods     MoodsConfig     `yaml:"moods"`
+	Admin     AdminConfig     `yaml:"admin"`
+	OTel      OTelConfig      `yaml:"otel"`
+	// Stations maps a station ID to the moods and audio it serves under
+	// /api/stations/{id}/. None = one unscoped station with every mood.
+	Stations map[string]StationConfig `yaml:"stations"`
+	// DefaultStation is the station the unscoped /api/ routes serve;
+	// required once any station is configured
+	DefaultStation string `yaml:"default_station"`
 }
 
 // ServerConfig holds HTTP server settings
@@ -22,16 +52,332 @@ type ServerConfig struct {
 	ReadTimeout     string `yaml:"read_timeout"`
 	WriteTimeout    string `yaml:"write_timeout"`
 	ShutdownTimeout string `yaml:"shutdown_timeout"`
+	// DrainTimeout is how long shutdown waits for in-flight audio transfers
+	// after new /api/ and /audio/ requests start getting 503 ("0s" skips draining)
+	DrainTimeout string `yaml:"drain_timeout"`
+	// SPAFallback serves web/index.html for extensionless paths that don't
+	// exist on disk, instead of 404, so client-side routes load the app
+	SPAFallback bool `yaml:"spa_fallback"`
+	// MaxBody caps API request bodies per route class; larger ones get 413
+	MaxBody BodyLimitsConfig `yaml:"max_body"`
+	// Compression negotiates Content-Encoding for text responses
+	Compression CompressionConfig `yaml:"compression"`
+}
+
+// CompressionConfig holds response compression settings
+type CompressionConfig struct {
+	// Encodings are offered in preference order: br, gzip, deflate.
+	// ["none"] disables compression.
+	Encodings []string `yaml:"encodings"`
+	// MinSize is the smallest response body in bytes that gets compressed
+	MinSize int `yaml:"min_size"`
+}
+
+// compressionEncodings are the server.compression.encodings values supported
+var compressionEncodings = []string{"br", "gzip", "deflate"}
+
+// BodyLimitsConfig holds request body limits in bytes
+type BodyLimitsConfig struct {
+	Events int64 `yaml:"events"` // public routes such as listen events
+	Admin  int64 `yaml:"admin"`  // /api/admin/ JSON
+	Upload int64 `yaml:"upload"` // audio uploads
 }
 
 // DatabaseConfig holds database settings
 type DatabaseConfig struct {
 	Path string `yaml:"path"`
+	// WriteDeadline bounds each play-recording transaction, including the
+	// wait for SQLite's single writer; must be shorter than a non-zero
+	// server.write_timeout. Below 5s it also lowers SQLite's lock wait for
+	// every write on the connection, not just plays.
+	WriteDeadline string `yaml:"write_deadline"`
+	// AutoMigrate applies the baseline schema at SchemaPath to a new, empty
+	// database on startup; without it an uninitialized database is an error
+	AutoMigrate bool   `yaml:"auto_migrate"`
+	SchemaPath  string `yaml:"schema_path"`
+	// SkipSchemaCheck starts without checking that the tables and columns
+	// the server queries exist, e.g. against a newer schema mid-rollout
+	SkipSchemaCheck bool `yaml:"skip_schema_check"`
+	// IntegrityCheck runs before the server accepts traffic: quick
+	// (PRAGMA quick_check), full (PRAGMA integrity_check), or off
+	IntegrityCheck string `yaml:"integrity_check"`
 }
 
 // AudioConfig holds audio storage settings
 type AudioConfig struct {
 	LocalPath string `yaml:"local_path"`
+	// Providers is an ordered list of storage backends; the first that has a
+	// file serves it. Empty = local_path only, without existence checks.
+	Providers []AudioProvider `yaml:"providers"`
+	// ExistsCacheTTL is how long provider existence checks and the file
+	// versions added to local audio URLs are cached
+	ExistsCacheTTL string `yaml:"exists_cache_ttl"`
+	// SigningKey enables HMAC-signed, expiring /audio/ URLs; requests without
+	// a valid token get 403. Empty = unsigned. At least 32 bytes.
+	SigningKey string `yaml:"signing_key"`
+	// SignedURLTTL is how long a signed URL stays valid. It must outlive the
+	// cached playlist it appears in plus the time to play through it.
+	SignedURLTTL string `yaml:"signed_url_ttl"`
+	// PrefetchHint adds a Link: <url>; rel=prefetch header for the second
+	// track's audio to playlist responses
+	PrefetchHint bool `yaml:"prefetch_hint"`
+	// ThrottleBPS paces /audio/ responses to this many bytes per second,
+	// Range responses included. 0 = unthrottled.
+	ThrottleBPS int `yaml:"throttle_bps"`
+}
+
+// AudioProvider describes one audio storage backend
+type AudioProvider struct {
+	Type string `yaml:"type"` // "local" (audio.local_path) or "remote"
+	URL  string `yaml:"url"`  // remote base URL
+	// Check is the remote existence check: "none" (assume present) or "head"
+	Check string `yaml:"check"`
+}
+
+// MetricsConfig holds metrics endpoint settings
+type MetricsConfig struct {
+	// AllowedCIDRs lists client networks permitted to read /metrics
+	AllowedCIDRs []string `yaml:"allowed_cidrs"`
+}
+
+// AdminConfig holds /api/admin/ access settings
+type AdminConfig struct {
+	// Tokens maps a name to an accepted bearer token, either the token
+	// itself or "sha256:<hex digest>" of it. The name is recorded as the
+	// actor of changes made with the token. Empty disables the admin API.
+	Tokens map[string]string `yaml:"tokens"`
+}
+
+// OTelConfig holds OpenTelemetry tracing settings
+type OTelConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the OTLP/HTTP collector URL
+	Endpoint string `yaml:"endpoint"`
+	// SampleRate is the fraction of new traces recorded, (0, 1]; requests
+	// carrying a traceparent follow the caller's decision
+	SampleRate float64 `yaml:"sample_rate"`
+}
+
+// adminTokenHashPrefix marks an admin token given as its SHA-256 digest
+const adminTokenHashPrefix = "sha256:"
+
+// minAdminTokenLen keeps plaintext admin tokens out of guessing range
+const minAdminTokenLen = 16
+
+// LoggingConfig holds access log settings. Requests left out of the log are
+// still counted in metrics.
+type LoggingConfig struct {
+	// SkipPaths are never logged; an entry ending in "*" is a prefix match
+	SkipPaths []string `yaml:"skip_paths"`
+	// SkipExtensions are file extensions (".css") never logged
+	SkipExtensions []string `yaml:"skip_extensions"`
+	// SampleRate maps a path prefix to the fraction of its requests logged
+	// (0-1); the longest matching prefix applies
+	SampleRate map[string]float64 `yaml:"sample_rate"`
+
+	// AccessLogFile, when set, receives the access log instead of stderr;
+	// errors and warnings stay on stderr. The file is reopened on SIGHUP,
+	// for logrotate.
+	AccessLogFile string `yaml:"access_log_file"`
+	// AccessLogMaxSizeMB rotates the file at this size; 0 leaves rotation
+	// to an external tool
+	AccessLogMaxSizeMB int `yaml:"access_log_max_size_mb"`
+	// AccessLogMaxBackups is how many rotated files to keep; 0 keeps all
+	AccessLogMaxBackups int `yaml:"access_log_max_backups"`
+	// AccessLogCompress gzips rotated files
+	AccessLogCompress bool `yaml:"access_log_compress"`
+}
+
+// ListenConfig holds listen event recording settings
+type ListenConfig struct {
+	// Async queues play/skip/complete writes for a background batch writer
+	// instead of writing inside the request.
+	Async         bool   `yaml:"async"`
+	QueueSize     int    `yaml:"queue_size"`
+	BatchSize     int    `yaml:"batch_size"`
+	FlushInterval string `yaml:"flush_interval"`
+	// OnFull is the backpressure policy when the queue is full: "drop" or "block"
+	OnFull string `yaml:"on_full"`
+	// DedupWindow ignores a repeat play of a track from the same client within
+	// this duration ("0s" disables)
+	DedupWindow     string `yaml:"dedup_window"`
+	DedupMaxEntries int    `yaml:"dedup_max_entries"`
+}
+
+// WorkersConfig sizes the pool running side effects after a request's
+// response is decided, such as radio state updates after a play
+type WorkersConfig struct {
+	Size int `yaml:"size"`
+	// QueueSize is how many tasks may wait for a worker; when full, tasks
+	// run inline in the request instead of being dropped
+	QueueSize int `yaml:"queue_size"`
+}
+
+// AnalyticsConfig holds admin analytics settings
+type AnalyticsConfig struct {
+	// SessionGap is the idle time that ends a listening session
+	SessionGap string `yaml:"session_gap"`
+	// MaxPosition is the playlist position from which the position funnel
+	// pools events into one overflow bucket
+	MaxPosition int `yaml:"max_position"`
+	// MaxTrackDuration flags longer tracks in the duration report as likely
+	// bad probes
+	MaxTrackDuration string `yaml:"max_track_duration"`
+	// PublicStats are the fields GET /api/stats/public exposes.
+	// ["none"] disables the endpoint.
+	PublicStats []string `yaml:"public_stats"`
+}
+
+// publicStatFields are the analytics.public_stats values supported
+var publicStatFields = []string{"tracks", "catalog_minutes", "plays", "plays_last_7_days", "hours_listened"}
+
+// RadioConfig holds playlist generation settings
+type RadioConfig struct {
+	// MinPlaylistLength pads playlists shorter than this with tracks from the
+	// mood's fallback (0 disables borrowing)
+	MinPlaylistLength int `yaml:"min_playlist_length"`
+	// MinDurationSeconds keeps shorter tracks (jingles, stingers) out of
+	// playlists; admin listings still show them (0 = no minimum)
+	MinDurationSeconds int `yaml:"min_duration_seconds"`
+	// FallbackMoods maps a mood to the related mood it borrows from
+	FallbackMoods map[string]string `yaml:"fallback_moods"`
+	// DefaultPlaylistSize caps playlists when the client sends no limit (0 = all tracks)
+	DefaultPlaylistSize int `yaml:"default_playlist_size"`
+	// MaxPlaylistSize caps any playlist, including ?limit= (0 = no cap)
+	MaxPlaylistSize int `yaml:"max_playlist_size"`
+	// Rules are per-mood programming constraints; rules for moods the
+	// library doesn't have are ignored
+	Rules map[string]RadioRulesConfig `yaml:"rules"`
+	// MaxCached bounds how many moods' radios (and their recently played
+	// state) are kept; the least recently used is evicted (0 = default, 32)
+	MaxCached int `yaml:"max_cached"`
+	// HeadMemory is how many opening tracks of a mood's last playlist are
+	// moved back in the next, so a refresh starts differently (0 = default, 3)
+	HeadMemory int `yaml:"head_memory"`
+	// RecentWindow is how long a played track is moved to the end of its
+	// mood's playlists
+	RecentWindow string `yaml:"recent_window"`
+	// CacheShuffle caches each shuffled playlist and serves it to every
+	// listener until it expires; false shuffles per request, caching only
+	// the unshuffled tracks (unset = true)
+	CacheShuffle *bool `yaml:"cache_shuffle"`
+	// RecencyWeight balances fairness against recency in shuffled playlists,
+	// 0-1: 0 keeps the least played first order and ignores recent plays, 1
+	// shuffles and moves recent plays to the end (unset = 1)
+	RecencyWeight *float64 `yaml:"recency_weight"`
+	// PlaylistLyrics is how playlist responses carry lyrics: "omit" (a
+	// has_lyrics flag; clients fetch GET /api/tracks/{id}), "preview", or
+	// "full"
+	PlaylistLyrics string `yaml:"playlist_lyrics"`
+	// LyricsPreviewLength is how many characters a "preview" keeps
+	LyricsPreviewLength int `yaml:"lyrics_preview_length"`
+}
+
+// ShuffleCached reports whether shuffled playlists are cached, which they
+// are unless cache_shuffle is set to false
+func (r RadioConfig) ShuffleCached() bool {
+	return r.CacheShuffle == nil || *r.CacheShuffle
+}
+
+// RecencyWeighting returns recency_weight, or 1 when unset
+func (r RadioConfig) RecencyWeighting() float64 {
+	if r.RecencyWeight == nil {
+		return 1
+	}
+	return *r.RecencyWeight
+}
+
+// RadioRulesConfig constrains the energy flow of one mood's playlists
+type RadioRulesConfig struct {
+	// AllowedEnergies drops tracks with any other energy (empty = all)
+	AllowedEnergies []string `yaml:"allowed_energies"`
+	// MaxConsecutive caps back-to-back tracks of an energy, e.g. {high: 2}
+	MaxConsecutive map[string]int `yaml:"max_consecutive"`
+	// RatioCaps allows at most max tracks of an energy in any window tracks
+	RatioCaps map[string]RatioCapConfig `yaml:"ratio_caps"`
+}
+
+// RatioCapConfig is an energy's share cap, e.g. {max: 1, window: 5}
+type RatioCapConfig struct {
+	Max    int `yaml:"max"`
+	Window int `yaml:"window"`
+}
+
+// energyLevels are the track energy values radio rules may name
+var energyLevels = []string{"low", "medium", "high"}
+
+// RoomsConfig holds listen-together room settings
+type RoomsConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	MaxRooms   int  `yaml:"max_rooms"`
+	MaxMembers int  `yaml:"max_members"` // per room
+	// EmptyTTL is how long a room with no members keeps its playback state
+	EmptyTTL string `yaml:"empty_ttl"`
+}
+
+// MoodsConfig holds mood presentation settings
+type MoodsConfig struct {
+	// DisplayNames maps a locale (primary language subtag, e.g. "es") to mood
+	// display names. Configured locales are merged over the built-in English.
+	DisplayNames map[string]map[string]string `yaml:"display_names"`
+	// Display holds per-mood presentation metadata for the player, keyed by
+	// mood. Unset fields are omitted from GET /api/moods.
+	Display map[string]MoodDisplay `yaml:"display"`
+}
+
+// StationConfig holds one station's settings
+type StationConfig struct {
+	// Moods the station plays; empty = every mood
+	Moods []string `yaml:"moods"`
+	// AudioPath is the directory, relative to audio.local_path, holding the
+	// station's tracks; only tracks whose file path lies under it are served.
+	// Empty = the whole library.
+	AudioPath string `yaml:"audio_path"`
+}
+
+// PathPrefix returns the file path prefix of the station's tracks ("" for
+// the whole library)
+func (s StationConfig) PathPrefix() string {
+	if s.AudioPath == "" {
+		return ""
+	}
+	return path.Clean(s.AudioPath) + "/"
+}
+
+// MoodDisplay is the presentation metadata for one mood
+type MoodDisplay struct {
+	Color       string `yaml:"color"` // hex, e.g. "#3b82f6"
+	Icon        string `yaml:"icon"`
+	Description string `yaml:"description"`
+}
+
+// CacheConfig holds response cache settings
+type CacheConfig struct {
+	// Backend is "memory" (per process) or "redis" (shared across instances)
+	Backend string `yaml:"backend"`
+	// Namespace prefixes every cache key so deployments sharing a backend
+	// don't collide. Empty = the build version.
+	Namespace string `yaml:"namespace"`
+	// LoadTimeout caps a shared cache fill (e.g. building a playlist) that
+	// runs on after the requests waiting for it give up
+	LoadTimeout string `yaml:"load_timeout"`
+	// MoodStatsTTL is how long the per-mood track counts and durations
+	// behind every moods list variant are cached. "0s" = aggregate on
+	// every moods list miss.
+	MoodStatsTTL string `yaml:"mood_stats_ttl"`
+	// CleanupBatchSize is how many expired entries the memory backend's
+	// cleanup removes per write-lock hold; smaller batches mean shorter stalls
+	CleanupBatchSize int              `yaml:"cleanup_batch_size"`
+	Redis            RedisCacheConfig `yaml:"redis"`
+}
+
+// RedisCacheConfig holds Redis connection settings for cache.backend: redis
+type RedisCacheConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	// Timeout bounds each Redis call; on failure requests are served uncached
+	Timeout string `yaml:"timeout"`
 }
 
 // defaults returns a Config with sensible defaults
@@ -42,12 +388,88 @@ func defaults() *Config {
 			ReadTimeout:     "15s",
 			WriteTimeout:    "15s",
 			ShutdownTimeout: "30s",
+			DrainTimeout:    "5m",
+			MaxBody: BodyLimitsConfig{
+				Events: 4 << 10,
+				Admin:  64 << 10,
+				Upload: 256 << 20,
+			},
+			Compression: CompressionConfig{
+				Encodings: []string{"br", "gzip", "deflate"},
+				MinSize:   1024,
+			},
 		},
 		Database: DatabaseConfig{
-			Path: "data/inventory.db",
+			Path:           "data/inventory.db",
+			WriteDeadline:  "3s",
+			SchemaPath:     "scripts/migrations/schema.sql",
+			IntegrityCheck: "off",
 		},
 		Audio: AudioConfig{
-			LocalPath: "audio",
+			LocalPath:      "audio",
+			ExistsCacheTTL: "5m",
+			SignedURLTTL:   "6h",
+		},
+		Metrics: MetricsConfig{
+			AllowedCIDRs: []string{"127.0.0.1/32", "::1/128"},
+		},
+		OTel: OTelConfig{
+			Endpoint:   "http://localhost:4318",
+			SampleRate: 1,
+		},
+		Logging: LoggingConfig{
+			SkipPaths: []string{"/health", "/ready"},
+			SkipExtensions: []string{
+				".css", ".js", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico",
+				".woff", ".woff2", ".ttf", ".eot", ".map", ".webp", ".mp3", ".webm",
+			},
+			AccessLogMaxSizeMB:  100,
+			AccessLogMaxBackups: 5,
+		},
+		Listen: ListenConfig{
+			Async:           false,
+			QueueSize:       1024,
+			BatchSize:       64,
+			FlushInterval:   "1s",
+			OnFull:          "drop",
+			DedupWindow:     "2s",
+			DedupMaxEntries: 10000,
+		},
+		Workers: WorkersConfig{
+			Size:      4,
+			QueueSize: 256,
+		},
+		Radio: RadioConfig{
+			RecentWindow:        "2h",
+			PlaylistLyrics:      "omit",
+			LyricsPreviewLength: 280,
+		},
+		Analytics: AnalyticsConfig{
+			SessionGap:       "30m",
+			MaxTrackDuration: "30m",
+			MaxPosition:      20,
+			PublicStats:      slices.Clone(publicStatFields),
+		},
+		Moods: MoodsConfig{
+			DisplayNames: map[string]map[string]string{
+				"en": mood.DisplayNames(),
+			},
+		},
+		Cache: CacheConfig{
+			Backend:          "memory",
+			LoadTimeout:      "10s",
+			MoodStatsTTL:     "5m",
+			CleanupBatchSize: 512,
+			Redis: RedisCacheConfig{
+				Addr:    "localhost:6379",
+				Timeout: "200ms",
+			},
+		},
+		Rooms: RoomsConfig{
+			Enabled:    false,
+			MaxRooms:   100,
+			MaxMembers: 16,
+			EmptyTTL:   "5m",
 		},
 	}
 }
@@ -58,9 +480,14 @@ func defaults() *Config {
 func Load(paths ...string) (*Config, error) {
 	cfg := defaults()
 
+	strict, err := strictKeys()
+	if err != nil {
+		return nil, err
+	}
+
 	// Load each config file in order
 	for _, path := range paths {
-		if err := loadFile(cfg, path); err != nil {
+		if err := loadFile(cfg, path, strict); err != nil {
 			// Skip missing files silently (config.local.yaml may not exist)
 			if os.IsNotExist(err) {
 				continue
@@ -70,7 +497,9 @@ func Load(paths ...string) (*Config, error) {
 	}
 
 	// Apply environment variable overrides
-	applyEnvOverrides(cfg)
+	if err := applyEnvOverrides(cfg); err != nil {
+		return nil, fmt.Errorf("environment overrides: %w", err)
+	}
 
 	// Validate
 	if err := validate(cfg); err != nil {
@@ -80,8 +509,26 @@ func Load(paths ...string) (*Config, error) {
 	return cfg, nil
 }
 
-// loadFile reads a YAML file and merges into cfg
-func loadFile(cfg *Config, path string) error {
+// strictEnv turns unknown config keys from an error into a logged warning
+// when set to false, e.g. to roll back to a build that predates a new key
+const strictEnv = "DRIFTFM_CONFIG_STRICT"
+
+// strictKeys reports whether unknown config keys fail loading (the default)
+func strictKeys() (bool, error) {
+	raw := os.Getenv(strictEnv)
+	if raw == "" {
+		return true, nil
+	}
+	strict, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid boolean %q", strictEnv, raw)
+	}
+	return strict, nil
+}
+
+// loadFile reads a YAML file and merges into cfg. Keys that match no config
+// field (usually typos) are an error when strict and a warning otherwise.
+func loadFile(cfg *Config, path string, strict bool) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -89,8 +536,15 @@ func loadFile(cfg *Config, path string) error {
 
 	// Parse YAML into a temporary struct, then merge non-zero values
 	var fileCfg Config
-	if err := yaml.Unmarshal(data, &fileCfg); err != nil {
-		return fmt.Errorf("parsing YAML: %w", err)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fileCfg); err != nil && !errors.Is(err, io.EOF) {
+		unknown := unknownKeys(err)
+		if strict || unknown == nil {
+			return fmt.Errorf("parsing YAML: %w", err)
+		}
+		// The decoder skips unknown keys and fills in everything else
+		log.Printf("Warning: %s: ignoring unknown config keys: %s", path, strings.Join(unknown, "; "))
 	}
 
 	// Merge: file values override defaults (only non-zero values)
@@ -98,6 +552,21 @@ func loadFile(cfg *Config, path string) error {
 	return nil
 }
 
+// unknownKeys returns the decoder's messages if err consists solely of
+// unknown-field errors, or nil if it holds anything else (e.g. a type mismatch)
+func unknownKeys(err error) []string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return nil
+	}
+	for _, msg := range typeErr.Errors {
+		if !strings.Contains(msg, " not found in type ") {
+			return nil
+		}
+	}
+	return typeErr.Errors
+}
+
 // mergeConfig copies non-zero values from src to dst
 func mergeConfig(dst, src *Config) {
 	// Server
@@ -113,38 +582,451 @@ func mergeConfig(dst, src *Config) {
 	if src.Server.ShutdownTimeout != "" {
 		dst.Server.ShutdownTimeout = src.Server.ShutdownTimeout
 	}
+	if src.Server.DrainTimeout != "" {
+		dst.Server.DrainTimeout = src.Server.DrainTimeout
+	}
+	if src.Server.SPAFallback {
+		dst.Server.SPAFallback = true
+	}
+	if src.Server.MaxBody.Events != 0 {
+		dst.Server.MaxBody.Events = src.Server.MaxBody.Events
+	}
+	if src.Server.MaxBody.Admin != 0 {
+		dst.Server.MaxBody.Admin = src.Server.MaxBody.Admin
+	}
+	if src.Server.MaxBody.Upload != 0 {
+		dst.Server.MaxBody.Upload = src.Server.MaxBody.Upload
+	}
+	if len(src.Server.Compression.Encodings) > 0 {
+		dst.Server.Compression.Encodings = src.Server.Compression.Encodings
+	}
+	if src.Server.Compression.MinSize != 0 {
+		dst.Server.Compression.MinSize = src.Server.Compression.MinSize
+	}
 
 	// Database
 	if src.Database.Path != "" {
 		dst.Database.Path = src.Database.Path
 	}
+	if src.Database.WriteDeadline != "" {
+		dst.Database.WriteDeadline = src.Database.WriteDeadline
+	}
+	if src.Database.AutoMigrate {
+		dst.Database.AutoMigrate = true
+	}
+	if src.Database.SchemaPath != "" {
+		dst.Database.SchemaPath = src.Database.SchemaPath
+	}
+	if src.Database.SkipSchemaCheck {
+		dst.Database.SkipSchemaCheck = true
+	}
+	if src.Database.IntegrityCheck != "" {
+		dst.Database.IntegrityCheck = src.Database.IntegrityCheck
+	}
 
 	// Audio
 	if src.Audio.LocalPath != "" {
 		dst.Audio.LocalPath = src.Audio.LocalPath
 	}
+	if len(src.Audio.Providers) > 0 {
+		dst.Audio.Providers = src.Audio.Providers
+	}
+	if src.Audio.ExistsCacheTTL != "" {
+		dst.Audio.ExistsCacheTTL = src.Audio.ExistsCacheTTL
+	}
+	if src.Audio.SigningKey != "" {
+		dst.Audio.SigningKey = src.Audio.SigningKey
+	}
+	if src.Audio.SignedURLTTL != "" {
+		dst.Audio.SignedURLTTL = src.Audio.SignedURLTTL
+	}
+	if src.Audio.PrefetchHint {
+		dst.Audio.PrefetchHint = true
+	}
+	if src.Audio.ThrottleBPS != 0 {
+		dst.Audio.ThrottleBPS = src.Audio.ThrottleBPS
+	}
+
+	// Metrics
+	if len(src.Metrics.AllowedCIDRs) > 0 {
+		dst.Metrics.AllowedCIDRs = src.Metrics.AllowedCIDRs
+	}
+
+	// Admin
+	if len(src.Admin.Tokens) > 0 {
+		dst.Admin.Tokens = src.Admin.Tokens
+	}
+
+	// Stations replace rather than merge, like other per-key tables
+	if len(src.Stations) > 0 {
+		dst.Stations = src.Stations
+	}
+	if src.DefaultStation != "" {
+		dst.DefaultStation = src.DefaultStation
+	}
+
+	// OTel
+	if src.OTel.Enabled {
+		dst.OTel.Enabled = true
+	}
+	if src.OTel.Endpoint != "" {
+		dst.OTel.Endpoint = src.OTel.Endpoint
+	}
+	if src.OTel.SampleRate != 0 {
+		dst.OTel.SampleRate = src.OTel.SampleRate
+	}
+
+	// Logging: lists replace, sample rates merge per prefix
+	if len(src.Logging.SkipPaths) > 0 {
+		dst.Logging.SkipPaths = src.Logging.SkipPaths
+	}
+	if len(src.Logging.SkipExtensions) > 0 {
+		dst.Logging.SkipExtensions = src.Logging.SkipExtensions
+	}
+	for prefix, rate := range src.Logging.SampleRate {
+		if dst.Logging.SampleRate == nil {
+			dst.Logging.SampleRate = make(map[string]float64)
+		}
+		dst.Logging.SampleRate[prefix] = rate
+	}
+	if src.Logging.AccessLogFile != "" {
+		dst.Logging.AccessLogFile = src.Logging.AccessLogFile
+	}
+	if src.Logging.AccessLogMaxSizeMB != 0 {
+		dst.Logging.AccessLogMaxSizeMB = src.Logging.AccessLogMaxSizeMB
+	}
+	if src.Logging.AccessLogMaxBackups != 0 {
+		dst.Logging.AccessLogMaxBackups = src.Logging.AccessLogMaxBackups
+	}
+	if src.Logging.AccessLogCompress {
+		dst.Logging.AccessLogCompress = true
+	}
+
+	// Workers
+	if src.Workers.Size != 0 {
+		dst.Workers.Size = src.Workers.Size
+	}
+	if src.Workers.QueueSize != 0 {
+		dst.Workers.QueueSize = src.Workers.QueueSize
+	}
+
+	// Listen
+	if src.Listen.Async {
+		dst.Listen.Async = true
+	}
+	if src.Listen.QueueSize != 0 {
+		dst.Listen.QueueSize = src.Listen.QueueSize
+	}
+	if src.Listen.BatchSize != 0 {
+		dst.Listen.BatchSize = src.Listen.BatchSize
+	}
+	if src.Listen.FlushInterval != "" {
+		dst.Listen.FlushInterval = src.Listen.FlushInterval
+	}
+	if src.Listen.OnFull != "" {
+		dst.Listen.OnFull = src.Listen.OnFull
+	}
+	if src.Listen.DedupWindow != "" {
+		dst.Listen.DedupWindow = src.Listen.DedupWindow
+	}
+	if src.Listen.DedupMaxEntries != 0 {
+		dst.Listen.DedupMaxEntries = src.Listen.DedupMaxEntries
+	}
+
+	// Analytics
+	if src.Analytics.SessionGap != "" {
+		dst.Analytics.SessionGap = src.Analytics.SessionGap
+	}
+	if src.Analytics.MaxPosition != 0 {
+		dst.Analytics.MaxPosition = src.Analytics.MaxPosition
+	}
+	if src.Analytics.MaxTrackDuration != "" {
+		dst.Analytics.MaxTrackDuration = src.Analytics.MaxTrackDuration
+	}
+	if len(src.Analytics.PublicStats) > 0 {
+		dst.Analytics.PublicStats = src.Analytics.PublicStats
+	}
+
+	// Radio
+	if src.Radio.MinPlaylistLength != 0 {
+		dst.Radio.MinPlaylistLength = src.Radio.MinPlaylistLength
+	}
+	if src.Radio.MinDurationSeconds != 0 {
+		dst.Radio.MinDurationSeconds = src.Radio.MinDurationSeconds
+	}
+	if len(src.Radio.FallbackMoods) > 0 {
+		dst.Radio.FallbackMoods = src.Radio.FallbackMoods
+	}
+	if src.Radio.DefaultPlaylistSize != 0 {
+		dst.Radio.DefaultPlaylistSize = src.Radio.DefaultPlaylistSize
+	}
+	if src.Radio.MaxPlaylistSize != 0 {
+		dst.Radio.MaxPlaylistSize = src.Radio.MaxPlaylistSize
+	}
+	if len(src.Radio.Rules) > 0 {
+		dst.Radio.Rules = src.Radio.Rules
+	}
+	if src.Radio.MaxCached != 0 {
+		dst.Radio.MaxCached = src.Radio.MaxCached
+	}
+	if src.Radio.HeadMemory != 0 {
+		dst.Radio.HeadMemory = src.Radio.HeadMemory
+	}
+	if src.Radio.RecentWindow != "" {
+		dst.Radio.RecentWindow = src.Radio.RecentWindow
+	}
+	if src.Radio.PlaylistLyrics != "" {
+		dst.Radio.PlaylistLyrics = src.Radio.PlaylistLyrics
+	}
+	if src.Radio.LyricsPreviewLength != 0 {
+		dst.Radio.LyricsPreviewLength = src.Radio.LyricsPreviewLength
+	}
+	if src.Radio.CacheShuffle != nil {
+		dst.Radio.CacheShuffle = src.Radio.CacheShuffle
+	}
+	if src.Radio.RecencyWeight != nil {
+		dst.Radio.RecencyWeight = src.Radio.RecencyWeight
+	}
+
+	// Rooms
+	if src.Rooms.Enabled {
+		dst.Rooms.Enabled = true
+	}
+	if src.Rooms.MaxRooms != 0 {
+		dst.Rooms.MaxRooms = src.Rooms.MaxRooms
+	}
+	if src.Rooms.MaxMembers != 0 {
+		dst.Rooms.MaxMembers = src.Rooms.MaxMembers
+	}
+	if src.Rooms.EmptyTTL != "" {
+		dst.Rooms.EmptyTTL = src.Rooms.EmptyTTL
+	}
+
+	// Moods: merge per locale and per mood so a file can add one translation
+	for locale, names := range src.Moods.DisplayNames {
+		if dst.Moods.DisplayNames == nil {
+			dst.Moods.DisplayNames = make(map[string]map[string]string)
+		}
+		if dst.Moods.DisplayNames[locale] == nil {
+			dst.Moods.DisplayNames[locale] = make(map[string]string)
+		}
+		for mood, name := range names {
+			dst.Moods.DisplayNames[locale][mood] = name
+		}
+	}
+	for mood, d := range src.Moods.Display {
+		if dst.Moods.Display == nil {
+			dst.Moods.Display = make(map[string]MoodDisplay)
+		}
+		merged := dst.Moods.Display[mood]
+		if d.Color != "" {
+			merged.Color = d.Color
+		}
+		if d.Icon != "" {
+			merged.Icon = d.Icon
+		}
+		if d.Description != "" {
+			merged.Description = d.Description
+		}
+		dst.Moods.Display[mood] = merged
+	}
+
+	// Cache
+	if src.Cache.Backend != "" {
+		dst.Cache.Backend = src.Cache.Backend
+	}
+	if src.Cache.Namespace != "" {
+		dst.Cache.Namespace = src.Cache.Namespace
+	}
+	if src.Cache.Redis.Addr != "" {
+		dst.Cache.Redis.Addr = src.Cache.Redis.Addr
+	}
+	if src.Cache.Redis.Password != "" {
+		dst.Cache.Redis.Password = src.Cache.Redis.Password
+	}
+	if src.Cache.Redis.DB != 0 {
+		dst.Cache.Redis.DB = src.Cache.Redis.DB
+	}
+	if src.Cache.LoadTimeout != "" {
+		dst.Cache.LoadTimeout = src.Cache.LoadTimeout
+	}
+	if src.Cache.MoodStatsTTL != "" {
+		dst.Cache.MoodStatsTTL = src.Cache.MoodStatsTTL
+	}
+	if src.Cache.CleanupBatchSize != 0 {
+		dst.Cache.CleanupBatchSize = src.Cache.CleanupBatchSize
+	}
+	if src.Cache.Redis.Timeout != "" {
+		dst.Cache.Redis.Timeout = src.Cache.Redis.Timeout
+	}
 }
 
-// applyEnvOverrides applies environment variable overrides
-func applyEnvOverrides(cfg *Config) {
-	// Server
-	if v := os.Getenv("PORT"); v != "" {
-		if port, err := strconv.Atoi(v); err == nil {
-			cfg.Server.Port = port
+// envPrefix is prepended to generated environment variable names.
+// A field's variable is DRIFTFM_<SECTION>_<FIELD> using its YAML keys,
+// e.g. server.read_timeout → DRIFTFM_SERVER_READ_TIMEOUT.
+const envPrefix = "DRIFTFM"
+
+// legacyEnvVars maps the original unprefixed variables to their generated
+// equivalents. They are applied first so the prefixed form wins.
+var legacyEnvVars = []struct {
+	name, key string
+}{
+	{"PORT", "DRIFTFM_SERVER_PORT"},
+	{"DB_PATH", "DRIFTFM_DATABASE_PATH"},
+	{"AUDIO_STORE_LOCAL_PATH", "DRIFTFM_AUDIO_LOCAL_PATH"},
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides applies environment variable overrides.
+// Unparseable values are reported rather than ignored.
+func applyEnvOverrides(cfg *Config) error {
+	fields := make(map[string]reflect.Value)
+	collectEnvFields(reflect.ValueOf(cfg).Elem(), envPrefix, fields)
+
+	for _, legacy := range legacyEnvVars {
+		if v := os.Getenv(legacy.name); v != "" {
+			if err := setFromEnv(fields[legacy.key], v); err != nil {
+				return fmt.Errorf("%s: %w", legacy.name, err)
+			}
 		}
 	}
 
-	// Database
-	if v := os.Getenv("DB_PATH"); v != "" {
-		cfg.Database.Path = v
+	for name, field := range fields {
+		if v := os.Getenv(name); v != "" {
+			if err := setFromEnv(field, v); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
 	}
+	return nil
+}
 
-	// Audio
-	if v := os.Getenv("AUDIO_STORE_LOCAL_PATH"); v != "" {
-		cfg.Audio.LocalPath = v
+// collectEnvFields walks v and records every settable leaf field under its
+// environment variable name.
+func collectEnvFields(v reflect.Value, prefix string, out map[string]reflect.Value) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(tag)
+		if sf.Type.Kind() == reflect.Struct {
+			collectEnvFields(v.Field(i), name, out)
+			continue
+		}
+		out[name] = v.Field(i)
 	}
 }
 
+// setFromEnv parses raw according to the field's type and assigns it.
+// Lists are comma-separated; maps are comma-separated key=value pairs.
+// Pointers, used to tell unset from zero, are set to a parsed value.
+func setFromEnv(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Pointer {
+		v := reflect.New(field.Type().Elem())
+		if err := setFromEnv(v.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", field.Type())
+		}
+		m := make(map[string]string)
+		for pair := range strings.SplitSeq(raw, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid key=value pair %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		field.Set(reflect.ValueOf(m))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", field.Type())
+		}
+		var items []string
+		for item := range strings.SplitSeq(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// validateRadioRules checks one mood's rules name known energies with
+// satisfiable limits
+func validateRadioRules(rules RadioRulesConfig) error {
+	for _, energy := range rules.AllowedEnergies {
+		if !slices.Contains(energyLevels, energy) {
+			return fmt.Errorf("allowed_energies must be from %v, got %q", energyLevels, energy)
+		}
+	}
+	for energy, n := range rules.MaxConsecutive {
+		if !slices.Contains(energyLevels, energy) {
+			return fmt.Errorf("max_consecutive must be keyed by %v, got %q", energyLevels, energy)
+		}
+		if n < 1 {
+			return fmt.Errorf("max_consecutive.%s must be at least 1, got %d", energy, n)
+		}
+	}
+	for energy, c := range rules.RatioCaps {
+		if !slices.Contains(energyLevels, energy) {
+			return fmt.Errorf("ratio_caps must be keyed by %v, got %q", energyLevels, energy)
+		}
+		if c.Max < 1 || c.Window <= c.Max {
+			return fmt.Errorf("ratio_caps.%s needs 1 <= max < window, got max %d window %d", energy, c.Max, c.Window)
+		}
+	}
+	return nil
+}
+
+// minSigningKeyLen is the shortest audio.signing_key accepted; HMAC-SHA256
+// keys shorter than the hash size weaken the signature
+const minSigningKeyLen = 32
+
 // validate checks required fields and value constraints
 func validate(cfg *Config) error {
 	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
@@ -154,21 +1036,422 @@ func validate(cfg *Config) error {
 	if cfg.Database.Path == "" {
 		return fmt.Errorf("database.path is required")
 	}
+	switch cfg.Database.IntegrityCheck {
+	case "quick", "full", "off":
+	default:
+		return fmt.Errorf("database.integrity_check must be quick, full, or off, got %q", cfg.Database.IntegrityCheck)
+	}
 
 	// Validate durations parse correctly
 	if _, err := cfg.GetReadTimeout(); err != nil {
 		return fmt.Errorf("server.read_timeout invalid: %w", err)
 	}
-	if _, err := cfg.GetWriteTimeout(); err != nil {
+	writeTimeout, err := cfg.GetWriteTimeout()
+	if err != nil {
 		return fmt.Errorf("server.write_timeout invalid: %w", err)
 	}
+	writeDeadline, err := cfg.GetWriteDeadline()
+	if err != nil {
+		return fmt.Errorf("database.write_deadline invalid: %w", err)
+	}
+	if writeDeadline <= 0 {
+		return fmt.Errorf("database.write_deadline must be positive, got %s", writeDeadline)
+	}
+	// A zero write timeout never cuts a response off, so any deadline fits
+	if writeTimeout > 0 && writeDeadline >= writeTimeout {
+		return fmt.Errorf("database.write_deadline must be shorter than server.write_timeout (%s), got %s", writeTimeout, writeDeadline)
+	}
 	if _, err := cfg.GetShutdownTimeout(); err != nil {
 		return fmt.Errorf("server.shutdown_timeout invalid: %w", err)
 	}
+	drainTimeout, err := cfg.GetDrainTimeout()
+	if err != nil {
+		return fmt.Errorf("server.drain_timeout invalid: %w", err)
+	}
+	if drainTimeout < 0 {
+		return fmt.Errorf("server.drain_timeout must not be negative, got %s", drainTimeout)
+	}
+	for name, n := range map[string]int64{
+		"events": cfg.Server.MaxBody.Events,
+		"admin":  cfg.Server.MaxBody.Admin,
+		"upload": cfg.Server.MaxBody.Upload,
+	} {
+		if n < 1 {
+			return fmt.Errorf("server.max_body.%s must be positive, got %d", name, n)
+		}
+	}
+	if encodings := cfg.Server.Compression.Encodings; !slices.Equal(encodings, []string{"none"}) {
+		for _, enc := range encodings {
+			if !slices.Contains(compressionEncodings, enc) {
+				return fmt.Errorf("server.compression.encodings must be from %v or just none, got %q", compressionEncodings, enc)
+			}
+		}
+	}
+	if cfg.Server.Compression.MinSize < 1 {
+		return fmt.Errorf("server.compression.min_size must be positive, got %d", cfg.Server.Compression.MinSize)
+	}
+
+	if _, err := cfg.GetExistsCacheTTL(); err != nil {
+		return fmt.Errorf("audio.exists_cache_ttl invalid: %w", err)
+	}
+	if cfg.Audio.SigningKey != "" && len(cfg.Audio.SigningKey) < minSigningKeyLen {
+		return fmt.Errorf("audio.signing_key must be at least %d bytes", minSigningKeyLen)
+	}
+	signedTTL, err := cfg.GetSignedURLTTL()
+	if err != nil {
+		return fmt.Errorf("audio.signed_url_ttl invalid: %w", err)
+	}
+	if signedTTL <= 0 {
+		return fmt.Errorf("audio.signed_url_ttl must be positive, got %s", signedTTL)
+	}
+	if cfg.Audio.ThrottleBPS < 0 {
+		return fmt.Errorf("audio.throttle_bps must not be negative, got %d", cfg.Audio.ThrottleBPS)
+	}
+	for i, p := range cfg.Audio.Providers {
+		switch p.Type {
+		case "local":
+		case "remote":
+			if !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+				return fmt.Errorf("audio.providers[%d]: remote url must be http(s), got %q", i, p.URL)
+			}
+			if p.Check != "" && p.Check != "none" && p.Check != "head" {
+				return fmt.Errorf("audio.providers[%d]: check must be \"none\" or \"head\", got %q", i, p.Check)
+			}
+		default:
+			return fmt.Errorf("audio.providers[%d]: type must be \"local\" or \"remote\", got %q", i, p.Type)
+		}
+	}
+
+	for name, token := range cfg.Admin.Tokens {
+		if name == "" {
+			return fmt.Errorf("admin.tokens entries must have a name")
+		}
+		if !validAdminToken(token) {
+			return fmt.Errorf("admin.tokens.%s must be at least %d characters or %s followed by 64 hex digits", name, minAdminTokenLen, adminTokenHashPrefix)
+		}
+	}
+	if cfg.OTel.Enabled {
+		if u, err := url.Parse(cfg.OTel.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("otel.endpoint must be an http(s) URL, got %q", cfg.OTel.Endpoint)
+		}
+	}
+	if cfg.OTel.SampleRate <= 0 || cfg.OTel.SampleRate > 1 {
+		return fmt.Errorf("otel.sample_rate must be in (0, 1], got %g", cfg.OTel.SampleRate)
+	}
+	if _, err := cfg.GetMetricsAllowedCIDRs(); err != nil {
+		return fmt.Errorf("metrics.allowed_cidrs invalid: %w", err)
+	}
+
+	for _, p := range cfg.Logging.SkipPaths {
+		if !strings.HasPrefix(p, "/") || strings.Contains(strings.TrimSuffix(p, "*"), "*") {
+			return fmt.Errorf("logging.skip_paths: %q must start with / and may only end in *", p)
+		}
+	}
+	for _, ext := range cfg.Logging.SkipExtensions {
+		if len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext[1:], "./") {
+			return fmt.Errorf("logging.skip_extensions: %q must look like \".css\"", ext)
+		}
+	}
+	for prefix, rate := range cfg.Logging.SampleRate {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("logging.sample_rate: prefix %q must start with /", prefix)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("logging.sample_rate.%s must be between 0 and 1, got %v", prefix, rate)
+		}
+	}
+	if cfg.Logging.AccessLogMaxSizeMB < 0 {
+		return fmt.Errorf("logging.access_log_max_size_mb must not be negative, got %d", cfg.Logging.AccessLogMaxSizeMB)
+	}
+	if cfg.Logging.AccessLogMaxBackups < 0 {
+		return fmt.Errorf("logging.access_log_max_backups must not be negative, got %d", cfg.Logging.AccessLogMaxBackups)
+	}
+
+	if cfg.Listen.QueueSize < 1 {
+		return fmt.Errorf("listen.queue_size must be at least 1, got %d", cfg.Listen.QueueSize)
+	}
+	if cfg.Listen.BatchSize < 1 {
+		return fmt.Errorf("listen.batch_size must be at least 1, got %d", cfg.Listen.BatchSize)
+	}
+	flushInterval, err := cfg.GetListenFlushInterval()
+	if err != nil {
+		return fmt.Errorf("listen.flush_interval invalid: %w", err)
+	}
+	if flushInterval <= 0 {
+		return fmt.Errorf("listen.flush_interval must be positive, got %s", flushInterval)
+	}
+	if cfg.Listen.OnFull != "drop" && cfg.Listen.OnFull != "block" {
+		return fmt.Errorf("listen.on_full must be \"drop\" or \"block\", got %q", cfg.Listen.OnFull)
+	}
+	dedupWindow, err := cfg.GetListenDedupWindow()
+	if err != nil {
+		return fmt.Errorf("listen.dedup_window invalid: %w", err)
+	}
+	if dedupWindow < 0 {
+		return fmt.Errorf("listen.dedup_window must not be negative, got %s", dedupWindow)
+	}
+	if cfg.Listen.DedupMaxEntries < 1 {
+		return fmt.Errorf("listen.dedup_max_entries must be at least 1, got %d", cfg.Listen.DedupMaxEntries)
+	}
+
+	if cfg.Workers.Size < 1 {
+		return fmt.Errorf("workers.size must be at least 1, got %d", cfg.Workers.Size)
+	}
+	if cfg.Workers.QueueSize < 1 {
+		return fmt.Errorf("workers.queue_size must be at least 1, got %d", cfg.Workers.QueueSize)
+	}
+
+	sessionGap, err := cfg.GetSessionGap()
+	if err != nil {
+		return fmt.Errorf("analytics.session_gap invalid: %w", err)
+	}
+	if sessionGap <= 0 {
+		return fmt.Errorf("analytics.session_gap must be positive, got %s", sessionGap)
+	}
+	if cfg.Analytics.MaxPosition < 1 {
+		return fmt.Errorf("analytics.max_position must be at least 1, got %d", cfg.Analytics.MaxPosition)
+	}
+	maxTrack, err := cfg.GetMaxTrackDuration()
+	if err != nil {
+		return fmt.Errorf("analytics.max_track_duration invalid: %w", err)
+	}
+	if maxTrack < time.Second {
+		return fmt.Errorf("analytics.max_track_duration must be at least 1s, got %s", maxTrack)
+	}
+	if fields := cfg.Analytics.PublicStats; !slices.Equal(fields, []string{"none"}) {
+		for _, field := range fields {
+			if !slices.Contains(publicStatFields, field) {
+				return fmt.Errorf("analytics.public_stats must be from %v or just none, got %q", publicStatFields, field)
+			}
+		}
+	}
+
+	if cfg.Radio.MinPlaylistLength < 0 {
+		return fmt.Errorf("radio.min_playlist_length must not be negative, got %d", cfg.Radio.MinPlaylistLength)
+	}
+	if cfg.Radio.MinDurationSeconds < 0 {
+		return fmt.Errorf("radio.min_duration_seconds must not be negative, got %d", cfg.Radio.MinDurationSeconds)
+	}
+	for mood, fallback := range cfg.Radio.FallbackMoods {
+		if mood == "" || fallback == "" {
+			return fmt.Errorf("radio.fallback_moods entries must name both moods")
+		}
+		if mood == fallback {
+			return fmt.Errorf("radio.fallback_moods: %s cannot fall back to itself", mood)
+		}
+	}
+	if cfg.Radio.DefaultPlaylistSize < 0 {
+		return fmt.Errorf("radio.default_playlist_size must not be negative, got %d", cfg.Radio.DefaultPlaylistSize)
+	}
+	if cfg.Radio.MaxCached < 0 {
+		return fmt.Errorf("radio.max_cached must not be negative, got %d", cfg.Radio.MaxCached)
+	}
+	if cfg.Radio.HeadMemory < 0 {
+		return fmt.Errorf("radio.head_memory must not be negative, got %d", cfg.Radio.HeadMemory)
+	}
+	recentWindow, err := cfg.GetRecentWindow()
+	if err != nil {
+		return fmt.Errorf("radio.recent_window invalid: %w", err)
+	}
+	if recentWindow <= 0 {
+		return fmt.Errorf("radio.recent_window must be positive, got %s", recentWindow)
+	}
+	if w := cfg.Radio.RecencyWeighting(); !(w >= 0 && w <= 1) {
+		return fmt.Errorf("radio.recency_weight must be 0-1, got %v", w)
+	}
+	switch cfg.Radio.PlaylistLyrics {
+	case "omit", "preview", "full":
+	default:
+		return fmt.Errorf("radio.playlist_lyrics must be omit, preview, or full, got %q", cfg.Radio.PlaylistLyrics)
+	}
+	if cfg.Radio.LyricsPreviewLength < 1 {
+		return fmt.Errorf("radio.lyrics_preview_length must be at least 1, got %d", cfg.Radio.LyricsPreviewLength)
+	}
+	if cfg.Radio.MaxPlaylistSize < 0 {
+		return fmt.Errorf("radio.max_playlist_size must not be negative, got %d", cfg.Radio.MaxPlaylistSize)
+	}
+	if cfg.Radio.MaxPlaylistSize > 0 && cfg.Radio.DefaultPlaylistSize > cfg.Radio.MaxPlaylistSize {
+		return fmt.Errorf("radio.default_playlist_size (%d) exceeds radio.max_playlist_size (%d)",
+			cfg.Radio.DefaultPlaylistSize, cfg.Radio.MaxPlaylistSize)
+	}
+	for mood, rules := range cfg.Radio.Rules {
+		if err := validateRadioRules(rules); err != nil {
+			return fmt.Errorf("radio.rules.%s: %w", mood, err)
+		}
+	}
+
+	if cfg.Rooms.MaxRooms < 1 {
+		return fmt.Errorf("rooms.max_rooms must be at least 1, got %d", cfg.Rooms.MaxRooms)
+	}
+	if cfg.Rooms.MaxMembers < 2 {
+		return fmt.Errorf("rooms.max_members must be at least 2, got %d", cfg.Rooms.MaxMembers)
+	}
+	emptyTTL, err := cfg.GetRoomsEmptyTTL()
+	if err != nil {
+		return fmt.Errorf("rooms.empty_ttl invalid: %w", err)
+	}
+	if emptyTTL <= 0 {
+		return fmt.Errorf("rooms.empty_ttl must be positive, got %s", emptyTTL)
+	}
+
+	for locale, names := range cfg.Moods.DisplayNames {
+		if !validLocale(locale) {
+			return fmt.Errorf("moods.display_names: locale %q must be a lowercase language subtag like \"es\"", locale)
+		}
+		for mood, name := range names {
+			if name == "" {
+				return fmt.Errorf("moods.display_names.%s.%s must not be empty", locale, mood)
+			}
+		}
+	}
+	for name, d := range cfg.Moods.Display {
+		if d.Color != "" && !mood.ValidColor(d.Color) {
+			return fmt.Errorf("moods.display.%s.color must be a hex color like #3b82f6, got %q", name, d.Color)
+		}
+	}
+
+	switch cfg.Cache.Backend {
+	case "memory":
+	case "redis":
+		if cfg.Cache.Redis.Addr == "" {
+			return fmt.Errorf("cache.redis.addr is required for the redis backend")
+		}
+		if cfg.Cache.Redis.DB < 0 {
+			return fmt.Errorf("cache.redis.db must not be negative, got %d", cfg.Cache.Redis.DB)
+		}
+		redisTimeout, err := cfg.GetCacheRedisTimeout()
+		if err != nil {
+			return fmt.Errorf("cache.redis.timeout invalid: %w", err)
+		}
+		if redisTimeout <= 0 {
+			return fmt.Errorf("cache.redis.timeout must be positive, got %s", redisTimeout)
+		}
+	default:
+		return fmt.Errorf("cache.backend must be memory or redis, got %q", cfg.Cache.Backend)
+	}
+	loadTimeout, err := cfg.GetCacheLoadTimeout()
+	if err != nil {
+		return fmt.Errorf("cache.load_timeout invalid: %w", err)
+	}
+	if loadTimeout <= 0 {
+		return fmt.Errorf("cache.load_timeout must be positive, got %s", loadTimeout)
+	}
+	moodStatsTTL, err := cfg.GetMoodStatsTTL()
+	if err != nil {
+		return fmt.Errorf("cache.mood_stats_ttl invalid: %w", err)
+	}
+	if moodStatsTTL < 0 {
+		return fmt.Errorf("cache.mood_stats_ttl must not be negative, got %s", moodStatsTTL)
+	}
+	if cfg.Cache.CleanupBatchSize < 1 {
+		return fmt.Errorf("cache.cleanup_batch_size must be at least 1, got %d", cfg.Cache.CleanupBatchSize)
+	}
+	if !validNamespace(cfg.Cache.Namespace) {
+		return fmt.Errorf("cache.namespace must be at most 64 characters of [A-Za-z0-9._-], got %q", cfg.Cache.Namespace)
+	}
+
+	for id, st := range cfg.Stations {
+		if !validStationID(id) {
+			return fmt.Errorf("stations: ID %q must be 1-32 characters of [a-z0-9-]", id)
+		}
+		for _, m := range st.Moods {
+			if !mood.Known(m) {
+				return fmt.Errorf("stations.%s.moods: unknown mood %q", id, m)
+			}
+		}
+		if st.AudioPath != "" && (!filepath.IsLocal(st.AudioPath) || strings.Contains(st.AudioPath, `\`)) {
+			return fmt.Errorf("stations.%s.audio_path must be a relative path inside audio.local_path, got %q", id, st.AudioPath)
+		}
+	}
+	if cfg.DefaultStation != "" {
+		if _, ok := cfg.Stations[cfg.DefaultStation]; !ok {
+			return fmt.Errorf("default_station %q is not a configured station", cfg.DefaultStation)
+		}
+	} else if len(cfg.Stations) > 0 {
+		return fmt.Errorf("default_station is required when stations are configured")
+	}
 
 	return nil
 }
 
+// validStationID reports whether id is usable as a /api/stations/{id}/ path
+// segment and cache key part: 1-32 lowercase letters, digits, and hyphens.
+func validStationID(id string) bool {
+	if len(id) < 1 || len(id) > 32 {
+		return false
+	}
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// validLocale reports whether locale is 2-8 lowercase ASCII letters, the
+// primary subtag form matched against Accept-Language.
+func validLocale(locale string) bool {
+	if len(locale) < 2 || len(locale) > 8 {
+		return false
+	}
+	for _, c := range locale {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validNamespace reports whether ns is usable as a cache key prefix. The
+// separator ':' is excluded so one namespace can't be a prefix of another's keys.
+func validNamespace(ns string) bool {
+	if len(ns) > 64 {
+		return false
+	}
+	for _, c := range ns {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '.' && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// validAdminToken reports whether an admin.tokens entry is a long enough
+// plaintext token or a well-formed SHA-256 digest
+func validAdminToken(token string) bool {
+	digest, hashed := strings.CutPrefix(token, adminTokenHashPrefix)
+	if !hashed {
+		return len(token) >= minAdminTokenLen
+	}
+	if len(digest) != 64 {
+		return false
+	}
+	for _, c := range digest {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCompressionEncodings returns the enabled response encodings in
+// preference order; nil when compression is disabled with ["none"].
+func (c *Config) GetCompressionEncodings() []string {
+	if slices.Equal(c.Server.Compression.Encodings, []string{"none"}) {
+		return nil
+	}
+	return c.Server.Compression.Encodings
+}
+
+// GetPublicStats returns the fields GET /api/stats/public exposes; nil
+// when the endpoint is disabled with ["none"].
+func (c *Config) GetPublicStats() []string {
+	if slices.Equal(c.Analytics.PublicStats, []string{"none"}) {
+		return nil
+	}
+	return c.Analytics.PublicStats
+}
+
 // Helper methods to get parsed duration values
 
 func (c *Config) GetReadTimeout() (time.Duration, error) {
@@ -179,6 +1462,71 @@ func (c *Config) GetWriteTimeout() (time.Duration, error) {
 	return time.ParseDuration(c.Server.WriteTimeout)
 }
 
+func (c *Config) GetWriteDeadline() (time.Duration, error) {
+	return time.ParseDuration(c.Database.WriteDeadline)
+}
+
 func (c *Config) GetShutdownTimeout() (time.Duration, error) {
 	return time.ParseDuration(c.Server.ShutdownTimeout)
 }
+
+func (c *Config) GetDrainTimeout() (time.Duration, error) {
+	return time.ParseDuration(c.Server.DrainTimeout)
+}
+
+func (c *Config) GetExistsCacheTTL() (time.Duration, error) {
+	return time.ParseDuration(c.Audio.ExistsCacheTTL)
+}
+
+func (c *Config) GetSignedURLTTL() (time.Duration, error) {
+	return time.ParseDuration(c.Audio.SignedURLTTL)
+}
+
+func (c *Config) GetListenFlushInterval() (time.Duration, error) {
+	return time.ParseDuration(c.Listen.FlushInterval)
+}
+
+func (c *Config) GetListenDedupWindow() (time.Duration, error) {
+	return time.ParseDuration(c.Listen.DedupWindow)
+}
+
+func (c *Config) GetRoomsEmptyTTL() (time.Duration, error) {
+	return time.ParseDuration(c.Rooms.EmptyTTL)
+}
+
+func (c *Config) GetCacheLoadTimeout() (time.Duration, error) {
+	return time.ParseDuration(c.Cache.LoadTimeout)
+}
+
+func (c *Config) GetMoodStatsTTL() (time.Duration, error) {
+	return time.ParseDuration(c.Cache.MoodStatsTTL)
+}
+
+func (c *Config) GetCacheRedisTimeout() (time.Duration, error) {
+	return time.ParseDuration(c.Cache.Redis.Timeout)
+}
+
+func (c *Config) GetRecentWindow() (time.Duration, error) {
+	return time.ParseDuration(c.Radio.RecentWindow)
+}
+
+func (c *Config) GetSessionGap() (time.Duration, error) {
+	return time.ParseDuration(c.Analytics.SessionGap)
+}
+
+func (c *Config) GetMaxTrackDuration() (time.Duration, error) {
+	return time.ParseDuration(c.Analytics.MaxTrackDuration)
+}
+
+// GetMetricsAllowedCIDRs parses metrics.allowed_cidrs into network prefixes.
+func (c *Config) GetMetricsAllowedCIDRs() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.Metrics.AllowedCIDRs))
+	for _, cidr := range c.Metrics.AllowedCIDRs {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
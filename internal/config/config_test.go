@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaults(t *testing.T) {
@@ -18,9 +21,20 @@ func TestDefaults(t *testing.T) {
 	if cfg.Database.Path != "data/inventory.db" {
 		t.Errorf("expected database path 'data/inventory.db', got %s", cfg.Database.Path)
 	}
+	if cfg.Database.AutoMigrate || cfg.Database.SchemaPath != "scripts/migrations/schema.sql" {
+		t.Errorf("expected auto_migrate off with the baseline schema path, got %v %q", cfg.Database.AutoMigrate, cfg.Database.SchemaPath)
+	}
 	if cfg.Audio.LocalPath != "audio" {
 		t.Errorf("expected audio local path 'audio', got %s", cfg.Audio.LocalPath)
 	}
+
+	prefixes, err := cfg.GetMetricsAllowedCIDRs()
+	if err != nil {
+		t.Fatalf("default metrics CIDRs invalid: %v", err)
+	}
+	if len(prefixes) != 2 || !prefixes[0].Addr().IsLoopback() || !prefixes[1].Addr().IsLoopback() {
+		t.Errorf("expected loopback-only metrics CIDRs by default, got %v", prefixes)
+	}
 }
 
 func TestLoadFromFile(t *testing.T) {
@@ -33,8 +47,12 @@ server:
   port: 9090
 database:
   path: /custom/path.db
+  auto_migrate: true
 audio:
   local_path: /custom/audio
+metrics:
+  allowed_cidrs:
+    - 10.42.0.0/16
 `
 	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
 		t.Fatal(err)
@@ -51,9 +69,15 @@ audio:
 	if cfg.Database.Path != "/custom/path.db" {
 		t.Errorf("expected '/custom/path.db', got %s", cfg.Database.Path)
 	}
+	if !cfg.Database.AutoMigrate {
+		t.Error("expected auto_migrate from file")
+	}
 	if cfg.Audio.LocalPath != "/custom/audio" {
 		t.Errorf("expected '/custom/audio', got %s", cfg.Audio.LocalPath)
 	}
+	if len(cfg.Metrics.AllowedCIDRs) != 1 || cfg.Metrics.AllowedCIDRs[0] != "10.42.0.0/16" {
+		t.Errorf("expected metrics CIDRs [10.42.0.0/16], got %v", cfg.Metrics.AllowedCIDRs)
+	}
 }
 
 func TestEnvOverride(t *testing.T) {
@@ -113,6 +137,506 @@ func TestValidation(t *testing.T) {
 			modify:  func(c *Config) { c.Server.ReadTimeout = "not-a-duration" },
 			wantErr: true,
 		},
+		{
+			name:    "valid metrics cidrs",
+			modify:  func(c *Config) { c.Metrics.AllowedCIDRs = []string{"10.0.0.0/8", "fd00::/8"} },
+			wantErr: false,
+		},
+		{
+			name:    "invalid metrics cidr",
+			modify:  func(c *Config) { c.Metrics.AllowedCIDRs = []string{"10.0.0.0/33"} },
+			wantErr: true,
+		},
+		{
+			name:    "invalid listen overflow policy",
+			modify:  func(c *Config) { c.Listen.OnFull = "retry" },
+			wantErr: true,
+		},
+		{
+			name:    "zero listen flush interval",
+			modify:  func(c *Config) { c.Listen.FlushInterval = "0s" },
+			wantErr: true,
+		},
+		{
+			name:    "dedup disabled",
+			modify:  func(c *Config) { c.Listen.DedupWindow = "0s" },
+			wantErr: false,
+		},
+		{
+			name:    "negative dedup window",
+			modify:  func(c *Config) { c.Listen.DedupWindow = "-2s" },
+			wantErr: true,
+		},
+		{
+			name:    "zero dedup max entries",
+			modify:  func(c *Config) { c.Listen.DedupMaxEntries = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "room of one",
+			modify:  func(c *Config) { c.Rooms.MaxMembers = 1 },
+			wantErr: true,
+		},
+		{
+			name:    "invalid room ttl",
+			modify:  func(c *Config) { c.Rooms.EmptyTTL = "soon" },
+			wantErr: true,
+		},
+		{
+			name:    "drain disabled",
+			modify:  func(c *Config) { c.Server.DrainTimeout = "0s" },
+			wantErr: false,
+		},
+		{
+			name:    "negative drain timeout",
+			modify:  func(c *Config) { c.Server.DrainTimeout = "-1m" },
+			wantErr: true,
+		},
+		{
+			name:    "audio signing key",
+			modify:  func(c *Config) { c.Audio.SigningKey = strings.Repeat("k", 32) },
+			wantErr: false,
+		},
+		{
+			name:    "short audio signing key",
+			modify:  func(c *Config) { c.Audio.SigningKey = "secret" },
+			wantErr: true,
+		},
+		{
+			name:    "zero signed url ttl",
+			modify:  func(c *Config) { c.Audio.SignedURLTTL = "0s" },
+			wantErr: true,
+		},
+		{
+			name:    "version cache namespace",
+			modify:  func(c *Config) { c.Cache.Namespace = "v1.4.0-3-gabc123-dirty" },
+			wantErr: false,
+		},
+		{
+			name:    "region-tagged locale",
+			modify:  func(c *Config) { c.Moods.DisplayNames["es-MX"] = map[string]string{"calm": "Calma"} },
+			wantErr: true,
+		},
+		{
+			name:    "empty display name",
+			modify:  func(c *Config) { c.Moods.DisplayNames["es"] = map[string]string{"calm": ""} },
+			wantErr: true,
+		},
+		{
+			name:    "mood display color",
+			modify:  func(c *Config) { c.Moods.Display = map[string]MoodDisplay{"calm": {Color: "#8B5CF6", Icon: "wave"}} },
+			wantErr: false,
+		},
+		{
+			name:    "mood display color not hex",
+			modify:  func(c *Config) { c.Moods.Display = map[string]MoodDisplay{"calm": {Color: "purple"}} },
+			wantErr: true,
+		},
+		{
+			name: "log skip prefix and sample rate",
+			modify: func(c *Config) {
+				c.Logging.SkipPaths = []string{"/api/config*"}
+				c.Logging.SampleRate = map[string]float64{"/api/moods/": 0.1}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "log skip path wildcard in middle",
+			modify:  func(c *Config) { c.Logging.SkipPaths = []string{"/api/*/playlist"} },
+			wantErr: true,
+		},
+		{
+			name:    "log skip extension without dot",
+			modify:  func(c *Config) { c.Logging.SkipExtensions = []string{"css"} },
+			wantErr: true,
+		},
+		{
+			name:    "log sample rate above one",
+			modify:  func(c *Config) { c.Logging.SampleRate = map[string]float64{"/api/": 1.5} },
+			wantErr: true,
+		},
+		{
+			name:    "log sample prefix not a path",
+			modify:  func(c *Config) { c.Logging.SampleRate = map[string]float64{"api": 0.5} },
+			wantErr: true,
+		},
+		{
+			name:    "negative access log size",
+			modify:  func(c *Config) { c.Logging.AccessLogMaxSizeMB = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "zero admin body limit",
+			modify:  func(c *Config) { c.Server.MaxBody.Admin = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "negative event body limit",
+			modify:  func(c *Config) { c.Server.MaxBody.Events = -1 },
+			wantErr: true,
+		},
+		{
+			name: "radio rules for an unknown mood",
+			modify: func(c *Config) {
+				c.Radio.Rules = map[string]RadioRulesConfig{"not_a_mood": {AllowedEnergies: []string{"low"}}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "radio rules unknown energy",
+			modify: func(c *Config) {
+				c.Radio.Rules = map[string]RadioRulesConfig{"late_night": {AllowedEnergies: []string{"chill"}}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "radio rules zero max consecutive",
+			modify: func(c *Config) {
+				c.Radio.Rules = map[string]RadioRulesConfig{"focus": {MaxConsecutive: map[string]int{"high": 0}}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "radio rules ratio cap wider than window",
+			modify: func(c *Config) {
+				c.Radio.Rules = map[string]RadioRulesConfig{
+					"late_night": {RatioCaps: map[string]RatioCapConfig{"medium": {Max: 5, Window: 5}}},
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "otel endpoint without scheme",
+			modify: func(c *Config) {
+				c.OTel.Enabled = true
+				c.OTel.Endpoint = "localhost:4318"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "otel endpoint ignored while disabled",
+			modify:  func(c *Config) { c.OTel.Endpoint = "localhost:4318" },
+			wantErr: false,
+		},
+		{
+			name:    "otel sample rate above 1",
+			modify:  func(c *Config) { c.OTel.SampleRate = 1.5 },
+			wantErr: true,
+		},
+		{
+			name:    "unknown compression encoding",
+			modify:  func(c *Config) { c.Server.Compression.Encodings = []string{"br", "zstd"} },
+			wantErr: true,
+		},
+		{
+			name:    "none mixed with compression encodings",
+			modify:  func(c *Config) { c.Server.Compression.Encodings = []string{"none", "gzip"} },
+			wantErr: true,
+		},
+		{
+			name:    "compression disabled",
+			modify:  func(c *Config) { c.Server.Compression.Encodings = []string{"none"} },
+			wantErr: false,
+		},
+		{
+			name:    "negative audio throttle",
+			modify:  func(c *Config) { c.Audio.ThrottleBPS = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "unknown public stat",
+			modify:  func(c *Config) { c.Analytics.PublicStats = []string{"tracks", "listeners"} },
+			wantErr: true,
+		},
+		{
+			name:    "none mixed with public stats",
+			modify:  func(c *Config) { c.Analytics.PublicStats = []string{"none", "plays"} },
+			wantErr: true,
+		},
+		{
+			name:    "public stats disabled",
+			modify:  func(c *Config) { c.Analytics.PublicStats = []string{"none"} },
+			wantErr: false,
+		},
+		{
+			name:    "negative compression min size",
+			modify:  func(c *Config) { c.Server.Compression.MinSize = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "write deadline not shorter than write timeout",
+			modify:  func(c *Config) { c.Database.WriteDeadline = "15s" },
+			wantErr: true,
+		},
+		{
+			name: "write deadline with no write timeout",
+			modify: func(c *Config) {
+				c.Server.WriteTimeout = "0s"
+				c.Database.WriteDeadline = "30s"
+			},
+			wantErr: false,
+		},
+		{
+			name:    "zero write deadline",
+			modify:  func(c *Config) { c.Database.WriteDeadline = "0s" },
+			wantErr: true,
+		},
+		{
+			name:    "unparseable write deadline",
+			modify:  func(c *Config) { c.Database.WriteDeadline = "soon" },
+			wantErr: true,
+		},
+		{
+			name: "admin tokens",
+			modify: func(c *Config) {
+				c.Admin.Tokens = map[string]string{"alice": "0123456789abcdef", "ci": "sha256:" + strings.Repeat("ab", 32)}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "short admin token",
+			modify:  func(c *Config) { c.Admin.Tokens = map[string]string{"alice": "secret"} },
+			wantErr: true,
+		},
+		{
+			name:    "unnamed admin token",
+			modify:  func(c *Config) { c.Admin.Tokens = map[string]string{"": "0123456789abcdef"} },
+			wantErr: true,
+		},
+		{
+			name:    "malformed admin token digest",
+			modify:  func(c *Config) { c.Admin.Tokens = map[string]string{"ci": "sha256:" + strings.Repeat("zz", 32)} },
+			wantErr: true,
+		},
+		{
+			name:    "redis cache backend",
+			modify:  func(c *Config) { c.Cache.Backend = "redis" },
+			wantErr: false,
+		},
+		{
+			name:    "zero cache load timeout",
+			modify:  func(c *Config) { c.Cache.LoadTimeout = "0s" },
+			wantErr: true,
+		},
+		{
+			name:    "zero mood stats ttl",
+			modify:  func(c *Config) { c.Cache.MoodStatsTTL = "0s" },
+			wantErr: false,
+		},
+		{
+			name:    "negative mood stats ttl",
+			modify:  func(c *Config) { c.Cache.MoodStatsTTL = "-1m" },
+			wantErr: true,
+		},
+		{
+			name:    "zero cache cleanup batch",
+			modify:  func(c *Config) { c.Cache.CleanupBatchSize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "unknown cache backend",
+			modify:  func(c *Config) { c.Cache.Backend = "memcached" },
+			wantErr: true,
+		},
+		{
+			name:    "full integrity check",
+			modify:  func(c *Config) { c.Database.IntegrityCheck = "full" },
+			wantErr: false,
+		},
+		{
+			name:    "unknown integrity check mode",
+			modify:  func(c *Config) { c.Database.IntegrityCheck = "fast" },
+			wantErr: true,
+		},
+		{
+			name:    "redis backend without address",
+			modify:  func(c *Config) { c.Cache.Backend, c.Cache.Redis.Addr = "redis", "" },
+			wantErr: true,
+		},
+		{
+			name:    "zero redis timeout",
+			modify:  func(c *Config) { c.Cache.Backend, c.Cache.Redis.Timeout = "redis", "0s" },
+			wantErr: true,
+		},
+		{
+			name:    "cache namespace with separator",
+			modify:  func(c *Config) { c.Cache.Namespace = "prod:v1" },
+			wantErr: true,
+		},
+		{
+			name:    "zero listen queue size",
+			modify:  func(c *Config) { c.Listen.QueueSize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero workers",
+			modify:  func(c *Config) { c.Workers.Size = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero worker queue size",
+			modify:  func(c *Config) { c.Workers.QueueSize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero analytics max position",
+			modify:  func(c *Config) { c.Analytics.MaxPosition = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "invalid analytics max track duration",
+			modify:  func(c *Config) { c.Analytics.MaxTrackDuration = "half an hour" },
+			wantErr: true,
+		},
+		{
+			name:    "sub-second analytics max track duration",
+			modify:  func(c *Config) { c.Analytics.MaxTrackDuration = "500ms" },
+			wantErr: true,
+		},
+		{
+			name:    "negative min playlist length",
+			modify:  func(c *Config) { c.Radio.MinPlaylistLength = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative head memory",
+			modify:  func(c *Config) { c.Radio.HeadMemory = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "full playlist lyrics",
+			modify:  func(c *Config) { c.Radio.PlaylistLyrics = "full" },
+			wantErr: false,
+		},
+		{
+			name:    "unknown playlist lyrics mode",
+			modify:  func(c *Config) { c.Radio.PlaylistLyrics = "none" },
+			wantErr: true,
+		},
+		{
+			name:    "recency weight of zero",
+			modify:  func(c *Config) { w := 0.0; c.Radio.RecencyWeight = &w },
+			wantErr: false,
+		},
+		{
+			name:    "recency weight above 1",
+			modify:  func(c *Config) { w := 1.5; c.Radio.RecencyWeight = &w },
+			wantErr: true,
+		},
+		{
+			name:    "negative lyrics preview length",
+			modify:  func(c *Config) { c.Radio.LyricsPreviewLength = -5 },
+			wantErr: true,
+		},
+		{
+			name: "stations with a default",
+			modify: func(c *Config) {
+				c.Stations = map[string]StationConfig{
+					"work":  {Moods: []string{"focus", "energize"}, AudioPath: "work"},
+					"sleep": {Moods: []string{"calm"}},
+				}
+				c.DefaultStation = "work"
+			},
+			wantErr: false,
+		},
+		{
+			name: "stations without a default",
+			modify: func(c *Config) {
+				c.Stations = map[string]StationConfig{"work": {}}
+			},
+			wantErr: true,
+		},
+		{
+			name:    "default station not configured",
+			modify:  func(c *Config) { c.DefaultStation = "work" },
+			wantErr: true,
+		},
+		{
+			name: "station ID with uppercase",
+			modify: func(c *Config) {
+				c.Stations = map[string]StationConfig{"Work": {}}
+				c.DefaultStation = "Work"
+			},
+			wantErr: true,
+		},
+		{
+			name: "station with unknown mood",
+			modify: func(c *Config) {
+				c.Stations = map[string]StationConfig{"work": {Moods: []string{"party"}}}
+				c.DefaultStation = "work"
+			},
+			wantErr: true,
+		},
+		{
+			name: "station audio path outside the library",
+			modify: func(c *Config) {
+				c.Stations = map[string]StationConfig{"work": {AudioPath: "../work"}}
+				c.DefaultStation = "work"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "zero recent window",
+			modify:  func(c *Config) { c.Radio.RecentWindow = "0s" },
+			wantErr: true,
+		},
+		{
+			name:    "malformed recent window",
+			modify:  func(c *Config) { c.Radio.RecentWindow = "2 hours" },
+			wantErr: true,
+		},
+		{
+			name:    "negative max cached radios",
+			modify:  func(c *Config) { c.Radio.MaxCached = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative min duration",
+			modify:  func(c *Config) { c.Radio.MinDurationSeconds = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "self fallback mood",
+			modify:  func(c *Config) { c.Radio.FallbackMoods = map[string]string{"focus": "focus"} },
+			wantErr: true,
+		},
+		{
+			name:    "playlist default within max",
+			modify:  func(c *Config) { c.Radio.DefaultPlaylistSize, c.Radio.MaxPlaylistSize = 50, 100 },
+			wantErr: false,
+		},
+		{
+			name:    "playlist default above max",
+			modify:  func(c *Config) { c.Radio.DefaultPlaylistSize, c.Radio.MaxPlaylistSize = 200, 100 },
+			wantErr: true,
+		},
+		{
+			name:    "negative max playlist size",
+			modify:  func(c *Config) { c.Radio.MaxPlaylistSize = -1 },
+			wantErr: true,
+		},
+		{
+			name: "valid audio providers",
+			modify: func(c *Config) {
+				c.Audio.Providers = []AudioProvider{{Type: "local"}, {Type: "remote", URL: "https://cdn.example.com", Check: "head"}}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "unknown audio provider type",
+			modify:  func(c *Config) { c.Audio.Providers = []AudioProvider{{Type: "s3"}} },
+			wantErr: true,
+		},
+		{
+			name:    "remote provider without url",
+			modify:  func(c *Config) { c.Audio.Providers = []AudioProvider{{Type: "remote"}} },
+			wantErr: true,
+		},
+		{
+			name:    "bare ip is not a cidr",
+			modify:  func(c *Config) { c.Metrics.AllowedCIDRs = []string{"10.0.0.1"} },
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -158,3 +682,303 @@ func TestFileMergeOrder(t *testing.T) {
 		t.Errorf("expected port 9999 (from second file), got %d", cfg.Server.Port)
 	}
 }
+
+func TestMoodDisplayNamesMerge(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	_ = os.WriteFile(file, []byte(`
+moods:
+  display_names:
+    en:
+      late_night: "After Hours"
+    es:
+      late_night: "Noche"
+`), 0644)
+
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	names := cfg.Moods.DisplayNames
+	if names["en"]["late_night"] != "After Hours" {
+		t.Errorf("en late_night = %q, want override", names["en"]["late_night"])
+	}
+	if names["en"]["focus"] != "Focus" {
+		t.Errorf("en focus = %q, want built-in default kept", names["en"]["focus"])
+	}
+	if names["es"]["late_night"] != "Noche" {
+		t.Errorf("es late_night = %q, want Noche", names["es"]["late_night"])
+	}
+}
+
+func TestMoodDisplayMerge(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	local := filepath.Join(dir, "config.local.yaml")
+	_ = os.WriteFile(base, []byte(`
+moods:
+  display:
+    focus:
+      color: "#3b82f6"
+      icon: target
+`), 0644)
+	_ = os.WriteFile(local, []byte(`
+moods:
+  display:
+    focus:
+      description: Deep work
+    rain:
+      color: "#64748b"
+`), 0644)
+
+	cfg, err := Load(base, local)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	want := MoodDisplay{Color: "#3b82f6", Icon: "target", Description: "Deep work"}
+	if got := cfg.Moods.Display["focus"]; got != want {
+		t.Errorf("focus display = %+v, want %+v", got, want)
+	}
+	if got := cfg.Moods.Display["rain"].Color; got != "#64748b" {
+		t.Errorf("rain color = %q, want #64748b", got)
+	}
+}
+
+func TestPrefixedEnvOverride(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(*testing.T, *Config)
+	}{
+		{
+			name: "int",
+			env:  map[string]string{"DRIFTFM_SERVER_PORT": "4000"},
+			check: func(t *testing.T, c *Config) {
+				if c.Server.Port != 4000 {
+					t.Errorf("port = %d, want 4000", c.Server.Port)
+				}
+			},
+		},
+		{
+			name: "duration string",
+			env:  map[string]string{"DRIFTFM_SERVER_READ_TIMEOUT": "45s"},
+			check: func(t *testing.T, c *Config) {
+				if d, _ := c.GetReadTimeout(); d != 45*time.Second {
+					t.Errorf("read timeout = %v, want 45s", d)
+				}
+			},
+		},
+		{
+			name: "string",
+			env:  map[string]string{"DRIFTFM_DATABASE_PATH": "/prefixed/path.db"},
+			check: func(t *testing.T, c *Config) {
+				if c.Database.Path != "/prefixed/path.db" {
+					t.Errorf("database path = %q, want /prefixed/path.db", c.Database.Path)
+				}
+			},
+		},
+		{
+			name: "comma-separated list",
+			env:  map[string]string{"DRIFTFM_METRICS_ALLOWED_CIDRS": "10.0.0.0/8, 192.168.0.0/16,"},
+			check: func(t *testing.T, c *Config) {
+				want := []string{"10.0.0.0/8", "192.168.0.0/16"}
+				if len(c.Metrics.AllowedCIDRs) != len(want) {
+					t.Fatalf("allowed cidrs = %v, want %v", c.Metrics.AllowedCIDRs, want)
+				}
+				for i := range want {
+					if c.Metrics.AllowedCIDRs[i] != want[i] {
+						t.Errorf("allowed cidrs[%d] = %q, want %q", i, c.Metrics.AllowedCIDRs[i], want[i])
+					}
+				}
+			},
+		},
+		{
+			name: "key=value map",
+			env:  map[string]string{"DRIFTFM_RADIO_FALLBACK_MOODS": "focus=calm, late_night=calm"},
+			check: func(t *testing.T, c *Config) {
+				want := map[string]string{"focus": "calm", "late_night": "calm"}
+				if !reflect.DeepEqual(c.Radio.FallbackMoods, want) {
+					t.Errorf("fallback moods = %v, want %v", c.Radio.FallbackMoods, want)
+				}
+			},
+		},
+		{
+			name: "prefixed wins over legacy",
+			env:  map[string]string{"PORT": "3000", "DRIFTFM_SERVER_PORT": "3001"},
+			check: func(t *testing.T, c *Config) {
+				if c.Server.Port != 3001 {
+					t.Errorf("port = %d, want 3001", c.Server.Port)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestMalformedEnvOverride(t *testing.T) {
+	tests := []struct {
+		name, key, value string
+	}{
+		{"legacy port", "PORT", "eighty"},
+		{"prefixed port", "DRIFTFM_SERVER_PORT", "80.5"},
+		{"map without equals", "DRIFTFM_RADIO_FALLBACK_MOODS", "focus:calm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("expected error for %s=%q", tt.key, tt.value)
+			}
+			if !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("error %q should name %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestSetFromEnvTypes(t *testing.T) {
+	var target struct {
+		Enabled bool
+		Cached  *bool
+		Window  time.Duration
+		Ratio   float64
+		Weights []int
+	}
+	v := reflect.ValueOf(&target).Elem()
+
+	tests := []struct {
+		name    string
+		field   string
+		raw     string
+		wantErr bool
+	}{
+		{"bool", "Enabled", "true", false},
+		{"bad bool", "Enabled", "yes please", true},
+		{"bool pointer", "Cached", "false", false},
+		{"bad bool pointer", "Cached", "maybe", true},
+		{"duration", "Window", "90s", false},
+		{"bad duration", "Window", "soon", true},
+		{"float", "Ratio", "0.25", false},
+		{"bad float", "Ratio", "quarter", true},
+		{"non-string list", "Weights", "1,2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := setFromEnv(v.FieldByName(tt.field), tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("setFromEnv(%s, %q) error = %v, wantErr %v", tt.field, tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+
+	if !target.Enabled || target.Cached == nil || *target.Cached || target.Window != 90*time.Second || target.Ratio != 0.25 {
+		t.Errorf("unexpected parsed values: %+v", target)
+	}
+}
+
+func TestCacheShuffle(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Radio.ShuffleCached() {
+		t.Error("shuffled playlists should be cached by default")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	_ = os.WriteFile(path, []byte("radio:\n  cache_shuffle: false\n"), 0644)
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Radio.ShuffleCached() {
+		t.Error("cache_shuffle: false should shuffle per request")
+	}
+}
+
+func TestStationPathPrefix(t *testing.T) {
+	for _, tt := range []struct{ audioPath, want string }{
+		{"", ""},
+		{"work", "work/"},
+		{"work/", "work/"},
+		{"music/./work", "music/work/"},
+	} {
+		if got := (StationConfig{AudioPath: tt.audioPath}).PathPrefix(); got != tt.want {
+			t.Errorf("PathPrefix() of %q = %q, want %q", tt.audioPath, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	typo := filepath.Join(dir, "typo.yaml")
+	_ = os.WriteFile(typo, []byte("server:\n  porrt: 9090\n  read_timeout: 20s\n"), 0644)
+	badType := filepath.Join(dir, "bad-type.yaml")
+	_ = os.WriteFile(badType, []byte("server:\n  porrt: 9090\n  port: lots\n"), 0644)
+
+	t.Run("strict by default", func(t *testing.T) {
+		_, err := Load(typo)
+		if err == nil {
+			t.Fatal("expected error for misspelled key")
+		}
+		if !strings.Contains(err.Error(), "porrt") || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("error = %v, want it to name the key and line", err)
+		}
+	})
+
+	t.Run("lenient warns and loads the rest", func(t *testing.T) {
+		t.Setenv(strictEnv, "false")
+		cfg, err := Load(typo)
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if cfg.Server.Port != 8080 || cfg.Server.ReadTimeout != "20s" {
+			t.Errorf("port = %d, read_timeout = %q; want default port and 20s", cfg.Server.Port, cfg.Server.ReadTimeout)
+		}
+	})
+
+	t.Run("lenient still rejects bad values", func(t *testing.T) {
+		t.Setenv(strictEnv, "false")
+		if _, err := Load(badType); err == nil {
+			t.Error("expected error for non-integer port")
+		}
+	})
+
+	t.Run("invalid setting", func(t *testing.T) {
+		t.Setenv(strictEnv, "sometimes")
+		if _, err := Load(typo); err == nil {
+			t.Errorf("expected error for invalid %s", strictEnv)
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty.yaml")
+		_ = os.WriteFile(empty, nil, 0644)
+		if _, err := Load(empty); err != nil {
+			t.Errorf("Load() on empty file failed: %v", err)
+		}
+	})
+}
+
+func TestShippedConfigHasNoUnknownKeys(t *testing.T) {
+	if _, err := Load("../../config.yaml"); err != nil {
+		t.Fatalf("config.yaml does not load strictly: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Package drain lets a server stop taking new API and audio requests ahead of
+// shutdown while in-flight audio transfers finish.
+//
+// http.Server.Shutdown waits for active requests only up to its context
+// deadline, which is too short for a listener halfway through a long track.
+// Draining first turns new work away with 503 and waits, on a separate and
+// longer budget, for audio downloads already in progress.
+package drain
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Drainer tracks in-flight audio transfers and the draining state
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	active   int           // in-flight /audio/ requests
+	idle     chan struct{} // closed once draining with no active transfers
+}
+
+// New creates a Drainer that is accepting requests
+func New() *Drainer {
+	return &Drainer{idle: make(chan struct{})}
+}
+
+// Start stops new /api/ and /audio/ requests. Safe to call more than once.
+func (d *Drainer) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return
+	}
+	d.draining = true
+	if d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// Draining reports whether Start has been called
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Active returns the number of audio transfers in flight
+func (d *Drainer) Active() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// Wait blocks until draining has started and every in-flight audio transfer
+// has finished, or ctx is done.
+func (d *Drainer) Wait(ctx context.Context) error {
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin registers an audio transfer; false means the drainer is draining
+func (d *Drainer) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+func (d *Drainer) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.draining && d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// Middleware rejects new /api/ and /audio/ requests with 503 once draining,
+// asking the client to close the connection so it reconnects elsewhere.
+// Audio requests admitted before draining are tracked until they complete.
+// Other paths (health, readiness, static files) pass through untouched.
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		audio := strings.HasPrefix(r.URL.Path, "/audio/")
+		if !audio && !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if audio {
+			if !d.begin() {
+				reject(w)
+				return
+			}
+			defer d.end()
+		} else if d.Draining() {
+			reject(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func reject(w http.ResponseWriter) {
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Retry-After", "5")
+	http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,103 @@
+package drain
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrainer_SlowAudioCompletesWhileNewRequestsRejected(t *testing.T) {
+	d := New()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/audio/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/audio/slow.mp3" {
+			_, _ = w.Write([]byte("first half,"))
+			close(started)
+			<-release
+			_, _ = w.Write([]byte("second half"))
+			return
+		}
+		_, _ = w.Write([]byte("fast"))
+	})
+	mux.HandleFunc("/api/moods", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := d.Middleware(mux)
+
+	slow := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(slow, httptest.NewRequest(http.MethodGet, "/audio/slow.mp3", nil))
+		close(done)
+	}()
+	<-started
+
+	d.Start()
+	if !d.Draining() {
+		t.Fatal("Draining() = false after Start")
+	}
+	if n := d.Active(); n != 1 {
+		t.Errorf("Active() = %d, want 1", n)
+	}
+
+	// New API and audio requests are turned away; other paths are not
+	for _, path := range []string{"/api/moods", "/audio/fast.mp3"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s while draining: status = %d, want %d", path, w.Code, http.StatusServiceUnavailable)
+		}
+		if got := w.Header().Get("Connection"); got != "close" {
+			t.Errorf("%s while draining: Connection = %q, want close", path, got)
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/ready passed through with status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Wait holds while the transfer is in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Wait(ctx); err == nil {
+		t.Fatal("Wait returned before the slow transfer finished")
+	}
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.Wait(ctx); err != nil {
+		t.Fatalf("Wait after transfer finished: %v", err)
+	}
+	<-done
+
+	if slow.Code != http.StatusOK || !strings.HasSuffix(slow.Body.String(), "second half") {
+		t.Errorf("slow transfer = %d %q, want 200 with the full body", slow.Code, slow.Body.String())
+	}
+}
+
+func TestDrainer_WaitWithNothingInFlight(t *testing.T) {
+	d := New()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Wait(ctx); err == nil {
+		t.Error("Wait returned before Start")
+	}
+
+	d.Start()
+	d.Start() // idempotent
+	if err := d.Wait(context.Background()); err != nil {
+		t.Errorf("Wait with nothing in flight: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+// Package health keeps a registry of component checks behind the readiness
+// probe and the verbose health report.
+//
+// Required components gate readiness. In the verbose report every component
+// is checked and a failure only changes the reported status: a failing
+// required component is "down", any other is "degraded".
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is the state of a component or of the service as a whole
+type Status string
+
+// Status values, from best to worst
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// ReportTimeout bounds how long the verbose report waits on its checks
+const ReportTimeout = 2 * time.Second
+
+// Check probes a component. It returns details worth reporting, and an error
+// when the component is unhealthy.
+type Check func(ctx context.Context) (map[string]any, error)
+
+// Component is one component's entry in a Report
+type Component struct {
+	Status    Status         `json:"status"`
+	Required  bool           `json:"required"`
+	LatencyMS float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Report is the verbose health response
+type Report struct {
+	Status        Status               `json:"status"`
+	Version       string               `json:"version"`
+	UptimeSeconds int64                `json:"uptime_seconds"`
+	Components    map[string]Component `json:"components"`
+}
+
+type registered struct {
+	name     string
+	required bool
+	check    Check
+}
+
+// Registry holds the component checks, in registration order
+type Registry struct {
+	version string
+	started time.Time
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	checks []registered
+}
+
+// NewRegistry creates an empty registry; uptime counts from now
+func NewRegistry(version string) *Registry {
+	return &Registry{version: version, started: time.Now(), now: time.Now}
+}
+
+// Register adds a component check. Required components must pass for the
+// service to be ready.
+func (r *Registry) Register(name string, required bool, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, registered{name: name, required: required, check: check})
+}
+
+func (r *Registry) snapshot() []registered {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]registered(nil), r.checks...)
+}
+
+// Ready runs the required checks in registration order and returns the
+// first failure, naming its component
+func (r *Registry) Ready(ctx context.Context) error {
+	for _, c := range r.snapshot() {
+		if !c.required {
+			continue
+		}
+		if _, err := c.check(ctx); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// Report runs every check concurrently and summarizes them. The overall
+// status is the worst of the components'.
+func (r *Registry) Report(ctx context.Context) Report {
+	checks := r.snapshot()
+	results := make([]Component, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() {
+			start := r.now()
+			details, err := c.check(ctx)
+			comp := Component{
+				Status:    StatusOK,
+				Required:  c.required,
+				LatencyMS: float64(r.now().Sub(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err != nil {
+				comp.Status = StatusDegraded
+				if c.required {
+					comp.Status = StatusDown
+				}
+				comp.Error = err.Error()
+			}
+			results[i] = comp
+		})
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:        StatusOK,
+		Version:       r.version,
+		UptimeSeconds: int64(r.now().Sub(r.started).Seconds()),
+		Components:    make(map[string]Component, len(checks)),
+	}
+	for i, c := range checks {
+		report.Components[c.name] = results[i]
+		report.Status = worse(report.Status, results[i].Status)
+	}
+	return report
+}
+
+func worse(a, b Status) Status {
+	rank := map[Status]int{StatusOK: 0, StatusDegraded: 1, StatusDown: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// LivenessHandler serves /health. The plain probe answers "ok <version>"
+// without running any check; ?verbose=1 responds with the JSON Report,
+// always 200 since a failing component doesn't make the process dead.
+func (r *Registry) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if v := req.URL.Query().Get("verbose"); v == "" || v == "0" || v == "false" {
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte("ok " + r.version)); err != nil {
+				log.Printf("Error writing health response: %v", err)
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), ReportTimeout)
+		defer cancel()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(r.Report(ctx)); err != nil {
+			log.Printf("Error encoding health report: %v", err)
+		}
+	}
+}
+
+// ReadinessHandler serves /ready: 503 "draining" while draining reports
+// true, 503 "not ready" when a required check fails, else 200 "ready"
+func (r *Registry) ReadinessHandler(draining func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		status, body := http.StatusOK, "ready"
+		if draining() {
+			status, body = http.StatusServiceUnavailable, "draining"
+		} else if err := r.Ready(req.Context()); err != nil {
+			status, body = http.StatusServiceUnavailable, "not ready"
+		}
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(body)); err != nil {
+			log.Printf("Error writing ready response: %v", err)
+		}
+	}
+}
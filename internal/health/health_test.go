@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func pass(details map[string]any) Check {
+	return func(context.Context) (map[string]any, error) { return details, nil }
+}
+
+func fail(msg string) Check {
+	return func(context.Context) (map[string]any, error) { return nil, errors.New(msg) }
+}
+
+func getReport(t *testing.T, h http.Handler) Report {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health?verbose=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("verbose status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	return report
+}
+
+func TestLiveness_DegradedComponent(t *testing.T) {
+	reg := NewRegistry("v1.2.3")
+	reg.Register("database", true, pass(nil))
+	reg.Register("cache", false, fail("redis: connection refused"))
+	reg.Register("audio", false, pass(map[string]any{"path": "/music"}))
+	h := reg.LivenessHandler()
+
+	// The plain probe runs no checks and stays 200
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok v1.2.3" {
+		t.Errorf("plain probe = %d %q, want 200 \"ok v1.2.3\"", w.Code, w.Body.String())
+	}
+
+	report := getReport(t, h)
+	if report.Status != StatusDegraded {
+		t.Errorf("status = %q, want degraded", report.Status)
+	}
+	if report.Version != "v1.2.3" {
+		t.Errorf("version = %q, want v1.2.3", report.Version)
+	}
+	if len(report.Components) != 3 {
+		t.Fatalf("got %d components, want 3: %+v", len(report.Components), report.Components)
+	}
+	if c := report.Components["cache"]; c.Status != StatusDegraded || c.Error != "redis: connection refused" {
+		t.Errorf("cache = %+v, want degraded with the check's error", c)
+	}
+	if c := report.Components["database"]; c.Status != StatusOK || !c.Required {
+		t.Errorf("database = %+v, want ok and required", c)
+	}
+	if c := report.Components["audio"]; c.Status != StatusOK || c.Details["path"] != "/music" {
+		t.Errorf("audio = %+v, want ok with details", c)
+	}
+
+	// Degraded components don't affect readiness
+	if err := reg.Ready(context.Background()); err != nil {
+		t.Errorf("Ready = %v, want nil with only optional failures", err)
+	}
+}
+
+func TestLiveness_RequiredComponentDown(t *testing.T) {
+	reg := NewRegistry("dev")
+	reg.Register("database", true, fail("database is locked"))
+	reg.Register("cache", false, fail("unavailable"))
+
+	report := getReport(t, reg.LivenessHandler())
+	if report.Status != StatusDown {
+		t.Errorf("status = %q, want down", report.Status)
+	}
+	if c := report.Components["database"]; c.Status != StatusDown {
+		t.Errorf("database = %+v, want down", c)
+	}
+	if c := report.Components["cache"]; c.Status != StatusDegraded {
+		t.Errorf("cache = %+v, want degraded", c)
+	}
+}
+
+func TestLiveness_NotVerbose(t *testing.T) {
+	called := false
+	reg := NewRegistry("dev")
+	reg.Register("database", true, func(context.Context) (map[string]any, error) {
+		called = true
+		return nil, nil
+	})
+	h := reg.LivenessHandler()
+
+	for _, path := range []string{"/health", "/health?verbose=0", "/health?verbose=false"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != "ok dev" {
+			t.Errorf("%s = %d %q, want the plain probe", path, w.Code, w.Body.String())
+		}
+	}
+	if called {
+		t.Error("plain probe ran a check")
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/health", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		database   Check
+		draining   bool
+		wantStatus int
+		wantBody   string
+	}{
+		{"ready", pass(nil), false, http.StatusOK, "ready"},
+		{"required check fails", fail("closed"), false, http.StatusServiceUnavailable, "not ready"},
+		{"draining", pass(nil), true, http.StatusServiceUnavailable, "draining"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := NewRegistry("dev")
+			reg.Register("database", true, tt.database)
+			reg.Register("cache", false, fail("unavailable"))
+
+			w := httptest.NewRecorder()
+			reg.ReadinessHandler(func() bool { return tt.draining }).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			if w.Code != tt.wantStatus || w.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestReady_NamesFailingComponent(t *testing.T) {
+	reg := NewRegistry("dev")
+	reg.Register("database", true, pass(nil))
+	reg.Register("storage", true, fail("disk full"))
+
+	err := reg.Ready(context.Background())
+	if err == nil || err.Error() != "storage: disk full" {
+		t.Errorf("Ready = %v, want \"storage: disk full\"", err)
+	}
+}
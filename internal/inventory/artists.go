@@ -0,0 +1,50 @@
+package inventory
+
+import "fmt"
+
+// GetByArtist returns one page of an artist's approved tracks ordered by
+// title, plus the artist's total approved track count. The artist name
+// matches case-insensitively (ASCII letters only, SQLite's NOCASE). A limit
+// of 0 or less returns every track from offset on.
+func (r *Repository) GetByArtist(artist string, limit, offset int) ([]*Track, int, error) {
+	clause, scopeArgs := r.scopeClause("t.")
+	where := `WHERE t.artist = ? COLLATE NOCASE AND t.status = ?` + clause
+	args := append([]any{artist, StatusApproved}, scopeArgs...)
+
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM tracks t `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count artist tracks: %w", err)
+	}
+
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		%s
+		ORDER BY t.title COLLATE NOCASE, t.id
+		LIMIT ? OFFSET ?
+	`, trackColumns, trackFrom, where)
+
+	rows, err := r.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query artist tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tracks := []*Track{}
+	for rows.Next() {
+		st, err := scanTrackRow(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, st.toTrack())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed iterating tracks: %w", err)
+	}
+
+	return tracks, total, nil
+}
@@ -0,0 +1,54 @@
+package inventory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetByArtist(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, artist, mood, duration_seconds, status) VALUES
+			(1, 'focus/a.mp3', 'Cedar', 'Ana Lua', 'focus', 180, 'approved'),
+			(2, 'calm/b.mp3', 'aspen', 'ana lua', 'calm', 200, 'approved'),
+			(3, 'focus/c.mp3', 'Birch', 'Ana Lua', 'focus', 240, 'approved'),
+			(4, 'focus/d.mp3', 'Alder', 'Ana Lua', 'focus', 150, 'pending'),
+			(5, 'calm/e.mp3', 'Dune', 'Other Artist', 'calm', 210, 'approved');
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(6, 'focus/f.mp3', 'Ember', 'focus', 190, 'approved');
+	`)
+
+	tests := []struct {
+		name          string
+		artist        string
+		limit, offset int
+		wantIDs       []int64
+		wantTotal     int
+	}{
+		{"all tracks by title, case-insensitive", "ANA LUA", 0, 0, []int64{2, 3, 1}, 3},
+		{"first page", "Ana Lua", 2, 0, []int64{2, 3}, 3},
+		{"second page", "Ana Lua", 2, 2, []int64{1}, 3},
+		{"past the end", "Ana Lua", 2, 4, []int64{}, 3},
+		{"other artist", "other artist", 10, 0, []int64{5}, 1},
+		{"default artist", "Drift FM", 10, 0, []int64{6}, 1},
+		{"unknown artist", "Nobody", 10, 0, []int64{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, total, err := repo.GetByArtist(tt.artist, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package inventory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Database bootstrap errors
+var (
+	ErrNotSQLite = errors.New("not a SQLite database")
+	ErrNoSchema  = errors.New("database has no schema")
+)
+
+// sqliteHeader opens every SQLite 3 database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// isFilePath reports whether dbPath names a plain file, as opposed to an
+// in-memory database or a file: URI, which are passed to the driver as-is
+func isFilePath(dbPath string) bool {
+	return dbPath != "" && dbPath != ":memory:" && !strings.HasPrefix(dbPath, "file:")
+}
+
+// prepareFile readies dbPath for opening: its directory is created if
+// missing, and an existing non-empty file must carry the SQLite header, so a
+// misconfigured path fails here instead of with "file is not a database"
+// on the first query. A missing or empty file is left for SQLite to create.
+func prepareFile(dbPath string) error {
+	if !isFilePath(dbPath) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	f, err := os.Open(dbPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open database file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	header := make([]byte, len(sqliteHeader))
+	n, err := io.ReadFull(f, header)
+	if n == 0 && errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read database file: %w", err)
+	}
+	if !bytes.Equal(header[:n], sqliteHeader) {
+		return fmt.Errorf("%w: %s (check database.path)", ErrNotSQLite, dbPath)
+	}
+	return nil
+}
+
+// HasSchema reports whether the database has been initialized with the
+// tracks table
+func (r *Repository) HasSchema() (bool, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tracks'`).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	return n > 0, nil
+}
+
+// EnsureSchema initializes a new, empty database. With autoMigrate the
+// baseline schema at schemaPath is applied; otherwise, or when that file is
+// missing, it returns ErrNoSchema explaining how to initialize. A database
+// that already has a schema is left alone.
+func (r *Repository) EnsureSchema(schemaPath string, autoMigrate bool) error {
+	ok, err := r.HasSchema()
+	if err != nil || ok {
+		return err
+	}
+
+	if !autoMigrate {
+		return fmt.Errorf("%w: run 'make db-init' or set database.auto_migrate to apply %s on startup", ErrNoSchema, schemaPath)
+	}
+	ddl, err := os.ReadFile(schemaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: baseline schema %s not found; run 'make db-init' from a source checkout or set database.schema_path", ErrNoSchema, schemaPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	// One transaction, so a failing statement can't leave a half-built
+	// schema that HasSchema would accept next time. The single connection
+	// means the ROLLBACK reaches the same session.
+	if _, err := r.db.Exec("BEGIN;\n" + string(ddl) + "\nCOMMIT;"); err != nil {
+		_, _ = r.db.Exec("ROLLBACK")
+		return fmt.Errorf("failed to apply schema %s: %w", schemaPath, err)
+	}
+	log.Printf("Initialized empty database from %s", schemaPath)
+	return nil
+}
@@ -0,0 +1,105 @@
+package inventory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// baselineSchema is the schema applied by make db-init
+const baselineSchema = "../../scripts/migrations/schema.sql"
+
+func TestNewRepository_FreshDirectory(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "data", "nested", "inventory.db")
+
+	repo, err := NewRepository(dbPath)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	info, err := os.Stat(filepath.Dir(dbPath))
+	if err != nil || !info.IsDir() {
+		t.Fatalf("database directory not created: %v", err)
+	}
+
+	// Without auto-migrate a new database fails fast
+	if err := repo.EnsureSchema(baselineSchema, false); !errors.Is(err, ErrNoSchema) {
+		t.Fatalf("EnsureSchema error = %v, want ErrNoSchema", err)
+	}
+
+	if err := repo.EnsureSchema(baselineSchema, true); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+	if ok, err := repo.HasSchema(); err != nil || !ok {
+		t.Fatalf("HasSchema = %v, %v after auto-migrate", ok, err)
+	}
+	if _, err := repo.GetMoodStats(); err != nil {
+		t.Errorf("query after auto-migrate failed: %v", err)
+	}
+
+	// A second run leaves the initialized database alone
+	if err := repo.EnsureSchema("does-not-exist.sql", true); err != nil {
+		t.Errorf("EnsureSchema on an initialized database = %v, want nil", err)
+	}
+}
+
+func TestNewRepository_EmptyFile(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "inventory.db")
+	if err := os.WriteFile(dbPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(dbPath)
+	if err != nil {
+		t.Fatalf("NewRepository on an empty file failed: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	if ok, err := repo.HasSchema(); err != nil || ok {
+		t.Fatalf("HasSchema = %v, %v for an empty file, want false", ok, err)
+	}
+	if err := repo.EnsureSchema(filepath.Join(t.TempDir(), "missing.sql"), true); !errors.Is(err, ErrNoSchema) {
+		t.Errorf("EnsureSchema with a missing schema file = %v, want ErrNoSchema", err)
+	}
+	if err := repo.EnsureSchema(baselineSchema, true); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+	if _, err := repo.GetByMood("focus", TrackFilter{}); err != nil {
+		t.Errorf("query after auto-migrate failed: %v", err)
+	}
+}
+
+func TestNewRepository_GarbageFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"yaml", "server:\n  port: 8080\ndatabase:\n  path: data/inventory.db\n"},
+		{"short", "SQL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(dbPath, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			repo, err := NewRepository(dbPath)
+			if err == nil {
+				_ = repo.Close()
+			}
+			if !errors.Is(err, ErrNotSQLite) {
+				t.Fatalf("NewRepository error = %v, want ErrNotSQLite", err)
+			}
+
+			// The file is left untouched
+			got, _ := os.ReadFile(dbPath)
+			if string(got) != tt.content {
+				t.Error("garbage file was modified")
+			}
+		})
+	}
+}
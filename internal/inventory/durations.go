@@ -0,0 +1,159 @@
+package inventory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxTrackSeconds is the duration past which a track is flagged as a
+// likely bad probe
+const DefaultMaxTrackSeconds = 30 * 60
+
+// Duration outlier reasons
+const (
+	OutlierZero     = "zero"
+	OutlierNegative = "negative"
+	OutlierTooLong  = "too_long"
+)
+
+// durationBucketEdges are the upper bounds, in seconds, of the duration
+// histogram's buckets; the last bucket is open-ended
+var durationBucketEdges = []int{60, 120, 180, 240, 300, 420, 600, 900, 1800}
+
+// DurationBucket counts a mood's tracks with MinSeconds <= duration <
+// MaxSeconds. The last bucket has no MaxSeconds.
+type DurationBucket struct {
+	MinSeconds int `json:"min_seconds"`
+	MaxSeconds int `json:"max_seconds,omitempty"`
+	Count      int `json:"count"`
+}
+
+// MoodDurations is one mood's duration histogram over its positive
+// durations, with every bucket present
+type MoodDurations struct {
+	Mood     string           `json:"mood"`
+	Buckets  []DurationBucket `json:"buckets"`
+	Outliers int              `json:"outliers"`
+}
+
+// DurationOutlier is a track whose duration is zero, negative, or past the
+// report's maximum
+type DurationOutlier struct {
+	Track  *Track `json:"track"`
+	Reason string `json:"reason"`
+}
+
+// DurationReport is the duration histogram of every mood and the tracks
+// flagged as outliers, which usually need re-probing
+type DurationReport struct {
+	MaxSeconds int               `json:"max_seconds"`
+	Moods      []MoodDurations   `json:"moods"`
+	Outliers   []DurationOutlier `json:"outliers"`
+}
+
+// DurationReport buckets the durations of tracks of any status by mood and
+// flags those that are zero, negative, or longer than maxSeconds, in ID
+// order. maxSeconds below 1 selects DefaultMaxTrackSeconds.
+func (r *Repository) DurationReport(maxSeconds int) (*DurationReport, error) {
+	if maxSeconds < 1 {
+		maxSeconds = DefaultMaxTrackSeconds
+	}
+
+	var bucket strings.Builder
+	bucket.WriteString("CASE")
+	for i, edge := range durationBucketEdges {
+		fmt.Fprintf(&bucket, " WHEN duration_seconds < %d THEN %d", edge, i)
+	}
+	fmt.Fprintf(&bucket, " ELSE %d END", len(durationBucketEdges))
+
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT mood, %s AS bucket, COUNT(*)
+		FROM tracks
+		WHERE duration_seconds > 0
+		GROUP BY mood, bucket
+		ORDER BY mood, bucket
+	`, bucket.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duration histogram: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	report := &DurationReport{MaxSeconds: maxSeconds, Moods: []MoodDurations{}, Outliers: []DurationOutlier{}}
+	// Moods are indexed rather than pointed to, as appending moves them
+	moods := map[string]int{}
+	mood := func(name string) *MoodDurations {
+		i, ok := moods[name]
+		if !ok {
+			i = len(report.Moods)
+			moods[name] = i
+			report.Moods = append(report.Moods, MoodDurations{Mood: name, Buckets: durationBuckets()})
+		}
+		return &report.Moods[i]
+	}
+	for rows.Next() {
+		var name string
+		var i, n int
+		if err := rows.Scan(&name, &i, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan duration histogram: %w", err)
+		}
+		mood(name).Buckets[i].Count = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating duration histogram: %w", err)
+	}
+
+	outliers, err := r.db.Query(fmt.Sprintf(`SELECT %s %s WHERE t.duration_seconds <= 0 OR t.duration_seconds > ? ORDER BY t.id`,
+		trackColumns, trackFrom), maxSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duration outliers: %w", err)
+	}
+	defer func() { _ = outliers.Close() }()
+
+	for outliers.Next() {
+		st, err := scanTrackRow(outliers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		t := st.toTrack()
+		reason := OutlierTooLong
+		switch {
+		case t.DurationSeconds == 0:
+			reason = OutlierZero
+		case t.DurationSeconds < 0:
+			reason = OutlierNegative
+		}
+		report.Outliers = append(report.Outliers, DurationOutlier{Track: t, Reason: reason})
+		mood(t.Mood).Outliers++
+	}
+	if err := outliers.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating duration outliers: %w", err)
+	}
+
+	slices.SortFunc(report.Moods, func(a, b MoodDurations) int { return strings.Compare(a.Mood, b.Mood) })
+	return report, nil
+}
+
+// durationBuckets returns the histogram's empty buckets
+func durationBuckets() []DurationBucket {
+	buckets := make([]DurationBucket, 0, len(durationBucketEdges)+1)
+	lo := 0
+	for _, edge := range durationBucketEdges {
+		buckets = append(buckets, DurationBucket{MinSeconds: lo, MaxSeconds: edge})
+		lo = edge
+	}
+	return append(buckets, DurationBucket{MinSeconds: lo})
+}
+
+// SetDuration replaces a track's duration, as measured by re-probing its
+// file. Returns ErrTrackNotFound (wrapped) for an unknown ID.
+func (r *Repository) SetDuration(id int64, seconds int) error {
+	res, err := r.db.Exec(`UPDATE tracks SET duration_seconds = ? WHERE id = ?`, seconds, id)
+	if err != nil {
+		return fmt.Errorf("failed to set duration: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: id %d", ErrTrackNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDurationReport(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/a.mp3', 'A', 'focus', 30, 'approved'),
+			(2, 'focus/b.mp3', 'B', 'focus', 200, 'approved'),
+			(3, 'focus/c.mp3', 'C', 'focus', 210, 'pending'),
+			(4, 'focus/zero.mp3', 'Zero', 'focus', 0, 'approved'),
+			(5, 'calm/negative.mp3', 'Negative', 'calm', -5, 'approved'),
+			(6, 'calm/long.mp3', 'Long', 'calm', 7200, 'approved'),
+			(7, 'calm/d.mp3', 'D', 'calm', 1800, 'approved');
+	`)
+
+	report, err := repo.DurationReport(0)
+	if err != nil {
+		t.Fatalf("DurationReport failed: %v", err)
+	}
+	if report.MaxSeconds != DefaultMaxTrackSeconds {
+		t.Errorf("MaxSeconds = %d, want default %d", report.MaxSeconds, DefaultMaxTrackSeconds)
+	}
+
+	if len(report.Moods) != 2 || report.Moods[0].Mood != "calm" || report.Moods[1].Mood != "focus" {
+		t.Fatalf("moods = %+v, want calm then focus", report.Moods)
+	}
+	count := func(m MoodDurations, minSeconds int) int {
+		for _, b := range m.Buckets {
+			if b.MinSeconds == minSeconds {
+				return b.Count
+			}
+		}
+		t.Fatalf("no bucket starting at %d", minSeconds)
+		return 0
+	}
+	calm, focus := report.Moods[0], report.Moods[1]
+	if len(focus.Buckets) != len(durationBucketEdges)+1 {
+		t.Errorf("got %d buckets, want %d", len(focus.Buckets), len(durationBucketEdges)+1)
+	}
+	if count(focus, 0) != 1 || count(focus, 180) != 2 {
+		t.Errorf("focus buckets = %+v", focus.Buckets)
+	}
+	// 1800 is exactly the maximum: bucketed, not flagged
+	if count(calm, 1800) != 2 || calm.Outliers != 2 || focus.Outliers != 1 {
+		t.Errorf("calm = %+v, focus outliers = %d", calm, focus.Outliers)
+	}
+
+	want := map[int64]string{4: OutlierZero, 5: OutlierNegative, 6: OutlierTooLong}
+	if len(report.Outliers) != len(want) {
+		t.Fatalf("got %d outliers, want %d", len(report.Outliers), len(want))
+	}
+	for _, o := range report.Outliers {
+		if want[o.Track.ID] != o.Reason {
+			t.Errorf("track %d flagged %q, want %q", o.Track.ID, o.Reason, want[o.Track.ID])
+		}
+	}
+
+	report, err = repo.DurationReport(10000)
+	if err != nil {
+		t.Fatalf("DurationReport failed: %v", err)
+	}
+	if len(report.Outliers) != 2 {
+		t.Errorf("got %d outliers under a 10000s maximum, want 2", len(report.Outliers))
+	}
+}
+
+func TestGetMoodStats_SkipsBadDurations(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/a.mp3', 'A', 'focus', 180, 'approved'),
+			(2, 'focus/zero.mp3', 'Zero', 'focus', 0, 'approved'),
+			(3, 'focus/negative.mp3', 'Negative', 'focus', -120, 'approved');
+	`)
+
+	stats, err := repo.GetMoodStats()
+	if err != nil {
+		t.Fatalf("GetMoodStats failed: %v", err)
+	}
+	if len(stats) != 1 || stats[0].TrackCount != 3 || stats[0].TotalSeconds != 180 {
+		t.Errorf("stats = %+v, want 3 focus tracks totalling 180s", stats)
+	}
+}
+
+func TestSetDuration(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if err := repo.SetDuration(1, 321); err != nil {
+		t.Fatalf("SetDuration failed: %v", err)
+	}
+	track, err := repo.GetByID(1)
+	if err != nil || track == nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if track.DurationSeconds != 321 {
+		t.Errorf("duration = %d, want 321", track.DurationSeconds)
+	}
+	if err := repo.SetDuration(999, 10); !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("SetDuration(unknown) error = %v, want ErrTrackNotFound", err)
+	}
+}
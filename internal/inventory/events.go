@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"fmt"
+	"time"
+)
+
+// CountEventsByType counts listen events created in [since, until) per event
+// type. Every type is present in the result, zero if no events matched.
+// Event times are stored to the second, so a fractional until is rounded up
+// to keep events from its own second.
+func (r *Repository) CountEventsByType(since, until time.Time) (map[string]int, error) {
+	if t := until.Truncate(time.Second); !t.Equal(until) {
+		until = t.Add(time.Second)
+	}
+
+	query := `
+		SELECT event_type, COUNT(*)
+		FROM listen_events
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY event_type
+	`
+
+	rows, err := r.db.Query(query, since.UTC().Format(time.DateTime), until.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count listen events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := map[string]int{EventPlay: 0, EventSkip: 0, EventComplete: 0}
+	for rows.Next() {
+		var eventType string
+		var n int
+		if err := rows.Scan(&eventType, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan event count: %w", err)
+		}
+		counts[eventType] = n
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating event counts: %w", err)
+	}
+	return counts, nil
+}
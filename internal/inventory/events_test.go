@@ -0,0 +1,59 @@
+package inventory
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestCountEventsByType(t *testing.T) {
+	repo := setupTestRepo(t)
+	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	seed := []struct {
+		event  string
+		offset time.Duration
+	}{
+		{EventPlay, -2 * time.Hour}, // before the range
+		{EventPlay, 0},              // at since: included
+		{EventPlay, 30 * time.Minute},
+		{EventSkip, time.Hour},
+		{EventComplete, 90 * time.Minute},
+		{EventSkip, 2 * time.Hour}, // at until: excluded
+		{EventComplete, 3 * time.Hour},
+	}
+	for _, e := range seed {
+		_, err := repo.db.Exec(
+			`INSERT INTO listen_events (track_id, mood, event_type, created_at) VALUES (1, 'focus', ?, ?)`,
+			e.event, base.Add(e.offset).Format(time.DateTime),
+		)
+		if err != nil {
+			t.Fatalf("failed to seed event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         map[string]int
+	}{
+		{"half-open range", base, base.Add(2 * time.Hour), map[string]int{EventPlay: 2, EventSkip: 1, EventComplete: 1}},
+		{"everything", base.Add(-24 * time.Hour), base.Add(24 * time.Hour), map[string]int{EventPlay: 3, EventSkip: 2, EventComplete: 2}},
+		{"fractional until keeps its second", base, base.Add(30*time.Minute + time.Millisecond), map[string]int{EventPlay: 2, EventSkip: 0, EventComplete: 0}},
+		{"empty range keeps every type", base.Add(10 * time.Hour), base.Add(11 * time.Hour), map[string]int{EventPlay: 0, EventSkip: 0, EventComplete: 0}},
+		// Bounds in another zone are compared in UTC, like the stored timestamps
+		{"non-UTC bounds", base.In(time.FixedZone("UTC+5", 5*3600)), base.Add(time.Hour).In(time.FixedZone("UTC-3", -3*3600)), map[string]int{EventPlay: 2, EventSkip: 0, EventComplete: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.CountEventsByType(tt.since, tt.until)
+			if err != nil {
+				t.Fatalf("CountEventsByType failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("counts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
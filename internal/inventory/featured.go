@@ -0,0 +1,51 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// FeaturedDate formats the UTC day a featured track is chosen for
+const FeaturedDate = "2006-01-02"
+
+// GetFeaturedTrack returns the approved track featured on date's UTC day.
+// The day's hash indexes the approved tracks by ID, so every caller gets
+// the same track that day and the pick rotates daily; approving or removing
+// tracks can reshuffle later days. Returns nil if no track is approved.
+func (r *Repository) GetFeaturedTrack(date time.Time) (*Track, error) {
+	clause, scopeArgs := r.scopeClause("t.")
+	args := append([]any{StatusApproved}, scopeArgs...)
+
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM tracks t WHERE t.status = ?`+clause, args...).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count approved tracks: %w", err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(date.UTC().Format(FeaturedDate)))
+	offset := h.Sum64() % uint64(count)
+
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		WHERE t.status = ?%s
+		ORDER BY t.id
+		LIMIT 1 OFFSET ?
+	`, trackColumns, trackFrom, clause)
+
+	st, err := scanTrackRow(r.db.QueryRow(query, append(args, int64(offset))...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil // tracks removed since counting
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get featured track: %w", err)
+	}
+
+	return st.toTrack(), nil
+}
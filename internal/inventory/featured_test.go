@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetFeaturedTrack(t *testing.T) {
+	repo := openTestDB(t, `
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 50)
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status)
+		SELECT i, 'focus/t' || i || '.mp3', 'Track ' || i, 'focus', 180,
+			CASE WHEN i % 10 = 0 THEN 'pending' ELSE 'approved' END FROM seq;
+	`)
+
+	day := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	first, err := repo.GetFeaturedTrack(day)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first == nil {
+		t.Fatal("expected a featured track")
+	}
+	if first.Status != StatusApproved {
+		t.Errorf("featured track %d has status %s, want approved", first.ID, first.Status)
+	}
+
+	// Any time on the same UTC day, in any zone, picks the same track
+	for _, same := range []time.Time{
+		day.Add(-9 * time.Hour),
+		day.Add(14 * time.Hour),
+		time.Date(2026, 3, 14, 20, 0, 0, 0, time.FixedZone("UTC-3", -3*60*60)),
+	} {
+		got, err := repo.GetFeaturedTrack(same)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.ID != first.ID {
+			t.Errorf("GetFeaturedTrack(%s) = track %d, want %d", same, got.ID, first.ID)
+		}
+	}
+
+	// Over a month, the pick rotates rather than sticking
+	seen := map[int64]bool{first.ID: true}
+	for d := 1; d <= 30; d++ {
+		got, err := repo.GetFeaturedTrack(day.AddDate(0, 0, d))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Status != StatusApproved {
+			t.Errorf("day +%d featured track %d has status %s", d, got.ID, got.Status)
+		}
+		seen[got.ID] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("only %d distinct tracks over 31 days, want the pick to rotate", len(seen))
+	}
+}
+
+func TestGetFeaturedTrack_NoApprovedTracks(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/a.mp3', 'Draft', 'focus', 180, 'pending');
+	`)
+
+	track, err := repo.GetFeaturedTrack(time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if track != nil {
+		t.Errorf("got track %d, want nil with nothing approved", track.ID)
+	}
+}
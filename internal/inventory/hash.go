@@ -0,0 +1,50 @@
+package inventory
+
+import "fmt"
+
+// DuplicateGroup is a set of tracks whose audio files have identical content
+type DuplicateGroup struct {
+	ContentHash string   `json:"content_hash"`
+	Tracks      []*Track `json:"tracks"`
+}
+
+// Duplicates returns every content hash shared by more than one track, with
+// those tracks in ID order. Tracks not yet hashed are never reported.
+func (r *Repository) Duplicates() ([]DuplicateGroup, error) {
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		WHERE t.content_hash IN (
+			SELECT content_hash FROM tracks
+			WHERE content_hash IS NOT NULL
+			GROUP BY content_hash HAVING COUNT(*) > 1
+		)
+		ORDER BY t.content_hash, t.id
+	`, trackColumns, trackFrom)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	groups := []DuplicateGroup{}
+	for rows.Next() {
+		st, err := scanTrackRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		track := st.toTrack()
+		hash := *track.ContentHash
+		if n := len(groups); n == 0 || groups[n-1].ContentHash != hash {
+			groups = append(groups, DuplicateGroup{ContentHash: hash})
+		}
+		last := &groups[len(groups)-1]
+		last.Tracks = append(last.Tracks, track)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating duplicates: %w", err)
+	}
+
+	return groups, nil
+}
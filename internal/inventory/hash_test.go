@@ -0,0 +1,27 @@
+package inventory
+
+import "testing"
+
+func TestDuplicates(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status, content_hash) VALUES
+			(1, 'tracks/a/one.mp3', 'One', 'focus', 180, 'approved', 'aaa'),
+			(2, 'tracks/b/two.mp3', 'Two', 'calm', 180, 'approved', 'bbb'),
+			(3, 'tracks/c/one-again.mp3', 'One Again', 'calm', 180, 'pending', 'aaa'),
+			(4, 'tracks/d/unhashed.mp3', 'Unhashed', 'focus', 180, 'approved', NULL),
+			(5, 'tracks/e/unhashed2.mp3', 'Unhashed 2', 'focus', 180, 'approved', NULL);
+	`)
+
+	// Only aaa is shared; NULL hashes never group together
+	groups, err := repo.Duplicates()
+	if err != nil {
+		t.Fatalf("Duplicates failed: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d duplicate groups, want 1: %+v", len(groups), groups)
+	}
+	if groups[0].ContentHash != "aaa" || len(groups[0].Tracks) != 2 ||
+		groups[0].Tracks[0].ID != 1 || groups[0].Tracks[1].ID != 3 {
+		t.Errorf("group = %+v, want aaa with tracks 1 and 3", groups[0])
+	}
+}
@@ -0,0 +1,44 @@
+package inventory
+
+import "fmt"
+
+// HideTrack records that a listener doesn't want to hear a track again.
+// Hiding an already hidden track is a no-op.
+func (r *Repository) HideTrack(listenerID string, trackID int64) error {
+	_, err := r.db.Exec(`INSERT OR IGNORE INTO hidden_tracks (listener_id, track_id) VALUES (?, ?)`, listenerID, trackID)
+	if err != nil {
+		return fmt.Errorf("failed to hide track: %w", err)
+	}
+	return nil
+}
+
+// UnhideTrack removes a track from a listener's hidden set, if it is there
+func (r *Repository) UnhideTrack(listenerID string, trackID int64) error {
+	_, err := r.db.Exec(`DELETE FROM hidden_tracks WHERE listener_id = ? AND track_id = ?`, listenerID, trackID)
+	if err != nil {
+		return fmt.Errorf("failed to unhide track: %w", err)
+	}
+	return nil
+}
+
+// HiddenTrackIDs returns the IDs of the tracks a listener hid, in ID order
+func (r *Repository) HiddenTrackIDs(listenerID string) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT track_id FROM hidden_tracks WHERE listener_id = ? ORDER BY track_id`, listenerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hidden tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan hidden track: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating hidden tracks: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,38 @@
+package inventory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHiddenTracks(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if ids, err := repo.HiddenTrackIDs("l1"); err != nil || len(ids) != 0 {
+		t.Fatalf("HiddenTrackIDs() = %v, %v; want none", ids, err)
+	}
+
+	for _, id := range []int64{3, 1, 3} {
+		if err := repo.HideTrack("l1", id); err != nil {
+			t.Fatalf("HideTrack(%d) failed: %v", id, err)
+		}
+	}
+	_ = repo.HideTrack("l2", 2)
+
+	if ids, _ := repo.HiddenTrackIDs("l1"); !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("hidden = %v, want [1 3]", ids)
+	}
+	if ids, _ := repo.HiddenTrackIDs("l2"); !slices.Equal(ids, []int64{2}) {
+		t.Errorf("another listener's hidden = %v, want [2]", ids)
+	}
+
+	if err := repo.UnhideTrack("l1", 3); err != nil {
+		t.Fatalf("UnhideTrack failed: %v", err)
+	}
+	if err := repo.UnhideTrack("l1", 3); err != nil {
+		t.Errorf("unhiding a track that isn't hidden = %v, want nil", err)
+	}
+	if ids, _ := repo.HiddenTrackIDs("l1"); !slices.Equal(ids, []int64{1}) {
+		t.Errorf("hidden after unhide = %v, want [1]", ids)
+	}
+}
@@ -0,0 +1,67 @@
+package inventory
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ActorSystem is the actor recorded for status changes made by the server
+// itself rather than by an admin request
+const ActorSystem = "system"
+
+// StatusChange is one row of a track's status audit log
+type StatusChange struct {
+	TrackID   int64     `json:"track_id"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// recordStatusChangeTx appends a status change within the caller's
+// transaction, stamped with the repository clock
+func (r *Repository) recordStatusChangeTx(tx *sql.Tx, c StatusChange) error {
+	query := `
+		INSERT INTO track_status_history (track_id, old_status, new_status, actor, reason, created_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?)
+	`
+	_, err := tx.Exec(query, c.TrackID, c.OldStatus, c.NewStatus, c.Actor, c.Reason, r.now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	return nil
+}
+
+// GetStatusHistory returns a track's status changes, oldest first. A track
+// with no recorded changes (or no such track) yields an empty slice.
+func (r *Repository) GetStatusHistory(trackID int64) ([]StatusChange, error) {
+	query := `
+		SELECT track_id, old_status, new_status, actor, COALESCE(reason, ''), created_at
+		FROM track_status_history
+		WHERE track_id = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	history := []StatusChange{}
+	for rows.Next() {
+		var c StatusChange
+		if err := rows.Scan(&c.TrackID, &c.OldStatus, &c.NewStatus, &c.Actor, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		history = append(history, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating status history: %w", err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateTrack_RecordsStatusChange(t *testing.T) {
+	repo := setupTestRepo(t)
+	changed := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	repo.SetClock(func() time.Time { return changed })
+
+	if err := repo.UpdateTrack(4, map[string]any{"status": StatusApproved}, "alice", "cleared review"); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	if err := repo.UpdateTrack(4, map[string]any{"status": StatusRejected}, ActorSystem, ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+
+	history, err := repo.GetStatusHistory(4)
+	if err != nil {
+		t.Fatalf("GetStatusHistory failed: %v", err)
+	}
+	want := []StatusChange{
+		{TrackID: 4, OldStatus: StatusPending, NewStatus: StatusApproved, Actor: "alice", Reason: "cleared review", CreatedAt: changed},
+		{TrackID: 4, OldStatus: StatusApproved, NewStatus: StatusRejected, Actor: ActorSystem, CreatedAt: changed},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d history rows, want %d: %+v", len(history), len(want), history)
+	}
+	for i := range want {
+		got := history[i]
+		if got.TrackID != want[i].TrackID || got.OldStatus != want[i].OldStatus || got.NewStatus != want[i].NewStatus ||
+			got.Actor != want[i].Actor || got.Reason != want[i].Reason || !got.CreatedAt.Equal(want[i].CreatedAt) {
+			t.Errorf("history[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestUpdateTrack_NoHistoryWithoutStatusChange(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	// Metadata edits and same-status writes leave no audit rows
+	if err := repo.UpdateTrack(1, map[string]any{"title": "Renamed"}, "alice", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	if err := repo.UpdateTrack(1, map[string]any{"status": StatusApproved}, "alice", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+
+	history, err := repo.GetStatusHistory(1)
+	if err != nil {
+		t.Fatalf("GetStatusHistory failed: %v", err)
+	}
+	if history == nil || len(history) != 0 {
+		t.Errorf("history = %v, want empty non-nil slice", history)
+	}
+}
+
+func TestUpdateTrack_HistoryFailureRollsBack(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	// With the audit table gone the history insert fails after the UPDATE
+	if _, err := repo.db.Exec(`DROP TABLE track_status_history`); err != nil {
+		t.Fatalf("failed to drop history table: %v", err)
+	}
+
+	err := repo.UpdateTrack(4, map[string]any{"status": StatusApproved, "title": "Promoted"}, "alice", "")
+	if err == nil {
+		t.Fatal("expected error when history cannot be written")
+	}
+
+	track, _ := repo.GetByID(4)
+	if track.Status != StatusPending {
+		t.Errorf("status = %q, want %q after rollback", track.Status, StatusPending)
+	}
+	if track.Title == nil || *track.Title != "Pending Track" {
+		t.Errorf("title = %v, want unchanged after rollback", track.Title)
+	}
+}
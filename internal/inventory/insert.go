@@ -0,0 +1,53 @@
+package inventory
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// ErrDuplicateFilePath is returned when a track's file_path is already in
+// the inventory
+var ErrDuplicateFilePath = errors.New("duplicate file path")
+
+// sqliteConstraintUnique is SQLITE_CONSTRAINT_UNIQUE, the extended result
+// code for a UNIQUE constraint violation
+const sqliteConstraintUnique = 2067
+
+// isDuplicateFilePath reports whether err is SQLite rejecting a second
+// track with the same file_path
+func isDuplicateFilePath(err error) bool {
+	var se *sqlite.Error
+	return errors.As(err, &se) && se.Code() == sqliteConstraintUnique && strings.Contains(se.Error(), "tracks.file_path")
+}
+
+// InsertTrack adds a track with t's file path, metadata and status (pending
+// when empty; energy defaults to low) and returns its ID. Returns ErrDuplicateFilePath (wrapped)
+// when another track has the file path.
+func (r *Repository) InsertTrack(t *Track) (int64, error) {
+	status := cmp.Or(t.Status, StatusPending)
+	energy := cmp.Or(t.Energy, "low")
+	res, err := r.db.Exec(`
+		INSERT INTO tracks (
+			file_path, title, artist, mood, energy, tempo_bpm, has_vocals,
+			musical_key, intensity, time_affinity, lyrics, duration_seconds,
+			content_hash, status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.FilePath, t.Title, t.Artist, t.Mood, energy, t.TempoBPM, t.HasVocals,
+		t.MusicalKey, t.Intensity, t.TimeAffinity, t.Lyrics, t.DurationSeconds,
+		t.ContentHash, status)
+	if isDuplicateFilePath(err) {
+		return 0, fmt.Errorf("%w: %s", ErrDuplicateFilePath, t.FilePath)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert track: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read track ID: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,32 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInsertTrack(t *testing.T) {
+	repo := setupTestRepo(t)
+	title := "New Track"
+
+	id, err := repo.InsertTrack(&Track{FilePath: "focus/new.mp3", Title: &title, Mood: "focus", DurationSeconds: 120})
+	if err != nil {
+		t.Fatalf("InsertTrack failed: %v", err)
+	}
+	track, err := repo.GetByID(id)
+	if err != nil || track == nil {
+		t.Fatalf("GetByID(%d) = %v, %v", id, track, err)
+	}
+	if track.Status != StatusPending || track.Energy != "low" || *track.Title != title {
+		t.Errorf("inserted track = %+v, want pending, low energy, titled", track)
+	}
+}
+
+func TestInsertTrack_DuplicateFilePath(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	_, err := repo.InsertTrack(&Track{FilePath: "focus/track1.mp3", Mood: "focus", DurationSeconds: 120})
+	if !errors.Is(err, ErrDuplicateFilePath) {
+		t.Errorf("err = %v, want ErrDuplicateFilePath", err)
+	}
+}
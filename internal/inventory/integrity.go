@@ -0,0 +1,141 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Integrity check modes
+const (
+	// IntegrityQuick runs PRAGMA quick_check: page structure and record
+	// formats, without cross-checking indexes against their tables
+	IntegrityQuick = "quick"
+	// IntegrityFull runs PRAGMA integrity_check, which also verifies every
+	// index; slow on large databases
+	IntegrityFull = "full"
+	// IntegrityOff skips the check
+	IntegrityOff = "off"
+)
+
+// Integrity errors
+var (
+	ErrInvalidIntegrityMode = errors.New("invalid integrity check mode")
+	// ErrCorrupt is returned when SQLite finds the database file damaged
+	ErrCorrupt = errors.New("database is corrupt")
+)
+
+// integrityProgressInterval is how often a running full check is logged
+const integrityProgressInterval = 10 * time.Second
+
+// IntegrityReport is the result of CheckIntegrity
+type IntegrityReport struct {
+	Mode string `json:"mode"`
+	OK   bool   `json:"ok"`
+	// Problems lists what SQLite found, at most 100 entries; empty when OK
+	Problems   []string `json:"problems,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// corruptionMessages are SQLite errors meaning the file itself is damaged,
+// so a check that fails with one reports corruption rather than an error
+var corruptionMessages = []string{
+	"database disk image is malformed",
+	"file is not a database",
+}
+
+// CheckIntegrity runs SQLite's integrity check in the given mode
+// (IntegrityQuick or IntegrityFull). Corruption is reported in the result,
+// not as an error; an error means the check itself couldn't run. A full
+// check logs its progress while it runs.
+func (r *Repository) CheckIntegrity(ctx context.Context, mode string) (*IntegrityReport, error) {
+	var pragma string
+	switch mode {
+	case IntegrityQuick:
+		pragma = "PRAGMA quick_check"
+	case IntegrityFull:
+		pragma = "PRAGMA integrity_check"
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidIntegrityMode, mode)
+	}
+
+	start := time.Now()
+	report := &IntegrityReport{Mode: mode}
+	if mode == IntegrityFull {
+		stop := logIntegrityProgress(start)
+		defer stop()
+	}
+
+	problems, err := r.runIntegrityPragma(ctx, pragma)
+	report.DurationMs = time.Since(start).Milliseconds()
+	if err != nil && !isCorruptionError(err) {
+		return nil, fmt.Errorf("failed to run %s integrity check: %w", mode, err)
+	}
+	if err != nil {
+		log.Printf("Warning: integrity check: %v", err)
+		problems = append(problems, err.Error())
+	}
+	report.Problems = problems
+	report.OK = len(problems) == 0
+	return report, nil
+}
+
+// runIntegrityPragma returns the pragma's rows other than a lone "ok".
+// Problems are logged as they are read, so a long full check shows them
+// before it finishes.
+func (r *Repository) runIntegrityPragma(ctx context.Context, pragma string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, pragma)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return problems, err
+		}
+		if line == "ok" {
+			continue
+		}
+		log.Printf("Warning: integrity check: %s", line)
+		problems = append(problems, line)
+	}
+	return problems, rows.Err()
+}
+
+// logIntegrityProgress logs that a check is still running every
+// integrityProgressInterval until the returned stop is called
+func logIntegrityProgress(start time.Time) (stop func()) {
+	log.Printf("Running full database integrity check...")
+	ticker := time.NewTicker(integrityProgressInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				log.Printf("Integrity check still running (%s elapsed)", time.Since(start).Round(time.Second))
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+func isCorruptionError(err error) bool {
+	msg := err.Error()
+	for _, m := range corruptionMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/testutil"
+)
+
+// writeIntegrityDB creates a database spanning many pages and returns its path
+func writeIntegrityDB(t *testing.T) string {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "inventory.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// Like a production database, left in WAL mode by NewRepository
+	_, err = db.Exec("PRAGMA journal_mode=WAL;" + testutil.SchemaDDL + `
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2000)
+		INSERT INTO tracks (file_path, title, mood, duration_seconds)
+		SELECT 'focus/' || i || '.mp3', printf('%.200c', 'x'), 'focus', 180 FROM n;
+	`)
+	if err != nil {
+		t.Fatalf("failed to populate db: %v", err)
+	}
+	return dbPath
+}
+
+// copyFile copies the database at src into a new temp directory
+func copyFile(t *testing.T, src string) string {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "copy.db")
+	if err := os.WriteFile(dst, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+// truncate cuts the file at path off halfway through
+func truncate(t *testing.T, path string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()/2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	dbPath := writeIntegrityDB(t)
+
+	for _, mode := range []string{IntegrityQuick, IntegrityFull} {
+		t.Run(mode, func(t *testing.T) {
+			copyPath := copyFile(t, dbPath)
+			repo, err := NewRepository(copyPath)
+			if err != nil {
+				t.Fatalf("NewRepository failed: %v", err)
+			}
+			defer func() { _ = repo.Close() }()
+
+			report, err := repo.CheckIntegrity(context.Background(), mode)
+			if err != nil {
+				t.Fatalf("CheckIntegrity failed: %v", err)
+			}
+			if !report.OK || len(report.Problems) != 0 || report.Mode != mode {
+				t.Errorf("healthy database report = %+v, want ok", report)
+			}
+
+			// Damage the file under the open repository
+			truncate(t, copyPath)
+			report, err = repo.CheckIntegrity(context.Background(), mode)
+			if err != nil {
+				t.Fatalf("CheckIntegrity on the truncated file failed: %v", err)
+			}
+			if report.OK || len(report.Problems) == 0 {
+				t.Errorf("truncated database report = %+v, want problems", report)
+			}
+		})
+	}
+}
+
+func TestNewRepository_Corrupt(t *testing.T) {
+	corruptPath := copyFile(t, writeIntegrityDB(t))
+	truncate(t, corruptPath)
+
+	if _, err := NewRepository(corruptPath); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("NewRepository on a truncated file error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestCheckIntegrity_InvalidMode(t *testing.T) {
+	repo := setupTestRepo(t)
+	for _, mode := range []string{"", IntegrityOff, "fast"} {
+		if _, err := repo.CheckIntegrity(context.Background(), mode); !errors.Is(err, ErrInvalidIntegrityMode) {
+			t.Errorf("CheckIntegrity(%q) error = %v, want ErrInvalidIntegrityMode", mode, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package inventory
+
+import (
+	"fmt"
+	"time"
+)
+
+// LibraryTotals are library-wide aggregates safe to publish: no figure
+// identifies a track or a listener
+type LibraryTotals struct {
+	ApprovedTracks  int `json:"approved_tracks"`
+	CatalogSeconds  int `json:"catalog_seconds"`  // approved tracks' positive durations
+	AllTimePlays    int `json:"all_time_plays"`   // sum of play counts
+	RecentPlays     int `json:"recent_plays"`     // play events since the cutoff
+	ListenedSeconds int `json:"listened_seconds"` // listen time of every event
+}
+
+// LibraryTotals aggregates the approved catalog, every play count, and the
+// play events recorded since since, in one query
+func (r *Repository) LibraryTotals(since time.Time) (*LibraryTotals, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM tracks WHERE status = ?),
+			(SELECT COALESCE(SUM(CASE WHEN duration_seconds > 0 THEN duration_seconds END), 0) FROM tracks WHERE status = ?),
+			(SELECT COALESCE(SUM(play_count), 0) FROM play_stats),
+			(SELECT COUNT(*) FROM listen_events WHERE event_type = ? AND created_at >= ?),
+			(SELECT COALESCE(SUM(listen_seconds), 0) FROM listen_events)
+	`
+
+	var t LibraryTotals
+	err := r.db.QueryRow(query, StatusApproved, StatusApproved, EventPlay, since.UTC().Format(time.DateTime)).Scan(
+		&t.ApprovedTracks, &t.CatalogSeconds, &t.AllTimePlays, &t.RecentPlays, &t.ListenedSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate library totals: %w", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,47 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLibraryTotals(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/a.mp3', 'A', 'focus', 180, 'approved'),
+			(2, 'focus/b.mp3', 'B', 'focus', 0, 'approved'),
+			(3, 'calm/c.mp3', 'C', 'calm', 240, 'approved'),
+			(4, 'calm/d.mp3', 'D', 'calm', 300, 'pending');
+		INSERT INTO play_stats (file_path, play_count) VALUES
+			('focus/a.mp3', 7), ('calm/c.mp3', 3), ('calm/d.mp3', 1);
+		INSERT INTO listen_events (track_id, mood, event_type, listen_seconds, created_at) VALUES
+			(1, 'focus', 'play', 100, '2026-03-01 10:00:00'),
+			(1, 'focus', 'play', 60, '2026-03-10 10:00:00'),
+			(3, 'calm', 'skip', 20, '2026-03-11 10:00:00'),
+			(3, 'calm', 'play', 0, '2026-03-12 10:00:00');
+	`)
+
+	got, err := repo.LibraryTotals(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("LibraryTotals failed: %v", err)
+	}
+	want := LibraryTotals{
+		ApprovedTracks:  3,
+		CatalogSeconds:  420, // the zero duration and the pending track are left out
+		AllTimePlays:    11,
+		RecentPlays:     2, // plays only, since the cutoff
+		ListenedSeconds: 180,
+	}
+	if *got != want {
+		t.Errorf("LibraryTotals = %+v, want %+v", *got, want)
+	}
+
+	empty := openTestDB(t, "")
+	got, err = empty.LibraryTotals(time.Now())
+	if err != nil {
+		t.Fatalf("LibraryTotals failed: %v", err)
+	}
+	if *got != (LibraryTotals{}) {
+		t.Errorf("empty library totals = %+v, want zeros", *got)
+	}
+}
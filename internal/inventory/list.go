@@ -0,0 +1,147 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Track listing page sizes
+const (
+	DefaultListPerPage = 50
+	MaxListPerPage     = 200
+)
+
+// ListStatusAll lists tracks of every status, rejected included
+const ListStatusAll = "all"
+
+// Track listing sorts
+const (
+	ListSortCreated = "created_at" // newest first
+	ListSortTitle   = "title"      // A to Z, ignoring case
+	ListSortPlays   = "plays"      // most played first
+)
+
+// listOrders maps each listing sort to its ORDER BY terms
+var listOrders = map[string]string{
+	ListSortCreated: `t.created_at DESC, t.id DESC`,
+	ListSortTitle:   `t.title COLLATE NOCASE, t.id`,
+	ListSortPlays:   `COALESCE(ps.play_count, 0) DESC, t.id`,
+}
+
+// ErrInvalidListOptions is returned by ListTracks for an unknown status or sort
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// ListOptions selects and pages tracks for ListTracks. Zero values apply no
+// filter, the default sort and the first page.
+type ListOptions struct {
+	// Status is one track status or ListStatusAll. Empty lists approved,
+	// pending and beta tracks; rejected ones only show when asked for.
+	Status string
+	Mood   string
+	// Query matches a substring of the title or artist, ignoring ASCII case
+	Query string
+	// Sort is one of the ListSort* values (default ListSortCreated)
+	Sort string
+	// Page is 1-based; PerPage defaults to DefaultListPerPage and is capped
+	// at MaxListPerPage
+	Page    int
+	PerPage int
+}
+
+// ListTracks returns one page of tracks of any status matching opts, and
+// how many match in total. User input only ever reaches the query as bound
+// parameters; the status and sort pick from fixed clauses.
+func (r *Repository) ListTracks(opts ListOptions) ([]*Track, int, error) {
+	where, args, err := listWhere(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort := opts.Sort
+	if sort == "" {
+		sort = ListSortCreated
+	}
+	orderBy, ok := listOrders[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: unknown sort %q", ErrInvalidListOptions, opts.Sort)
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM tracks t `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tracks: %w", err)
+	}
+
+	perPage := opts.PerPage
+	if perPage < 1 {
+		perPage = DefaultListPerPage
+	}
+	perPage = min(perPage, MaxListPerPage)
+	page := max(opts.Page, 1)
+
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		%s
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+	`, trackColumns, trackFrom, where, orderBy)
+
+	rows, err := r.db.Query(query, append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tracks := []*Track{}
+	for rows.Next() {
+		st, err := scanTrackRow(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, st.toTrack())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed iterating tracks: %w", err)
+	}
+	return tracks, total, nil
+}
+
+// listWhere builds the WHERE clause for a track listing over the tracks
+// table aliased as t
+func listWhere(opts ListOptions) (string, []any, error) {
+	var conds []string
+	var args []any
+
+	switch opts.Status {
+	case "":
+		conds = append(conds, "t.status IN (?, ?, ?)")
+		args = append(args, StatusApproved, StatusPending, StatusBeta)
+	case StatusApproved, StatusPending, StatusRejected, StatusBeta:
+		conds = append(conds, "t.status = ?")
+		args = append(args, opts.Status)
+	case ListStatusAll:
+	default:
+		return "", nil, fmt.Errorf("%w: unknown status %q", ErrInvalidListOptions, opts.Status)
+	}
+	if opts.Mood != "" {
+		conds = append(conds, "t.mood = ?")
+		args = append(args, opts.Mood)
+	}
+	if opts.Query != "" {
+		pattern := "%" + escapeLike(opts.Query) + "%"
+		conds = append(conds, `(t.title LIKE ? ESCAPE '\' OR t.artist LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// likeEscaper escapes LIKE wildcards so they match literally under ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
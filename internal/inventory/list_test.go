@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func setupListRepo(t *testing.T) *Repository {
+	t.Helper()
+	return openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, artist, mood, duration_seconds, status, created_at) VALUES
+			(1, 'focus/a.mp3', 'Alpha Waves', 'Nova', 'focus', 180, 'approved', '2026-01-01 10:00:00'),
+			(2, 'focus/b.mp3', 'beta Drift', 'Lumen', 'focus', 180, 'pending', '2026-01-02 10:00:00'),
+			(3, 'calm/c.mp3', 'Gamma 100%', 'Nova', 'calm', 180, 'approved', '2026-01-03 10:00:00'),
+			(4, 'calm/d.mp3', 'Delta_Rain', 'Aster', 'calm', 180, 'rejected', '2026-01-04 10:00:00'),
+			(5, 'focus/e.mp3', 'Epsilon', 'O''Brien', 'focus', 180, 'approved', '2026-01-05 10:00:00');
+		INSERT INTO play_stats (file_path, play_count) VALUES
+			('focus/a.mp3', 3), ('calm/c.mp3', 9), ('focus/e.mp3', 1);
+	`)
+}
+
+func TestListTracks(t *testing.T) {
+	repo := setupListRepo(t)
+
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		want      []int64
+		wantTotal int
+	}{
+		{"defaults hide rejected, newest first", ListOptions{}, []int64{5, 3, 2, 1}, 4},
+		{"rejected when asked", ListOptions{Status: StatusRejected}, []int64{4}, 1},
+		{"every status", ListOptions{Status: ListStatusAll}, []int64{5, 4, 3, 2, 1}, 5},
+		{"mood", ListOptions{Mood: "focus"}, []int64{5, 2, 1}, 3},
+		{"mood and status", ListOptions{Mood: "focus", Status: StatusApproved}, []int64{5, 1}, 2},
+		{"query matches artist", ListOptions{Query: "nova"}, []int64{3, 1}, 2},
+		{"query ignores case in title", ListOptions{Query: "BETA"}, []int64{2}, 1},
+		{"query, mood and status", ListOptions{Query: "nova", Mood: "calm", Status: StatusApproved}, []int64{3}, 1},
+		{"sort by title", ListOptions{Sort: ListSortTitle}, []int64{1, 2, 5, 3}, 4},
+		{"sort by plays", ListOptions{Sort: ListSortPlays}, []int64{3, 1, 5, 2}, 4},
+		{"first page", ListOptions{Sort: ListSortTitle, PerPage: 3}, []int64{1, 2, 5}, 4},
+		{"second page", ListOptions{Sort: ListSortTitle, Page: 2, PerPage: 3}, []int64{3}, 4},
+		{"past the last page", ListOptions{Page: 9, PerPage: 3}, []int64{}, 4},
+		{"per page capped", ListOptions{PerPage: MaxListPerPage + 1}, []int64{5, 3, 2, 1}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, total, err := repo.ListTracks(tt.opts)
+			if err != nil {
+				t.Fatalf("ListTracks failed: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			if !slices.Equal(ids, tt.want) || total != tt.wantTotal {
+				t.Errorf("ids = %v, total = %d; want %v, %d", ids, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestListTracks_HostileInput(t *testing.T) {
+	repo := setupListRepo(t)
+
+	// Wildcards and quotes match literally; nothing reaches the SQL text
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []int64
+	}{
+		{"percent", ListOptions{Query: "%"}, []int64{3}},
+		{"underscore", ListOptions{Query: "_", Status: ListStatusAll}, []int64{4}},
+		{"backslash", ListOptions{Query: `\`}, []int64{}},
+		{"quote", ListOptions{Query: "O'Brien"}, []int64{5}},
+		{"tautology", ListOptions{Query: "' OR '1'='1"}, []int64{}},
+		{"drop table", ListOptions{Query: "'); DROP TABLE tracks; --"}, []int64{}},
+		{"mood injection", ListOptions{Mood: "focus' OR 1=1 --"}, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, _, err := repo.ListTracks(tt.opts)
+			if err != nil {
+				t.Fatalf("ListTracks failed: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	for _, opts := range []ListOptions{
+		{Status: "approved' OR 1=1 --"},
+		{Sort: "t.id; DROP TABLE tracks"},
+	} {
+		if _, _, err := repo.ListTracks(opts); !errors.Is(err, ErrInvalidListOptions) {
+			t.Errorf("ListTracks(%+v) error = %v, want ErrInvalidListOptions", opts, err)
+		}
+	}
+
+	if _, total, err := repo.ListTracks(ListOptions{Status: ListStatusAll}); err != nil || total != 5 {
+		t.Errorf("tracks after hostile input = %d, %v; want all 5 intact", total, err)
+	}
+}
@@ -0,0 +1,88 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ListenerState is the last track and position an anonymous listener
+// reported for a mood
+type ListenerState struct {
+	ListenerID      string
+	Mood            string
+	TrackID         int64
+	PositionSeconds int
+	UpdatedAt       time.Time
+}
+
+// SaveListenerState records a listener's position in a mood, replacing any
+// earlier state for that mood. UpdatedAt is stamped with the repository clock.
+func (r *Repository) SaveListenerState(s ListenerState) error {
+	query := `
+		INSERT INTO listener_state (listener_id, mood, track_id, position_seconds, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (listener_id, mood) DO UPDATE SET
+			track_id = excluded.track_id,
+			position_seconds = excluded.position_seconds,
+			updated_at = excluded.updated_at
+	`
+	_, err := r.db.Exec(query, s.ListenerID, s.Mood, s.TrackID, s.PositionSeconds, r.now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save listener state: %w", err)
+	}
+	return nil
+}
+
+// GetListenerState returns a listener's state for a mood if it was updated
+// within maxAge, or nil if there is none that recent.
+func (r *Repository) GetListenerState(listenerID, mood string, maxAge time.Duration) (*ListenerState, error) {
+	query := `
+		SELECT listener_id, mood, track_id, position_seconds, updated_at
+		FROM listener_state
+		WHERE listener_id = ? AND mood = ? AND updated_at >= ?
+	`
+	since := r.now().Add(-maxAge).UTC().Format(time.RFC3339)
+
+	var s ListenerState
+	err := r.db.QueryRow(query, listenerID, mood, since).Scan(&s.ListenerID, &s.Mood, &s.TrackID, &s.PositionSeconds, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listener state: %w", err)
+	}
+	return &s, nil
+}
+
+// ResumePoint is the track a listener's latest listen event was for
+type ResumePoint struct {
+	TrackID          int64
+	Mood             string
+	PlaylistPosition *int
+	At               time.Time
+}
+
+// GetLastPosition returns the track, mood, and playlist position of the
+// listener's most recent listen event in any mood, or nil if the listener
+// has none
+func (r *Repository) GetLastPosition(clientID string) (*ResumePoint, error) {
+	query := `
+		SELECT track_id, mood, playlist_position, created_at
+		FROM listen_events
+		WHERE client_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var p ResumePoint
+	err := r.db.QueryRow(query, clientID).Scan(&p.TrackID, &p.Mood, &p.PlaylistPosition, &p.At)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last position: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,98 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestListenerState_SaveAndGet(t *testing.T) {
+	repo := setupTestRepo(t)
+	now := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	repo.SetClock(func() time.Time { return now })
+
+	if got, err := repo.GetListenerState("l1", "focus", 24*time.Hour); err != nil || got != nil {
+		t.Fatalf("GetListenerState() = %+v, %v; want nil before any save", got, err)
+	}
+
+	_ = repo.SaveListenerState(ListenerState{ListenerID: "l1", Mood: "focus", TrackID: 1, PositionSeconds: 30})
+	now = now.Add(time.Minute)
+	if err := repo.SaveListenerState(ListenerState{ListenerID: "l1", Mood: "focus", TrackID: 2, PositionSeconds: 95}); err != nil {
+		t.Fatalf("SaveListenerState failed: %v", err)
+	}
+	_ = repo.SaveListenerState(ListenerState{ListenerID: "l1", Mood: "calm", TrackID: 3, PositionSeconds: 10})
+
+	got, err := repo.GetListenerState("l1", "focus", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetListenerState failed: %v", err)
+	}
+	if got == nil || got.TrackID != 2 || got.PositionSeconds != 95 || !got.UpdatedAt.Equal(now) {
+		t.Errorf("focus state = %+v, want track 2 at 95s updated %s", got, now)
+	}
+	if other, _ := repo.GetListenerState("l2", "focus", 24*time.Hour); other != nil {
+		t.Errorf("another listener's state = %+v, want nil", other)
+	}
+}
+
+func TestListenerState_Expiry(t *testing.T) {
+	repo := setupTestRepo(t)
+	now := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	repo.SetClock(func() time.Time { return now })
+
+	_ = repo.SaveListenerState(ListenerState{ListenerID: "l1", Mood: "focus", TrackID: 1, PositionSeconds: 30})
+
+	now = now.Add(24 * time.Hour)
+	if got, _ := repo.GetListenerState("l1", "focus", 24*time.Hour); got == nil {
+		t.Error("state exactly maxAge old should still be returned")
+	}
+	now = now.Add(time.Second)
+	if got, _ := repo.GetListenerState("l1", "focus", 24*time.Hour); got != nil {
+		t.Errorf("expired state = %+v, want nil", got)
+	}
+}
+
+func TestGetLastPosition(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if got, err := repo.GetLastPosition("l1"); err != nil || got != nil {
+		t.Fatalf("GetLastPosition() = %+v, %v; want nil without history", got, err)
+	}
+
+	pos := func(n int) *int { return &n }
+	events := []struct {
+		evt ListenEvent
+		at  string
+	}{
+		{ListenEvent{TrackID: 1, Mood: "focus", EventType: EventPlay, PlaylistPosition: pos(0), ClientID: "l1"}, "2026-06-01 20:00:00"},
+		{ListenEvent{TrackID: 3, Mood: "calm", EventType: EventComplete, PlaylistPosition: pos(4), ClientID: "l1"}, "2026-06-01 20:10:00"},
+		{ListenEvent{TrackID: 2, Mood: "focus", EventType: EventSkip, PlaylistPosition: pos(1), ClientID: "l1"}, "2026-06-01 20:05:00"},
+		{ListenEvent{TrackID: 2, Mood: "focus", EventType: EventPlay, PlaylistPosition: pos(9), ClientID: "l2"}, "2026-06-01 21:00:00"},
+		{ListenEvent{TrackID: 1, Mood: "focus", EventType: EventPlay}, "2026-06-01 22:00:00"},
+	}
+	for _, e := range events {
+		tx, err := repo.BeginTx(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.RecordListenEventTx(tx, e.evt); err != nil {
+			t.Fatalf("RecordListenEventTx failed: %v", err)
+		}
+		if _, err := tx.Exec(`UPDATE listen_events SET created_at = ? WHERE id = last_insert_rowid()`, e.at); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.GetLastPosition("l1")
+	if err != nil {
+		t.Fatalf("GetLastPosition failed: %v", err)
+	}
+	if got == nil || got.TrackID != 3 || got.Mood != "calm" || got.PlaylistPosition == nil || *got.PlaylistPosition != 4 {
+		t.Errorf("last position = %+v, want track 3 in calm at position 4", got)
+	}
+	if want := time.Date(2026, 6, 1, 20, 10, 0, 0, time.UTC); got != nil && !got.At.Equal(want) {
+		t.Errorf("At = %s, want %s", got.At, want)
+	}
+}
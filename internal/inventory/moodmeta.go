@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/mood"
+)
+
+// Limits on admin-edited mood metadata
+const (
+	MaxMoodIconLength        = 64
+	MaxMoodDescriptionLength = 280
+)
+
+// MoodMeta is the stored presentation metadata for a mood. Empty fields are
+// unset and fall back to the configured or generated defaults.
+type MoodMeta struct {
+	Mood        string    `json:"mood"`
+	Color       string    `json:"color"`
+	Icon        string    `json:"icon"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GetMoodMeta returns the stored metadata of every mood that has any, keyed
+// by mood
+func (r *Repository) GetMoodMeta() (map[string]MoodMeta, error) {
+	rows, err := r.db.Query(`SELECT mood, color, icon, description, updated_at FROM mood_meta`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mood meta: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	meta := make(map[string]MoodMeta)
+	for rows.Next() {
+		var m MoodMeta
+		if err := rows.Scan(&m.Mood, &m.Color, &m.Icon, &m.Description, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan mood meta: %w", err)
+		}
+		meta[m.Mood] = m
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating mood meta: %w", err)
+	}
+
+	return meta, nil
+}
+
+// SetMoodMeta replaces a mood's metadata, stamping UpdatedAt with the
+// repository clock. Returns ErrInvalidField (wrapped) for a color that isn't
+// a hex color or an over-long icon or description.
+func (r *Repository) SetMoodMeta(m MoodMeta) error {
+	if m.Color != "" && !mood.ValidColor(m.Color) {
+		return fmt.Errorf("%w: color must be a hex color like #3b82f6", ErrInvalidField)
+	}
+	if len(m.Icon) > MaxMoodIconLength {
+		return fmt.Errorf("%w: icon must be at most %d characters", ErrInvalidField, MaxMoodIconLength)
+	}
+	if len(m.Description) > MaxMoodDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidField, MaxMoodDescriptionLength)
+	}
+
+	query := `
+		INSERT INTO mood_meta (mood, color, icon, description, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (mood) DO UPDATE SET
+			color = excluded.color,
+			icon = excluded.icon,
+			description = excluded.description,
+			updated_at = excluded.updated_at
+	`
+	_, err := r.db.Exec(query, m.Mood, m.Color, m.Icon, m.Description, r.now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save mood meta: %w", err)
+	}
+	return nil
+}
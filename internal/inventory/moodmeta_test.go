@@ -0,0 +1,72 @@
+package inventory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetMoodMeta(t *testing.T) {
+	repo := setupTestRepo(t)
+	first := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	repo.SetClock(func() time.Time { return first })
+
+	meta, err := repo.GetMoodMeta()
+	if err != nil {
+		t.Fatalf("GetMoodMeta failed: %v", err)
+	}
+	if len(meta) != 0 {
+		t.Errorf("got %d entries before any update, want 0", len(meta))
+	}
+
+	if err := repo.SetMoodMeta(MoodMeta{Mood: "focus", Color: "#3b82f6", Icon: "target", Description: "Deep work"}); err != nil {
+		t.Fatalf("SetMoodMeta failed: %v", err)
+	}
+
+	// A second write replaces every field, clearing the ones left empty
+	second := first.Add(time.Hour)
+	repo.SetClock(func() time.Time { return second })
+	if err := repo.SetMoodMeta(MoodMeta{Mood: "focus", Color: "#fff"}); err != nil {
+		t.Fatalf("SetMoodMeta failed: %v", err)
+	}
+
+	meta, err = repo.GetMoodMeta()
+	if err != nil {
+		t.Fatalf("GetMoodMeta failed: %v", err)
+	}
+	got := meta["focus"]
+	if got.Color != "#fff" || got.Icon != "" || got.Description != "" || !got.UpdatedAt.Equal(second) {
+		t.Errorf("focus meta = %+v, want only color #fff updated at %v", got, second)
+	}
+	if len(meta) != 1 {
+		t.Errorf("got %d entries, want 1", len(meta))
+	}
+}
+
+func TestSetMoodMeta_Invalid(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tests := []struct {
+		name string
+		meta MoodMeta
+	}{
+		{"color without hash", MoodMeta{Mood: "focus", Color: "3b82f6"}},
+		{"color name", MoodMeta{Mood: "focus", Color: "blue"}},
+		{"long icon", MoodMeta{Mood: "focus", Icon: strings.Repeat("x", MaxMoodIconLength+1)}},
+		{"long description", MoodMeta{Mood: "focus", Description: strings.Repeat("x", MaxMoodDescriptionLength+1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.SetMoodMeta(tt.meta); !errors.Is(err, ErrInvalidField) {
+				t.Errorf("SetMoodMeta error = %v, want ErrInvalidField", err)
+			}
+		})
+	}
+
+	meta, _ := repo.GetMoodMeta()
+	if len(meta) != 0 {
+		t.Errorf("invalid updates were stored: %+v", meta)
+	}
+}
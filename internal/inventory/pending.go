@@ -0,0 +1,86 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotPending is returned by Approve for a track that isn't awaiting review
+var ErrNotPending = errors.New("track is not pending")
+
+// GetPending returns one page of tracks awaiting review, oldest first. A
+// limit of 0 or less returns every track from offset on.
+func (r *Repository) GetPending(limit, offset int) ([]*Track, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		WHERE t.status = ?
+		ORDER BY t.created_at, t.id
+		LIMIT ? OFFSET ?
+	`, trackColumns, trackFrom)
+
+	rows, err := r.db.Query(query, StatusPending, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tracks := []*Track{}
+	for rows.Next() {
+		st, err := scanTrackRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, st.toTrack())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating tracks: %w", err)
+	}
+
+	return tracks, nil
+}
+
+// Approve moves a pending track to approved, recording the change under
+// actor in the status audit log. Returns ErrTrackNotFound or ErrNotPending
+// (wrapped) when there is no pending track with the ID.
+func (r *Repository) Approve(id int64, actor string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM tracks WHERE id = ?`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: id %d", ErrTrackNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read track status: %w", err)
+	}
+	if status != StatusPending {
+		return fmt.Errorf("%w: id %d is %s", ErrNotPending, id, status)
+	}
+
+	if _, err := tx.Exec(`UPDATE tracks SET status = ? WHERE id = ?`, StatusApproved, id); err != nil {
+		return fmt.Errorf("failed to approve track: %w", err)
+	}
+	change := StatusChange{
+		TrackID:   id,
+		OldStatus: StatusPending,
+		NewStatus: StatusApproved,
+		Actor:     actor,
+	}
+	if err := r.recordStatusChangeTx(tx, change); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit approval: %w", err)
+	}
+	return nil
+}
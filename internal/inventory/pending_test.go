@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetPending(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, mood, duration_seconds, status, created_at) VALUES
+			(1, 'focus/a.mp3', 'focus', 180, 'pending', '2026-03-02 10:00:00'),
+			(2, 'focus/b.mp3', 'focus', 180, 'approved', '2026-03-01 10:00:00'),
+			(3, 'calm/c.mp3', 'calm', 180, 'pending', '2026-03-01 10:00:00'),
+			(4, 'calm/d.mp3', 'calm', 180, 'rejected', '2026-03-01 10:00:00'),
+			(5, 'focus/e.mp3', 'focus', 180, 'pending', '2026-03-03 10:00:00');
+	`)
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		wantIDs       []int64
+	}{
+		{"all, oldest first", 0, 0, []int64{3, 1, 5}},
+		{"first page", 2, 0, []int64{3, 1}},
+		{"second page", 2, 2, []int64{5}},
+		{"past the end", 2, 4, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := repo.GetPending(tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("GetPending failed: %v", err)
+			}
+			if tracks == nil {
+				t.Fatal("GetPending returned nil, want an empty slice")
+			}
+			if len(tracks) != len(tt.wantIDs) {
+				t.Fatalf("got %d tracks, want %d", len(tracks), len(tt.wantIDs))
+			}
+			for i, track := range tracks {
+				if track.ID != tt.wantIDs[i] {
+					t.Errorf("tracks[%d].ID = %d, want %d", i, track.ID, tt.wantIDs[i])
+				}
+				if track.Status != StatusPending {
+					t.Errorf("track %d status = %q, want pending", track.ID, track.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestApprove(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	before, err := repo.GetByMood("focus", TrackFilter{})
+	if err != nil {
+		t.Fatalf("GetByMood failed: %v", err)
+	}
+	for _, track := range before {
+		if track.ID == 4 {
+			t.Fatal("pending track returned before approval")
+		}
+	}
+
+	if err := repo.Approve(4, "alice"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+
+	after, err := repo.GetByMood("focus", TrackFilter{})
+	if err != nil {
+		t.Fatalf("GetByMood failed: %v", err)
+	}
+	if len(after) != len(before)+1 {
+		t.Errorf("got %d focus tracks after approval, want %d", len(after), len(before)+1)
+	}
+
+	history, err := repo.GetStatusHistory(4)
+	if err != nil {
+		t.Fatalf("GetStatusHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].OldStatus != StatusPending || history[0].NewStatus != StatusApproved || history[0].Actor != "alice" {
+		t.Errorf("history = %+v, want one pending -> approved change by alice", history)
+	}
+}
+
+func TestApprove_Errors(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{"missing track", 99, ErrTrackNotFound},
+		{"already approved", 1, ErrNotPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.Approve(tt.id, "alice"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Approve(%d) error = %v, want %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+
+	history, _ := repo.GetStatusHistory(1)
+	if len(history) != 0 {
+		t.Errorf("failed approval recorded history: %+v", history)
+	}
+}
@@ -0,0 +1,128 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Play stats recalculation strategies
+const (
+	// RecalcKeepMax keeps the larger of the stored and recomputed values, so
+	// plays recorded before listen_events existed are not lost
+	RecalcKeepMax = "max"
+	// RecalcEvents treats listen_events as authoritative
+	RecalcEvents = "events"
+)
+
+// ErrInvalidStrategy is returned for an unknown recalculation strategy
+var ErrInvalidStrategy = errors.New("invalid recalculation strategy")
+
+// RecalcResult reports what RecalculatePlayStats did
+type RecalcResult struct {
+	Strategy string `json:"strategy"`
+	Tracks   int    `json:"tracks"`  // tracks with stored stats or counted events
+	Changed  int    `json:"changed"` // play_stats rows written
+}
+
+// playStatsRow pairs a track's stored stats with those recomputed from events.
+// Timestamps are RFC 3339 UTC strings, which order chronologically as text.
+type playStatsRow struct {
+	filePath    string
+	storedCount int
+	storedLast  sql.NullString
+	eventCount  int
+	eventLast   sql.NullString
+}
+
+// RecalculatePlayStats rebuilds play_stats.play_count and last_played_at
+// from non-skip listen events, the same events that increment them live.
+// strategy is RecalcKeepMax or RecalcEvents. Runs in one transaction, and
+// only rows whose values differ are written, so a second run changes nothing.
+func (r *Repository) RecalculatePlayStats(ctx context.Context, strategy string) (*RecalcResult, error) {
+	if strategy != RecalcKeepMax && strategy != RecalcEvents {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStrategy, strategy)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Read everything before writing: the repository has a single connection
+	rows, err := tx.QueryContext(ctx, `
+		SELECT t.file_path,
+			COALESCE(ps.play_count, 0),
+			strftime('%Y-%m-%dT%H:%M:%SZ', ps.last_played_at),
+			COALESCE(ev.plays, 0),
+			ev.last_played_at
+		FROM tracks t
+		LEFT JOIN play_stats ps ON ps.file_path = t.file_path
+		LEFT JOIN (
+			SELECT track_id, COUNT(*) AS plays,
+				strftime('%Y-%m-%dT%H:%M:%SZ', MAX(created_at)) AS last_played_at
+			FROM listen_events
+			WHERE event_type != ?
+			GROUP BY track_id
+		) ev ON ev.track_id = t.id
+		WHERE ps.file_path IS NOT NULL OR ev.track_id IS NOT NULL
+		ORDER BY t.id
+	`, EventSkip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query play stats: %w", err)
+	}
+
+	var stats []playStatsRow
+	for rows.Next() {
+		var s playStatsRow
+		if err := rows.Scan(&s.filePath, &s.storedCount, &s.storedLast, &s.eventCount, &s.eventLast); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan play stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("failed iterating play stats: %w", err)
+	}
+	_ = rows.Close()
+
+	result := &RecalcResult{Strategy: strategy, Tracks: len(stats)}
+	for _, s := range stats {
+		count, last := s.eventCount, s.eventLast
+		if strategy == RecalcKeepMax {
+			count = max(count, s.storedCount)
+			last = laterTimestamp(last, s.storedLast)
+		}
+		if count == s.storedCount && last == s.storedLast {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO play_stats (file_path, play_count, last_played_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT(file_path) DO UPDATE SET
+				play_count = excluded.play_count,
+				last_played_at = excluded.last_played_at
+		`, s.filePath, count, last)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update play stats for %s: %w", s.filePath, err)
+		}
+		result.Changed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit play stats: %w", err)
+	}
+	return result, nil
+}
+
+// laterTimestamp returns the later of two optional RFC 3339 UTC timestamps
+func laterTimestamp(a, b sql.NullString) sql.NullString {
+	if !a.Valid || (b.Valid && b.String > a.String) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,135 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// seedDivergentStats sets up:
+//   - track 1: stored 5 plays, events say 2 (plays predating event collection)
+//   - track 2: stored 1 play, events say 3 (undercounted)
+//   - track 3: no stored stats, 1 complete + 1 skip
+//   - track 4: stored 2 plays, no events at all
+func seedDivergentStats(t *testing.T) *Repository {
+	t.Helper()
+	return openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/a.mp3', 'A', 'focus', 180, 'approved'),
+			(2, 'focus/b.mp3', 'B', 'focus', 180, 'approved'),
+			(3, 'calm/c.mp3', 'C', 'calm', 180, 'approved'),
+			(4, 'calm/d.mp3', 'D', 'calm', 180, 'approved'),
+			(5, 'calm/e.mp3', 'E', 'calm', 180, 'approved');
+		INSERT INTO play_stats (file_path, play_count, last_played_at) VALUES
+			('focus/a.mp3', 5, '2026-03-01T10:00:00Z'),
+			('focus/b.mp3', 1, '2026-01-01T10:00:00Z'),
+			('calm/d.mp3', 2, '2026-02-01T10:00:00Z');
+		INSERT INTO listen_events (track_id, mood, event_type, created_at) VALUES
+			(1, 'focus', 'play', '2026-02-01 09:00:00'),
+			(1, 'focus', 'complete', '2026-02-01 09:03:00'),
+			(1, 'focus', 'skip', '2026-04-01 09:00:00'),
+			(2, 'focus', 'play', '2026-02-01 08:00:00'),
+			(2, 'focus', 'play', '2026-02-02 08:00:00'),
+			(2, 'focus', 'complete', '2026-02-03 08:00:00'),
+			(3, 'calm', 'complete', '2026-02-05 12:00:00'),
+			(3, 'calm', 'skip', '2026-02-06 12:00:00');
+	`)
+}
+
+type storedStats struct {
+	count int
+	last  sql.NullString
+}
+
+func readPlayStats(t *testing.T, repo *Repository) map[string]storedStats {
+	t.Helper()
+	rows, err := repo.db.Query(`SELECT file_path, play_count, last_played_at FROM play_stats`)
+	if err != nil {
+		t.Fatalf("query play_stats: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make(map[string]storedStats)
+	for rows.Next() {
+		var path string
+		var s storedStats
+		if err := rows.Scan(&path, &s.count, &s.last); err != nil {
+			t.Fatalf("scan play_stats: %v", err)
+		}
+		out[path] = s
+	}
+	return out
+}
+
+func TestRecalculatePlayStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    string
+		wantChanged int
+		want        map[string]storedStats
+	}{
+		{
+			name:        "keep max",
+			strategy:    RecalcKeepMax,
+			wantChanged: 2, // b raised, c created; a and d keep their larger stored values
+			want: map[string]storedStats{
+				"focus/a.mp3": {5, sql.NullString{String: "2026-03-01T10:00:00Z", Valid: true}},
+				"focus/b.mp3": {3, sql.NullString{String: "2026-02-03T08:00:00Z", Valid: true}},
+				"calm/c.mp3":  {1, sql.NullString{String: "2026-02-05T12:00:00Z", Valid: true}},
+				"calm/d.mp3":  {2, sql.NullString{String: "2026-02-01T10:00:00Z", Valid: true}},
+			},
+		},
+		{
+			name:        "events authoritative",
+			strategy:    RecalcEvents,
+			wantChanged: 4,
+			want: map[string]storedStats{
+				"focus/a.mp3": {2, sql.NullString{String: "2026-02-01T09:03:00Z", Valid: true}},
+				"focus/b.mp3": {3, sql.NullString{String: "2026-02-03T08:00:00Z", Valid: true}},
+				"calm/c.mp3":  {1, sql.NullString{String: "2026-02-05T12:00:00Z", Valid: true}},
+				"calm/d.mp3":  {0, sql.NullString{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := seedDivergentStats(t)
+
+			result, err := repo.RecalculatePlayStats(context.Background(), tt.strategy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Tracks != 4 || result.Changed != tt.wantChanged {
+				t.Errorf("result = %+v, want 4 tracks, %d changed", result, tt.wantChanged)
+			}
+
+			got := readPlayStats(t, repo)
+			if len(got) != len(tt.want) {
+				t.Errorf("play_stats rows = %d, want %d", len(got), len(tt.want))
+			}
+			for path, want := range tt.want {
+				if got[path] != want {
+					t.Errorf("%s = %+v, want %+v", path, got[path], want)
+				}
+			}
+
+			// A second run finds nothing to change
+			again, err := repo.RecalculatePlayStats(context.Background(), tt.strategy)
+			if err != nil {
+				t.Fatalf("second run: %v", err)
+			}
+			if again.Changed != 0 {
+				t.Errorf("second run changed %d rows, want 0", again.Changed)
+			}
+		})
+	}
+}
+
+func TestRecalculatePlayStats_InvalidStrategy(t *testing.T) {
+	repo := seedDivergentStats(t)
+	if _, err := repo.RecalculatePlayStats(context.Background(), "sum"); !errors.Is(err, ErrInvalidStrategy) {
+		t.Errorf("err = %v, want ErrInvalidStrategy", err)
+	}
+}
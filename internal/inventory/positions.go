@@ -0,0 +1,107 @@
+package inventory
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultMaxPosition is where the position funnel starts pooling later
+// positions into one overflow bucket
+const DefaultMaxPosition = 20
+
+// PositionStats counts listen events at one playlist position
+type PositionStats struct {
+	Position  int  `json:"position"`           // 0-based, as sent by the player
+	Overflow  bool `json:"overflow,omitempty"` // this and every later position
+	Plays     int  `json:"plays"`
+	Skips     int  `json:"skips"`
+	Completes int  `json:"completes"`
+
+	// Retention is plays here over plays at position 0
+	Retention float64 `json:"retention"`
+	// DropOff is the share of plays here not followed by a play at the next
+	// position. Unset where the next bucket isn't a single position, or
+	// nothing was played here.
+	DropOff *float64 `json:"drop_off,omitempty"`
+}
+
+// PositionFunnel is listen-through by playlist position for a mood (or all
+// moods), positions in order with none missing up to the last one seen
+type PositionFunnel struct {
+	Mood      string          `json:"mood,omitempty"`
+	Positions []PositionStats `json:"positions"`
+	// ExcludedEvents had no recorded position
+	ExcludedEvents int `json:"excluded_events"`
+}
+
+// GetPositionFunnel aggregates listen events since the given time by
+// playlist position, for one mood or, when mood is empty, all of them.
+// Positions at or past maxPosition share one overflow bucket.
+func (r *Repository) GetPositionFunnel(mood string, since time.Time, maxPosition int) (*PositionFunnel, error) {
+	where := "WHERE created_at >= ?"
+	args := []any{since.UTC().Format(time.DateTime)}
+	if mood != "" {
+		where += " AND mood = ?"
+		args = append(args, mood)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT MIN(playlist_position, ?) AS pos,
+			SUM(event_type = 'play'), SUM(event_type = 'skip'), SUM(event_type = 'complete')
+		FROM listen_events
+		%s AND playlist_position >= 0
+		GROUP BY pos
+		ORDER BY pos
+	`, where)
+
+	rows, err := r.db.Query(query, append([]any{maxPosition}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query position funnel: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var counted []PositionStats
+	for rows.Next() {
+		var st PositionStats
+		if err := rows.Scan(&st.Position, &st.Plays, &st.Skips, &st.Completes); err != nil {
+			return nil, fmt.Errorf("failed to scan position stats: %w", err)
+		}
+		counted = append(counted, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating position stats: %w", err)
+	}
+
+	funnel := &PositionFunnel{Mood: mood, Positions: fillPositions(counted, maxPosition)}
+	err = r.db.QueryRow(`SELECT COUNT(*) FROM listen_events `+where+` AND playlist_position IS NULL`, args...).
+		Scan(&funnel.ExcludedEvents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events without position: %w", err)
+	}
+	return funnel, nil
+}
+
+// fillPositions turns counts ordered by position into a gapless funnel from
+// position 0, marking the overflow bucket and computing the curve
+func fillPositions(counted []PositionStats, maxPosition int) []PositionStats {
+	positions := []PositionStats{}
+	for _, st := range counted {
+		for len(positions) < st.Position {
+			positions = append(positions, PositionStats{Position: len(positions)})
+		}
+		st.Overflow = st.Position >= maxPosition
+		positions = append(positions, st)
+	}
+
+	for i := range positions {
+		st := &positions[i]
+		if first := positions[0].Plays; first > 0 {
+			st.Retention = float64(st.Plays) / float64(first)
+		}
+		if i+1 < len(positions) && !positions[i+1].Overflow && st.Plays > 0 {
+			dropOff := 1 - float64(positions[i+1].Plays)/float64(st.Plays)
+			st.DropOff = &dropOff
+		}
+	}
+	return positions
+}
@@ -0,0 +1,110 @@
+package inventory
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetPositionFunnel(t *testing.T) {
+	repo := setupTestRepo(t)
+	now := time.Now().UTC()
+	recent := now.Add(-time.Hour).Format(time.DateTime)
+
+	insert := func(mood, event string, position sql.NullInt64, createdAt string) {
+		t.Helper()
+		_, err := repo.db.Exec(
+			`INSERT INTO listen_events (track_id, mood, event_type, playlist_position, created_at) VALUES (1, ?, ?, ?, ?)`,
+			mood, event, position, createdAt,
+		)
+		if err != nil {
+			t.Fatalf("failed to seed event: %v", err)
+		}
+	}
+	at := func(p int64) sql.NullInt64 { return sql.NullInt64{Int64: p, Valid: true} }
+	seedPlays := func(position int64, n int) {
+		for range n {
+			insert("focus", EventPlay, at(position), recent)
+		}
+	}
+
+	// 10 listeners start, 8 reach the second track, 4 the third, nobody the
+	// fourth, 2 the fifth; with a cap of 5, positions 5 and 7 overflow
+	seedPlays(0, 10)
+	seedPlays(1, 8)
+	seedPlays(2, 4)
+	seedPlays(4, 2)
+	seedPlays(5, 1)
+	seedPlays(7, 1)
+	insert("focus", EventSkip, at(0), recent)
+	insert("focus", EventSkip, at(0), recent)
+	insert("focus", EventComplete, at(1), recent)
+	insert("focus", EventPlay, sql.NullInt64{}, recent)                             // no position
+	insert("focus", EventPlay, at(0), now.AddDate(0, 0, -10).Format(time.DateTime)) // too old
+	insert("calm", EventPlay, at(0), recent)                                        // other mood
+	insert("calm", EventSkip, sql.NullInt64{}, recent)                              // other mood, no position
+
+	funnel, err := repo.GetPositionFunnel("focus", now.AddDate(0, 0, -7), 5)
+	if err != nil {
+		t.Fatalf("GetPositionFunnel failed: %v", err)
+	}
+	if funnel.ExcludedEvents != 1 {
+		t.Errorf("ExcludedEvents = %d, want 1", funnel.ExcludedEvents)
+	}
+
+	dropOff := func(f float64) *float64 { return &f }
+	want := []struct {
+		position, plays, skips, completes int
+		overflow                          bool
+		retention                         float64
+		dropOff                           *float64
+	}{
+		{0, 10, 2, 0, false, 1, dropOff(0.2)},
+		{1, 8, 0, 1, false, 0.8, dropOff(0.5)},
+		{2, 4, 0, 0, false, 0.4, dropOff(1)},
+		{3, 0, 0, 0, false, 0, nil},   // gap filled, nothing to drop off from
+		{4, 2, 0, 0, false, 0.2, nil}, // next bucket is overflow
+		{5, 2, 0, 0, true, 0.2, nil},
+	}
+	if len(funnel.Positions) != len(want) {
+		t.Fatalf("got %d positions, want %d: %+v", len(funnel.Positions), len(want), funnel.Positions)
+	}
+	for i, w := range want {
+		got := funnel.Positions[i]
+		if got.Position != w.position || got.Plays != w.plays || got.Skips != w.skips ||
+			got.Completes != w.completes || got.Overflow != w.overflow {
+			t.Errorf("positions[%d] = %+v, want %+v", i, got, w)
+		}
+		if math.Abs(got.Retention-w.retention) > 1e-9 {
+			t.Errorf("positions[%d].Retention = %v, want %v", i, got.Retention, w.retention)
+		}
+		switch {
+		case w.dropOff == nil && got.DropOff != nil:
+			t.Errorf("positions[%d].DropOff = %v, want unset", i, *got.DropOff)
+		case w.dropOff != nil && (got.DropOff == nil || math.Abs(*got.DropOff-*w.dropOff) > 1e-9):
+			t.Errorf("positions[%d].DropOff = %v, want %v", i, got.DropOff, *w.dropOff)
+		}
+	}
+
+	// Without a mood every mood counts
+	all, err := repo.GetPositionFunnel("", now.AddDate(0, 0, -7), 5)
+	if err != nil {
+		t.Fatalf("GetPositionFunnel for all moods failed: %v", err)
+	}
+	if all.Positions[0].Plays != 11 || all.ExcludedEvents != 2 {
+		t.Errorf("all moods: position 0 plays = %d, excluded = %d, want 11, 2", all.Positions[0].Plays, all.ExcludedEvents)
+	}
+}
+
+func TestGetPositionFunnel_NoEvents(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	funnel, err := repo.GetPositionFunnel("focus", time.Now().AddDate(0, 0, -7), 20)
+	if err != nil {
+		t.Fatalf("GetPositionFunnel failed: %v", err)
+	}
+	if funnel.Positions == nil || len(funnel.Positions) != 0 || funnel.ExcludedEvents != 0 {
+		t.Errorf("funnel = %+v, want empty positions", funnel)
+	}
+}
@@ -0,0 +1,191 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// QueueOptions configures an EventQueue
+type QueueOptions struct {
+	Size          int           // channel capacity
+	BatchSize     int           // max events per write transaction
+	FlushInterval time.Duration // max time an event waits before being written
+	Block         bool          // block producers when full instead of dropping
+	WriteDeadline time.Duration // bound on each batch transaction (0 = none)
+}
+
+// EventQueue buffers listen events and writes them in batches from a
+// background goroutine, so play requests don't wait on SQLite's single writer.
+type EventQueue struct {
+	repo *Repository
+	opts QueueOptions
+	ch   chan ListenEvent
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	written  atomic.Int64
+	failed   atomic.Int64
+
+	lastFlush atomic.Int64 // unix nanoseconds of the last batch written, 0 = none
+
+	stopCh  chan struct{}
+	stopped chan struct{}
+}
+
+// NewEventQueue creates a queue and starts its background writer.
+func NewEventQueue(repo *Repository, opts QueueOptions) *EventQueue {
+	q := &EventQueue{
+		repo:    repo,
+		opts:    opts,
+		ch:      make(chan ListenEvent, opts.Size),
+		stopCh:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue adds an event for background writing. When the queue is full it
+// either drops the event or, in block mode, waits until space frees up or
+// ctx is done. Returns false if the event was not queued.
+func (q *EventQueue) Enqueue(ctx context.Context, evt ListenEvent) bool {
+	select {
+	case q.ch <- evt:
+		q.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	if q.opts.Block {
+		select {
+		case q.ch <- evt:
+			q.enqueued.Add(1)
+			return true
+		case <-ctx.Done():
+		case <-q.stopCh:
+		}
+	}
+
+	q.dropped.Add(1)
+	return false
+}
+
+func (q *EventQueue) run() {
+	defer close(q.stopped)
+	ticker := time.NewTicker(q.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ListenEvent, 0, q.opts.BatchSize)
+	for {
+		select {
+		case evt := <-q.ch:
+			batch = append(batch, evt)
+			if len(batch) >= q.opts.BatchSize {
+				q.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				q.flush(batch)
+				batch = batch[:0]
+			}
+		case <-q.stopCh:
+			// Drain whatever is still buffered before exiting
+			for {
+				select {
+				case evt := <-q.ch:
+					batch = append(batch, evt)
+					if len(batch) >= q.opts.BatchSize {
+						q.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						q.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch in one transaction. If the batch fails (e.g. one
+// track was deleted), events are retried individually so one bad event
+// doesn't discard the rest.
+func (q *EventQueue) flush(batch []ListenEvent) {
+	defer q.lastFlush.Store(time.Now().UnixNano())
+	if err := q.writeTx(batch); err == nil {
+		q.written.Add(int64(len(batch)))
+		return
+	}
+	for _, evt := range batch {
+		if err := q.writeTx([]ListenEvent{evt}); err != nil {
+			log.Printf("Error writing queued %s event for track %d: %v", evt.EventType, evt.TrackID, err)
+			q.failed.Add(1)
+			continue
+		}
+		q.written.Add(1)
+	}
+}
+
+// writeTx applies the same writes the synchronous play handler does:
+// play_stats for non-skip events, and a listen event row when the mood is known.
+func (q *EventQueue) writeTx(events []ListenEvent) error {
+	ctx, cancel := WriteContext(context.Background(), q.opts.WriteDeadline)
+	defer cancel()
+
+	return q.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, evt := range events {
+			if evt.EventType != EventSkip {
+				if err := q.repo.UpdatePlayStatsTx(tx, evt.TrackID); err != nil {
+					return err
+				}
+			}
+			if evt.Mood != "" {
+				if err := q.repo.RecordListenEventTx(tx, evt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// LastFlush returns when the queue last wrote a batch, or the zero time if
+// it hasn't yet
+func (q *EventQueue) LastFlush() time.Time {
+	ns := q.lastFlush.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// Stats returns queue statistics for the metrics endpoint.
+func (q *EventQueue) Stats() map[string]any {
+	policy := "drop"
+	if q.opts.Block {
+		policy = "block"
+	}
+	return map[string]any{
+		"depth":    len(q.ch),
+		"capacity": cap(q.ch),
+		"enqueued": q.enqueued.Load(),
+		"dropped":  q.dropped.Load(),
+		"written":  q.written.Load(),
+		"failed":   q.failed.Load(),
+		"on_full":  policy,
+	}
+}
+
+// Close stops accepting blocked producers, flushes buffered events, and
+// waits for the writer to exit.
+func (q *EventQueue) Close() error {
+	close(q.stopCh)
+	<-q.stopped
+	return nil
+}
@@ -0,0 +1,119 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func countListenEvents(t *testing.T, repo *Repository) int {
+	t.Helper()
+	var n int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM listen_events`).Scan(&n); err != nil {
+		t.Fatalf("failed to count listen events: %v", err)
+	}
+	return n
+}
+
+func TestEventQueue_EventuallyPersisted(t *testing.T) {
+	repo := setupTestRepo(t)
+	q := NewEventQueue(repo, QueueOptions{Size: 16, BatchSize: 4, FlushInterval: 10 * time.Millisecond})
+	t.Cleanup(func() { _ = q.Close() })
+
+	events := []ListenEvent{
+		{TrackID: 2, Mood: "focus", EventType: EventPlay},
+		{TrackID: 2, Mood: "focus", EventType: EventComplete, ListenSeconds: 240},
+		{TrackID: 3, Mood: "calm", EventType: EventSkip, ListenSeconds: 12},
+	}
+	for _, evt := range events {
+		if !q.Enqueue(context.Background(), evt) {
+			t.Fatalf("enqueue failed for %+v", evt)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countListenEvents(t, repo) < len(events) {
+		if time.Now().After(deadline) {
+			t.Fatalf("listen events not persisted: got %d, want %d", countListenEvents(t, repo), len(events))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Play and complete bump play_stats; skip does not
+	track, _ := repo.GetByID(2)
+	if track.PlayCount != 2 {
+		t.Errorf("track 2 play_count = %d, want 2", track.PlayCount)
+	}
+	track, _ = repo.GetByID(3)
+	if track.PlayCount != 2 {
+		t.Errorf("track 3 play_count = %d, want 2 (skip must not count)", track.PlayCount)
+	}
+}
+
+func TestEventQueue_CloseFlushesBuffered(t *testing.T) {
+	repo := setupTestRepo(t)
+	// Long interval and large batch: only Close can trigger the write
+	q := NewEventQueue(repo, QueueOptions{Size: 8, BatchSize: 100, FlushInterval: time.Hour})
+
+	q.Enqueue(context.Background(), ListenEvent{TrackID: 1, Mood: "focus", EventType: EventPlay})
+	if !q.LastFlush().IsZero() {
+		t.Error("LastFlush set before any batch was written")
+	}
+	_ = q.Close()
+
+	if got := countListenEvents(t, repo); got != 1 {
+		t.Errorf("listen events after close = %d, want 1", got)
+	}
+	if q.LastFlush().IsZero() {
+		t.Error("LastFlush not set after the closing flush")
+	}
+}
+
+func TestEventQueue_BadEventDoesNotSinkBatch(t *testing.T) {
+	repo := setupTestRepo(t)
+	q := NewEventQueue(repo, QueueOptions{Size: 8, BatchSize: 8, FlushInterval: time.Hour})
+
+	q.Enqueue(context.Background(), ListenEvent{TrackID: 999, Mood: "focus", EventType: EventPlay})
+	q.Enqueue(context.Background(), ListenEvent{TrackID: 1, Mood: "focus", EventType: EventPlay})
+	_ = q.Close()
+
+	stats := q.Stats()
+	if stats["written"].(int64) != 1 || stats["failed"].(int64) != 1 {
+		t.Errorf("written=%v failed=%v, want 1 and 1", stats["written"], stats["failed"])
+	}
+}
+
+func TestEventQueue_DropWhenFull(t *testing.T) {
+	repo := setupTestRepo(t)
+	q := &EventQueue{repo: repo, opts: QueueOptions{Size: 1}, ch: make(chan ListenEvent, 1)}
+
+	evt := ListenEvent{TrackID: 1, Mood: "focus", EventType: EventPlay}
+	if !q.Enqueue(context.Background(), evt) {
+		t.Fatal("first enqueue should succeed")
+	}
+	if q.Enqueue(context.Background(), evt) {
+		t.Error("enqueue into full queue should drop")
+	}
+	if got := q.Stats()["dropped"].(int64); got != 1 {
+		t.Errorf("dropped = %d, want 1", got)
+	}
+}
+
+func TestEventQueue_BlockRespectsContext(t *testing.T) {
+	repo := setupTestRepo(t)
+	q := &EventQueue{
+		repo:   repo,
+		opts:   QueueOptions{Size: 1, Block: true},
+		ch:     make(chan ListenEvent, 1),
+		stopCh: make(chan struct{}),
+	}
+
+	evt := ListenEvent{TrackID: 1, Mood: "focus", EventType: EventPlay}
+	q.Enqueue(context.Background(), evt)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if q.Enqueue(ctx, evt) {
+		t.Error("blocked enqueue should give up when context expires")
+	}
+}
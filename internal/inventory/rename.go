@@ -0,0 +1,67 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrFilePathExists is returned by RenameFilePath when the new path is
+// already taken by another track or its play stats
+var ErrFilePathExists = errors.New("file path already exists")
+
+// RenameFilePath moves a track to a new file path, carrying its play_stats
+// row (keyed by file_path) along so play counts survive the rename. Both
+// tables change in one transaction. Returns ErrTrackNotFound if no track has
+// oldPath and ErrFilePathExists if newPath is in use (both wrapped).
+func (r *Repository) RenameFilePath(oldPath, newPath string) error {
+	if newPath == "" {
+		return fmt.Errorf("%w: empty file path", ErrInvalidField)
+	}
+	if oldPath == newPath {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// play_stats references tracks(file_path); with foreign keys enforced the
+	// two rows are only consistent again once both are updated
+	if _, err := tx.Exec(`PRAGMA defer_foreign_keys = ON`); err != nil {
+		return fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+
+	// An orphaned stats row at newPath belongs to some earlier file, so it
+	// is a conflict too rather than something to merge into
+	var taken int
+	err = tx.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM tracks WHERE file_path = ?)
+			+ (SELECT COUNT(*) FROM play_stats WHERE file_path = ?)
+	`, newPath, newPath).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("failed to check file path: %w", err)
+	}
+	if taken > 0 {
+		return fmt.Errorf("%w: %s", ErrFilePathExists, newPath)
+	}
+
+	res, err := tx.Exec(`UPDATE tracks SET file_path = ? WHERE file_path = ?`, newPath, oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to rename track: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: file path %s", ErrTrackNotFound, oldPath)
+	}
+
+	if _, err := tx.Exec(`UPDATE play_stats SET file_path = ? WHERE file_path = ?`, newPath, oldPath); err != nil {
+		return fmt.Errorf("failed to move play stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+const renameSeed = `
+	INSERT INTO tracks (id, file_path, title, mood, duration_seconds) VALUES
+		(1, 'focus/old.mp3', 'Old Name', 'focus', 180),
+		(2, 'focus/taken.mp3', 'Taken', 'focus', 200),
+		(3, 'calm/unplayed.mp3', 'Unplayed', 'calm', 240);
+	INSERT INTO play_stats (file_path, play_count, last_played_at) VALUES
+		('focus/old.mp3', 7, '2026-01-02 03:04:05'),
+		('focus/taken.mp3', 2, '2026-01-01 00:00:00'),
+		('calm/orphan.mp3', 4, '2025-12-31 00:00:00');
+`
+
+func TestRenameFilePath(t *testing.T) {
+	repo := openTestDB(t, renameSeed)
+
+	// Deferring the foreign key check must let both rows move together
+	if _, err := repo.db.Exec(`PRAGMA foreign_keys = ON`); err != nil {
+		t.Fatalf("failed to enable foreign keys: %v", err)
+	}
+
+	if err := repo.RenameFilePath("focus/old.mp3", "focus/new.mp3"); err != nil {
+		t.Fatalf("RenameFilePath failed: %v", err)
+	}
+
+	track, err := repo.GetByID(1)
+	if err != nil || track == nil {
+		t.Fatalf("GetByID(1) = %v, %v", track, err)
+	}
+	if track.FilePath != "focus/new.mp3" {
+		t.Errorf("file_path = %q, want focus/new.mp3", track.FilePath)
+	}
+	if track.PlayCount != 7 {
+		t.Errorf("play_count = %d after rename, want 7", track.PlayCount)
+	}
+	if track.LastPlayedAt == nil {
+		t.Error("last_played_at lost in rename")
+	}
+
+	var stale int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM play_stats WHERE file_path = 'focus/old.mp3'`).Scan(&stale); err != nil {
+		t.Fatalf("failed to count stats: %v", err)
+	}
+	if stale != 0 {
+		t.Errorf("%d play_stats rows left at the old path", stale)
+	}
+
+	// A track with no plays yet renames without creating stats
+	if err := repo.RenameFilePath("calm/unplayed.mp3", "calm/renamed.mp3"); err != nil {
+		t.Fatalf("RenameFilePath without stats failed: %v", err)
+	}
+	if track, _ := repo.GetByID(3); track.FilePath != "calm/renamed.mp3" || track.PlayCount != 0 {
+		t.Errorf("unplayed track = %s with %d plays, want calm/renamed.mp3 with 0", track.FilePath, track.PlayCount)
+	}
+}
+
+func TestRenameFilePath_Conflicts(t *testing.T) {
+	repo := openTestDB(t, renameSeed)
+
+	tests := []struct {
+		name             string
+		oldPath, newPath string
+		wantErr          error
+	}{
+		{"path of another track", "focus/old.mp3", "focus/taken.mp3", ErrFilePathExists},
+		{"orphaned stats at new path", "focus/old.mp3", "calm/orphan.mp3", ErrFilePathExists},
+		{"unknown old path", "focus/missing.mp3", "focus/new.mp3", ErrTrackNotFound},
+		{"empty new path", "focus/old.mp3", "", ErrInvalidField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.RenameFilePath(tt.oldPath, tt.newPath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RenameFilePath(%q, %q) = %v, want %v", tt.oldPath, tt.newPath, err, tt.wantErr)
+			}
+		})
+	}
+
+	// Nothing moved: both tracks keep their paths and counts
+	for id, want := range map[int64]struct {
+		path  string
+		plays int
+	}{1: {"focus/old.mp3", 7}, 2: {"focus/taken.mp3", 2}} {
+		track, err := repo.GetByID(id)
+		if err != nil || track == nil {
+			t.Fatalf("GetByID(%d) = %v, %v", id, track, err)
+		}
+		if track.FilePath != want.path || track.PlayCount != want.plays {
+			t.Errorf("track %d = %s with %d plays, want %s with %d", id, track.FilePath, track.PlayCount, want.path, want.plays)
+		}
+	}
+
+	// Renaming to the same path is a no-op
+	if err := repo.RenameFilePath("focus/old.mp3", "focus/old.mp3"); err != nil {
+		t.Errorf("same-path rename = %v, want nil", err)
+	}
+}
@@ -5,34 +5,47 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
 // Repository handles track storage operations
 type Repository struct {
-	db *sql.DB
+	db          *sql.DB
+	now         func() time.Time // clock for play timestamps; time.Now outside tests
+	isBusy      func(error) bool // IsBusy outside tests
+	minDuration int              // seconds; shorter tracks stay out of playlists
+	pathPrefix  string           // listener queries only see files under it; see WithScope
+	moods       []string         // listener queries only see these moods; nil = all
 }
 
-// NewRepository creates a new inventory repository
+// NewRepository creates a new inventory repository, creating the database's
+// directory if needed. A new database has no schema; see EnsureSchema.
 func NewRepository(dbPath string) (*Repository, error) {
+	if err := prepareFile(dbPath); err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// Ping opens the file and WAL mode is its first read, so damage that
+	// breaks every query shows up in one of these
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return nil, openFailure(db, dbPath, "failed to ping database", err)
 	}
 
 	// WAL mode allows concurrent reads during writes
 	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
-		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
+		return nil, openFailure(db, dbPath, "failed to set WAL mode", err)
 	}
 	// Wait up to 5s for write lock instead of failing immediately
-	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
-		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout=%d", busyTimeout.Milliseconds())); err != nil {
+		return nil, openFailure(db, dbPath, "failed to set busy timeout", err)
 	}
 
 	// SQLite supports one writer at a time; constrain the pool accordingly
@@ -40,7 +53,29 @@ func NewRepository(dbPath string) (*Repository, error) {
 	db.SetMaxIdleConns(1)
 	db.SetConnMaxLifetime(0)
 
-	return &Repository{db: db}, nil
+	return &Repository{db: db, now: time.Now, isBusy: IsBusy}, nil
+}
+
+// openFailure closes a database NewRepository couldn't set up and reports
+// why, as ErrCorrupt when SQLite found the file damaged
+func openFailure(db *sql.DB, dbPath, msg string, err error) error {
+	_ = db.Close()
+	if isCorruptionError(err) {
+		return fmt.Errorf("%w: %s: %v (restore it from a backup)", ErrCorrupt, dbPath, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// SetClock replaces the clock used to timestamp plays, so tests can pin it
+func (r *Repository) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// SetMinDuration keeps tracks shorter than seconds out of the mood queries
+// playlists are built from (GetByMood and its count, sample and ID
+// variants). Admin listings are unaffected; 0 disables.
+func (r *Repository) SetMinDuration(seconds int) {
+	r.minDuration = seconds
 }
 
 // Close closes the database connection
@@ -56,8 +91,9 @@ func (r *Repository) Ping() error {
 // trackColumns is the standard column list for track queries.
 // Play data comes from play_stats via LEFT JOIN (see trackFrom).
 const trackColumns = `t.id, t.file_path, t.title, t.artist, t.mood, t.energy, t.tempo_bpm, t.has_vocals,
-	t.musical_key, t.intensity, t.time_affinity, t.lyrics, t.duration_seconds,
-	t.status, COALESCE(ps.play_count, 0), ps.last_played_at, t.created_at`
+	t.musical_key, t.intensity, t.time_affinity, t.lyrics, t.duration_seconds, t.content_hash,
+	t.fade_in_ms, t.fade_out_ms, t.file_missing_at, t.status, t.rollout_percent, COALESCE(ps.play_count, 0), ps.last_played_at, t.created_at,
+	(SELECT group_concat(tg.tag, ',') FROM track_tags tg WHERE tg.track_id = t.id)`
 
 const trackFrom = `FROM tracks t LEFT JOIN play_stats ps ON t.file_path = ps.file_path`
 
@@ -78,10 +114,16 @@ func scanTrackRow(row interface{ Scan(...any) error }) (*scanTrack, error) {
 		&st.TimeAffinity,
 		&st.Lyrics,
 		&st.DurationSeconds,
+		&st.ContentHash,
+		&st.FadeInMs,
+		&st.FadeOutMs,
+		&st.FileMissingAt,
 		&st.Status,
+		&st.RolloutPercent,
 		&st.PlayCount,
 		&st.LastPlayedAt,
 		&st.CreatedAt,
+		&st.Tags,
 	)
 	return &st, err
 }
@@ -101,20 +143,173 @@ func (r *Repository) GetByID(id int64) (*Track, error) {
 	return st.toTrack(), nil
 }
 
-// GetByMood retrieves all approved tracks for a mood.
-// If instrumentalOnly is true, only tracks with has_vocals=0 are returned.
-func (r *Repository) GetByMood(mood string, instrumentalOnly bool) ([]*Track, error) {
+// GetByMood retrieves all approved tracks for a mood, narrowed by filter and
+// in the filter's sort order.
+func (r *Repository) GetByMood(mood string, filter TrackFilter) ([]*Track, error) {
+	where, args := r.moodWhere(mood, filter)
+	return r.queryTracks(where, trackOrder(filter.Sort), args)
+}
+
+// CountByMood returns how many approved tracks GetByMood would return
+func (r *Repository) CountByMood(mood string, filter TrackFilter) (int, error) {
+	where, args := r.moodWhere(mood, filter)
+
+	var n int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM tracks t `+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count tracks: %w", err)
+	}
+	return n, nil
+}
+
+// SampleByMood returns up to n approved tracks for a mood chosen uniformly at
+// random, narrowed by filter. Only track IDs are shuffled inside SQLite, so
+// the full row (joins, tags) is built for the n sampled tracks alone.
+func (r *Repository) SampleByMood(mood string, filter TrackFilter, n int) ([]*Track, error) {
+	inner, args := r.moodWhere(mood, filter)
+	where := fmt.Sprintf(`WHERE t.id IN (SELECT t.id FROM tracks t %s ORDER BY random() LIMIT ?)`, inner)
+	return r.queryTracks(where, leastPlayedOrder, append(args, n))
+}
+
+// GetRandom returns up to n approved tracks from any mood, chosen uniformly
+// at random. ORDER BY random() still reads and sorts every approved track
+// ID, so this is O(tracks) per call; fine for a library of thousands, not
+// for millions.
+func (r *Repository) GetRandom(n int) ([]*Track, error) {
+	inner := "WHERE t.status = ?"
+	args := []any{StatusApproved}
+	if r.minDuration > 0 {
+		inner += " AND t.duration_seconds >= ?"
+		args = append(args, r.minDuration)
+	}
+	clause, scopeArgs := r.scopeClause("t.")
+	inner += clause
+	args = append(args, scopeArgs...)
+	where := fmt.Sprintf(`WHERE t.id IN (SELECT t.id FROM tracks t %s ORDER BY random() LIMIT ?)`, inner)
+	return r.queryTracks(where, "random()", append(args, n))
+}
+
+// TrackIDsByMood returns the IDs of the tracks GetByMood would return, in ID
+// order, without building the rows
+func (r *Repository) TrackIDsByMood(mood string, filter TrackFilter) ([]int64, error) {
+	where, args := r.moodWhere(mood, filter)
+
+	rows, err := r.db.Query(`SELECT t.id FROM tracks t `+where+` ORDER BY t.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query track IDs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan track ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating track IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// GetByIDs retrieves the tracks with the given IDs, in the order given. IDs
+// without a track are skipped.
+func (r *Repository) GetByIDs(ids []int64) ([]*Track, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	where := `WHERE t.id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+	found, err := r.queryTracks(where, `t.id`, args)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*Track, len(found))
+	for _, t := range found {
+		byID[t.ID] = t
+	}
+	tracks := make([]*Track, 0, len(found))
+	for _, id := range ids {
+		if t, ok := byID[id]; ok {
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks, nil
+}
+
+// moodWhere builds the WHERE clause selecting a mood's approved tracks (and
+// beta tracks rolled out to the filter's bucket), of at least the minimum
+// duration, under filter, over the tracks table aliased as t
+func (r *Repository) moodWhere(mood string, filter TrackFilter) (string, []any) {
 	where := "WHERE t.mood = ? AND t.status = ?"
 	args := []any{mood, StatusApproved}
-	if instrumentalOnly {
+	if filter.IncludeBeta {
+		where = "WHERE t.mood = ? AND (t.status = ? OR (t.status = ? AND ? < t.rollout_percent))"
+		args = append(args, StatusBeta, filter.RolloutBucket)
+	}
+	if r.minDuration > 0 {
+		where += " AND t.duration_seconds >= ?"
+		args = append(args, r.minDuration)
+	}
+	clause, scopeArgs := r.scopeClause("t.")
+	where += clause
+	args = append(args, scopeArgs...)
+	if filter.InstrumentalOnly {
 		where += " AND t.has_vocals = 0"
 	}
+	if len(filter.Tags) > 0 {
+		clause, tagArgs := tagsClause(filter.Tags)
+		where += " AND " + clause
+		args = append(args, tagArgs...)
+	}
+	// NULL intensity or tempo fails both comparisons, so bounded queries skip unrated tracks
+	if filter.IntensityMin > 0 {
+		where += " AND t.intensity >= ?"
+		args = append(args, filter.IntensityMin)
+	}
+	if filter.IntensityMax > 0 {
+		where += " AND t.intensity <= ?"
+		args = append(args, filter.IntensityMax)
+	}
+	if filter.BPMMin > 0 {
+		where += " AND t.tempo_bpm >= ?"
+		args = append(args, filter.BPMMin)
+	}
+	if filter.BPMMax > 0 {
+		where += " AND t.tempo_bpm <= ?"
+		args = append(args, filter.BPMMax)
+	}
+	return where, args
+}
+
+// leastPlayedOrder is the default track order: least-played first
+const leastPlayedOrder = `COALESCE(ps.play_count, 0) ASC, ps.last_played_at ASC NULLS FIRST`
+
+// trackOrder returns the ORDER BY terms for a TrackFilter sort
+func trackOrder(sort string) string {
+	switch sort {
+	case SortBPMAsc:
+		return `t.tempo_bpm ASC NULLS LAST, t.id ASC`
+	case SortBPMDesc:
+		return `t.tempo_bpm DESC NULLS LAST, t.id ASC`
+	default:
+		return leastPlayedOrder
+	}
+}
 
+// queryTracks runs a track query with the given WHERE clause and ORDER BY terms.
+func (r *Repository) queryTracks(where, orderBy string, args []any) ([]*Track, error) {
 	query := fmt.Sprintf(`
 		SELECT %s %s
 		%s
-		ORDER BY COALESCE(ps.play_count, 0) ASC, ps.last_played_at ASC NULLS FIRST
-	`, trackColumns, trackFrom, where)
+		ORDER BY %s
+	`, trackColumns, trackFrom, where, orderBy)
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
@@ -138,6 +333,107 @@ func (r *Repository) GetByMood(mood string, instrumentalOnly bool) ([]*Track, er
 	return tracks, nil
 }
 
+// streamPageSize is how many tracks StreamTracks reads per query
+const streamPageSize = 500
+
+// StreamTracks calls fn for each approved track in ID order, without
+// loading the whole inventory into memory. Tracks are read in keyset pages
+// (id > last ID seen) and each page's rows are closed before fn sees them,
+// so a slow fn never holds the repository's single connection. Returning an
+// error from fn stops the scan and is returned as-is.
+func (r *Repository) StreamTracks(fn func(*Track) error) error {
+	var after int64
+	for {
+		page, err := r.streamPage(after)
+		if err != nil {
+			return err
+		}
+		for _, track := range page {
+			if err := fn(track); err != nil {
+				return err
+			}
+		}
+		if len(page) < streamPageSize {
+			return nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// streamPage reads the next page of approved tracks with IDs above after
+func (r *Repository) streamPage(after int64) ([]*Track, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE t.status = ? AND t.id > ? ORDER BY t.id LIMIT ?`, trackColumns, trackFrom)
+
+	rows, err := r.db.Query(query, StatusApproved, after, streamPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	page := make([]*Track, 0, streamPageSize)
+	for rows.Next() {
+		st, err := scanTrackRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		page = append(page, st.toTrack())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating tracks: %w", err)
+	}
+
+	return page, nil
+}
+
+// InvalidTracks returns tracks of any status whose duration_seconds is zero
+// or negative, or whose audio file was found missing, in ID order.
+// Playlists, M3U output, and completion analytics all assume a positive
+// duration, so these need re-probing; missing files need restoring.
+func (r *Repository) InvalidTracks() ([]*Track, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE t.duration_seconds <= 0 OR t.file_missing_at IS NOT NULL ORDER BY t.id`,
+		trackColumns, trackFrom)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invalid tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tracks := []*Track{}
+	for rows.Next() {
+		st, err := scanTrackRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, st.toTrack())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating tracks: %w", err)
+	}
+
+	return tracks, nil
+}
+
+// SetFileMissing records that a track's audio file was found missing at
+// since, or clears the flag when since is zero. Returns ErrTrackNotFound
+// (wrapped) for an unknown ID.
+func (r *Repository) SetFileMissing(id int64, since time.Time) error {
+	var at any
+	if !since.IsZero() {
+		at = since.UTC().Format(time.DateTime)
+	}
+	res, err := r.db.Exec(`UPDATE tracks SET file_missing_at = ? WHERE id = ?`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to flag missing file: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: id %d", ErrTrackNotFound, id)
+	}
+	return nil
+}
+
 // UpdatePlayStats increments play count in the play_stats table.
 // Uses a single INSERT...SELECT to atomically resolve file_path and UPSERT.
 func (r *Repository) UpdatePlayStats(id int64) error {
@@ -149,7 +445,7 @@ func (r *Repository) UpdatePlayStats(id int64) error {
 			play_count = play_count + 1,
 			last_played_at = excluded.last_played_at
 	`
-	result, err := r.db.Exec(query, time.Now().UTC().Format(time.RFC3339), id)
+	result, err := r.db.Exec(query, r.now().UTC().Format(time.RFC3339), id)
 	if err != nil {
 		return fmt.Errorf("failed to update play stats: %w", err)
 	}
@@ -170,6 +466,93 @@ func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
 	return r.db.BeginTx(ctx, nil)
 }
 
+// txAttempts is how many times WithTx runs a transaction that keeps failing
+// with SQLITE_BUSY; attempts after the first wait txRetryBackoff times the
+// attempt number
+const (
+	txAttempts     = 3
+	txRetryBackoff = 50 * time.Millisecond
+)
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back if it fails or panics (the panic is re-raised). A transaction that
+// fails with SQLITE_BUSY is retried from the start, so fn must not have
+// effects outside tx; retries stop once ctx is done.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := r.runTx(ctx, fn)
+		if err == nil || !r.isBusy(err) || attempt == txAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
+	}
+}
+
+func (r *Repository) runTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Also runs while a panic unwinds; a no-op after Commit
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// busyTimeout is how long SQLite waits for another connection's write lock
+const busyTimeout = 5 * time.Second
+
+// sqliteBusy is SQLITE_BUSY, the primary result code for a lock wait that
+// ran out of busy_timeout
+const sqliteBusy = 5
+
+// DefaultWriteDeadline bounds a write transaction. It is under busyTimeout
+// and the server's write timeout so a stuck writer fails the request
+// instead of hanging it.
+const DefaultWriteDeadline = 3 * time.Second
+
+// SetWriteDeadline lowers busy_timeout to d when d is shorter. SQLite's lock
+// wait does not observe context cancellation, so this is what bounds a
+// write blocked by another process; WriteContext bounds the wait for the
+// pool's single connection. busy_timeout is a connection setting, so the
+// lower wait applies to every write through r, admin edits included, not
+// only play transactions.
+func (r *Repository) SetWriteDeadline(d time.Duration) error {
+	if d <= 0 || d >= busyTimeout {
+		return nil
+	}
+	if _, err := r.db.Exec(fmt.Sprintf("PRAGMA busy_timeout=%d", d.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set busy timeout: %w", err)
+	}
+	return nil
+}
+
+// IsBusy reports whether err is SQLite giving up on a locked database
+func IsBusy(err error) bool {
+	var se *sqlite.Error
+	return errors.As(err, &se) && se.Code()&0xff == sqliteBusy
+}
+
+// WriteContext derives the context for a write transaction. A zero
+// deadline returns ctx unchanged. The transaction rolls back if the
+// deadline passes before Commit.
+func WriteContext(ctx context.Context, deadline time.Duration) (context.Context, context.CancelFunc) {
+	if deadline <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, deadline)
+}
+
 // UpdatePlayStatsTx increments play count within an existing transaction
 func (r *Repository) UpdatePlayStatsTx(tx *sql.Tx, id int64) error {
 	query := `
@@ -180,7 +563,7 @@ func (r *Repository) UpdatePlayStatsTx(tx *sql.Tx, id int64) error {
 			play_count = play_count + 1,
 			last_played_at = excluded.last_played_at
 	`
-	result, err := tx.Exec(query, time.Now().UTC().Format(time.RFC3339), id)
+	result, err := tx.Exec(query, r.now().UTC().Format(time.RFC3339), id)
 	if err != nil {
 		return fmt.Errorf("failed to update play stats: %w", err)
 	}
@@ -199,10 +582,11 @@ func (r *Repository) UpdatePlayStatsTx(tx *sql.Tx, id int64) error {
 // RecordListenEventTx inserts a listen event within an existing transaction
 func (r *Repository) RecordListenEventTx(tx *sql.Tx, evt ListenEvent) error {
 	query := `
-		INSERT INTO listen_events (track_id, mood, event_type, listen_seconds, playlist_position)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO listen_events (track_id, mood, event_type, listen_seconds, playlist_position, session_id, platform, app_version, client_id, playlist_id, station, reason)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))
 	`
-	_, err := tx.Exec(query, evt.TrackID, evt.Mood, evt.EventType, evt.ListenSeconds, evt.PlaylistPosition)
+	_, err := tx.Exec(query, evt.TrackID, evt.Mood, evt.EventType, evt.ListenSeconds, evt.PlaylistPosition, evt.SessionID,
+		evt.Client.Platform, evt.Client.AppVersion, evt.ClientID, evt.PlaylistID, evt.Station, evt.Reason)
 	if err != nil {
 		return fmt.Errorf("failed to record listen event: %w", err)
 	}
@@ -216,17 +600,21 @@ type MoodStats struct {
 	TotalSeconds int
 }
 
-// GetMoodStats returns track count and total duration per mood
+// GetMoodStats returns track count and total duration per mood. Zero and
+// negative durations, left by bad probes, are out of the total rather than
+// dragging it down; see DurationReport.
 func (r *Repository) GetMoodStats() ([]MoodStats, error) {
+	clause, args := r.scopeClause("")
 	query := `
-		SELECT mood, COUNT(*) as track_count, COALESCE(SUM(duration_seconds), 0) as total_seconds
+		SELECT mood, COUNT(*) as track_count,
+			COALESCE(SUM(CASE WHEN duration_seconds > 0 THEN duration_seconds END), 0) as total_seconds
 		FROM tracks
-		WHERE status = ?
+		WHERE status = ?` + clause + `
 		GROUP BY mood
 		ORDER BY mood
 	`
 
-	rows, err := r.db.Query(query, StatusApproved)
+	rows, err := r.db.Query(query, append([]any{StatusApproved}, args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query mood stats: %w", err)
 	}
@@ -1,8 +1,13 @@
 package inventory
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/1mb-dev/driftfm/internal/testutil"
 	_ "modernc.org/sqlite"
@@ -62,7 +67,7 @@ func TestGetByMood(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tracks, err := repo.GetByMood(tt.mood, false)
+			tracks, err := repo.GetByMood(tt.mood, TrackFilter{})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -80,7 +85,7 @@ func TestGetByMood_InstrumentalOnly(t *testing.T) {
 	repo := setupTestRepo(t)
 
 	// Focus has 2 approved: track1 (instrumental), track2 (vocals)
-	all, err := repo.GetByMood("focus", false)
+	all, err := repo.GetByMood("focus", TrackFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -88,7 +93,7 @@ func TestGetByMood_InstrumentalOnly(t *testing.T) {
 		t.Fatalf("got %d tracks, want 2", len(all))
 	}
 
-	instrumental, err := repo.GetByMood("focus", true)
+	instrumental, err := repo.GetByMood("focus", TrackFilter{InstrumentalOnly: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -100,6 +105,228 @@ func TestGetByMood_InstrumentalOnly(t *testing.T) {
 	}
 }
 
+func TestGetByMood_MinDuration(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/song.mp3', 'Song', 'focus', 180, 'approved'),
+			(2, 'focus/stinger.mp3', 'Stinger', 'focus', 4, 'approved'),
+			(3, 'focus/edge.mp3', 'Edge', 'focus', 30, 'approved');
+	`)
+
+	// No minimum by default
+	if tracks, _ := repo.GetByMood("focus", TrackFilter{}); len(tracks) != 3 {
+		t.Fatalf("got %d tracks without a minimum, want 3", len(tracks))
+	}
+
+	repo.SetMinDuration(30)
+	tracks, err := repo.GetByMood("focus", TrackFilter{})
+	if err != nil {
+		t.Fatalf("GetByMood failed: %v", err)
+	}
+	ids := make([]int64, len(tracks))
+	for i, tr := range tracks {
+		ids[i] = tr.ID
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("ids = %v, want [1 3] (the 4s stinger filtered out)", ids)
+	}
+	if n, _ := repo.CountByMood("focus", TrackFilter{}); n != 2 {
+		t.Errorf("CountByMood = %d, want 2", n)
+	}
+	if ids, _ := repo.TrackIDsByMood("focus", TrackFilter{}); !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("TrackIDsByMood = %v, want [1 3]", ids)
+	}
+
+	// Admin listings still show the stinger
+	if _, total, _ := repo.ListTracks(ListOptions{Mood: "focus"}); total != 3 {
+		t.Errorf("ListTracks total = %d, want 3", total)
+	}
+}
+
+func TestGetByMood_IntensityRange(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status, has_vocals, intensity) VALUES
+			(1, 'focus/i1.mp3', 'I1', 'focus', 180, 'approved', 0, 1),
+			(2, 'focus/i3.mp3', 'I3', 'focus', 180, 'approved', 1, 3),
+			(3, 'focus/i6.mp3', 'I6', 'focus', 180, 'approved', 0, 6),
+			(4, 'focus/i10.mp3', 'I10', 'focus', 180, 'approved', 0, 10),
+			(5, 'focus/unrated.mp3', 'Unrated', 'focus', 180, 'approved', 0, NULL);
+	`)
+
+	tests := []struct {
+		name    string
+		filter  TrackFilter
+		wantIDs []int64
+	}{
+		{"no bounds includes unrated", TrackFilter{}, []int64{1, 2, 3, 4, 5}},
+		{"inclusive range", TrackFilter{IntensityMin: 3, IntensityMax: 6}, []int64{2, 3}},
+		{"min only", TrackFilter{IntensityMin: 6}, []int64{3, 4}},
+		{"max only", TrackFilter{IntensityMax: 3}, []int64{1, 2}},
+		{"scale edges", TrackFilter{IntensityMin: 1, IntensityMax: 10}, []int64{1, 2, 3, 4}},
+		{"single value", TrackFilter{IntensityMin: 10, IntensityMax: 10}, []int64{4}},
+		{"composes with instrumental", TrackFilter{InstrumentalOnly: true, IntensityMin: 3, IntensityMax: 6}, []int64{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := repo.GetByMood("focus", tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func setupTempoRepo(t *testing.T) *Repository {
+	t.Helper()
+	return openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status, has_vocals, tempo_bpm) VALUES
+			(1, 'energize/b128.mp3', 'B128', 'energize', 180, 'approved', 0, 128),
+			(2, 'energize/b95.mp3', 'B95', 'energize', 180, 'approved', 1, 95),
+			(3, 'energize/b100.mp3', 'B100', 'energize', 180, 'approved', 0, 100),
+			(4, 'energize/b130.mp3', 'B130', 'energize', 180, 'approved', 1, 130),
+			(5, 'energize/untimed.mp3', 'Untimed', 'energize', 180, 'approved', 0, NULL),
+			(6, 'energize/b100b.mp3', 'B100b', 'energize', 180, 'approved', 0, 100);
+		INSERT INTO play_stats (file_path, play_count) VALUES
+			('energize/b100.mp3', 9);
+	`)
+}
+
+func TestGetByMood_BPMRange(t *testing.T) {
+	repo := setupTempoRepo(t)
+
+	tests := []struct {
+		name    string
+		filter  TrackFilter
+		wantIDs []int64
+	}{
+		{"no bounds includes untimed", TrackFilter{}, []int64{1, 2, 3, 4, 5, 6}},
+		{"inclusive range", TrackFilter{BPMMin: 100, BPMMax: 130}, []int64{1, 3, 4, 6}},
+		{"min only excludes untimed", TrackFilter{BPMMin: 1}, []int64{1, 2, 3, 4, 6}},
+		{"max only excludes untimed", TrackFilter{BPMMax: 100}, []int64{2, 3, 6}},
+		{"composes with instrumental", TrackFilter{InstrumentalOnly: true, BPMMin: 100, BPMMax: 130}, []int64{1, 3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := repo.GetByMood("energize", tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestGetByMood_SortByBPM(t *testing.T) {
+	repo := setupTempoRepo(t)
+
+	// Order ignores play counts; equal tempos fall back to ID and untimed
+	// tracks come last in both directions
+	tests := []struct {
+		name    string
+		filter  TrackFilter
+		wantIDs []int64
+	}{
+		{"ascending", TrackFilter{Sort: SortBPMAsc}, []int64{2, 3, 6, 1, 4, 5}},
+		{"descending", TrackFilter{Sort: SortBPMDesc}, []int64{4, 1, 3, 6, 2, 5}},
+		{"instrumental ascending", TrackFilter{Sort: SortBPMAsc, InstrumentalOnly: true}, []int64{3, 6, 1, 5}},
+		{"instrumental descending in range", TrackFilter{Sort: SortBPMDesc, InstrumentalOnly: true, BPMMin: 100}, []int64{1, 3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := repo.GetByMood("energize", tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := make([]int64, len(tracks))
+			for i, tr := range tracks {
+				ids[i] = tr.ID
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestStreamTracks(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	var ids []int64
+	err := repo.StreamTracks(func(track *Track) error {
+		ids = append(ids, track.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamTracks() error = %v", err)
+	}
+	// Pending track 4 excluded, ID order
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("streamed ids = %v, want [1 2 3]", ids)
+	}
+
+	// An error from fn stops the scan and is returned unwrapped
+	stop := errors.New("client gone")
+	calls := 0
+	err = repo.StreamTracks(func(*Track) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("StreamTracks() = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
+func TestStreamTracks_Pages(t *testing.T) {
+	repo := setupTestRepo(t)
+	_, err := repo.db.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?)
+		INSERT INTO tracks (file_path, mood, duration_seconds, status)
+		SELECT 'focus/bulk' || i || '.mp3', 'focus', 180, 'approved' FROM n`, streamPageSize+10)
+	if err != nil {
+		t.Fatalf("failed to insert tracks: %v", err)
+	}
+
+	// fn can query the repository mid-stream: no page holds the connection
+	var last int64
+	count := 0
+	err = repo.StreamTracks(func(track *Track) error {
+		if track.ID <= last {
+			return fmt.Errorf("track %d after %d, want ID order", track.ID, last)
+		}
+		last = track.ID
+		count++
+		if _, err := repo.GetByID(track.ID); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamTracks() error = %v", err)
+	}
+	if want := 3 + streamPageSize + 10; count != want {
+		t.Errorf("streamed %d tracks, want %d", count, want)
+	}
+}
+
 func TestUpdatePlayStats(t *testing.T) {
 	repo := setupTestRepo(t)
 
@@ -123,6 +350,21 @@ func TestUpdatePlayStats(t *testing.T) {
 	}
 }
 
+func TestUpdatePlayStats_UsesClock(t *testing.T) {
+	repo := setupTestRepo(t)
+	played := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	repo.SetClock(func() time.Time { return played })
+
+	if err := repo.UpdatePlayStats(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	track, _ := repo.GetByID(1)
+	if track.LastPlayedAt == nil || !track.LastPlayedAt.Equal(played) {
+		t.Errorf("last_played_at = %v, want %v", track.LastPlayedAt, played)
+	}
+}
+
 func TestUpdatePlayStats_NewTrack(t *testing.T) {
 	repo := setupTestRepo(t)
 
@@ -155,6 +397,180 @@ func TestUpdatePlayStats_NonExistent(t *testing.T) {
 	}
 }
 
+func TestWriteContext(t *testing.T) {
+	parent := context.Background()
+
+	ctx, cancel := WriteContext(parent, 0)
+	defer cancel()
+	if ctx != parent {
+		t.Error("zero deadline should return the parent context")
+	}
+
+	ctx, cancel = WriteContext(parent, time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline")
+	}
+	if until := time.Until(deadline); until <= 0 || until > time.Minute {
+		t.Errorf("deadline in %v, want within a minute", until)
+	}
+}
+
+func TestSetWriteDeadline(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	for _, d := range []time.Duration{0, 50 * time.Millisecond, busyTimeout + time.Second} {
+		if err := repo.SetWriteDeadline(d); err != nil {
+			t.Errorf("SetWriteDeadline(%v): %v", d, err)
+		}
+	}
+	if IsBusy(errors.New("database is locked")) {
+		t.Error("IsBusy should only match SQLite errors")
+	}
+	if IsBusy(nil) {
+		t.Error("IsBusy(nil) should be false")
+	}
+}
+
+func TestGetRandom(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	for _, n := range []int{1, 2, 3, 10} {
+		tracks, err := repo.GetRandom(n)
+		if err != nil {
+			t.Fatalf("GetRandom(%d) failed: %v", n, err)
+		}
+		want := min(n, 3) // three approved tracks across focus and calm
+		if len(tracks) != want {
+			t.Errorf("GetRandom(%d) returned %d tracks, want %d", n, len(tracks), want)
+		}
+		seen := make(map[int64]bool)
+		for _, track := range tracks {
+			if track.Status != StatusApproved {
+				t.Errorf("GetRandom(%d) returned track %d with status %q", n, track.ID, track.Status)
+			}
+			if seen[track.ID] {
+				t.Errorf("GetRandom(%d) returned track %d twice", n, track.ID)
+			}
+			seen[track.ID] = true
+		}
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+	rename := func(title string) func(tx *sql.Tx) error {
+		return func(tx *sql.Tx) error {
+			_, err := tx.Exec(`UPDATE tracks SET title = ? WHERE id = 1`, title)
+			return err
+		}
+	}
+	title := func() string {
+		track, err := repo.GetByID(1)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		return *track.Title
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		if err := repo.WithTx(ctx, rename("Committed")); err != nil {
+			t.Fatalf("WithTx failed: %v", err)
+		}
+		if got := title(); got != "Committed" {
+			t.Errorf("title = %q, want Committed", got)
+		}
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := repo.WithTx(ctx, func(tx *sql.Tx) error {
+			if err := rename("Rolled back")(tx); err != nil {
+				return err
+			}
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Errorf("WithTx = %v, want the callback's error", err)
+		}
+		if got := title(); got != "Committed" {
+			t.Errorf("title = %q after a failed transaction, want Committed", got)
+		}
+	})
+
+	t.Run("rollback on panic", func(t *testing.T) {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("WithTx swallowed the panic")
+				}
+			}()
+			_ = repo.WithTx(ctx, func(tx *sql.Tx) error {
+				_ = rename("Panicked")(tx)
+				panic("boom")
+			})
+		}()
+		if got := title(); got != "Committed" {
+			t.Errorf("title = %q after a panic, want Committed", got)
+		}
+		// The connection went back to the pool usable
+		if err := repo.WithTx(ctx, rename("After panic")); err != nil {
+			t.Errorf("WithTx after a panic failed: %v", err)
+		}
+	})
+}
+
+func TestWithTx_RetriesBusy(t *testing.T) {
+	repo := setupTestRepo(t)
+	errBusy := errors.New("database is locked")
+	errOther := errors.New("constraint failed")
+	repo.isBusy = func(err error) bool { return errors.Is(err, errBusy) }
+
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive attempts; nil after
+		wantCalls int
+		wantErr   error
+	}{
+		{"busy then success", []error{errBusy, errBusy}, 3, nil},
+		{"busy every attempt", []error{errBusy, errBusy, errBusy, errBusy}, txAttempts, errBusy},
+		{"other errors are not retried", []error{errOther}, 1, errOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := repo.WithTx(context.Background(), func(tx *sql.Tx) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("callback ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithTx = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := repo.WithTx(ctx, func(tx *sql.Tx) error {
+			calls++
+			cancel()
+			return errBusy
+		})
+		if calls != 1 || !errors.Is(err, errBusy) {
+			t.Errorf("after cancel: %d calls, %v; want 1 call returning the busy error", calls, err)
+		}
+	})
+}
+
 func TestGetMoodStats(t *testing.T) {
 	repo := setupTestRepo(t)
 
@@ -225,3 +641,176 @@ func TestPing(t *testing.T) {
 		t.Errorf("Ping should succeed on valid repo: %v", err)
 	}
 }
+
+func TestInvalidTracks(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status) VALUES
+			(1, 'focus/ok.mp3', 'OK', 'focus', 180, 'approved'),
+			(2, 'focus/zero.mp3', 'Zero', 'focus', 0, 'approved'),
+			(3, 'calm/negative.mp3', 'Negative', 'calm', -5, 'pending');
+	`)
+
+	tracks, err := repo.InvalidTracks()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tracks) != 2 || tracks[0].ID != 2 || tracks[1].ID != 3 {
+		ids := make([]int64, len(tracks))
+		for i, tr := range tracks {
+			ids[i] = tr.ID
+		}
+		t.Fatalf("flagged ids = %v, want [2 3]", ids)
+	}
+
+	clean := setupTestRepo(t)
+	tracks, err = clean.InvalidTracks()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracks == nil || len(tracks) != 0 {
+		t.Errorf("clean inventory = %v, want empty non-nil slice", tracks)
+	}
+}
+
+func TestSetFileMissing(t *testing.T) {
+	repo := setupTestRepo(t)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := repo.SetFileMissing(1, at); err != nil {
+		t.Fatalf("SetFileMissing failed: %v", err)
+	}
+	track, _ := repo.GetByID(1)
+	if track.FileMissingAt == nil || !track.FileMissingAt.Equal(at) {
+		t.Errorf("file_missing_at = %v, want %v", track.FileMissingAt, at)
+	}
+	tracks, err := repo.InvalidTracks()
+	if err != nil || len(tracks) != 1 || tracks[0].ID != 1 {
+		t.Errorf("invalid tracks = %v, %v; want track 1", tracks, err)
+	}
+
+	if err := repo.SetFileMissing(1, time.Time{}); err != nil {
+		t.Fatalf("SetFileMissing(zero) failed: %v", err)
+	}
+	if track, _ := repo.GetByID(1); track.FileMissingAt != nil {
+		t.Errorf("file_missing_at after clearing = %v, want nil", track.FileMissingAt)
+	}
+	if tracks, _ := repo.InvalidTracks(); len(tracks) != 0 {
+		t.Errorf("invalid tracks after clearing = %d, want 0", len(tracks))
+	}
+
+	if err := repo.SetFileMissing(999, at); !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("unknown track error = %v, want ErrTrackNotFound", err)
+	}
+}
+
+func TestCountByMood(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tests := []struct {
+		name   string
+		mood   string
+		filter TrackFilter
+		want   int
+	}{
+		{"approved only", "focus", TrackFilter{}, 2},
+		{"with filter", "focus", TrackFilter{InstrumentalOnly: true}, 1},
+		{"unknown mood", "unknown", TrackFilter{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := repo.CountByMood(tt.mood, tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != tt.want {
+				t.Errorf("count = %d, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleByMood(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status, has_vocals) VALUES
+			(1, 'focus/a.mp3', 'A', 'focus', 180, 'approved', 0),
+			(2, 'focus/b.mp3', 'B', 'focus', 180, 'approved', 1),
+			(3, 'focus/c.mp3', 'C', 'focus', 180, 'approved', 0),
+			(4, 'focus/d.mp3', 'D', 'focus', 180, 'approved', 0),
+			(5, 'focus/pending.mp3', 'P', 'focus', 180, 'pending', 0),
+			(6, 'calm/e.mp3', 'E', 'calm', 180, 'approved', 0);
+		INSERT INTO track_tags (track_id, tag) VALUES (1, 'piano'), (3, 'piano');
+		INSERT INTO play_stats (file_path, play_count) VALUES ('focus/a.mp3', 7);
+	`)
+
+	seen := make(map[int64]bool)
+	for range 30 {
+		tracks, err := repo.SampleByMood("focus", TrackFilter{}, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tracks) != 2 {
+			t.Fatalf("got %d tracks, want 2", len(tracks))
+		}
+		for _, tr := range tracks {
+			if tr.ID < 1 || tr.ID > 4 {
+				t.Fatalf("sampled track %d outside approved focus tracks", tr.ID)
+			}
+			if tr.ID == 1 && (tr.PlayCount != 7 || len(tr.Tags) != 1) {
+				t.Errorf("sampled track 1 missing joined data: plays=%d tags=%v", tr.PlayCount, tr.Tags)
+			}
+			seen[tr.ID] = true
+		}
+	}
+	if len(seen) < 3 {
+		t.Errorf("30 samples covered %d distinct tracks, want variety", len(seen))
+	}
+
+	// Filters narrow the population; n beyond it returns everything that matches
+	tracks, err := repo.SampleByMood("focus", TrackFilter{Tags: []string{"piano"}, InstrumentalOnly: true}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := make([]int64, len(tracks))
+	for i, tr := range tracks {
+		ids[i] = tr.ID
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("filtered sample ids = %v, want [1 3]", ids)
+	}
+}
+
+func TestTrackIDsByMood_GetByIDs(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	ids, err := repo.TrackIDsByMood("focus", TrackFilter{})
+	if err != nil {
+		t.Fatalf("TrackIDsByMood failed: %v", err)
+	}
+	if !slices.Equal(ids, []int64{1, 2}) {
+		t.Errorf("focus ids = %v, want [1 2]", ids)
+	}
+	ids, err = repo.TrackIDsByMood("focus", TrackFilter{InstrumentalOnly: true})
+	if err != nil {
+		t.Fatalf("TrackIDsByMood failed: %v", err)
+	}
+	if !slices.Equal(ids, []int64{1}) {
+		t.Errorf("instrumental focus ids = %v, want [1]", ids)
+	}
+
+	// The given order is kept and unknown IDs are skipped
+	tracks, err := repo.GetByIDs([]int64{3, 99, 1})
+	if err != nil {
+		t.Fatalf("GetByIDs failed: %v", err)
+	}
+	if len(tracks) != 2 || tracks[0].ID != 3 || tracks[1].ID != 1 {
+		t.Fatalf("GetByIDs = %v, want tracks 3, 1", tracks)
+	}
+	if tracks[1].PlayCount != 5 {
+		t.Errorf("track 1 play count = %d, want 5 from play_stats", tracks[1].PlayCount)
+	}
+	if tracks, err := repo.GetByIDs(nil); err != nil || len(tracks) != 0 {
+		t.Errorf("GetByIDs(nil) = %v, %v, want none", tracks, err)
+	}
+}
@@ -0,0 +1,31 @@
+package inventory
+
+import "hash/fnv"
+
+// rolloutBuckets is how many buckets listeners are split into, one per
+// rollout percentage point
+const rolloutBuckets = 100
+
+// RolloutBucket returns a listener's stable rollout bucket, 0-99. A beta
+// track with rollout percent p is served to buckets below p, so raising p
+// only ever adds listeners, and a listener keeps the same beta tracks across
+// requests.
+func RolloutBucket(listenerID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(listenerID))
+	return int(h.Sum32() % rolloutBuckets)
+}
+
+// RolledOutTo reports whether a listener in bucket is served the track:
+// approved tracks always, beta tracks when bucket is under their rollout
+// percent, others never
+func (t *Track) RolledOutTo(bucket int) bool {
+	switch t.Status {
+	case StatusApproved:
+		return true
+	case StatusBeta:
+		return bucket < t.RolloutPercent
+	default:
+		return false
+	}
+}
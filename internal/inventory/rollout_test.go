@@ -0,0 +1,72 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRolloutBucket(t *testing.T) {
+	if a, b := RolloutBucket("listener-a"), RolloutBucket("listener-a"); a != b {
+		t.Errorf("same listener got buckets %d and %d", a, b)
+	}
+
+	seen := make(map[int]bool)
+	for i := range 1000 {
+		b := RolloutBucket(fmt.Sprintf("listener-%d", i))
+		if b < 0 || b >= 100 {
+			t.Fatalf("bucket %d out of range", b)
+		}
+		seen[b] = true
+	}
+	if len(seen) < 90 {
+		t.Errorf("1000 listeners landed in %d buckets, want a spread", len(seen))
+	}
+}
+
+func TestGetByMood_Beta(t *testing.T) {
+	repo := setupTestRepo(t)
+	fields := map[string]any{"status": StatusBeta, "rollout_percent": float64(40)}
+	if err := repo.UpdateTrack(2, fields, "alice", "trial"); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter TrackFilter
+		want   int
+	}{
+		{"anonymous", TrackFilter{}, 1},
+		{"bucket under rollout", TrackFilter{IncludeBeta: true, RolloutBucket: 39}, 2},
+		{"bucket at rollout", TrackFilter{IncludeBeta: true, RolloutBucket: 40}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := repo.GetByMood("focus", tt.filter)
+			if err != nil {
+				t.Fatalf("GetByMood failed: %v", err)
+			}
+			if len(tracks) != tt.want {
+				t.Errorf("got %d tracks, want %d", len(tracks), tt.want)
+			}
+		})
+	}
+
+	track, err := repo.GetByID(2)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if track.RolloutPercent != 40 || !track.RolledOutTo(39) || track.RolledOutTo(40) {
+		t.Errorf("track = %d%% rollout, want reaching buckets below 40", track.RolloutPercent)
+	}
+}
+
+func TestUpdateTrack_RolloutPercent(t *testing.T) {
+	repo := setupTestRepo(t)
+	for _, v := range []any{float64(101), float64(-1), nil, "50"} {
+		err := repo.UpdateTrack(1, map[string]any{"rollout_percent": v}, "alice", "")
+		if !errors.Is(err, ErrInvalidField) {
+			t.Errorf("rollout_percent %v: err = %v, want ErrInvalidField", v, err)
+		}
+	}
+}
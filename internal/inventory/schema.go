@@ -0,0 +1,86 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSchemaMismatch is returned when the database lacks tables or columns
+// the code queries
+var ErrSchemaMismatch = errors.New("database schema is out of date")
+
+// expectedSchema lists the tables and columns the repository queries, in
+// schema.sql order. Add to it with each migration.
+var expectedSchema = []struct {
+	table   string
+	columns []string
+}{
+	{"tracks", []string{
+		"id", "file_path", "title", "artist", "mood", "energy", "tempo_bpm", "has_vocals",
+		"musical_key", "intensity", "time_affinity", "lyrics", "duration_seconds",
+		"content_hash", "fade_in_ms", "fade_out_ms", "file_missing_at", "status", "rollout_percent",
+		"created_at",
+	}},
+	{"play_stats", []string{"file_path", "play_count", "last_played_at"}},
+	{"listen_events", []string{
+		"id", "track_id", "mood", "event_type", "listen_seconds", "playlist_position",
+		"session_id", "platform", "app_version", "client_id", "playlist_id", "station",
+		"reason", "created_at",
+	}},
+	{"track_tags", []string{"track_id", "tag"}},
+	{"track_status_history", []string{"id", "track_id", "old_status", "new_status", "actor", "reason", "created_at"}},
+	{"listener_state", []string{"listener_id", "mood", "track_id", "position_seconds", "updated_at"}},
+	{"hidden_tracks", []string{"listener_id", "track_id"}},
+	{"mood_meta", []string{"mood", "color", "icon", "description", "updated_at"}},
+}
+
+// VerifySchema checks that every table and column the repository queries
+// exists, so a database that missed a migration fails at startup with the
+// missing names instead of with "no such column" on some later request.
+// Extra tables and columns are fine.
+func (r *Repository) VerifySchema() error {
+	var missing []string
+	for _, want := range expectedSchema {
+		have, err := r.tableColumns(want.table)
+		if err != nil {
+			return err
+		}
+		if len(have) == 0 {
+			missing = append(missing, "table "+want.table)
+			continue
+		}
+		for _, col := range want.columns {
+			if !have[col] {
+				missing = append(missing, want.table+"."+col)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s (apply scripts/migrations)", ErrSchemaMismatch, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// tableColumns returns the set of a table's column names, empty if the
+// table doesn't exist
+func (r *Repository) tableColumns(table string) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		cols[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating columns of %s: %w", table, err)
+	}
+	return cols, nil
+}
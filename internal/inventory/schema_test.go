@@ -0,0 +1,47 @@
+package inventory
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifySchema(t *testing.T) {
+	// The test schema and the baseline schema both match what the code queries
+	if err := setupTestRepo(t).VerifySchema(); err != nil {
+		t.Errorf("VerifySchema on the test schema = %v", err)
+	}
+
+	repo, err := NewRepository(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	if err := repo.EnsureSchema(baselineSchema, true); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+	if err := repo.VerifySchema(); err != nil {
+		t.Errorf("VerifySchema on the baseline schema = %v", err)
+	}
+}
+
+func TestVerifySchema_Missing(t *testing.T) {
+	repo := openTestDB(t, `
+		ALTER TABLE tracks DROP COLUMN intensity;
+		DROP TABLE hidden_tracks;
+	`)
+
+	err := repo.VerifySchema()
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("VerifySchema error = %v, want ErrSchemaMismatch", err)
+	}
+	for _, want := range []string{"tracks.intensity", "table hidden_tracks"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %s", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "tracks.title") {
+		t.Errorf("error %q names a column that exists", err)
+	}
+}
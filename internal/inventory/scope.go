@@ -0,0 +1,37 @@
+package inventory
+
+import "strings"
+
+// WithScope returns a view of the repository whose listener-facing queries
+// (mood playlists, random and featured picks, mood stats, tag counts, artist
+// pages) only see tracks whose file path starts with pathPrefix and whose
+// mood is one of moods, as for one station. An empty pathPrefix or moods
+// leaves that side open. Lookups by ID and admin queries still see every
+// track. The view shares the connection and settings of r, so set those
+// first and close r, not the view.
+func (r *Repository) WithScope(pathPrefix string, moods []string) *Repository {
+	view := *r
+	view.pathPrefix = pathPrefix
+	view.moods = moods
+	return &view
+}
+
+// scopeClause returns an " AND ..." fragment keeping the view's scope on
+// the tracks table, qualified by alias ("t." or ""), or "" for an unscoped
+// repository. instr matches the path prefix byte for byte, where LIKE would
+// ignore ASCII case.
+func (r *Repository) scopeClause(alias string) (string, []any) {
+	var clause string
+	var args []any
+	if r.pathPrefix != "" {
+		clause += " AND instr(" + alias + "file_path, ?) = 1"
+		args = append(args, r.pathPrefix)
+	}
+	if len(r.moods) > 0 {
+		clause += " AND " + alias + "mood IN (?" + strings.Repeat(", ?", len(r.moods)-1) + ")"
+		for _, m := range r.moods {
+			args = append(args, m)
+		}
+	}
+	return clause, args
+}
@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWithScope(t *testing.T) {
+	repo := openTestDB(t, `
+		INSERT INTO tracks (id, file_path, title, artist, mood, duration_seconds, status) VALUES
+			(1, 'work/focus1.mp3', 'Work Focus', 'Ana', 'focus', 180, 'approved'),
+			(2, 'work/calm1.mp3', 'Work Calm', 'Ana', 'calm', 180, 'approved'),
+			(3, 'sleep/focus1.mp3', 'Sleep Focus', 'Ana', 'focus', 180, 'approved'),
+			(4, 'Work/focus2.mp3', 'Other Case', 'Ana', 'focus', 180, 'approved'),
+			(5, 'workshop/focus3.mp3', 'Sibling Dir', 'Ana', 'focus', 180, 'approved');
+		INSERT INTO track_tags (track_id, tag) VALUES (1, 'piano'), (3, 'rain');
+	`)
+	work := repo.WithScope("work/", []string{"focus"})
+
+	ids := func(tracks []*Track) []int64 {
+		out := make([]int64, len(tracks))
+		for i, tr := range tracks {
+			out[i] = tr.ID
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	focus, err := work.GetByMood("focus", TrackFilter{})
+	if err != nil {
+		t.Fatalf("GetByMood failed: %v", err)
+	}
+	if got := ids(focus); !slices.Equal(got, []int64{1}) {
+		t.Errorf("scoped focus tracks = %v, want [1]", got)
+	}
+	calm, err := work.GetByMood("calm", TrackFilter{})
+	if err != nil {
+		t.Fatalf("GetByMood failed: %v", err)
+	}
+	if len(calm) != 0 {
+		t.Errorf("got %d calm tracks outside the scope's moods, want 0", len(calm))
+	}
+
+	random, err := work.GetRandom(10)
+	if err != nil {
+		t.Fatalf("GetRandom failed: %v", err)
+	}
+	if got := ids(random); !slices.Equal(got, []int64{1}) {
+		t.Errorf("scoped random tracks = %v, want [1]", got)
+	}
+
+	featured, err := work.GetFeaturedTrack(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetFeaturedTrack failed: %v", err)
+	}
+	if featured == nil || featured.ID != 1 {
+		t.Errorf("scoped featured track = %v, want track 1", featured)
+	}
+
+	stats, err := work.GetMoodStats()
+	if err != nil {
+		t.Fatalf("GetMoodStats failed: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Mood != "focus" || stats[0].TrackCount != 1 {
+		t.Errorf("scoped mood stats = %+v, want focus with 1 track", stats)
+	}
+
+	tags, err := work.GetTagCounts()
+	if err != nil {
+		t.Fatalf("GetTagCounts failed: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Tag != "piano" {
+		t.Errorf("scoped tag counts = %+v, want only piano", tags)
+	}
+
+	byArtist, total, err := work.GetByArtist("Ana", 0, 0)
+	if err != nil {
+		t.Fatalf("GetByArtist failed: %v", err)
+	}
+	if got := ids(byArtist); total != 1 || !slices.Equal(got, []int64{1}) {
+		t.Errorf("scoped artist tracks = %v (total %d), want [1]", got, total)
+	}
+
+	// Lookups by ID and the unscoped repository still see every track
+	if track, err := work.GetByID(3); err != nil || track == nil {
+		t.Errorf("GetByID(3) through the scope = %v, %v; want the track", track, err)
+	}
+	all, err := repo.GetByMood("focus", TrackFilter{})
+	if err != nil {
+		t.Fatalf("GetByMood failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("unscoped repository got %d focus tracks, want 4", len(all))
+	}
+}
@@ -0,0 +1,234 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultSessionGap is the idle time after which a listener's next event
+// starts a new session
+const DefaultSessionGap = 30 * time.Minute
+
+// SessionStats summarizes listening sessions for one mood (or all moods)
+type SessionStats struct {
+	Mood                  string  `json:"mood,omitempty"`
+	Sessions              int     `json:"sessions"`
+	AvgDurationSeconds    float64 `json:"avg_duration_seconds"`
+	MedianDurationSeconds float64 `json:"median_duration_seconds"`
+	AvgTracks             float64 `json:"avg_tracks_per_session"`
+	CompletionRate        float64 `json:"completion_rate"` // complete / (complete + skip)
+	// SkipReasons counts skips by reason; skips sent without one count as
+	// SkipReasonUnspecified
+	SkipReasons map[string]int `json:"skip_reasons,omitempty"`
+}
+
+// SessionReport holds session stats overall and per mood, plus event counts
+// per client platform
+type SessionReport struct {
+	Overall   SessionStats    `json:"overall"`
+	Moods     []SessionStats  `json:"moods"`
+	Platforms []PlatformStats `json:"platforms"`
+}
+
+// PlatformUnknown labels events recorded before clients reported a platform
+const PlatformUnknown = "unknown"
+
+// SkipReasonUnspecified labels skips sent without a reason
+const SkipReasonUnspecified = "unspecified"
+
+// PlatformStats counts listen events from one client platform
+type PlatformStats struct {
+	Platform  string `json:"platform"`
+	Plays     int    `json:"plays"`
+	Skips     int    `json:"skips"`
+	Completes int    `json:"completes"`
+}
+
+// sessionEvent is the subset of a listen event needed to build sessions
+type sessionEvent struct {
+	SessionID string
+	Mood      string
+	EventType string
+	Reason    string
+	At        time.Time
+}
+
+// session is a run of events from one session ID in one mood with no gap
+// longer than the threshold
+type session struct {
+	mood      string
+	start     time.Time
+	end       time.Time
+	tracks    int
+	completes int
+	skips     int
+	reasons   map[string]int
+}
+
+// sessionFolder builds sessions from events ordered by session ID then time
+type sessionFolder struct {
+	gap      time.Duration
+	current  *session
+	lastID   string
+	sessions []session
+}
+
+func (f *sessionFolder) add(evt sessionEvent) {
+	c := f.current
+	if c == nil || evt.SessionID != f.lastID || evt.Mood != c.mood || evt.At.Sub(c.end) > f.gap {
+		f.close()
+		c = &session{mood: evt.Mood, start: evt.At, end: evt.At}
+		f.current = c
+		f.lastID = evt.SessionID
+	}
+
+	c.end = evt.At
+	switch evt.EventType {
+	case EventPlay:
+		c.tracks++
+	case EventComplete:
+		c.completes++
+	case EventSkip:
+		c.skips++
+		if c.reasons == nil {
+			c.reasons = make(map[string]int)
+		}
+		c.reasons[evt.Reason]++
+	}
+}
+
+func (f *sessionFolder) close() {
+	if f.current != nil {
+		f.sessions = append(f.sessions, *f.current)
+		f.current = nil
+	}
+}
+
+// summarize aggregates sessions into overall and per-mood stats
+func summarize(sessions []session) *SessionReport {
+	byMood := make(map[string][]session)
+	for _, s := range sessions {
+		byMood[s.mood] = append(byMood[s.mood], s)
+	}
+
+	report := &SessionReport{Overall: sessionStats("", sessions), Moods: []SessionStats{}}
+	for mood, ss := range byMood {
+		report.Moods = append(report.Moods, sessionStats(mood, ss))
+	}
+	sort.Slice(report.Moods, func(i, j int) bool { return report.Moods[i].Mood < report.Moods[j].Mood })
+	return report
+}
+
+func sessionStats(mood string, sessions []session) SessionStats {
+	st := SessionStats{Mood: mood, Sessions: len(sessions)}
+	if len(sessions) == 0 {
+		return st
+	}
+
+	durations := make([]float64, len(sessions))
+	var totalDuration float64
+	var tracks, completes, skips int
+	for i, s := range sessions {
+		durations[i] = s.end.Sub(s.start).Seconds()
+		totalDuration += durations[i]
+		tracks += s.tracks
+		completes += s.completes
+		skips += s.skips
+		for reason, n := range s.reasons {
+			if st.SkipReasons == nil {
+				st.SkipReasons = make(map[string]int)
+			}
+			st.SkipReasons[reason] += n
+		}
+	}
+
+	sort.Float64s(durations)
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		st.MedianDurationSeconds = (durations[mid-1] + durations[mid]) / 2
+	} else {
+		st.MedianDurationSeconds = durations[mid]
+	}
+
+	n := float64(len(sessions))
+	st.AvgDurationSeconds = totalDuration / n
+	st.AvgTracks = float64(tracks) / n
+	if completes+skips > 0 {
+		st.CompletionRate = float64(completes) / float64(completes+skips)
+	}
+	return st
+}
+
+// GetSessionStats groups listen events since the given time into sessions and
+// summarizes them. Consecutive events with the same session ID and mood belong
+// to one session until a gap longer than gap. Events without a session ID
+// (older clients) are excluded. Skips are also counted by reason.
+func (r *Repository) GetSessionStats(since time.Time, gap time.Duration) (*SessionReport, error) {
+	query := `
+		SELECT session_id, mood, event_type, COALESCE(reason, ?), created_at
+		FROM listen_events
+		WHERE session_id IS NOT NULL AND created_at >= ?
+		ORDER BY session_id, created_at, id
+	`
+
+	rows, err := r.db.Query(query, SkipReasonUnspecified, since.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	folder := &sessionFolder{gap: gap}
+	for rows.Next() {
+		var evt sessionEvent
+		if err := rows.Scan(&evt.SessionID, &evt.Mood, &evt.EventType, &evt.Reason, &evt.At); err != nil {
+			return nil, fmt.Errorf("failed to scan session event: %w", err)
+		}
+		folder.add(evt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating session events: %w", err)
+	}
+	folder.close()
+
+	report := summarize(folder.sessions)
+	if report.Platforms, err = r.platformStats(since); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// platformStats counts events since the given time per client platform,
+// including events without a session ID
+func (r *Repository) platformStats(since time.Time) ([]PlatformStats, error) {
+	query := `
+		SELECT COALESCE(platform, ?) AS p,
+			SUM(event_type = 'play'), SUM(event_type = 'skip'), SUM(event_type = 'complete')
+		FROM listen_events
+		WHERE created_at >= ?
+		GROUP BY p
+		ORDER BY p
+	`
+
+	rows, err := r.db.Query(query, PlatformUnknown, since.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query platform stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := []PlatformStats{}
+	for rows.Next() {
+		var p PlatformStats
+		if err := rows.Scan(&p.Platform, &p.Plays, &p.Skips, &p.Completes); err != nil {
+			return nil, fmt.Errorf("failed to scan platform stats: %w", err)
+		}
+		stats = append(stats, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating platform stats: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,229 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// seedEvents inserts listen events at explicit offsets from base
+func seedEvents(t *testing.T, repo *Repository, base time.Time, events []struct {
+	session any
+	mood    string
+	event   string
+	offset  time.Duration
+}) {
+	t.Helper()
+	for _, e := range events {
+		_, err := repo.db.Exec(
+			`INSERT INTO listen_events (track_id, mood, event_type, session_id, created_at) VALUES (1, ?, ?, ?, ?)`,
+			e.mood, e.event, e.session, base.Add(e.offset).UTC().Format(time.DateTime),
+		)
+		if err != nil {
+			t.Fatalf("failed to seed event: %v", err)
+		}
+	}
+}
+
+func TestSessionFolder_Boundaries(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := &sessionFolder{gap: 30 * time.Minute}
+
+	for _, evt := range []sessionEvent{
+		// Session a: two tracks, 29-minute gap stays in one session
+		{SessionID: "a", Mood: "focus", EventType: EventPlay, At: base},
+		{SessionID: "a", Mood: "focus", EventType: EventComplete, At: base.Add(3 * time.Minute)},
+		{SessionID: "a", Mood: "focus", EventType: EventPlay, At: base.Add(32 * time.Minute)},
+		{SessionID: "a", Mood: "focus", EventType: EventSkip, At: base.Add(33 * time.Minute)},
+		// 31-minute gap starts a new session
+		{SessionID: "a", Mood: "focus", EventType: EventPlay, At: base.Add(64 * time.Minute)},
+		// Mood switch starts a new session
+		{SessionID: "a", Mood: "calm", EventType: EventPlay, At: base.Add(65 * time.Minute)},
+		// New session ID starts a new session
+		{SessionID: "b", Mood: "calm", EventType: EventPlay, At: base.Add(65 * time.Minute)},
+	} {
+		f.add(evt)
+	}
+	f.close()
+
+	if len(f.sessions) != 4 {
+		t.Fatalf("got %d sessions, want 4: %+v", len(f.sessions), f.sessions)
+	}
+	first := f.sessions[0]
+	if first.end.Sub(first.start) != 33*time.Minute || first.tracks != 2 || first.completes != 1 || first.skips != 1 {
+		t.Errorf("first session = %+v, want 33m span, 2 tracks, 1 complete, 1 skip", first)
+	}
+	if f.sessions[2].mood != "calm" || f.sessions[3].mood != "calm" {
+		t.Errorf("expected calm sessions at the end, got %+v", f.sessions[2:])
+	}
+}
+
+func TestGetSessionStats(t *testing.T) {
+	repo := setupTestRepo(t)
+	base := time.Now().Add(-2 * time.Hour)
+
+	seedEvents(t, repo, base, []struct {
+		session any
+		mood    string
+		event   string
+		offset  time.Duration
+	}{
+		// s1 focus: 10 minutes, 2 tracks, both complete
+		{"s1", "focus", "play", 0},
+		{"s1", "focus", "complete", 5 * time.Minute},
+		{"s1", "focus", "play", 5 * time.Minute},
+		{"s1", "focus", "complete", 10 * time.Minute},
+		// s1 again after a 45-minute gap: 0-minute session with 1 skip
+		{"s1", "focus", "play", 55 * time.Minute},
+		{"s1", "focus", "skip", 55 * time.Minute},
+		// s2 calm: 20 minutes, 1 track
+		{"s2", "calm", "play", 0},
+		{"s2", "calm", "complete", 20 * time.Minute},
+		// Legacy events without a session are excluded
+		{nil, "focus", "play", 0},
+		{nil, "focus", "complete", 90 * time.Minute},
+	})
+	// Events before the window are excluded
+	seedEvents(t, repo, base.Add(-48*time.Hour), []struct {
+		session any
+		mood    string
+		event   string
+		offset  time.Duration
+	}{
+		{"old", "calm", "play", 0},
+		{"old", "calm", "complete", 10 * time.Minute},
+	})
+
+	report, err := repo.GetSessionStats(time.Now().Add(-24*time.Hour), 30*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Overall.Sessions != 3 {
+		t.Errorf("overall sessions = %d, want 3", report.Overall.Sessions)
+	}
+	// Durations 600s, 0s, 1200s → avg 600, median 600
+	if math.Abs(report.Overall.AvgDurationSeconds-600) > 1 || math.Abs(report.Overall.MedianDurationSeconds-600) > 1 {
+		t.Errorf("overall avg/median = %.0f/%.0f, want 600/600",
+			report.Overall.AvgDurationSeconds, report.Overall.MedianDurationSeconds)
+	}
+	// 3 completes + 1 skip in focus+calm (s1, s2)
+	if math.Abs(report.Overall.CompletionRate-0.75) > 0.001 {
+		t.Errorf("overall completion rate = %.3f, want 0.75", report.Overall.CompletionRate)
+	}
+
+	if len(report.Moods) != 2 || report.Moods[0].Mood != "calm" || report.Moods[1].Mood != "focus" {
+		t.Fatalf("moods = %+v, want calm then focus", report.Moods)
+	}
+	focus := report.Moods[1]
+	if focus.Sessions != 2 || focus.AvgTracks != 1.5 || math.Abs(focus.MedianDurationSeconds-300) > 1 {
+		t.Errorf("focus stats = %+v, want 2 sessions, 1.5 tracks, median 300s", focus)
+	}
+}
+
+func TestGetSessionStats_Empty(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	report, err := repo.GetSessionStats(time.Now().Add(-24*time.Hour), DefaultSessionGap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Overall.Sessions != 0 || len(report.Moods) != 0 || report.Platforms == nil || len(report.Platforms) != 0 {
+		t.Errorf("expected empty report, got %+v", report)
+	}
+}
+
+func TestGetSessionStats_Platforms(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tx, err := repo.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	for _, evt := range []ListenEvent{
+		{TrackID: 1, Mood: "focus", EventType: EventPlay, Client: ClientInfo{Platform: PlatformIOS, AppVersion: "2.1.0"}},
+		{TrackID: 1, Mood: "focus", EventType: EventSkip, Client: ClientInfo{Platform: PlatformIOS}},
+		{TrackID: 2, Mood: "focus", EventType: EventPlay, Client: ClientInfo{Platform: PlatformDesktop}},
+		{TrackID: 2, Mood: "focus", EventType: EventComplete, Client: ClientInfo{Platform: PlatformDesktop}},
+		{TrackID: 3, Mood: "calm", EventType: EventPlay}, // legacy client
+	} {
+		if err := repo.RecordListenEventTx(tx, evt); err != nil {
+			t.Fatalf("RecordListenEventTx failed: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	var version string
+	if err := repo.db.QueryRow(`SELECT app_version FROM listen_events WHERE id = 1`).Scan(&version); err != nil || version != "2.1.0" {
+		t.Errorf("app_version = %q (%v), want 2.1.0", version, err)
+	}
+
+	report, err := repo.GetSessionStats(time.Now().Add(-time.Hour), DefaultSessionGap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PlatformStats{
+		{Platform: PlatformDesktop, Plays: 1, Completes: 1},
+		{Platform: PlatformIOS, Plays: 1, Skips: 1},
+		{Platform: PlatformUnknown, Plays: 1},
+	}
+	if !slices.Equal(report.Platforms, want) {
+		t.Errorf("platforms = %+v, want %+v", report.Platforms, want)
+	}
+}
+
+func TestGetSessionStats_SkipReasons(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tx, err := repo.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	for _, evt := range []ListenEvent{
+		{TrackID: 1, Mood: "focus", EventType: EventPlay, SessionID: "s1"},
+		{TrackID: 1, Mood: "focus", EventType: EventSkip, SessionID: "s1", Reason: SkipReasonTooLong},
+		{TrackID: 2, Mood: "focus", EventType: EventSkip, SessionID: "s1", Reason: SkipReasonTooLong},
+		{TrackID: 2, Mood: "focus", EventType: EventSkip, SessionID: "s1"}, // no reason sent
+		{TrackID: 3, Mood: "calm", EventType: EventSkip, SessionID: "s2", Reason: SkipReasonWrongMood},
+		{TrackID: 3, Mood: "calm", EventType: EventComplete, SessionID: "s2"},
+	} {
+		if err := repo.RecordListenEventTx(tx, evt); err != nil {
+			t.Fatalf("RecordListenEventTx failed: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	var stored, unsent sql.NullString
+	if err := repo.db.QueryRow(`SELECT reason FROM listen_events WHERE id = 2`).Scan(&stored); err != nil || stored.String != SkipReasonTooLong {
+		t.Errorf("reason = %v (%v), want %s", stored, err, SkipReasonTooLong)
+	}
+	if err := repo.db.QueryRow(`SELECT reason FROM listen_events WHERE id = 4`).Scan(&unsent); err != nil || unsent.Valid {
+		t.Errorf("reason = %v (%v), want NULL", unsent, err)
+	}
+
+	report, err := repo.GetSessionStats(time.Now().Add(-time.Hour), DefaultSessionGap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	overall := map[string]int{SkipReasonTooLong: 2, SkipReasonUnspecified: 1, SkipReasonWrongMood: 1}
+	if !maps.Equal(report.Overall.SkipReasons, overall) {
+		t.Errorf("overall skip reasons = %v, want %v", report.Overall.SkipReasons, overall)
+	}
+	if len(report.Moods) != 2 {
+		t.Fatalf("moods = %+v, want calm and focus", report.Moods)
+	}
+	if calm := map[string]int{SkipReasonWrongMood: 1}; !maps.Equal(report.Moods[0].SkipReasons, calm) {
+		t.Errorf("calm skip reasons = %v, want %v", report.Moods[0].SkipReasons, calm)
+	}
+	if focus := map[string]int{SkipReasonTooLong: 2, SkipReasonUnspecified: 1}; !maps.Equal(report.Moods[1].SkipReasons, focus) {
+		t.Errorf("focus skip reasons = %v, want %v", report.Moods[1].SkipReasons, focus)
+	}
+}
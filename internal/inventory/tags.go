@@ -0,0 +1,177 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxTagLength is the longest tag accepted after normalization
+const MaxTagLength = 32
+
+// NormalizeTag lowercases and trims a tag, maps whitespace to "-", and drops
+// characters outside [a-z0-9_-]. Returns false if nothing usable remains.
+func NormalizeTag(raw string) (string, bool) {
+	var b strings.Builder
+	for _, c := range strings.ToLower(strings.TrimSpace(raw)) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+			b.WriteRune(c)
+		case c == ' ' || c == '\t':
+			b.WriteByte('-')
+		}
+	}
+	tag := strings.Trim(b.String(), "-_")
+	if tag == "" || len(tag) > MaxTagLength {
+		return "", false
+	}
+	return tag, true
+}
+
+// NormalizeTags normalizes, de-duplicates, and sorts tags.
+// Returns an error naming the first tag that normalizes to nothing.
+func NormalizeTags(raw []string) ([]string, error) {
+	seen := make(map[string]bool, len(raw))
+	tags := make([]string, 0, len(raw))
+	for _, r := range raw {
+		tag, ok := NormalizeTag(r)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q", r)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// tagsClause returns a WHERE fragment matching tracks that carry every tag.
+func tagsClause(tags []string) (string, []any) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(tags)), ",")
+	clause := fmt.Sprintf(`t.id IN (
+		SELECT track_id FROM track_tags WHERE tag IN (%s)
+		GROUP BY track_id HAVING COUNT(DISTINCT tag) = ?
+	)`, placeholders)
+
+	args := make([]any, 0, len(tags)+1)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	args = append(args, len(tags))
+	return clause, args
+}
+
+// SetTags replaces a track's tags. Tags are normalized first, so values that
+// differ only in case or punctuation collapse into one.
+func (r *Repository) SetTags(trackID int64, tags []string) error {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`DELETE FROM track_tags WHERE track_id = ?`, trackID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+	for _, tag := range normalized {
+		if _, err := tx.Exec(`INSERT INTO track_tags (track_id, tag) VALUES (?, ?)`, trackID, tag); err != nil {
+			return fmt.Errorf("failed to insert tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return nil
+}
+
+// GetTags returns a track's tags in sorted order
+func (r *Repository) GetTags(trackID int64) ([]string, error) {
+	rows, err := r.db.Query(`SELECT tag FROM track_tags WHERE track_id = ? ORDER BY tag`, trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetByTag retrieves approved tracks carrying a tag.
+// An empty mood matches tracks in every mood.
+func (r *Repository) GetByTag(tag string, mood string) ([]*Track, error) {
+	normalized, ok := NormalizeTag(tag)
+	if !ok {
+		return nil, fmt.Errorf("invalid tag %q", tag)
+	}
+
+	clause, args := tagsClause([]string{normalized})
+	where := "WHERE t.status = ? AND " + clause
+	args = append([]any{StatusApproved}, args...)
+	if mood != "" {
+		where += " AND t.mood = ?"
+		args = append(args, mood)
+	}
+
+	return r.queryTracks(where, leastPlayedOrder, args)
+}
+
+// TagCount holds the number of approved tracks carrying a tag
+type TagCount struct {
+	Tag        string
+	TrackCount int
+}
+
+// GetTagCounts returns every tag in use by approved tracks with its track count
+func (r *Repository) GetTagCounts() ([]TagCount, error) {
+	clause, args := r.scopeClause("t.")
+	query := `
+		SELECT tg.tag, COUNT(*) as track_count
+		FROM track_tags tg
+		JOIN tracks t ON t.id = tg.track_id
+		WHERE t.status = ?` + clause + `
+		GROUP BY tg.tag
+		ORDER BY tg.tag
+	`
+
+	rows, err := r.db.Query(query, append([]any{StatusApproved}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag counts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var counts []TagCount
+	for rows.Next() {
+		var c TagCount
+		if err := rows.Scan(&c.Tag, &c.TrackCount); err != nil {
+			return nil, fmt.Errorf("failed to scan tag count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating tag counts: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,154 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{"piano", "piano", true},
+		{"  Piano ", "piano", true},
+		{"Lo-Fi", "lo-fi", true},
+		{"late night", "late-night", true},
+		{"rain!!", "rain", true},
+		{"snake_case", "snake_case", true},
+		{"ñandú", "and", true},
+		{"   ", "", false},
+		{"!!!", "", false},
+		{"abcdefghijklmnopqrstuvwxyz0123456789", "", false}, // 36 chars > MaxTagLength
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := NormalizeTag(tt.raw)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("NormalizeTag(%q) = (%q, %v), want (%q, %v)", tt.raw, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSetTags_NormalizationCollisions(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if err := repo.SetTags(1, []string{"Piano", " piano ", "PIANO!", "Rain"}); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+
+	tags, err := repo.GetTags(1)
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	if want := []string{"piano", "rain"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+
+	// Replacing tags drops the old set
+	if err := repo.SetTags(1, []string{"lofi"}); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	track, _ := repo.GetByID(1)
+	if want := []string{"lofi"}; !reflect.DeepEqual(track.Tags, want) {
+		t.Errorf("track tags = %v, want %v", track.Tags, want)
+	}
+}
+
+func TestSetTags_RejectsInvalid(t *testing.T) {
+	repo := setupTestRepo(t)
+	_ = repo.SetTags(1, []string{"piano"})
+
+	if err := repo.SetTags(1, []string{"rain", "???"}); err == nil {
+		t.Fatal("expected error for tag with no usable characters")
+	}
+
+	// Failed SetTags must leave existing tags alone
+	tags, _ := repo.GetTags(1)
+	if want := []string{"piano"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+}
+
+func TestGetByMood_TagFilter(t *testing.T) {
+	repo := setupTestRepo(t)
+	_ = repo.SetTags(1, []string{"piano", "rain"})
+	_ = repo.SetTags(2, []string{"piano"})
+	_ = repo.SetTags(4, []string{"piano", "rain"}) // pending, never returned
+
+	tests := []struct {
+		name    string
+		tags    []string
+		wantIDs []int64
+	}{
+		{"single tag", []string{"piano"}, []int64{1, 2}},
+		{"all tags required", []string{"piano", "rain"}, []int64{1}},
+		{"unknown tag", []string{"piano", "vinyl"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := repo.GetByMood("focus", TrackFilter{Tags: tt.tags})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var ids []int64
+			for _, tr := range tracks {
+				ids = append(ids, tr.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("got ids %v, want %v", ids, tt.wantIDs)
+			}
+			want := make(map[int64]bool)
+			for _, id := range tt.wantIDs {
+				want[id] = true
+			}
+			for _, id := range ids {
+				if !want[id] {
+					t.Errorf("unexpected track %d in %v", id, ids)
+				}
+			}
+		})
+	}
+}
+
+func TestGetByTag(t *testing.T) {
+	repo := setupTestRepo(t)
+	_ = repo.SetTags(1, []string{"piano"})
+	_ = repo.SetTags(3, []string{"piano"})
+
+	all, err := repo.GetByTag("Piano", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("got %d tracks across moods, want 2", len(all))
+	}
+
+	calm, err := repo.GetByTag("piano", "calm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calm) != 1 || calm[0].ID != 3 {
+		t.Errorf("got %d calm tracks, want only track 3", len(calm))
+	}
+}
+
+func TestGetTagCounts(t *testing.T) {
+	repo := setupTestRepo(t)
+	_ = repo.SetTags(1, []string{"piano", "rain"})
+	_ = repo.SetTags(3, []string{"piano"})
+	_ = repo.SetTags(4, []string{"rain"}) // pending, not counted
+
+	counts, err := repo.GetTagCounts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TagCount{{Tag: "piano", TrackCount: 2}, {Tag: "rain", TrackCount: 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %+v, want %+v", counts, want)
+	}
+}
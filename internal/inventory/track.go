@@ -2,6 +2,8 @@ package inventory
 
 import (
 	"database/sql"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,9 @@ type Track struct {
 
 	// AudioURL is the resolved playable URL (computed at runtime, not stored)
 	AudioURL string `json:"audio_url,omitempty"`
+	// PlaylistID identifies the generated playlist the track was served in
+	// (set at runtime, not stored)
+	PlaylistID string `json:"playlist_id,omitempty"`
 
 	// Display metadata
 	Title  *string `json:"title,omitempty"`
@@ -31,12 +36,27 @@ type Track struct {
 	// Content
 	Lyrics *string `json:"lyrics,omitempty"`
 
+	// Cross-mood descriptors (normalized, sorted)
+	Tags []string `json:"tags,omitempty"`
+
 	// Audio properties
 	DurationSeconds int `json:"duration_seconds"`
+	// ContentHash is the hex SHA-256 of the audio file (nil until hashed).
+	// It stays out of JSON: listeners have no use for it, and admin
+	// duplicate reports carry it per group.
+	ContentHash *string `json:"-"`
+	// Crossfade lead-in and lead-out in milliseconds (nil until set)
+	FadeInMs  *int `json:"fade_in_ms,omitempty"`
+	FadeOutMs *int `json:"fade_out_ms,omitempty"`
+	// FileMissingAt is when the audio file was last found missing (nil
+	// while it's present)
+	FileMissingAt *time.Time `json:"file_missing_at,omitempty"`
 
 	// Status and tracking
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	Status string `json:"status"`
+	// RolloutPercent is the share of listeners (0-100) served a beta track
+	RolloutPercent int       `json:"rollout_percent"`
+	CreatedAt      time.Time `json:"created_at"`
 
 	// Play stats (sourced from play_stats table via LEFT JOIN, not from tracks)
 	PlayCount    int        `json:"play_count"`
@@ -58,10 +78,16 @@ type scanTrack struct {
 	TimeAffinity    sql.NullString
 	Lyrics          sql.NullString
 	DurationSeconds int
+	ContentHash     sql.NullString
+	FadeInMs        sql.NullInt64
+	FadeOutMs       sql.NullInt64
+	FileMissingAt   sql.NullTime
 	Status          string
+	RolloutPercent  int
 	PlayCount       int
 	LastPlayedAt    sql.NullTime
 	CreatedAt       time.Time
+	Tags            sql.NullString
 }
 
 func (s *scanTrack) toTrack() *Track {
@@ -73,6 +99,7 @@ func (s *scanTrack) toTrack() *Track {
 		HasVocals:       s.HasVocals == 1,
 		DurationSeconds: s.DurationSeconds,
 		Status:          s.Status,
+		RolloutPercent:  s.RolloutPercent,
 		PlayCount:       s.PlayCount,
 		CreatedAt:       s.CreatedAt,
 	}
@@ -99,15 +126,83 @@ func (s *scanTrack) toTrack() *Track {
 	if s.Lyrics.Valid {
 		t.Lyrics = &s.Lyrics.String
 	}
+	if s.ContentHash.Valid {
+		t.ContentHash = &s.ContentHash.String
+	}
+	if s.FadeInMs.Valid {
+		v := int(s.FadeInMs.Int64)
+		t.FadeInMs = &v
+	}
+	if s.FadeOutMs.Valid {
+		v := int(s.FadeOutMs.Int64)
+		t.FadeOutMs = &v
+	}
+	if s.FileMissingAt.Valid {
+		t.FileMissingAt = &s.FileMissingAt.Time
+	}
 	if s.LastPlayedAt.Valid {
 		t.LastPlayedAt = &s.LastPlayedAt.Time
 	}
+	if s.Tags.Valid && s.Tags.String != "" {
+		t.Tags = strings.Split(s.Tags.String, ",")
+		sort.Strings(t.Tags)
+	}
 	return t
 }
 
+// TrackFilter narrows the tracks returned by GetByMood
+type TrackFilter struct {
+	InstrumentalOnly bool     // only tracks with has_vocals=0
+	Tags             []string // normalized; a track must carry every tag (AND)
+
+	// Inclusive intensity bounds; 0 leaves that side open. When either bound
+	// is set, tracks without an intensity are excluded.
+	IntensityMin int
+	IntensityMax int
+
+	// Inclusive tempo bounds in BPM, with the same rules as intensity
+	BPMMin int
+	BPMMax int
+
+	// Sort orders the tracks by tempo (SortBPMAsc or SortBPMDesc), ties by
+	// ID, instead of least-played first. Tracks without a tempo come last.
+	Sort string
+
+	// IncludeBeta adds the beta tracks whose rollout percentage is above
+	// RolloutBucket (see RolloutBucket). The zero value is an anonymous
+	// listener, who never gets beta tracks.
+	IncludeBeta   bool
+	RolloutBucket int
+}
+
+// Intensity scale bounds
+const (
+	MinIntensity = 1
+	MaxIntensity = 10
+)
+
+// Tempo bounds, in beats per minute
+const (
+	MinBPM = 1
+	MaxBPM = 400
+)
+
+// MaxFadeMs caps a crossfade lead-in or lead-out, in milliseconds
+const MaxFadeMs = 30000
+
+// Track sort orders
+const (
+	SortBPMAsc  = "bpm_asc"
+	SortBPMDesc = "bpm_desc"
+)
+
 // Status constants
 const (
 	StatusApproved = "approved"
+	StatusPending  = "pending"
+	StatusRejected = "rejected"
+	// StatusBeta tracks are served only to a rollout share of listeners
+	StatusBeta = "beta"
 )
 
 // ListenEvent represents a single listen engagement event
@@ -117,11 +212,54 @@ type ListenEvent struct {
 	EventType        string `json:"event"`
 	ListenSeconds    int    `json:"listen_seconds"`
 	PlaylistPosition *int   `json:"position,omitempty"`
+	SessionID        string `json:"session_id,omitempty"`
+	// PlaylistID is the playlist_id of the playlist the track was served in
+	PlaylistID string `json:"playlist_id,omitempty"`
+	// PositionSeconds is the playback offset within the track, for resume
+	PositionSeconds *int `json:"position_seconds,omitempty"`
+	// Reason is why a skip event skipped, one of the SkipReason* values;
+	// other events carry none
+	Reason string `json:"reason,omitempty"`
+
+	// Client identifies the player; Platform is one of the Platform* values
+	Client ClientInfo `json:"client,omitzero"`
+	// ClientID is the listener cookie ID, set by the server and never
+	// taken from the request body
+	ClientID string `json:"-"`
+	// Station is the station the play came through, set by the server from
+	// the request path; "" outside a station
+	Station string `json:"-"`
 }
 
+// ClientInfo describes the player that sent a listen event
+type ClientInfo struct {
+	Platform   string `json:"platform,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+}
+
+// Client platform constants for listen events
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+	PlatformOther   = "other"
+)
+
 // Listen event type constants
 const (
 	EventPlay     = "play"
 	EventSkip     = "skip"
 	EventComplete = "complete"
+	// EventProgress reports the playback position for resuming; it updates
+	// listener state only and is never stored as a listen event
+	EventProgress = "progress"
+)
+
+// Skip reason constants for skip events
+const (
+	SkipReasonDislike   = "dislike"
+	SkipReasonWrongMood = "wrong_mood"
+	SkipReasonTooLong   = "too_long"
+	SkipReasonRepeat    = "repeat"
+	SkipReasonOther     = "other"
 )
@@ -0,0 +1,202 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/1mb-dev/driftfm/internal/mood"
+)
+
+// Errors returned by UpdateTrack
+var (
+	ErrTrackNotFound = errors.New("track not found")
+	ErrInvalidField  = errors.New("invalid field")
+)
+
+// editableFields maps each column UpdateTrack may change to its value converter.
+// Converters return the value to bind, or an error for bad input.
+var editableFields = map[string]func(any) (any, error){
+	"title":           nullableString,
+	"artist":          nullableString,
+	"mood":            knownMood,
+	"energy":          oneOf("low", "medium", "high"),
+	"tempo_bpm":       nullableInt(MinBPM, MaxBPM),
+	"has_vocals":      boolInt,
+	"intensity":       nullableInt(MinIntensity, MaxIntensity),
+	"time_affinity":   oneOf("morning", "afternoon", "evening", "night", "any"),
+	"status":          oneOf(StatusApproved, StatusPending, StatusRejected, StatusBeta),
+	"rollout_percent": requiredInt(0, rolloutBuckets),
+	"fade_in_ms":      nullableInt(0, MaxFadeMs),
+	"fade_out_ms":     nullableInt(0, MaxFadeMs),
+}
+
+// UpdateTrack updates only the provided columns of a track. Keys must be in
+// the editable whitelist; values are validated before anything is written.
+// A status change is recorded in track_status_history under actor and reason
+// in the same transaction, so the audit log never disagrees with the track.
+// Returns ErrInvalidField or ErrTrackNotFound (wrapped) on bad input.
+func (r *Repository) UpdateTrack(id int64, fields map[string]any, actor, reason string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: no fields to update", ErrInvalidField)
+	}
+
+	// Sorted for a stable statement (and stable error for multiple bad keys)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sets := make([]string, 0, len(names))
+	args := make([]any, 0, len(names)+1)
+	var newStatus string
+	for _, name := range names {
+		convert, ok := editableFields[name]
+		if !ok {
+			return fmt.Errorf("%w: %s is not editable", ErrInvalidField, name)
+		}
+		v, err := convert(fields[name])
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidField, name, err)
+		}
+		if name == "status" {
+			newStatus = v.(string)
+		}
+		sets = append(sets, name+" = ?")
+		args = append(args, v)
+	}
+	args = append(args, id)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var oldStatus string
+	err = tx.QueryRow(`SELECT status FROM tracks WHERE id = ?`, id).Scan(&oldStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: id %d", ErrTrackNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read track status: %w", err)
+	}
+
+	query := fmt.Sprintf(`UPDATE tracks SET %s WHERE id = ?`, strings.Join(sets, ", "))
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to update track: %w", err)
+	}
+
+	if newStatus != "" && newStatus != oldStatus {
+		change := StatusChange{
+			TrackID:   id,
+			OldStatus: oldStatus,
+			NewStatus: newStatus,
+			Actor:     actor,
+			Reason:    reason,
+		}
+		if err := r.recordStatusChangeTx(tx, change); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit track update: %w", err)
+	}
+	return nil
+}
+
+func nullableString(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("expected string")
+	}
+	return s, nil
+}
+
+func requiredString(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok || strings.TrimSpace(s) == "" {
+		return nil, errors.New("expected non-empty string")
+	}
+	return s, nil
+}
+
+// knownMood accepts only moods in the registry, so a typo can't move a
+// track into a mood no playlist serves
+func knownMood(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok || !mood.Known(s) {
+		return nil, fmt.Errorf("must be one of %s", strings.Join(mood.Names(), ", "))
+	}
+	return s, nil
+}
+
+func oneOf(allowed ...string) func(any) (any, error) {
+	return func(v any) (any, error) {
+		s, ok := v.(string)
+		if ok {
+			for _, a := range allowed {
+				if s == a {
+					return s, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// nullableInt accepts integers (JSON numbers decode as float64) within [lo, hi], or nil.
+func nullableInt(lo, hi int) func(any) (any, error) {
+	return func(v any) (any, error) {
+		var n int
+		switch x := v.(type) {
+		case nil:
+			return nil, nil
+		case int:
+			n = x
+		case int64:
+			n = int(x)
+		case float64:
+			if x != math.Trunc(x) {
+				return nil, errors.New("expected integer")
+			}
+			n = int(x)
+		default:
+			return nil, errors.New("expected integer")
+		}
+		if n < lo || n > hi {
+			return nil, fmt.Errorf("must be %d-%d", lo, hi)
+		}
+		return n, nil
+	}
+}
+
+// requiredInt is nullableInt for a NOT NULL column
+func requiredInt(lo, hi int) func(any) (any, error) {
+	convert := nullableInt(lo, hi)
+	return func(v any) (any, error) {
+		if v == nil {
+			return nil, errors.New("expected integer")
+		}
+		return convert(v)
+	}
+}
+
+func boolInt(v any) (any, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return nil, errors.New("expected boolean")
+	}
+	if b {
+		return 1, nil
+	}
+	return 0, nil
+}
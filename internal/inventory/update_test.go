@@ -0,0 +1,105 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateTrack_SingleField(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if err := repo.UpdateTrack(1, map[string]any{"title": "Corrected"}, "tester", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+
+	track, _ := repo.GetByID(1)
+	if track.Title == nil || *track.Title != "Corrected" {
+		t.Errorf("title = %v, want %q", track.Title, "Corrected")
+	}
+	// Untouched columns keep their values
+	if track.Mood != "focus" || track.DurationSeconds != 180 {
+		t.Errorf("unexpected side effects: mood=%q duration=%d", track.Mood, track.DurationSeconds)
+	}
+}
+
+func TestUpdateTrack_MultipleFields(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	err := repo.UpdateTrack(2, map[string]any{
+		"mood":       "calm",
+		"has_vocals": false,
+		"intensity":  float64(7), // as decoded from JSON
+		"tempo_bpm":  nil,
+	}, "tester", "")
+	if err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+
+	track, _ := repo.GetByID(2)
+	if track.Mood != "calm" || track.HasVocals || track.Intensity == nil || *track.Intensity != 7 || track.TempoBPM != nil {
+		t.Errorf("unexpected track after update: %+v", track)
+	}
+}
+
+func TestUpdateTrack_Fades(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	track, _ := repo.GetByID(1)
+	if track.FadeInMs != nil || track.FadeOutMs != nil {
+		t.Fatalf("fades before update = %v, %v; want unset", track.FadeInMs, track.FadeOutMs)
+	}
+
+	if err := repo.UpdateTrack(1, map[string]any{"fade_in_ms": float64(1500), "fade_out_ms": 0}, "tester", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	track, _ = repo.GetByID(1)
+	if track.FadeInMs == nil || *track.FadeInMs != 1500 || track.FadeOutMs == nil || *track.FadeOutMs != 0 {
+		t.Errorf("fades = %v, %v; want 1500, 0", track.FadeInMs, track.FadeOutMs)
+	}
+
+	// Clearing goes back to unknown
+	if err := repo.UpdateTrack(1, map[string]any{"fade_in_ms": nil}, "tester", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	track, _ = repo.GetByID(1)
+	if track.FadeInMs != nil || track.FadeOutMs == nil {
+		t.Errorf("fades after clearing fade in = %v, %v; want nil, 0", track.FadeInMs, track.FadeOutMs)
+	}
+}
+
+func TestUpdateTrack_Rejects(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tests := []struct {
+		name    string
+		id      int64
+		fields  map[string]any
+		wantErr error
+	}{
+		{"non-whitelisted field", 1, map[string]any{"file_path": "x.mp3"}, ErrInvalidField},
+		{"whitelisted mixed with forbidden", 1, map[string]any{"title": "ok", "id": 5}, ErrInvalidField},
+		{"bad energy", 1, map[string]any{"energy": "extreme"}, ErrInvalidField},
+		{"fractional intensity", 1, map[string]any{"intensity": 4.5}, ErrInvalidField},
+		{"negative fade", 1, map[string]any{"fade_in_ms": -1}, ErrInvalidField},
+		{"fade too long", 1, map[string]any{"fade_out_ms": MaxFadeMs + 1}, ErrInvalidField},
+		{"empty mood", 1, map[string]any{"mood": " "}, ErrInvalidField},
+		{"unknown mood", 1, map[string]any{"mood": "foucs"}, ErrInvalidField},
+		{"no fields", 1, map[string]any{}, ErrInvalidField},
+		{"missing track", 999, map[string]any{"title": "x"}, ErrTrackNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.UpdateTrack(tt.id, tt.fields, "tester", "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Rejected updates must not write anything
+	track, _ := repo.GetByID(1)
+	if track.Title == nil || *track.Title != "Focus Track 1" {
+		t.Errorf("title changed by rejected update: %v", track.Title)
+	}
+}
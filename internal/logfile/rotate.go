@@ -0,0 +1,254 @@
+// Package logfile provides a log file that rotates by size and can be
+// reopened after an external rotation.
+package logfile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files, sorting oldest first
+const backupTimeFormat = "20060102T150405.000"
+
+// compressSuffix marks a compressed backup
+const compressSuffix = ".gz"
+
+// Options configures a RotatingFile
+type Options struct {
+	// MaxSize rotates the file before a write would take it past this many
+	// bytes; 0 never rotates
+	MaxSize int64
+	// MaxBackups is how many rotated files to keep; 0 keeps them all
+	MaxBackups int
+	// Compress gzips rotated files in the background
+	Compress bool
+}
+
+// RotatingFile is an io.Writer appending to a file. When a write would
+// exceed MaxSize the file is renamed to <name>.<timestamp> and a new one
+// started; the oldest backups beyond MaxBackups are removed. Writes are
+// safe for concurrent use, and a single write is never split across files.
+type RotatingFile struct {
+	name string
+	opts Options
+	now  func() time.Time
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	lastBackup time.Time
+
+	// mill compresses and prunes backups off the write path, one pass at a time
+	millMu sync.Mutex
+	millWG sync.WaitGroup
+}
+
+// Open opens (or creates) name for appending
+func Open(name string, opts Options) (*RotatingFile, error) {
+	f := &RotatingFile{name: name, opts: opts, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the file at f.name, picking up the size of what's there.
+// Caller must hold f.mu (or own f exclusively).
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.name), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would take the file past MaxSize.
+// A write larger than MaxSize goes to a fresh file on its own.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.opts.MaxSize {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			log.Printf("Warning: %v", err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file to a timestamped backup and starts a new
+// one. Caller must hold f.mu.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+	// Backups are named to the millisecond; rotations closer together than
+	// that still get distinct names
+	stamp := f.now().UTC().Truncate(time.Millisecond)
+	if !stamp.After(f.lastBackup) {
+		stamp = f.lastBackup.Add(time.Millisecond)
+	}
+	f.lastBackup = stamp
+	backup := f.name + "." + stamp.Format(backupTimeFormat)
+	if err := os.Rename(f.name, backup); err != nil {
+		// Keep writing to the oversized file rather than not at all
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.millWG.Add(1)
+	go func() {
+		defer f.millWG.Done()
+		f.mill()
+	}()
+	return nil
+}
+
+// Reopen closes and reopens the file by name, so writes follow a file that
+// logrotate moved away. Call it on SIGHUP.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			log.Printf("Warning: failed to close log file %s: %v", f.name, err)
+		}
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Close closes the file after any background compression finishes
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.millWG.Wait()
+	return err
+}
+
+// mill compresses uncompressed backups when enabled, then removes the
+// oldest beyond MaxBackups. Failures are logged; the next rotation retries.
+func (f *RotatingFile) mill() {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		log.Printf("Warning: failed to list log backups: %v", err)
+		return
+	}
+	if f.opts.Compress {
+		for i, b := range backups {
+			if strings.HasSuffix(b, compressSuffix) {
+				continue
+			}
+			if err := compressFile(b); err != nil {
+				log.Printf("Warning: failed to compress %s: %v", b, err)
+				continue
+			}
+			backups[i] = b + compressSuffix
+		}
+	}
+	if f.opts.MaxBackups > 0 && len(backups) > f.opts.MaxBackups {
+		for _, b := range backups[:len(backups)-f.opts.MaxBackups] {
+			if err := os.Remove(b); err != nil && !os.IsNotExist(err) {
+				log.Printf("Warning: failed to remove old log %s: %v", b, err)
+			}
+		}
+	}
+}
+
+// backups returns the rotated files of f, oldest first
+func (f *RotatingFile) backups() ([]string, error) {
+	matches, err := filepath.Glob(f.name + ".*")
+	if err != nil {
+		return nil, err
+	}
+	prefix := f.name + "."
+	var backups []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, prefix), compressSuffix)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	slices.SortFunc(backups, func(a, b string) int {
+		return strings.Compare(strings.TrimSuffix(a, compressSuffix), strings.TrimSuffix(b, compressSuffix))
+	})
+	return backups, nil
+}
+
+// compressFile gzips name to name.gz and removes the original
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(name+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(name + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	// The original is removed last, so a failure above leaves it to retry
+	if rmErr := os.Remove(name); rmErr != nil {
+		log.Printf("Warning: failed to remove %s after compressing: %v", name, rmErr)
+	}
+	return nil
+}
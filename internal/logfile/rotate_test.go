@@ -0,0 +1,190 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTest opens a RotatingFile in a temp dir with a clock advancing a
+// second per rotation
+func openTest(t *testing.T, opts Options) (*RotatingFile, string) {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "access.log")
+	f, err := Open(name, opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return f, name
+}
+
+func write(t *testing.T, f *RotatingFile, s string) {
+	t.Helper()
+	if _, err := f.Write([]byte(s)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func backups(t *testing.T, f *RotatingFile) []string {
+	t.Helper()
+	f.millWG.Wait()
+	b, err := f.backups()
+	if err != nil {
+		t.Fatalf("backups failed: %v", err)
+	}
+	return b
+}
+
+func TestRotatingFile_RotatesAtMaxSize(t *testing.T) {
+	f, name := openTest(t, Options{MaxSize: 10})
+
+	// Writes up to exactly MaxSize stay in one file
+	write(t, f, "12345")
+	write(t, f, "67890")
+	if got := backups(t, f); len(got) != 0 {
+		t.Fatalf("rotated at the threshold: %v", got)
+	}
+
+	// The next byte would cross it, so the file rotates first
+	write(t, f, "a")
+	got := backups(t, f)
+	if len(got) != 1 {
+		t.Fatalf("backups = %v, want one", got)
+	}
+	if s := readFile(t, got[0]); s != "1234567890" {
+		t.Errorf("backup = %q, want the full first file", s)
+	}
+	if s := readFile(t, name); s != "a" {
+		t.Errorf("current file = %q, want \"a\"", s)
+	}
+
+	// An oversized write lands whole in a fresh file
+	write(t, f, strings.Repeat("x", 25))
+	if s := readFile(t, name); s != strings.Repeat("x", 25) {
+		t.Errorf("current file = %q, want the oversized write alone", s)
+	}
+}
+
+func TestRotatingFile_ResumesSizeOfExistingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(name, []byte("12345678"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(name, Options{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+
+	write(t, f, "abc")
+	if got := backups(t, f); len(got) != 1 {
+		t.Errorf("backups = %v, want the existing content rotated", got)
+	}
+}
+
+func TestRotatingFile_PrunesAndCompresses(t *testing.T) {
+	f, _ := openTest(t, Options{MaxSize: 4, MaxBackups: 2, Compress: true})
+	for _, s := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+		write(t, f, s)
+	}
+
+	got := backups(t, f)
+	if len(got) != 2 {
+		t.Fatalf("backups = %v, want the newest two", got)
+	}
+	for i, want := range []string{"bbbb", "cccc"} {
+		if !strings.HasSuffix(got[i], ".gz") {
+			t.Fatalf("backup %s not compressed", got[i])
+		}
+		file, err := os.Open(got[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("gzip.NewReader failed: %v", err)
+		}
+		b, _ := io.ReadAll(zr)
+		_ = file.Close()
+		if string(b) != want {
+			t.Errorf("backup %d = %q, want %q", i, b, want)
+		}
+	}
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	f, name := openTest(t, Options{})
+	write(t, f, "before\n")
+
+	// logrotate moves the file away, then signals
+	moved := name + ".1"
+	if err := os.Rename(name, moved); err != nil {
+		t.Fatal(err)
+	}
+	write(t, f, "still old\n")
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	write(t, f, "after\n")
+
+	if s := readFile(t, moved); s != "before\nstill old\n" {
+		t.Errorf("moved file = %q", s)
+	}
+	if s := readFile(t, name); s != "after\n" {
+		t.Errorf("reopened file = %q, want only new lines", s)
+	}
+}
+
+func TestRotatingFile_ConcurrentWrites(t *testing.T) {
+	f, name := openTest(t, Options{MaxSize: 100})
+	line := strings.Repeat("z", 9) + "\n"
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				if _, err := f.Write([]byte(line)); err != nil {
+					t.Errorf("Write failed: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	total := 0
+	for _, b := range append(backups(t, f), name) {
+		s := readFile(t, b)
+		if len(s) > 100 {
+			t.Errorf("%s is %d bytes, over MaxSize", b, len(s))
+		}
+		for _, l := range strings.SplitAfter(s, "\n") {
+			if l != "" && l != line {
+				t.Errorf("%s has a torn line %q", b, l)
+			}
+		}
+		total += len(s)
+	}
+	if total != 8*50*len(line) {
+		t.Errorf("wrote %d bytes across files, want %d", total, 8*50*len(line))
+	}
+}
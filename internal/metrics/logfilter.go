@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"cmp"
+	"io"
+	"log"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultSkipPaths are the request paths left out of the access log by default
+var DefaultSkipPaths = []string{"/health", "/ready"}
+
+// DefaultSkipExtensions are the static asset extensions left out of the
+// access log by default
+var DefaultSkipExtensions = []string{
+	".css", ".js", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico",
+	".woff", ".woff2", ".ttf", ".eot", ".map", ".webp", ".mp3", ".webm",
+}
+
+// sampleRule logs a fraction rate of requests under prefix
+type sampleRule struct {
+	prefix string
+	rate   float64
+}
+
+// LogFilter decides which requests get an access log line. Rules are
+// compiled once by NewLogFilter so the per-request check is map lookups and
+// prefix comparisons.
+type LogFilter struct {
+	exact    map[string]bool
+	prefixes []string
+	exts     map[string]bool
+	samples  []sampleRule // longest prefix first
+	rand     func() float64
+	out      *log.Logger // nil logs through the standard logger
+}
+
+// NewLogFilter compiles access log rules. A skipPaths entry ending in "*"
+// matches any path with that prefix; others match exactly. Extensions match
+// case-insensitively. sampleRates maps a path prefix to the fraction of its
+// requests logged, the longest matching prefix winning.
+func NewLogFilter(skipPaths, skipExts []string, sampleRates map[string]float64) *LogFilter {
+	f := &LogFilter{
+		exact: make(map[string]bool),
+		exts:  make(map[string]bool, len(skipExts)),
+		rand:  rand.Float64,
+	}
+	for _, p := range skipPaths {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			f.prefixes = append(f.prefixes, prefix)
+		} else {
+			f.exact[p] = true
+		}
+	}
+	for _, ext := range skipExts {
+		f.exts[strings.ToLower(ext)] = true
+	}
+	for prefix, rate := range sampleRates {
+		f.samples = append(f.samples, sampleRule{prefix, rate})
+	}
+	slices.SortFunc(f.samples, func(a, b sampleRule) int {
+		return cmp.Or(cmp.Compare(len(b.prefix), len(a.prefix)), strings.Compare(a.prefix, b.prefix))
+	})
+	return f
+}
+
+// DefaultLogFilter skips probes and static assets and logs everything else
+func DefaultLogFilter() *LogFilter {
+	return NewLogFilter(DefaultSkipPaths, DefaultSkipExtensions, nil)
+}
+
+// SetOutput sends access log lines to w, in the standard logger's format,
+// instead of the standard logger; errors and warnings stay where they were.
+// Call it before serving.
+func (f *LogFilter) SetOutput(w io.Writer) {
+	f.out = log.New(w, "", log.LstdFlags)
+}
+
+// print writes an access log line
+func (f *LogFilter) print(line string) {
+	if f.out != nil {
+		f.out.Print(line)
+		return
+	}
+	log.Print(line)
+}
+
+// Skip reports whether the request path p should be left out of the log
+func (f *LogFilter) Skip(p string) bool {
+	if f.exact[p] {
+		return true
+	}
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	if len(f.exts) > 0 && f.exts[strings.ToLower(path.Ext(p))] {
+		return true
+	}
+	for _, s := range f.samples {
+		if strings.HasPrefix(p, s.prefix) {
+			return f.rand() >= s.rate
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogFilter_Skip(t *testing.T) {
+	f := NewLogFilter(
+		[]string{"/health", "/api/config", "/internal/*"},
+		[]string{".CSS", ".mp3"},
+		nil,
+	)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/health", true},
+		{"/healthz", false}, // exact entries don't prefix-match
+		{"/api/config", true},
+		{"/api/config/x", false},
+		{"/internal/", true},
+		{"/internal/debug/vars", true},
+		{"/internals", false},
+		{"/style.css", true},
+		{"/audio/tracks/a/song.MP3", true},
+		{"/app.js", false},
+		{"/api/moods", false},
+	}
+	for _, tt := range tests {
+		if got := f.Skip(tt.path); got != tt.want {
+			t.Errorf("Skip(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLogFilter_SampleLongestPrefixWins(t *testing.T) {
+	f := NewLogFilter([]string{"/api/moods/quiet/playlist"}, nil, map[string]float64{
+		"/api/":        1,
+		"/api/moods/":  0.1,
+		"/api/moods/x": 0,
+	})
+	roll := 0.5
+	f.rand = func() float64 { return roll }
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/api/tags", false},                // /api/ logs everything
+		{"/api/moods/focus/playlist", true}, // 0.5 >= 0.1
+		{"/api/moods/x", true},              // rate 0 never logs
+		{"/api/moods/quiet/playlist", true}, // skip list beats sampling
+		{"/metrics", false},                 // no rule
+	}
+	for _, tt := range tests {
+		if got := f.Skip(tt.path); got != tt.want {
+			t.Errorf("Skip(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	roll = 0.05
+	if f.Skip("/api/moods/focus/playlist") {
+		t.Error("roll 0.05 under rate 0.1 should be logged")
+	}
+}
+
+func TestLogFilter_MiddlewareCountsSkipped(t *testing.T) {
+	m := &Metrics{}
+	old := global
+	global = m
+	t.Cleanup(func() { global = old })
+
+	var buf bytes.Buffer
+	oldOut := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(oldOut) })
+
+	f := NewLogFilter([]string{"/api/config"}, nil, map[string]float64{"/api/moods/": 0.1})
+	rolls := []float64{0.9, 0.05}
+	f.rand = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+	handler := f.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, p := range []string{"/api/config", "/api/moods/focus/playlist", "/api/moods/calm/playlist"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	if got := atomic.LoadUint64(&m.requestsTotal); got != 3 {
+		t.Errorf("requestsTotal = %d, want 3 (skipped requests still count)", got)
+	}
+	logged := buf.String()
+	if strings.Contains(logged, "/api/config") || strings.Contains(logged, "/focus/") {
+		t.Errorf("skipped requests were logged:\n%s", logged)
+	}
+	if !strings.Contains(logged, "/api/moods/calm/playlist") {
+		t.Errorf("sampled-in request missing from log:\n%s", logged)
+	}
+}
+
+func TestLogFilter_MiddlewareLogsTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	oldOut := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(oldOut) })
+
+	handler := NewLogFilter(nil, nil, nil).Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	for _, flags := range []trace.TraceFlags{trace.FlagsSampled, 0} {
+		buf.Reset()
+		sc := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     trace.SpanID{0, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			TraceFlags: flags,
+		})
+		req := httptest.NewRequest(http.MethodGet, "/api/moods", nil)
+		req = req.WithContext(trace.ContextWithSpanContext(req.Context(), sc))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		want := flags.IsSampled()
+		if got := strings.Contains(buf.String(), "trace_id="+traceID.String()); got != want {
+			t.Errorf("sampled=%v: trace_id logged = %v, want %v:\n%s", flags.IsSampled(), got, want, buf.String())
+		}
+	}
+}
+
+func TestLogFilter_SetOutput(t *testing.T) {
+	var stderr bytes.Buffer
+	oldOut := log.Writer()
+	log.SetOutput(&stderr)
+	t.Cleanup(func() { log.SetOutput(oldOut) })
+
+	var access bytes.Buffer
+	f := NewLogFilter(nil, nil, nil)
+	f.SetOutput(&access)
+	handler := f.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/moods", nil))
+
+	if !strings.Contains(access.String(), "GET /api/moods 418") {
+		t.Errorf("access output = %q, want the request line", access.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("standard logger got %q, want nothing", stderr.String())
+	}
+}
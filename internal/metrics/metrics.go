@@ -11,12 +11,17 @@ type Metrics struct {
 	startTime time.Time
 
 	// Request counters
-	requestsTotal   uint64
-	requestsSuccess uint64
-	requestsError   uint64
+	requestsTotal       uint64
+	requestsSuccess     uint64
+	requestsClientError uint64 // 4xx
+	requestsServerError uint64 // 5xx, the alert-worthy ones
+	bodyTooLarge        uint64 // requests rejected for an oversized body
+	adminAuthFail       uint64 // /api/admin/ requests without a valid token
 
 	// Audio metrics
-	playsTotal uint64
+	playsTotal     uint64
+	downloadsTotal uint64 // track downloads, counted apart from plays
+	radioRuleDrops uint64 // tracks kept out of playlists by radio rules
 
 	// Latency tracking
 	mu           sync.RWMutex
@@ -37,10 +42,13 @@ func Get() *Metrics {
 // RecordRequest records a request with status and latency
 func (m *Metrics) RecordRequest(status int, latency time.Duration) {
 	atomic.AddUint64(&m.requestsTotal, 1)
-	if status >= 200 && status < 400 {
+	switch {
+	case status >= 200 && status < 400:
 		atomic.AddUint64(&m.requestsSuccess, 1)
-	} else if status >= 400 {
-		atomic.AddUint64(&m.requestsError, 1)
+	case status >= 400 && status < 500:
+		atomic.AddUint64(&m.requestsClientError, 1)
+	case status >= 500:
+		atomic.AddUint64(&m.requestsServerError, 1)
 	}
 
 	m.mu.Lock()
@@ -54,6 +62,28 @@ func (m *Metrics) RecordPlay() {
 	atomic.AddUint64(&m.playsTotal, 1)
 }
 
+// RecordDownload records a track file download
+func (m *Metrics) RecordDownload() {
+	atomic.AddUint64(&m.downloadsTotal, 1)
+}
+
+// RecordBodyTooLarge counts a request rejected for exceeding its body limit
+func (m *Metrics) RecordBodyTooLarge() {
+	atomic.AddUint64(&m.bodyTooLarge, 1)
+}
+
+// RecordAdminAuthFailure counts an admin request rejected for a missing or
+// invalid bearer token
+func (m *Metrics) RecordAdminAuthFailure() {
+	atomic.AddUint64(&m.adminAuthFail, 1)
+}
+
+// RecordRadioRuleDrops counts tracks radio programming rules kept out of
+// a playlist
+func (m *Metrics) RecordRadioRuleDrops(n int) {
+	atomic.AddUint64(&m.radioRuleDrops, uint64(n))
+}
+
 // Snapshot returns current metrics as a map
 func (m *Metrics) Snapshot() map[string]any {
 	m.mu.RLock()
@@ -63,12 +93,20 @@ func (m *Metrics) Snapshot() map[string]any {
 	}
 	m.mu.RUnlock()
 
+	clientErrors := atomic.LoadUint64(&m.requestsClientError)
+	serverErrors := atomic.LoadUint64(&m.requestsServerError)
 	return map[string]any{
-		"uptime_seconds":   time.Since(m.startTime).Seconds(),
-		"requests_total":   atomic.LoadUint64(&m.requestsTotal),
-		"requests_success": atomic.LoadUint64(&m.requestsSuccess),
-		"requests_error":   atomic.LoadUint64(&m.requestsError),
-		"plays_total":      atomic.LoadUint64(&m.playsTotal),
-		"avg_latency_ms":   avgLatency,
+		"uptime_seconds":          time.Since(m.startTime).Seconds(),
+		"requests_total":          atomic.LoadUint64(&m.requestsTotal),
+		"requests_success":        atomic.LoadUint64(&m.requestsSuccess),
+		"requests_error":          clientErrors + serverErrors, // kept for existing dashboards
+		"requests_client_error":   clientErrors,
+		"requests_server_error":   serverErrors,
+		"requests_body_too_large": atomic.LoadUint64(&m.bodyTooLarge),
+		"admin_auth_failures":     atomic.LoadUint64(&m.adminAuthFail),
+		"plays_total":             atomic.LoadUint64(&m.playsTotal),
+		"downloads_total":         atomic.LoadUint64(&m.downloadsTotal),
+		"radio_rule_drops":        atomic.LoadUint64(&m.radioRuleDrops),
+		"avg_latency_ms":          avgLatency,
 	}
 }
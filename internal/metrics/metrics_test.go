@@ -28,6 +28,27 @@ func TestRecordRequest(t *testing.T) {
 	}
 }
 
+func TestRecordRequest_ErrorClasses(t *testing.T) {
+	m := &Metrics{startTime: time.Now()}
+
+	m.RecordRequest(404, time.Millisecond)
+	m.RecordRequest(500, time.Millisecond)
+	m.RecordRequest(503, time.Millisecond)
+
+	snap := m.Snapshot()
+	want := map[string]uint64{
+		"requests_client_error": 1,
+		"requests_server_error": 2,
+		"requests_error":        3,
+		"requests_success":      0,
+	}
+	for key, n := range want {
+		if got := snap[key].(uint64); got != n {
+			t.Errorf("%s = %d, want %d", key, got, n)
+		}
+	}
+}
+
 func TestRecordPlay(t *testing.T) {
 	m := &Metrics{startTime: time.Now()}
 
@@ -42,6 +63,22 @@ func TestRecordPlay(t *testing.T) {
 	}
 }
 
+func TestRecordDownload(t *testing.T) {
+	m := &Metrics{startTime: time.Now()}
+
+	m.RecordDownload()
+	m.RecordDownload()
+
+	snap := m.Snapshot()
+
+	if snap["downloads_total"].(uint64) != 2 {
+		t.Errorf("expected 2 downloads, got %v", snap["downloads_total"])
+	}
+	if snap["plays_total"].(uint64) != 0 {
+		t.Errorf("downloads counted as plays: %v", snap["plays_total"])
+	}
+}
+
 func TestLatencyAverage(t *testing.T) {
 	m := &Metrics{startTime: time.Now()}
 
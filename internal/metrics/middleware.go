@@ -1,11 +1,16 @@
 package metrics
 
 import (
-	"log"
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
-	"path"
+	"net/netip"
 	"strings"
 	"time"
+
+	"github.com/1mb-dev/driftfm/internal/tracing"
 )
 
 // responseWriter wraps http.ResponseWriter to capture status code and bytes written.
@@ -26,6 +31,30 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Flush passes through to the underlying writer so streamed responses
+// (e.g. NDJSON exports) are not held back by the middleware.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades take over the connection through the middleware.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("underlying ResponseWriter does not support hijacking")
+	}
+	rw.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController, so
+// handlers below the middleware can set per-write deadlines
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // clientIP extracts the client IP from X-Forwarded-For (set by Caddy) or falls back to RemoteAddr.
 func clientIP(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
@@ -42,25 +71,66 @@ func clientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
-// staticExts are file extensions to skip in access logs.
-var staticExts = map[string]bool{
-	".css": true, ".js": true, ".png": true, ".jpg": true,
-	".jpeg": true, ".gif": true, ".svg": true, ".ico": true,
-	".woff": true, ".woff2": true, ".ttf": true, ".eot": true,
-	".map": true, ".webp": true, ".mp3": true, ".webm": true,
+// ClientAddr returns the client address for access control and per-client
+// bookkeeping. X-Forwarded-For is only honored when the direct peer is
+// loopback (the local reverse proxy); from any other peer the header could
+// be spoofed. Even then only the hops proxies appended are trusted: the
+// address is the rightmost entry that isn't itself loopback, as everything
+// left of it came from the client.
+func ClientAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	peer = peer.Unmap()
+
+	xff := r.Header.Values("X-Forwarded-For")
+	if !peer.IsLoopback() || len(xff) == 0 {
+		return peer, true
+	}
+	hops := strings.Split(strings.Join(xff, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		peer = hop.Unmap()
+		if !peer.IsLoopback() {
+			break
+		}
+	}
+	return peer, true
 }
 
-// skipLog returns true for health probes and static asset requests.
-func skipLog(p string) bool {
-	if p == "/health" || p == "/ready" {
-		return true
+// AllowedClient reports whether the request's client address falls within
+// one of the allowed prefixes.
+func AllowedClient(r *http.Request, allowed []netip.Prefix) bool {
+	addr, ok := ClientAddr(r)
+	if !ok {
+		return false
 	}
-	return staticExts[strings.ToLower(path.Ext(p))]
+	for _, p := range allowed {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
 }
 
 // Middleware records request latency and status for every request
-// except health/readiness probes (which skew metrics).
+// except health/readiness probes (which skew metrics), logging requests
+// not skipped by DefaultLogFilter.
 func Middleware(next http.Handler) http.Handler {
+	return DefaultLogFilter().Middleware(next)
+}
+
+// Middleware is the package Middleware with f deciding which requests are
+// logged. Requests f skips are still counted in metrics.
+func (f *LogFilter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Skip high-frequency probes
 		if r.URL.Path == "/health" || r.URL.Path == "/ready" {
@@ -76,16 +146,21 @@ func Middleware(next http.Handler) http.Handler {
 		duration := time.Since(start)
 		Get().RecordRequest(rw.status, duration)
 
-		if skipLog(r.URL.Path) {
+		if f.Skip(r.URL.Path) {
 			return
 		}
 
-		// Access log: remote_ip method path status bytes latency user_agent
-		log.Printf("%s %s %s %d %d %.3fms %q",
+		// Access log: remote_ip method path status bytes latency user_agent,
+		// then trace_id when the request was traced
+		line := fmt.Sprintf("%s %s %s %d %d %.3fms %q",
 			clientIP(r), r.Method, r.URL.RequestURI(),
 			rw.status, rw.bytes,
 			float64(duration.Microseconds())/1000.0,
 			r.UserAgent(),
 		)
+		if id := tracing.TraceID(r.Context()); id != "" {
+			line += " trace_id=" + id
+		}
+		f.print(line)
 	})
 }
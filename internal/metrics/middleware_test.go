@@ -3,6 +3,7 @@ package metrics
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"sync/atomic"
 	"testing"
 )
@@ -42,8 +43,8 @@ func TestMiddleware_StatusCapture(t *testing.T) {
 					t.Errorf("requestsSuccess = %d, want 1", got)
 				}
 			} else {
-				if got := atomic.LoadUint64(&m.requestsError); got != 1 {
-					t.Errorf("requestsError = %d, want 1", got)
+				if got := m.Snapshot()["requests_error"].(uint64); got != 1 {
+					t.Errorf("requests_error = %d, want 1", got)
 				}
 			}
 		})
@@ -74,6 +75,25 @@ func TestMiddleware_LatencyRecorded(t *testing.T) {
 	}
 }
 
+func TestMiddleware_PassesThroughFlushAndHijack(t *testing.T) {
+	var flushOK, hijackOK bool
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, flushOK = w.(http.Flusher)
+		_, hijackOK = w.(http.Hijacker)
+		w.(http.Flusher).Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/tracks/export", nil))
+
+	if !flushOK || !hijackOK {
+		t.Errorf("wrapped writer: Flusher = %v, Hijacker = %v; want both", flushOK, hijackOK)
+	}
+	if !rec.Flushed {
+		t.Error("Flush did not reach the underlying writer")
+	}
+}
+
 func TestMiddleware_SkipsProbes(t *testing.T) {
 	for _, path := range []string{"/health", "/ready"} {
 		t.Run(path, func(t *testing.T) {
@@ -96,3 +116,43 @@ func TestMiddleware_SkipsProbes(t *testing.T) {
 		})
 	}
 }
+
+func TestAllowedClient(t *testing.T) {
+	allowed := []netip.Prefix{
+		netip.MustParsePrefix("127.0.0.1/32"),
+		netip.MustParsePrefix("::1/128"),
+		netip.MustParsePrefix("10.42.0.0/16"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       bool
+	}{
+		{"loopback", "127.0.0.1:5000", "", true},
+		{"loopback v6", "[::1]:5000", "", true},
+		{"allowed scraper", "10.42.3.7:41234", "", true},
+		{"disallowed ip", "192.168.1.20:41234", "", false},
+		{"spoofed xff from untrusted peer", "192.168.1.20:41234", "10.42.3.7", false},
+		{"proxied allowed client", "127.0.0.1:5000", "10.42.3.7", true},
+		{"proxied disallowed client", "127.0.0.1:5000", "203.0.113.9, 127.0.0.1", false},
+		{"forged leftmost hop", "127.0.0.1:5000", "10.42.3.7, 203.0.113.9", false},
+		{"proxy chain to allowed client", "127.0.0.1:5000", "203.0.113.9, 10.42.3.7", true},
+		{"unparseable hop", "127.0.0.1:5000", "10.42.3.7, unknown", false},
+		{"unparseable remote", "garbage", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := AllowedClient(req, allowed); got != tt.want {
+				t.Errorf("AllowedClient(%s, xff=%q) = %v, want %v", tt.remoteAddr, tt.xff, got, tt.want)
+			}
+		})
+	}
+}
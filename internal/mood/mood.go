@@ -0,0 +1,72 @@
+// Package mood is the canonical registry of the station's moods: their
+// identifiers and built-in English display names. Request validation, the
+// default config and the API all read from it, so adding a mood is one
+// entry here. It also holds the rules mood display metadata is checked
+// against wherever it is set.
+package mood
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Mood identifiers
+const (
+	Focus     = "focus"
+	Calm      = "calm"
+	LateNight = "late_night"
+	Energize  = "energize"
+)
+
+// displayNames maps each known mood to its English display name
+var displayNames = map[string]string{
+	Focus:     "Focus",
+	Calm:      "Calm",
+	LateNight: "Late Night",
+	Energize:  "Energize",
+}
+
+// names is the sorted list of known moods
+var names = slices.Sorted(maps.Keys(displayNames))
+
+// Names returns the known mood identifiers in sorted order
+func Names() []string {
+	return slices.Clone(names)
+}
+
+// Known reports whether name is a registered mood
+func Known(name string) bool {
+	_, ok := displayNames[name]
+	return ok
+}
+
+// Set returns the known moods as a lookup set
+func Set() map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// DisplayNames returns the English display name of every known mood. The
+// map is a copy the caller may change.
+func DisplayNames() map[string]string {
+	return maps.Clone(displayNames)
+}
+
+// ValidColor reports whether c is a CSS hex color, as a mood's display
+// color must be: '#' followed by 3 or 6 hex digits
+func ValidColor(c string) bool {
+	digits, ok := strings.CutPrefix(c, "#")
+	if !ok || (len(digits) != 3 && len(digits) != 6) {
+		return false
+	}
+	for _, d := range digits {
+		if (d < '0' || d > '9') && (d < 'a' || d > 'f') && (d < 'A' || d > 'F') {
+			return false
+		}
+	}
+	return true
+}
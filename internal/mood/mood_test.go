@@ -0,0 +1,55 @@
+package mood
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	got := Names()
+	if !slices.IsSorted(got) {
+		t.Errorf("Names() = %v, want sorted", got)
+	}
+	for _, name := range []string{Focus, Calm, LateNight, Energize} {
+		if !Known(name) || !slices.Contains(got, name) {
+			t.Errorf("%q missing from the registry", name)
+		}
+	}
+	if Known("") || Known("Focus") {
+		t.Error("Known accepts a name outside the registry")
+	}
+
+	set := Set()
+	display := DisplayNames()
+	if len(set) != len(got) || len(display) != len(got) {
+		t.Fatalf("sizes differ: %d names, %d in set, %d display names", len(got), len(set), len(display))
+	}
+	for _, name := range got {
+		if !set[name] {
+			t.Errorf("Set() lacks %q", name)
+		}
+		if display[name] == "" {
+			t.Errorf("%q has no display name", name)
+		}
+	}
+
+	// Callers get copies
+	got[0] = "changed"
+	display[Focus] = "changed"
+	if Names()[0] == "changed" || DisplayNames()[Focus] == "changed" {
+		t.Error("changing a returned value changed the registry")
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for _, c := range []string{"#3b82f6", "#FFF", "#abc"} {
+		if !ValidColor(c) {
+			t.Errorf("ValidColor(%q) = false, want true", c)
+		}
+	}
+	for _, c := range []string{"", "3b82f6", "#3b82f", "#3b82f6ff", "#ggg", "blue"} {
+		if ValidColor(c) {
+			t.Errorf("ValidColor(%q) = true, want false", c)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MPEG audio versions as coded in the frame header
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// Layer III bitrates in kbit/s by bitrate index, for MPEG-1 and MPEG-2/2.5
+var (
+	mpeg1Bitrates = [15]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mpeg2Bitrates = [15]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+// Sample rates in Hz by version and sample rate index
+var mpegSampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// maxResync bounds how far mp3Silence scans past garbage for the next frame
+const maxResync = 64 << 10
+
+// mp3Frame is the part of a Layer III frame header mp3Silence needs
+type mp3Frame struct {
+	version    int
+	mono       bool
+	crc        bool
+	sampleRate int
+	length     int // whole frame in bytes, header included
+}
+
+// parseMP3Header decodes a 4-byte frame header, reporting false for anything
+// that isn't a valid Layer III header
+func parseMP3Header(h []byte) (mp3Frame, bool) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := int(h[1]>>3) & 0x3
+	layer := int(h[1]>>1) & 0x3
+	bitrateIdx := int(h[2] >> 4)
+	rateIdx := int(h[2]>>2) & 0x3
+	if version == 1 || layer != 1 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return mp3Frame{}, false
+	}
+
+	f := mp3Frame{
+		version:    version,
+		mono:       h[3]>>6 == 3,
+		crc:        h[1]&0x1 == 0,
+		sampleRate: mpegSampleRates[version][rateIdx],
+	}
+	padding := int(h[2]>>1) & 0x1
+	if version == mpeg1 {
+		f.length = 144000*mpeg1Bitrates[bitrateIdx]/f.sampleRate + padding
+	} else {
+		f.length = 72000*mpeg2Bitrates[bitrateIdx]/f.sampleRate + padding
+	}
+	return f, true
+}
+
+// samples is the number of PCM samples per channel the frame decodes to
+func (f mp3Frame) samples() int {
+	if f.version == mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// sideInfoLen is the size in bytes of the frame's side information
+func (f mp3Frame) sideInfoLen() int {
+	switch {
+	case f.version == mpeg1 && f.mono:
+		return 17
+	case f.version == mpeg1:
+		return 32
+	case f.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// silent reports whether every granule of every channel in the frame has a
+// zero part2_3_length, that is no scale factors and no Huffman-coded
+// spectrum. body is the frame after its 4-byte header.
+func (f mp3Frame) silent(body []byte) bool {
+	if f.crc {
+		body = body[2:]
+	}
+	bits := bitReader{buf: body}
+	channels := 2
+	if f.mono {
+		channels = 1
+	}
+
+	if f.version == mpeg1 {
+		bits.skip(9) // main_data_begin
+		if f.mono {
+			bits.skip(5)
+		} else {
+			bits.skip(3)
+		}
+		bits.skip(4 * channels) // scfsi
+		for range 2 * channels {
+			if bits.read(12) != 0 {
+				return false
+			}
+			bits.skip(59 - 12)
+		}
+		return true
+	}
+
+	bits.skip(8) // main_data_begin
+	bits.skip(channels)
+	for range channels {
+		if bits.read(12) != 0 {
+			return false
+		}
+		bits.skip(63 - 12)
+	}
+	return true
+}
+
+// isInfoFrame reports whether the frame is a Xing/Info/VBRI header frame,
+// which carries encoder metadata rather than audio
+func (f mp3Frame) isInfoFrame(body []byte) bool {
+	off := f.sideInfoLen()
+	if f.crc {
+		off += 2
+	}
+	if len(body) >= off+4 {
+		if tag := string(body[off : off+4]); tag == "Xing" || tag == "Info" {
+			return true
+		}
+	}
+	// VBRI sits at a fixed 32 bytes after the header
+	return len(body) >= 36 && string(body[32:36]) == "VBRI"
+}
+
+// mp3Silence walks the Layer III frames of an MP3 stream, flagging each as
+// silent or not
+func mp3Silence(r io.Reader) (Silence, error) {
+	br := bufio.NewReader(r)
+	if err := skipID3v2(br); err != nil {
+		return Silence{}, err
+	}
+
+	var silent []bool
+	var unit time.Duration
+	body := make([]byte, 0, 1441)
+	skipped := 0
+	for {
+		peek, err := br.Peek(4)
+		if err != nil {
+			break
+		}
+		if bytes.HasPrefix(peek, []byte("TAG")) {
+			break // ID3v1 trailer
+		}
+		f, ok := parseMP3Header(peek)
+		if !ok {
+			if skipped++; skipped > maxResync {
+				break
+			}
+			_, _ = br.Discard(1)
+			continue
+		}
+		skipped = 0
+
+		_, _ = br.Discard(4)
+		body = body[:f.length-4]
+		if _, err := io.ReadFull(br, body); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				break // truncated last frame
+			}
+			return Silence{}, fmt.Errorf("failed to read MP3 frame: %w", err)
+		}
+		if len(silent) == 0 && unit == 0 && f.isInfoFrame(body) {
+			unit = time.Duration(f.samples()) * time.Second / time.Duration(f.sampleRate)
+			continue
+		}
+		if unit == 0 {
+			unit = time.Duration(f.samples()) * time.Second / time.Duration(f.sampleRate)
+		}
+		silent = append(silent, f.silent(body))
+	}
+
+	if len(silent) == 0 {
+		return Silence{}, fmt.Errorf("%w: no MPEG Layer III frames", ErrUnsupported)
+	}
+	return edges(silent, unit), nil
+}
+
+// skipID3v2 discards a leading ID3v2 tag, if there is one
+func skipID3v2(br *bufio.Reader) error {
+	hdr, err := br.Peek(10)
+	if err != nil || string(hdr[0:3]) != "ID3" {
+		return nil
+	}
+	size := int(hdr[6]&0x7F)<<21 | int(hdr[7]&0x7F)<<14 | int(hdr[8]&0x7F)<<7 | int(hdr[9]&0x7F)
+	if hdr[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	if _, err := br.Discard(10 + size); err != nil {
+		return fmt.Errorf("failed to skip ID3v2 tag: %w", err)
+	}
+	return nil
+}
+
+// bitReader reads big-endian bit fields; reads past the end yield zeros
+type bitReader struct {
+	buf []byte
+	pos int // in bits
+}
+
+func (b *bitReader) read(n int) uint32 {
+	var v uint32
+	for range n {
+		v <<= 1
+		if i := b.pos / 8; i < len(b.buf) {
+			v |= uint32(b.buf[i]>>(7-b.pos%8)) & 1
+		}
+		b.pos++
+	}
+	return v
+}
+
+func (b *bitReader) skip(n int) {
+	b.pos += n
+}
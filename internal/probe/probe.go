@@ -0,0 +1,84 @@
+// Package probe inspects audio files without fully decoding them.
+//
+// Silence reports how much silent padding a file has at each end, which is
+// what a client needs to line up a crossfade. WAV files are measured by RMS
+// level over short windows of PCM samples. MP3 files are measured from frame
+// side information: a frame whose granules carry no encoded spectral data
+// decodes to digital silence. That is conservative — a quiet but non-zero
+// fade reads as sound — which errs on the side of a shorter crossfade.
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned for a file format or encoding DetectSilence can't measure
+var ErrUnsupported = errors.New("unsupported audio format")
+
+// SilenceThresholdDB is the RMS level, in dBFS, below which a PCM window
+// counts as silent
+const SilenceThresholdDB = -50.0
+
+// pcmWindow is the length of the windows PCM audio is measured over
+const pcmWindow = 10 * time.Millisecond
+
+// Silence is the silent padding found at each end of a file
+type Silence struct {
+	Lead     time.Duration // silence before the first sound
+	Trail    time.Duration // silence after the last sound
+	Duration time.Duration // length of the whole file
+}
+
+// Silent reports whether no sound was found at all. Lead then covers the
+// whole file and Trail is zero.
+func (s Silence) Silent() bool {
+	return s.Lead >= s.Duration
+}
+
+// DetectSilence measures the silent padding of the audio file at path,
+// picking the method from the file extension.
+func DetectSilence(path string) (Silence, error) {
+	var measure func(io.Reader) (Silence, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		measure = mp3Silence
+	case ".wav":
+		measure = wavSilence
+	default:
+		return Silence{}, fmt.Errorf("%w: %s", ErrUnsupported, filepath.Ext(path))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Silence{}, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return measure(f)
+}
+
+// edges converts per-unit silence flags, each unit lasting unit, to the
+// padding at each end
+func edges(silent []bool, unit time.Duration) Silence {
+	s := Silence{Duration: time.Duration(len(silent)) * unit}
+	lead := 0
+	for lead < len(silent) && silent[lead] {
+		lead++
+	}
+	s.Lead = time.Duration(lead) * unit
+	if lead == len(silent) {
+		return s
+	}
+	trail := 0
+	for trail < len(silent) && silent[len(silent)-1-trail] {
+		trail++
+	}
+	s.Trail = time.Duration(trail) * unit
+	return s
+}
@@ -0,0 +1,218 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFixture writes data to name in a temp dir and returns the path
+func writeFixture(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+// wavFixture builds a 16-bit PCM WAV of channels at rate Hz: lead of
+// near-silence (noise well under the threshold), tone of a 440 Hz sine and
+// trail of near-silence again
+func wavFixture(channels, rate int, lead, tone, trail time.Duration) []byte {
+	frames := func(d time.Duration) int { return int(d * time.Duration(rate) / time.Second) }
+	var pcm bytes.Buffer
+	sample := func(v int16) {
+		for range channels {
+			_ = binary.Write(&pcm, binary.LittleEndian, v)
+		}
+	}
+	for i := range frames(lead) {
+		sample(int16(i%5 - 2))
+	}
+	for i := range frames(tone) {
+		sample(int16(10000 * math.Sin(2*math.Pi*440*float64(i)/float64(rate))))
+	}
+	for i := range frames(trail) {
+		sample(int16(i%5 - 2))
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(36+8+4+pcm.Len()))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	for _, v := range []any{
+		uint32(16), uint16(wavFormatPCM), uint16(channels), uint32(rate),
+		uint32(rate * channels * 2), uint16(channels * 2), uint16(16),
+	} {
+		_ = binary.Write(&b, binary.LittleEndian, v)
+	}
+	// An unrelated chunk before the samples must be skipped
+	b.WriteString("LIST")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4))
+	b.WriteString("INFO")
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(pcm.Len()))
+	b.Write(pcm.Bytes())
+	return b.Bytes()
+}
+
+func TestDetectSilence_WAV(t *testing.T) {
+	tests := []struct {
+		name               string
+		channels, rate     int
+		lead, tone, trail  time.Duration
+		wantLead, wantTail time.Duration
+	}{
+		{"mono padding", 1, 8000, 500 * time.Millisecond, time.Second, 250 * time.Millisecond, 500 * time.Millisecond, 250 * time.Millisecond},
+		{"stereo padding", 2, 44100, 1200 * time.Millisecond, 2 * time.Second, 3 * time.Second, 1200 * time.Millisecond, 3 * time.Second},
+		{"no padding", 1, 16000, 0, time.Second, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFixture(t, "track.wav", wavFixture(tt.channels, tt.rate, tt.lead, tt.tone, tt.trail))
+			s, err := DetectSilence(path)
+			if err != nil {
+				t.Fatalf("DetectSilence failed: %v", err)
+			}
+			// A window straddling the edge of the tone may go either way
+			if d := s.Lead - tt.wantLead; d < -pcmWindow || d > pcmWindow {
+				t.Errorf("lead = %v, want %v", s.Lead, tt.wantLead)
+			}
+			if d := s.Trail - tt.wantTail; d < -pcmWindow || d > pcmWindow {
+				t.Errorf("trail = %v, want %v", s.Trail, tt.wantTail)
+			}
+			if want := tt.lead + tt.tone + tt.trail; s.Duration != want {
+				t.Errorf("duration = %v, want %v", s.Duration, want)
+			}
+			if s.Silent() {
+				t.Error("Silent() = true for a file with a tone")
+			}
+		})
+	}
+
+	t.Run("all silent", func(t *testing.T) {
+		path := writeFixture(t, "quiet.wav", wavFixture(1, 8000, time.Second, 0, 0))
+		s, err := DetectSilence(path)
+		if err != nil {
+			t.Fatalf("DetectSilence failed: %v", err)
+		}
+		if !s.Silent() || s.Lead != time.Second || s.Trail != 0 {
+			t.Errorf("silence = %+v, want all lead", s)
+		}
+	})
+}
+
+// Frame headers for a 417-byte MPEG-1 Layer III frame (128 kbit/s,
+// 44.1 kHz, stereo, no CRC), and a 208-byte MPEG-2 one (64 kbit/s,
+// 22.05 kHz, mono)
+var (
+	mpeg1Header = []byte{0xFF, 0xFB, 0x90, 0x00}
+	mpeg2Header = []byte{0xFF, 0xF3, 0x80, 0xC0}
+)
+
+// mp3Fixture builds a stream of frames after header: an ID3v2 tag, an Info
+// frame, lead silent frames, sound frames with coded spectrum, trail silent
+// frames and an ID3v1 tag
+func mp3Fixture(header []byte, length, lead, sound, trail int) []byte {
+	frame := func(body func([]byte)) []byte {
+		f := make([]byte, length)
+		copy(f, header)
+		body(f[4:])
+		return f
+	}
+
+	var b bytes.Buffer
+	b.Write([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20})
+	b.Write(make([]byte, 20))
+	b.Write(frame(func(body []byte) { copy(body[9:], "Info"); copy(body[32:], "Info") }))
+	for range lead {
+		b.Write(frame(func([]byte) {}))
+	}
+	for range sound {
+		// Sets bits inside the first granule's part2_3_length for either
+		// side info layout
+		b.Write(frame(func(body []byte) { body[2], body[3] = 0xFF, 0xFF }))
+	}
+	for range trail {
+		b.Write(frame(func([]byte) {}))
+	}
+	b.WriteString("TAG")
+	b.Write(make([]byte, 125))
+	return b.Bytes()
+}
+
+func TestDetectSilence_MP3(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		length int
+		unit   time.Duration
+	}{
+		{"MPEG-1 stereo", mpeg1Header, 417, 1152 * time.Second / 44100},
+		{"MPEG-2 mono", mpeg2Header, 208, 576 * time.Second / 22050},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFixture(t, "track.mp3", mp3Fixture(tt.header, tt.length, 5, 20, 3))
+			s, err := DetectSilence(path)
+			if err != nil {
+				t.Fatalf("DetectSilence failed: %v", err)
+			}
+			if s.Lead != 5*tt.unit || s.Trail != 3*tt.unit || s.Duration != 28*tt.unit {
+				t.Errorf("silence = %+v, want lead %v, trail %v, duration %v", s, 5*tt.unit, 3*tt.unit, 28*tt.unit)
+			}
+		})
+	}
+
+	t.Run("garbage between frames", func(t *testing.T) {
+		data := mp3Fixture(mpeg1Header, 417, 2, 4, 0)
+		// Splice junk in after the ID3 tag and Info frame
+		at := 30 + 417
+		data = append(data[:at:at], append([]byte{0x00, 0xFF, 0x12}, data[at:]...)...)
+		s, err := DetectSilence(writeFixture(t, "junk.mp3", data))
+		if err != nil {
+			t.Fatalf("DetectSilence failed: %v", err)
+		}
+		if unit := 1152 * time.Second / 44100; s.Lead != 2*unit || s.Trail != 0 {
+			t.Errorf("silence = %+v, want lead of 2 frames and no trail", s)
+		}
+	})
+}
+
+func TestDetectSilence_Unsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{"extension", "track.flac", []byte("fLaC")},
+		{"not a WAV", "track.wav", []byte("RIFF\x04\x00\x00\x00AVI ")},
+		{"24-bit WAV", "track.wav", func() []byte {
+			b := wavFixture(1, 8000, 0, time.Second, 0)
+			binary.LittleEndian.PutUint16(b[34:], 24)
+			return b
+		}()},
+		{"no MP3 frames", "track.mp3", bytes.Repeat([]byte{0x42}, 1000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DetectSilence(writeFixture(t, tt.file, tt.data)); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("error = %v, want ErrUnsupported", err)
+			}
+		})
+	}
+
+	if _, err := DetectSilence(filepath.Join(t.TempDir(), "missing.mp3")); err == nil || errors.Is(err, ErrUnsupported) {
+		t.Errorf("missing file error = %v, want an open error", err)
+	}
+}
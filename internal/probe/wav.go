@@ -0,0 +1,114 @@
+package probe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// WAV format tags for integer PCM
+const (
+	wavFormatPCM        = 1
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavSilence measures 16-bit PCM WAV audio by RMS level over pcmWindow
+// windows across all channels
+func wavSilence(r io.Reader) (Silence, error) {
+	br := bufio.NewReader(r)
+
+	var riff [12]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil {
+		return Silence{}, fmt.Errorf("failed to read WAV header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return Silence{}, fmt.Errorf("%w: not a RIFF/WAVE file", ErrUnsupported)
+	}
+
+	var channels, bits int
+	var sampleRate int
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			return Silence{}, fmt.Errorf("failed to find WAV data chunk: %w", err)
+		}
+		id, size := string(hdr[0:4]), int64(binary.LittleEndian.Uint32(hdr[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return Silence{}, fmt.Errorf("%w: short fmt chunk", ErrUnsupported)
+			}
+			var fmtChunk [16]byte
+			if _, err := io.ReadFull(br, fmtChunk[:]); err != nil {
+				return Silence{}, fmt.Errorf("failed to read WAV fmt chunk: %w", err)
+			}
+			format := binary.LittleEndian.Uint16(fmtChunk[0:2])
+			channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bits = int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+			if format != wavFormatPCM && format != wavFormatExtensible {
+				return Silence{}, fmt.Errorf("%w: WAV format tag %d", ErrUnsupported, format)
+			}
+			if _, err := br.Discard(int(size-16) + int(size%2)); err != nil {
+				return Silence{}, fmt.Errorf("failed to read WAV fmt chunk: %w", err)
+			}
+		case "data":
+			if channels == 0 {
+				return Silence{}, fmt.Errorf("%w: data chunk before fmt chunk", ErrUnsupported)
+			}
+			if bits != 16 || sampleRate <= 0 {
+				return Silence{}, fmt.Errorf("%w: %d-bit WAV at %d Hz", ErrUnsupported, bits, sampleRate)
+			}
+			return pcm16Silence(io.LimitReader(br, size), channels, sampleRate)
+		default:
+			if _, err := br.Discard(int(size + size%2)); err != nil {
+				return Silence{}, fmt.Errorf("failed to skip WAV %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// pcm16Silence flags each pcmWindow of interleaved little-endian 16-bit
+// samples as silent or not
+func pcm16Silence(r io.Reader, channels, sampleRate int) (Silence, error) {
+	frameBytes := 2 * channels
+	windowFrames := max(sampleRate*int(pcmWindow)/int(time.Second), 1)
+	buf := make([]byte, windowFrames*frameBytes)
+	threshold := math.Pow(10, SilenceThresholdDB/20) * math.MaxInt16
+
+	var silent []bool
+	frames := 0
+	for {
+		n, err := io.ReadFull(r, buf)
+		n -= n % frameBytes
+		if n > 0 {
+			var sum float64
+			for i := 0; i < n; i += 2 {
+				v := float64(int16(binary.LittleEndian.Uint16(buf[i:])))
+				sum += v * v
+			}
+			rms := math.Sqrt(sum / float64(n/2))
+			silent = append(silent, rms < threshold)
+			frames += n / frameBytes
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return Silence{}, fmt.Errorf("failed to read WAV samples: %w", err)
+		}
+	}
+
+	// The last window may be partial; report the exact length and keep the
+	// padding within it
+	s := edges(silent, pcmWindow)
+	s.Duration = time.Duration(frames) * time.Second / time.Duration(sampleRate)
+	s.Lead = min(s.Lead, s.Duration)
+	s.Trail = min(s.Trail, s.Duration-s.Lead)
+	return s, nil
+}
@@ -0,0 +1,63 @@
+package radio
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+)
+
+// ChunkOrderWindow is how long GetPlaylistChunk keeps one shuffle order, so
+// a client paging through a playlist sees each track once
+const ChunkOrderWindow = 10 * time.Minute
+
+// GetPlaylistChunk returns the window [offset, offset+limit) of a shuffled
+// playlist, and how many tracks the whole playlist has. Only track IDs are
+// loaded and shuffled; full rows are built for the window alone. limit <= 0
+// returns everything from offset.
+//
+// The order is seeded by the mood and the current ChunkOrderWindow, so
+// pages fetched within a window fit together without overlap. It changes
+// with the next window, or when the mood's tracks change. Recency demotion
+// and the mood's rules are not applied: both depend on state that moves
+// between pages.
+func (r *Radio) GetPlaylistChunk(ctx context.Context, filter inventory.TrackFilter, limit, offset int) ([]*inventory.Track, int, error) {
+	ids, err := tracing.Call(ctx, "inventory.TrackIDsByMood", func() ([]int64, error) {
+		return r.repo.TrackIDsByMood(r.mood, filter)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(ids)
+	offset = min(max(offset, 0), total)
+	end := total
+	if limit > 0 {
+		end = min(offset+limit, total)
+	}
+	if offset == end {
+		return []*inventory.Track{}, total, nil
+	}
+
+	rng := rand.New(rand.NewSource(r.chunkSeed()))
+	rng.Shuffle(total, func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	tracks, err := tracing.Call(ctx, "inventory.GetByIDs", func() ([]*inventory.Track, error) {
+		return r.repo.GetByIDs(ids[offset:end])
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return tracks, total, nil
+}
+
+// chunkSeed derives the shuffle seed for the mood's current order window
+func (r *Radio) chunkSeed() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.mood))
+	window := r.now().Truncate(ChunkOrderWindow).Unix()
+	return int64(h.Sum64()) ^ window
+}
@@ -0,0 +1,145 @@
+package radio
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// pageIDs fetches the whole playlist in pages of size, checking the total
+func pageIDs(t *testing.T, r *Radio, filter inventory.TrackFilter, size, wantTotal int) []int64 {
+	t.Helper()
+	var ids []int64
+	for offset := 0; ; offset += size {
+		tracks, total, err := r.GetPlaylistChunk(context.Background(), filter, size, offset)
+		if err != nil {
+			t.Fatalf("GetPlaylistChunk(%d, %d) failed: %v", size, offset, err)
+		}
+		if total != wantTotal {
+			t.Fatalf("total = %d, want %d", total, wantTotal)
+		}
+		if len(tracks) == 0 {
+			return ids
+		}
+		if len(tracks) > size {
+			t.Fatalf("page at %d has %d tracks, limit %d", offset, len(tracks), size)
+		}
+		for _, track := range tracks {
+			ids = append(ids, track.ID)
+		}
+	}
+}
+
+func TestGetPlaylistChunk_PagesCoverMood(t *testing.T) {
+	repo := openLargeRepo(t, 53)
+	radio := NewRadio(repo, "focus")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	radio.now = func() time.Time { return now }
+
+	order := pageIDs(t, radio, inventory.TrackFilter{}, 10, 53)
+	sorted := slices.Sorted(slices.Values(order))
+	want := make([]int64, 53)
+	for i := range want {
+		want[i] = int64(i + 1)
+	}
+	if !slices.Equal(sorted, want) {
+		t.Fatalf("pages covered %v, want every track once", sorted)
+	}
+	if slices.Equal(order, want) {
+		t.Error("chunked playlist is in ID order, want shuffled")
+	}
+
+	// Other page sizes within the window walk the same order
+	if got := pageIDs(t, radio, inventory.TrackFilter{}, 7, 53); !slices.Equal(got, order) {
+		t.Errorf("pages of 7 = %v, want the order of pages of 10 %v", got, order)
+	}
+	tracks, _, err := radio.GetPlaylistChunk(context.Background(), inventory.TrackFilter{}, 0, 50)
+	if err != nil {
+		t.Fatalf("GetPlaylistChunk failed: %v", err)
+	}
+	if len(tracks) != 3 || tracks[0].ID != order[50] {
+		t.Errorf("unlimited chunk from 50 = %d tracks, want the last 3", len(tracks))
+	}
+
+	// Recording plays doesn't move tracks between pages
+	radio.RecordPlay(order[0])
+	if got := pageIDs(t, radio, inventory.TrackFilter{}, 10, 53); !slices.Equal(got, order) {
+		t.Error("order changed after a play within the window")
+	}
+
+	// The next window reshuffles
+	now = now.Add(ChunkOrderWindow)
+	if got := pageIDs(t, radio, inventory.TrackFilter{}, 10, 53); slices.Equal(got, order) {
+		t.Error("order unchanged in the next window")
+	}
+}
+
+func TestGetPlaylistChunk_Filter(t *testing.T) {
+	repo := openLargeRepo(t, 20)
+	radio := NewRadio(repo, "focus")
+
+	ids := pageIDs(t, radio, inventory.TrackFilter{InstrumentalOnly: true}, 4, 10)
+	if len(ids) != 10 {
+		t.Fatalf("got %d instrumental tracks, want 10", len(ids))
+	}
+	for _, id := range ids {
+		if id%2 == 1 {
+			t.Errorf("instrumental chunk returned vocal track %d", id)
+		}
+	}
+}
+
+func TestGetPlaylistChunk_OutOfRange(t *testing.T) {
+	repo := openLargeRepo(t, 5)
+
+	tests := []struct {
+		name          string
+		mood          string
+		limit, offset int
+		want          int
+	}{
+		{"past the end", "focus", 10, 5, 0},
+		{"far past the end", "focus", 10, 100, 0},
+		{"negative offset starts at 0", "focus", 2, -3, 2},
+		{"unknown mood", "unknown", 10, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, _, err := NewRadio(repo, tt.mood).GetPlaylistChunk(context.Background(), inventory.TrackFilter{}, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("GetPlaylistChunk failed: %v", err)
+			}
+			if tracks == nil || len(tracks) != tt.want {
+				t.Errorf("got %d tracks (nil=%v), want %d", len(tracks), tracks == nil, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkGetPlaylistChunk_10k compares building a whole 10,000-track
+// playlist with materializing one 50-track page of it
+func BenchmarkGetPlaylistChunk_10k(b *testing.B) {
+	repo := openLargeRepo(b, 10000)
+	radio := NewRadio(repo, "focus")
+	radio.sampleAbove = 0
+
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("chunk", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, _, err := radio.GetPlaylistChunk(context.Background(), inventory.TrackFilter{}, 50, 5000); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
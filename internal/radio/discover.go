@@ -0,0 +1,104 @@
+package radio
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/mood"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MoodWeight is a mood's relative share of a discover playlist
+type MoodWeight struct {
+	Mood   string
+	Weight float64
+}
+
+// GetDiscoverPlaylist returns up to size tracks blended from several moods,
+// each drawn in proportion to its weight and spread evenly through the
+// playlist. Every mood's radio shuffles and demotes recent plays as usual.
+// A mood that runs short leaves its share to the others.
+func (m *Manager) GetDiscoverPlaylist(ctx context.Context, weights []MoodWeight, size int) ([]*inventory.Track, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("discover needs at least 1 mood")
+	}
+	for _, w := range weights {
+		if !mood.Known(w.Mood) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownMood, w.Mood)
+		}
+		if !(w.Weight > 0) || math.IsInf(w.Weight, 1) {
+			return nil, fmt.Errorf("weight of %s must be a positive number, got %v", w.Mood, w.Weight)
+		}
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("discover needs a size of at least 1, got %d", size)
+	}
+	ctx, span := tracing.Start(ctx, "radio.GetDiscoverPlaylist",
+		attribute.Int("moods", len(weights)), attribute.Int("size", size))
+	defer span.End()
+
+	lists := make([][]*inventory.Track, len(weights))
+	for i, w := range weights {
+		tracks, err := m.GetPlaylist(ctx, w.Mood, inventory.TrackFilter{}, size)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = tracks
+	}
+	tracks := weave(lists, weights, size)
+	stampPlaylist(tracks)
+	return tracks, nil
+}
+
+// weave draws up to size tracks from lists by smooth weighted round-robin:
+// each position goes to the list furthest behind its weighted share, so
+// lists contribute in proportion to their weights and their tracks are
+// spread out rather than bunched. An exhausted list drops out of the
+// rotation. A track in several lists is placed once.
+func weave(lists [][]*inventory.Track, weights []MoodWeight, size int) []*inventory.Track {
+	out := make([]*inventory.Track, 0, size)
+	seen := make(map[int64]bool, size)
+	next := make([]int, len(lists))
+	credit := make([]float64, len(lists))
+	live := make([]bool, len(lists))
+	for i := range lists {
+		live[i] = true
+	}
+
+	for len(out) < size {
+		var total float64
+		pick := -1
+		for i := range lists {
+			if !live[i] {
+				continue
+			}
+			credit[i] += weights[i].Weight
+			total += weights[i].Weight
+			if pick < 0 || credit[i] > credit[pick] {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			break
+		}
+		credit[pick] -= total
+
+		var t *inventory.Track
+		for t == nil && next[pick] < len(lists[pick]) {
+			if c := lists[pick][next[pick]]; !seen[c.ID] {
+				t = c
+			}
+			next[pick]++
+		}
+		if t == nil {
+			live[pick] = false
+			continue
+		}
+		seen[t.ID] = true
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package radio
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+func TestWeave(t *testing.T) {
+	focus := moodTracks("focus", 1, 50)
+	calm := moodTracks("calm", 101, 50)
+
+	t.Run("proportional", func(t *testing.T) {
+		got := weave([][]*inventory.Track{focus, calm}, []MoodWeight{{"focus", 3}, {"calm", 1}}, 40)
+		if len(got) != 40 {
+			t.Fatalf("weave returned %d tracks, want 40", len(got))
+		}
+		if n := countMood(got, "focus"); n != 30 {
+			t.Errorf("got %d focus tracks, want 30 of 40 at 3:1", n)
+		}
+		// Spread out: every run of four has a calm track
+		for i := 0; i+4 <= len(got); i += 4 {
+			if countMood(got[i:i+4], "calm") != 1 {
+				t.Errorf("positions %d-%d have %d calm tracks, want 1", i, i+3, countMood(got[i:i+4], "calm"))
+			}
+		}
+	})
+
+	t.Run("equal weights", func(t *testing.T) {
+		got := weave([][]*inventory.Track{focus, calm}, []MoodWeight{{"focus", 1}, {"calm", 1}}, 10)
+		if n := countMood(got, "focus"); n != 5 {
+			t.Errorf("got %d focus tracks, want 5 of 10", n)
+		}
+	})
+
+	t.Run("short mood", func(t *testing.T) {
+		got := weave([][]*inventory.Track{focus, calm[:2]}, []MoodWeight{{"focus", 1}, {"calm", 5}}, 10)
+		if len(got) != 10 || countMood(got, "calm") != 2 {
+			t.Errorf("got %d tracks, %d calm; want 10 with both calm tracks", len(got), countMood(got, "calm"))
+		}
+	})
+
+	t.Run("shared tracks placed once", func(t *testing.T) {
+		got := weave([][]*inventory.Track{focus[:3], focus[:3]}, []MoodWeight{{"focus", 1}, {"calm", 1}}, 10)
+		if len(got) != 3 {
+			t.Errorf("got %d tracks, want the 3 distinct ones", len(got))
+		}
+	})
+}
+
+func TestManagerGetDiscoverPlaylist(t *testing.T) {
+	m := NewManager(nil)
+	ctx := context.Background()
+	if _, err := m.GetDiscoverPlaylist(ctx, []MoodWeight{{"disco", 1}}, 10); !errors.Is(err, ErrUnknownMood) {
+		t.Errorf("unknown mood: err = %v, want ErrUnknownMood", err)
+	}
+	if _, err := m.GetDiscoverPlaylist(ctx, []MoodWeight{{"focus", 0}}, 10); err == nil {
+		t.Error("expected an error for a zero weight")
+	}
+	if _, err := m.GetDiscoverPlaylist(ctx, nil, 10); err == nil {
+		t.Error("expected an error without moods")
+	}
+}
@@ -1,53 +1,280 @@
 package radio
 
 import (
+	"context"
+	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// DefaultMaxCached is how many moods' radios a Manager keeps before evicting
+// the least recently used
+const DefaultMaxCached = 32
+
+// TrackCache keeps moods' track sets between playlists, so a playlist
+// shuffled for every request doesn't read the repository every time.
+// Tracks returns the set for mood under filter, calling load on a miss. The
+// caller may reorder the returned slice and modify its tracks.
+type TrackCache interface {
+	Tracks(ctx context.Context, mood string, filter inventory.TrackFilter, load func(ctx context.Context) ([]*inventory.Track, error)) ([]*inventory.Track, error)
+}
+
+// cachedRadio is a Manager's radio with when it was last used, as a tick of
+// the Manager's use counter
+type cachedRadio struct {
+	radio *Radio
+	used  atomic.Uint64
+}
+
 // Manager manages radios for all moods
 type Manager struct {
 	repo   *inventory.Repository
-	radios map[string]*Radio
+	radios map[string]*cachedRadio
 	mu     sync.RWMutex
+
+	// The radios map is bounded: a new mood past maxCached evicts the
+	// least recently used radio, whose recency state starts over if the
+	// mood is served again
+	maxCached int
+	uses      atomic.Uint64
+
+	// Borrowing: moods with fewer than minLength tracks are padded from fallbacks
+	minLength int
+	fallbacks map[string]string
+
+	// Playlist size bounds (0 = unlimited)
+	defaultSize int
+	maxSize     int
+
+	// Programming rules by mood
+	rules map[string]*Rules
+
+	// Leading tracks of each playlist the next one demotes
+	headMemory int
+
+	// How long a played track stays demoted
+	recentWindow time.Duration
+
+	// How strongly the shuffle and recency demotion override fairness order
+	recencyWeight float64
+
+	// Track sets shared between playlists (nil = read per playlist)
+	trackCache TrackCache
 }
 
 // NewManager creates a new radio manager
 func NewManager(repo *inventory.Repository) *Manager {
 	return &Manager{
-		repo:   repo,
-		radios: make(map[string]*Radio),
+		repo:          repo,
+		radios:        make(map[string]*cachedRadio),
+		maxCached:     DefaultMaxCached,
+		headMemory:    DefaultHeadMemory,
+		recentWindow:  DefaultRecentWindow,
+		recencyWeight: DefaultRecencyWeight,
 	}
 }
 
 // GetRadio returns the radio for a mood (creates if needed)
 func (m *Manager) GetRadio(mood string) *Radio {
 	m.mu.RLock()
-	radio, exists := m.radios[mood]
+	entry, exists := m.radios[mood]
 	m.mu.RUnlock()
 
 	if exists {
-		return radio
+		entry.used.Store(m.uses.Add(1))
+		return entry.radio
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if radio, exists = m.radios[mood]; exists {
-		return radio
+	if entry, exists = m.radios[mood]; exists {
+		entry.used.Store(m.uses.Add(1))
+		return entry.radio
+	}
+
+	if len(m.radios) >= m.maxCached {
+		m.evictLocked()
+	}
+	entry = &cachedRadio{radio: NewRadio(m.repo, mood)}
+	entry.radio.rules = m.rules[mood]
+	entry.radio.headMemory = m.headMemory
+	entry.radio.recentWindow = m.recentWindow
+	entry.radio.fairness = 1 - m.recencyWeight
+	entry.radio.trackCache = m.trackCache
+	entry.used.Store(m.uses.Add(1))
+	m.radios[mood] = entry
+	return entry.radio
+}
+
+// evictLocked drops the least recently used radio. Callers already holding
+// it keep working; its state is just no longer shared. Caller must hold m.mu
+// for writing.
+func (m *Manager) evictLocked() {
+	var oldestMood string
+	var oldest uint64
+	for mood, entry := range m.radios {
+		if used := entry.used.Load(); oldestMood == "" || used < oldest {
+			oldestMood, oldest = mood, used
+		}
+	}
+	delete(m.radios, oldestMood)
+}
+
+// SetHeadMemory sets how many leading tracks of a mood's last served
+// playlist are moved back in the next one; n below 1 selects
+// DefaultHeadMemory. Call before serving requests.
+func (m *Manager) SetHeadMemory(n int) {
+	if n < 1 {
+		n = DefaultHeadMemory
+	}
+	m.headMemory = n
+}
+
+// SetRecentWindow sets how long a played track is moved to the end of its
+// mood's playlists; d of 0 or less selects DefaultRecentWindow. Call before
+// serving requests.
+func (m *Manager) SetRecentWindow(d time.Duration) {
+	if d <= 0 {
+		d = DefaultRecentWindow
+	}
+	m.recentWindow = d
+}
+
+// SetRecencyWeight balances play-count fairness against recency in shuffled
+// playlists. At 1 (the default) tracks are shuffled, with recent plays and
+// the last playlist's head moved back; at 0 recency is ignored and tracks
+// keep the repository's order, least played first. Weights in between
+// interpolate each track's position between the two. w is clamped to 0-1.
+// Call before serving requests.
+func (m *Manager) SetRecencyWeight(w float64) {
+	if math.IsNaN(w) {
+		w = DefaultRecencyWeight
+	}
+	m.recencyWeight = min(max(w, 0), 1)
+}
+
+// SetTrackCache loads moods' track sets through c, which also skips
+// sampling large moods. Call before serving requests.
+func (m *Manager) SetTrackCache(c TrackCache) {
+	m.trackCache = c
+}
+
+// SetMaxCached bounds how many moods' radios are kept; n below 1 selects
+// DefaultMaxCached. Call before serving requests.
+func (m *Manager) SetMaxCached(n int) {
+	if n < 1 {
+		n = DefaultMaxCached
 	}
+	m.maxCached = n
+}
+
+// SetBorrowing configures mood borrowing: playlists shorter than minLength
+// are padded with tracks from the mood's fallback (fallbacks[mood]).
+// Call before serving requests.
+func (m *Manager) SetBorrowing(minLength int, fallbacks map[string]string) {
+	m.minLength = minLength
+	m.fallbacks = fallbacks
+}
+
+// SetPlaylistSize bounds playlist length: defaultSize applies when the caller
+// passes no limit, and maxSize caps any limit. 0 leaves either unbounded.
+// Call before serving requests.
+func (m *Manager) SetPlaylistSize(defaultSize, maxSize int) {
+	m.defaultSize = defaultSize
+	m.maxSize = maxSize
+}
+
+// SetRules sets per-mood programming rules, keyed by mood. Rules only take
+// effect when their mood is served, so rules for unknown moods are ignored.
+// Call before serving requests.
+func (m *Manager) SetRules(rules map[string]*Rules) {
+	m.rules = rules
+}
+
+// PlaylistSize resolves a requested limit against the default and maximum.
+// limit <= 0 selects the default; a result of 0 means unlimited.
+func PlaylistSize(limit, defaultSize, maxSize int) int {
+	if limit <= 0 {
+		limit = defaultSize
+	}
+	if maxSize > 0 && (limit <= 0 || limit > maxSize) {
+		limit = maxSize
+	}
+	return limit
+}
+
+// PlaylistSize returns how many tracks a playlist requested with limit
+// holds at most (0 = configured default), not counting beta tracks
+func (m *Manager) PlaylistSize(limit int) int {
+	return PlaylistSize(limit, m.defaultSize, m.maxSize)
+}
+
+// GetPlaylist returns up to limit tracks for a mood (0 = configured default),
+// plus any beta tracks among them, padded from its fallback mood when
+// borrowing is configured and the mood is sparse. Sorted playlists are never padded, since borrowed tracks would
+// break the order. Every track carries the new playlist's ID.
+func (m *Manager) GetPlaylist(ctx context.Context, mood string, filter inventory.TrackFilter, limit int) ([]*inventory.Track, error) {
+	size := PlaylistSize(limit, m.defaultSize, m.maxSize)
+	ctx, span := tracing.Start(ctx, "radio.GetPlaylist", attribute.String("mood", mood), attribute.Int("limit", size))
+	defer span.End()
 
-	radio = NewRadio(m.repo, mood)
-	m.radios[mood] = radio
-	return radio
+	radio := m.GetRadio(mood)
+	tracks, err := radio.GetPlaylist(ctx, filter, size)
+	if err == nil && filter.Sort == "" {
+		tracks, err = m.borrow(ctx, mood, filter, tracks, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stampPlaylist(tracks)
+	return tracks, nil
 }
 
-// GetPlaylist returns the playlist for a mood
-func (m *Manager) GetPlaylist(mood string, instrumentalOnly bool) ([]*inventory.Track, error) {
+// borrow appends tracks from the fallback mood until the playlist reaches
+// minLength (or size, if smaller). Borrowed tracks keep their own Mood, so
+// callers can tell them apart, but must pass the borrowing mood's rules.
+// Only one hop is followed, so fallback cycles are harmless.
+func (m *Manager) borrow(ctx context.Context, mood string, filter inventory.TrackFilter, tracks []*inventory.Track, size int) ([]*inventory.Track, error) {
+	target := m.minLength
+	if size > 0 && size < target {
+		target = size
+	}
+
+	fallback, ok := m.fallbacks[mood]
+	if !ok || len(tracks) >= target {
+		return tracks, nil
+	}
+
+	extra, err := m.GetRadio(fallback).GetPlaylist(ctx, filter, target)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(tracks))
+	for _, t := range tracks {
+		seen[t.ID] = true
+	}
+	combined := tracks
+	for _, t := range extra {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			combined = append(combined, t)
+		}
+	}
+
 	radio := m.GetRadio(mood)
-	return radio.GetPlaylist(instrumentalOnly)
+	radio.mu.Lock()
+	combined = radio.enforceRulesLocked(combined)
+	radio.mu.Unlock()
+
+	return truncate(combined, target), nil
 }
 
 // RecordPlay records a play for the mood's radio
@@ -55,3 +282,21 @@ func (m *Manager) RecordPlay(mood string, trackID int64) {
 	radio := m.GetRadio(mood)
 	radio.RecordPlay(trackID)
 }
+
+// ShuffleStats returns the day's shuffle accounting of the mood's radio,
+// creating the radio if it hasn't served the mood yet. A radio evicted from
+// the cache takes its counts with it.
+func (m *Manager) ShuffleStats(mood string) ShuffleStats {
+	return m.GetRadio(mood).ShuffleStats()
+}
+
+// StateSnapshot returns the mood's radio state along with its borrowing
+// configuration. The radio is created if it hasn't served the mood yet.
+func (m *Manager) StateSnapshot(mood string) StateSnapshot {
+	snap := m.GetRadio(mood).StateSnapshot()
+	if fallback, ok := m.fallbacks[mood]; ok {
+		snap.Fallback = fallback
+		snap.MinPlaylistLength = m.minLength
+	}
+	return snap
+}
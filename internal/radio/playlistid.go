@@ -0,0 +1,34 @@
+package radio
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// playlistIDBytes is the random part of a playlist ID: enough that
+// playlists generated within an analytics window don't collide
+const playlistIDBytes = 6
+
+// NewPlaylistID returns a short random ID for a generated playlist. Listen
+// events echo it back, tying skips to the exact ordering served; nothing
+// else records it.
+func NewPlaylistID() string {
+	b := make([]byte, playlistIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// stampPlaylist marks every track with one new playlist ID
+func stampPlaylist(tracks []*inventory.Track) {
+	if len(tracks) == 0 {
+		return
+	}
+	id := NewPlaylistID()
+	for _, t := range tracks {
+		t.PlaylistID = id
+	}
+}
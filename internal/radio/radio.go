@@ -1,42 +1,93 @@
 package radio
 
 import (
+	"cmp"
+	"context"
 	"math/rand"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/tracing"
 )
 
-// DefaultMaxRecent is the number of recently played tracks to remember
-// for avoiding repetition in playlist generation
-const DefaultMaxRecent = 3
+// DefaultRecentWindow is how long a played track stays demoted in
+// playlist generation
+const DefaultRecentWindow = 2 * time.Hour
+
+// DefaultMaxRecent caps how many plays within the recency window a radio
+// remembers, bounding memory in a busy mood
+const DefaultMaxRecent = 100
+
+// DefaultHeadMemory is how many leading tracks of the last served playlist
+// are demoted in the next one, so a refresh doesn't open with the same songs
+const DefaultHeadMemory = 3
+
+// DefaultRecencyWeight applies recency demotion in full; see
+// Manager.SetRecencyWeight
+const DefaultRecencyWeight = 1.0
+
+// DefaultSampleThreshold is the mood size above which a limited playlist is
+// sampled in SQL instead of loading and shuffling every track
+const DefaultSampleThreshold = 2000
+
+// recentPlay is a track played within the recency window
+type recentPlay struct {
+	id       int64
+	playedAt time.Time
+}
 
 // Radio manages playlist generation for a mood
 type Radio struct {
 	repo           *inventory.Repository
 	mood           string
-	recentlyPlayed []int64
+	recentlyPlayed []recentPlay // oldest first; pruned lazily
+	recentWindow   time.Duration
 	maxRecent      int
+	lastServedHead []int64 // first tracks of the last shuffled playlist
+	headMemory     int
+	fairness       float64 // 1 - recency weight: how far the shuffle leans back to repository order
+	sampleAbove    int     // track count above which limited playlists are sampled (0 = never)
+	rules          *Rules
+	trackCache     TrackCache     // nil = every playlist reads the repository
+	warned         map[int64]bool // tracks already logged as excluded by rules
+	stats          shuffleStats   // today's shuffle accounting, for diagnostics
 	mu             sync.Mutex
 	rng            *rand.Rand
+	now            func() time.Time // clock for the recency and chunk order windows
 }
 
 // NewRadio creates a new radio for a mood
 func NewRadio(repo *inventory.Repository, mood string) *Radio {
 	return &Radio{
-		repo:           repo,
-		mood:           mood,
-		recentlyPlayed: make([]int64, 0),
-		maxRecent:      DefaultMaxRecent,
-		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		repo:         repo,
+		mood:         mood,
+		recentWindow: DefaultRecentWindow,
+		maxRecent:    DefaultMaxRecent,
+		headMemory:   DefaultHeadMemory,
+		sampleAbove:  DefaultSampleThreshold,
+		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:          time.Now,
 	}
 }
 
-// GetPlaylist returns a shuffled playlist for the mood.
-// Recently played tracks are pushed to the end of the playlist.
-func (r *Radio) GetPlaylist(instrumentalOnly bool) ([]*inventory.Track, error) {
-	tracks, err := r.repo.GetByMood(r.mood, instrumentalOnly)
+// GetPlaylist returns a shuffled playlist for the mood, narrowed by filter.
+// Recently played tracks are pushed to the end of the playlist, after the
+// head of the previous playlist, then the mood's rules drop or reorder
+// tracks. A positive limit truncates last, so the subset stays random and
+// recently played tracks are the first dropped; beta tracks don't count
+// toward it (see truncate).
+//
+// A filter with a Sort skips the shuffle and the recency demotion: the
+// playlist keeps the repository's order, so the same request gets the same
+// tracks. The rules still apply.
+func (r *Radio) GetPlaylist(ctx context.Context, filter inventory.TrackFilter, limit int) ([]*inventory.Track, error) {
+	if filter.Sort != "" {
+		return r.sortedPlaylist(ctx, filter, limit)
+	}
+
+	tracks, err := r.candidates(ctx, filter, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -49,67 +100,251 @@ func (r *Radio) GetPlaylist(instrumentalOnly bool) ([]*inventory.Track, error) {
 	shuffled := make([]*inventory.Track, len(tracks))
 	copy(shuffled, tracks)
 
+	_, span := tracing.Start(ctx, "radio.shuffle")
 	r.mu.Lock()
-	r.shuffleWithRecencyLocked(shuffled)
-	r.mu.Unlock()
+	defer r.mu.Unlock()
+	recentHits, headHits := r.shuffleWithRecencyLocked(shuffled)
+	shuffled = r.enforceRulesLocked(shuffled)
+	span.End()
 
+	shuffled = truncate(shuffled, limit)
+	r.rememberHeadLocked(shuffled)
+	r.recordShuffleLocked(shuffled, recentHits, headHits)
 	return shuffled, nil
 }
 
-// shuffleWithRecencyLocked shuffles tracks, pushing recently played to the end.
-// Caller must hold r.mu.
-func (r *Radio) shuffleWithRecencyLocked(tracks []*inventory.Track) {
-	recentSet := make(map[int64]bool)
-	for _, id := range r.recentlyPlayed {
-		recentSet[id] = true
+// rememberHeadLocked records the first headMemory tracks of a served
+// playlist for the next shuffle to demote. Caller must hold r.mu.
+func (r *Radio) rememberHeadLocked(playlist []*inventory.Track) {
+	n := min(r.headMemory, len(playlist))
+	r.lastServedHead = r.lastServedHead[:0]
+	for _, t := range playlist[:n] {
+		r.lastServedHead = append(r.lastServedHead, t.ID)
+	}
+}
+
+// sortedPlaylist returns the mood's tracks in the filter's sort order. Every
+// track is loaded, as a random sample would make the order unrepeatable.
+func (r *Radio) sortedPlaylist(ctx context.Context, filter inventory.TrackFilter, limit int) ([]*inventory.Track, error) {
+	tracks, err := r.allTracks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	tracks = r.enforceRulesLocked(tracks)
+	r.mu.Unlock()
+
+	return truncate(tracks, limit), nil
+}
+
+// truncate cuts tracks to a positive limit, not counting beta tracks. Each
+// listener is served only the beta tracks rolled out to them, so a shared
+// playlist keeps limit other tracks and the handler cuts it to limit once
+// the listener's rollout is applied.
+func truncate(tracks []*inventory.Track, limit int) []*inventory.Track {
+	if limit <= 0 {
+		return tracks
+	}
+	n := 0
+	for i, t := range tracks {
+		if t.Status == inventory.StatusBeta {
+			continue
+		}
+		if n++; n == limit {
+			return tracks[:i+1]
+		}
+	}
+	return tracks
+}
+
+// candidates loads the tracks a playlist is drawn from. Large moods with a
+// limit are sampled so only about limit rows are read; the sample is padded
+// by the recent list's and remembered head's lengths so demoting them still
+// leaves limit fresh tracks. Smaller moods load every track, as does any
+// mood with a track cache, where the whole set is read once per cache entry.
+func (r *Radio) candidates(ctx context.Context, filter inventory.TrackFilter, limit int) ([]*inventory.Track, error) {
+	if limit <= 0 || r.sampleAbove <= 0 || r.trackCache != nil {
+		return r.allTracks(ctx, filter)
+	}
+
+	count, err := tracing.Call(ctx, "inventory.CountByMood", func() (int, error) {
+		return r.repo.CountByMood(r.mood, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count <= r.sampleAbove {
+		return r.allTracks(ctx, filter)
+	}
+
+	r.mu.Lock()
+	r.pruneRecentLocked()
+	size := limit + len(r.recentlyPlayed) + len(r.lastServedHead)
+	r.mu.Unlock()
+	return tracing.Call(ctx, "inventory.SampleByMood", func() ([]*inventory.Track, error) {
+		return r.repo.SampleByMood(r.mood, filter, size)
+	})
+}
+
+// allTracks loads every track of the mood under filter, through the track
+// cache when there is one
+func (r *Radio) allTracks(ctx context.Context, filter inventory.TrackFilter) ([]*inventory.Track, error) {
+	load := func(ctx context.Context) ([]*inventory.Track, error) {
+		return tracing.Call(ctx, "inventory.GetByMood", func() ([]*inventory.Track, error) {
+			return r.repo.GetByMood(r.mood, filter)
+		})
+	}
+	if r.trackCache == nil {
+		return load(ctx)
+	}
+	return r.trackCache.Tracks(ctx, r.mood, filter, load)
+}
+
+// shuffleWithRecencyLocked shuffles tracks, then moves the head of the last
+// served playlist after the rest and tracks played within the recency
+// window to the end, the least recently played first. With a fairness
+// share, each track's position is then interpolated between that order and
+// its position in tracks as given, the repository's least played first
+// order: fairness 1 keeps the given order untouched. Returns how many
+// recent and head tracks were demoted. Caller must hold r.mu.
+func (r *Radio) shuffleWithRecencyLocked(tracks []*inventory.Track) (recentHits, headHits int) {
+	var fairOrder map[int64]int
+	if r.fairness > 0 {
+		fairOrder = make(map[int64]int, len(tracks))
+		for i, track := range tracks {
+			fairOrder[track.ID] = i
+		}
+	}
+
+	r.pruneRecentLocked()
+	recentOrder := make(map[int64]int, len(r.recentlyPlayed))
+	for i, p := range r.recentlyPlayed {
+		recentOrder[p.id] = i
+	}
+	headSet := make(map[int64]bool)
+	for _, id := range r.lastServedHead {
+		headSet[id] = true
 	}
 
-	// Partition: non-recent first, recent last
-	nonRecent := make([]*inventory.Track, 0, len(tracks))
+	// Partition: fresh first, last served head next, recent last
+	fresh := make([]*inventory.Track, 0, len(tracks))
+	head := make([]*inventory.Track, 0)
 	recent := make([]*inventory.Track, 0)
 
 	for _, track := range tracks {
-		if recentSet[track.ID] {
+		_, isRecent := recentOrder[track.ID]
+		switch {
+		case isRecent:
 			recent = append(recent, track)
-		} else {
-			nonRecent = append(nonRecent, track)
+		case headSet[track.ID]:
+			head = append(head, track)
+		default:
+			fresh = append(fresh, track)
 		}
 	}
 
-	// Fisher-Yates shuffle for non-recent tracks
-	for i := len(nonRecent) - 1; i > 0; i-- {
-		j := r.rng.Intn(i + 1)
-		nonRecent[i], nonRecent[j] = nonRecent[j], nonRecent[i]
-	}
+	// Fisher-Yates shuffle for fresh tracks, and the head so a small mood
+	// that is all head still varies
+	r.shuffleLocked(fresh)
+	r.shuffleLocked(head)
+	slices.SortFunc(recent, func(a, b *inventory.Track) int {
+		return recentOrder[a.ID] - recentOrder[b.ID]
+	})
 
-	// Rebuild tracks slice: non-recent first, recent last
+	// Rebuild tracks slice in partition order
 	idx := 0
-	for _, track := range nonRecent {
-		tracks[idx] = track
-		idx++
+	for _, part := range [][]*inventory.Track{fresh, head, recent} {
+		for _, track := range part {
+			tracks[idx] = track
+			idx++
+		}
 	}
-	for _, track := range recent {
-		tracks[idx] = track
-		idx++
+
+	if fairOrder == nil {
+		return len(recent), len(head)
+	}
+	blended := make(map[int64]float64, len(tracks))
+	for i, track := range tracks {
+		blended[track.ID] = r.fairness*float64(fairOrder[track.ID]) + (1-r.fairness)*float64(i)
+	}
+	// Stable, so ties keep the recency order
+	slices.SortStableFunc(tracks, func(a, b *inventory.Track) int {
+		return cmp.Compare(blended[a.ID], blended[b.ID])
+	})
+	return len(recent), len(head)
+}
+
+// shuffleLocked shuffles tracks in place. Caller must hold r.mu.
+func (r *Radio) shuffleLocked(tracks []*inventory.Track) {
+	for i := len(tracks) - 1; i > 0; i-- {
+		j := r.rng.Intn(i + 1)
+		tracks[i], tracks[j] = tracks[j], tracks[i]
 	}
 }
 
-// RecordPlay records that a track was played
+// RecordPlay records that a track was played now. Playing a track again
+// within the recency window restarts its window rather than adding an entry.
 func (r *Radio) RecordPlay(trackID int64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Check if already in recent list
-	for _, id := range r.recentlyPlayed {
-		if id == trackID {
-			return
-		}
-	}
-
-	r.recentlyPlayed = append(r.recentlyPlayed, trackID)
+	r.pruneRecentLocked()
+	r.recentlyPlayed = slices.DeleteFunc(r.recentlyPlayed, func(p recentPlay) bool { return p.id == trackID })
+	r.recentlyPlayed = append(r.recentlyPlayed, recentPlay{id: trackID, playedAt: r.now()})
 
 	// Trim to max size
 	if len(r.recentlyPlayed) > r.maxRecent {
-		r.recentlyPlayed = r.recentlyPlayed[1:]
+		r.recentlyPlayed = r.recentlyPlayed[len(r.recentlyPlayed)-r.maxRecent:]
+	}
+}
+
+// pruneRecentLocked drops plays older than the recency window. Plays are
+// kept oldest first, so the expired ones are a prefix. Caller must hold r.mu.
+func (r *Radio) pruneRecentLocked() {
+	cutoff := r.now().Add(-r.recentWindow)
+	i := 0
+	for i < len(r.recentlyPlayed) && !r.recentlyPlayed[i].playedAt.After(cutoff) {
+		i++
+	}
+	r.recentlyPlayed = r.recentlyPlayed[i:]
+}
+
+// StateSnapshot is a point-in-time copy of a radio's internal state, for
+// diagnostics
+type StateSnapshot struct {
+	Mood           string  `json:"mood"`
+	RecentlyPlayed []int64 `json:"recently_played"` // within the recency window, oldest first
+	RecentWindow   string  `json:"recent_window"`
+	MaxRecent      int     `json:"max_recent"`
+	LastServedHead []int64 `json:"last_served_head"`
+	HeadMemory     int     `json:"head_memory"`
+	RecencyWeight  float64 `json:"recency_weight"`
+	SampleAbove    int     `json:"sample_above"`
+
+	// Borrowing, filled in by Manager.StateSnapshot
+	Fallback          string `json:"fallback,omitempty"`
+	MinPlaylistLength int    `json:"min_playlist_length,omitempty"`
+}
+
+// StateSnapshot copies the radio's state under its lock
+func (r *Radio) StateSnapshot() StateSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pruneRecentLocked()
+	recent := make([]int64, len(r.recentlyPlayed))
+	for i, p := range r.recentlyPlayed {
+		recent[i] = p.id
+	}
+	return StateSnapshot{
+		Mood:           r.mood,
+		RecentlyPlayed: recent,
+		RecentWindow:   r.recentWindow.String(),
+		MaxRecent:      r.maxRecent,
+		LastServedHead: append([]int64{}, r.lastServedHead...),
+		HeadMemory:     r.headMemory,
+		RecencyWeight:  1 - r.fairness,
+		SampleAbove:    r.sampleAbove,
 	}
 }
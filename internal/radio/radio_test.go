@@ -1,10 +1,14 @@
 package radio
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"math/rand"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/1mb-dev/driftfm/internal/inventory"
 	"github.com/1mb-dev/driftfm/internal/testutil"
@@ -47,10 +51,32 @@ func setupTestRepo(t *testing.T) *inventory.Repository {
 	return repo
 }
 
+// recentIDs lists a radio's remembered plays, oldest first
+func recentIDs(r *Radio) []int64 {
+	ids := make([]int64, len(r.recentlyPlayed))
+	for i, p := range r.recentlyPlayed {
+		ids[i] = p.id
+	}
+	return ids
+}
+
+// testClock is a settable clock for a radio's recency window
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time { return c.t }
+
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestClock() *testClock {
+	return &testClock{t: time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)}
+}
+
 func TestRecordPlay(t *testing.T) {
+	clock := newTestClock()
 	r := &Radio{
-		recentlyPlayed: make([]int64, 0),
-		maxRecent:      3,
+		recentWindow: time.Hour,
+		maxRecent:    3,
+		now:          clock.now,
 	}
 
 	// Record plays
@@ -62,28 +88,141 @@ func TestRecordPlay(t *testing.T) {
 		t.Errorf("got %d recent, want 3", len(r.recentlyPlayed))
 	}
 
-	// Record 4th - should trim oldest
+	// Record 4th - the cap should trim the oldest
 	r.RecordPlay(4)
-	if len(r.recentlyPlayed) != 3 {
-		t.Errorf("got %d recent, want 3 (should trim)", len(r.recentlyPlayed))
+	if got := recentIDs(r); !slices.Equal(got, []int64{2, 3, 4}) {
+		t.Errorf("recent = %v, want [2 3 4] (should trim)", got)
+	}
+
+	// A replay within the window restarts it instead of adding an entry
+	clock.advance(time.Minute)
+	r.RecordPlay(2)
+	if got := recentIDs(r); !slices.Equal(got, []int64{3, 4, 2}) {
+		t.Errorf("recent = %v, want [3 4 2] after replaying 2", got)
 	}
-	if r.recentlyPlayed[0] != 2 {
-		t.Errorf("oldest should be 2, got %d", r.recentlyPlayed[0])
+	if got := r.recentlyPlayed[2].playedAt; !got.Equal(clock.t) {
+		t.Errorf("replayed at %v, want %v", got, clock.t)
 	}
+}
 
-	// Duplicate should be ignored
-	r.RecordPlay(4)
-	if len(r.recentlyPlayed) != 3 {
-		t.Error("duplicate should not add to recent list")
+func TestRecordPlay_Window(t *testing.T) {
+	clock := newTestClock()
+	r := &Radio{
+		recentWindow: 2 * time.Hour,
+		maxRecent:    DefaultMaxRecent,
+		now:          clock.now,
+	}
+
+	r.RecordPlay(1)
+	clock.advance(30 * time.Minute)
+	r.RecordPlay(2)
+
+	// One second short of the window, track 1 is still recent
+	clock.advance(90*time.Minute - time.Second)
+	if got := r.StateSnapshot().RecentlyPlayed; !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("recent = %v, want [1 2] inside the window", got)
+	}
+
+	// At the window's end it expires; track 2 has 30 minutes left
+	clock.advance(time.Second)
+	if got := r.StateSnapshot().RecentlyPlayed; !slices.Equal(got, []int64{2}) {
+		t.Errorf("recent = %v, want [2] once 1's window ends", got)
+	}
+
+	// An expired track played again is recent anew
+	r.RecordPlay(1)
+	if got := recentIDs(r); !slices.Equal(got, []int64{2, 1}) {
+		t.Errorf("recent = %v, want [2 1]", got)
+	}
+
+	clock.advance(2 * time.Hour)
+	if got := r.StateSnapshot().RecentlyPlayed; len(got) != 0 {
+		t.Errorf("recent = %v, want none after the window", got)
+	}
+}
+
+func TestShuffleWithRecency_Window(t *testing.T) {
+	clock := newTestClock()
+	r := &Radio{
+		recentWindow: time.Hour,
+		maxRecent:    DefaultMaxRecent,
+		rng:          rand.New(rand.NewSource(42)),
+		now:          clock.now,
+	}
+	r.RecordPlay(3)
+	clock.advance(10 * time.Minute)
+	r.RecordPlay(1)
+	clock.advance(10 * time.Minute)
+	r.RecordPlay(2)
+
+	tracks := []*inventory.Track{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	r.mu.Lock()
+	r.shuffleWithRecencyLocked(tracks)
+	r.mu.Unlock()
+
+	// Recent tracks go last, the least recently played first
+	if got := trackIDs(tracks); !slices.Equal(got, []int64{4, 3, 1, 2}) {
+		t.Errorf("order = %v, want [4 3 1 2]", got)
+	}
+
+	// Once track 3's window ends it shuffles with the fresh tracks
+	clock.advance(40 * time.Minute)
+	r.mu.Lock()
+	r.shuffleWithRecencyLocked(tracks)
+	r.mu.Unlock()
+	if got := trackIDs(tracks)[2:]; !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("order = %v, want 1 and 2 last", trackIDs(tracks))
+	}
+}
+
+func TestStateSnapshot(t *testing.T) {
+	r := NewRadio(nil, "focus")
+	for _, id := range []int64{5, 6, 7, 8} {
+		r.RecordPlay(id)
+	}
+
+	snap := r.StateSnapshot()
+	if snap.Mood != "focus" || snap.MaxRecent != DefaultMaxRecent || snap.SampleAbove != DefaultSampleThreshold || snap.RecentWindow != "2h0m0s" {
+		t.Errorf("snapshot = %+v, want focus with default limits", snap)
+	}
+	if !slices.Equal(snap.RecentlyPlayed, []int64{5, 6, 7, 8}) {
+		t.Errorf("recently played = %v, want [5 6 7 8]", snap.RecentlyPlayed)
+	}
+
+	// The snapshot is a copy, unaffected by later plays
+	r.RecordPlay(5)
+	if !slices.Equal(snap.RecentlyPlayed, []int64{5, 6, 7, 8}) {
+		t.Errorf("snapshot changed after RecordPlay: %v", snap.RecentlyPlayed)
+	}
+}
+
+func TestManagerStateSnapshot(t *testing.T) {
+	m := NewManager(setupTestRepo(t))
+	m.SetBorrowing(5, map[string]string{"calm": "focus"})
+	m.SetRecentWindow(30 * time.Minute)
+	m.RecordPlay("calm", 2)
+
+	snap := m.StateSnapshot("calm")
+	if !slices.Equal(snap.RecentlyPlayed, []int64{2}) || snap.Fallback != "focus" || snap.MinPlaylistLength != 5 {
+		t.Errorf("calm snapshot = %+v, want recent [2] borrowing from focus below 5", snap)
+	}
+	if snap.RecentWindow != "30m0s" {
+		t.Errorf("recent window = %q, want 30m0s", snap.RecentWindow)
+	}
+	if snap := m.StateSnapshot("focus"); snap.Fallback != "" || len(snap.RecentlyPlayed) != 0 {
+		t.Errorf("focus snapshot = %+v, want no plays or fallback", snap)
 	}
 }
 
 func TestShuffleWithRecency(t *testing.T) {
 	r := &Radio{
-		recentlyPlayed: []int64{1, 2}, // tracks 1,2 recently played
-		maxRecent:      3,
-		rng:            rand.New(rand.NewSource(42)), // deterministic
+		recentWindow: time.Hour,
+		maxRecent:    3,
+		rng:          rand.New(rand.NewSource(42)), // deterministic
+		now:          time.Now,
 	}
+	r.RecordPlay(1) // tracks 1,2 recently played
+	r.RecordPlay(2)
 
 	tracks := []*inventory.Track{
 		{ID: 1},
@@ -117,6 +256,62 @@ func TestShuffleWithRecency(t *testing.T) {
 	}
 }
 
+func trackIDs(tracks []*inventory.Track) []int64 {
+	ids := make([]int64, len(tracks))
+	for i, t := range tracks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func TestShuffleWithRecency_LastServedHead(t *testing.T) {
+	r := &Radio{
+		recentWindow:   time.Hour,
+		maxRecent:      3,
+		lastServedHead: []int64{1, 2, 3},
+		rng:            rand.New(rand.NewSource(42)),
+		now:            time.Now,
+	}
+	r.RecordPlay(1)
+	tracks := []*inventory.Track{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+
+	r.mu.Lock()
+	r.shuffleWithRecencyLocked(tracks)
+	r.mu.Unlock()
+
+	// Fresh 4 and 5, then the last head's 2 and 3, then recently played 1
+	tiers := map[int64]int{4: 0, 5: 0, 2: 1, 3: 1, 1: 2}
+	for i := 1; i < len(tracks); i++ {
+		if tiers[tracks[i].ID] < tiers[tracks[i-1].ID] {
+			t.Fatalf("order %v breaks fresh, last head, recent", trackIDs(tracks))
+		}
+	}
+}
+
+func TestGetPlaylist_RefreshChangesHead(t *testing.T) {
+	repo := setupTestRepo(t)
+	radio := NewRadio(repo, "focus")
+	radio.headMemory = 1
+
+	var prev int64
+	for i := range 20 {
+		tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tracks) != 3 {
+			t.Fatalf("got %d tracks, want 3", len(tracks))
+		}
+		if i > 0 && tracks[0].ID == prev {
+			t.Fatalf("refresh %d opened with track %d again", i, prev)
+		}
+		prev = tracks[0].ID
+	}
+	if got := radio.StateSnapshot().LastServedHead; len(got) != 1 || got[0] != prev {
+		t.Errorf("last served head = %v, want [%d]", got, prev)
+	}
+}
+
 // TestGetPlaylist tests the core playlist generation
 func TestGetPlaylist(t *testing.T) {
 	repo := setupTestRepo(t)
@@ -135,7 +330,7 @@ func TestGetPlaylist(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			radio := NewRadio(repo, tt.mood)
-			tracks, err := radio.GetPlaylist(false)
+			tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 0)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -146,6 +341,288 @@ func TestGetPlaylist(t *testing.T) {
 	}
 }
 
+func TestGetPlaylist_Limit(t *testing.T) {
+	repo := setupTestRepo(t)
+	radio := NewRadio(repo, "focus")
+
+	tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Errorf("got %d tracks, want 2", len(tracks))
+	}
+
+	// Recently played tracks are the first dropped when truncating
+	radio.RecordPlay(1)
+	for range 20 {
+		tracks, _ := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 2)
+		for _, track := range tracks {
+			if track.ID == 1 {
+				t.Fatal("recently played track kept while fresh tracks were dropped")
+			}
+		}
+	}
+}
+
+func TestGetPlaylist_LimitSkipsBeta(t *testing.T) {
+	repo := setupTestRepo(t)
+	fields := map[string]any{"status": inventory.StatusBeta, "rollout_percent": 100}
+	if err := repo.UpdateTrack(2, fields, "alice", ""); err != nil {
+		t.Fatalf("UpdateTrack failed: %v", err)
+	}
+	radio := NewRadio(repo, "focus")
+
+	// Beta tracks ride along past the limit, so listeners outside the
+	// rollout still get a full playlist once they are filtered out
+	filter := inventory.TrackFilter{IncludeBeta: true}
+	for range 20 {
+		tracks, err := radio.GetPlaylist(context.Background(), filter, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		approved := 0
+		for _, track := range tracks {
+			if track.Status != inventory.StatusBeta {
+				approved++
+			}
+		}
+		if approved != 2 {
+			t.Fatalf("got %d approved tracks in %d, want 2", approved, len(tracks))
+		}
+	}
+}
+
+func TestGetPlaylist_LimitRotatesSubsets(t *testing.T) {
+	repo := setupTestRepo(t)
+	radio := NewRadio(repo, "focus")
+
+	seen := make(map[int64]bool)
+	for range 50 {
+		tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		seen[tracks[0].ID] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("50 single-track playlists covered %d distinct tracks, want rotation", len(seen))
+	}
+}
+
+// openLargeRepo creates a repository holding n approved focus tracks;
+// odd IDs have vocals
+func openLargeRepo(tb testing.TB, n int) *inventory.Repository {
+	tb.Helper()
+
+	tmpDB := tb.TempDir() + "/test.db"
+	db, err := sql.Open("sqlite", tmpDB)
+	if err != nil {
+		tb.Fatalf("failed to open test db: %v", err)
+	}
+
+	_, err = db.Exec(testutil.SchemaDDL + fmt.Sprintf(`
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < %d)
+		INSERT INTO tracks (id, file_path, title, mood, duration_seconds, status, has_vocals)
+		SELECT i, 'focus/t' || i || '.mp3', 'Track ' || i, 'focus', 180, 'approved', i %% 2 FROM seq;
+		CREATE INDEX idx_tracks_mood_status ON tracks(mood, status);
+	`, n))
+	if err != nil {
+		tb.Fatalf("failed to setup test db: %v", err)
+	}
+	_ = db.Close()
+
+	repo, err := inventory.NewRepository(tmpDB)
+	if err != nil {
+		tb.Fatalf("failed to create repository: %v", err)
+	}
+
+	tb.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func TestGetPlaylist_SampledLargeMood(t *testing.T) {
+	repo := openLargeRepo(t, 12)
+	radio := NewRadio(repo, "focus")
+	radio.sampleAbove = 10
+
+	// Recency demotion must still hold on the sample, which here covers
+	// nearly the whole mood, so recent tracks are almost always drawn
+	radio.RecordPlay(2)
+	radio.RecordPlay(4)
+	for range 30 {
+		tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 8)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tracks) != 8 {
+			t.Fatalf("got %d tracks, want 8", len(tracks))
+		}
+		seen := make(map[int64]bool)
+		for _, track := range tracks {
+			if track.ID == 2 || track.ID == 4 {
+				t.Fatalf("recently played track %d kept while fresh tracks were available", track.ID)
+			}
+			if seen[track.ID] {
+				t.Fatalf("duplicate track %d in sampled playlist", track.ID)
+			}
+			seen[track.ID] = true
+		}
+	}
+
+	// Filters apply to the sample
+	tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{InstrumentalOnly: true}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, track := range tracks {
+		if track.HasVocals {
+			t.Errorf("instrumental-only sample returned vocal track %d", track.ID)
+		}
+	}
+}
+
+func TestGetPlaylist_SmallMoodLoadsAll(t *testing.T) {
+	repo := openLargeRepo(t, 12)
+	radio := NewRadio(repo, "focus")
+
+	// Below the threshold the full-load path is used; unlimited always is
+	for _, limit := range []int{0, 5} {
+		tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := 12
+		if limit > 0 {
+			want = limit
+		}
+		if len(tracks) != want {
+			t.Errorf("limit %d: got %d tracks, want %d", limit, len(tracks), want)
+		}
+	}
+}
+
+func TestGetPlaylist_SortedSkipsShuffle(t *testing.T) {
+	repo := openLargeRepo(t, 12)
+	radio := NewRadio(repo, "focus")
+	radio.sampleAbove = 10
+
+	// No track has a tempo, so the sort falls back to ID order. Recently
+	// played tracks keep their place and the large mood is not sampled.
+	radio.RecordPlay(1)
+	radio.RecordPlay(2)
+	filter := inventory.TrackFilter{Sort: inventory.SortBPMAsc}
+	for range 10 {
+		tracks, err := radio.GetPlaylist(context.Background(), filter, 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids := make([]int64, len(tracks))
+		for i, track := range tracks {
+			ids[i] = track.ID
+		}
+		if want := []int64{1, 2, 3, 4}; !slices.Equal(ids, want) {
+			t.Fatalf("sorted playlist = %v, want %v", ids, want)
+		}
+	}
+}
+
+func TestManagerGetPlaylist_SortedNotPadded(t *testing.T) {
+	repo := setupTestRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetBorrowing(3, map[string]string{"calm": "focus"})
+
+	tracks, err := mgr.GetPlaylist(context.Background(), "calm", inventory.TrackFilter{Sort: inventory.SortBPMDesc}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tracks) != 1 || tracks[0].Mood != "calm" {
+		t.Errorf("got %d tracks, want calm's 1 track without borrowing", len(tracks))
+	}
+}
+
+// BenchmarkGetPlaylist_10k compares loading a whole 10,000-track mood with
+// sampling it for a 50-track playlist
+func BenchmarkGetPlaylist_10k(b *testing.B) {
+	repo := openLargeRepo(b, 10000)
+
+	for _, bc := range []struct {
+		name        string
+		sampleAbove int
+	}{
+		{"full", 0},
+		{"sampled", DefaultSampleThreshold},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			radio := NewRadio(repo, "focus")
+			radio.sampleAbove = bc.sampleAbove
+			radio.RecordPlay(1)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 50); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaylistSize(t *testing.T) {
+	tests := []struct {
+		name                    string
+		limit, defaultSz, maxSz int
+		want                    int
+	}{
+		{"unbounded", 0, 0, 0, 0},
+		{"default applies", 0, 50, 100, 50},
+		{"explicit limit", 20, 50, 100, 20},
+		{"clamped to max", 1000000, 50, 100, 100},
+		{"no default caps at max", 0, 0, 60, 60},
+		{"default above max clamped", 0, 200, 60, 60},
+		{"negative treated as default", -3, 50, 100, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlaylistSize(tt.limit, tt.defaultSz, tt.maxSz); got != tt.want {
+				t.Errorf("PlaylistSize(%d, %d, %d) = %d, want %d", tt.limit, tt.defaultSz, tt.maxSz, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerPlaylistSize(t *testing.T) {
+	repo := setupTestRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetPlaylistSize(2, 3)
+	mgr.SetBorrowing(10, map[string]string{"focus": "calm"})
+
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{"default size", 0, 2},
+		{"explicit", 1, 1},
+		{"clamped", 500, 3}, // borrowing pads only up to the cap
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := mgr.GetPlaylist(context.Background(), "focus", inventory.TrackFilter{}, tt.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tracks) != tt.want {
+				t.Errorf("got %d tracks, want %d", len(tracks), tt.want)
+			}
+			if got := mgr.PlaylistSize(tt.limit); got != tt.want {
+				t.Errorf("PlaylistSize(%d) = %d, want %d", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestManagerGetPlaylist tests the manager's playlist delegation
 func TestManagerGetPlaylist(t *testing.T) {
 	repo := setupTestRepo(t)
@@ -163,7 +640,7 @@ func TestManagerGetPlaylist(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tracks, err := mgr.GetPlaylist(tt.mood, false)
+			tracks, err := mgr.GetPlaylist(context.Background(), tt.mood, inventory.TrackFilter{}, 0)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -174,6 +651,32 @@ func TestManagerGetPlaylist(t *testing.T) {
 	}
 }
 
+func TestManagerGetPlaylist_PlaylistID(t *testing.T) {
+	repo := setupTestRepo(t)
+	mgr := NewManager(repo)
+
+	var ids []string
+	for range 2 {
+		tracks, err := mgr.GetPlaylist(context.Background(), "focus", inventory.TrackFilter{}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		id := tracks[0].PlaylistID
+		if id == "" {
+			t.Fatal("playlist has no ID")
+		}
+		for _, track := range tracks {
+			if track.PlaylistID != id {
+				t.Errorf("track %d playlist ID = %q, want %q", track.ID, track.PlaylistID, id)
+			}
+		}
+		ids = append(ids, id)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("two playlists share ID %q", ids[0])
+	}
+}
+
 // TestManagerGetRadio tests radio caching and concurrent access
 func TestManagerGetRadio(t *testing.T) {
 	repo := setupTestRepo(t)
@@ -194,6 +697,38 @@ func TestManagerGetRadio(t *testing.T) {
 	}
 }
 
+func TestManagerGetRadio_Eviction(t *testing.T) {
+	repo := setupTestRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetMaxCached(3)
+
+	mgr.RecordPlay("focus", 1)
+	focus := mgr.GetRadio("focus")
+	for i := range 10 {
+		mgr.GetRadio(fmt.Sprintf("typo%d", i))
+		// An active mood stays the most recently used
+		mgr.RecordPlay("focus", 2)
+	}
+
+	if n := len(mgr.radios); n != 3 {
+		t.Errorf("manager caches %d radios, want 3", n)
+	}
+	for i := range 10 {
+		mood := fmt.Sprintf("typo%d", i)
+		if _, cached := mgr.radios[mood]; cached != (i >= 8) {
+			t.Errorf("%s cached = %v, want only typo8 and typo9 kept", mood, cached)
+		}
+	}
+
+	// The active mood kept its radio and recency
+	if mgr.GetRadio("focus") != focus {
+		t.Fatal("active mood's radio was evicted")
+	}
+	if got := focus.StateSnapshot().RecentlyPlayed; !slices.Contains(got, 1) {
+		t.Errorf("recently played = %v, want it to still hold track 1", got)
+	}
+}
+
 // TestConcurrentAccess verifies no data races when multiple goroutines
 // call GetPlaylist and RecordPlay concurrently.
 // Run with: go test -race ./internal/radio/...
@@ -206,7 +741,7 @@ func TestConcurrentAccess(t *testing.T) {
 		wg.Add(2)
 		go func() {
 			defer wg.Done()
-			_, _ = r.GetPlaylist(false)
+			_, _ = r.GetPlaylist(context.Background(), inventory.TrackFilter{}, 0)
 		}()
 		go func() {
 			defer wg.Done()
@@ -226,7 +761,117 @@ func TestManagerRecordPlay(t *testing.T) {
 
 	// Verify it was recorded in the radio
 	radio := mgr.GetRadio("focus")
-	if len(radio.recentlyPlayed) != 1 || radio.recentlyPlayed[0] != 1 {
-		t.Errorf("expected track 1 in recent, got %v", radio.recentlyPlayed)
+	if got := recentIDs(radio); !slices.Equal(got, []int64{1}) {
+		t.Errorf("expected track 1 in recent, got %v", got)
+	}
+}
+
+// TestManagerBorrowing tests padding a sparse mood from its fallback
+func TestManagerBorrowing(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tests := []struct {
+		name         string
+		minLength    int
+		fallbacks    map[string]string
+		mood         string
+		wantLen      int
+		wantBorrowed int
+	}{
+		{"sparse mood padded", 3, map[string]string{"calm": "focus"}, "calm", 3, 2},
+		{"padding capped by fallback size", 10, map[string]string{"calm": "focus"}, "calm", 4, 3},
+		{"long enough mood untouched", 3, map[string]string{"focus": "calm"}, "focus", 3, 0},
+		{"no fallback configured", 3, map[string]string{}, "calm", 1, 0},
+		{"borrowing disabled", 0, map[string]string{"calm": "focus"}, "calm", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := NewManager(repo)
+			mgr.SetBorrowing(tt.minLength, tt.fallbacks)
+
+			tracks, err := mgr.GetPlaylist(context.Background(), tt.mood, inventory.TrackFilter{}, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tracks) != tt.wantLen {
+				t.Fatalf("got %d tracks, want %d", len(tracks), tt.wantLen)
+			}
+
+			seen := make(map[int64]bool)
+			borrowed := 0
+			for i, track := range tracks {
+				if seen[track.ID] {
+					t.Errorf("duplicate track %d", track.ID)
+				}
+				seen[track.ID] = true
+				if track.Mood != tt.mood {
+					borrowed++
+				} else if borrowed > 0 {
+					t.Errorf("own track at position %d after borrowed tracks", i)
+				}
+			}
+			if borrowed != tt.wantBorrowed {
+				t.Errorf("borrowed %d tracks, want %d", borrowed, tt.wantBorrowed)
+			}
+		})
+	}
+}
+
+// TestManagerBorrowing_Cycle verifies mutual fallbacks don't recurse
+func TestManagerBorrowing_Cycle(t *testing.T) {
+	repo := setupTestRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetBorrowing(5, map[string]string{"calm": "focus", "focus": "calm"})
+
+	tracks, err := mgr.GetPlaylist(context.Background(), "calm", inventory.TrackFilter{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tracks) != 4 {
+		t.Errorf("got %d tracks, want 4 (all calm + all focus)", len(tracks))
+	}
+}
+
+func TestRecencyWeight(t *testing.T) {
+	playlists := func(t *testing.T, weight float64) [][]int64 {
+		t.Helper()
+		m := NewManager(setupTestRepo(t))
+		m.SetRecencyWeight(weight)
+		m.RecordPlay("focus", 2)
+		var out [][]int64
+		for range 10 {
+			tracks, err := m.GetPlaylist(context.Background(), "focus", inventory.TrackFilter{}, 0)
+			if err != nil {
+				t.Fatalf("GetPlaylist failed: %v", err)
+			}
+			out = append(out, trackIDs(tracks))
+		}
+		return out
+	}
+
+	// Least played first is 2 (0 plays), 3 (5), 1 (10)
+	t.Run("zero keeps fairness order", func(t *testing.T) {
+		for _, got := range playlists(t, 0) {
+			if !slices.Equal(got, []int64{2, 3, 1}) {
+				t.Fatalf("order = %v, want [2 3 1] despite 2 playing recently", got)
+			}
+		}
+	})
+	t.Run("one demotes recent plays", func(t *testing.T) {
+		for _, got := range playlists(t, 1) {
+			if got[len(got)-1] != 2 {
+				t.Fatalf("order = %v, want recently played 2 last", got)
+			}
+		}
+	})
+
+	m := NewManager(setupTestRepo(t))
+	m.SetRecencyWeight(-0.5)
+	if snap := m.StateSnapshot("focus"); snap.RecencyWeight != 0 {
+		t.Errorf("recency weight = %v, want -0.5 clamped to 0", snap.RecencyWeight)
+	}
+	if snap := NewManager(nil).StateSnapshot("focus"); snap.RecencyWeight != DefaultRecencyWeight {
+		t.Errorf("default recency weight = %v, want %v", snap.RecencyWeight, DefaultRecencyWeight)
 	}
 }
@@ -0,0 +1,143 @@
+package radio
+
+import (
+	"log"
+	"slices"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/metrics"
+)
+
+// Rules are programming constraints on the energy flow of a mood's
+// playlists. Tracks without an energy label are never excluded or counted.
+type Rules struct {
+	// AllowedEnergies drops tracks with any other energy (empty = all allowed)
+	AllowedEnergies []string `json:"allowed_energies,omitempty"`
+	// MaxConsecutive caps back-to-back tracks of an energy
+	MaxConsecutive map[string]int `json:"max_consecutive,omitempty"`
+	// RatioCaps caps an energy within any run of Window consecutive tracks
+	RatioCaps map[string]RatioCap `json:"ratio_caps,omitempty"`
+}
+
+// RatioCap allows at most Max tracks of an energy in any Window tracks
+type RatioCap struct {
+	Max    int `json:"max"`
+	Window int `json:"window"`
+}
+
+// apply returns tracks that satisfy the rules, keeping their order where it
+// can: each slot takes the earliest remaining track that fits, so tracks are
+// only pushed later to make room, and recently played ones stay at the end.
+// A capped energy jumps the queue once the other tracks left are only just
+// enough to space it out. Tracks that fit no remaining slot are dropped.
+// excluded is the disallowed energies, dropped the tracks lost to spacing.
+func (rl *Rules) apply(tracks []*inventory.Track) (out, excluded []*inventory.Track, dropped int) {
+	pending := make([]*inventory.Track, 0, len(tracks))
+	for _, t := range tracks {
+		if t.Energy != "" && len(rl.AllowedEnergies) > 0 && !slices.Contains(rl.AllowedEnergies, t.Energy) {
+			excluded = append(excluded, t)
+			continue
+		}
+		pending = append(pending, t)
+	}
+
+	out = make([]*inventory.Track, 0, len(pending))
+	for len(pending) > 0 {
+		urgent := rl.urgent(pending)
+		i := slices.IndexFunc(pending, func(t *inventory.Track) bool { return urgent[t.Energy] && rl.fits(out, t) })
+		if i < 0 {
+			i = slices.IndexFunc(pending, func(t *inventory.Track) bool { return rl.fits(out, t) })
+		}
+		if i < 0 {
+			break
+		}
+		out = append(out, pending[i])
+		pending = slices.Delete(pending, i, i+1)
+	}
+	return out, excluded, len(pending)
+}
+
+// urgent returns the capped energies whose remaining tracks need every
+// other remaining track as spacing
+func (rl *Rules) urgent(pending []*inventory.Track) map[string]bool {
+	var urgent map[string]bool
+	for energy, c := range rl.RatioCaps {
+		n := 0
+		for _, t := range pending {
+			if t.Energy == energy {
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		spacing := ((n+c.Max-1)/c.Max - 1) * (c.Window - c.Max)
+		if len(pending)-n <= spacing {
+			if urgent == nil {
+				urgent = make(map[string]bool)
+			}
+			urgent[energy] = true
+		}
+	}
+	return urgent
+}
+
+// fits reports whether t can follow the playlist so far
+func (rl *Rules) fits(playlist []*inventory.Track, t *inventory.Track) bool {
+	if t.Energy == "" {
+		return true
+	}
+	if limit, ok := rl.MaxConsecutive[t.Energy]; ok {
+		run := 0
+		for i := len(playlist) - 1; i >= 0 && playlist[i].Energy == t.Energy; i-- {
+			run++
+		}
+		if run >= limit {
+			return false
+		}
+	}
+	if c, ok := rl.RatioCaps[t.Energy]; ok {
+		count := 0
+		for i := len(playlist) - 1; i >= 0 && i > len(playlist)-c.Window; i-- {
+			if playlist[i].Energy == t.Energy {
+				count++
+			}
+		}
+		if count >= c.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// enforceRulesLocked applies the radio's rules to a playlist, counting what
+// they keep out and warning once per track excluded for its energy, since
+// that usually means the track is mislabeled. Caller must hold r.mu.
+func (r *Radio) enforceRulesLocked(tracks []*inventory.Track) []*inventory.Track {
+	if r.rules == nil {
+		return tracks
+	}
+
+	out, excluded, dropped := r.rules.apply(tracks)
+	if n := len(excluded) + dropped; n > 0 {
+		metrics.Get().RecordRadioRuleDrops(n)
+	}
+	for _, t := range excluded {
+		if r.warned[t.ID] {
+			continue
+		}
+		if r.warned == nil {
+			r.warned = make(map[int64]bool)
+		}
+		r.warned[t.ID] = true
+		log.Printf("Warning: radio rules for %s exclude track %d (energy %s)", r.mood, t.ID, t.Energy)
+	}
+	return out
+}
+
+// SetRules sets the programming rules for the radio's playlists (nil = none)
+func (r *Radio) SetRules(rules *Rules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = rules
+}
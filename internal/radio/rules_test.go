@@ -0,0 +1,285 @@
+package radio
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/metrics"
+	"github.com/1mb-dev/driftfm/internal/testutil"
+)
+
+// lateNightRules keeps high energy out and spaces medium tracks
+var lateNightRules = &Rules{
+	AllowedEnergies: []string{"low", "medium"},
+	RatioCaps:       map[string]RatioCap{"medium": {Max: 1, Window: 5}},
+}
+
+// setupMixedRepo creates a late_night library with 16 low, 4 medium, and 3
+// mislabeled high tracks, plus a calm mood holding only high tracks
+func setupMixedRepo(t *testing.T) *inventory.Repository {
+	t.Helper()
+
+	var rows []string
+	id := 1
+	for _, group := range []struct {
+		mood, energy string
+		n            int
+	}{
+		{"late_night", "low", 16},
+		{"late_night", "medium", 4},
+		{"late_night", "high", 3},
+		{"calm", "high", 3},
+	} {
+		for range group.n {
+			rows = append(rows, fmt.Sprintf("(%d, '%s/t%d.mp3', '%s', 180, 'approved', '%s')",
+				id, group.mood, id, group.mood, group.energy))
+			id++
+		}
+	}
+
+	tmpDB := t.TempDir() + "/test.db"
+	db, err := sql.Open("sqlite", tmpDB)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	_, err = db.Exec(testutil.SchemaDDL + `
+		INSERT INTO tracks (id, file_path, mood, duration_seconds, status, energy) VALUES ` +
+		strings.Join(rows, ",\n") + ";")
+	if err != nil {
+		t.Fatalf("failed to setup test db: %v", err)
+	}
+	_ = db.Close()
+
+	repo, err := inventory.NewRepository(tmpDB)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+// checkRules fails the test for any track breaking rules
+func checkRules(t *testing.T, rules *Rules, tracks []*inventory.Track) {
+	t.Helper()
+	energies := make([]string, len(tracks))
+	for i, track := range tracks {
+		energies[i] = track.Energy
+	}
+
+	for i, energy := range energies {
+		if len(rules.AllowedEnergies) > 0 && !slices.Contains(rules.AllowedEnergies, energy) {
+			t.Errorf("position %d has disallowed energy %s: %v", i, energy, energies)
+		}
+		if limit, ok := rules.MaxConsecutive[energy]; ok && i >= limit {
+			run := 0
+			for j := i; j >= 0 && energies[j] == energy; j-- {
+				run++
+			}
+			if run > limit {
+				t.Errorf("position %d ends a run of %d %s, max %d: %v", i, run, energy, limit, energies)
+			}
+		}
+		if c, ok := rules.RatioCaps[energy]; ok {
+			count := 0
+			for j := i; j >= 0 && j > i-c.Window; j-- {
+				if energies[j] == energy {
+					count++
+				}
+			}
+			if count > c.Max {
+				t.Errorf("window ending at %d has %d %s, max %d: %v", i, count, energy, c.Max, energies)
+			}
+		}
+	}
+}
+
+func TestGetPlaylist_Rules(t *testing.T) {
+	repo := setupMixedRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetRules(map[string]*Rules{"late_night": lateNightRules})
+
+	before := metrics.Get().Snapshot()["radio_rule_drops"].(uint64)
+	for range 50 {
+		tracks, err := mgr.GetPlaylist(context.Background(), "late_night", inventory.TrackFilter{}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkRules(t, lateNightRules, tracks)
+
+		// 16 low tracks make room for all 4 medium ones
+		if len(tracks) != 20 {
+			t.Fatalf("got %d tracks, want the 20 low and medium", len(tracks))
+		}
+	}
+	if dropped := metrics.Get().Snapshot()["radio_rule_drops"].(uint64) - before; dropped < 50*3 {
+		t.Errorf("radio_rule_drops grew by %d, want at least %d", dropped, 50*3)
+	}
+}
+
+func TestGetPlaylist_RulesDropUnplaceable(t *testing.T) {
+	repo := setupMixedRepo(t)
+	radio := NewRadio(repo, "late_night")
+	rules := &Rules{RatioCaps: map[string]RatioCap{"high": {Max: 1, Window: 10}}}
+	radio.SetRules(rules)
+
+	tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkRules(t, rules, tracks)
+
+	// 20 other tracks leave room for at most 3 high ones 10 apart
+	highs := 0
+	for _, track := range tracks {
+		if track.Energy == "high" {
+			highs++
+		}
+	}
+	if highs < 1 || highs > 3 {
+		t.Errorf("kept %d high tracks, want 1 to 3", highs)
+	}
+	if len(tracks) != 20+highs {
+		t.Errorf("got %d tracks, want %d", len(tracks), 20+highs)
+	}
+}
+
+func TestGetPlaylist_RulesWithLimit(t *testing.T) {
+	repo := setupMixedRepo(t)
+	radio := NewRadio(repo, "late_night")
+	radio.SetRules(lateNightRules)
+
+	for range 20 {
+		tracks, err := radio.GetPlaylist(context.Background(), inventory.TrackFilter{}, 8)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tracks) != 8 {
+			t.Fatalf("got %d tracks, want 8", len(tracks))
+		}
+		checkRules(t, lateNightRules, tracks)
+	}
+}
+
+func TestRulesApply(t *testing.T) {
+	mk := func(energies ...string) []*inventory.Track {
+		tracks := make([]*inventory.Track, len(energies))
+		for i, e := range energies {
+			tracks[i] = &inventory.Track{ID: int64(i + 1), Energy: e}
+		}
+		return tracks
+	}
+	ids := func(tracks []*inventory.Track) []int64 {
+		out := make([]int64, len(tracks))
+		for i, track := range tracks {
+			out[i] = track.ID
+		}
+		return out
+	}
+
+	tests := []struct {
+		name         string
+		rules        Rules
+		energies     []string
+		wantIDs      []int64
+		wantExcluded int
+		wantDropped  int
+	}{
+		{
+			name:     "no constraints keeps order",
+			energies: []string{"high", "low", "medium"},
+			wantIDs:  []int64{1, 2, 3},
+		},
+		{
+			name:         "disallowed energy excluded",
+			rules:        Rules{AllowedEnergies: []string{"low"}},
+			energies:     []string{"low", "high", "", "medium"},
+			wantIDs:      []int64{1, 3},
+			wantExcluded: 2,
+		},
+		{
+			name:     "consecutive run broken by the next fitting track",
+			rules:    Rules{MaxConsecutive: map[string]int{"high": 2}},
+			energies: []string{"high", "high", "high", "low", "high"},
+			wantIDs:  []int64{1, 2, 4, 3, 5},
+		},
+		{
+			name:        "run without a breaker is dropped",
+			rules:       Rules{MaxConsecutive: map[string]int{"high": 1}},
+			energies:    []string{"high", "high", "low", "high"},
+			wantIDs:     []int64{1, 3, 2},
+			wantDropped: 1,
+		},
+		{
+			name:     "ratio cap spaces tracks",
+			rules:    Rules{RatioCaps: map[string]RatioCap{"medium": {Max: 1, Window: 3}}},
+			energies: []string{"medium", "medium", "low", "low", "low"},
+			wantIDs:  []int64{1, 3, 4, 2, 5},
+		},
+		{
+			name:     "unlabeled tracks always fit",
+			rules:    Rules{MaxConsecutive: map[string]int{"low": 1}},
+			energies: []string{"low", "", "low", ""},
+			wantIDs:  []int64{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, excluded, dropped := tt.rules.apply(mk(tt.energies...))
+			if got := ids(out); fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("order = %v, want %v", got, tt.wantIDs)
+			}
+			if len(excluded) != tt.wantExcluded {
+				t.Errorf("excluded %d, want %d", len(excluded), tt.wantExcluded)
+			}
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped %d, want %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestManagerRules_UnknownMoodIgnored(t *testing.T) {
+	repo := setupMixedRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetRules(map[string]*Rules{"not_a_mood": {AllowedEnergies: []string{"low"}}})
+
+	tracks, err := mgr.GetPlaylist(context.Background(), "late_night", inventory.TrackFilter{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tracks) != 23 {
+		t.Errorf("got %d tracks, want all 23 (rules for other moods don't apply)", len(tracks))
+	}
+
+	tracks, err = mgr.GetPlaylist(context.Background(), "not_a_mood", inventory.TrackFilter{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tracks) != 0 {
+		t.Errorf("got %d tracks for an unknown mood, want 0", len(tracks))
+	}
+}
+
+func TestManagerRules_BorrowedTracks(t *testing.T) {
+	repo := setupMixedRepo(t)
+	mgr := NewManager(repo)
+	mgr.SetRules(map[string]*Rules{"late_night": lateNightRules})
+	mgr.SetBorrowing(30, map[string]string{"late_night": "calm"})
+
+	tracks, err := mgr.GetPlaylist(context.Background(), "late_night", inventory.TrackFilter{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkRules(t, lateNightRules, tracks)
+	for _, track := range tracks {
+		if track.Mood == "calm" {
+			t.Errorf("borrowed high-energy calm track %d despite late_night rules", track.ID)
+		}
+	}
+}
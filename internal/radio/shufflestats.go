@@ -0,0 +1,118 @@
+package radio
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+// ShuffleStatsPositions is how many leading positions of each shuffled
+// playlist the shuffle stats count: the tracks a listener actually hears
+const ShuffleStatsPositions = 10
+
+// maxShuffleStatsTracks bounds the tracks counted per day; appearances of
+// tracks past it are pooled in ShuffleStats.OtherAppearances
+const maxShuffleStatsTracks = 1000
+
+// RNGMode describes the shuffle's random source
+const RNGMode = "math/rand, clock-seeded per radio"
+
+// shuffleStats is one UTC day's accounting of a radio's shuffles. The zero
+// value is ready to use.
+type shuffleStats struct {
+	day         time.Time     // start of the UTC day counted
+	playlists   int           // shuffled playlists served
+	appearances map[int64]int // track ID -> times in the leading positions
+	other       int           // appearances of tracks past maxShuffleStatsTracks
+	recentHits  int           // recently played tracks moved to the end
+	headHits    int           // tracks of the last served head moved back
+}
+
+// TrackAppearances counts how often a track opened a shuffled playlist
+type TrackAppearances struct {
+	TrackID int64   `json:"track_id"`
+	Count   int     `json:"count"`
+	Share   float64 `json:"share"` // of the day's playlists
+}
+
+// ShuffleStats reports how a mood's shuffles spread tracks over the leading
+// positions since the start of the UTC day, with the recency demotions
+// behind that spread
+type ShuffleStats struct {
+	Mood             string             `json:"mood"`
+	Since            time.Time          `json:"since"`
+	Playlists        int                `json:"playlists"`
+	Positions        int                `json:"positions"`
+	Tracks           []TrackAppearances `json:"tracks"` // most frequent first
+	OtherAppearances int                `json:"other_appearances,omitempty"`
+	RecencyDemotions int                `json:"recency_demotions"`
+	HeadDemotions    int                `json:"head_demotions"`
+	RecentlyPlayed   []int64            `json:"recently_played"` // within the recency window, oldest first
+	RNG              string             `json:"rng"`
+}
+
+// rollStatsLocked starts a new day's counters once the UTC day has changed,
+// keeping the appearances map for reuse. Caller must hold r.mu.
+func (r *Radio) rollStatsLocked() {
+	day := r.now().UTC().Truncate(24 * time.Hour)
+	if day.Equal(r.stats.day) {
+		return
+	}
+	clear(r.stats.appearances)
+	r.stats = shuffleStats{day: day, appearances: r.stats.appearances}
+}
+
+// recordShuffleLocked counts a served playlist's leading tracks and the
+// demotions its shuffle made. It allocates only when a track is first seen
+// in a day. Caller must hold r.mu.
+func (r *Radio) recordShuffleLocked(playlist []*inventory.Track, recentHits, headHits int) {
+	r.rollStatsLocked()
+	s := &r.stats
+	if s.appearances == nil {
+		s.appearances = make(map[int64]int, ShuffleStatsPositions)
+	}
+	s.playlists++
+	s.recentHits += recentHits
+	s.headHits += headHits
+	for _, t := range playlist[:min(ShuffleStatsPositions, len(playlist))] {
+		if _, ok := s.appearances[t.ID]; ok || len(s.appearances) < maxShuffleStatsTracks {
+			s.appearances[t.ID]++
+		} else {
+			s.other++
+		}
+	}
+}
+
+// ShuffleStats copies the day's shuffle accounting under the radio's lock
+func (r *Radio) ShuffleStats() ShuffleStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rollStatsLocked()
+	r.pruneRecentLocked()
+	s := r.stats
+	stats := ShuffleStats{
+		Mood:             r.mood,
+		Since:            s.day,
+		Playlists:        s.playlists,
+		Positions:        ShuffleStatsPositions,
+		Tracks:           make([]TrackAppearances, 0, len(s.appearances)),
+		OtherAppearances: s.other,
+		RecencyDemotions: s.recentHits,
+		HeadDemotions:    s.headHits,
+		RecentlyPlayed:   make([]int64, len(r.recentlyPlayed)),
+		RNG:              RNGMode,
+	}
+	for id, n := range s.appearances {
+		stats.Tracks = append(stats.Tracks, TrackAppearances{TrackID: id, Count: n, Share: float64(n) / float64(s.playlists)})
+	}
+	slices.SortFunc(stats.Tracks, func(a, b TrackAppearances) int {
+		return cmp.Or(b.Count-a.Count, cmp.Compare(a.TrackID, b.TrackID))
+	})
+	for i, p := range r.recentlyPlayed {
+		stats.RecentlyPlayed[i] = p.id
+	}
+	return stats
+}
@@ -0,0 +1,157 @@
+package radio
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+)
+
+func TestShuffleStats(t *testing.T) {
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	r := NewRadio(setupTestRepo(t), "focus")
+	r.now = func() time.Time { return now }
+	r.headMemory = 0
+	r.RecordPlay(1)
+
+	for range 5 {
+		if _, err := r.GetPlaylist(context.Background(), inventory.TrackFilter{}, 0); err != nil {
+			t.Fatalf("GetPlaylist failed: %v", err)
+		}
+	}
+
+	stats := r.ShuffleStats()
+	if stats.Mood != "focus" || stats.Playlists != 5 || stats.Positions != ShuffleStatsPositions || stats.RNG != RNGMode {
+		t.Errorf("stats = %+v, want 5 focus playlists", stats)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !stats.Since.Equal(want) {
+		t.Errorf("since = %v, want %v", stats.Since, want)
+	}
+	// All 3 focus tracks open every playlist, so each appears 5 times
+	if len(stats.Tracks) != 3 {
+		t.Fatalf("tracks = %+v, want 3", stats.Tracks)
+	}
+	for _, ta := range stats.Tracks {
+		if ta.Count != 5 || ta.Share != 1 {
+			t.Errorf("track %d: count %d share %v, want 5 and 1", ta.TrackID, ta.Count, ta.Share)
+		}
+	}
+	if stats.RecencyDemotions != 5 || stats.HeadDemotions != 0 {
+		t.Errorf("demotions = %d recent, %d head; want 5 and 0", stats.RecencyDemotions, stats.HeadDemotions)
+	}
+	if !slices.Equal(stats.RecentlyPlayed, []int64{1}) {
+		t.Errorf("recently played = %v, want [1]", stats.RecentlyPlayed)
+	}
+
+	// Sorted playlists aren't shuffles and go uncounted
+	if _, err := r.GetPlaylist(context.Background(), inventory.TrackFilter{Sort: inventory.SortBPMAsc}, 0); err != nil {
+		t.Fatalf("GetPlaylist failed: %v", err)
+	}
+	if got := r.ShuffleStats().Playlists; got != 5 {
+		t.Errorf("playlists after a sorted one = %d, want 5", got)
+	}
+
+	// The counters reset with the UTC day
+	now = now.Add(3 * time.Hour)
+	stats = r.ShuffleStats()
+	if stats.Playlists != 0 || len(stats.Tracks) != 0 || stats.RecencyDemotions != 0 {
+		t.Errorf("stats after midnight = %+v, want empty", stats)
+	}
+	if want := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC); !stats.Since.Equal(want) {
+		t.Errorf("since = %v, want %v", stats.Since, want)
+	}
+}
+
+func TestShuffleStatsOrderAndBound(t *testing.T) {
+	r := &Radio{mood: "focus", now: time.Now}
+	playlist := func(ids ...int64) []*inventory.Track {
+		tracks := make([]*inventory.Track, len(ids))
+		for i, id := range ids {
+			tracks[i] = &inventory.Track{ID: id}
+		}
+		return tracks
+	}
+
+	r.mu.Lock()
+	r.recordShuffleLocked(playlist(1, 2), 0, 1)
+	r.recordShuffleLocked(playlist(2, 3), 0, 1)
+	// Only the leading positions count
+	r.recordShuffleLocked(playlist(2, 4, 5, 6, 7, 8, 9, 10, 11, 12, 99), 2, 0)
+	r.mu.Unlock()
+
+	stats := r.ShuffleStats()
+	if stats.Tracks[0].TrackID != 2 || stats.Tracks[0].Count != 3 || stats.Tracks[0].Share != 1 {
+		t.Errorf("top track = %+v, want track 2 in all 3 playlists", stats.Tracks[0])
+	}
+	if stats.Tracks[1].TrackID != 1 {
+		t.Errorf("second track = %+v, want track 1 (ties by ID)", stats.Tracks[1])
+	}
+	if slices.ContainsFunc(stats.Tracks, func(ta TrackAppearances) bool { return ta.TrackID == 99 }) {
+		t.Error("track 99 was past the leading positions but was counted")
+	}
+	if stats.RecencyDemotions != 2 || stats.HeadDemotions != 2 {
+		t.Errorf("demotions = %d recent, %d head; want 2 and 2", stats.RecencyDemotions, stats.HeadDemotions)
+	}
+
+	// Past the track bound, new tracks pool into other
+	r.mu.Lock()
+	for id := range int64(2 * maxShuffleStatsTracks) {
+		r.recordShuffleLocked(playlist(1000+id), 0, 0)
+	}
+	r.mu.Unlock()
+	stats = r.ShuffleStats()
+	if len(stats.Tracks) != maxShuffleStatsTracks {
+		t.Errorf("counted %d tracks, want the bound %d", len(stats.Tracks), maxShuffleStatsTracks)
+	}
+	// 12 tracks were counted before, leaving room for maxShuffleStatsTracks-12
+	if want := 2*maxShuffleStatsTracks - (maxShuffleStatsTracks - 12); stats.OtherAppearances != want {
+		t.Errorf("other appearances = %d, want %d", stats.OtherAppearances, want)
+	}
+}
+
+// TestShuffleStatsAllocs guards the shuffle path: once a day's tracks have
+// been seen, recording a playlist allocates nothing
+func TestShuffleStatsAllocs(t *testing.T) {
+	r := &Radio{mood: "focus", now: time.Now}
+	tracks := make([]*inventory.Track, 50)
+	for i := range tracks {
+		tracks[i] = &inventory.Track{ID: int64(i + 1)}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordShuffleLocked(tracks, 0, 0)
+
+	if allocs := testing.AllocsPerRun(100, func() { r.recordShuffleLocked(tracks, 1, 1) }); allocs != 0 {
+		t.Errorf("recordShuffleLocked allocates %v times per playlist, want 0", allocs)
+	}
+}
+
+func BenchmarkShuffleStats(b *testing.B) {
+	tracks := make([]*inventory.Track, 2000)
+	for i := range tracks {
+		tracks[i] = &inventory.Track{ID: int64(i + 1)}
+	}
+
+	for _, bc := range []struct {
+		name   string
+		record bool
+	}{
+		{"shuffle", false},
+		{"shuffle+stats", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			r := &Radio{mood: "focus", recentWindow: time.Hour, maxRecent: DefaultMaxRecent, rng: rand.New(rand.NewSource(1)), now: time.Now}
+			r.RecordPlay(1)
+			b.ReportAllocs()
+			for b.Loop() {
+				recent, head := r.shuffleWithRecencyLocked(tracks)
+				if bc.record {
+					r.recordShuffleLocked(tracks[:50], recent, head)
+				}
+			}
+		})
+	}
+}
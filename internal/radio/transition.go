@@ -0,0 +1,102 @@
+package radio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/mood"
+	"github.com/1mb-dev/driftfm/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrUnknownMood is returned for a transition to or from a mood that isn't
+// in the registry
+var ErrUnknownMood = errors.New("unknown mood")
+
+// GetTransitionPlaylist returns a playlist of up to steps tracks that starts
+// in the from mood and drifts into the to mood: each position is more
+// likely than the last to come from to, ending on it. Both moods' radios
+// shuffle and demote recent plays as usual. When one mood runs short the
+// other fills in, so the playlist may be shorter than steps only when both
+// do.
+func (m *Manager) GetTransitionPlaylist(ctx context.Context, from, to string, steps int) ([]*inventory.Track, error) {
+	for _, name := range []string{from, to} {
+		if !mood.Known(name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownMood, name)
+		}
+	}
+	if steps < 1 {
+		return nil, fmt.Errorf("transition needs at least 1 step, got %d", steps)
+	}
+	ctx, span := tracing.Start(ctx, "radio.GetTransitionPlaylist",
+		attribute.String("from", from), attribute.String("to", to), attribute.Int("steps", steps))
+	defer span.End()
+
+	fromTracks, err := m.GetPlaylist(ctx, from, inventory.TrackFilter{}, steps)
+	if err != nil {
+		return nil, err
+	}
+	toTracks, err := m.GetPlaylist(ctx, to, inventory.TrackFilter{}, steps)
+	if err != nil {
+		return nil, err
+	}
+	tracks := blend(fromTracks, toTracks, steps)
+	stampPlaylist(tracks)
+	return tracks, nil
+}
+
+// blend interleaves up to steps tracks from two playlists. The share of
+// position i drawn from to rises linearly from 0 at the first position to 1
+// at the last; a position takes a to track once the rounded running total
+// of those shares gets ahead of the to tracks placed so far. A track in
+// both lists is placed once.
+func blend(from, to []*inventory.Track, steps int) []*inventory.Track {
+	out := make([]*inventory.Track, 0, steps)
+	seen := make(map[int64]bool, steps)
+	var fromNext, toNext int
+	take := func(list []*inventory.Track, next *int) *inventory.Track {
+		for *next < len(list) {
+			t := list[*next]
+			*next++
+			if !seen[t.ID] {
+				return t
+			}
+		}
+		return nil
+	}
+
+	var owed float64
+	placed := 0
+	for i := range steps {
+		if steps > 1 {
+			owed += float64(i) / float64(steps-1)
+		}
+		wantTo := math.Round(owed) > float64(placed)
+
+		var t *inventory.Track
+		fromTo := false
+		if wantTo {
+			t = take(to, &toNext)
+			fromTo = t != nil
+		}
+		if t == nil {
+			t = take(from, &fromNext)
+		}
+		if t == nil {
+			t = take(to, &toNext)
+			fromTo = t != nil
+		}
+		if t == nil {
+			break
+		}
+		if fromTo {
+			placed++
+		}
+		seen[t.ID] = true
+		out = append(out, t)
+	}
+	return out
+}
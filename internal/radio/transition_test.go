@@ -0,0 +1,115 @@
+package radio
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/1mb-dev/driftfm/internal/inventory"
+	"github.com/1mb-dev/driftfm/internal/testutil"
+)
+
+// moodTracks returns n tracks of mood with IDs starting at first
+func moodTracks(mood string, first int64, n int) []*inventory.Track {
+	tracks := make([]*inventory.Track, n)
+	for i := range tracks {
+		tracks[i] = &inventory.Track{ID: first + int64(i), Mood: mood}
+	}
+	return tracks
+}
+
+// countMood counts the tracks of mood in tracks
+func countMood(tracks []*inventory.Track, mood string) int {
+	n := 0
+	for _, t := range tracks {
+		if t.Mood == mood {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBlend(t *testing.T) {
+	got := blend(moodTracks("energize", 1, 10), moodTracks("calm", 101, 10), 10)
+	if len(got) != 10 {
+		t.Fatalf("blend returned %d tracks, want 10", len(got))
+	}
+	first, second := got[:5], got[5:]
+	if to := countMood(first, "calm"); to >= 5-to {
+		t.Errorf("first half has %d calm of 5, want it to skew toward energize", to)
+	}
+	if to := countMood(second, "calm"); to <= 5-to {
+		t.Errorf("second half has %d calm of 5, want it to skew toward calm", to)
+	}
+	if got[0].Mood != "energize" || got[9].Mood != "calm" {
+		t.Errorf("playlist runs %s to %s, want energize to calm", got[0].Mood, got[9].Mood)
+	}
+
+	t.Run("short mood", func(t *testing.T) {
+		got := blend(moodTracks("energize", 1, 10), moodTracks("calm", 101, 2), 8)
+		if len(got) != 8 || countMood(got, "calm") != 2 {
+			t.Errorf("got %d tracks with %d calm, want 8 with both calm tracks", len(got), countMood(got, "calm"))
+		}
+	})
+
+	t.Run("shared tracks placed once", func(t *testing.T) {
+		shared := moodTracks("calm", 1, 4)
+		got := blend(shared, shared, 4)
+		if len(got) != 4 {
+			t.Errorf("got %v, want each of the 4 tracks once", trackIDs(got))
+		}
+	})
+}
+
+func TestManagerGetTransitionPlaylist(t *testing.T) {
+	var seed strings.Builder
+	seed.WriteString("INSERT INTO tracks (file_path, title, mood, duration_seconds, status) VALUES ")
+	for i := range 20 {
+		mood := "energize"
+		if i >= 10 {
+			mood = "calm"
+		}
+		if i > 0 {
+			seed.WriteString(", ")
+		}
+		fmt.Fprintf(&seed, "('%s/%d.mp3', 'Track %d', '%s', 180, 'approved')", mood, i, i, mood)
+	}
+	dbPath := t.TempDir() + "/test.db"
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(testutil.SchemaDDL + seed.String()); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+	_ = db.Close()
+	repo, err := inventory.NewRepository(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	m := NewManager(repo)
+	tracks, err := m.GetTransitionPlaylist(context.Background(), "energize", "calm", 10)
+	if err != nil {
+		t.Fatalf("GetTransitionPlaylist failed: %v", err)
+	}
+	if len(tracks) != 10 {
+		t.Fatalf("got %d tracks, want 10", len(tracks))
+	}
+	if calm := countMood(tracks[:5], "calm"); calm >= 3 {
+		t.Errorf("first half has %d calm tracks, want mostly energize", calm)
+	}
+	if calm := countMood(tracks[5:], "calm"); calm <= 2 {
+		t.Errorf("second half has %d calm tracks, want mostly calm", calm)
+	}
+
+	for _, pair := range [][2]string{{"disco", "calm"}, {"energize", "disco"}} {
+		if _, err := m.GetTransitionPlaylist(context.Background(), pair[0], pair[1], 10); !errors.Is(err, ErrUnknownMood) {
+			t.Errorf("%s → %s: err = %v, want ErrUnknownMood", pair[0], pair[1], err)
+		}
+	}
+}
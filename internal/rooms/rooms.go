@@ -0,0 +1,406 @@
+// Package rooms implements synchronized "listen together" rooms over WebSocket.
+//
+// The first member of a room is its host. The host's client reports each
+// track start; the server stamps it and rebroadcasts the room state so every
+// member can seek to the same offset. When the host leaves, the longest-
+// connected remaining member takes over.
+package rooms
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrInvalidRoom is returned for room names outside [a-z0-9_-]{1,32}
+	ErrInvalidRoom = errors.New("invalid room name")
+	// ErrTooManyRooms is returned when a new room would exceed MaxRooms
+	ErrTooManyRooms = errors.New("room limit reached")
+	// ErrRoomFull is returned when a room already has MaxMembers members
+	ErrRoomFull = errors.New("room is full")
+)
+
+// Message types
+const (
+	TypeTrackStart = "track_start" // client → server, host only
+	TypeState      = "state"       // server → client
+)
+
+const (
+	maxRoomNameLength = 32
+	maxMessageSize    = 512
+
+	writeWait  = 5 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+)
+
+// Options bounds hub resources
+type Options struct {
+	MaxRooms   int
+	MaxMembers int           // per room
+	EmptyTTL   time.Duration // how long an empty room keeps its state
+	SendBuffer int           // queued messages per member before it is dropped as slow
+}
+
+// Command is a message from a client
+type Command struct {
+	Type     string  `json:"type"`
+	TrackID  int64   `json:"track_id"`
+	Position float64 `json:"position"` // seconds into the track when sent
+}
+
+// State is the room snapshot broadcast to members
+type State struct {
+	Type      string     `json:"type"`
+	TrackID   int64      `json:"track_id,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // when position 0 played
+	Position  float64    `json:"position"`             // seconds into the track at send time
+	Members   int        `json:"members"`
+	Host      bool       `json:"host"` // the recipient is the host
+}
+
+// Hub tracks rooms and their members
+type Hub struct {
+	opts     Options
+	upgrader websocket.Upgrader
+	now      func() time.Time
+
+	mu    sync.Mutex
+	rooms map[string]*room
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+type room struct {
+	name       string
+	members    []*member // join order; the first is promoted when the host leaves
+	host       *member
+	pending    int // upgrades in flight that hold a slot
+	trackID    int64
+	startedAt  time.Time
+	emptySince time.Time
+}
+
+type member struct {
+	conn      *websocket.Conn
+	send      chan State
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (m *member) close() {
+	m.closeOnce.Do(func() { close(m.done) })
+}
+
+// offer queues a state without blocking; false means the member is too slow
+func (m *member) offer(s State) bool {
+	select {
+	case m.send <- s:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewHub creates a hub and starts its expiry loop
+func NewHub(opts Options) *Hub {
+	h := &Hub{
+		opts:  opts,
+		now:   time.Now,
+		rooms: make(map[string]*room),
+		stop:  make(chan struct{}),
+	}
+	go h.expireLoop()
+	return h
+}
+
+// ValidRoomName reports whether name is 1-32 characters of [a-z0-9_-]
+func ValidRoomName(name string) bool {
+	if name == "" || len(name) > maxRoomNameLength {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Serve upgrades the request to a WebSocket and runs the member's session in
+// the named room until it disconnects. Errors returned before the upgrade
+// (ErrInvalidRoom, ErrTooManyRooms, ErrRoomFull) leave w untouched so the
+// caller can respond; a failed upgrade has already been answered.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, name string) error {
+	if !ValidRoomName(name) {
+		return ErrInvalidRoom
+	}
+
+	rm, err := h.reserve(name)
+	if err != nil {
+		return err
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.mu.Lock()
+		rm.pending--
+		h.mu.Unlock()
+		return err
+	}
+
+	m := &member{
+		conn: conn,
+		send: make(chan State, h.opts.SendBuffer),
+		done: make(chan struct{}),
+	}
+	h.join(rm, m)
+
+	go h.writePump(m)
+	h.readPump(rm, m)
+	return nil
+}
+
+// reserve finds or creates the room and holds a member slot for the upgrade
+func (h *Hub) reserve(name string) (*room, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rm, ok := h.rooms[name]
+	if !ok {
+		if len(h.rooms) >= h.opts.MaxRooms {
+			return nil, ErrTooManyRooms
+		}
+		rm = &room{name: name, emptySince: h.now()}
+		h.rooms[name] = rm
+	}
+	if len(rm.members)+rm.pending >= h.opts.MaxMembers {
+		return nil, ErrRoomFull
+	}
+	rm.pending++
+	return rm, nil
+}
+
+func (h *Hub) join(rm *room, m *member) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rm.pending--
+	rm.members = append(rm.members, m)
+	if rm.host == nil {
+		rm.host = m
+	}
+	h.broadcastLocked(rm)
+}
+
+// leave removes a member and, if it was the host, hands off to the next one
+func (h *Hub) leave(rm *room, m *member) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.removeLocked(rm, m) {
+		h.broadcastLocked(rm)
+	}
+}
+
+// removeLocked drops m from the room. Returns false if it was already gone.
+// Caller must hold h.mu.
+func (h *Hub) removeLocked(rm *room, m *member) bool {
+	idx := -1
+	for i, other := range rm.members {
+		if other == m {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+
+	rm.members = append(rm.members[:idx], rm.members[idx+1:]...)
+	m.close()
+
+	if rm.host == m {
+		rm.host = nil
+		if len(rm.members) > 0 {
+			rm.host = rm.members[0]
+		}
+	}
+	if len(rm.members) == 0 {
+		rm.emptySince = h.now()
+	}
+	return true
+}
+
+// broadcastLocked sends the room state to every member. Members whose send
+// buffer is full are dropped, and the remaining members get a fresh state
+// reflecting the new count. Caller must hold h.mu.
+func (h *Hub) broadcastLocked(rm *room) {
+	for len(rm.members) > 0 {
+		var slow []*member
+		for _, m := range rm.members {
+			if !m.offer(h.stateLocked(rm, m)) {
+				slow = append(slow, m)
+			}
+		}
+		if len(slow) == 0 {
+			return
+		}
+		for _, m := range slow {
+			log.Printf("Warning: dropping slow member from room %s", rm.name)
+			h.removeLocked(rm, m)
+		}
+	}
+}
+
+// stateLocked builds the snapshot for one recipient. Caller must hold h.mu.
+func (h *Hub) stateLocked(rm *room, to *member) State {
+	s := State{
+		Type:    TypeState,
+		Members: len(rm.members),
+		Host:    rm.host == to,
+	}
+	if rm.trackID != 0 {
+		started := rm.startedAt
+		s.TrackID = rm.trackID
+		s.StartedAt = &started
+		s.Position = h.now().Sub(started).Seconds()
+	}
+	return s
+}
+
+// trackStart records the host's track start and rebroadcasts it
+func (h *Hub) trackStart(rm *room, from *member, cmd Command) {
+	if cmd.TrackID <= 0 || cmd.Position < 0 || math.IsNaN(cmd.Position) || math.IsInf(cmd.Position, 0) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if rm.host != from {
+		return
+	}
+	rm.trackID = cmd.TrackID
+	rm.startedAt = h.now().Add(-time.Duration(cmd.Position * float64(time.Second)))
+	h.broadcastLocked(rm)
+}
+
+func (h *Hub) readPump(rm *room, m *member) {
+	defer func() {
+		h.leave(rm, m)
+		_ = m.conn.Close()
+	}()
+
+	m.conn.SetReadLimit(maxMessageSize)
+	_ = m.conn.SetReadDeadline(time.Now().Add(pongWait))
+	m.conn.SetPongHandler(func(string) error {
+		return m.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var cmd Command
+		if err := m.conn.ReadJSON(&cmd); err != nil {
+			return
+		}
+		if cmd.Type == TypeTrackStart {
+			h.trackStart(rm, m, cmd)
+		}
+	}
+}
+
+func (h *Hub) writePump(m *member) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = m.conn.Close()
+	}()
+
+	for {
+		select {
+		case s := <-m.send:
+			_ = m.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := m.conn.WriteJSON(s); err != nil {
+				m.close()
+				return
+			}
+		case <-ticker.C:
+			_ = m.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := m.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				m.close()
+				return
+			}
+		case <-m.done:
+			_ = m.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+func (h *Hub) expireLoop() {
+	interval := h.opts.EmptyTTL / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.expire()
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+// expire removes rooms that have been empty for longer than EmptyTTL
+func (h *Hub) expire() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	for name, rm := range h.rooms {
+		if len(rm.members) == 0 && rm.pending == 0 && now.Sub(rm.emptySince) >= h.opts.EmptyTTL {
+			delete(h.rooms, name)
+		}
+	}
+}
+
+// Stats returns room statistics for the metrics endpoint
+func (h *Hub) Stats() map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	members := 0
+	for _, rm := range h.rooms {
+		members += len(rm.members)
+	}
+	return map[string]any{
+		"rooms":   len(h.rooms),
+		"members": members,
+	}
+}
+
+// Close stops the expiry loop and disconnects every member
+func (h *Hub) Close() {
+	h.stopOnce.Do(func() { close(h.stop) })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, rm := range h.rooms {
+		for _, m := range rm.members {
+			m.close()
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package rooms
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startHub serves a hub at /rooms/{room} on a test server
+func startHub(t *testing.T, opts Options) (*Hub, *httptest.Server) {
+	t.Helper()
+	hub := NewHub(opts)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/rooms/")
+		switch err := hub.Serve(w, r, name); {
+		case errors.Is(err, ErrInvalidRoom):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrTooManyRooms), errors.Is(err, ErrRoomFull):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+	return hub, srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, room string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/rooms/" + room
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial %s: %v (status %d)", room, err, status)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readState(t *testing.T, conn *websocket.Conn) State {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var s State
+	if err := conn.ReadJSON(&s); err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+	return s
+}
+
+func defaultOptions() Options {
+	return Options{MaxRooms: 10, MaxMembers: 4, EmptyTTL: time.Minute, SendBuffer: 8}
+}
+
+func TestRoom_StatePropagationAndHostHandoff(t *testing.T) {
+	_, srv := startHub(t, defaultOptions())
+
+	host := dial(t, srv, "friday")
+	if s := readState(t, host); !s.Host || s.Members != 1 {
+		t.Fatalf("first member state = %+v, want host with 1 member", s)
+	}
+
+	guest := dial(t, srv, "friday")
+	if s := readState(t, guest); s.Host || s.Members != 2 {
+		t.Fatalf("guest state = %+v, want non-host with 2 members", s)
+	}
+	if s := readState(t, host); !s.Host || s.Members != 2 {
+		t.Fatalf("host join update = %+v, want host with 2 members", s)
+	}
+
+	// Host starts track 7, already 3s in; guest receives it
+	if err := host.WriteJSON(Command{Type: TypeTrackStart, TrackID: 7, Position: 3}); err != nil {
+		t.Fatalf("host write: %v", err)
+	}
+	s := readState(t, guest)
+	if s.TrackID != 7 || s.StartedAt == nil || s.Position < 3 || s.Position > 4 {
+		t.Errorf("guest state after track start = %+v, want track 7 near position 3", s)
+	}
+	readState(t, host)
+
+	// Non-host commands are ignored: the guest's next message is the handoff
+	if err := guest.WriteJSON(Command{Type: TypeTrackStart, TrackID: 99}); err != nil {
+		t.Fatalf("guest write: %v", err)
+	}
+
+	_ = host.Close()
+	s = readState(t, guest)
+	if !s.Host || s.Members != 1 {
+		t.Errorf("guest state after host left = %+v, want host with 1 member", s)
+	}
+	if s.TrackID != 7 {
+		t.Errorf("track after handoff = %d, want 7 (guest command must be ignored)", s.TrackID)
+	}
+}
+
+func TestRoom_LateJoinerGetsCurrentTrack(t *testing.T) {
+	_, srv := startHub(t, defaultOptions())
+
+	host := dial(t, srv, "late")
+	readState(t, host)
+	if err := host.WriteJSON(Command{Type: TypeTrackStart, TrackID: 3, Position: 10}); err != nil {
+		t.Fatalf("host write: %v", err)
+	}
+	readState(t, host)
+
+	late := dial(t, srv, "late")
+	if s := readState(t, late); s.TrackID != 3 || s.Position < 10 {
+		t.Errorf("late joiner state = %+v, want track 3 at position >= 10", s)
+	}
+}
+
+func TestRoom_Limits(t *testing.T) {
+	opts := defaultOptions()
+	opts.MaxRooms = 1
+	opts.MaxMembers = 1
+	hub, srv := startHub(t, opts)
+
+	dial(t, srv, "one")
+
+	if _, err := hub.reserve("one"); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("second member: err = %v, want ErrRoomFull", err)
+	}
+	if _, err := hub.reserve("two"); !errors.Is(err, ErrTooManyRooms) {
+		t.Errorf("second room: err = %v, want ErrTooManyRooms", err)
+	}
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/rooms/BAD!"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid room name: err = %v, want 400", err)
+	}
+}
+
+func TestRoom_SlowConsumerDropped(t *testing.T) {
+	hub := NewHub(Options{MaxRooms: 1, MaxMembers: 4, EmptyTTL: time.Minute, SendBuffer: 1})
+	t.Cleanup(hub.Close)
+
+	// Members without writers: nothing drains their send buffers
+	fast := &member{send: make(chan State, 8), done: make(chan struct{})}
+	slow := &member{send: make(chan State, 1), done: make(chan struct{})}
+	rm := &room{name: "r"}
+
+	hub.mu.Lock()
+	rm.members = []*member{fast, slow}
+	rm.host = fast
+	hub.broadcastLocked(rm) // fills slow's buffer
+	hub.broadcastLocked(rm) // slow can't take this one
+	hub.mu.Unlock()
+
+	if len(rm.members) != 1 || rm.members[0] != fast {
+		t.Fatalf("members = %d, want only the fast member left", len(rm.members))
+	}
+	select {
+	case <-slow.done:
+	default:
+		t.Error("slow member should be closed")
+	}
+
+	// The fast member's latest state reflects the drop
+	var last State
+	for len(fast.send) > 0 {
+		last = <-fast.send
+	}
+	if last.Members != 1 {
+		t.Errorf("latest member count = %d, want 1", last.Members)
+	}
+}
+
+func TestRoom_EmptyRoomsExpire(t *testing.T) {
+	hub := NewHub(Options{MaxRooms: 1, MaxMembers: 4, EmptyTTL: time.Minute, SendBuffer: 8})
+	t.Cleanup(hub.Close)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	hub.now = func() time.Time { return now }
+
+	rm, err := hub.reserve("gone")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	m := &member{send: make(chan State, 8), done: make(chan struct{})}
+	hub.join(rm, m)
+	hub.leave(rm, m)
+
+	now = now.Add(30 * time.Second)
+	hub.expire()
+	if _, err := hub.reserve("other"); !errors.Is(err, ErrTooManyRooms) {
+		t.Fatalf("room expired early: err = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	hub.expire()
+	if _, err := hub.reserve("other"); err != nil {
+		t.Errorf("expired room still counted: %v", err)
+	}
+}
+
+func TestValidRoomName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"friday", true},
+		{"late_night-2", true},
+		{"", false},
+		{"Friday", false},
+		{"a/b", false},
+		{strings.Repeat("a", 33), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRoomName(tt.name); got != tt.want {
+			t.Errorf("ValidRoomName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -18,7 +18,12 @@ const SchemaDDL = `
 		time_affinity TEXT DEFAULT 'any',
 		lyrics TEXT,
 		duration_seconds INTEGER NOT NULL,
+		content_hash TEXT,
+		fade_in_ms INTEGER,
+		fade_out_ms INTEGER,
+		file_missing_at DATETIME,
 		status TEXT NOT NULL DEFAULT 'approved',
+		rollout_percent INTEGER NOT NULL DEFAULT 100,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);
 	CREATE TABLE play_stats (
@@ -34,6 +39,48 @@ const SchemaDDL = `
 		event_type TEXT NOT NULL CHECK (event_type IN ('play', 'skip', 'complete')),
 		listen_seconds INTEGER NOT NULL DEFAULT 0,
 		playlist_position INTEGER,
+		session_id TEXT,
+		platform TEXT,
+		app_version TEXT,
+		client_id TEXT,
+		playlist_id TEXT,
+		station TEXT,
+		reason TEXT,
 		created_at DATETIME NOT NULL DEFAULT (datetime('now'))
 	);
+	CREATE TABLE track_tags (
+		track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (track_id, tag)
+	);
+	CREATE TABLE track_status_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+		old_status TEXT NOT NULL,
+		new_status TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		reason TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE listener_state (
+		listener_id TEXT NOT NULL,
+		mood TEXT NOT NULL,
+		track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+		position_seconds INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (listener_id, mood)
+	);
+	CREATE TABLE hidden_tracks (
+		listener_id TEXT NOT NULL,
+		track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (listener_id, track_id)
+	);
+	CREATE TABLE mood_meta (
+		mood TEXT PRIMARY KEY,
+		color TEXT NOT NULL DEFAULT '',
+		icon TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL
+	);
 `
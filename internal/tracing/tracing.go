@@ -0,0 +1,166 @@
+// Package tracing wires optional OpenTelemetry tracing: a server span per
+// request and child spans around the work it does. Child spans come from
+// the tracer provider of the span already in the context, so with tracing
+// disabled there is no span, every helper hits the no-op tracer, and the
+// middleware is not installed at all.
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope names the instrumentation library on every span
+const scope = "github.com/1mb-dev/driftfm"
+
+// propagator joins traces started by an upstream proxy or client
+var propagator = propagation.TraceContext{}
+
+// Options configures the OTLP exporter and sampling
+type Options struct {
+	Endpoint       string  // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	SampleRate     float64 // fraction of new traces recorded, 0-1
+	ServiceVersion string
+}
+
+// New creates a tracer provider exporting to an OTLP/HTTP collector in
+// batches. Traces joined from upstream keep the upstream sampling decision.
+// Call Shutdown on the provider to flush buffered spans.
+func New(ctx context.Context, opts Options) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return NewWithExporter(exporter, opts.SampleRate, opts.ServiceVersion), nil
+}
+
+// NewWithExporter creates a tracer provider on an existing exporter
+func NewWithExporter(exporter sdktrace.SpanExporter, sampleRate float64, serviceVersion string) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(
+		attribute.String("service.name", "driftfm"),
+		attribute.String("service.version", serviceVersion),
+	)
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRate))),
+	)
+}
+
+// Middleware starts a server span per request on tp, named by the route
+// pattern the mux matched. A nil tp disables tracing and returns next.
+func Middleware(tp trace.TracerProvider, next http.Handler) http.Handler {
+	if tp == nil {
+		return next
+	}
+	tracer := tp.Tracer(scope)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		// The mux sets r.Pattern on the request it is handed
+		r = r.WithContext(ctx)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Method + " " + strings.TrimPrefix(r.Pattern, r.Method+" "))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+	})
+}
+
+// Start starts a child span of the span in ctx. Without one, the span is a
+// no-op and costs next to nothing.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(scope).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Call runs fn in a child span named name, recording its error. It is the
+// wrapper for calls that take no context, such as most repository methods.
+func Call[T any](ctx context.Context, name string, fn func() (T, error)) (T, error) {
+	_, span := Start(ctx, name)
+	defer span.End()
+
+	v, err := fn()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return v, err
+}
+
+// TraceID returns the ID of the sampled trace in ctx, or "" when the
+// request isn't traced
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// statusWriter captures the response status for the server span
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader && code >= http.StatusOK {
+		sw.status, sw.wroteHeader = code, true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush passes through so streamed responses aren't held back
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("underlying ResponseWriter does not support hijacking")
+	}
+	sw.status, sw.wroteHeader = http.StatusSwitchingProtocols, true
+	return h.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp, exp
+}
+
+func TestMiddleware_NilProviderReturnsNext(t *testing.T) {
+	var called bool
+	handler := Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if _, ok := w.(*statusWriter); ok {
+			t.Error("writer wrapped with tracing disabled")
+		}
+		if id := TraceID(r.Context()); id != "" {
+			t.Errorf("TraceID = %q with tracing disabled, want empty", id)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/moods", nil))
+
+	if !called {
+		t.Error("next handler not called")
+	}
+}
+
+func TestMiddleware_SpanNameAndStatus(t *testing.T) {
+	tp, exp := newTestProvider(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/moods/{mood}/playlist", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	})
+	handler := Middleware(tp, mux)
+
+	tests := []struct {
+		path      string
+		wantName  string
+		wantError bool
+	}{
+		{"/api/moods/focus/playlist", "GET /api/moods/{mood}/playlist", true},
+		{"/api/tags", "GET /api/tags", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			exp.Reset()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			spans := exp.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			if spans[0].Name != tt.wantName {
+				t.Errorf("span name = %q, want %q", spans[0].Name, tt.wantName)
+			}
+			if gotError := spans[0].Status.Code == codes.Error; gotError != tt.wantError {
+				t.Errorf("error status = %v, want %v", gotError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestMiddleware_JoinsUpstreamTrace(t *testing.T) {
+	tp, exp := newTestProvider(t)
+
+	var traceID string
+	handler := Middleware(tp, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		traceID = TraceID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/moods", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("TraceID = %q, want the upstream trace", traceID)
+	}
+	spans := exp.GetSpans()
+	if len(spans) != 1 || spans[0].Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span is not a child of the upstream span: %+v", spans)
+	}
+}
+
+func TestStart_WithoutSpanIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "orphan")
+	defer span.End()
+
+	if span.IsRecording() {
+		t.Error("span without a parent should not record")
+	}
+	if id := TraceID(ctx); id != "" {
+		t.Errorf("TraceID = %q, want empty", id)
+	}
+}
+
+func TestCall_RecordsError(t *testing.T) {
+	tp, exp := newTestProvider(t)
+	ctx, root := tp.Tracer("test").Start(context.Background(), "root")
+
+	errBoom := errors.New("boom")
+	if _, err := Call(ctx, "inventory.GetByMood", func() (int, error) { return 0, errBoom }); !errors.Is(err, errBoom) {
+		t.Fatalf("Call error = %v, want %v", err, errBoom)
+	}
+	if v, err := Call(ctx, "inventory.CountByMood", func() (int, error) { return 3, nil }); v != 3 || err != nil {
+		t.Fatalf("Call = %d, %v; want 3, nil", v, err)
+	}
+	root.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	for _, s := range spans[:2] {
+		if s.Parent.SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of root", s.Name)
+		}
+	}
+	if spans[0].Status.Code != codes.Error || len(spans[0].Events) == 0 {
+		t.Errorf("failed call: status %v with %d events, want an error and a recorded exception", spans[0].Status.Code, len(spans[0].Events))
+	}
+	if spans[1].Status.Code == codes.Error {
+		t.Error("successful call has error status")
+	}
+}
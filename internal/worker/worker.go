@@ -0,0 +1,129 @@
+// Package worker runs fire-and-forget side effects, such as the in-memory
+// bookkeeping after a play is recorded, on a bounded pool of goroutines so
+// requests don't wait on them.
+//
+// Tasks run in no particular order, possibly concurrently, and a task may
+// run on the submitting goroutine. Nothing is dropped: a full queue pushes
+// back by running the task inline.
+package worker
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// Options configures a Pool
+type Options struct {
+	Workers   int // goroutines running tasks
+	QueueSize int // tasks buffered ahead of the workers
+}
+
+// Pool is a fixed set of workers fed by a bounded queue
+type Pool struct {
+	opts  Options
+	tasks chan func()
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex // guards closed against sends on a closed channel
+	closed bool
+
+	queued    atomic.Int64
+	inline    atomic.Int64
+	completed atomic.Int64
+	panicked  atomic.Int64
+}
+
+// New creates a pool and starts its workers. Workers and QueueSize below 1
+// are raised to 1.
+func New(opts Options) *Pool {
+	opts.Workers = max(opts.Workers, 1)
+	opts.QueueSize = max(opts.QueueSize, 1)
+	p := &Pool{opts: opts, tasks: make(chan func(), opts.QueueSize)}
+	p.wg.Add(opts.Workers)
+	for range opts.Workers {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues task for a worker and reports true. When the queue is full,
+// or the pool is closed, task runs on the caller's goroutine before Submit
+// returns false.
+func (p *Pool) Submit(task func()) bool {
+	p.mu.RLock()
+	if !p.closed {
+		select {
+		case p.tasks <- task:
+			p.mu.RUnlock()
+			p.queued.Add(1)
+			return true
+		default:
+		}
+	}
+	p.mu.RUnlock()
+
+	p.inline.Add(1)
+	p.run(task)
+	return false
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		p.run(task)
+	}
+}
+
+// run calls task, logging a panic rather than letting it take the process
+// (or the request it runs inline on) down
+func (p *Pool) run(task func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.panicked.Add(1)
+			log.Printf("Error in background task: panic: %v\n%s", v, debug.Stack())
+			return
+		}
+		p.completed.Add(1)
+	}()
+	task()
+}
+
+// Stats returns pool statistics for the metrics endpoint
+func (p *Pool) Stats() map[string]any {
+	return map[string]any{
+		"workers":   p.opts.Workers,
+		"depth":     len(p.tasks),
+		"capacity":  cap(p.tasks),
+		"queued":    p.queued.Load(),
+		"inline":    p.inline.Load(),
+		"completed": p.completed.Load(),
+		"panicked":  p.panicked.Load(),
+	}
+}
+
+// Close stops queueing new tasks, which then run inline, and waits for the
+// workers to finish everything already queued, or for ctx to be done. Safe
+// to call more than once.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
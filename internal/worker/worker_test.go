@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockWorkers occupies every worker of p until the returned func is called
+func blockWorkers(t *testing.T, p *Pool) (release func()) {
+	t.Helper()
+	gate := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(p.opts.Workers)
+	for range p.opts.Workers {
+		if !p.Submit(func() {
+			started.Done()
+			<-gate
+		}) {
+			t.Fatal("blocking task ran inline")
+		}
+	}
+	started.Wait()
+	return func() { close(gate) }
+}
+
+func TestPool_RunsEveryTask(t *testing.T) {
+	p := New(Options{Workers: 4, QueueSize: 8})
+
+	var ran atomic.Int64
+	for range 100 {
+		p.Submit(func() { ran.Add(1) })
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if got := ran.Load(); got != 100 {
+		t.Errorf("ran %d tasks, want 100", got)
+	}
+	stats := p.Stats()
+	if stats["queued"].(int64)+stats["inline"].(int64) != 100 || stats["completed"] != int64(100) {
+		t.Errorf("stats = %v, want 100 submitted and completed", stats)
+	}
+}
+
+func TestPool_NoOrderingGuarantee(t *testing.T) {
+	// Two tasks that each wait for the other to start can only finish if the
+	// pool runs them concurrently, i.e. the second doesn't wait on the first
+	p := New(Options{Workers: 2, QueueSize: 2})
+	defer func() { _ = p.Close(context.Background()) }()
+
+	var both sync.WaitGroup
+	both.Add(2)
+	done := make(chan struct{}, 2)
+	for range 2 {
+		p.Submit(func() {
+			both.Done()
+			both.Wait()
+			done <- struct{}{}
+		})
+	}
+
+	for range 2 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("tasks were serialized, want them free to run concurrently")
+		}
+	}
+}
+
+func TestPool_FullQueueRunsInline(t *testing.T) {
+	p := New(Options{Workers: 1, QueueSize: 1})
+	release := blockWorkers(t, p)
+
+	if !p.Submit(func() {}) {
+		t.Fatal("task with room in the queue ran inline")
+	}
+
+	// The queue is full: the task runs on this goroutine before Submit returns
+	ranInline := false
+	if p.Submit(func() { ranInline = true }) {
+		t.Error("Submit reported queued with a full queue")
+	}
+	if !ranInline {
+		t.Error("task not run before Submit returned")
+	}
+	if got := p.Stats()["inline"]; got != int64(1) {
+		t.Errorf("inline = %v, want 1", got)
+	}
+	if got := p.Stats()["depth"]; got != 1 {
+		t.Errorf("depth = %v, want 1", got)
+	}
+
+	release()
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestPool_CloseDrainsQueue(t *testing.T) {
+	p := New(Options{Workers: 1, QueueSize: 10})
+	release := blockWorkers(t, p)
+
+	var ran atomic.Int64
+	for range 5 {
+		p.Submit(func() { ran.Add(1) })
+	}
+
+	closed := make(chan error)
+	go func() { closed <- p.Close(context.Background()) }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned with tasks still queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	if err := <-closed; err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := ran.Load(); got != 5 {
+		t.Errorf("ran %d queued tasks before Close returned, want 5", got)
+	}
+
+	// After Close, tasks still run, inline
+	ranInline := false
+	if p.Submit(func() { ranInline = true }) || !ranInline {
+		t.Error("Submit after Close should run the task inline")
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestPool_CloseTimesOut(t *testing.T) {
+	p := New(Options{Workers: 1, QueueSize: 1})
+	release := blockWorkers(t, p)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestPool_RecoversPanics(t *testing.T) {
+	p := New(Options{Workers: 1, QueueSize: 4})
+
+	var ran atomic.Bool
+	p.Submit(func() { panic("boom") })
+	p.Submit(func() { ran.Store(true) })
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if !ran.Load() {
+		t.Error("worker stopped after a panicking task")
+	}
+	if got := p.Stats()["panicked"]; got != int64(1) {
+		t.Errorf("panicked = %v, want 1", got)
+	}
+}
@@ -0,0 +1,46 @@
+#!/bin/bash
+#
+# Backfill content_hash for tracks imported before duplicate detection
+# Usage: ./scripts/hash-tracks.sh
+#
+# Hashes each track whose content_hash is NULL from audio/<file_path>, then
+# lists any content shared by more than one track.
+
+set -e
+
+# shellcheck source=lib/hash.sh
+. "$(dirname "$0")/lib/hash.sh"
+
+DB="data/inventory.db"
+AUDIO_DIR="audio"
+
+if [[ ! -f "$DB" ]]; then
+    echo "Error: Database not found. Run 'make db-init' first."
+    exit 1
+fi
+
+HASHED=0
+MISSING=0
+while IFS='|' read -r ID FILE_PATH; do
+    FILE="${AUDIO_DIR}/${FILE_PATH}"
+    if [[ ! -f "$FILE" ]]; then
+        echo "  [MISS] $ID $FILE_PATH"
+        MISSING=$((MISSING + 1))
+        continue
+    fi
+    HASH=$(content_hash "$FILE")
+    sqlite3 "$DB" "UPDATE tracks SET content_hash='$HASH' WHERE id=$ID;"
+    HASHED=$((HASHED + 1))
+done < <(sqlite3 "$DB" "SELECT id, file_path FROM tracks WHERE content_hash IS NULL ORDER BY id;")
+
+echo "Hashed $HASHED tracks ($MISSING files missing)"
+
+DUPES=$(sqlite3 "$DB" "SELECT content_hash, group_concat(id || ':' || file_path, ', ') FROM tracks
+    WHERE content_hash IS NOT NULL GROUP BY content_hash HAVING COUNT(*) > 1;")
+if [[ -n "$DUPES" ]]; then
+    echo ""
+    echo "Duplicate content:"
+    echo "$DUPES" | while IFS='|' read -r HASH TRACKS; do
+        echo "  ${HASH:0:16}…  $TRACKS"
+    done
+fi
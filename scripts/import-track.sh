@@ -27,6 +27,8 @@ set -e
 . "$(dirname "$0")/lib/clean-lyrics.sh"
 # shellcheck source=lib/moods.sh
 . "$(dirname "$0")/lib/moods.sh"
+# shellcheck source=lib/hash.sh
+. "$(dirname "$0")/lib/hash.sh"
 
 # Parse arguments
 INPUT_FILE=""
@@ -138,6 +140,18 @@ fi
 
 # Get duration
 DURATION=$(ffprobe -v quiet -show_entries format=duration -of csv=p=0 "$INPUT_FILE" | cut -d'.' -f1)
+if [[ ! "$DURATION" =~ ^[0-9]+$ ]] || (( DURATION <= 0 )); then
+    echo "Error: Could not read a positive duration from $INPUT_FILE (got '${DURATION}')"
+    exit 1
+fi
+
+# Reject content that is already in the inventory under another name
+CONTENT_HASH=$(content_hash "$INPUT_FILE")
+EXISTING=$(sqlite3 "$DB" "SELECT id || ' (' || file_path || ')' FROM tracks WHERE content_hash='$CONTENT_HASH' LIMIT 1;")
+if [[ -n "$EXISTING" ]]; then
+    echo "Error: $INPUT_FILE duplicates existing track $EXISTING"
+    exit 1
+fi
 
 # Auto-detect vocals and lyrics from companion .txt file
 # Convention: place a .txt file next to the .mp3 with the same name
@@ -166,6 +180,7 @@ echo ""
 echo "  Source:     $INPUT_FILE"
 echo "  Dest:       audio/tracks/<prefix>/<title>-<id>.mp3"
 echo "  Duration:   ${DURATION}s ($(( DURATION / 60 )):$(printf '%02d' $(( DURATION % 60 ))))"
+echo "  SHA-256:    ${CONTENT_HASH:0:16}…"
 echo ""
 echo "  Title:      $TITLE"
 echo "  Artist:     $ARTIST"
@@ -197,7 +212,7 @@ cp "$INPUT_FILE" "$TEMP_PATH"
 SQL="INSERT INTO tracks (
     file_path, title, artist, mood, energy, tempo_bpm, has_vocals,
     musical_key, intensity, time_affinity, lyrics, duration_seconds,
-    status
+    content_hash, status
 ) VALUES (
     'tracks/tmp/${TEMP_FILENAME}',
     '$(echo "$TITLE" | sed "s/'/''/g")',
@@ -211,6 +226,7 @@ SQL="INSERT INTO tracks (
     '$TIME_AFFINITY',
     $([ -n "$LYRICS" ] && echo "'$(echo "$LYRICS" | sed "s/'/''/g")'" || echo "NULL"),
     $DURATION,
+    '$CONTENT_HASH',
     'approved'
 );"
 
@@ -0,0 +1,12 @@
+# Content hashing for Drift FM import pipeline
+# Source this file: . scripts/lib/hash.sh
+
+# Print the hex SHA-256 of a file (sha256sum on Linux, shasum on macOS)
+# Usage: HASH=$(content_hash "$FILE")
+content_hash() {
+    if command -v sha256sum >/dev/null 2>&1; then
+        sha256sum "$1" | cut -d' ' -f1
+    else
+        shasum -a 256 "$1" | cut -d' ' -f1
+    fi
+}
@@ -0,0 +1,11 @@
+-- Migration 006: track tags
+-- Free-form tags that cut across moods ("rain", "piano", "lofi").
+-- Values are normalized by the application: lowercase [a-z0-9_-], max 32 chars.
+
+CREATE TABLE IF NOT EXISTS track_tags (
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (track_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_tags_tag ON track_tags(tag);
@@ -0,0 +1,7 @@
+-- Migration 007: listen sessions
+-- Per-tab session ID sent by the player, used to group listen events into
+-- sessions for analytics. NULL for events from older clients.
+
+ALTER TABLE listen_events ADD COLUMN session_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_listen_events_session ON listen_events(session_id, created_at);
@@ -0,0 +1,15 @@
+-- Migration 008: track status history
+-- Audit log of track status changes (approve/reject), written in the same
+-- transaction as the change. actor is the admin identity or 'system'.
+
+CREATE TABLE IF NOT EXISTS track_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    old_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_status_history_track ON track_status_history(track_id, id);
@@ -0,0 +1,7 @@
+-- Migration 009: content hash
+-- Hex SHA-256 of each track's audio file, so the same recording imported
+-- under two names can be found. NULL until hashed (see scripts/hash-tracks.sh).
+
+ALTER TABLE tracks ADD COLUMN content_hash TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_content_hash ON tracks(content_hash);
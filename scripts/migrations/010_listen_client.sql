@@ -0,0 +1,7 @@
+-- Migration 010: listen client
+-- Player platform (ios, android, desktop, other) and optional app version on
+-- listen events, so skips and completions can be broken down by client.
+-- NULL for events recorded before this migration.
+
+ALTER TABLE listen_events ADD COLUMN platform TEXT;
+ALTER TABLE listen_events ADD COLUMN app_version TEXT;
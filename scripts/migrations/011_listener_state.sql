@@ -0,0 +1,15 @@
+-- Migration 011: listener state
+-- Last reported track and position per anonymous listener and mood, so the
+-- player can resume where it left off. One row per (listener_id, mood),
+-- overwritten by progress reports.
+
+CREATE TABLE IF NOT EXISTS listener_state (
+    listener_id TEXT NOT NULL,
+    mood TEXT NOT NULL,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    position_seconds INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (listener_id, mood)
+);
+
+CREATE INDEX IF NOT EXISTS idx_listener_state_updated ON listener_state(updated_at);
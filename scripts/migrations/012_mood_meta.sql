@@ -0,0 +1,11 @@
+-- Migration 012: mood metadata
+-- Presentation metadata per mood (theme color, icon, description) edited
+-- through the admin API. Empty columns fall back to config, then defaults.
+
+CREATE TABLE IF NOT EXISTS mood_meta (
+    mood TEXT PRIMARY KEY,
+    color TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL
+);
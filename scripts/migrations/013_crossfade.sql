@@ -0,0 +1,7 @@
+-- Migration 013: crossfade metadata
+-- Lead-in and lead-out lengths, in milliseconds, for clients that crossfade
+-- between tracks. NULL until set through the admin API or estimated from
+-- the silent padding of the audio file.
+
+ALTER TABLE tracks ADD COLUMN fade_in_ms INTEGER;
+ALTER TABLE tracks ADD COLUMN fade_out_ms INTEGER;
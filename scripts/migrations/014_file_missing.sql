@@ -0,0 +1,6 @@
+-- Migration 014: missing audio files
+-- When a track's audio file was last found missing from disk. Set when a
+-- download finds no file and cleared once it's served again; such tracks are
+-- listed by GET /api/admin/tracks/invalid.
+
+ALTER TABLE tracks ADD COLUMN file_missing_at DATETIME;
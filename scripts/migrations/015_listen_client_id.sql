@@ -0,0 +1,9 @@
+-- Migration 015: listen event client ID
+-- The anonymous driftfm_listener cookie ID on listen events, so a listener
+-- can resume from their latest event in any mood (GET /api/me/resume).
+-- NULL for events from clients without the cookie or recorded before this
+-- migration.
+
+ALTER TABLE listen_events ADD COLUMN client_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_listen_events_client ON listen_events(client_id, created_at);
@@ -0,0 +1,11 @@
+-- Migration 016: hidden tracks
+-- Tracks a listener asked never to hear again ("don't play this"), keyed by
+-- the anonymous driftfm_listener cookie ID. Playlists served to that
+-- listener leave them out.
+
+CREATE TABLE IF NOT EXISTS hidden_tracks (
+    listener_id TEXT NOT NULL,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (listener_id, track_id)
+);
@@ -0,0 +1,6 @@
+-- Migration 017: beta rollout percentage
+-- A track with status 'beta' is served only to the listeners whose stable
+-- rollout bucket (0-99, from the driftfm_listener cookie) falls under its
+-- rollout_percent. Other statuses ignore the column.
+
+ALTER TABLE tracks ADD COLUMN rollout_percent INTEGER NOT NULL DEFAULT 100;
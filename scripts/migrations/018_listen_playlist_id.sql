@@ -0,0 +1,9 @@
+-- Migration 018: listen event playlist ID
+-- The playlist_id of the generated playlist a listen event's track was
+-- served in, so skips can be analyzed by position within the exact ordering
+-- a listener got. NULL for clients that don't send it and for events
+-- recorded before this migration.
+
+ALTER TABLE listen_events ADD COLUMN playlist_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_listen_events_playlist ON listen_events(playlist_id, playlist_position);
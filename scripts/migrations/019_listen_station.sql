@@ -0,0 +1,8 @@
+-- Migration 019: listen event station
+-- The station a listen event was played on, so per-station listening can be
+-- told apart. NULL for plays through the unscoped API of a server without
+-- stations and for events recorded before this migration.
+
+ALTER TABLE listen_events ADD COLUMN station TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_listen_events_station ON listen_events(station, created_at);
@@ -0,0 +1,6 @@
+-- Migration 020: listen event skip reason
+-- Why a listener skipped: dislike, wrong_mood, too_long, repeat, or other.
+-- Only skip events carry one. NULL for skips sent without a reason and for
+-- events recorded before this migration.
+
+ALTER TABLE listen_events ADD COLUMN reason TEXT;
@@ -10,6 +10,21 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 -- Mark this schema as including all migrations
 INSERT OR IGNORE INTO schema_migrations (version) VALUES ('004_play_stats');
 INSERT OR IGNORE INTO schema_migrations (version) VALUES ('005_listen_events');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('006_track_tags');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('007_listen_sessions');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('008_track_status_history');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('009_content_hash');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('010_listen_client');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('011_listener_state');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('012_mood_meta');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('013_crossfade');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('014_file_missing');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('015_listen_client_id');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('016_hidden_tracks');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('017_rollout_percent');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('018_listen_playlist_id');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('019_listen_station');
+INSERT OR IGNORE INTO schema_migrations (version) VALUES ('020_listen_skip_reason');
 
 CREATE TABLE IF NOT EXISTS tracks (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -39,9 +54,14 @@ CREATE TABLE IF NOT EXISTS tracks (
 
     -- Audio properties
     duration_seconds INTEGER NOT NULL,
+    content_hash TEXT,                                -- Hex SHA-256 of the audio file (duplicate detection)
+    fade_in_ms INTEGER,                               -- Crossfade lead-in (NULL = unknown)
+    fade_out_ms INTEGER,                              -- Crossfade lead-out (NULL = unknown)
+    file_missing_at DATETIME,                         -- Audio file last found missing (NULL = present)
 
     -- Status workflow: pending -> approved -> (played) -> expired
     status TEXT NOT NULL DEFAULT 'approved',
+    rollout_percent INTEGER NOT NULL DEFAULT 100,     -- Share of listeners (0-100) served a 'beta' track
 
     -- Timestamps
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -52,6 +72,7 @@ CREATE INDEX IF NOT EXISTS idx_tracks_mood ON tracks(mood);
 CREATE INDEX IF NOT EXISTS idx_tracks_status ON tracks(status);
 CREATE INDEX IF NOT EXISTS idx_tracks_mood_status ON tracks(mood, status);
 CREATE INDEX IF NOT EXISTS idx_tracks_intensity ON tracks(intensity);
+CREATE INDEX IF NOT EXISTS idx_tracks_content_hash ON tracks(content_hash);
 
 -- Runtime play data (separated from content data for safe reimports)
 CREATE TABLE IF NOT EXISTS play_stats (
@@ -61,8 +82,8 @@ CREATE TABLE IF NOT EXISTS play_stats (
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
--- Listen events: write-only engagement data (play/skip/complete).
--- Used as a signal log for future playlist tuning. Not queried at runtime.
+-- Listen events: engagement data (play/skip/complete).
+-- Used as a signal log for playlist tuning and admin session analytics.
 CREATE TABLE IF NOT EXISTS listen_events (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     track_id INTEGER NOT NULL REFERENCES tracks(id),
@@ -70,9 +91,75 @@ CREATE TABLE IF NOT EXISTS listen_events (
     event_type TEXT NOT NULL CHECK (event_type IN ('play', 'skip', 'complete')),
     listen_seconds INTEGER NOT NULL DEFAULT 0,
     playlist_position INTEGER,
+    session_id TEXT,                                  -- Per-tab random ID from the player (NULL for legacy clients)
+    platform TEXT,                                    -- ios, android, desktop, other (NULL for legacy clients)
+    app_version TEXT,                                 -- Player version when the client reports one
+    client_id TEXT,                                   -- Anonymous listener cookie ID (NULL without the cookie)
+    playlist_id TEXT,                                 -- Generated playlist the track was served in (NULL when not sent)
+    station TEXT,                                     -- Station the track was played on (NULL without stations)
+    reason TEXT,                                      -- Why a skip event skipped (NULL when not sent and for other events)
     created_at DATETIME NOT NULL DEFAULT (datetime('now'))
 );
 
 CREATE INDEX IF NOT EXISTS idx_listen_events_track ON listen_events(track_id, event_type);
 CREATE INDEX IF NOT EXISTS idx_listen_events_mood ON listen_events(mood, created_at);
 CREATE INDEX IF NOT EXISTS idx_listen_events_created ON listen_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_listen_events_session ON listen_events(session_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_listen_events_client ON listen_events(client_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_listen_events_playlist ON listen_events(playlist_id, playlist_position);
+CREATE INDEX IF NOT EXISTS idx_listen_events_station ON listen_events(station, created_at);
+
+-- Free-form tags that cut across moods ("rain", "piano", "lofi").
+-- Values are normalized by the application: lowercase [a-z0-9_-], max 32 chars.
+CREATE TABLE IF NOT EXISTS track_tags (
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (track_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_tags_tag ON track_tags(tag);
+
+-- Audit log of track status changes, written in the same transaction as the
+-- change. actor is the admin identity or 'system' for automated changes.
+CREATE TABLE IF NOT EXISTS track_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    old_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_status_history_track ON track_status_history(track_id, id);
+
+-- Last reported track and position per anonymous listener (cookie) and mood,
+-- for resuming playback. Overwritten by progress reports.
+CREATE TABLE IF NOT EXISTS listener_state (
+    listener_id TEXT NOT NULL,
+    mood TEXT NOT NULL,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    position_seconds INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (listener_id, mood)
+);
+
+CREATE INDEX IF NOT EXISTS idx_listener_state_updated ON listener_state(updated_at);
+
+-- Tracks an anonymous listener (cookie) hid; left out of their playlists.
+CREATE TABLE IF NOT EXISTS hidden_tracks (
+    listener_id TEXT NOT NULL,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (listener_id, track_id)
+);
+
+-- Per-mood theme color, icon, and description set through the admin API.
+-- Empty columns fall back to moods.display in config, then generated colors.
+CREATE TABLE IF NOT EXISTS mood_meta (
+    mood TEXT PRIMARY KEY,
+    color TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL
+);
@@ -28,6 +28,7 @@ class DriftFMPlayer {
     this.audioNext = document.getElementById('audio-next');
     this.playBtn = document.getElementById('play-btn');
     this.skipBtn = document.getElementById('skip-btn');
+    this.skipReasons = document.getElementById('skip-reasons');
     this.playIcon = document.getElementById('play-icon');
     this.pauseIcon = document.getElementById('pause-icon');
     this.trackName = document.getElementById('track-name');
@@ -80,6 +81,8 @@ class DriftFMPlayer {
     // Skip friction state (progressive soft friction)
     this.skipCount = 0; // Skips in current friction window
     this.skipResetTimeout = null; // Timer to reset skip count after engagement
+    this.skipPressTimeout = null; // Long-press timer that opens skip reasons
+    this.skipLongPressed = false; // Swallow the click that ends a long press
 
     // Track info cycling state
     this.metadataCycleIndex = 0;
@@ -96,7 +99,30 @@ class DriftFMPlayer {
   bindEvents() {
     this.playBtn.addEventListener('click', () => this.togglePlay());
     if (this.skipBtn) {
-      this.skipBtn.addEventListener('click', () => this.skipTrack());
+      this.skipBtn.addEventListener('click', () => {
+        if (this.skipLongPressed) {
+          this.skipLongPressed = false;
+          return;
+        }
+        this.skipTrack();
+      });
+      // Long press (or right click) asks why before skipping
+      this.skipBtn.addEventListener('pointerdown', () => this.startSkipPress());
+      for (const type of ['pointerup', 'pointerleave', 'pointercancel']) {
+        this.skipBtn.addEventListener(type, () => this.cancelSkipPress());
+      }
+      this.skipBtn.addEventListener('contextmenu', (e) => {
+        e.preventDefault();
+        this.showSkipReasons();
+      });
+    }
+    if (this.skipReasons) {
+      this.skipReasons.addEventListener('click', (e) => {
+        const item = e.target.closest('[data-reason]');
+        if (!item) return;
+        this.hideSkipReasons();
+        this.skipTrack(item.dataset.reason);
+      });
     }
 
     // Bind audio events (use bound handlers for proper removal on swap)
@@ -129,6 +155,13 @@ class DriftFMPlayer {
         return;
       }
 
+      // Escape closes the skip reasons menu first
+      if (e.code === 'Escape' && this.skipReasons && !this.skipReasons.hidden) {
+        this.hideSkipReasons();
+        this.skipBtn.focus();
+        return;
+      }
+
       // Escape closes about panel in any state
       if (e.code === 'Escape' && this.about.isOpen()) {
         this.about.close();
@@ -246,6 +279,7 @@ class DriftFMPlayer {
 
   // Close all overlay panels (for state transitions)
   closeAllPanels() {
+    this.hideSkipReasons();
     this.about.close();
     this.settings.close();
     this.lyrics.close(false); // Don't persist - state transition, not user action
@@ -376,6 +410,19 @@ class DriftFMPlayer {
     }
   }
 
+  applyMoodAccent(moodData) {
+    // A configured color overrides the per-mood accent from tokens.css, so
+    // moods added purely in config still get their own color
+    const style = document.body.style;
+    if (moodData && moodData.color) {
+      style.setProperty('--color-accent', moodData.color);
+      style.setProperty('--color-accent-dim', `color-mix(in srgb, ${moodData.color} 60%, black)`);
+    } else {
+      style.removeProperty('--color-accent');
+      style.removeProperty('--color-accent-dim');
+    }
+  }
+
   renderFallbackMoods() {
     this.moods = KNOWN_MOODS.map(m => ({ ...m, track_count: 0, total_minutes: 0 }));
     this.renderMoodSelector();
@@ -432,6 +479,7 @@ class DriftFMPlayer {
 
       // Update body data attribute and transition to playing state
       document.body.dataset.mood = mood;
+      this.applyMoodAccent(moodData);
       this.enterPlayingState();
 
       // Handle fetch failure with user feedback
@@ -497,6 +545,7 @@ class DriftFMPlayer {
     // Reset state
     document.body.dataset.state = 'idle';
     document.body.dataset.mood = '';
+    this.applyMoodAccent(null);
     this.playerBar.hidden = true;
     this.currentIndex = -1;
     this.trackName.textContent = 'Ready';
@@ -775,7 +824,7 @@ class DriftFMPlayer {
     }
   }
 
-  notifyListen(trackId, eventType, listenSeconds = 0, useBeacon = false) {
+  notifyListen(trackId, eventType, listenSeconds = 0, useBeacon = false, reason = '') {
     // Double-count guard: skip if already reported for this play
     if (this.playStartTime < 0 && eventType !== 'play') return;
 
@@ -785,6 +834,9 @@ class DriftFMPlayer {
       mood: this.currentMood,
       position: this.currentIndex,
     };
+    if (reason) {
+      data.reason = reason;
+    }
     reportListen(trackId, data, { beacon: useBeacon });
 
     // Mark as reported to prevent double-counting
@@ -882,14 +934,15 @@ class DriftFMPlayer {
     }, 1000);
   }
 
-  // Skip to next track (with progressive soft friction)
-  skipTrack() {
+  // Skip to next track (with progressive soft friction). reason, from the
+  // long-press menu, is one of dislike, wrong_mood, too_long, repeat, other.
+  skipTrack(reason = '') {
     if (this.playlist.length === 0) return;
 
     // Report skip event for current track
     if (this.currentTrack && this.playStartTime >= 0) {
       const listenSeconds = this.audioCurrent.currentTime - this.playStartTime;
-      this.notifyListen(this.currentTrack.id, 'skip', listenSeconds);
+      this.notifyListen(this.currentTrack.id, 'skip', listenSeconds, false, reason);
     }
 
     // Increment skip count and clear any pending reset
@@ -911,6 +964,37 @@ class DriftFMPlayer {
     this.playNext();
   }
 
+  // Skip reasons menu (long press on skip)
+
+  startSkipPress() {
+    this.cancelSkipPress();
+    this.skipPressTimeout = setTimeout(() => {
+      this.skipPressTimeout = null;
+      this.skipLongPressed = true;
+      this.showSkipReasons();
+    }, 500);
+  }
+
+  cancelSkipPress() {
+    if (this.skipPressTimeout) {
+      clearTimeout(this.skipPressTimeout);
+      this.skipPressTimeout = null;
+    }
+  }
+
+  showSkipReasons() {
+    if (!this.skipReasons || this.playlist.length === 0) return;
+    this.skipReasons.hidden = false;
+    this.skipBtn.setAttribute('aria-expanded', 'true');
+    this.skipReasons.querySelector('[data-reason]')?.focus();
+  }
+
+  hideSkipReasons() {
+    if (!this.skipReasons || this.skipReasons.hidden) return;
+    this.skipReasons.hidden = true;
+    this.skipBtn.setAttribute('aria-expanded', 'false');
+  }
+
   // Visual nudge when approaching skip limit
   showSkipNudge() {
     if (!this.skipBtn) return;
@@ -4,6 +4,13 @@
  * reportListen: sends listen events to /api/tracks/:id/play (SQLite)
  */
 
+/**
+ * Per-tab listening session ID. Random and never persisted, so it groups
+ * events into sessions without identifying the listener across visits.
+ */
+const sessionId = globalThis.crypto?.randomUUID?.()
+  ?? `${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 10)}`;
+
 /**
  * Report a listen event to the server.
  * @param {number} trackId
@@ -12,7 +19,7 @@
  */
 export function reportListen(trackId, data, options = {}) {
   const url = `/api/tracks/${trackId}/play`;
-  const payload = JSON.stringify(data);
+  const payload = JSON.stringify({ ...data, session_id: sessionId });
   try {
     if (options.beacon && navigator.sendBeacon) {
       navigator.sendBeacon(url, new globalThis.Blob([payload], { type: 'application/json' }));
@@ -142,10 +142,10 @@ export class LyricsManager {
 
   /**
    * Update lyrics display for a track
-   * @param {Object|null} track - Track object with lyrics property
+   * @param {Object|null} track - Track object with lyrics or has_lyrics property
    */
   updateDisplay(track) {
-    const hasLyrics = track && track.lyrics;
+    const hasLyrics = track && (track.lyrics || track.has_lyrics);
     const shouldShowButton = hasLyrics && this.getShowLyricsButton();
 
     // Show/hide lyrics button based on whether track has lyrics AND user preference
@@ -163,12 +163,15 @@ export class LyricsManager {
       this.content.classList.add('lyrics-panel__content--loading');
 
       // After fade out, update content and fade in
-      setTimeout(() => {
+      setTimeout(async () => {
         // Guard against stale update (user skipped to another track)
         if (this._updateSeq !== seq) return;
 
-        if (hasLyrics) {
-          this.content.textContent = track.lyrics;
+        const lyrics = hasLyrics ? await this._loadLyrics(track) : null;
+        if (this._updateSeq !== seq) return;
+
+        if (lyrics) {
+          this.content.textContent = lyrics;
           this.content.classList.remove('lyrics-panel__content--empty');
         } else {
           // Show rotating surrender-philosophy message
@@ -195,6 +198,30 @@ export class LyricsManager {
     }
   }
 
+  /**
+   * Full lyrics for a track. Playlists omit or shorten them, so they are
+   * fetched from the track endpoint once and kept on the track.
+   * @param {Object} track - Track object from a playlist
+   * @returns {Promise<string|null>}
+   */
+  async _loadLyrics(track) {
+    if (track.lyrics && !track.lyrics_truncated) {
+      return track.lyrics;
+    }
+    try {
+      const response = await fetch(`/api/tracks/${track.id}`);
+      if (response.ok) {
+        const detail = await response.json();
+        track.lyrics = detail.lyrics || null;
+        track.lyrics_truncated = false;
+      }
+    } catch (err) {
+      // Fall back to whatever the playlist carried
+      console.warn('Lyrics fetch failed:', err);
+    }
+    return track.lyrics || null;
+  }
+
   _getSurrenderMessage() {
     // Pick a random message, avoiding immediate repeat
     let index;